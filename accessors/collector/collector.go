@@ -1,6 +1,7 @@
 package collector
 
 import (
+	"crypto"
 	"errors"
 	"fmt"
 	"io"
@@ -18,6 +19,7 @@ import (
 	"www.velocidex.com/golang/velociraptor/uploads"
 	"www.velocidex.com/golang/velociraptor/utils"
 
+	"www.velocidex.com/golang/velociraptor/crypto/storage"
 	crypto_utils "www.velocidex.com/golang/velociraptor/crypto/utils"
 	vql_subsystem "www.velocidex.com/golang/velociraptor/vql"
 	"www.velocidex.com/golang/vfilter"
@@ -201,7 +203,11 @@ func ExtractPassword(
 			continue
 		}
 
-		if strings.ToLower(scheme) == "x509" {
+		switch strings.ToLower(scheme) {
+		case storage.RECIPIENTS_SCHEME:
+			return extractRecipientsPassword(scope, row)
+
+		case "x509":
 			ep, ok := row.GetString("EncryptedPass")
 			if !ok {
 				return "", errors.New(
@@ -233,6 +239,69 @@ func ExtractPassword(
 	return "", utils.NotFoundError
 }
 
+// Recover the session password from a container encrypted for
+// multiple recipients. Any private key given in the scope may be
+// used, as well as the server's own key when we are an admin.
+func extractRecipientsPassword(
+	scope vfilter.Scope, row *ordereddict.Dict) (string, error) {
+
+	recipients_any, _ := row.Get("Recipients")
+	serialized, err := json.Marshal(recipients_any)
+	if err != nil {
+		return "", err
+	}
+
+	recipients := []*storage.Recipient{}
+	err = json.Unmarshal(serialized, &recipients)
+	if err != nil {
+		return "", fmt.Errorf("Decoding recipients: %w", err)
+	}
+
+	var private_keys []crypto.PrivateKey
+
+	keys_any, pres := scope.Resolve(constants.COLLECTION_PRIVATE_KEYS)
+	if pres {
+		for _, pem := range pemStrings(keys_any) {
+			keys, err := storage.ParsePrivateKeys([]byte(pem))
+			if err != nil {
+				return "", fmt.Errorf("Parsing private keys: %w", err)
+			}
+			private_keys = append(private_keys, keys...)
+		}
+	}
+
+	if vql_subsystem.CheckAccess(scope, acls.SERVER_ADMIN) == nil {
+		key, err := crypto_utils.GetPrivateKeyFromScope(scope)
+		if err == nil {
+			private_keys = append(private_keys, key)
+		}
+	}
+
+	zip_pass, err := storage.UnwrapSessionKey(recipients, private_keys)
+	if err != nil {
+		return "", fmt.Errorf("Unable to extract zip password: %w", err)
+	}
+
+	return string(zip_pass), nil
+}
+
+// The private keys may be given as a string or a list of strings.
+func pemStrings(value vfilter.Any) []string {
+	switch t := value.(type) {
+	case string:
+		return []string{t}
+	case []string:
+		return t
+	case []vfilter.Any:
+		result := make([]string, 0, len(t))
+		for _, item := range t {
+			result = append(result, utils.ToString(item))
+		}
+		return result
+	}
+	return nil
+}
+
 // Try to set a password if it exists in metadata
 func (self *CollectorAccessor) maybeSetZipPassword(
 	full_path *accessors.OSPath) (*accessors.OSPath, error) {
@@ -0,0 +1,202 @@
+package actions
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Velocidex/ordereddict"
+	"www.velocidex.com/golang/velociraptor/accessors"
+	"www.velocidex.com/golang/velociraptor/responder"
+	"www.velocidex.com/golang/velociraptor/uploads"
+	"www.velocidex.com/golang/vfilter"
+	"www.velocidex.com/golang/vfilter/types"
+)
+
+// Implemented by the flow responder to account for the work a dry
+// run query would have done.
+type dryRunCharger interface {
+	ChargeDryRun(uploaded_files, expected_bytes, files_touched int64)
+}
+
+func chargeDryRun(responder responder.Responder,
+	uploaded_files, expected_bytes, files_touched int64) {
+	charger, ok := responder.(dryRunCharger)
+	if ok {
+		charger.ChargeDryRun(uploaded_files, expected_bytes, files_touched)
+	}
+}
+
+// An uploader which does not send anything to the server. It only
+// records the size of each file the query would have uploaded.
+type DryRunUploader struct {
+	responder responder.Responder
+}
+
+func NewDryRunUploader(responder responder.Responder) *DryRunUploader {
+	return &DryRunUploader{responder: responder}
+}
+
+func (self *DryRunUploader) Upload(
+	ctx context.Context,
+	scope vfilter.Scope,
+	filename *accessors.OSPath,
+	accessor string,
+	store_as_name *accessors.OSPath,
+	expected_size int64,
+	mtime time.Time,
+	atime time.Time,
+	ctime time.Time,
+	btime time.Time,
+	mode os.FileMode,
+	reader io.ReadSeeker) (*uploads.UploadResponse, error) {
+
+	if store_as_name == nil {
+		store_as_name = filename
+	}
+
+	// Avoid reading the file if we can help it - seeking to the end
+	// is enough to find its size.
+	size := expected_size
+	if size <= 0 {
+		end, err := reader.Seek(0, io.SeekEnd)
+		if err == nil {
+			size = end
+		}
+	}
+
+	chargeDryRun(self.responder, 1, size, 1)
+
+	return &uploads.UploadResponse{
+		Path:       store_as_name.String(),
+		Size:       uint64(size),
+		StoredName: store_as_name.String(),
+		Components: store_as_name.Components,
+		Accessor:   accessor,
+		ID:         self.responder.NextUploadId(),
+	}, nil
+}
+
+func getDryRunArg(ctx context.Context,
+	args *ordereddict.Dict, name string) vfilter.Any {
+	value, _ := args.Get(name)
+	lazy, ok := value.(types.LazyExpr)
+	if ok {
+		return lazy.Reduce(ctx)
+	}
+	return value
+}
+
+// Functions which change the endpoint or send data off it, with the
+// argument we log to show what they would have done.
+var dryRunFunctions = []struct {
+	name, arg string
+}{
+	{"write_file", "dest"},
+	{"rm", "filename"},
+	{"copy", "dest"},
+	{"upload_directory", "output"},
+	{"upload_s3", "file"},
+	{"upload_gcs", "file"},
+	{"upload_azure", "file"},
+	{"upload_sftp", "file"},
+	{"upload_smb", "file"},
+	{"upload_webdav", "file"},
+	{"gcs_pubsub_publish", "topic"},
+	{"mail", "to"},
+}
+
+// Plugins with side effects. These do not produce any rows in a dry
+// run.
+var dryRunPlugins = []struct {
+	name, arg string
+}{
+	{"execve", "argv"},
+	{"write_csv", "filename"},
+	{"write_jsonl", "filename"},
+	{"mail", "to"},
+	{"logscale_upload", "apibaseurl"},
+}
+
+// Replace plugins and functions with side effects on the endpoint
+// with stubs that only log what they would have done. Only calls
+// present in this build are replaced.
+func installDryRunStubs(scope vfilter.Scope, responder responder.Responder) {
+	for _, item := range dryRunPlugins {
+		_, pres := scope.GetPlugin(item.name)
+		if !pres {
+			continue
+		}
+
+		name, arg := item.name, item.arg
+		scope.AppendPlugins(
+			vfilter.GenericListPlugin{
+				PluginName: name,
+				Doc:        "Dry run stub for " + name + "()",
+				Function: func(
+					ctx context.Context, scope vfilter.Scope,
+					args *ordereddict.Dict) []vfilter.Row {
+					scope.Log("dry_run: Skipping %v(%v=%v)",
+						name, arg, getDryRunArg(ctx, args, arg))
+					return nil
+				},
+			})
+	}
+
+	for _, item := range dryRunFunctions {
+		_, pres := scope.GetFunction(item.name)
+		if !pres {
+			continue
+		}
+
+		name, arg := item.name, item.arg
+		scope.AppendFunctions(
+			vfilter.GenericFunction{
+				FunctionName: name,
+				Doc:          "Dry run stub for " + name + "()",
+				Function: func(
+					ctx context.Context, scope vfilter.Scope,
+					args *ordereddict.Dict) vfilter.Any {
+					value := getDryRunArg(ctx, args, arg)
+					scope.Log("dry_run: Skipping %v(%v=%v)", name, arg, value)
+					chargeDryRun(responder, 0, 0, 1)
+					return value
+				},
+			})
+	}
+
+	// http_client may still fetch data but must not send any.
+	delegate, pres := scope.GetPlugin("http_client")
+	if pres {
+		scope.AppendPlugins(dryRunHTTPClient{delegate: delegate})
+	}
+}
+
+// Only allow http_client() to make requests without side effects.
+type dryRunHTTPClient struct {
+	delegate vfilter.PluginGeneratorInterface
+}
+
+func (self dryRunHTTPClient) Call(ctx context.Context,
+	scope vfilter.Scope, args *ordereddict.Dict) <-chan vfilter.Row {
+	method, _ := getDryRunArg(ctx, args, "method").(string)
+	method = strings.ToUpper(method)
+	switch method {
+	case "", "GET", "HEAD":
+		return self.delegate.Call(ctx, scope, args)
+	}
+
+	output_chan := make(chan vfilter.Row)
+	close(output_chan)
+
+	scope.Log("dry_run: Skipping http_client(method=%v, url=%v)",
+		method, getDryRunArg(ctx, args, "url"))
+	return output_chan
+}
+
+func (self dryRunHTTPClient) Info(
+	scope vfilter.Scope, type_map *vfilter.TypeMap) *vfilter.PluginInfo {
+	return self.delegate.Info(scope, type_map)
+}
@@ -0,0 +1,196 @@
+package actions
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/Velocidex/ordereddict"
+	actions_proto "www.velocidex.com/golang/velociraptor/actions/proto"
+	config_proto "www.velocidex.com/golang/velociraptor/config/proto"
+	"www.velocidex.com/golang/velociraptor/json"
+	"www.velocidex.com/golang/velociraptor/responder"
+	"www.velocidex.com/golang/velociraptor/services/writeback"
+)
+
+const (
+	// Rows which disappeared since the base flow are sent as a
+	// tombstone containing only the key column and this field.
+	INCREMENTAL_DELETED_COLUMN = "_Deleted"
+)
+
+// Implemented by the flow responder to flag the query results as a
+// delta against the incremental base.
+type incrementalMarker interface {
+	AddIncrementalSource(name string)
+}
+
+// The state we keep on the endpoint for each keyed query. We only
+// store a short hash of each row so the state remains small.
+type incrementalState struct {
+	FlowId string            `json:"FlowId"`
+	Rows   map[string]string `json:"Rows"`
+}
+
+// Tracks the rows of a keyed query for incremental collections. When
+// the server asks us to compare against the same flow we last
+// recorded, only new or changed rows are sent followed by tombstones
+// for the rows that went away. Otherwise all rows are sent and the
+// state is rebuilt.
+type IncrementalTracker struct {
+	path       string
+	key_column string
+	flow_id    string
+
+	// Set when the previous state matches the server's base flow.
+	diffing  bool
+	previous map[string]string
+	current  map[string]string
+}
+
+func NewIncrementalTracker(
+	config_obj *config_proto.Config,
+	arg *actions_proto.VQLCollectorArgs,
+	query *actions_proto.VQLRequest,
+	flow_id string) (*IncrementalTracker, error) {
+
+	if !arg.Incremental || arg.DryRun ||
+		query.KeyColumn == "" || query.Name == "" {
+		return nil, nil
+	}
+
+	location, err := writeback.WritebackLocation(config_obj)
+	if err != nil {
+		return nil, err
+	}
+
+	// The state is specific to the query and its parameters.
+	hash := sha256.New()
+	hash.Write([]byte(query.Name))
+	hash.Write([]byte{0})
+	hash.Write([]byte(query.KeyColumn))
+	for _, env := range arg.Env {
+		hash.Write([]byte{0})
+		hash.Write([]byte(env.Key))
+		hash.Write([]byte{0})
+		hash.Write([]byte(env.Value))
+	}
+
+	result := &IncrementalTracker{
+		path: filepath.Join(filepath.Dir(location), "incremental",
+			hex.EncodeToString(hash.Sum(nil))+".json"),
+		key_column: query.KeyColumn,
+		flow_id:    flow_id,
+		current:    make(map[string]string),
+	}
+
+	data, err := os.ReadFile(result.path)
+	if err != nil {
+		// No previous state - send everything.
+		return result, nil
+	}
+
+	state := &incrementalState{}
+	err = json.Unmarshal(data, state)
+	if err == nil && arg.IncrementalBase != "" &&
+		state.FlowId == arg.IncrementalBase {
+		result.diffing = true
+		result.previous = state.Rows
+	}
+
+	return result, nil
+}
+
+// Returns true if the tracker only sends changed rows.
+func (self *IncrementalTracker) Diffing() bool {
+	return self.diffing
+}
+
+// Record the row and decide if it needs to be sent.
+func (self *IncrementalTracker) Filter(
+	row *ordereddict.Dict, serialized []byte) bool {
+	key_value, pres := row.Get(self.key_column)
+	if !pres {
+		return true
+	}
+
+	key, err := json.MarshalString(key_value)
+	if err != nil {
+		return true
+	}
+
+	row_hash := sha256.Sum256(serialized)
+	hash := hex.EncodeToString(row_hash[:8])
+	self.current[key] = hash
+
+	if !self.diffing {
+		return true
+	}
+
+	previous, pres := self.previous[key]
+	return !pres || previous != hash
+}
+
+// Tombstones for all the keys we saw in the base flow but not in
+// this one.
+func (self *IncrementalTracker) Tombstones() []*ordereddict.Dict {
+	if !self.diffing {
+		return nil
+	}
+
+	var keys []string
+	for key := range self.previous {
+		_, pres := self.current[key]
+		if !pres {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	result := make([]*ordereddict.Dict, 0, len(keys))
+	for _, key := range keys {
+		var key_value interface{}
+		err := json.Unmarshal([]byte(key), &key_value)
+		if err != nil {
+			continue
+		}
+		result = append(result, ordereddict.NewDict().
+			Set(self.key_column, key_value).
+			Set(INCREMENTAL_DELETED_COLUMN, true))
+	}
+
+	return result
+}
+
+// Store the rows of this flow as the base for the next collection.
+func (self *IncrementalTracker) Commit() error {
+	err := os.MkdirAll(filepath.Dir(self.path), 0700)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(&incrementalState{
+		FlowId: self.flow_id,
+		Rows:   self.current,
+	})
+	if err != nil {
+		return err
+	}
+
+	tmp_path := self.path + ".tmp"
+	err = os.WriteFile(tmp_path, data, 0600)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp_path, self.path)
+}
+
+func markIncremental(responder responder.Responder, name string) {
+	marker, ok := responder.(incrementalMarker)
+	if ok {
+		marker.AddIncrementalSource(name)
+	}
+}
@@ -27,7 +27,10 @@ type VQLRequest struct {
 	// The obfuscated name of the artifact this query came from.
 	Name string `protobuf:"bytes,2,opt,name=Name,proto3" json:"Name,omitempty"`
 	// The compiled VQL query to evaluate on the endpoint.
-	VQL           string `protobuf:"bytes,1,opt,name=VQL,proto3" json:"VQL,omitempty"`
+	VQL string `protobuf:"bytes,1,opt,name=VQL,proto3" json:"VQL,omitempty"`
+	// If set, rows are identified by this column for incremental
+	// collections.
+	KeyColumn     string `protobuf:"bytes,3,opt,name=key_column,json=keyColumn,proto3" json:"key_column,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -76,6 +79,13 @@ func (x *VQLRequest) GetVQL() string {
 	return ""
 }
 
+func (x *VQLRequest) GetKeyColumn() string {
+	if x != nil {
+		return x.KeyColumn
+	}
+	return ""
+}
+
 type VQLEnv struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
@@ -176,9 +186,17 @@ type VQLCollectorArgs struct {
 	Heartbeat uint64   `protobuf:"varint,27,opt,name=heartbeat,proto3" json:"heartbeat,omitempty"`
 	Tools     []string `protobuf:"bytes,26,rep,name=tools,proto3" json:"tools,omitempty"`
 	// Used only for API based calls
-	OrgId         string `protobuf:"bytes,35,opt,name=org_id,json=orgId,proto3" json:"org_id,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	OrgId string `protobuf:"bytes,35,opt,name=org_id,json=orgId,proto3" json:"org_id,omitempty"`
+	// If set the client runs the query without uploading files or
+	// calling side effecting plugins, and only reports how much it
+	// would have collected.
+	DryRun bool `protobuf:"varint,39,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
+	// If set the client remembers the rows of keyed queries and only
+	// sends rows which changed since the incremental_base flow.
+	Incremental     bool   `protobuf:"varint,40,opt,name=incremental,proto3" json:"incremental,omitempty"`
+	IncrementalBase string `protobuf:"bytes,41,opt,name=incremental_base,json=incrementalBase,proto3" json:"incremental_base,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
 func (x *VQLCollectorArgs) Reset() {
@@ -351,6 +369,27 @@ func (x *VQLCollectorArgs) GetOrgId() string {
 	return ""
 }
 
+func (x *VQLCollectorArgs) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+func (x *VQLCollectorArgs) GetIncremental() bool {
+	if x != nil {
+		return x.Incremental
+	}
+	return false
+}
+
+func (x *VQLCollectorArgs) GetIncrementalBase() string {
+	if x != nil {
+		return x.IncrementalBase
+	}
+	return ""
+}
+
 type VQLTypeMap struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Column        string                 `protobuf:"bytes,1,opt,name=column,proto3" json:"column,omitempty"`
@@ -916,15 +955,17 @@ var File_vql_proto protoreflect.FileDescriptor
 
 const file_vql_proto_rawDesc = "" +
 	"\n" +
-	"\tvql.proto\x12\x05proto\x1a\x14proto/semantic.proto\x1a\x1eartifacts/proto/artifact.proto\"2\n" +
+	"\tvql.proto\x12\x05proto\x1a\x14proto/semantic.proto\x1a\x1eartifacts/proto/artifact.proto\"Q\n" +
 	"\n" +
 	"VQLRequest\x12\x12\n" +
 	"\x04Name\x18\x02 \x01(\tR\x04Name\x12\x10\n" +
-	"\x03VQL\x18\x01 \x01(\tR\x03VQL\"J\n" +
+	"\x03VQL\x18\x01 \x01(\tR\x03VQL\x12\x1d\n" +
+	"\n" +
+	"key_column\x18\x03 \x01(\tR\tkeyColumn\"J\n" +
 	"\x06VQLEnv\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
 	"\x05value\x18\x02 \x01(\tR\x05value\x12\x18\n" +
-	"\acomment\x18\x03 \x01(\tR\acomment\"\x9e\v\n" +
+	"\acomment\x18\x03 \x01(\tR\acomment\"\x84\f\n" +
 	"\x10VQLCollectorArgs\x12\x19\n" +
 	"\bquery_id\x18  \x01(\x03R\aqueryId\x12#\n" +
 	"\rtotal_queries\x18! \x01(\x03R\ftotalQueries\x12\x16\n" +
@@ -946,7 +987,10 @@ const file_vql_proto_rawDesc = "" +
 	"\atimeout\x18\x19 \x01(\x04B*\xe2\xfc\xe3\xc4\x01$\x12\"Maximum time for the query to run.R\atimeout\x12\x1c\n" +
 	"\theartbeat\x18\x1b \x01(\x04R\theartbeat\x12K\n" +
 	"\x05tools\x18\x1a \x03(\tB5\xe2\xfc\xe3\xc4\x01/\x12-A list of tools we will need to run this VQL.R\x05tools\x12\x15\n" +
-	"\x06org_id\x18# \x01(\tR\x05orgId\"8\n" +
+	"\x06org_id\x18# \x01(\tR\x05orgId\x12\x17\n" +
+	"\adry_run\x18' \x01(\bR\x06dryRun\x12 \n" +
+	"\vincremental\x18( \x01(\bR\vincremental\x12)\n" +
+	"\x10incremental_base\x18) \x01(\tR\x0fincrementalBase\"8\n" +
 	"\n" +
 	"VQLTypeMap\x12\x16\n" +
 	"\x06column\x18\x01 \x01(\tR\x06column\x12\x12\n" +
@@ -35,6 +35,7 @@ import (
 	"www.velocidex.com/golang/velociraptor/constants"
 	crypto_proto "www.velocidex.com/golang/velociraptor/crypto/proto"
 	"www.velocidex.com/golang/velociraptor/executor/throttler"
+	"www.velocidex.com/golang/velociraptor/json"
 	"www.velocidex.com/golang/velociraptor/logging"
 	"www.velocidex.com/golang/velociraptor/responder"
 	"www.velocidex.com/golang/velociraptor/services"
@@ -42,6 +43,7 @@ import (
 	"www.velocidex.com/golang/velociraptor/utils"
 	vql_subsystem "www.velocidex.com/golang/velociraptor/vql"
 	"www.velocidex.com/golang/velociraptor/vql/acl_managers"
+	vql_profiler "www.velocidex.com/golang/velociraptor/vql/profiler"
 	"www.velocidex.com/golang/vfilter"
 	"www.velocidex.com/golang/vfilter/types"
 )
@@ -183,6 +185,11 @@ func (self VQLClientAction) StartQuery(
 		Logger:     logger,
 	}
 
+	// In dry run mode nothing is uploaded - we only measure.
+	if arg.DryRun {
+		builder.Uploader = NewDryRunUploader(responder)
+	}
+
 	for _, env_spec := range arg.Env {
 		builder.Env.Set(env_spec.Key, env_spec.Value)
 	}
@@ -201,6 +208,18 @@ func (self VQLClientAction) StartQuery(
 	// Add some additional context for debugging
 	scope.SetContext(constants.SCOPE_QUERY_NAME, name)
 
+	// The profile is sent to the server when all the queries are
+	// done.
+	profiler := vql_profiler.MaybeInstall(scope, utils.GetQueryName(arg.Query))
+	if profiler != nil {
+		defer sendProfile(ctx, responder, profiler)
+	}
+
+	if arg.DryRun {
+		installDryRunStubs(scope, responder)
+		scope.Log("INFO:Running %v in dry run mode: uploads and side effects are disabled.", name)
+	}
+
 	if runtime.GOARCH == "386" &&
 		os.Getenv("PROCESSOR_ARCHITEW6432") == "AMD64" {
 		scope.Log("You are running a 32 bit built binary on Windows x64. " +
@@ -267,8 +286,22 @@ func (self VQLClientAction) StartQuery(
 			return
 		}
 
-		result_chan := EncodeIntoResponsePackets(
-			vql, sub_ctx, scope,
+		// The server refers to resumed flows by their parent id.
+		flow_id, _ := utils.SplitSessionIdToParentAndChild(
+			responder.FlowContext().SessionId())
+		tracker, err := NewIncrementalTracker(config_obj, arg, query, flow_id)
+		if err != nil {
+			scope.Log("%v: Unable to collect incrementally: %v", name, err)
+		}
+
+		if tracker != nil && tracker.Diffing() {
+			markIncremental(responder, query.Name)
+			scope.Log("INFO:%v: Only sending rows changed since %v",
+				name, arg.IncrementalBase)
+		}
+
+		result_chan := encodeIntoResponsePackets(
+			vql, sub_ctx, scope, tracker,
 			int(max_row), int(max_wait), int(max_row_buffer_size))
 	run_query:
 		for {
@@ -303,6 +336,16 @@ func (self VQLClientAction) StartQuery(
 			case result, ok := <-result_chan:
 				if !ok {
 					query_log.Close()
+
+					// Only remember the rows if the query ran to
+					// completion.
+					if tracker != nil && sub_ctx.Err() == nil {
+						err := tracker.Commit()
+						if err != nil {
+							scope.Log("%v: Unable to store incremental state: %v",
+								name, err)
+						}
+					}
 					break run_query
 				}
 
@@ -358,6 +401,17 @@ func (self VQLClientAction) StartQuery(
 	}
 }
 
+func sendProfile(ctx context.Context,
+	responder responder.Responder, profiler *vql_profiler.Profiler) {
+	defer profiler.Close()
+
+	serialized, err := json.MarshalJsonl(profiler.Rows())
+	if err != nil || len(serialized) == 0 {
+		return
+	}
+	responder.Log(ctx, logging.PROFILE, string(serialized))
+}
+
 func CheckPreconditions(
 	ctx context.Context,
 	scope vfilter.Scope,
@@ -390,6 +444,20 @@ func EncodeIntoResponsePackets(
 	max_wait int,
 	// How large do we allow the payload to get
 	max_row_buffer_size int) <-chan *vfilter.VFilterJsonResult {
+	return encodeIntoResponsePackets(vql, ctx, scope, nil,
+		maxrows, max_wait, max_row_buffer_size)
+}
+
+// When a tracker is given only rows it selects are sent, followed by
+// its tombstones once the query is done.
+func encodeIntoResponsePackets(
+	vql *vfilter.VQL,
+	ctx context.Context,
+	scope types.Scope,
+	tracker *IncrementalTracker,
+	maxrows int,
+	max_wait int,
+	max_row_buffer_size int) <-chan *vfilter.VFilterJsonResult {
 	result_chan := make(chan *vfilter.VFilterJsonResult)
 
 	encoder := vql_subsystem.MarshalJsonl(scope)
@@ -398,7 +466,7 @@ func EncodeIntoResponsePackets(
 		defer close(result_chan)
 
 		part := 0
-		row_chan := vql.Eval(ctx, scope)
+		row_chan := vql_profiler.Eval(ctx, scope, vql)
 		buffer := bytes.Buffer{}
 		var columns []string
 		var total_rows int
@@ -444,6 +512,21 @@ func EncodeIntoResponsePackets(
 
 			case row, ok := <-row_chan:
 				if !ok {
+					if tracker == nil {
+						return
+					}
+
+					for _, tombstone := range tracker.Tombstones() {
+						if len(columns) == 0 {
+							columns = tombstone.Keys()
+						}
+						s, err := encoder([]types.Row{tombstone})
+						if err != nil {
+							continue
+						}
+						total_rows++
+						buffer.Write(s)
+					}
 					return
 				}
 
@@ -462,6 +545,10 @@ func EncodeIntoResponsePackets(
 					scope.Log("Unable to serialize: %v", err)
 					return
 				}
+
+				if tracker != nil && !tracker.Filter(value, s) {
+					continue
+				}
 				// Accumulate the jsonl into the buffer
 				total_rows++
 				buffer.Write(s)
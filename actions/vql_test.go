@@ -1,22 +1,31 @@
 package actions_test
 
 import (
+	"fmt"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/Velocidex/ordereddict"
 	"github.com/stretchr/testify/suite"
 	"www.velocidex.com/golang/velociraptor/actions"
 	actions_proto "www.velocidex.com/golang/velociraptor/actions/proto"
 	artifacts_proto "www.velocidex.com/golang/velociraptor/artifacts/proto"
 	crypto_proto "www.velocidex.com/golang/velociraptor/crypto/proto"
 	"www.velocidex.com/golang/velociraptor/file_store/test_utils"
+	"www.velocidex.com/golang/velociraptor/json"
 	"www.velocidex.com/golang/velociraptor/logging"
 	"www.velocidex.com/golang/velociraptor/responder"
 	"www.velocidex.com/golang/velociraptor/vtesting"
 	"www.velocidex.com/golang/velociraptor/vtesting/assert"
 
+	// For upload()
+	_ "www.velocidex.com/golang/velociraptor/accessors/data"
+
 	// For execve and query
+	_ "www.velocidex.com/golang/velociraptor/vql/filesystem"
+	_ "www.velocidex.com/golang/velociraptor/vql/networking"
 	_ "www.velocidex.com/golang/velociraptor/vql/protocols"
 	_ "www.velocidex.com/golang/velociraptor/vql/tools"
 	_ "www.velocidex.com/golang/velociraptor/vql/tools/shell"
@@ -119,6 +128,66 @@ func (self *ClientVQLTestSuite) TestDependentArtifacts() {
 	})
 }
 
+// Profiled queries send their profile to the server when done.
+func (self *ClientVQLTestSuite) TestProfile() {
+	resp := responder.TestResponderWithFlowId(self.ConfigObj, "TestProfile")
+
+	actions.VQLClientAction{}.StartQuery(self.ConfigObj, self.Sm.Ctx, resp,
+		&actions_proto.VQLCollectorArgs{
+			Env: []*actions_proto.VQLEnv{
+				{Key: "VQL_PROFILE", Value: "Y"},
+			},
+			Query: []*actions_proto.VQLRequest{
+				{
+					VQL: "LET X = SELECT * FROM range(end=5)",
+				},
+				{
+					Name: "Query",
+					VQL:  "SELECT * FROM Artifact.Custom.Profile.A()",
+				},
+			},
+			Artifacts: []*artifacts_proto.Artifact{
+				{
+					Name: "Custom.Profile.A",
+					Sources: []*artifacts_proto.ArtifactSource{
+						{
+							Query: "SELECT format(format='%v', args=_value) AS X FROM range(end=3)",
+						},
+					},
+				},
+			},
+		})
+
+	var profile []string
+	vtesting.WaitUntil(5*time.Second, self.T(), func() bool {
+		profile = nil
+		for _, item := range resp.Drain.Messages() {
+			if item.LogMessage != nil &&
+				item.LogMessage.Level == logging.PROFILE {
+				for _, line := range strings.Split(
+					strings.TrimSpace(item.LogMessage.Jsonl), "\n") {
+					row := ordereddict.NewDict()
+					assert.NoError(self.T(), json.Unmarshal([]byte(line), row))
+					stack, _ := row.GetString("Stack")
+					calls, _ := row.GetInt64("Calls")
+					rows, _ := row.GetInt64("Rows")
+					profile = append(profile, fmt.Sprintf(
+						"%v: %v calls, %v rows", stack, calls, rows))
+				}
+			}
+		}
+		return len(profile) > 0
+	})
+
+	assert.Equal(self.T(), []string{
+		"Query;LET X: 1 calls, 0 rows",
+		"Query;SELECT: 1 calls, 3 rows",
+		"Query;SELECT;Artifact.Custom.Profile.A(): 1 calls, 3 rows",
+		"Query;SELECT;Artifact.Custom.Profile.A();format(): 3 calls, 0 rows",
+		"Query;SELECT;Artifact.Custom.Profile.A();range(): 1 calls, 3 rows",
+	}, profile)
+}
+
 func (self *ClientVQLTestSuite) TestMaxRows() {
 	resp := responder.TestResponderWithFlowId(self.ConfigObj, "TestMaxRows")
 
@@ -212,6 +281,124 @@ func (self *ClientVQLTestSuite) TestMaxWait() {
 		}))
 }
 
+func (self *ClientVQLTestSuite) TestDryRun() {
+	resp := responder.TestResponderWithFlowId(self.ConfigObj, "TestDryRun")
+	defer resp.Close()
+
+	actions.VQLClientAction{}.StartQuery(self.ConfigObj, self.Sm.Ctx, resp,
+		&actions_proto.VQLCollectorArgs{
+			DryRun: true,
+			Query: []*actions_proto.VQLRequest{
+				{
+					Name: "Query",
+					VQL: `SELECT upload(accessor='data', file='Hello world',
+                                     name='hello.txt') AS Upload,
+                              rm(filename='/tmp/should_not_exist') AS Rm,
+                              copy(filename='Hello', accessor='data',
+                                   dest='/tmp/should_not_exist') AS Copy,
+                              upload_s3(file='Hello', accessor='data',
+                                        bucket='bucket') AS S3
+                       FROM scope()`,
+				},
+				{
+					Name: "HTTP",
+					VQL: `SELECT * FROM http_client(
+                               url='http://127.0.0.1:1/', method='POST')`,
+				},
+				{
+					Name: "Execve",
+					VQL:  "SELECT * FROM execve(argv='ls')",
+				},
+			},
+		})
+
+	var responses []*crypto_proto.VeloMessage
+	vtesting.WaitUntil(5*time.Second, self.T(), func() bool {
+		responses = resp.Drain.Messages()
+		return strings.Contains(getLogs(responses), "Skipping execve")
+	})
+
+	// Nothing is actually uploaded.
+	for _, r := range responses {
+		assert.Nil(self.T(), r.FileBuffer)
+	}
+
+	// The stubs log what they would have done instead of running.
+	logs := getLogs(responses)
+	assert.Contains(self.T(), logs, "dry_run: Skipping rm(filename=/tmp/should_not_exist)")
+	assert.Contains(self.T(), logs, "dry_run: Skipping execve(argv=ls)")
+	assert.Contains(self.T(), logs, "dry_run: Skipping copy(dest=/tmp/should_not_exist)")
+	assert.Contains(self.T(), logs, "dry_run: Skipping upload_s3(file=Hello)")
+	assert.Contains(self.T(), logs, "dry_run: Skipping http_client(method=POST, url=http://127.0.0.1:1/)")
+	assert.NotContains(self.T(), logs, "Not allowed to execve")
+
+	// But the stats estimate the cost.
+	status := resp.GetStatus()
+	assert.Equal(self.T(), int64(1), status.ResultRows)
+	assert.Equal(self.T(), int64(1), status.UploadedFiles)
+	assert.Equal(self.T(), int64(0), status.UploadedBytes)
+	assert.Equal(self.T(), int64(len("Hello world")), status.ExpectedUploadedBytes)
+	assert.Equal(self.T(), int64(4), status.FilesTouched)
+}
+
+func (self *ClientVQLTestSuite) runIncremental(
+	flow_id, base, vql string) (string, *crypto_proto.VeloStatus) {
+	resp := responder.TestResponderWithFlowId(self.ConfigObj, flow_id)
+	defer resp.Close()
+
+	actions.VQLClientAction{}.StartQuery(self.ConfigObj, self.Sm.Ctx, resp,
+		&actions_proto.VQLCollectorArgs{
+			Incremental:     true,
+			IncrementalBase: base,
+			Query: []*actions_proto.VQLRequest{
+				{
+					Name:      "Query",
+					VQL:       vql,
+					KeyColumn: "Key",
+				},
+			},
+		})
+
+	var responses []*crypto_proto.VeloMessage
+	vtesting.WaitUntil(5*time.Second, self.T(), func() bool {
+		responses = resp.Drain.Messages()
+		return strings.Contains(getLogs(responses), "is done after")
+	})
+
+	rows := ""
+	for _, r := range responses {
+		if r.VQLResponse != nil {
+			rows += r.VQLResponse.JSONLResponse
+		}
+	}
+	return rows, resp.GetStatus()
+}
+
+func (self *ClientVQLTestSuite) TestIncremental() {
+	self.ConfigObj.Client.WritebackLinux = filepath.Join(
+		self.T().TempDir(), "writeback.yaml")
+
+	// No base yet - everything is sent.
+	rows, status := self.runIncremental("F.1", "",
+		"SELECT _value AS Key, 1 AS Value FROM range(end=3)")
+	assert.Equal(self.T(), 3, strings.Count(rows, "\n"))
+	assert.Equal(self.T(), 0, len(status.IncrementalSources))
+
+	// Key 0 is unchanged, key 1 changed and key 2 went away.
+	rows, status = self.runIncremental("F.2", "F.1", `
+SELECT _value AS Key, if(condition=_value = 1, then=2, else=1) AS Value
+FROM range(end=2)`)
+	assert.Equal(self.T(),
+		`{"Key":1,"Value":2}`+"\n"+`{"Key":2,"_Deleted":true}`+"\n", rows)
+	assert.Equal(self.T(), []string{"Query"}, status.IncrementalSources)
+
+	// The client state is now at F.2 so a stale base sends all rows.
+	rows, status = self.runIncremental("F.3", "F.1",
+		"SELECT _value AS Key, 1 AS Value FROM range(end=2)")
+	assert.Equal(self.T(), 2, strings.Count(rows, "\n"))
+	assert.Equal(self.T(), 0, len(status.IncrementalSources))
+}
+
 func TestClientVQL(t *testing.T) {
 	suite.Run(t, &ClientVQLTestSuite{})
 }
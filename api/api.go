@@ -1227,12 +1227,20 @@ func startAPIServer(
 
 	// Only accept certs signed by the Velociraptor internal CA
 	tls_config.ClientAuth = tls.RequireAndVerifyClientCert
+
+	// API tokens are presented instead of a client certificate.
+	if config_obj.API.Tokens != nil && config_obj.API.Tokens.AllowGrpc &&
+		!config_obj.API.Tokens.Disabled {
+		tls_config.ClientAuth = tls.VerifyClientCertIfGiven
+	}
 	tls_config.Certificates = []tls.Certificate{cert}
 	tls_config.ClientCAs = CA_Pool
 
 	creds := credentials.NewTLS(tls_config)
 
-	grpcServer := grpc.NewServer(grpc.Creds(creds))
+	grpcServer := grpc.NewServer(grpc.Creds(creds),
+		grpc.UnaryInterceptor(rateLimitUnaryInterceptor(config_obj, CA_Pool)),
+		grpc.StreamInterceptor(rateLimitStreamInterceptor(config_obj, CA_Pool)))
 	api_proto.RegisterAPIServer(
 		grpcServer,
 		&ApiServer{
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"context"
+
+	"google.golang.org/protobuf/types/known/emptypb"
+	api_proto "www.velocidex.com/golang/velociraptor/api/proto"
+	"www.velocidex.com/golang/velociraptor/services"
+)
+
+func (self *ApiServer) CreateApiToken(
+	ctx context.Context,
+	in *api_proto.CreateApiTokenRequest) (*api_proto.CreateApiTokenResponse, error) {
+
+	defer Instrument("CreateApiToken")()
+
+	users_manager := services.GetUserManager()
+	user_record, org_config_obj, err := users_manager.GetUserFromContext(ctx)
+	if err != nil {
+		return nil, Status(self.verbose, err)
+	}
+	principal := user_record.Name
+
+	// Tokens are restricted to the current org by default.
+	if len(in.Orgs) == 0 {
+		in.Orgs = []string{org_config_obj.OrgId}
+	}
+
+	result, err := users_manager.CreateAPIToken(ctx, principal, in)
+	return result, Status(self.verbose, err)
+}
+
+func (self *ApiServer) ListApiTokens(
+	ctx context.Context,
+	in *api_proto.ApiTokenRequest) (*api_proto.ApiTokens, error) {
+
+	defer Instrument("ListApiTokens")()
+
+	users_manager := services.GetUserManager()
+	user_record, _, err := users_manager.GetUserFromContext(ctx)
+	if err != nil {
+		return nil, Status(self.verbose, err)
+	}
+	principal := user_record.Name
+
+	username := in.Username
+	if username == "" {
+		username = principal
+	}
+
+	tokens, err := users_manager.ListAPITokens(ctx, principal, username)
+	if err != nil {
+		return nil, Status(self.verbose, err)
+	}
+
+	return &api_proto.ApiTokens{Items: tokens}, nil
+}
+
+func (self *ApiServer) RevokeApiToken(
+	ctx context.Context,
+	in *api_proto.ApiTokenRequest) (*emptypb.Empty, error) {
+
+	defer Instrument("RevokeApiToken")()
+
+	users_manager := services.GetUserManager()
+	user_record, _, err := users_manager.GetUserFromContext(ctx)
+	if err != nil {
+		return nil, Status(self.verbose, err)
+	}
+	principal := user_record.Name
+
+	username := in.Username
+	if username == "" {
+		username = principal
+	}
+
+	if in.TokenId == "" {
+		return nil, InvalidStatus("TokenId must be specified")
+	}
+
+	err = users_manager.RevokeAPIToken(ctx, principal, username, in.TokenId)
+	if err != nil {
+		return nil, Status(self.verbose, err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
@@ -0,0 +1,60 @@
+package authenticators
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	api_proto "www.velocidex.com/golang/velociraptor/api/proto"
+	api_utils "www.velocidex.com/golang/velociraptor/api/utils"
+	config_proto "www.velocidex.com/golang/velociraptor/config/proto"
+	"www.velocidex.com/golang/velociraptor/constants"
+	"www.velocidex.com/golang/velociraptor/json"
+	"www.velocidex.com/golang/velociraptor/services"
+)
+
+// Requests carrying an API token bearer are authenticated by the
+// token instead of the GUI authenticator. Browsers never send the
+// Authorization header on their own so these requests do not need
+// CSRF protection. All other requests go to the fallback handler.
+func APITokenHandler(config_obj *config_proto.Config,
+	parent http.Handler, fallback http.Handler) http.Handler {
+
+	if config_obj.API != nil && config_obj.API.Tokens != nil &&
+		config_obj.API.Tokens.Disabled {
+		return api_utils.HandlerFunc(fallback, fallback.ServeHTTP)
+	}
+
+	logger := GetLoggingHandler(config_obj)(parent)
+
+	// Most requests go to the fallback so report it as the parent.
+	return api_utils.HandlerFunc(fallback,
+		func(w http.ResponseWriter, r *http.Request) {
+			bearer, ok := strings.CutPrefix(
+				r.Header.Get("Authorization"), "Bearer ")
+			if !ok || !strings.HasPrefix(bearer, "vrt_") {
+				fallback.ServeHTTP(w, r)
+				return
+			}
+
+			users_manager := services.GetUserManager()
+			token, err := users_manager.VerifyAPIToken(r.Context(),
+				bearer, r.RemoteAddr, r.Method+" "+r.URL.Path)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+
+			// The token acts as its own principal so the ACL
+			// manager can restrict it to the token's scope.
+			user_info := &api_proto.VelociraptorUser{
+				Name: services.APITokenPrincipal(token.Username, token.TokenId),
+			}
+
+			serialized, _ := json.Marshal(user_info)
+			ctx := context.WithValue(
+				r.Context(), constants.GRPC_USER_CONTEXT, string(serialized))
+
+			logger.ServeHTTP(w, r.WithContext(ctx))
+		})
+}
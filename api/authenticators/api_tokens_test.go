@@ -0,0 +1,84 @@
+package authenticators
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	api_proto "www.velocidex.com/golang/velociraptor/api/proto"
+	"www.velocidex.com/golang/velociraptor/file_store/test_utils"
+	"www.velocidex.com/golang/velociraptor/services"
+	"www.velocidex.com/golang/velociraptor/services/users"
+	"www.velocidex.com/golang/velociraptor/vtesting/assert"
+)
+
+type APITokenTestSuite struct {
+	test_utils.TestSuite
+}
+
+func (self *APITokenTestSuite) TestAPITokenHandler() {
+	t := self.T()
+
+	user_record, err := users.NewUserRecord(self.ConfigObj, "alice")
+	assert.NoError(t, err)
+
+	users_manager := services.GetUserManager()
+	err = users_manager.SetUser(self.Ctx, user_record)
+	assert.NoError(t, err)
+
+	err = services.GrantRoles(self.ConfigObj, "alice", []string{"reader"})
+	assert.NoError(t, err)
+
+	response, err := users_manager.CreateAPIToken(self.Ctx, "alice",
+		&api_proto.CreateApiTokenRequest{
+			Name:        "Test",
+			Permissions: []string{"READ_RESULTS"},
+			Orgs:        []string{"root"},
+		})
+	assert.NoError(t, err)
+
+	// Record the principal the handler was called with.
+	principal := ""
+	parent := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user_record, err := users_manager.GetUserFromHTTPContext(r.Context())
+		assert.NoError(t, err)
+		principal = user_record.Name
+	})
+
+	fallback := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "fallback", http.StatusTeapot)
+	})
+
+	handler := APITokenHandler(self.ConfigObj, parent, fallback)
+
+	request := func(authorization string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/v1/GetUserUITraits", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	// Requests without a token go to the regular authenticator.
+	w := request("")
+	assert.Equal(t, http.StatusTeapot, w.Code)
+
+	w = request("Basic YWxpY2U6cGFzc3dvcmQ=")
+	assert.Equal(t, http.StatusTeapot, w.Code)
+
+	w = request("Bearer " + response.Secret + "A")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, "", principal)
+
+	w = request("Bearer " + response.Secret)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, services.APITokenPrincipal(
+		"alice", response.Token.TokenId), principal)
+}
+
+func TestAPITokenHandler(t *testing.T) {
+	suite.Run(t, &APITokenTestSuite{})
+}
@@ -196,9 +196,20 @@ func init() {
 		ctx *HTTPClientContext,
 		config_obj *config_proto.Config,
 		auth_config *config_proto.Authenticator) (Authenticator, error) {
-		return &BasicAuthenticator{
+		result := &BasicAuthenticator{
 			config_obj: config_obj,
-		}, nil
+		}
+		if auth_config.Mfa != nil {
+			result.mfa = NewMFAManager(config_obj, auth_config)
+		}
+		return result, nil
+	})
+
+	RegisterAuthenticator("ldap", func(
+		ctx *HTTPClientContext,
+		config_obj *config_proto.Config,
+		auth_config *config_proto.Authenticator) (Authenticator, error) {
+		return NewLdapAuthenticator(config_obj, auth_config)
 	})
 
 	RegisterAuthenticator("certs", func(
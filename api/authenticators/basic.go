@@ -20,10 +20,16 @@ import (
 // Implement basic authentication.
 type BasicAuthenticator struct {
 	config_obj *config_proto.Config
+
+	// Optional second factor.
+	mfa *MFAManager
 }
 
-// Basic auth does not need any special handlers.
+// Basic auth only needs handlers for the second factor.
 func (self *BasicAuthenticator) AddHandlers(mux *api_utils.ServeMux) error {
+	if self.mfa != nil {
+		self.mfa.AddHandlers(mux, self.verifyPassword)
+	}
 	return nil
 }
 
@@ -49,6 +55,10 @@ func (self *BasicAuthenticator) AddLogoff(mux *api_utils.ServeMux) error {
 						return
 					}
 
+					if self.mfa != nil {
+						self.mfa.ClearSession(w)
+					}
+
 					w.Header().Set("WWW-Authenticate", `Basic realm="Restricted"`)
 					http.Error(w, "authorization failed", http.StatusUnauthorized)
 				})))
@@ -78,49 +88,31 @@ func (self *BasicAuthenticator) AuthenticateUserHandler(
 	return api_utils.HandlerFunc(parent,
 		func(w http.ResponseWriter, r *http.Request) {
 			w.Header().Set("X-CSRF-Token", csrf.Token(r))
-			w.Header().Set("WWW-Authenticate", `Basic realm="Restricted"`)
 
-			username, password, ok := r.BasicAuth()
+			user_record, ok := self.verifyPassword(w, r)
 			if !ok {
-				http.Error(w, "Not authorized", http.StatusUnauthorized)
 				return
 			}
+			username := user_record.Name
 
-			// Get the full user record with hashes so we can
-			// verify it below.
-			users_manager := services.GetUserManager()
-			user_record, err := users_manager.GetUserWithHashes(r.Context(),
-				username, username)
-			if err != nil {
-				err := services.LogAudit(r.Context(),
-					self.config_obj, username, "Unknown username",
-					ordereddict.NewDict().
-						Set("remote", r.RemoteAddr).
-						Set("status", http.StatusUnauthorized))
+			// Users with a second factor must also present it.
+			if self.mfa != nil {
+				err := self.mfa.Check(r, user_record)
 				if err != nil {
-					logger := logging.GetLogger(self.config_obj, &logging.FrontendComponent)
-					logger.Error("Unknown username %v %v", username, r.RemoteAddr)
+					self.mfa.Reject(w, r, err)
+					return
 				}
-				http.Error(w, "authorization failed", http.StatusUnauthorized)
-				return
 			}
 
-			ok, err = users_manager.VerifyPassword(r.Context(),
-				user_record.Name, user_record.Name, password)
-			if !ok || err != nil {
-				err := services.LogAudit(r.Context(),
-					self.config_obj, user_record.Name, "Invalid password",
-					ordereddict.NewDict().
-						Set("remote", r.RemoteAddr).
-						Set("status", http.StatusUnauthorized))
-
-				// If we cant emit an audit log, log to regular logging.
-				if err != nil {
-					logger := logging.GetLogger(self.config_obj, &logging.FrontendComponent)
-					logger.Error("Invalid Password %v %v", user_record.Name, r.RemoteAddr)
-				}
+			// Each browser has its own second factor session.
+			mfa_session := ""
+			cookie, err := r.Cookie(mfaCookieName)
+			if err == nil && self.mfa != nil {
+				mfa_session = cookie.Value
+			}
 
-				http.Error(w, "authorization failed", http.StatusUnauthorized)
+			if !checkCredentialSession(self.config_obj, w, r, username,
+				r.Header.Get("Authorization"), mfa_session) {
 				return
 			}
 
@@ -158,6 +150,7 @@ func (self *BasicAuthenticator) AuthenticateUserHandler(
 			ctx := context.WithValue(
 				r.Context(), constants.GRPC_USER_CONTEXT, string(serialized))
 
+			users_manager := services.GetUserManager()
 			_ = users_manager.SetUserStats(r.Context(), self.config_obj, username,
 				&api_proto.UserStats{
 					LastActiveTime: utils.GetTime().Now().Unix(),
@@ -169,3 +162,56 @@ func (self *BasicAuthenticator) AuthenticateUserHandler(
 			logger.ServeHTTP(w, r.WithContext(ctx))
 		}).AddChild("GetLoggingHandler")
 }
+
+// Check the password presented with basic auth. On failure the error
+// is written to the response.
+func (self *BasicAuthenticator) verifyPassword(
+	w http.ResponseWriter, r *http.Request) (*api_proto.VelociraptorUser, bool) {
+	w.Header().Set("WWW-Authenticate", `Basic realm="Restricted"`)
+
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		http.Error(w, "Not authorized", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	// Get the full user record with hashes so we can
+	// verify it below.
+	users_manager := services.GetUserManager()
+	user_record, err := users_manager.GetUserWithHashes(r.Context(),
+		username, username)
+	if err != nil {
+		err := services.LogAudit(r.Context(),
+			self.config_obj, username, "Unknown username",
+			ordereddict.NewDict().
+				Set("remote", r.RemoteAddr).
+				Set("status", http.StatusUnauthorized))
+		if err != nil {
+			logger := logging.GetLogger(self.config_obj, &logging.FrontendComponent)
+			logger.Error("Unknown username %v %v", username, r.RemoteAddr)
+		}
+		http.Error(w, "authorization failed", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	ok, err = users_manager.VerifyPassword(r.Context(),
+		user_record.Name, user_record.Name, password)
+	if !ok || err != nil {
+		err := services.LogAudit(r.Context(),
+			self.config_obj, user_record.Name, "Invalid password",
+			ordereddict.NewDict().
+				Set("remote", r.RemoteAddr).
+				Set("status", http.StatusUnauthorized))
+
+		// If we cant emit an audit log, log to regular logging.
+		if err != nil {
+			logger := logging.GetLogger(self.config_obj, &logging.FrontendComponent)
+			logger.Error("Invalid Password %v %v", user_record.Name, r.RemoteAddr)
+		}
+
+		http.Error(w, "authorization failed", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	return user_record, true
+}
@@ -217,6 +217,11 @@ func (self *CertAuthenticator) AuthenticateUserHandler(
 				}
 			}
 
+			if !checkCredentialSession(self.config_obj, w, r, user_record.Name,
+				string(r.TLS.PeerCertificates[0].Raw)) {
+				return
+			}
+
 			// Does the user have access to the specified org?
 			err = CheckOrgAccess(self.config_obj, r, user_record, permission)
 			if err != nil {
@@ -0,0 +1,665 @@
+package authenticators
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Velocidex/ordereddict"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/gorilla/csrf"
+	"www.velocidex.com/golang/velociraptor/acls"
+	acl_proto "www.velocidex.com/golang/velociraptor/acls/proto"
+	api_proto "www.velocidex.com/golang/velociraptor/api/proto"
+	api_utils "www.velocidex.com/golang/velociraptor/api/utils"
+	config_proto "www.velocidex.com/golang/velociraptor/config/proto"
+	"www.velocidex.com/golang/velociraptor/constants"
+	"www.velocidex.com/golang/velociraptor/json"
+	"www.velocidex.com/golang/velociraptor/logging"
+	"www.velocidex.com/golang/velociraptor/services"
+	"www.velocidex.com/golang/velociraptor/services/users"
+	utils "www.velocidex.com/golang/velociraptor/utils"
+)
+
+const (
+	ldapDefaultUserFilter   = "(sAMAccountName=%s)"
+	ldapDefaultGroupFilter  = "(member=%s)"
+	ldapDefaultNestingDepth = 10
+	ldapDefaultCacheExpiry  = 300
+	ldapDefaultTimeout      = 10
+)
+
+// A group the user is a member of, directly or through nesting.
+type LdapGroup struct {
+	DN string `json:"dn"`
+	CN string `json:"cn"`
+}
+
+// The result of a successful LDAP login.
+type LdapUser struct {
+	// The Velociraptor username
+	Name   string      `json:"name"`
+	DN     string      `json:"dn"`
+	Groups []LdapGroup `json:"groups"`
+}
+
+type ldapLogin struct {
+	username string
+	expires  time.Time
+}
+
+// Implement LDAP authentication. The user presents their credentials
+// using basic auth and we bind to the LDAP server as the user to
+// verify them. The user's groups are then mapped to Velociraptor
+// roles.
+type LdapAuthenticator struct {
+	config_obj  *config_proto.Config
+	ldap_config *config_proto.LDAPConfig
+	tls_config  *tls.Config
+
+	// The browser sends the credentials with every request so we
+	// cache successful logins to avoid querying the server each
+	// time. The cache is keyed by a salted hash of the credentials.
+	mu    sync.Mutex
+	salt  []byte
+	cache map[string]*ldapLogin
+}
+
+func NewLdapAuthenticator(
+	config_obj *config_proto.Config,
+	auth_config *config_proto.Authenticator) (*LdapAuthenticator, error) {
+
+	ldap_config := auth_config.Ldap
+	if ldap_config == nil || ldap_config.Url == "" {
+		return nil, errors.New("LdapAuthenticator: ldap.url must be set")
+	}
+
+	if ldap_config.BindDn == "" && ldap_config.UserDnTemplate == "" {
+		return nil, errors.New(
+			"LdapAuthenticator: One of ldap.bind_dn or ldap.user_dn_template must be set")
+	}
+
+	tls_config := &tls.Config{
+		InsecureSkipVerify: ldap_config.InsecureSkipVerify,
+	}
+
+	if ldap_config.RootCa != "" {
+		tls_config.RootCAs = x509.NewCertPool()
+		if !tls_config.RootCAs.AppendCertsFromPEM([]byte(ldap_config.RootCa)) {
+			return nil, errors.New(
+				"LdapAuthenticator: Unable to parse ldap.root_ca")
+		}
+	}
+
+	salt := make([]byte, 16)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LdapAuthenticator{
+		config_obj:  config_obj,
+		ldap_config: ldap_config,
+		tls_config:  tls_config,
+		salt:        salt,
+		cache:       make(map[string]*ldapLogin),
+	}, nil
+}
+
+// LDAP auth does not need any special handlers.
+func (self *LdapAuthenticator) AddHandlers(mux *api_utils.ServeMux) error {
+	return nil
+}
+
+// Logging off works the same way as for basic auth.
+func (self *LdapAuthenticator) AddLogoff(mux *api_utils.ServeMux) error {
+	basic := &BasicAuthenticator{config_obj: self.config_obj}
+	return basic.AddLogoff(mux)
+}
+
+func (self *LdapAuthenticator) IsPasswordLess() bool {
+	return false
+}
+
+func (self *LdapAuthenticator) RequireClientCerts() bool {
+	return false
+}
+
+func (self *LdapAuthenticator) AuthRedirectTemplate() string {
+	return ""
+}
+
+func (self *LdapAuthenticator) AuthenticateUserHandler(
+	parent http.Handler,
+	permission acls.ACL_PERMISSION,
+) http.Handler {
+
+	logger := GetLoggingHandler(self.config_obj)(parent)
+
+	return api_utils.HandlerFunc(parent,
+		func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-CSRF-Token", csrf.Token(r))
+			w.Header().Set("WWW-Authenticate", `Basic realm="Restricted"`)
+
+			login, password, ok := r.BasicAuth()
+			if !ok {
+				http.Error(w, "Not authorized", http.StatusUnauthorized)
+				return
+			}
+
+			username, err := self.Login(r.Context(), login, password)
+			if err != nil {
+				err1 := services.LogAudit(r.Context(),
+					self.config_obj, login, "LDAP login failed",
+					ordereddict.NewDict().
+						Set("err", err.Error()).
+						Set("remote", r.RemoteAddr).
+						Set("status", http.StatusUnauthorized))
+				if err1 != nil {
+					logger := logging.GetLogger(self.config_obj, &logging.FrontendComponent)
+					logger.Error("LDAP login failed %v %v: %v", login, r.RemoteAddr, err)
+				}
+				http.Error(w, "authorization failed", http.StatusUnauthorized)
+				return
+			}
+
+			users_manager := services.GetUserManager()
+			user_record, err := users_manager.GetUser(r.Context(), username, username)
+			if err != nil {
+				err := services.LogAudit(r.Context(),
+					self.config_obj, username, "Unknown username",
+					ordereddict.NewDict().
+						Set("remote", r.RemoteAddr).
+						Set("status", http.StatusUnauthorized))
+				if err != nil {
+					logger := logging.GetLogger(self.config_obj, &logging.FrontendComponent)
+					logger.Error("Unknown username %v %v", username, r.RemoteAddr)
+				}
+				http.Error(w, "authorization failed", http.StatusUnauthorized)
+				return
+			}
+
+			if !checkCredentialSession(self.config_obj, w, r, user_record.Name,
+				r.Header.Get("Authorization")) {
+				return
+			}
+
+			// Does the user have access to the specified org?
+			err = CheckOrgAccess(self.config_obj, r, user_record, permission)
+			if err != nil {
+				err1 := services.LogAudit(r.Context(),
+					self.config_obj, user_record.Name, "User Unauthorized for Org",
+					ordereddict.NewDict().
+						Set("err", err.Error()).
+						Set("remote", r.RemoteAddr).
+						Set("status", http.StatusUnauthorized))
+				if err1 != nil {
+					logger := logging.GetLogger(self.config_obj, &logging.FrontendComponent)
+					logger.Error("CheckOrgAccess LogAudit: User Unauthorized for Org %v %v",
+						user_record.Name, r.RemoteAddr)
+				}
+
+				// Return status forbidden because we don't want the user
+				// to reauthenticate
+				http.Error(w, err.Error(), http.StatusForbidden)
+				return
+			}
+
+			user_info := &api_proto.VelociraptorUser{
+				Name: user_record.Name,
+			}
+
+			// Must use json encoding because grpc can not handle
+			// binary data in metadata.
+			serialized, _ := json.Marshal(user_info)
+			ctx := context.WithValue(
+				r.Context(), constants.GRPC_USER_CONTEXT, string(serialized))
+
+			_ = users_manager.SetUserStats(r.Context(), self.config_obj,
+				user_record.Name, &api_proto.UserStats{
+					LastActiveTime: utils.GetTime().Now().Unix(),
+					LastIpAddress:  r.RemoteAddr,
+				})
+
+			// Need to call logging after auth so it can access
+			// the USER value in the context.
+			logger.ServeHTTP(w, r.WithContext(ctx))
+		}).AddChild("GetLoggingHandler")
+}
+
+// Verify the credentials and sync the user's roles. Returns the
+// Velociraptor username.
+func (self *LdapAuthenticator) Login(
+	ctx context.Context, login, password string) (string, error) {
+
+	key := self.cacheKey(login, password)
+	now := utils.GetTime().Now()
+
+	self.mu.Lock()
+	cached, pres := self.cache[key]
+	if pres && now.Before(cached.expires) {
+		self.mu.Unlock()
+		return cached.username, nil
+	}
+	delete(self.cache, key)
+	self.mu.Unlock()
+
+	user, err := self.Authenticate(login, password)
+	if err != nil {
+		return "", err
+	}
+
+	err = self.SetRolesForUser(ctx, self.config_obj, user)
+	if err != nil {
+		return "", err
+	}
+
+	expiry := self.ldap_config.CacheExpirySec
+	if expiry == 0 {
+		expiry = ldapDefaultCacheExpiry
+	}
+
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	// Expire stale logins so the cache does not grow unbounded.
+	for k, v := range self.cache {
+		if now.After(v.expires) {
+			delete(self.cache, k)
+		}
+	}
+
+	self.cache[key] = &ldapLogin{
+		username: user.Name,
+		expires:  now.Add(time.Duration(expiry) * time.Second),
+	}
+
+	return user.Name, nil
+}
+
+func (self *LdapAuthenticator) cacheKey(login, password string) string {
+	h := sha256.New()
+	h.Write(self.salt)
+	h.Write([]byte(login))
+	h.Write([]byte{0})
+	h.Write([]byte(password))
+	return string(h.Sum(nil))
+}
+
+func (self *LdapAuthenticator) timeout() time.Duration {
+	timeout := self.ldap_config.TimeoutSec
+	if timeout == 0 {
+		timeout = ldapDefaultTimeout
+	}
+	return time.Duration(timeout) * time.Second
+}
+
+func (self *LdapAuthenticator) dial() (*ldap.Conn, error) {
+	timeout := self.timeout()
+
+	conn, err := ldap.DialURL(self.ldap_config.Url,
+		ldap.DialWithDialer(&net.Dialer{Timeout: timeout}),
+		ldap.DialWithTLSConfig(self.tls_config))
+	if err != nil {
+		return nil, err
+	}
+
+	conn.SetTimeout(timeout)
+
+	if self.ldap_config.StartTls {
+		err = conn.StartTLS(self.tls_config)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	return conn, nil
+}
+
+// Bind to the server as the user and resolve their groups.
+func (self *LdapAuthenticator) Authenticate(
+	login, password string) (*LdapUser, error) {
+
+	// An empty password results in an unauthenticated bind which
+	// always succeeds.
+	if login == "" || password == "" {
+		return nil, errors.New("LdapAuthenticator: Empty username or password")
+	}
+
+	conn, err := self.dial()
+	if err != nil {
+		return nil, fmt.Errorf("LdapAuthenticator: %w", err)
+	}
+	defer conn.Close()
+
+	var entry *ldap.Entry
+	var user_dn string
+
+	if self.ldap_config.BindDn != "" {
+		// Find the user's DN using the service account.
+		err = conn.Bind(self.ldap_config.BindDn, self.ldap_config.BindPassword)
+		if err != nil {
+			return nil, fmt.Errorf("LdapAuthenticator: Service account bind: %w", err)
+		}
+
+		entry, err = self.searchUser(conn, login)
+		if err != nil {
+			return nil, err
+		}
+		user_dn = entry.DN
+
+		err = conn.Bind(user_dn, password)
+		if err != nil {
+			return nil, fmt.Errorf("LdapAuthenticator: %w", err)
+		}
+
+	} else {
+		user_dn = fmt.Sprintf(self.ldap_config.UserDnTemplate,
+			ldap.EscapeDN(login))
+		err = conn.Bind(user_dn, password)
+		if err != nil {
+			return nil, fmt.Errorf("LdapAuthenticator: %w", err)
+		}
+
+		// The bind DN may not be the real DN (e.g. an AD UPN bind)
+		// so look up the user entry when we can.
+		if self.ldap_config.BaseDn != "" {
+			entry, err = self.searchUser(conn, login)
+			if err != nil {
+				return nil, err
+			}
+			user_dn = entry.DN
+		}
+	}
+
+	result := &LdapUser{
+		Name: login,
+		DN:   user_dn,
+	}
+
+	if entry != nil && self.ldap_config.UsernameAttribute != "" {
+		name := entry.GetAttributeValue(self.ldap_config.UsernameAttribute)
+		if name == "" {
+			return nil, fmt.Errorf(
+				"LdapAuthenticator: User %v has no %v attribute",
+				user_dn, self.ldap_config.UsernameAttribute)
+		}
+		result.Name = name
+	}
+
+	// The directory may contain names we can not represent (e.g. with
+	// a # which would be confused with an API token principal).
+	err = users.ValidateUsername(self.config_obj, result.Name)
+	if err != nil {
+		return nil, fmt.Errorf("LdapAuthenticator: %w", err)
+	}
+
+	if self.ldap_config.GroupBaseDn != "" {
+		result.Groups, err = self.resolveGroups(conn, user_dn)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+func (self *LdapAuthenticator) searchUser(
+	conn *ldap.Conn, login string) (*ldap.Entry, error) {
+	user_filter := self.ldap_config.UserFilter
+	if user_filter == "" {
+		user_filter = ldapDefaultUserFilter
+	}
+
+	attributes := []string{"dn"}
+	if self.ldap_config.UsernameAttribute != "" {
+		attributes = append(attributes, self.ldap_config.UsernameAttribute)
+	}
+
+	res, err := conn.Search(ldap.NewSearchRequest(
+		self.ldap_config.BaseDn,
+		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 2,
+		int(self.timeout().Seconds()), false,
+		fmt.Sprintf(user_filter, ldap.EscapeFilter(login)),
+		attributes, nil))
+	if err != nil {
+		return nil, fmt.Errorf("LdapAuthenticator: User search: %w", err)
+	}
+
+	switch len(res.Entries) {
+	case 0:
+		return nil, fmt.Errorf("LdapAuthenticator: User %v not found", login)
+	case 1:
+		return res.Entries[0], nil
+	default:
+		return nil, fmt.Errorf("LdapAuthenticator: User %v is ambiguous", login)
+	}
+}
+
+// Find all the groups the DN is a member of, following nested groups
+// breadth first.
+func (self *LdapAuthenticator) resolveGroups(
+	conn *ldap.Conn, user_dn string) ([]LdapGroup, error) {
+	group_filter := self.ldap_config.GroupFilter
+	if group_filter == "" {
+		group_filter = ldapDefaultGroupFilter
+	}
+
+	max_depth := self.ldap_config.MaxNestingDepth
+	if max_depth == 0 {
+		max_depth = ldapDefaultNestingDepth
+	}
+
+	var result []LdapGroup
+	seen := map[string]bool{normalizeDN(user_dn): true}
+	members := []string{user_dn}
+
+	for depth := uint64(0); depth < max_depth && len(members) > 0; depth++ {
+		var next []string
+
+		for _, member := range members {
+			res, err := conn.Search(ldap.NewSearchRequest(
+				self.ldap_config.GroupBaseDn,
+				ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0,
+				int(self.timeout().Seconds()), false,
+				fmt.Sprintf(group_filter, ldap.EscapeFilter(member)),
+				[]string{"cn"}, nil))
+			if err != nil {
+				return nil, fmt.Errorf("LdapAuthenticator: Group search: %w", err)
+			}
+
+			for _, entry := range res.Entries {
+				key := normalizeDN(entry.DN)
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+
+				result = append(result, LdapGroup{
+					DN: entry.DN,
+					CN: entry.GetAttributeValue("cn"),
+				})
+				next = append(next, entry.DN)
+			}
+		}
+		members = next
+	}
+
+	return result, nil
+}
+
+// DNs compare case insensitively and ignoring whitespace between
+// components.
+func normalizeDN(dn string) string {
+	parsed, err := ldap.ParseDN(dn)
+	if err == nil {
+		dn = parsed.String()
+	}
+	return strings.ToLower(dn)
+}
+
+// Find the role map entries that apply to the group.
+func (self *LdapAuthenticator) aclsForGroup(
+	group LdapGroup) []*config_proto.LDAPGroupACL {
+	var result []*config_proto.LDAPGroupACL
+
+	dn := normalizeDN(group.DN)
+	cn := strings.ToLower(group.CN)
+
+	for name, acl_spec := range self.ldap_config.RoleMap {
+		key := normalizeDN(name)
+		if key == dn || (cn != "" && key == cn) {
+			result = append(result, acl_spec)
+		}
+	}
+	return result
+}
+
+func (self *LdapAuthenticator) shouldUpdateACLs(
+	new_acl, existing_acls *acl_proto.ApiClientACL,
+) (*acl_proto.ApiClientACL, bool) {
+
+	// When OverrideAcls is specified we just replace the
+	// existing_acls with the new_acl if they are different.
+	if self.ldap_config.OverrideAcls {
+		return new_acl, !acls.ACLEqual(new_acl, existing_acls)
+	}
+
+	// Merge the old ACL with the new ACL
+	new_acl = acls.MergeACL(existing_acls, new_acl)
+	return new_acl, !acls.ACLEqual(new_acl, existing_acls)
+}
+
+// Sync the user's roles in each org with their LDAP groups.
+func (self *LdapAuthenticator) SetRolesForUser(
+	ctx context.Context,
+	config_obj *config_proto.Config,
+	user *LdapUser) error {
+
+	// Do nothing if automatic roles are not configured.
+	if len(self.ldap_config.RoleMap) == 0 {
+		return nil
+	}
+
+	org_manager, err := services.GetOrgManager()
+	if err != nil {
+		return err
+	}
+
+	// Build the ACL for each org from the user's groups.
+	new_acls := make(map[string]*acl_proto.ApiClientACL)
+	granted := false
+	for _, org := range org_manager.ListOrgs() {
+		new_acl := &acl_proto.ApiClientACL{}
+		new_acls[org.Id] = new_acl
+
+		for _, group := range user.Groups {
+			for _, acl_spec := range self.aclsForGroup(group) {
+				if len(acl_spec.Orgs) > 0 &&
+					!utils.InString(acl_spec.Orgs, org.Id) {
+					continue
+				}
+
+				for _, role := range acl_spec.Roles {
+					if !utils.InString(new_acl.Roles, role) {
+						new_acl.Roles = append(new_acl.Roles, role)
+					}
+				}
+
+				err := acls.SetTokenPermission(new_acl, acl_spec.Permissions...)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		if len(new_acl.Roles) > 0 || len(acls.DescribePermissions(new_acl)) > 0 {
+			granted = true
+		}
+	}
+
+	user_manager := services.GetUserManager()
+	logger := logging.GetLogger(config_obj, &logging.GUIComponent)
+
+	// First check the user exist at all.
+	_, err = user_manager.GetUser(ctx, user.Name, user.Name)
+	if utils.IsNotFound(err) {
+		// Users without any mapped groups are not created.
+		if !granted {
+			return nil
+		}
+
+		err = services.LogAudit(ctx, config_obj, user.Name,
+			"Create User From LDAP Groups",
+			ordereddict.NewDict().Set("User", user))
+		if err != nil {
+			return err
+		}
+
+		err = user_manager.SetUser(ctx, &api_proto.VelociraptorUser{
+			Name: user.Name,
+		})
+		if err != nil {
+			return err
+		}
+
+		// Some other error occurred - reject.
+	} else if err != nil {
+		return err
+	}
+
+	for _, org := range org_manager.ListOrgs() {
+		org_config_obj, err := org_manager.GetOrgConfig(org.Id)
+		if err != nil {
+			continue
+		}
+
+		new_acl, pres := new_acls[org.Id]
+		if !pres {
+			continue
+		}
+
+		// Get the user's ACL policy in that org
+		existing_acls, err := services.GetPolicy(org_config_obj, user.Name)
+		if err != nil {
+			// Nothing to grant and nothing to remove.
+			if len(new_acl.Roles) == 0 &&
+				len(acls.DescribePermissions(new_acl)) == 0 {
+				continue
+			}
+			existing_acls = &acl_proto.ApiClientACL{}
+		}
+
+		new_acl, should_update := self.shouldUpdateACLs(new_acl, existing_acls)
+		if should_update {
+			err = services.LogAudit(ctx, config_obj, user.Name,
+				"Grant User Role From LDAP Group",
+				ordereddict.NewDict().
+					Set("ACL", new_acl).
+					Set("OrgId", org.Id).
+					Set("User", user))
+			if err != nil {
+				continue
+			}
+
+			logger.Info("Granting acl %v to User %v in org %v",
+				json.MustMarshalString(new_acl), user.Name, org.Id)
+			err = services.SetPolicy(org_config_obj, user.Name, new_acl)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
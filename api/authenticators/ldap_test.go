@@ -0,0 +1,484 @@
+package authenticators
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/stretchr/testify/suite"
+	"www.velocidex.com/golang/velociraptor/acls"
+	config_proto "www.velocidex.com/golang/velociraptor/config/proto"
+	"www.velocidex.com/golang/velociraptor/file_store/test_utils"
+	"www.velocidex.com/golang/velociraptor/services"
+	"www.velocidex.com/golang/velociraptor/vtesting/assert"
+)
+
+type testLdapEntry struct {
+	dn       string
+	password string
+	attrs    map[string][]string
+}
+
+// A minimal in-process LDAP server supporting simple binds and
+// searches with equality, presence and boolean filters.
+type testLdapServer struct {
+	mu       sync.Mutex
+	listener net.Listener
+	entries  []*testLdapEntry
+}
+
+func newTestLdapServer(t *testing.T, entries []*testLdapEntry) *testLdapServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	self := &testLdapServer{
+		listener: listener,
+		entries:  entries,
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go self.serve(conn)
+		}
+	}()
+
+	return self
+}
+
+func (self *testLdapServer) URL() string {
+	return "ldap://" + self.listener.Addr().String()
+}
+
+func (self *testLdapServer) Close() {
+	self.listener.Close()
+}
+
+func (self *testLdapServer) SetAttribute(dn, attr string, values ...string) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	for _, e := range self.entries {
+		if normalizeDN(e.dn) == normalizeDN(dn) {
+			e.attrs[attr] = values
+		}
+	}
+}
+
+func (self *testLdapServer) serve(conn net.Conn) {
+	defer conn.Close()
+
+	bound := false
+	for {
+		packet, err := ber.ReadPacket(conn)
+		if err != nil || len(packet.Children) < 2 {
+			return
+		}
+
+		id := packet.Children[0].Value
+		op := packet.Children[1]
+
+		switch op.Tag {
+		case ldap.ApplicationBindRequest:
+			name, _ := op.Children[1].Value.(string)
+			password := op.Children[2].Data.String()
+
+			code := ldap.LDAPResultInvalidCredentials
+			if self.checkPassword(name, password) {
+				code = ldap.LDAPResultSuccess
+				bound = true
+			}
+			self.send(conn, id, ldap.ApplicationBindResponse, code)
+
+		case ldap.ApplicationSearchRequest:
+			if bound {
+				base, _ := op.Children[0].Value.(string)
+				for _, entry := range self.search(base, op.Children[6]) {
+					self.sendEntry(conn, id, entry)
+				}
+			}
+			self.send(conn, id, ldap.ApplicationSearchResultDone,
+				ldap.LDAPResultSuccess)
+
+		default:
+			return
+		}
+	}
+}
+
+func (self *testLdapServer) checkPassword(dn, password string) bool {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	for _, e := range self.entries {
+		if normalizeDN(e.dn) == normalizeDN(dn) {
+			return e.password != "" && e.password == password
+		}
+	}
+	return false
+}
+
+func (self *testLdapServer) search(
+	base string, filter *ber.Packet) []*testLdapEntry {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	var result []*testLdapEntry
+	for _, e := range self.entries {
+		if strings.HasSuffix(normalizeDN(e.dn), normalizeDN(base)) &&
+			self.matches(e, filter) {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+func (self *testLdapServer) matches(e *testLdapEntry, filter *ber.Packet) bool {
+	switch filter.Tag {
+	case ldap.FilterAnd:
+		for _, child := range filter.Children {
+			if !self.matches(e, child) {
+				return false
+			}
+		}
+		return true
+
+	case ldap.FilterOr:
+		for _, child := range filter.Children {
+			if self.matches(e, child) {
+				return true
+			}
+		}
+		return false
+
+	case ldap.FilterNot:
+		return !self.matches(e, filter.Children[0])
+
+	case ldap.FilterPresent:
+		_, pres := e.attrs[filter.Data.String()]
+		return pres
+
+	case ldap.FilterEqualityMatch:
+		attr, _ := filter.Children[0].Value.(string)
+		value, _ := filter.Children[1].Value.(string)
+		for _, v := range e.attrs[attr] {
+			if normalizeDN(v) == normalizeDN(value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (self *testLdapServer) send(
+	conn net.Conn, id interface{}, tag ber.Tag, code int) {
+	response := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "")
+	response.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive,
+		ber.TagEnumerated, int64(code), ""))
+	response.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive,
+		ber.TagOctetString, "", ""))
+	response.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive,
+		ber.TagOctetString, "", ""))
+	self.write(conn, id, response)
+}
+
+func (self *testLdapServer) sendEntry(
+	conn net.Conn, id interface{}, entry *testLdapEntry) {
+	response := ber.Encode(ber.ClassApplication, ber.TypeConstructed,
+		ldap.ApplicationSearchResultEntry, nil, "")
+	response.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive,
+		ber.TagOctetString, entry.dn, ""))
+
+	attributes := ber.NewSequence("")
+	for k, values := range entry.attrs {
+		attr := ber.NewSequence("")
+		attr.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive,
+			ber.TagOctetString, k, ""))
+		set := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "")
+		for _, v := range values {
+			set.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive,
+				ber.TagOctetString, v, ""))
+		}
+		attr.AppendChild(set)
+		attributes.AppendChild(attr)
+	}
+	response.AppendChild(attributes)
+	self.write(conn, id, response)
+}
+
+func (self *testLdapServer) write(
+	conn net.Conn, id interface{}, response *ber.Packet) {
+	envelope := ber.NewSequence("")
+	envelope.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive,
+		ber.TagInteger, id, ""))
+	envelope.AppendChild(response)
+	_, _ = conn.Write(envelope.Bytes())
+}
+
+var testLdapDirectory = []*testLdapEntry{
+	{
+		dn:       "CN=svc,OU=Service,DC=example,DC=com",
+		password: "svc_password",
+		attrs:    map[string][]string{"cn": {"svc"}},
+	},
+	{
+		dn:       "CN=Alice,OU=Users,DC=example,DC=com",
+		password: "alice_password",
+		attrs: map[string][]string{
+			"cn":             {"Alice"},
+			"sAMAccountName": {"alice"},
+			"mail":           {"alice@example.com"},
+		},
+	},
+	{
+		dn:       "CN=Bob,OU=Users,DC=example,DC=com",
+		password: "bob_password",
+		attrs: map[string][]string{
+			"cn":             {"Bob"},
+			"sAMAccountName": {"bob"},
+			"mail":           {"bob@example.com"},
+		},
+	},
+	{
+		dn: "CN=Analysts,OU=Groups,DC=example,DC=com",
+		attrs: map[string][]string{
+			"cn":     {"Analysts"},
+			"member": {"CN=Alice,OU=Users,DC=example,DC=com"},
+		},
+	},
+
+	// Nested group - Alice is a member through Analysts.
+	{
+		dn: "CN=DFIR,OU=Groups,DC=example,DC=com",
+		attrs: map[string][]string{
+			"cn": {"DFIR"},
+			"member": {
+				"CN=Analysts,OU=Groups,DC=example,DC=com",
+				"CN=Loop,OU=Groups,DC=example,DC=com",
+			},
+		},
+	},
+
+	// A membership cycle must not cause an infinite loop.
+	{
+		dn: "CN=Loop,OU=Groups,DC=example,DC=com",
+		attrs: map[string][]string{
+			"cn":     {"Loop"},
+			"member": {"CN=DFIR,OU=Groups,DC=example,DC=com"},
+		},
+	},
+}
+
+type LdapTestSuite struct {
+	test_utils.TestSuite
+
+	server *testLdapServer
+}
+
+func (self *LdapTestSuite) SetupTest() {
+	self.TestSuite.SetupTest()
+
+	// Take a copy so tests may change the directory.
+	var entries []*testLdapEntry
+	for _, e := range testLdapDirectory {
+		attrs := make(map[string][]string)
+		for k, v := range e.attrs {
+			attrs[k] = v
+		}
+		entries = append(entries, &testLdapEntry{
+			dn: e.dn, password: e.password, attrs: attrs})
+	}
+
+	self.server = newTestLdapServer(self.T(), entries)
+}
+
+func (self *LdapTestSuite) TearDownTest() {
+	self.server.Close()
+	self.TestSuite.TearDownTest()
+}
+
+func (self *LdapTestSuite) makeAuthenticator(
+	ldap_config *config_proto.LDAPConfig) *LdapAuthenticator {
+	ldap_config.Url = self.server.URL()
+
+	auther, err := getAuthenticatorByType(nil, self.ConfigObj,
+		&config_proto.Authenticator{
+			Type: "ldap",
+			Ldap: ldap_config,
+		})
+	assert.NoError(self.T(), err)
+
+	return auther.(*LdapAuthenticator)
+}
+
+func (self *LdapTestSuite) serviceAccountConfig() *config_proto.LDAPConfig {
+	return &config_proto.LDAPConfig{
+		BindDn:            "CN=svc,OU=Service,DC=example,DC=com",
+		BindPassword:      "svc_password",
+		BaseDn:            "OU=Users,DC=example,DC=com",
+		UsernameAttribute: "mail",
+		GroupBaseDn:       "OU=Groups,DC=example,DC=com",
+		RoleMap: map[string]*config_proto.LDAPGroupACL{
+			// Groups may be given by DN
+			"cn=dfir, ou=groups, dc=example, dc=com": {
+				Roles: []string{"investigator"},
+			},
+
+			// Or by CN
+			"Analysts": {
+				Roles:       []string{"reader"},
+				Permissions: []string{"COLLECT_SERVER"},
+				Orgs:        []string{"root"},
+			},
+		},
+	}
+}
+
+func (self *LdapTestSuite) TestAuthenticate() {
+	t := self.T()
+	auther := self.makeAuthenticator(self.serviceAccountConfig())
+
+	_, err := auther.Authenticate("alice", "wrong")
+	assert.Error(t, err)
+
+	_, err = auther.Authenticate("alice", "")
+	assert.Error(t, err)
+
+	_, err = auther.Authenticate("mallory", "alice_password")
+	assert.Error(t, err)
+
+	user, err := auther.Authenticate("alice", "alice_password")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "alice@example.com", user.Name)
+	assert.Equal(t, "CN=Alice,OU=Users,DC=example,DC=com", user.DN)
+
+	var groups []string
+	for _, g := range user.Groups {
+		groups = append(groups, g.CN)
+	}
+	assert.Equal(t, []string{"Analysts", "DFIR", "Loop"}, groups)
+
+	// Bob is not in any groups
+	user, err = auther.Authenticate("bob", "bob_password")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(user.Groups))
+}
+
+func (self *LdapTestSuite) TestDirectBind() {
+	t := self.T()
+	auther := self.makeAuthenticator(&config_proto.LDAPConfig{
+		UserDnTemplate: "CN=%s,OU=Users,DC=example,DC=com",
+	})
+
+	_, err := auther.Authenticate("Alice", "bob_password")
+	assert.Error(t, err)
+
+	user, err := auther.Authenticate("Alice", "alice_password")
+	assert.NoError(t, err)
+	assert.Equal(t, "Alice", user.Name)
+	assert.Equal(t, 0, len(user.Groups))
+}
+
+func (self *LdapTestSuite) TestRoleSync() {
+	t := self.T()
+	ldap_config := self.serviceAccountConfig()
+	auther := self.makeAuthenticator(ldap_config)
+
+	user_manager := services.GetUserManager()
+
+	// Users without mapped groups are not created.
+	_, err := auther.Login(self.Ctx, "bob", "bob_password")
+	assert.NoError(t, err)
+
+	_, err = user_manager.GetUser(self.Ctx, "bob@example.com", "bob@example.com")
+	assert.Error(t, err)
+
+	// Alice gets roles through both groups.
+	username, err := auther.Login(self.Ctx, "alice", "alice_password")
+	assert.NoError(t, err)
+	assert.Equal(t, "alice@example.com", username)
+
+	policy, err := services.GetPolicy(self.ConfigObj, username)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"investigator", "reader"}, policy.Roles)
+	assert.True(t, policy.CollectServer)
+
+	// Remove Alice from Analysts - by default roles are only added.
+	self.server.SetAttribute("CN=Analysts,OU=Groups,DC=example,DC=com", "member")
+
+	user, err := auther.Authenticate("alice", "alice_password")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(user.Groups))
+
+	err = auther.SetRolesForUser(self.Ctx, self.ConfigObj, user)
+	assert.NoError(t, err)
+
+	policy, err = services.GetPolicy(self.ConfigObj, username)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"investigator", "reader"}, policy.Roles)
+
+	// With override_acls the ACL follows the directory exactly.
+	ldap_config.OverrideAcls = true
+	self.server.SetAttribute("CN=Analysts,OU=Groups,DC=example,DC=com", "member",
+		"CN=Bob,OU=Users,DC=example,DC=com")
+	self.server.SetAttribute("CN=DFIR,OU=Groups,DC=example,DC=com", "member",
+		"CN=Alice,OU=Users,DC=example,DC=com")
+
+	user, err = auther.Authenticate("alice", "alice_password")
+	assert.NoError(t, err)
+
+	err = auther.SetRolesForUser(self.Ctx, self.ConfigObj, user)
+	assert.NoError(t, err)
+
+	policy, err = services.GetPolicy(self.ConfigObj, username)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"investigator"}, policy.Roles)
+	assert.False(t, policy.CollectServer)
+
+	// Directory names which look like API token principals are
+	// rejected.
+	self.server.SetAttribute("CN=Alice,OU=Users,DC=example,DC=com", "mail",
+		"bob@example.com#T.1234")
+	_, err = auther.Authenticate("alice", "alice_password")
+	assert.ErrorContains(t, err, "# is not allowed")
+}
+
+func (self *LdapTestSuite) TestHandler() {
+	t := self.T()
+	auther := self.makeAuthenticator(self.serviceAccountConfig())
+
+	parent := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := auther.AuthenticateUserHandler(parent, acls.READ_RESULTS)
+
+	get := func(username, password string) int {
+		req := httptest.NewRequest("GET", "/api/v1/GetUserUITraits", nil)
+		req.SetBasicAuth(username, password)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, get("alice", "wrong"))
+	assert.Equal(t, http.StatusOK, get("alice", "alice_password"))
+
+	// Logins are cached so they keep working while the server is
+	// unavailable.
+	self.server.Close()
+	assert.Equal(t, http.StatusOK, get("alice", "alice_password"))
+	assert.Equal(t, http.StatusUnauthorized, get("bob", "bob_password"))
+}
+
+func TestLdapAuthenticator(t *testing.T) {
+	suite.Run(t, &LdapTestSuite{})
+}
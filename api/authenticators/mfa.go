@@ -0,0 +1,950 @@
+package authenticators
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Velocidex/ordereddict"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	jwt "github.com/golang-jwt/jwt/v4"
+	api_proto "www.velocidex.com/golang/velociraptor/api/proto"
+	api_utils "www.velocidex.com/golang/velociraptor/api/utils"
+	config_proto "www.velocidex.com/golang/velociraptor/config/proto"
+	"www.velocidex.com/golang/velociraptor/json"
+	"www.velocidex.com/golang/velociraptor/logging"
+	"www.velocidex.com/golang/velociraptor/services"
+	utils "www.velocidex.com/golang/velociraptor/utils"
+)
+
+const (
+	mfaCookieName          = "VelociraptorMFA"
+	mfaChallengeCookieName = "VelociraptorMFAChallenge"
+
+	// WebAuthn ceremonies must complete within this time.
+	mfaChallengeExpiry = 5 * time.Minute
+
+	// Lockouts double in length up to this limit.
+	maxTOTPLockout = 24 * time.Hour
+)
+
+var (
+	mfaRequiredError = errors.New("Second factor required")
+	mfaEnrollError   = errors.New("Second factor enrollment required")
+	mfaReauthError   = errors.New(
+		"This action requires a recent second factor verification")
+	mfaLockedError = errors.New("Too many invalid codes")
+
+	// These actions can cause code to run on the server or the
+	// endpoints, or change who can do so.
+	defaultSensitivePaths = []string{
+		"/api/v1/CollectArtifact",
+		"/api/v1/CreateHunt",
+		"/api/v1/ModifyHunt",
+		"/api/v1/SetArtifactFile",
+		"/api/v1/LoadArtifactPack",
+		"/api/v1/SetToolInfo",
+		"/api/v1/UploadTool",
+		"/api/v1/SetServerMonitoringState",
+		"/api/v1/SetClientMonitoringState",
+		"/api/v1/NewNotebookCell",
+		"/api/v1/UpdateNotebookCell",
+		"/api/v1/CreateUser",
+		"/api/v1/SetUserRoles",
+		"/api/v1/SetPassword",
+		"/api/v1/AddSecret",
+		"/api/v1/ModifySecret",
+		"/api/v1/CreateApiToken",
+		"/api/v1/RevokeApiToken",
+		"/api/v1/RevokeGUISession",
+	}
+)
+
+// The claims in the second factor session cookie.
+type MFAClaims struct {
+	Username string `json:"username"`
+
+	// When the second factor was last presented.
+	AuthTime float64 `json:"auth_time"`
+	Expires  float64 `json:"expires"`
+}
+
+func (self *MFAClaims) Valid() error {
+	if self.Username == "" {
+		return errors.New("username not present")
+	}
+
+	if self.Expires < float64(utils.GetTime().Now().Unix()) {
+		return errors.New("the second factor session is expired")
+	}
+	return nil
+}
+
+// Holds the WebAuthn ceremony state between the begin and finish
+// requests.
+type mfaChallengeClaims struct {
+	Username string               `json:"username"`
+	Ceremony string               `json:"ceremony"`
+	Name     string               `json:"name"`
+	Session  webauthn.SessionData `json:"session"`
+	Expires  float64              `json:"expires"`
+}
+
+func (self *mfaChallengeClaims) Valid() error {
+	if self.Expires < float64(utils.GetTime().Now().Unix()) {
+		return errors.New("the challenge is expired")
+	}
+	return nil
+}
+
+// Verifies the password of the basic auth request, writing the error
+// if it fails.
+type passwordVerifier func(
+	w http.ResponseWriter, r *http.Request) (*api_proto.VelociraptorUser, bool)
+
+// Implements the TOTP and WebAuthn second factor for the basic
+// authenticator. Users verify their second factor once on the
+// mfa.html page which gives them a signed session cookie. The cookie
+// is checked on each request in addition to the password.
+type MFAManager struct {
+	config_obj *config_proto.Config
+	mfa_config *config_proto.BasicMFAConfig
+
+	session_expiry  time.Duration
+	reauth_max_age  time.Duration
+	sensitive_paths []string
+
+	max_totp_attempts int64
+	totp_lockout      time.Duration
+
+	// Serializes TOTP verification so concurrent guesses can not
+	// overtake the failure count.
+	totp_mu sync.Mutex
+}
+
+func NewMFAManager(
+	config_obj *config_proto.Config,
+	auth_config *config_proto.Authenticator) *MFAManager {
+
+	mfa_config := auth_config.Mfa
+
+	expiry_min := mfa_config.SessionExpiryMin
+	if expiry_min == 0 {
+		expiry_min = auth_config.DefaultSessionExpiryMin
+	}
+	if expiry_min == 0 {
+		expiry_min = 60 * 24 // 1 Day by default
+	}
+
+	reauth_sec := mfa_config.ReauthMaxAgeSec
+	if reauth_sec == 0 {
+		reauth_sec = 300
+	}
+
+	sensitive_paths := mfa_config.SensitivePaths
+	if len(sensitive_paths) == 0 {
+		sensitive_paths = defaultSensitivePaths
+	}
+
+	max_totp_attempts := mfa_config.MaxTotpAttempts
+	if max_totp_attempts == 0 {
+		max_totp_attempts = 5
+	}
+
+	lockout_sec := mfa_config.TotpLockoutSec
+	if lockout_sec == 0 {
+		lockout_sec = 300
+	}
+
+	return &MFAManager{
+		config_obj:        config_obj,
+		mfa_config:        mfa_config,
+		session_expiry:    time.Duration(expiry_min) * time.Minute,
+		reauth_max_age:    time.Duration(reauth_sec) * time.Second,
+		sensitive_paths:   sensitive_paths,
+		max_totp_attempts: int64(max_totp_attempts),
+		totp_lockout:      time.Duration(lockout_sec) * time.Second,
+	}
+}
+
+func (self *MFAManager) issuer() string {
+	if self.mfa_config.Issuer != "" {
+		return self.mfa_config.Issuer
+	}
+	return "Velociraptor"
+}
+
+func (self *MFAManager) signingKey() []byte {
+	if self.config_obj.Frontend == nil {
+		return nil
+	}
+	return []byte(self.config_obj.Frontend.PrivateKey)
+}
+
+// The relying party is derived from the public URL unless
+// configured.
+func (self *MFAManager) getWebAuthn() (*webauthn.WebAuthn, error) {
+	rp_id := self.mfa_config.RpId
+	origins := self.mfa_config.RpOrigins
+
+	if rp_id == "" || len(origins) == 0 {
+		public_url, err := url.Parse(api_utils.PublicURL(self.config_obj))
+		if err != nil || public_url.Host == "" {
+			return nil, errors.New(
+				"WebAuthn requires GUI.public_url or mfa.rp_id to be set")
+		}
+
+		if rp_id == "" {
+			rp_id = public_url.Hostname()
+		}
+
+		if len(origins) == 0 {
+			origins = []string{public_url.Scheme + "://" + public_url.Host}
+		}
+	}
+
+	return webauthn.New(&webauthn.Config{
+		RPID:          rp_id,
+		RPDisplayName: self.issuer(),
+		RPOrigins:     origins,
+	})
+}
+
+func hasMFA(user_record *api_proto.VelociraptorUser) bool {
+	mfa := user_record.Mfa
+	return mfa != nil &&
+		(mfa.TotpEnabled || len(mfa.WebauthnCredentials) > 0)
+}
+
+// Check the second factor session of a user who already presented
+// their password.
+func (self *MFAManager) Check(
+	r *http.Request, user_record *api_proto.VelociraptorUser) error {
+
+	if !hasMFA(user_record) {
+		if self.mfa_config.Required {
+			return mfaEnrollError
+		}
+		return nil
+	}
+
+	claims, err := self.getSession(r, user_record.Name)
+	if err != nil {
+		return mfaRequiredError
+	}
+
+	if self.isSensitive(r) {
+		auth_time := time.Unix(int64(claims.AuthTime), 0)
+		if utils.GetTime().Now().Sub(auth_time) > self.reauth_max_age {
+			return mfaReauthError
+		}
+	}
+
+	return nil
+}
+
+func (self *MFAManager) isSensitive(r *http.Request) bool {
+	for _, path := range self.sensitive_paths {
+		if strings.HasPrefix(r.URL.Path,
+			api_utils.GetBasePath(self.config_obj, path)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Reject a request without a valid second factor session. Browsers
+// are sent to the second factor page while API calls receive an
+// error.
+func (self *MFAManager) Reject(
+	w http.ResponseWriter, r *http.Request, err error) {
+	mfa_page := api_utils.GetBasePath(self.config_obj, "/app/mfa.html")
+
+	base_path := api_utils.GetBasePath(self.config_obj, "/api/")
+	if strings.HasPrefix(r.URL.Path, base_path) || r.Method != "GET" {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Velociraptor-MFA", mfa_page)
+
+		// Return status forbidden because we don't want the browser
+		// to ask for the password again.
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(json.Format(`{"message": %q}`,
+			fmt.Sprintf("%v: Please visit %v", err, mfa_page))))
+		return
+	}
+
+	http.Redirect(w, r, mfa_page+"?next="+url.QueryEscape(r.URL.RequestURI()),
+		http.StatusTemporaryRedirect)
+}
+
+func (self *MFAManager) getSession(
+	r *http.Request, username string) (*MFAClaims, error) {
+	cookie, err := r.Cookie(mfaCookieName)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := &MFAClaims{}
+	err = self.parseToken(cookie.Value, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	// The session must belong to the user who presented the
+	// password.
+	if claims.Username != username {
+		return nil, errors.New("second factor session is for a different user")
+	}
+
+	return claims, nil
+}
+
+func (self *MFAManager) parseToken(value string, claims jwt.Claims) error {
+	token, err := jwt.ParseWithClaims(value, claims,
+		func(token *jwt.Token) (interface{}, error) {
+			_, ok := token.Method.(*jwt.SigningMethodHMAC)
+			if !ok {
+				return nil, errors.New("invalid signing method")
+			}
+			return self.signingKey(), nil
+		})
+	if err != nil {
+		return err
+	}
+
+	if !token.Valid {
+		return errors.New("invalid token")
+	}
+	return nil
+}
+
+func (self *MFAManager) makeCookie(
+	name, value string, expiry time.Time) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     api_utils.GetBaseDirectory(self.config_obj),
+		Secure:   self.config_obj.GUI == nil || !self.config_obj.GUI.UsePlainHttp,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+		Expires:  expiry,
+	}
+}
+
+// Issue a fresh session after the user presented their second
+// factor.
+func (self *MFAManager) setSession(w http.ResponseWriter, username string) error {
+	now := utils.GetTime().Now()
+	expiry := now.Add(self.session_expiry)
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &MFAClaims{
+		Username: username,
+		AuthTime: float64(now.Unix()),
+		Expires:  float64(expiry.Unix()),
+	})
+
+	value, err := token.SignedString(self.signingKey())
+	if err != nil {
+		return err
+	}
+
+	http.SetCookie(w, self.makeCookie(mfaCookieName, value, expiry))
+	return nil
+}
+
+// Cleared when the user logs off.
+func (self *MFAManager) ClearSession(w http.ResponseWriter) {
+	http.SetCookie(w, self.makeCookie(mfaCookieName, "deleted",
+		time.Unix(0, 0)))
+}
+
+func (self *MFAManager) setChallenge(
+	w http.ResponseWriter, claims *mfaChallengeClaims) error {
+	expiry := utils.GetTime().Now().Add(mfaChallengeExpiry)
+	claims.Expires = float64(expiry.Unix())
+
+	value, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).
+		SignedString(self.signingKey())
+	if err != nil {
+		return err
+	}
+
+	http.SetCookie(w, self.makeCookie(mfaChallengeCookieName, value, expiry))
+	return nil
+}
+
+func (self *MFAManager) getChallenge(
+	w http.ResponseWriter, r *http.Request,
+	username, ceremony string) (*mfaChallengeClaims, error) {
+	cookie, err := r.Cookie(mfaChallengeCookieName)
+	if err != nil {
+		return nil, errors.New("No WebAuthn challenge in progress")
+	}
+
+	// A challenge may only be used once.
+	http.SetCookie(w, self.makeCookie(mfaChallengeCookieName, "deleted",
+		time.Unix(0, 0)))
+
+	claims := &mfaChallengeClaims{}
+	err = self.parseToken(cookie.Value, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.Username != username || claims.Ceremony != ceremony {
+		return nil, errors.New("Invalid WebAuthn challenge")
+	}
+
+	return claims, nil
+}
+
+// Changing enrolled factors requires a recent second factor so a
+// stolen password alone can not replace them.
+func (self *MFAManager) checkFresh(
+	r *http.Request, user_record *api_proto.VelociraptorUser) error {
+	if !hasMFA(user_record) {
+		return nil
+	}
+
+	claims, err := self.getSession(r, user_record.Name)
+	if err != nil {
+		return mfaRequiredError
+	}
+
+	auth_time := time.Unix(int64(claims.AuthTime), 0)
+	if utils.GetTime().Now().Sub(auth_time) > self.reauth_max_age {
+		return mfaReauthError
+	}
+	return nil
+}
+
+func (self *MFAManager) getMFA(
+	ctx context.Context, username string) (*api_proto.UserMFA, error) {
+	users_manager := services.GetUserManager()
+	user_record, err := users_manager.GetUserWithHashes(ctx, username, username)
+	if err != nil {
+		return nil, err
+	}
+
+	if user_record.Mfa == nil {
+		return &api_proto.UserMFA{}, nil
+	}
+	return user_record.Mfa, nil
+}
+
+func (self *MFAManager) audit(
+	r *http.Request, username, operation string, err error) {
+	details := ordereddict.NewDict().
+		Set("operation", operation).
+		Set("remote", r.RemoteAddr)
+	if err != nil {
+		details.Set("err", err.Error())
+	}
+
+	err1 := services.LogAudit(r.Context(),
+		self.config_obj, username, "Second factor", details)
+	if err1 != nil {
+		logger := logging.GetLogger(self.config_obj, &logging.FrontendComponent)
+		logger.Error("Second factor %v for %v %v: %v",
+			operation, username, r.RemoteAddr, err)
+	}
+}
+
+// Install the handlers for the second factor page and API. These
+// only require the password.
+func (self *MFAManager) AddHandlers(
+	mux *api_utils.ServeMux, verify passwordVerifier) {
+
+	handle := func(path, method string,
+		handler func(w http.ResponseWriter, r *http.Request,
+			user_record *api_proto.VelociraptorUser) error) {
+
+		mux.Handle(api_utils.GetBasePath(self.config_obj, path),
+			IpFilter(self.config_obj, api_utils.HandlerFunc(nil,
+				func(w http.ResponseWriter, r *http.Request) {
+					if r.Method != method {
+						http.Error(w, "Method not allowed",
+							http.StatusMethodNotAllowed)
+						return
+					}
+
+					// Requiring a JSON body forces a CORS preflight
+					// for cross origin requests.
+					if method == "POST" && !strings.HasPrefix(
+						r.Header.Get("Content-Type"), "application/json") {
+						http.Error(w, "Content-Type must be application/json",
+							http.StatusUnsupportedMediaType)
+						return
+					}
+
+					user_record, ok := verify(w, r)
+					if !ok {
+						return
+					}
+
+					err := handler(w, r, user_record)
+					if err != nil {
+						status := http.StatusBadRequest
+						if errors.Is(err, mfaRequiredError) ||
+							errors.Is(err, mfaReauthError) {
+							status = http.StatusForbidden
+						} else if errors.Is(err, mfaLockedError) {
+							status = http.StatusTooManyRequests
+						}
+
+						w.Header().Set("Content-Type", "application/json")
+						w.WriteHeader(status)
+						_, _ = w.Write([]byte(json.Format(
+							`{"message": %q}`, err.Error())))
+					}
+				})))
+	}
+
+	handle("/app/mfa.html", "GET", self.servePage)
+	handle("/api/v1/mfa/status", "GET", self.status)
+	handle("/api/v1/mfa/totp/enroll", "POST", self.enrollTOTP)
+	handle("/api/v1/mfa/totp/verify", "POST", self.verifyTOTP)
+	handle("/api/v1/mfa/webauthn/register/begin", "POST", self.beginRegistration)
+	handle("/api/v1/mfa/webauthn/register/finish", "POST", self.finishRegistration)
+	handle("/api/v1/mfa/webauthn/login/begin", "POST", self.beginLogin)
+	handle("/api/v1/mfa/webauthn/login/finish", "POST", self.finishLogin)
+}
+
+func writeJSON(w http.ResponseWriter, value interface{}) error {
+	serialized, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(serialized)
+	return err
+}
+
+func readJSON(r *http.Request, value interface{}) error {
+	data, err := io.ReadAll(io.LimitReader(r.Body, 64*1024))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, value)
+}
+
+func (self *MFAManager) status(w http.ResponseWriter, r *http.Request,
+	user_record *api_proto.VelociraptorUser) error {
+
+	keys := []*ordereddict.Dict{}
+	totp_enabled := false
+	if user_record.Mfa != nil {
+		totp_enabled = user_record.Mfa.TotpEnabled
+		for _, c := range user_record.Mfa.WebauthnCredentials {
+			keys = append(keys, ordereddict.NewDict().
+				Set("name", c.Name).
+				Set("created", c.Created).
+				Set("last_used", c.LastUsed))
+		}
+	}
+
+	_, err := self.getWebAuthn()
+	_, session_err := self.getSession(r, user_record.Name)
+
+	return writeJSON(w, ordereddict.NewDict().
+		Set("username", user_record.Name).
+		Set("required", self.mfa_config.Required).
+		Set("enrolled", hasMFA(user_record)).
+		Set("verified", session_err == nil).
+		Set("fresh", self.checkFresh(r, user_record) == nil).
+		Set("totp_enabled", totp_enabled).
+		Set("webauthn_available", err == nil).
+		Set("webauthn_keys", keys))
+}
+
+// Start enrolling a new TOTP secret. It is only enabled once the user
+// verifies a code from it.
+func (self *MFAManager) enrollTOTP(w http.ResponseWriter, r *http.Request,
+	user_record *api_proto.VelociraptorUser) error {
+	err := self.checkFresh(r, user_record)
+	if err != nil {
+		return err
+	}
+
+	secret, err := NewTOTPSecret()
+	if err != nil {
+		return err
+	}
+
+	mfa, err := self.getMFA(r.Context(), user_record.Name)
+	if err != nil {
+		return err
+	}
+	mfa.PendingTotpSecret = secret
+
+	err = services.GetUserManager().SetUserMFA(
+		r.Context(), user_record.Name, user_record.Name, mfa)
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, ordereddict.NewDict().
+		Set("secret", EncodeTOTPSecret(secret)).
+		Set("uri", TOTPURI(self.issuer(), user_record.Name, secret)))
+}
+
+func (self *MFAManager) verifyTOTP(w http.ResponseWriter, r *http.Request,
+	user_record *api_proto.VelociraptorUser) error {
+	request := struct {
+		Code string `json:"code"`
+	}{}
+	err := readJSON(r, &request)
+	if err != nil {
+		return err
+	}
+	code := strings.TrimSpace(request.Code)
+
+	self.totp_mu.Lock()
+	defer self.totp_mu.Unlock()
+
+	mfa, err := self.getMFA(r.Context(), user_record.Name)
+	if err != nil {
+		return err
+	}
+
+	now := utils.GetTime().Now()
+	operation := "TOTP login"
+
+	locked_until := time.Unix(mfa.TotpLockedUntil, 0)
+	if now.Before(locked_until) {
+		err := fmt.Errorf("%w: try again in %v", mfaLockedError,
+			locked_until.Sub(now).Round(time.Second))
+		self.audit(r, user_record.Name, operation, err)
+		return err
+	}
+
+	// Completing an enrollment
+	step, ok := ValidateTOTP(mfa.PendingTotpSecret, code, now, 0)
+	if ok {
+		operation = "TOTP enrolled"
+		mfa.TotpSecret = mfa.PendingTotpSecret
+		mfa.TotpEnabled = true
+		mfa.PendingTotpSecret = nil
+
+	} else if mfa.TotpEnabled {
+		step, ok = ValidateTOTP(mfa.TotpSecret, code, now, mfa.TotpLastStep)
+	}
+
+	if !ok {
+		err := errors.New("Invalid TOTP code")
+		lockout := self.recordTOTPFailure(mfa, now)
+		if lockout > 0 {
+			operation = "TOTP locked out"
+			err = fmt.Errorf("%w: try again in %v", mfaLockedError, lockout)
+		}
+
+		err1 := services.GetUserManager().SetUserMFA(
+			r.Context(), user_record.Name, user_record.Name, mfa)
+		if err1 != nil {
+			return err1
+		}
+
+		self.audit(r, user_record.Name, operation, err)
+		return err
+	}
+
+	mfa.TotpLastStep = step
+	mfa.TotpFailedAttempts = 0
+	mfa.TotpLockedUntil = 0
+	err = services.GetUserManager().SetUserMFA(
+		r.Context(), user_record.Name, user_record.Name, mfa)
+	if err != nil {
+		return err
+	}
+
+	self.audit(r, user_record.Name, operation, nil)
+
+	err = self.setSession(w, user_record.Name)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, ordereddict.NewDict().Set("verified", true))
+}
+
+// Count an invalid code. Every max_totp_attempts consecutive failures
+// lock the user out, for twice as long as the previous lockout.
+// Returns the length of the new lockout or 0.
+func (self *MFAManager) recordTOTPFailure(
+	mfa *api_proto.UserMFA, now time.Time) time.Duration {
+	mfa.TotpFailedAttempts++
+	if mfa.TotpFailedAttempts%self.max_totp_attempts != 0 {
+		return 0
+	}
+
+	lockout := self.totp_lockout
+	for i := int64(1); i < mfa.TotpFailedAttempts/self.max_totp_attempts; i++ {
+		lockout *= 2
+		if lockout >= maxTOTPLockout {
+			lockout = maxTOTPLockout
+			break
+		}
+	}
+
+	mfa.TotpLockedUntil = now.Add(lockout).Unix()
+	return lockout
+}
+
+// Adapts the user record to the WebAuthn library.
+type webAuthnUser struct {
+	name string
+	mfa  *api_proto.UserMFA
+}
+
+func (self *webAuthnUser) WebAuthnID() []byte {
+	return self.mfa.WebauthnUserId
+}
+
+func (self *webAuthnUser) WebAuthnName() string {
+	return self.name
+}
+
+func (self *webAuthnUser) WebAuthnDisplayName() string {
+	return self.name
+}
+
+func (self *webAuthnUser) WebAuthnCredentials() []webauthn.Credential {
+	result := []webauthn.Credential{}
+	for _, c := range self.mfa.WebauthnCredentials {
+		credential := webauthn.Credential{
+			ID:              c.Id,
+			PublicKey:       c.PublicKey,
+			AttestationType: c.AttestationType,
+			Flags: webauthn.CredentialFlags{
+				BackupEligible: c.BackupEligible,
+				BackupState:    c.BackupState,
+			},
+			Authenticator: webauthn.Authenticator{
+				AAGUID:    c.Aaguid,
+				SignCount: c.SignCount,
+			},
+		}
+
+		for _, t := range c.Transports {
+			credential.Transport = append(credential.Transport,
+				protocol.AuthenticatorTransport(t))
+		}
+		result = append(result, credential)
+	}
+	return result
+}
+
+func (self *MFAManager) beginRegistration(w http.ResponseWriter, r *http.Request,
+	user_record *api_proto.VelociraptorUser) error {
+	err := self.checkFresh(r, user_record)
+	if err != nil {
+		return err
+	}
+
+	request := struct {
+		Name string `json:"name"`
+	}{}
+	err = readJSON(r, &request)
+	if err != nil {
+		return err
+	}
+
+	if request.Name == "" {
+		return errors.New("A name is required for the security key")
+	}
+
+	wa, err := self.getWebAuthn()
+	if err != nil {
+		return err
+	}
+
+	mfa, err := self.getMFA(r.Context(), user_record.Name)
+	if err != nil {
+		return err
+	}
+
+	// The user handle is random so it does not reveal the username.
+	if len(mfa.WebauthnUserId) == 0 {
+		mfa.WebauthnUserId = make([]byte, 32)
+		_, err = rand.Read(mfa.WebauthnUserId)
+		if err != nil {
+			return err
+		}
+
+		err = services.GetUserManager().SetUserMFA(
+			r.Context(), user_record.Name, user_record.Name, mfa)
+		if err != nil {
+			return err
+		}
+	}
+
+	user := &webAuthnUser{name: user_record.Name, mfa: mfa}
+	options, session, err := wa.BeginRegistration(user,
+		webauthn.WithExclusions(
+			webauthn.Credentials(user.WebAuthnCredentials()).
+				CredentialDescriptors()))
+	if err != nil {
+		return err
+	}
+
+	err = self.setChallenge(w, &mfaChallengeClaims{
+		Username: user_record.Name,
+		Ceremony: "register",
+		Name:     request.Name,
+		Session:  *session,
+	})
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, options)
+}
+
+func (self *MFAManager) finishRegistration(w http.ResponseWriter, r *http.Request,
+	user_record *api_proto.VelociraptorUser) error {
+	challenge, err := self.getChallenge(w, r, user_record.Name, "register")
+	if err != nil {
+		return err
+	}
+
+	wa, err := self.getWebAuthn()
+	if err != nil {
+		return err
+	}
+
+	mfa, err := self.getMFA(r.Context(), user_record.Name)
+	if err != nil {
+		return err
+	}
+
+	user := &webAuthnUser{name: user_record.Name, mfa: mfa}
+	credential, err := wa.FinishRegistration(user, challenge.Session, r)
+	if err != nil {
+		self.audit(r, user_record.Name, "WebAuthn registration", err)
+		return err
+	}
+
+	now := utils.GetTime().Now().Unix()
+	record := &api_proto.WebAuthnCredential{
+		Name:            challenge.Name,
+		Id:              credential.ID,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		Aaguid:          credential.Authenticator.AAGUID,
+		SignCount:       credential.Authenticator.SignCount,
+		BackupEligible:  credential.Flags.BackupEligible,
+		BackupState:     credential.Flags.BackupState,
+		Created:         now,
+		LastUsed:        now,
+	}
+	for _, t := range credential.Transport {
+		record.Transports = append(record.Transports, string(t))
+	}
+	mfa.WebauthnCredentials = append(mfa.WebauthnCredentials, record)
+
+	err = services.GetUserManager().SetUserMFA(
+		r.Context(), user_record.Name, user_record.Name, mfa)
+	if err != nil {
+		return err
+	}
+
+	self.audit(r, user_record.Name, "WebAuthn key registered: "+challenge.Name, nil)
+
+	err = self.setSession(w, user_record.Name)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, ordereddict.NewDict().Set("verified", true))
+}
+
+func (self *MFAManager) beginLogin(w http.ResponseWriter, r *http.Request,
+	user_record *api_proto.VelociraptorUser) error {
+	wa, err := self.getWebAuthn()
+	if err != nil {
+		return err
+	}
+
+	mfa, err := self.getMFA(r.Context(), user_record.Name)
+	if err != nil {
+		return err
+	}
+
+	if len(mfa.WebauthnCredentials) == 0 {
+		return errors.New("No security keys registered")
+	}
+
+	user := &webAuthnUser{name: user_record.Name, mfa: mfa}
+	options, session, err := wa.BeginLogin(user)
+	if err != nil {
+		return err
+	}
+
+	err = self.setChallenge(w, &mfaChallengeClaims{
+		Username: user_record.Name,
+		Ceremony: "login",
+		Session:  *session,
+	})
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, options)
+}
+
+func (self *MFAManager) finishLogin(w http.ResponseWriter, r *http.Request,
+	user_record *api_proto.VelociraptorUser) error {
+	challenge, err := self.getChallenge(w, r, user_record.Name, "login")
+	if err != nil {
+		return err
+	}
+
+	wa, err := self.getWebAuthn()
+	if err != nil {
+		return err
+	}
+
+	mfa, err := self.getMFA(r.Context(), user_record.Name)
+	if err != nil {
+		return err
+	}
+
+	user := &webAuthnUser{name: user_record.Name, mfa: mfa}
+	credential, err := wa.FinishLogin(user, challenge.Session, r)
+	if err == nil && credential.Authenticator.CloneWarning {
+		err = errors.New("Security key signature counter went backwards - it may be cloned")
+	}
+	if err != nil {
+		self.audit(r, user_record.Name, "WebAuthn login", err)
+		return err
+	}
+
+	for _, c := range mfa.WebauthnCredentials {
+		if string(c.Id) == string(credential.ID) {
+			c.SignCount = credential.Authenticator.SignCount
+			c.BackupState = credential.Flags.BackupState
+			c.LastUsed = utils.GetTime().Now().Unix()
+		}
+	}
+
+	err = services.GetUserManager().SetUserMFA(
+		r.Context(), user_record.Name, user_record.Name, mfa)
+	if err != nil {
+		return err
+	}
+
+	self.audit(r, user_record.Name, "WebAuthn login", nil)
+
+	err = self.setSession(w, user_record.Name)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, ordereddict.NewDict().Set("verified", true))
+}
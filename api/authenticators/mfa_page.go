@@ -0,0 +1,220 @@
+package authenticators
+
+import (
+	"html/template"
+	"net/http"
+	"strings"
+
+	api_proto "www.velocidex.com/golang/velociraptor/api/proto"
+	api_utils "www.velocidex.com/golang/velociraptor/api/utils"
+)
+
+// The second factor page is self contained so it works before the
+// user can access the GUI.
+var mfaPageTemplate = template.Must(template.New("mfa").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Issuer}} - Second Factor</title>
+<style>
+body { font-family: sans-serif; background: #f4f4f4; }
+.box { max-width: 28em; margin: 4em auto; padding: 1em 2em;
+       background: white; border: 1px solid #ccc; border-radius: 4px; }
+.error { color: #b00; }
+.hidden { display: none; }
+code { word-break: break-all; }
+input, button { margin: 0.3em 0; padding: 0.3em; }
+</style>
+</head>
+<body>
+<div class="box">
+  <h2>{{.Issuer}}</h2>
+  <p>Signed in as <b>{{.Username}}</b></p>
+  <p id="message" class="error"></p>
+
+  <div id="verify" class="hidden">
+    <h3>Verify your second factor</h3>
+    <div id="verify-totp" class="hidden">
+      <input id="code" autocomplete="one-time-code" inputmode="numeric"
+             placeholder="6 digit code" size="10">
+      <button onclick="verifyTOTP('code')">Verify</button>
+    </div>
+    <div id="verify-webauthn" class="hidden">
+      <button onclick="loginWebAuthn()">Use security key</button>
+    </div>
+  </div>
+
+  <div id="enroll" class="hidden">
+    <h3>Enroll a second factor</h3>
+    <div>
+      <button onclick="enrollTOTP()">Set up an authenticator app</button>
+      <div id="totp-secret" class="hidden">
+        <p>Add this secret to your authenticator app:</p>
+        <p><code id="secret"></code></p>
+        <p><a id="uri" href="#">Open in authenticator app</a></p>
+        <input id="enroll-code" autocomplete="one-time-code" inputmode="numeric"
+               placeholder="6 digit code" size="10">
+        <button onclick="verifyTOTP('enroll-code')">Confirm</button>
+      </div>
+    </div>
+    <div id="enroll-webauthn" class="hidden">
+      <input id="key-name" placeholder="Security key name">
+      <button onclick="registerWebAuthn()">Register security key</button>
+    </div>
+  </div>
+
+  <p><a href="{{.Next}}">Continue</a></p>
+</div>
+<script>
+const base = {{.BasePath}};
+const next = {{.Next}};
+
+function showMessage(msg) {
+  document.getElementById("message").textContent = msg;
+}
+
+function show(id, visible) {
+  document.getElementById(id).classList.toggle("hidden", !visible);
+}
+
+async function post(path, body) {
+  const resp = await fetch(base + path, {
+    method: "POST",
+    headers: {"Content-Type": "application/json"},
+    body: JSON.stringify(body || {}),
+  });
+  const data = await resp.json();
+  if (!resp.ok) {
+    throw new Error(data.message || resp.statusText);
+  }
+  return data;
+}
+
+function b64ToBuf(value) {
+  const s = value.replace(/-/g, "+").replace(/_/g, "/");
+  return Uint8Array.from(atob(s), c => c.charCodeAt(0)).buffer;
+}
+
+function bufToB64(buf) {
+  return btoa(String.fromCharCode(...new Uint8Array(buf)))
+    .replace(/\+/g, "-").replace(/\//g, "_").replace(/=+$/, "");
+}
+
+function done() {
+  window.location = next;
+}
+
+async function refresh() {
+  const resp = await fetch(base + "/api/v1/mfa/status");
+  const status = await resp.json();
+  const keys = status.webauthn_keys || [];
+
+  show("verify", status.enrolled);
+  show("verify-totp", status.totp_enabled);
+  show("verify-webauthn", keys.length > 0);
+
+  // Changing factors requires a recent verification.
+  show("enroll", !status.enrolled || status.fresh);
+  show("enroll-webauthn", status.webauthn_available);
+
+  if (status.required && !status.enrolled) {
+    showMessage("You must enroll a second factor to continue.");
+  }
+}
+
+async function verifyTOTP(id) {
+  try {
+    await post("/api/v1/mfa/totp/verify",
+               {code: document.getElementById(id).value});
+    done();
+  } catch(e) { showMessage(e.message); }
+}
+
+async function enrollTOTP() {
+  try {
+    const data = await post("/api/v1/mfa/totp/enroll");
+    document.getElementById("secret").textContent = data.secret;
+    document.getElementById("uri").href = data.uri;
+    show("totp-secret", true);
+  } catch(e) { showMessage(e.message); }
+}
+
+async function registerWebAuthn() {
+  try {
+    const options = await post("/api/v1/mfa/webauthn/register/begin",
+      {name: document.getElementById("key-name").value});
+    const pk = options.publicKey;
+    pk.challenge = b64ToBuf(pk.challenge);
+    pk.user.id = b64ToBuf(pk.user.id);
+    (pk.excludeCredentials || []).forEach(c => c.id = b64ToBuf(c.id));
+
+    const cred = await navigator.credentials.create({publicKey: pk});
+    await post("/api/v1/mfa/webauthn/register/finish", {
+      id: cred.id,
+      rawId: bufToB64(cred.rawId),
+      type: cred.type,
+      response: {
+        attestationObject: bufToB64(cred.response.attestationObject),
+        clientDataJSON: bufToB64(cred.response.clientDataJSON),
+        transports: cred.response.getTransports ? cred.response.getTransports() : [],
+      },
+    });
+    done();
+  } catch(e) { showMessage(e.message); }
+}
+
+async function loginWebAuthn() {
+  try {
+    const options = await post("/api/v1/mfa/webauthn/login/begin");
+    const pk = options.publicKey;
+    pk.challenge = b64ToBuf(pk.challenge);
+    (pk.allowCredentials || []).forEach(c => c.id = b64ToBuf(c.id));
+
+    const cred = await navigator.credentials.get({publicKey: pk});
+    await post("/api/v1/mfa/webauthn/login/finish", {
+      id: cred.id,
+      rawId: bufToB64(cred.rawId),
+      type: cred.type,
+      response: {
+        authenticatorData: bufToB64(cred.response.authenticatorData),
+        clientDataJSON: bufToB64(cred.response.clientDataJSON),
+        signature: bufToB64(cred.response.signature),
+        userHandle: cred.response.userHandle ? bufToB64(cred.response.userHandle) : null,
+      },
+    });
+    done();
+  } catch(e) { showMessage(e.message); }
+}
+
+refresh();
+</script>
+</body>
+</html>
+`))
+
+type mfaPageArgs struct {
+	Issuer   string
+	Username string
+	BasePath string
+	Next     string
+}
+
+func (self *MFAManager) servePage(w http.ResponseWriter, r *http.Request,
+	user_record *api_proto.VelociraptorUser) error {
+
+	// Only redirect within the GUI.
+	next := r.URL.Query().Get("next")
+	base := api_utils.GetBaseDirectory(self.config_obj)
+	if !strings.HasPrefix(next, base) ||
+		strings.HasPrefix(next, "//") || strings.Contains(next, "\\") {
+		next = api_utils.Homepage(self.config_obj)
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	return mfaPageTemplate.Execute(w, &mfaPageArgs{
+		Issuer:   self.issuer(),
+		Username: user_record.Name,
+		BasePath: api_utils.GetBasePath(self.config_obj),
+		Next:     next,
+	})
+}
@@ -0,0 +1,350 @@
+package authenticators
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"www.velocidex.com/golang/velociraptor/acls"
+	api_utils "www.velocidex.com/golang/velociraptor/api/utils"
+	config_proto "www.velocidex.com/golang/velociraptor/config/proto"
+	"www.velocidex.com/golang/velociraptor/file_store/test_utils"
+	"www.velocidex.com/golang/velociraptor/json"
+	"www.velocidex.com/golang/velociraptor/services"
+	"www.velocidex.com/golang/velociraptor/services/users"
+	"www.velocidex.com/golang/velociraptor/utils"
+	"www.velocidex.com/golang/velociraptor/vtesting/assert"
+)
+
+// Test vectors from RFC 6238 Appendix B truncated to 6 digits.
+func TestTOTP(t *testing.T) {
+	secret := []byte("12345678901234567890")
+
+	for _, tc := range []struct {
+		time int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	} {
+		now := time.Unix(tc.time, 0)
+		assert.Equal(t, tc.code, TOTPCode(secret, TOTPStep(now)))
+
+		step, ok := ValidateTOTP(secret, tc.code, now, 0)
+		assert.True(t, ok)
+
+		// The same code can not be used twice.
+		_, ok = ValidateTOTP(secret, tc.code, now, step)
+		assert.False(t, ok)
+	}
+
+	// Codes from the next period are accepted to allow for clock
+	// skew but not beyond that.
+	now := time.Unix(1234567890, 0)
+	_, ok := ValidateTOTP(secret, "005924", now.Add(totpPeriod*time.Second), 0)
+	assert.True(t, ok)
+
+	_, ok = ValidateTOTP(secret, "005924", now.Add(3*totpPeriod*time.Second), 0)
+	assert.False(t, ok)
+}
+
+type MFATestSuite struct {
+	test_utils.TestSuite
+
+	clock   *utils.MockClock
+	closer  func()
+	mux     *api_utils.ServeMux
+	handler http.Handler
+}
+
+func (self *MFATestSuite) SetupTest() {
+	self.TestSuite.SetupTest()
+
+	self.clock = utils.NewMockClock(time.Unix(1800000000, 0))
+	self.closer = utils.MockTime(self.clock)
+
+	for _, username := range []string{"alice", "bob"} {
+		user_record, err := users.NewUserRecord(self.ConfigObj, username)
+		assert.NoError(self.T(), err)
+
+		users.SetPassword(user_record, username+"_password")
+		err = services.GetUserManager().SetUser(self.Ctx, user_record)
+		assert.NoError(self.T(), err)
+
+		err = services.GrantRoles(self.ConfigObj, username,
+			[]string{"administrator"})
+		assert.NoError(self.T(), err)
+	}
+
+	auther, err := getAuthenticatorByType(nil, self.ConfigObj,
+		&config_proto.Authenticator{
+			Type: "basic",
+			Mfa: &config_proto.BasicMFAConfig{
+				Required: true,
+			},
+		})
+	assert.NoError(self.T(), err)
+
+	self.mux = api_utils.NewServeMux()
+	err = auther.AddHandlers(self.mux)
+	assert.NoError(self.T(), err)
+
+	parent := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	self.handler = auther.AuthenticateUserHandler(parent, acls.READ_RESULTS)
+}
+
+func (self *MFATestSuite) TearDownTest() {
+	self.closer()
+	self.TestSuite.TearDownTest()
+}
+
+func (self *MFATestSuite) request(handler http.Handler,
+	method, path, username string, body interface{},
+	cookies ...*http.Cookie) *httptest.ResponseRecorder {
+
+	var data []byte
+	if body != nil {
+		data = []byte(json.MustMarshalString(body))
+	}
+
+	req := httptest.NewRequest(method, path, bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	req.SetBasicAuth(username, username+"_password")
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func (self *MFATestSuite) getCookie(
+	w *httptest.ResponseRecorder, name string) *http.Cookie {
+	for _, c := range w.Result().Cookies() {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+func (self *MFATestSuite) TestTOTPEnrollment() {
+	t := self.T()
+
+	// Enrollment is required before the GUI can be used.
+	w := self.request(self.handler, "GET", "/api/v1/GetUserUITraits", "alice", nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, "/app/mfa.html", w.Header().Get("X-Velociraptor-MFA"))
+
+	// Browsers are redirected to the second factor page.
+	w = self.request(self.handler, "GET", "/app/index.html", "alice", nil)
+	assert.Equal(t, http.StatusTemporaryRedirect, w.Code)
+	assert.Contains(t, w.Header().Get("Location"), "/app/mfa.html?next=")
+
+	// The second factor API still requires the password.
+	w = self.request(self.mux, "POST", "/api/v1/mfa/totp/enroll", "mallory", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// Cross origin form posts are rejected.
+	req := httptest.NewRequest("POST", "/api/v1/mfa/totp/enroll", nil)
+	req.SetBasicAuth("alice", "alice_password")
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	self.mux.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+
+	w = self.request(self.mux, "POST", "/api/v1/mfa/totp/enroll", "alice", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	enrollment := struct {
+		Secret string `json:"secret"`
+		URI    string `json:"uri"`
+	}{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &enrollment))
+	assert.Contains(t, enrollment.URI, "otpauth://totp/Velociraptor:alice?")
+
+	secret, err := totpEncoding.DecodeString(enrollment.Secret)
+	assert.NoError(t, err)
+
+	// A wrong code does not enroll the secret.
+	w = self.request(self.mux, "POST", "/api/v1/mfa/totp/verify", "alice",
+		map[string]string{"code": "000000"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	code := TOTPCode(secret, TOTPStep(self.clock.Now()))
+	w = self.request(self.mux, "POST", "/api/v1/mfa/totp/verify", "alice",
+		map[string]string{"code": code})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	session := self.getCookie(w, mfaCookieName)
+	assert.NotNil(t, session)
+
+	// The code can not be replayed.
+	w = self.request(self.mux, "POST", "/api/v1/mfa/totp/verify", "alice",
+		map[string]string{"code": code})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Now the session allows access.
+	w = self.request(self.handler, "GET", "/api/v1/GetUserUITraits",
+		"alice", nil, session)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = self.request(self.handler, "POST", "/api/v1/CollectArtifact",
+		"alice", nil, session)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// The session is bound to the user so it does not let bob in.
+	w = self.request(self.handler, "GET", "/api/v1/GetUserUITraits",
+		"bob", nil, session)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// Sensitive actions require a recent verification.
+	self.clock.Set(self.clock.Now().Add(10 * time.Minute))
+
+	w = self.request(self.handler, "GET", "/api/v1/GetUserUITraits",
+		"alice", nil, session)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = self.request(self.handler, "POST", "/api/v1/CollectArtifact",
+		"alice", nil, session)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "recent second factor")
+
+	// Minting API tokens and revoking sessions are also sensitive.
+	for _, path := range []string{"/api/v1/CreateApiToken",
+		"/api/v1/RevokeApiToken", "/api/v1/RevokeGUISession"} {
+		w = self.request(self.handler, "POST", path, "alice", nil, session)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	}
+
+	// So does replacing the enrolled factor.
+	w = self.request(self.mux, "POST", "/api/v1/mfa/totp/enroll",
+		"alice", nil, session)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// Verifying again refreshes the session.
+	code = TOTPCode(secret, TOTPStep(self.clock.Now()))
+	w = self.request(self.mux, "POST", "/api/v1/mfa/totp/verify", "alice",
+		map[string]string{"code": code}, session)
+	assert.Equal(t, http.StatusOK, w.Code)
+	session = self.getCookie(w, mfaCookieName)
+
+	w = self.request(self.handler, "POST", "/api/v1/CollectArtifact",
+		"alice", nil, session)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// The session expires eventually.
+	self.clock.Set(self.clock.Now().Add(25 * time.Hour))
+	w = self.request(self.handler, "GET", "/api/v1/GetUserUITraits",
+		"alice", nil, session)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "Second factor required")
+}
+
+func (self *MFATestSuite) TestAdminReset() {
+	t := self.T()
+	users_manager := services.GetUserManager()
+
+	w := self.request(self.mux, "POST", "/api/v1/mfa/totp/enroll", "alice", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	user_record, err := users_manager.GetUserWithHashes(self.Ctx, "alice", "alice")
+	assert.NoError(t, err)
+
+	code := TOTPCode(user_record.Mfa.PendingTotpSecret,
+		TOTPStep(self.clock.Now()))
+	w = self.request(self.mux, "POST", "/api/v1/mfa/totp/verify", "alice",
+		map[string]string{"code": code})
+	assert.Equal(t, http.StatusOK, w.Code)
+	session := self.getCookie(w, mfaCookieName)
+
+	// Bob is an administrator so may reset alice's second factor.
+	err = users_manager.SetUserMFA(self.Ctx, "bob", "alice", nil)
+	assert.NoError(t, err)
+
+	// Alice must now enroll again.
+	w = self.request(self.handler, "GET", "/api/v1/GetUserUITraits",
+		"alice", nil, session)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "enrollment required")
+
+	w = self.request(self.mux, "GET", "/api/v1/mfa/status", "alice", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	status := struct {
+		Required bool `json:"required"`
+		Enrolled bool `json:"enrolled"`
+	}{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &status))
+	assert.True(t, status.Required)
+	assert.False(t, status.Enrolled)
+}
+
+func (self *MFATestSuite) TestTOTPLockout() {
+	t := self.T()
+	users_manager := services.GetUserManager()
+
+	w := self.request(self.mux, "POST", "/api/v1/mfa/totp/enroll", "alice", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	user_record, err := users_manager.GetUserWithHashes(self.Ctx, "alice", "alice")
+	assert.NoError(t, err)
+	secret := user_record.Mfa.PendingTotpSecret
+
+	verify := func(code string) *httptest.ResponseRecorder {
+		return self.request(self.mux, "POST", "/api/v1/mfa/totp/verify",
+			"alice", map[string]string{"code": code})
+	}
+
+	// The fifth invalid code locks the user out.
+	for i := 0; i < 4; i++ {
+		w = verify("000000")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	}
+
+	w = verify("000000")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Contains(t, w.Body.String(), "Too many invalid codes")
+
+	// Even a valid code is rejected while locked out.
+	w = verify(TOTPCode(secret, TOTPStep(self.clock.Now())))
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+
+	self.clock.Set(self.clock.Now().Add(5*time.Minute + time.Second))
+
+	// The next failures lock the user out for twice as long.
+	for i := 0; i < 4; i++ {
+		w = verify("000000")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	}
+	w = verify("000000")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Contains(t, w.Body.String(), "10m0s")
+
+	self.clock.Set(self.clock.Now().Add(5*time.Minute + time.Second))
+	w = verify(TOTPCode(secret, TOTPStep(self.clock.Now())))
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+
+	// A valid code after the lockout resets the count.
+	self.clock.Set(self.clock.Now().Add(5 * time.Minute))
+	w = verify(TOTPCode(secret, TOTPStep(self.clock.Now())))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	user_record, err = users_manager.GetUserWithHashes(self.Ctx, "alice", "alice")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), user_record.Mfa.TotpFailedAttempts)
+	assert.True(t, user_record.Mfa.TotpEnabled)
+}
+
+func TestMFA(t *testing.T) {
+	suite.Run(t, &MFATestSuite{})
+}
@@ -138,6 +138,26 @@ func (self *SamlAuthenticator) AuthenticateUserHandler(
 
 			username := sa.GetAttributes().Get(self.user_attribute)
 
+			// A revoked session must log in through the IdP again.
+			cookie_name := "token"
+			provider, ok := samlMiddleware.Session.(samlsp.CookieSessionProvider)
+			if ok {
+				cookie_name = provider.Name
+			}
+
+			cookie, err := r.Cookie(cookie_name)
+			if err == nil {
+				err = checkGUISession(self.config_obj, r, username, cookie.Value,
+					utils.GetTime().Now().Add(getSessionExpiry(self.config_obj)))
+			}
+			if err != nil {
+				// The request still carries the session so
+				// reject_handler would accept it.
+				_ = samlMiddleware.Session.DeleteSession(w, r)
+				samlMiddleware.HandleStartAuthFlow(w, r)
+				return
+			}
+
 			user_record, err := self.MaybeCreateUser(r.Context(), username, r.RemoteAddr)
 			if err != nil {
 				err := services.LogAudit(r.Context(),
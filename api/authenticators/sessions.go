@@ -0,0 +1,98 @@
+package authenticators
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/Velocidex/ordereddict"
+	api_proto "www.velocidex.com/golang/velociraptor/api/proto"
+	config_proto "www.velocidex.com/golang/velociraptor/config/proto"
+	"www.velocidex.com/golang/velociraptor/logging"
+	"www.velocidex.com/golang/velociraptor/services"
+	utils "www.velocidex.com/golang/velociraptor/utils"
+)
+
+// GUI sessions are tracked by the user manager so admins can see who
+// is logged in and end their sessions. A session is identified by
+// the cookie the browser presents on each request and is recorded the
+// first time the cookie is seen.
+func checkGUISession(
+	config_obj *config_proto.Config, r *http.Request,
+	username, cookie string, expires time.Time) error {
+
+	authenticator := ""
+	if config_obj.GUI != nil && config_obj.GUI.Authenticator != nil {
+		authenticator = config_obj.GUI.Authenticator.Type
+	}
+
+	users_manager := services.GetUserManager()
+	_, err := users_manager.VerifyGUISession(r.Context(), cookie,
+		&api_proto.GUISession{
+			Username:       username,
+			Authenticator:  authenticator,
+			LastActiveFrom: r.RemoteAddr,
+			UserAgent:      r.UserAgent(),
+			Expires:        uint64(expires.Unix()),
+		})
+	if err != nil {
+		err1 := services.LogAudit(r.Context(),
+			config_obj, username, "GUI session rejected",
+			ordereddict.NewDict().
+				Set("remote", r.RemoteAddr).
+				Set("url", r.URL.Path).
+				Set("err", err.Error()))
+		if err1 != nil {
+			logger := logging.GetLogger(config_obj, &logging.FrontendComponent)
+			logger.Error("<red>GUI session rejected</> %v %v: %v",
+				username, r.RemoteAddr, err)
+		}
+	}
+	return err
+}
+
+func getSessionExpiry(config_obj *config_proto.Config) time.Duration {
+	expiry_min := uint64(0)
+	if config_obj.GUI != nil && config_obj.GUI.Authenticator != nil {
+		expiry_min = config_obj.GUI.Authenticator.DefaultSessionExpiryMin
+	}
+
+	if expiry_min == 0 {
+		expiry_min = 60 * 24 // 1 Day by default
+	}
+	return time.Duration(expiry_min) * time.Minute
+}
+
+// Authenticators which check credentials on every request (basic
+// auth, client certificates) have no login cookie so the session is
+// bound to the credentials instead. Revoking such a session rejects
+// the credentials until the session expires or the password is
+// changed. The credentials are keyed so their hash can not be
+// recovered from the user record.
+func checkCredentialSession(
+	config_obj *config_proto.Config,
+	w http.ResponseWriter, r *http.Request,
+	username string, credentials ...string) bool {
+
+	key := ""
+	if config_obj.Frontend != nil {
+		key = config_obj.Frontend.PrivateKey
+	}
+
+	mac := hmac.New(sha256.New, []byte(key))
+	for _, c := range credentials {
+		mac.Write([]byte(c))
+		mac.Write([]byte{0})
+	}
+
+	err := checkGUISession(config_obj, r, username,
+		hex.EncodeToString(mac.Sum(nil)),
+		utils.GetTime().Now().Add(getSessionExpiry(config_obj)))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,97 @@
+package authenticators
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// Time based one time passwords (RFC 6238) using the defaults
+// understood by all authenticator apps: SHA1, 6 digits and a 30
+// second period.
+const (
+	totpPeriod = 30
+	totpDigits = 6
+
+	// Accept codes from one period either side to allow for clock
+	// skew.
+	totpSkew = 1
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+func NewTOTPSecret() ([]byte, error) {
+	secret := make([]byte, 20)
+	_, err := rand.Read(secret)
+	return secret, err
+}
+
+func EncodeTOTPSecret(secret []byte) string {
+	return totpEncoding.EncodeToString(secret)
+}
+
+// The URI used to enroll the secret in authenticator apps.
+func TOTPURI(issuer, username string, secret []byte) string {
+	params := url.Values{}
+	params.Set("secret", EncodeTOTPSecret(secret))
+	params.Set("issuer", issuer)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprintf("%d", totpDigits))
+	params.Set("period", fmt.Sprintf("%d", totpPeriod))
+
+	return (&url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + issuer + ":" + username,
+		RawQuery: params.Encode(),
+	}).String()
+}
+
+// The HOTP code (RFC 4226) for the time step.
+func TOTPCode(secret []byte, step int64) string {
+	counter := make([]byte, 8)
+	binary.BigEndian.PutUint64(counter, uint64(step))
+
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(counter)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000)
+}
+
+func TOTPStep(now time.Time) int64 {
+	return now.Unix() / totpPeriod
+}
+
+// Check the code against the secret. Returns the matching time step
+// which must be recorded so codes can not be replayed: steps at or
+// before last_step are rejected.
+func ValidateTOTP(secret []byte, code string,
+	now time.Time, last_step int64) (int64, bool) {
+	if len(secret) == 0 || len(code) != totpDigits {
+		return 0, false
+	}
+
+	current := TOTPStep(now)
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if step <= last_step {
+			continue
+		}
+
+		if subtle.ConstantTimeCompare(
+			[]byte(TOTPCode(secret, step)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
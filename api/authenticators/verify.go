@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/Velocidex/ordereddict"
 	jwt "github.com/golang-jwt/jwt/v4"
@@ -62,6 +63,15 @@ func authenticateUserHandle(
 				return
 			}
 
+			// The cookie was checked above.
+			auth_cookie, _ := r.Cookie("VelociraptorAuth")
+			err = checkGUISession(config_obj, r, username, auth_cookie.Value,
+				time.Unix(int64(claims.Expires), 0))
+			if err != nil {
+				reject_cb(w, r, err, username)
+				return
+			}
+
 			// Does the user have access to the specified org?
 			err = CheckOrgAccess(config_obj, r, user_record, permission)
 			if err != nil {
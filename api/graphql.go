@@ -0,0 +1,100 @@
+package api
+
+import (
+	"net/http"
+
+	"www.velocidex.com/golang/velociraptor/api/authenticators"
+	"www.velocidex.com/golang/velociraptor/api/graphql"
+	api_utils "www.velocidex.com/golang/velociraptor/api/utils"
+	config_proto "www.velocidex.com/golang/velociraptor/config/proto"
+	"www.velocidex.com/golang/velociraptor/json"
+	"www.velocidex.com/golang/velociraptor/services"
+	"www.velocidex.com/golang/velociraptor/utils"
+)
+
+const maxGraphQLRequestSize = 1024 * 1024
+
+// A read only GraphQL endpoint over clients, flows, hunts and their
+// results. Queries are POSTed as JSON or passed in the query string
+// of a GET. A GET without a query returns the schema.
+func graphQLHandler(config_obj *config_proto.Config) http.Handler {
+	return api_utils.HandlerFunc(nil,
+		func(w http.ResponseWriter, r *http.Request) {
+			org_id := authenticators.GetOrgIdFromRequest(r)
+			org_manager, err := services.GetOrgManager()
+			if err != nil {
+				returnError(config_obj, w, http.StatusUnauthorized, err)
+				return
+			}
+
+			org_config_obj, err := org_manager.GetOrgConfig(org_id)
+			if err != nil {
+				returnError(config_obj, w, http.StatusUnauthorized, err)
+				return
+			}
+
+			request := &graphql.Request{}
+			switch r.Method {
+			case http.MethodGet:
+				request.Query = r.URL.Query().Get("query")
+				request.OperationName = r.URL.Query().Get("operationName")
+				variables := r.URL.Query().Get("variables")
+				if variables != "" {
+					err = json.Unmarshal([]byte(variables), &request.Variables)
+					if err != nil {
+						returnError(config_obj, w, http.StatusBadRequest,
+							utils.InvalidArgError)
+						return
+					}
+				}
+
+				if request.Query == "" {
+					schema, err := graphql.GetSchema()
+					if err != nil {
+						returnError(config_obj, w, http.StatusInternalServerError, err)
+						return
+					}
+					w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+					_, _ = w.Write([]byte(schema.SDL()))
+					return
+				}
+
+			case http.MethodPost:
+				body, err := utils.ReadAllWithLimit(r.Body, maxGraphQLRequestSize)
+				if err != nil {
+					returnError(config_obj, w, http.StatusBadRequest, err)
+					return
+				}
+
+				err = json.Unmarshal(body, request)
+				if err != nil {
+					returnError(config_obj, w, http.StatusBadRequest,
+						utils.InvalidArgError)
+					return
+				}
+
+			default:
+				returnError(config_obj, w, http.StatusMethodNotAllowed,
+					utils.InvalidArgError)
+				return
+			}
+
+			principal := GetUserInfo(r.Context(), org_config_obj).Name
+			response := graphql.Execute(
+				r.Context(), org_config_obj, principal, request)
+
+			serialized, err := json.Marshal(response)
+			if err != nil {
+				returnError(config_obj, w, http.StatusInternalServerError, err)
+				return
+			}
+
+			// Per the GraphQL over HTTP spec, requests which fail
+			// before execution are client errors.
+			w.Header().Set("Content-Type", "application/json")
+			if response.Data == nil {
+				w.WriteHeader(http.StatusBadRequest)
+			}
+			_, _ = w.Write(serialized)
+		})
+}
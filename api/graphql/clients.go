@@ -0,0 +1,201 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Velocidex/ordereddict"
+	"www.velocidex.com/golang/velociraptor/acls"
+	api_proto "www.velocidex.com/golang/velociraptor/api/proto"
+	"www.velocidex.com/golang/velociraptor/api/tables"
+	"www.velocidex.com/golang/velociraptor/services"
+	"www.velocidex.com/golang/velociraptor/utils"
+)
+
+func clientType() *Object {
+	return &Object{
+		Name: "Client",
+		Fields: []*Field{
+			clientField("client_id", String, "", func(c *api_proto.ApiClient) interface{} {
+				return c.ClientId
+			}),
+			clientField("hostname", String, "", func(c *api_proto.ApiClient) interface{} {
+				return c.GetOsInfo().GetHostname()
+			}),
+			clientField("fqdn", String, "", func(c *api_proto.ApiClient) interface{} {
+				return c.GetOsInfo().GetFqdn()
+			}),
+			clientField("os", String, "", func(c *api_proto.ApiClient) interface{} {
+				return c.GetOsInfo().GetSystem()
+			}),
+			clientField("release", String, "", func(c *api_proto.ApiClient) interface{} {
+				return c.GetOsInfo().GetRelease()
+			}),
+			clientField("architecture", String, "", func(c *api_proto.ApiClient) interface{} {
+				return c.GetOsInfo().GetMachine()
+			}),
+			clientField("mac_addresses", "[String]", "", func(c *api_proto.ApiClient) interface{} {
+				return c.GetOsInfo().GetMacAddresses()
+			}),
+			clientField("client_version", String, "", func(c *api_proto.ApiClient) interface{} {
+				return c.GetAgentInformation().GetVersion()
+			}),
+			clientField("labels", "[String]", "", func(c *api_proto.ApiClient) interface{} {
+				return c.Labels
+			}),
+			clientField("first_seen_at", Int, "Seconds since the epoch.",
+				func(c *api_proto.ApiClient) interface{} {
+					return c.FirstSeenAt
+				}),
+			clientField("last_seen_at", Int, "Microseconds since the epoch.",
+				func(c *api_proto.ApiClient) interface{} {
+					return c.LastSeenAt
+				}),
+			clientField("last_ip", String, "", func(c *api_proto.ApiClient) interface{} {
+				return c.LastIp
+			}),
+			{
+				Name:        "flows",
+				Type:        "FlowConnection",
+				Description: "The client's collections, most recent first.",
+				Arguments:   pagingArguments(),
+				Permission:  acls.READ_RESULTS,
+				Resolve:     resolveClientFlows,
+			},
+		},
+	}
+}
+
+func clientField(name, typ, description string,
+	getter func(c *api_proto.ApiClient) interface{}) *Field {
+	return &Field{
+		Name:        name,
+		Type:        typ,
+		Description: description,
+		Resolve: func(ctx context.Context,
+			parent interface{}, args *ordereddict.Dict) (interface{}, error) {
+			client, ok := parent.(*api_proto.ApiClient)
+			if !ok {
+				return nil, unexpectedParent(parent)
+			}
+			return getter(client), nil
+		},
+	}
+}
+
+func resolveClients(ctx context.Context,
+	parent interface{}, args *ordereddict.Dict) (interface{}, error) {
+	request, err := getRequestContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	start, rows, err := getPaging(args)
+	if err != nil {
+		return nil, err
+	}
+
+	search, _ := getString(args, "search")
+	if search == "" {
+		search = "all"
+	}
+
+	indexer, err := services.GetIndexer(request.config_obj)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := indexer.SearchClients(ctx, request.config_obj,
+		&api_proto.SearchClientsRequest{
+			Query:  search,
+			Offset: uint64(start),
+			Limit:  uint64(rows),
+		}, request.principal)
+	if err != nil {
+		return nil, err
+	}
+
+	return &connection{
+		start: start,
+		total: int64(result.Total),
+		items: result.Items,
+		count: len(result.Items),
+	}, nil
+}
+
+func resolveClient(ctx context.Context,
+	parent interface{}, args *ordereddict.Dict) (interface{}, error) {
+	request, err := getRequestContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	client_id, _ := getString(args, "client_id")
+	return getClient(ctx, request, client_id)
+}
+
+// Unknown clients resolve to null rather than an error.
+func getClient(ctx context.Context,
+	request *requestContext, client_id string) (interface{}, error) {
+	indexer, err := services.GetIndexer(request.config_obj)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := indexer.FastGetApiClient(ctx, request.config_obj, client_id)
+	if errors.Is(err, utils.NotFoundError) {
+		return nil, nil
+	}
+	return client, err
+}
+
+func resolveClientFlows(ctx context.Context,
+	parent interface{}, args *ordereddict.Dict) (interface{}, error) {
+	client, ok := parent.(*api_proto.ApiClient)
+	if !ok {
+		return nil, unexpectedParent(parent)
+	}
+	return getFlows(ctx, client.ClientId, args)
+}
+
+func getFlows(ctx context.Context,
+	client_id string, args *ordereddict.Dict) (interface{}, error) {
+	request, err := getRequestContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	start, rows, err := getPaging(args)
+	if err != nil {
+		return nil, err
+	}
+
+	launcher, err := services.GetLauncher(request.config_obj)
+	if err != nil {
+		return nil, err
+	}
+
+	// Flow Ids have times encoded in them so they sort
+	// chronologically.
+	options, err := tables.GetTableOptions(&api_proto.GetTableRequest{
+		SortColumn:    "FlowId",
+		SortDirection: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := launcher.GetFlows(ctx, request.config_obj,
+		client_id, options, services.GetFlowOptions{Request: true},
+		start, rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return &connection{
+		start: start,
+		total: int64(result.Total),
+		items: result.Items,
+		count: len(result.Items),
+	}, nil
+}
@@ -0,0 +1,780 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+
+	"github.com/Velocidex/ordereddict"
+	"www.velocidex.com/golang/velociraptor/acls"
+	"www.velocidex.com/golang/velociraptor/utils"
+)
+
+const (
+	// Deeply nested queries fan out to many service calls.
+	maxDepth = 10
+
+	// Aliases and fragments can repeat expensive fields many times
+	// at the same level.
+	maxFields = 500
+
+	// The estimated number of fields the query resolves. Each
+	// connection multiplies the cost of its selections by the rows
+	// requested.
+	maxCost = 50000
+)
+
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// Data is not present when the request fails before execution.
+type Response struct {
+	Data   *ordereddict.Dict `json:"data,omitempty"`
+	Errors []*Error          `json:"errors,omitempty"`
+}
+
+// Decides if the caller may resolve fields which require the
+// permission.
+type AccessChecker func(permission acls.ACL_PERMISSION) (bool, error)
+
+type executor struct {
+	schema    *Schema
+	doc       *document
+	variables map[string]interface{}
+	checker   AccessChecker
+
+	// Permission checks are cached for the duration of the request.
+	access map[acls.ACL_PERMISSION]bool
+
+	// The number of fields selected by the query.
+	field_count int
+
+	errors []*Error
+}
+
+func (self *Schema) Execute(
+	ctx context.Context, request *Request, checker AccessChecker) *Response {
+
+	doc, err := parse(request.Query)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	op, err := selectOperation(doc, request.OperationName)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	if op.kind != "query" {
+		return errorResponse(newError(op.location,
+			"Only queries are supported: the GraphQL endpoint is read-only"))
+	}
+
+	exec := &executor{
+		schema:  self,
+		doc:     doc,
+		checker: checker,
+		access:  make(map[acls.ACL_PERMISSION]bool),
+	}
+
+	exec.validate(op)
+	if len(exec.errors) > 0 {
+		return &Response{Errors: exec.errors}
+	}
+
+	exec.variables, err = coerceVariables(op, request.Variables)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	// Reject expensive queries before resolving anything.
+	if exec.queryCost(self.query, op.selections, 1) > maxCost {
+		return errorResponse(newError(op.location,
+			"Query is too expensive: it may resolve more than %v fields. Request fewer rows or fields.",
+			maxCost))
+	}
+
+	data := exec.executeSelectionSet(ctx, self.query, nil, op.selections, nil)
+	return &Response{Data: data, Errors: exec.errors}
+}
+
+func errorResponse(err error) *Response {
+	gql_err, ok := err.(*Error)
+	if !ok {
+		gql_err = &Error{Message: err.Error()}
+	}
+	return &Response{Errors: []*Error{gql_err}}
+}
+
+func selectOperation(doc *document, name string) (*operation, error) {
+	if name == "" {
+		if len(doc.operations) > 1 {
+			return nil, &Error{
+				Message: "Must provide operation name if query contains multiple operations",
+			}
+		}
+		return doc.operations[0], nil
+	}
+
+	for _, op := range doc.operations {
+		if op.name == name {
+			return op, nil
+		}
+	}
+	return nil, &Error{Message: fmt.Sprintf("Unknown operation named %q", name)}
+}
+
+func coerceVariables(op *operation,
+	values map[string]interface{}) (map[string]interface{}, error) {
+	result := make(map[string]interface{})
+
+	for _, definition := range op.variables {
+		value, pres := values[definition.name]
+		if !pres {
+			if definition.has_default {
+				value, err := coerceInput(definition.typ, definition.default_value)
+				if err != nil {
+					return nil, &Error{Message: fmt.Sprintf(
+						"Variable \"$%v\" has invalid default value: %v",
+						definition.name, err)}
+				}
+				result[definition.name] = value
+				continue
+			}
+
+			if definition.typ.non_null {
+				return nil, &Error{Message: fmt.Sprintf(
+					"Variable \"$%v\" of required type \"%v\" was not provided.",
+					definition.name, definition.typ)}
+			}
+			continue
+		}
+
+		value, err := coerceInput(definition.typ, value)
+		if err != nil {
+			return nil, &Error{Message: fmt.Sprintf(
+				"Variable \"$%v\" got invalid value: %v", definition.name, err)}
+		}
+		result[definition.name] = value
+	}
+
+	return result, nil
+}
+
+func (self *executor) addError(err *Error) {
+	self.errors = append(self.errors, err)
+}
+
+func (self *executor) validate(op *operation) {
+	defined := make(map[string]bool)
+	for _, definition := range op.variables {
+		if !self.schema.isScalar(definition.typ.namedType()) {
+			self.addError(newError(op.location,
+				"Variable \"$%v\" cannot be non-input type \"%v\".",
+				definition.name, definition.typ))
+		}
+		defined[definition.name] = true
+	}
+
+	self.validateSelections(self.schema.query, op.selections,
+		defined, 1, make(map[string]bool))
+}
+
+func (self *executor) validateSelections(
+	obj *Object, selections []selection,
+	defined map[string]bool, depth int, visiting map[string]bool) {
+
+	if depth > maxDepth {
+		if len(selections) > 0 {
+			self.addError(newError(selectionLocation(selections[0]),
+				"Query is nested deeper than %v levels", maxDepth))
+		}
+		return
+	}
+
+	for _, sel := range selections {
+		if self.field_count > maxFields {
+			return
+		}
+
+		switch t := sel.(type) {
+		case *field:
+			self.field_count++
+			if self.field_count > maxFields {
+				self.addError(newError(t.location,
+					"Query selects more than %v fields", maxFields))
+				return
+			}
+
+			self.validateDirectives(t.directives, defined)
+			self.validateField(obj, t, defined, depth, visiting)
+
+		case *inlineFragment:
+			self.validateDirectives(t.directives, defined)
+			if t.type_condition != "" && t.type_condition != obj.Name {
+				self.addError(newError(t.location,
+					"Fragment cannot be spread here as objects of type \"%v\" can never be of type \"%v\".",
+					obj.Name, t.type_condition))
+				continue
+			}
+			self.validateSelections(obj, t.selections, defined, depth, visiting)
+
+		case *fragmentSpread:
+			self.validateDirectives(t.directives, defined)
+			frag, pres := self.doc.fragments[t.name]
+			if !pres {
+				self.addError(newError(t.location, "Unknown fragment %q.", t.name))
+				continue
+			}
+
+			if visiting[t.name] {
+				self.addError(newError(t.location,
+					"Cannot spread fragment %q within itself.", t.name))
+				continue
+			}
+
+			if frag.type_condition != obj.Name {
+				self.addError(newError(t.location,
+					"Fragment %q cannot be spread here as objects of type \"%v\" can never be of type \"%v\".",
+					t.name, obj.Name, frag.type_condition))
+				continue
+			}
+
+			visiting[t.name] = true
+			self.validateSelections(obj, frag.selections, defined, depth, visiting)
+			delete(visiting, t.name)
+		}
+	}
+}
+
+func (self *executor) validateField(
+	obj *Object, f *field,
+	defined map[string]bool, depth int, visiting map[string]bool) {
+
+	if f.name == "__typename" {
+		if len(f.selections) > 0 {
+			self.addError(newError(f.location,
+				"Field \"__typename\" must not have a selection since type \"String\" has no subfields."))
+		}
+		return
+	}
+
+	definition := obj.field(f.name)
+	if definition == nil {
+		self.addError(newError(f.location,
+			"Cannot query field %q on type %q.", f.name, obj.Name))
+		return
+	}
+
+	for _, arg := range f.arguments {
+		if definition.argument(arg.name) == nil {
+			self.addError(newError(arg.location,
+				"Unknown argument %q on field \"%v.%v\".",
+				arg.name, obj.Name, f.name))
+		}
+		self.validateVariables(arg.location, arg.value, defined)
+	}
+
+	for _, arg := range definition.Arguments {
+		if !arg.typ.non_null || arg.Default != nil {
+			continue
+		}
+
+		provided := false
+		for _, a := range f.arguments {
+			if a.name == arg.Name && a.value != nil {
+				provided = true
+			}
+		}
+
+		if !provided {
+			self.addError(newError(f.location,
+				"Field \"%v\" argument \"%v\" of type \"%v\" is required, but it was not provided.",
+				f.name, arg.Name, arg.Type))
+		}
+	}
+
+	named_type := definition.typ.namedType()
+	child, is_object := self.schema.objects[named_type]
+	if !is_object && len(f.selections) > 0 {
+		self.addError(newError(f.location,
+			"Field %q must not have a selection since type %q has no subfields.",
+			f.name, definition.Type))
+		return
+	}
+
+	if is_object && len(f.selections) == 0 {
+		self.addError(newError(f.location,
+			"Field %q of type %q must have a selection of subfields.",
+			f.name, definition.Type))
+		return
+	}
+
+	if is_object {
+		self.validateSelections(child, f.selections, defined, depth+1, visiting)
+	}
+}
+
+func (self *executor) validateDirectives(
+	directives []*directive, defined map[string]bool) {
+	for _, d := range directives {
+		if d.name != "skip" && d.name != "include" {
+			self.addError(newError(d.location, "Unknown directive \"@%v\".", d.name))
+			continue
+		}
+
+		if len(d.arguments) != 1 || d.arguments[0].name != "if" {
+			self.addError(newError(d.location,
+				"Directive \"@%v\" argument \"if\" of type \"Boolean!\" is required.",
+				d.name))
+			continue
+		}
+		self.validateVariables(d.location, d.arguments[0].value, defined)
+	}
+}
+
+func (self *executor) validateVariables(
+	location Location, value interface{}, defined map[string]bool) {
+	switch t := value.(type) {
+	case variableRef:
+		if !defined[string(t)] {
+			self.addError(newError(location,
+				"Variable \"$%v\" is not defined.", string(t)))
+		}
+
+	case []interface{}:
+		for _, item := range t {
+			self.validateVariables(location, item, defined)
+		}
+
+	case *ordereddict.Dict:
+		for _, k := range t.Keys() {
+			item, _ := t.Get(k)
+			self.validateVariables(location, item, defined)
+		}
+	}
+}
+
+// Estimate the number of fields the query resolves. Fields which
+// take a rows argument return that many items so their selections
+// are resolved that many times. Stops counting once the cost exceeds
+// maxCost.
+func (self *executor) queryCost(
+	obj *Object, selections []selection, multiplier int64) int64 {
+
+	fields := ordereddict.NewDict()
+	self.collectFields(obj, selections, fields, make(map[string]bool))
+
+	cost := int64(0)
+	for _, key := range fields.Keys() {
+		item, _ := fields.Get(key)
+		same_fields := item.([]*field)
+
+		cost += multiplier
+		if cost > maxCost {
+			return cost
+		}
+
+		f := same_fields[0]
+		definition := obj.field(f.name)
+		if definition == nil {
+			continue
+		}
+
+		child, pres := self.schema.objects[definition.typ.namedType()]
+		if !pres {
+			continue
+		}
+
+		// Invalid paging arguments fail when the field is resolved.
+		child_multiplier := multiplier
+		if definition.argument("rows") != nil {
+			args, err := self.coerceArguments(definition, f)
+			if err == nil {
+				_, rows, err := getPaging(args)
+				if err == nil {
+					child_multiplier *= rows
+				}
+			}
+		}
+
+		var child_selections []selection
+		for _, f := range same_fields {
+			child_selections = append(child_selections, f.selections...)
+		}
+
+		cost += self.queryCost(child, child_selections, child_multiplier)
+		if cost > maxCost {
+			return cost
+		}
+	}
+	return cost
+}
+
+func selectionLocation(sel selection) Location {
+	switch t := sel.(type) {
+	case *field:
+		return t.location
+	case *inlineFragment:
+		return t.location
+	case *fragmentSpread:
+		return t.location
+	}
+	return Location{}
+}
+
+// Group the fields by their response key, expanding fragments.
+func (self *executor) collectFields(
+	obj *Object, selections []selection,
+	result *ordereddict.Dict, visited map[string]bool) {
+
+	for _, sel := range selections {
+		switch t := sel.(type) {
+		case *field:
+			if !self.shouldInclude(t.directives) {
+				continue
+			}
+
+			key := t.responseKey()
+			existing, _ := result.Get(key)
+			fields, _ := existing.([]*field)
+			result.Set(key, append(fields, t))
+
+		case *inlineFragment:
+			if !self.shouldInclude(t.directives) {
+				continue
+			}
+			self.collectFields(obj, t.selections, result, visited)
+
+		case *fragmentSpread:
+			if visited[t.name] || !self.shouldInclude(t.directives) {
+				continue
+			}
+			visited[t.name] = true
+
+			frag, pres := self.doc.fragments[t.name]
+			if pres {
+				self.collectFields(obj, frag.selections, result, visited)
+			}
+		}
+	}
+}
+
+func (self *executor) shouldInclude(directives []*directive) bool {
+	for _, d := range directives {
+		if len(d.arguments) == 0 {
+			continue
+		}
+
+		value, _ := self.valueFromAST(d.arguments[0].value).(bool)
+		switch d.name {
+		case "skip":
+			if value {
+				return false
+			}
+		case "include":
+			if !value {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (self *executor) executeSelectionSet(
+	ctx context.Context, obj *Object, parent interface{},
+	selections []selection, path []interface{}) *ordereddict.Dict {
+
+	fields := ordereddict.NewDict()
+	self.collectFields(obj, selections, fields, make(map[string]bool))
+
+	result := ordereddict.NewDict()
+	for _, key := range fields.Keys() {
+		item, _ := fields.Get(key)
+		field_path := append(path[:len(path):len(path)], key)
+		result.Set(key, self.executeField(
+			ctx, obj, parent, item.([]*field), field_path))
+	}
+	return result
+}
+
+func (self *executor) executeField(
+	ctx context.Context, obj *Object, parent interface{},
+	fields []*field, path []interface{}) interface{} {
+
+	f := fields[0]
+	if f.name == "__typename" {
+		return obj.Name
+	}
+
+	definition := obj.field(f.name)
+	if definition == nil {
+		return nil
+	}
+
+	fieldError := func(format string, args ...interface{}) interface{} {
+		err := newError(f.location, format, args...)
+		err.Path = path
+		self.addError(err)
+		return nil
+	}
+
+	if definition.Permission != acls.NO_PERMISSIONS &&
+		!self.checkAccess(definition.Permission) {
+		return fieldError("PermissionDenied: %v.%v requires the %v permission",
+			obj.Name, definition.Name, definition.Permission)
+	}
+
+	args, err := self.coerceArguments(definition, f)
+	if err != nil {
+		return fieldError("%v", err)
+	}
+
+	value, err := definition.Resolve(ctx, parent, args)
+	if err != nil {
+		return fieldError("%v", err)
+	}
+
+	return self.completeValue(ctx, definition.typ, fields, value, path)
+}
+
+func (self *executor) checkAccess(permission acls.ACL_PERMISSION) bool {
+	ok, pres := self.access[permission]
+	if !pres {
+		var err error
+		ok, err = self.checker(permission)
+		if err != nil {
+			ok = false
+		}
+		self.access[permission] = ok
+	}
+	return ok
+}
+
+func (self *executor) completeValue(
+	ctx context.Context, typ *typeRef, fields []*field,
+	value interface{}, path []interface{}) interface{} {
+
+	if utils.IsNil(value) {
+		return nil
+	}
+
+	if typ.elem != nil {
+		v := reflect.ValueOf(value)
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			err := newError(fields[0].location,
+				"Expected a list for field %q", fields[0].name)
+			err.Path = path
+			self.addError(err)
+			return nil
+		}
+
+		result := make([]interface{}, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			item_path := append(path[:len(path):len(path)], i)
+			result = append(result, self.completeValue(
+				ctx, typ.elem, fields, v.Index(i).Interface(), item_path))
+		}
+		return result
+	}
+
+	obj, pres := self.schema.objects[typ.name]
+	if pres {
+		var selections []selection
+		for _, f := range fields {
+			selections = append(selections, f.selections...)
+		}
+		return self.executeSelectionSet(ctx, obj, value, selections, path)
+	}
+
+	return serializeScalar(typ.name, value)
+}
+
+func serializeScalar(typ string, value interface{}) interface{} {
+	switch typ {
+	case Int:
+		v := reflect.ValueOf(value)
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return v.Int()
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return v.Uint()
+		case reflect.Float32, reflect.Float64:
+			return int64(v.Float())
+		}
+
+	case Float:
+		v := reflect.ValueOf(value)
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return float64(v.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return float64(v.Uint())
+		case reflect.Float32, reflect.Float64:
+			return v.Float()
+		}
+
+	case String:
+		switch t := value.(type) {
+		case string:
+			return t
+		case fmt.Stringer:
+			return t.String()
+		}
+		return fmt.Sprintf("%v", value)
+
+	case Boolean:
+		b, ok := value.(bool)
+		if ok {
+			return b
+		}
+	}
+
+	return value
+}
+
+func (self *executor) coerceArguments(
+	definition *Field, f *field) (*ordereddict.Dict, error) {
+	result := ordereddict.NewDict()
+
+	for _, arg := range definition.Arguments {
+		var value interface{}
+		provided := false
+
+		for _, a := range f.arguments {
+			if a.name != arg.Name {
+				continue
+			}
+
+			// A variable which was not provided is the same as a
+			// missing argument.
+			name, is_var := a.value.(variableRef)
+			if is_var {
+				_, provided = self.variables[string(name)]
+			} else {
+				provided = true
+			}
+			value = self.valueFromAST(a.value)
+		}
+
+		if !provided {
+			if arg.Default != nil {
+				result.Set(arg.Name, arg.Default)
+				continue
+			}
+
+			if arg.typ.non_null {
+				return nil, fmt.Errorf(
+					"Argument %q of required type %q was not provided.",
+					arg.Name, arg.Type)
+			}
+			continue
+		}
+
+		coerced, err := coerceInput(arg.typ, value)
+		if err != nil {
+			return nil, fmt.Errorf("Argument %q has invalid value: %v",
+				arg.Name, err)
+		}
+		result.Set(arg.Name, coerced)
+	}
+
+	return result, nil
+}
+
+// Substitute variables in a value from the query.
+func (self *executor) valueFromAST(value interface{}) interface{} {
+	switch t := value.(type) {
+	case variableRef:
+		return self.variables[string(t)]
+
+	case []interface{}:
+		result := make([]interface{}, 0, len(t))
+		for _, item := range t {
+			result = append(result, self.valueFromAST(item))
+		}
+		return result
+
+	case *ordereddict.Dict:
+		result := ordereddict.NewDict()
+		for _, k := range t.Keys() {
+			item, _ := t.Get(k)
+			result.Set(k, self.valueFromAST(item))
+		}
+		return result
+	}
+	return value
+}
+
+func coerceInput(typ *typeRef, value interface{}) (interface{}, error) {
+	if utils.IsNil(value) {
+		if typ.non_null {
+			return nil, fmt.Errorf("Expected non-nullable type %q not to be null", typ)
+		}
+		return nil, nil
+	}
+
+	if typ.elem != nil {
+		items, ok := value.([]interface{})
+		if !ok {
+			// A single value is accepted as a list of one.
+			items = []interface{}{value}
+		}
+
+		result := make([]interface{}, 0, len(items))
+		for _, item := range items {
+			coerced, err := coerceInput(typ.elem, item)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, coerced)
+		}
+		return result, nil
+	}
+
+	switch typ.name {
+	case Int:
+		switch t := value.(type) {
+		case int64:
+			return t, nil
+		case int:
+			return int64(t), nil
+		case float64:
+			if t == math.Trunc(t) && math.Abs(t) < math.MaxInt64 {
+				return int64(t), nil
+			}
+		}
+
+	case Float:
+		switch t := value.(type) {
+		case int64:
+			return float64(t), nil
+		case int:
+			return float64(t), nil
+		case float64:
+			return t, nil
+		}
+
+	case String:
+		s, ok := value.(string)
+		if ok {
+			return s, nil
+		}
+
+	case Boolean:
+		b, ok := value.(bool)
+		if ok {
+			return b, nil
+		}
+
+	case JSON:
+		return value, nil
+	}
+
+	return nil, fmt.Errorf("%v cannot represent %v", typ.name,
+		utils.ToString(value))
+}
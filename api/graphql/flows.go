@@ -0,0 +1,251 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Velocidex/ordereddict"
+	"www.velocidex.com/golang/velociraptor/acls"
+	api_proto "www.velocidex.com/golang/velociraptor/api/proto"
+	"www.velocidex.com/golang/velociraptor/api/tables"
+	flows_proto "www.velocidex.com/golang/velociraptor/flows/proto"
+	"www.velocidex.com/golang/velociraptor/json"
+	"www.velocidex.com/golang/velociraptor/services"
+	"www.velocidex.com/golang/velociraptor/utils"
+)
+
+func flowType() *Object {
+	return &Object{
+		Name: "Flow",
+		Fields: []*Field{
+			flowField("flow_id", String, "", func(f *flows_proto.ArtifactCollectorContext) interface{} {
+				return f.SessionId
+			}),
+			flowField("client_id", String, "", func(f *flows_proto.ArtifactCollectorContext) interface{} {
+				return f.ClientId
+			}),
+			{
+				Name:        "client",
+				Type:        "Client",
+				Description: "Null for server collections.",
+				Resolve:     resolveFlowClient,
+			},
+			flowField("state", String, "", func(f *flows_proto.ArtifactCollectorContext) interface{} {
+				return f.State.String()
+			}),
+			flowField("status", String, "The error message of failed collections.",
+				func(f *flows_proto.ArtifactCollectorContext) interface{} {
+					return f.Status
+				}),
+			flowField("artifacts", "[String]", "", func(f *flows_proto.ArtifactCollectorContext) interface{} {
+				return f.GetRequest().GetArtifacts()
+			}),
+			flowField("artifacts_with_results", "[String]", "",
+				func(f *flows_proto.ArtifactCollectorContext) interface{} {
+					return f.ArtifactsWithResults
+				}),
+			flowField("creator", String, "", func(f *flows_proto.ArtifactCollectorContext) interface{} {
+				return f.GetRequest().GetCreator()
+			}),
+			flowField("create_time", Int, "Microseconds since the epoch.",
+				func(f *flows_proto.ArtifactCollectorContext) interface{} {
+					return f.CreateTime
+				}),
+			flowField("start_time", Int, "Microseconds since the epoch.",
+				func(f *flows_proto.ArtifactCollectorContext) interface{} {
+					return f.StartTime
+				}),
+			flowField("active_time", Int, "Microseconds since the epoch.",
+				func(f *flows_proto.ArtifactCollectorContext) interface{} {
+					return f.ActiveTime
+				}),
+			flowField("total_collected_rows", Int, "",
+				func(f *flows_proto.ArtifactCollectorContext) interface{} {
+					return f.TotalCollectedRows
+				}),
+			flowField("total_uploaded_files", Int, "",
+				func(f *flows_proto.ArtifactCollectorContext) interface{} {
+					return f.TotalUploadedFiles
+				}),
+			flowField("total_uploaded_bytes", Int, "",
+				func(f *flows_proto.ArtifactCollectorContext) interface{} {
+					return f.TotalUploadedBytes
+				}),
+			flowField("total_logs", Int, "", func(f *flows_proto.ArtifactCollectorContext) interface{} {
+				return f.TotalLogs
+			}),
+			{
+				Name:        "results",
+				Type:        "ResultConnection",
+				Description: "The rows an artifact returned in this collection.",
+				Arguments:   resultsArguments(),
+				Permission:  acls.READ_RESULTS,
+				Resolve:     resolveFlowResults,
+			},
+		},
+	}
+}
+
+func flowField(name, typ, description string,
+	getter func(f *flows_proto.ArtifactCollectorContext) interface{}) *Field {
+	return &Field{
+		Name:        name,
+		Type:        typ,
+		Description: description,
+		Resolve: func(ctx context.Context,
+			parent interface{}, args *ordereddict.Dict) (interface{}, error) {
+			flow, ok := parent.(*flows_proto.ArtifactCollectorContext)
+			if !ok {
+				return nil, unexpectedParent(parent)
+			}
+			return getter(flow), nil
+		},
+	}
+}
+
+func resolveFlow(ctx context.Context,
+	parent interface{}, args *ordereddict.Dict) (interface{}, error) {
+	request, err := getRequestContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	client_id, _ := getString(args, "client_id")
+	flow_id, _ := getString(args, "flow_id")
+
+	launcher, err := services.GetLauncher(request.config_obj)
+	if err != nil {
+		return nil, err
+	}
+
+	details, err := launcher.GetFlowDetails(ctx, request.config_obj,
+		services.GetFlowOptions{Request: true}, client_id, flow_id)
+	if errors.Is(err, utils.NotFoundError) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return details.Context, nil
+}
+
+func resolveFlowClient(ctx context.Context,
+	parent interface{}, args *ordereddict.Dict) (interface{}, error) {
+	flow, ok := parent.(*flows_proto.ArtifactCollectorContext)
+	if !ok {
+		return nil, unexpectedParent(parent)
+	}
+
+	if flow.ClientId == "server" {
+		return nil, nil
+	}
+
+	request, err := getRequestContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return getClient(ctx, request, flow.ClientId)
+}
+
+func resolveFlowResults(ctx context.Context,
+	parent interface{}, args *ordereddict.Dict) (interface{}, error) {
+	flow, ok := parent.(*flows_proto.ArtifactCollectorContext)
+	if !ok {
+		return nil, unexpectedParent(parent)
+	}
+
+	return getResults(ctx, args, &api_proto.GetTableRequest{
+		ClientId: flow.ClientId,
+		FlowId:   flow.SessionId,
+	})
+}
+
+func resultsArguments() []*Argument {
+	return append([]*Argument{{
+		Name:        "artifact",
+		Type:        "String!",
+		Description: "The artifact, or artifact/source for artifacts with sources.",
+	}}, pagingArguments()...)
+}
+
+func resultsType() *Object {
+	return &Object{
+		Name:        "ResultConnection",
+		Description: "A page of result rows.",
+		Fields: []*Field{{
+			Name:        "total",
+			Type:        Int,
+			Description: "The total number of rows.",
+			Resolve: connectionField(func(c *connection) interface{} {
+				return c.total
+			}),
+		}, {
+			Name:        "next_cursor",
+			Type:        String,
+			Description: "Pass as the cursor to get the next page. Null on the last page.",
+			Resolve:     connectionField(nextCursor),
+		}, {
+			Name: "columns",
+			Type: "[String]",
+			Resolve: connectionField(func(c *connection) interface{} {
+				return c.columns
+			}),
+		}, {
+			Name:        "rows",
+			Type:        "[JSON]",
+			Description: "Each row is an object keyed by column.",
+			Resolve: connectionField(func(c *connection) interface{} {
+				return c.items
+			}),
+		}},
+	}
+}
+
+// Read a page of results through the same code as the GUI's tables
+// so the same access checks apply.
+func getResults(ctx context.Context, args *ordereddict.Dict,
+	in *api_proto.GetTableRequest) (interface{}, error) {
+	request, err := getRequestContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	start, rows, err := getPaging(args)
+	if err != nil {
+		return nil, err
+	}
+
+	in.Artifact, _ = getString(args, "artifact")
+	in.StartRow = uint64(start)
+	in.Rows = uint64(rows)
+
+	table, err := tables.GetTable(ctx, request.config_obj, in, request.principal)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*ordereddict.Dict, 0, len(table.Rows))
+	for _, row := range table.Rows {
+		var cells []interface{}
+		err := json.Unmarshal([]byte(row.Json), &cells)
+		if err != nil {
+			return nil, err
+		}
+
+		item := ordereddict.NewDict()
+		for i, column := range table.Columns {
+			if i < len(cells) {
+				item.Set(column, cells[i])
+			}
+		}
+		items = append(items, item)
+	}
+
+	return &connection{
+		start:   start,
+		total:   table.TotalRows,
+		items:   items,
+		count:   len(items),
+		columns: table.Columns,
+	}, nil
+}
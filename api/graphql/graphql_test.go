@@ -0,0 +1,202 @@
+package graphql_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Velocidex/ordereddict"
+	"www.velocidex.com/golang/velociraptor/acls"
+	"www.velocidex.com/golang/velociraptor/api/graphql"
+	"www.velocidex.com/golang/velociraptor/json"
+	"www.velocidex.com/golang/velociraptor/vtesting/assert"
+	"www.velocidex.com/golang/velociraptor/vtesting/goldie"
+)
+
+type book struct {
+	title   string
+	pages   int
+	authors []string
+}
+
+var books = []*book{
+	{title: "Dune", pages: 412, authors: []string{"Frank Herbert"}},
+	{title: "Good Omens", pages: 288,
+		authors: []string{"Terry Pratchett", "Neil Gaiman"}},
+}
+
+func bookField(name, typ string, getter func(b *book) interface{}) *graphql.Field {
+	return &graphql.Field{
+		Name: name,
+		Type: typ,
+		Resolve: func(ctx context.Context,
+			parent interface{}, args *ordereddict.Dict) (interface{}, error) {
+			return getter(parent.(*book)), nil
+		},
+	}
+}
+
+func makeTestSchema(t *testing.T) *graphql.Schema {
+	schema, err := graphql.NewSchema(&graphql.Object{
+		Name: "Query",
+		Fields: []*graphql.Field{{
+			Name: "books",
+			Type: "[Book]",
+			Arguments: []*graphql.Argument{{
+				Name:    "min_pages",
+				Type:    graphql.Int,
+				Default: int64(0),
+			}},
+			Resolve: func(ctx context.Context,
+				parent interface{}, args *ordereddict.Dict) (interface{}, error) {
+				min_pages, _ := args.Get("min_pages")
+				var result []*book
+				for _, b := range books {
+					if int64(b.pages) >= min_pages.(int64) {
+						result = append(result, b)
+					}
+				}
+				return result, nil
+			},
+		}, {
+			Name: "book",
+			Type: "Book",
+			Arguments: []*graphql.Argument{{
+				Name: "title",
+				Type: "String!",
+			}},
+			Resolve: func(ctx context.Context,
+				parent interface{}, args *ordereddict.Dict) (interface{}, error) {
+				title, _ := args.Get("title")
+				for _, b := range books {
+					if b.title == title {
+						return b, nil
+					}
+				}
+				return nil, fmt.Errorf("No book titled %v", title)
+			},
+		}, {
+			Name:       "secret",
+			Type:       graphql.String,
+			Permission: acls.SERVER_ADMIN,
+			Resolve: func(ctx context.Context,
+				parent interface{}, args *ordereddict.Dict) (interface{}, error) {
+				return "hunter2", nil
+			},
+		}},
+	}, &graphql.Object{
+		Name: "Book",
+		Fields: []*graphql.Field{
+			bookField("title", graphql.String, func(b *book) interface{} {
+				return b.title
+			}),
+			bookField("pages", graphql.Int, func(b *book) interface{} {
+				return b.pages
+			}),
+			bookField("authors", "[String]", func(b *book) interface{} {
+				return b.authors
+			}),
+		},
+	})
+	assert.NoError(t, err)
+	return schema
+}
+
+var executeTestCases = []struct {
+	name      string
+	query     string
+	variables map[string]interface{}
+}{
+	{"Shorthand", `{ books { title } }`, nil},
+	{"Aliases and arguments",
+		`{ long: books(min_pages: 300) { title pages } all: books { title } }`, nil},
+	{"Named fragment", `
+query Books {
+  books { ...Details }
+}
+fragment Details on Book { title authors }`, nil},
+	{"Inline fragment and typename",
+		`{ books { __typename ... on Book { pages } } }`, nil},
+	{"Variables with JSON numbers", `
+query ($min: Int) { books(min_pages: $min) { title } }`,
+		map[string]interface{}{"min": float64(300)}},
+	{"Skip and include", `
+query ($skip: Boolean!) {
+  books { title @skip(if: $skip) pages @include(if: false) }
+}`, map[string]interface{}{"skip": true}},
+	{"Resolver error nulls the field",
+		`{ book(title: "Missing") { title } books { title } }`, nil},
+	{"Field needs a permission", `{ secret books { title } }`, nil},
+	{"Syntax error", "{ books {\n  title\n", nil},
+	{"Mutations are rejected", `mutation { books { title } }`, nil},
+	{"Unknown field", `{ books { isbn } }`, nil},
+	{"Missing required argument", `{ book { title } }`, nil},
+	{"Missing sub selection", `{ books }`, nil},
+	{"Undefined variable", `{ books(min_pages: $min) { title } }`, nil},
+	{"Invalid variable", `query ($min: Int) { books(min_pages: $min) { title } }`,
+		map[string]interface{}{"min": "many"}},
+	{"Fragment cycle", `
+{ books { ...A } }
+fragment A on Book { ...B }
+fragment B on Book { ...A }`, nil},
+	{"Too many fields",
+		"{ books { " + strings.Repeat("title ", 501) + "} }", nil},
+}
+
+func TestExecute(t *testing.T) {
+	schema := makeTestSchema(t)
+
+	// Only READ_RESULTS is granted.
+	checker := func(permission acls.ACL_PERMISSION) (bool, error) {
+		return permission == acls.READ_RESULTS, nil
+	}
+
+	golden := ordereddict.NewDict()
+	for _, test_case := range executeTestCases {
+		response := schema.Execute(context.Background(),
+			&graphql.Request{
+				Query:     test_case.query,
+				Variables: test_case.variables,
+			}, checker)
+		golden.Set(test_case.name, response)
+	}
+
+	goldie.Assert(t, "TestExecute", json.MustMarshalIndent(golden))
+}
+
+func TestSDL(t *testing.T) {
+	schema := makeTestSchema(t)
+	goldie.Assert(t, "TestSDL", []byte(schema.SDL()))
+}
+
+func TestInvalidSchema(t *testing.T) {
+	resolver := func(ctx context.Context,
+		parent interface{}, args *ordereddict.Dict) (interface{}, error) {
+		return nil, nil
+	}
+
+	_, err := graphql.NewSchema(&graphql.Object{
+		Name: "Query",
+		Fields: []*graphql.Field{{
+			Name: "missing", Type: "Missing", Resolve: resolver,
+		}},
+	})
+	assert.ErrorContains(t, err, "unknown type Missing")
+
+	_, err = graphql.NewSchema(&graphql.Object{
+		Name: "Query",
+		Fields: []*graphql.Field{{
+			Name: "required", Type: "String!", Resolve: resolver,
+		}},
+	})
+	assert.ErrorContains(t, err, "may not be non null")
+
+	_, err = graphql.NewSchema(&graphql.Object{
+		Name: "Query",
+		Fields: []*graphql.Field{{
+			Name: "unresolved", Type: "String",
+		}},
+	})
+	assert.ErrorContains(t, err, "no resolver")
+}
@@ -0,0 +1,204 @@
+package graphql
+
+import (
+	"context"
+
+	"github.com/Velocidex/ordereddict"
+	"www.velocidex.com/golang/velociraptor/acls"
+	api_proto "www.velocidex.com/golang/velociraptor/api/proto"
+	"www.velocidex.com/golang/velociraptor/api/tables"
+	flows_proto "www.velocidex.com/golang/velociraptor/flows/proto"
+	"www.velocidex.com/golang/velociraptor/services"
+	vql_subsystem "www.velocidex.com/golang/velociraptor/vql"
+)
+
+func huntType() *Object {
+	return &Object{
+		Name: "Hunt",
+		Fields: []*Field{
+			huntField("hunt_id", String, "", func(h *api_proto.Hunt) interface{} {
+				return h.HuntId
+			}),
+			huntField("description", String, "", func(h *api_proto.Hunt) interface{} {
+				return h.HuntDescription
+			}),
+			huntField("creator", String, "", func(h *api_proto.Hunt) interface{} {
+				return h.Creator
+			}),
+			huntField("state", String, "", func(h *api_proto.Hunt) interface{} {
+				return h.State.String()
+			}),
+			huntField("tags", "[String]", "", func(h *api_proto.Hunt) interface{} {
+				return h.Tags
+			}),
+			huntField("artifacts", "[String]", "", func(h *api_proto.Hunt) interface{} {
+				return h.Artifacts
+			}),
+			huntField("create_time", Int, "Microseconds since the epoch.",
+				func(h *api_proto.Hunt) interface{} {
+					return h.CreateTime
+				}),
+			huntField("start_time", Int, "Microseconds since the epoch.",
+				func(h *api_proto.Hunt) interface{} {
+					return h.StartTime
+				}),
+			huntField("expires", Int, "Microseconds since the epoch.",
+				func(h *api_proto.Hunt) interface{} {
+					return h.Expires
+				}),
+			huntField("total_clients_scheduled", Int, "",
+				func(h *api_proto.Hunt) interface{} {
+					return h.GetStats().GetTotalClientsScheduled()
+				}),
+			huntField("total_clients_with_results", Int, "",
+				func(h *api_proto.Hunt) interface{} {
+					return h.GetStats().GetTotalClientsWithResults()
+				}),
+			huntField("total_clients_with_errors", Int, "",
+				func(h *api_proto.Hunt) interface{} {
+					return h.GetStats().GetTotalClientsWithErrors()
+				}),
+			{
+				Name:        "flows",
+				Type:        "FlowConnection",
+				Description: "The collections the hunt scheduled on each client.",
+				Arguments:   pagingArguments(),
+				Permission:  acls.READ_RESULTS,
+				Resolve:     resolveHuntFlows,
+			},
+		},
+	}
+}
+
+func huntField(name, typ, description string,
+	getter func(h *api_proto.Hunt) interface{}) *Field {
+	return &Field{
+		Name:        name,
+		Type:        typ,
+		Description: description,
+		Resolve: func(ctx context.Context,
+			parent interface{}, args *ordereddict.Dict) (interface{}, error) {
+			hunt, ok := parent.(*api_proto.Hunt)
+			if !ok {
+				return nil, unexpectedParent(parent)
+			}
+			return getter(hunt), nil
+		},
+	}
+}
+
+func resolveHunts(ctx context.Context,
+	parent interface{}, args *ordereddict.Dict) (interface{}, error) {
+	request, err := getRequestContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	start, rows, err := getPaging(args)
+	if err != nil {
+		return nil, err
+	}
+
+	hunt_dispatcher, err := services.GetHuntDispatcher(request.config_obj)
+	if err != nil {
+		return nil, err
+	}
+
+	options, err := tables.GetTableOptions(&api_proto.GetTableRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	hunts, total, err := hunt_dispatcher.GetHunts(ctx, request.config_obj,
+		options, services.GetHuntOptions{}, start, rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return &connection{
+		start: start,
+		total: total,
+		items: hunts,
+		count: len(hunts),
+	}, nil
+}
+
+func resolveHunt(ctx context.Context,
+	parent interface{}, args *ordereddict.Dict) (interface{}, error) {
+	request, err := getRequestContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	hunt_dispatcher, err := services.GetHuntDispatcher(request.config_obj)
+	if err != nil {
+		return nil, err
+	}
+
+	hunt_id, _ := getString(args, "hunt_id")
+	hunt, pres := hunt_dispatcher.GetHunt(ctx, services.GetHuntOptions{}, hunt_id)
+	if !pres {
+		return nil, nil
+	}
+	return hunt, nil
+}
+
+func resolveHuntFlows(ctx context.Context,
+	parent interface{}, args *ordereddict.Dict) (interface{}, error) {
+	hunt, ok := parent.(*api_proto.Hunt)
+	if !ok {
+		return nil, unexpectedParent(parent)
+	}
+
+	request, err := getRequestContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	start, rows, err := getPaging(args)
+	if err != nil {
+		return nil, err
+	}
+
+	hunt_dispatcher, err := services.GetHuntDispatcher(request.config_obj)
+	if err != nil {
+		return nil, err
+	}
+
+	// Stop reading the hunt's flows once we have a page.
+	sub_ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	scope := vql_subsystem.MakeScope()
+	defer scope.Close()
+
+	flow_chan, total, err := hunt_dispatcher.GetFlows(sub_ctx,
+		request.config_obj, services.FlowSearchOptions{}, scope,
+		hunt.HuntId, int(start))
+	if err != nil {
+		return nil, err
+	}
+
+	items := []*flows_proto.ArtifactCollectorContext{}
+	for flow_details := range flow_chan {
+		if flow_details.Context != nil {
+			items = append(items, flow_details.Context)
+		}
+
+		if int64(len(items)) >= rows {
+			cancel()
+			break
+		}
+	}
+
+	// Drain the channel so the reader can exit.
+	for range flow_chan {
+	}
+
+	return &connection{
+		start: start,
+		total: total,
+		items: items,
+		count: len(items),
+	}, nil
+}
@@ -0,0 +1,297 @@
+package graphql
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+const bom = "\uFEFF"
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPunctuator
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	line  int
+	col   int
+}
+
+func (self token) String() string {
+	if self.kind == tokenEOF {
+		return "end of query"
+	}
+	return fmt.Sprintf("%q", self.value)
+}
+
+type lexer struct {
+	input string
+	pos   int
+	line  int
+	col   int
+}
+
+func newLexer(input string) *lexer {
+	return &lexer{input: input, line: 1, col: 1}
+}
+
+func (self *lexer) errorf(format string, args ...interface{}) error {
+	return newError(Location{Line: self.line, Column: self.col},
+		"Syntax Error: "+format, args...)
+}
+
+func (self *lexer) advance(n int) {
+	for i := 0; i < n && self.pos < len(self.input); i++ {
+		if self.input[self.pos] == '\n' {
+			self.line++
+			self.col = 1
+		} else {
+			self.col++
+		}
+		self.pos++
+	}
+}
+
+// Whitespace, commas and comments are insignificant.
+func (self *lexer) skipIgnored() {
+	for self.pos < len(self.input) {
+		c := self.input[self.pos]
+		switch c {
+		case ' ', '\t', '\n', '\r', ',':
+			self.advance(1)
+
+		case '#':
+			for self.pos < len(self.input) && self.input[self.pos] != '\n' {
+				self.advance(1)
+			}
+
+		default:
+			// Skip the unicode BOM
+			if strings.HasPrefix(self.input[self.pos:], bom) {
+				self.pos += len(bom)
+				continue
+			}
+			return
+		}
+	}
+}
+
+func (self *lexer) next() (token, error) {
+	self.skipIgnored()
+
+	result := token{line: self.line, col: self.col}
+	if self.pos >= len(self.input) {
+		result.kind = tokenEOF
+		return result, nil
+	}
+
+	c := self.input[self.pos]
+	switch {
+	case strings.HasPrefix(self.input[self.pos:], "..."):
+		result.kind = tokenPunctuator
+		result.value = "..."
+		self.advance(3)
+		return result, nil
+
+	case strings.IndexByte("!$()[]{}:=@|&", c) >= 0:
+		result.kind = tokenPunctuator
+		result.value = string(c)
+		self.advance(1)
+		return result, nil
+
+	case c == '_' || isLetter(c):
+		start := self.pos
+		for self.pos < len(self.input) &&
+			(self.input[self.pos] == '_' || isLetter(self.input[self.pos]) ||
+				isDigit(self.input[self.pos])) {
+			self.advance(1)
+		}
+		result.kind = tokenName
+		result.value = self.input[start:self.pos]
+		return result, nil
+
+	case c == '-' || isDigit(c):
+		return self.readNumber(result)
+
+	case strings.HasPrefix(self.input[self.pos:], `"""`):
+		return self.readBlockString(result)
+
+	case c == '"':
+		return self.readString(result)
+	}
+
+	r, _ := utf8.DecodeRuneInString(self.input[self.pos:])
+	return result, self.errorf("Unexpected character %q", r)
+}
+
+func (self *lexer) readNumber(result token) (token, error) {
+	start := self.pos
+	result.kind = tokenInt
+
+	if self.input[self.pos] == '-' {
+		self.advance(1)
+	}
+
+	digits := func() int {
+		count := 0
+		for self.pos < len(self.input) && isDigit(self.input[self.pos]) {
+			self.advance(1)
+			count++
+		}
+		return count
+	}
+
+	if digits() == 0 {
+		return result, self.errorf("Invalid number")
+	}
+
+	if self.pos < len(self.input) && self.input[self.pos] == '.' {
+		result.kind = tokenFloat
+		self.advance(1)
+		if digits() == 0 {
+			return result, self.errorf("Invalid number")
+		}
+	}
+
+	if self.pos < len(self.input) &&
+		(self.input[self.pos] == 'e' || self.input[self.pos] == 'E') {
+		result.kind = tokenFloat
+		self.advance(1)
+		if self.pos < len(self.input) &&
+			(self.input[self.pos] == '+' || self.input[self.pos] == '-') {
+			self.advance(1)
+		}
+		if digits() == 0 {
+			return result, self.errorf("Invalid number")
+		}
+	}
+
+	result.value = self.input[start:self.pos]
+	return result, nil
+}
+
+func (self *lexer) readString(result token) (token, error) {
+	result.kind = tokenString
+
+	// Skip the opening quote
+	self.advance(1)
+
+	var value strings.Builder
+	for self.pos < len(self.input) {
+		c := self.input[self.pos]
+		switch c {
+		case '"':
+			self.advance(1)
+			result.value = value.String()
+			return result, nil
+
+		case '\n', '\r':
+			return result, self.errorf("Unterminated string")
+
+		case '\\':
+			if self.pos+1 >= len(self.input) {
+				return result, self.errorf("Unterminated string")
+			}
+			escape := self.input[self.pos+1]
+			switch escape {
+			case '"', '\\', '/':
+				value.WriteByte(escape)
+			case 'b':
+				value.WriteByte('\b')
+			case 'f':
+				value.WriteByte('\f')
+			case 'n':
+				value.WriteByte('\n')
+			case 'r':
+				value.WriteByte('\r')
+			case 't':
+				value.WriteByte('\t')
+			case 'u':
+				if self.pos+6 > len(self.input) {
+					return result, self.errorf("Invalid unicode escape")
+				}
+				var r rune
+				_, err := fmt.Sscanf(self.input[self.pos+2:self.pos+6], "%04x", &r)
+				if err != nil {
+					return result, self.errorf("Invalid unicode escape")
+				}
+				value.WriteRune(r)
+				self.advance(4)
+			default:
+				return result, self.errorf("Invalid escape sequence \\%c", escape)
+			}
+			self.advance(2)
+
+		default:
+			value.WriteByte(c)
+			self.advance(1)
+		}
+	}
+
+	return result, self.errorf("Unterminated string")
+}
+
+// Block strings are taken literally except for the common
+// indentation which is removed.
+func (self *lexer) readBlockString(result token) (token, error) {
+	result.kind = tokenString
+	self.advance(3)
+
+	// Find the closing quotes which are not escaped.
+	end := -1
+	for i := self.pos; i+3 <= len(self.input); i++ {
+		if self.input[i] == '\\' && strings.HasPrefix(self.input[i+1:], `"""`) {
+			i += 3
+			continue
+		}
+		if strings.HasPrefix(self.input[i:], `"""`) {
+			end = i - self.pos
+			break
+		}
+	}
+
+	if end < 0 {
+		return result, self.errorf("Unterminated string")
+	}
+
+	raw := strings.ReplaceAll(self.input[self.pos:self.pos+end], `\"""`, `"""`)
+	self.advance(end + 3)
+
+	lines := strings.Split(strings.ReplaceAll(raw, "\r\n", "\n"), "\n")
+	indent := -1
+	for _, line := range lines[1:] {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed == "" {
+			continue
+		}
+		if n := len(line) - len(trimmed); indent < 0 || n < indent {
+			indent = n
+		}
+	}
+
+	for i := 1; i < len(lines) && indent > 0; i++ {
+		if len(lines[i]) >= indent {
+			lines[i] = lines[i][indent:]
+		}
+	}
+
+	result.value = strings.Trim(strings.Join(lines, "\n"), "\n")
+	return result, nil
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
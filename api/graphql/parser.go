@@ -0,0 +1,663 @@
+package graphql
+
+import (
+	"strconv"
+
+	"github.com/Velocidex/ordereddict"
+)
+
+type document struct {
+	operations []*operation
+	fragments  map[string]*fragment
+}
+
+type operation struct {
+	kind       string
+	name       string
+	variables  []*variableDefinition
+	directives []*directive
+	selections []selection
+	location   Location
+}
+
+type variableDefinition struct {
+	name          string
+	typ           *typeRef
+	default_value interface{}
+	has_default   bool
+}
+
+// A reference to a type, e.g. [String!]!
+type typeRef struct {
+	name     string
+	elem     *typeRef
+	non_null bool
+}
+
+func (self *typeRef) String() string {
+	result := self.name
+	if self.elem != nil {
+		result = "[" + self.elem.String() + "]"
+	}
+	if self.non_null {
+		result += "!"
+	}
+	return result
+}
+
+type fragment struct {
+	name           string
+	type_condition string
+	directives     []*directive
+	selections     []selection
+	location       Location
+}
+
+// One of *field, *fragmentSpread or *inlineFragment
+type selection interface{}
+
+type field struct {
+	alias      string
+	name       string
+	arguments  []*argument
+	directives []*directive
+	selections []selection
+	location   Location
+}
+
+func (self *field) responseKey() string {
+	if self.alias != "" {
+		return self.alias
+	}
+	return self.name
+}
+
+type fragmentSpread struct {
+	name       string
+	directives []*directive
+	location   Location
+}
+
+type inlineFragment struct {
+	type_condition string
+	directives     []*directive
+	selections     []selection
+	location       Location
+}
+
+type argument struct {
+	name     string
+	value    interface{}
+	location Location
+}
+
+type directive struct {
+	name      string
+	arguments []*argument
+	location  Location
+}
+
+// Values in the query are represented by plain Go values (int64,
+// float64, string, bool, nil, []interface{} and *ordereddict.Dict)
+// except for variables and enums which must be distinguished from
+// strings.
+type variableRef string
+type enumValue string
+
+type parser struct {
+	lexer *lexer
+	token token
+}
+
+func parse(query string) (*document, error) {
+	self := &parser{lexer: newLexer(query)}
+	err := self.advance()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &document{
+		fragments: make(map[string]*fragment),
+	}
+
+	for self.token.kind != tokenEOF {
+		switch {
+		// The query shorthand
+		case self.peek("{"):
+			op := &operation{kind: "query", location: self.location()}
+			op.selections, err = self.parseSelectionSet()
+			if err != nil {
+				return nil, err
+			}
+			result.operations = append(result.operations, op)
+
+		case self.peekName("query", "mutation", "subscription"):
+			op, err := self.parseOperation()
+			if err != nil {
+				return nil, err
+			}
+			result.operations = append(result.operations, op)
+
+		case self.peekName("fragment"):
+			frag, err := self.parseFragment()
+			if err != nil {
+				return nil, err
+			}
+			_, pres := result.fragments[frag.name]
+			if pres {
+				return nil, newError(frag.location,
+					"There can be only one fragment named %q", frag.name)
+			}
+			result.fragments[frag.name] = frag
+
+		default:
+			return nil, self.unexpected()
+		}
+	}
+
+	if len(result.operations) == 0 {
+		return nil, newError(Location{Line: 1, Column: 1},
+			"The query contains no operations")
+	}
+
+	return result, nil
+}
+
+func (self *parser) advance() (err error) {
+	self.token, err = self.lexer.next()
+	return err
+}
+
+func (self *parser) location() Location {
+	return Location{Line: self.token.line, Column: self.token.col}
+}
+
+func (self *parser) unexpected() error {
+	return newError(self.location(), "Syntax Error: Unexpected %v", self.token)
+}
+
+func (self *parser) peek(punctuator string) bool {
+	return self.token.kind == tokenPunctuator && self.token.value == punctuator
+}
+
+func (self *parser) peekName(names ...string) bool {
+	if self.token.kind != tokenName {
+		return false
+	}
+	if len(names) == 0 {
+		return true
+	}
+	for _, name := range names {
+		if self.token.value == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (self *parser) expect(punctuator string) error {
+	if !self.peek(punctuator) {
+		return newError(self.location(),
+			"Syntax Error: Expected %q, found %v", punctuator, self.token)
+	}
+	return self.advance()
+}
+
+func (self *parser) expectName() (string, error) {
+	if !self.peekName() {
+		return "", newError(self.location(),
+			"Syntax Error: Expected Name, found %v", self.token)
+	}
+	name := self.token.value
+	return name, self.advance()
+}
+
+func (self *parser) parseOperation() (*operation, error) {
+	result := &operation{
+		kind:     self.token.value,
+		location: self.location(),
+	}
+
+	err := self.advance()
+	if err != nil {
+		return nil, err
+	}
+
+	if self.peekName() {
+		result.name, err = self.expectName()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if self.peek("(") {
+		result.variables, err = self.parseVariableDefinitions()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result.directives, err = self.parseDirectives()
+	if err != nil {
+		return nil, err
+	}
+
+	result.selections, err = self.parseSelectionSet()
+	return result, err
+}
+
+func (self *parser) parseVariableDefinitions() ([]*variableDefinition, error) {
+	var result []*variableDefinition
+
+	err := self.expect("(")
+	if err != nil {
+		return nil, err
+	}
+
+	for !self.peek(")") {
+		err = self.expect("$")
+		if err != nil {
+			return nil, err
+		}
+
+		definition := &variableDefinition{}
+		definition.name, err = self.expectName()
+		if err != nil {
+			return nil, err
+		}
+
+		err = self.expect(":")
+		if err != nil {
+			return nil, err
+		}
+
+		definition.typ, err = self.parseTypeRef()
+		if err != nil {
+			return nil, err
+		}
+
+		if self.peek("=") {
+			err = self.advance()
+			if err != nil {
+				return nil, err
+			}
+
+			definition.default_value, err = self.parseValue(true)
+			if err != nil {
+				return nil, err
+			}
+			definition.has_default = true
+		}
+
+		// Directives on variables are allowed but ignored.
+		_, err = self.parseDirectives()
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, definition)
+	}
+
+	return result, self.expect(")")
+}
+
+func (self *parser) parseTypeRef() (*typeRef, error) {
+	result := &typeRef{}
+
+	if self.peek("[") {
+		err := self.advance()
+		if err != nil {
+			return nil, err
+		}
+
+		result.elem, err = self.parseTypeRef()
+		if err != nil {
+			return nil, err
+		}
+
+		err = self.expect("]")
+		if err != nil {
+			return nil, err
+		}
+
+	} else {
+		name, err := self.expectName()
+		if err != nil {
+			return nil, err
+		}
+		result.name = name
+	}
+
+	if self.peek("!") {
+		result.non_null = true
+		return result, self.advance()
+	}
+
+	return result, nil
+}
+
+func (self *parser) parseFragment() (*fragment, error) {
+	result := &fragment{location: self.location()}
+
+	// Skip the fragment keyword
+	err := self.advance()
+	if err != nil {
+		return nil, err
+	}
+
+	result.name, err = self.expectName()
+	if err != nil {
+		return nil, err
+	}
+
+	if result.name == "on" {
+		return nil, newError(result.location,
+			"Syntax Error: Unexpected fragment name \"on\"")
+	}
+
+	if !self.peekName("on") {
+		return nil, self.unexpected()
+	}
+
+	err = self.advance()
+	if err != nil {
+		return nil, err
+	}
+
+	result.type_condition, err = self.expectName()
+	if err != nil {
+		return nil, err
+	}
+
+	result.directives, err = self.parseDirectives()
+	if err != nil {
+		return nil, err
+	}
+
+	result.selections, err = self.parseSelectionSet()
+	return result, err
+}
+
+func (self *parser) parseSelectionSet() ([]selection, error) {
+	var result []selection
+
+	err := self.expect("{")
+	if err != nil {
+		return nil, err
+	}
+
+	for !self.peek("}") {
+		var item selection
+
+		if self.peek("...") {
+			item, err = self.parseFragmentSelection()
+		} else {
+			item, err = self.parseField()
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, item)
+	}
+
+	if len(result) == 0 {
+		return nil, newError(self.location(),
+			"Syntax Error: Expected Name, found \"}\"")
+	}
+
+	return result, self.expect("}")
+}
+
+func (self *parser) parseFragmentSelection() (selection, error) {
+	location := self.location()
+	err := self.expect("...")
+	if err != nil {
+		return nil, err
+	}
+
+	// A fragment spread.
+	if self.peekName() && !self.peekName("on") {
+		result := &fragmentSpread{location: location}
+		result.name, err = self.expectName()
+		if err != nil {
+			return nil, err
+		}
+
+		result.directives, err = self.parseDirectives()
+		return result, err
+	}
+
+	result := &inlineFragment{location: location}
+	if self.peekName("on") {
+		err = self.advance()
+		if err != nil {
+			return nil, err
+		}
+
+		result.type_condition, err = self.expectName()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result.directives, err = self.parseDirectives()
+	if err != nil {
+		return nil, err
+	}
+
+	result.selections, err = self.parseSelectionSet()
+	return result, err
+}
+
+func (self *parser) parseField() (*field, error) {
+	result := &field{location: self.location()}
+
+	name, err := self.expectName()
+	if err != nil {
+		return nil, err
+	}
+
+	if self.peek(":") {
+		err = self.advance()
+		if err != nil {
+			return nil, err
+		}
+
+		result.alias = name
+		name, err = self.expectName()
+		if err != nil {
+			return nil, err
+		}
+	}
+	result.name = name
+
+	if self.peek("(") {
+		result.arguments, err = self.parseArguments(false)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result.directives, err = self.parseDirectives()
+	if err != nil {
+		return nil, err
+	}
+
+	if self.peek("{") {
+		result.selections, err = self.parseSelectionSet()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+func (self *parser) parseArguments(is_const bool) ([]*argument, error) {
+	var result []*argument
+
+	err := self.expect("(")
+	if err != nil {
+		return nil, err
+	}
+
+	for !self.peek(")") {
+		arg := &argument{location: self.location()}
+		arg.name, err = self.expectName()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, existing := range result {
+			if existing.name == arg.name {
+				return nil, newError(arg.location,
+					"There can be only one argument named %q", arg.name)
+			}
+		}
+
+		err = self.expect(":")
+		if err != nil {
+			return nil, err
+		}
+
+		arg.value, err = self.parseValue(is_const)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, arg)
+	}
+
+	if len(result) == 0 {
+		return nil, newError(self.location(),
+			"Syntax Error: Expected Name, found \")\"")
+	}
+
+	return result, self.expect(")")
+}
+
+func (self *parser) parseDirectives() ([]*directive, error) {
+	var result []*directive
+
+	for self.peek("@") {
+		item := &directive{location: self.location()}
+		err := self.advance()
+		if err != nil {
+			return nil, err
+		}
+
+		item.name, err = self.expectName()
+		if err != nil {
+			return nil, err
+		}
+
+		if self.peek("(") {
+			item.arguments, err = self.parseArguments(false)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		result = append(result, item)
+	}
+
+	return result, nil
+}
+
+func (self *parser) parseValue(is_const bool) (interface{}, error) {
+	tok := self.token
+
+	switch tok.kind {
+	case tokenInt:
+		value, err := strconv.ParseInt(tok.value, 10, 64)
+		if err != nil {
+			return nil, newError(self.location(),
+				"Syntax Error: Invalid integer %v", tok.value)
+		}
+		return value, self.advance()
+
+	case tokenFloat:
+		value, err := strconv.ParseFloat(tok.value, 64)
+		if err != nil {
+			return nil, newError(self.location(),
+				"Syntax Error: Invalid number %v", tok.value)
+		}
+		return value, self.advance()
+
+	case tokenString:
+		return tok.value, self.advance()
+
+	case tokenName:
+		err := self.advance()
+		switch tok.value {
+		case "true":
+			return true, err
+		case "false":
+			return false, err
+		case "null":
+			return nil, err
+		}
+		return enumValue(tok.value), err
+
+	case tokenPunctuator:
+		switch tok.value {
+		case "$":
+			if is_const {
+				return nil, self.unexpected()
+			}
+
+			err := self.advance()
+			if err != nil {
+				return nil, err
+			}
+
+			name, err := self.expectName()
+			return variableRef(name), err
+
+		case "[":
+			err := self.advance()
+			if err != nil {
+				return nil, err
+			}
+
+			result := []interface{}{}
+			for !self.peek("]") {
+				item, err := self.parseValue(is_const)
+				if err != nil {
+					return nil, err
+				}
+				result = append(result, item)
+			}
+			return result, self.advance()
+
+		case "{":
+			err := self.advance()
+			if err != nil {
+				return nil, err
+			}
+
+			result := ordereddict.NewDict()
+			for !self.peek("}") {
+				name, err := self.expectName()
+				if err != nil {
+					return nil, err
+				}
+
+				err = self.expect(":")
+				if err != nil {
+					return nil, err
+				}
+
+				item, err := self.parseValue(is_const)
+				if err != nil {
+					return nil, err
+				}
+				result.Set(name, item)
+			}
+			return result, self.advance()
+		}
+	}
+
+	return nil, self.unexpected()
+}
@@ -0,0 +1,277 @@
+package graphql
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Velocidex/ordereddict"
+	"www.velocidex.com/golang/velociraptor/acls"
+	config_proto "www.velocidex.com/golang/velociraptor/config/proto"
+	"www.velocidex.com/golang/velociraptor/services"
+)
+
+const (
+	defaultRows = 100
+	maxRows     = 1000
+)
+
+var (
+	schema_once sync.Once
+	schema      *Schema
+	schema_err  error
+)
+
+type contextKey int
+
+const requestContextKey contextKey = iota
+
+// The org and principal the query runs as.
+type requestContext struct {
+	config_obj *config_proto.Config
+	principal  string
+}
+
+func getRequestContext(ctx context.Context) (*requestContext, error) {
+	result, ok := ctx.Value(requestContextKey).(*requestContext)
+	if !ok {
+		return nil, fmt.Errorf("GraphQL: query has no org")
+	}
+	return result, nil
+}
+
+// Execute the query in the org as the principal. Each field is
+// checked against the principal's permissions in the org.
+func Execute(ctx context.Context,
+	config_obj *config_proto.Config,
+	principal string, request *Request) *Response {
+
+	schema, err := GetSchema()
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	ctx = context.WithValue(ctx, requestContextKey, &requestContext{
+		config_obj: config_obj,
+		principal:  principal,
+	})
+
+	return schema.Execute(ctx, request,
+		func(permission acls.ACL_PERMISSION) (bool, error) {
+			return services.CheckAccess(config_obj, principal, permission)
+		})
+}
+
+func GetSchema() (*Schema, error) {
+	schema_once.Do(func() {
+		schema, schema_err = NewSchema(queryType(),
+			clientType(), connectionType("ClientConnection", "Client"),
+			flowType(), connectionType("FlowConnection", "Flow"),
+			huntType(), connectionType("HuntConnection", "Hunt"),
+			resultsType())
+	})
+	return schema, schema_err
+}
+
+func queryType() *Object {
+	return &Object{
+		Name:        "Query",
+		Description: "Read only access to clients, flows, hunts and their results.",
+		Fields: []*Field{{
+			Name:        "clients",
+			Type:        "ClientConnection",
+			Description: "Search for clients using the same syntax as the GUI search box.",
+			Arguments: append([]*Argument{{
+				Name:        "search",
+				Type:        String,
+				Description: "The search term (default all clients).",
+			}}, pagingArguments()...),
+			Permission: acls.READ_RESULTS,
+			Resolve:    resolveClients,
+		}, {
+			Name: "client",
+			Type: "Client",
+			Arguments: []*Argument{{
+				Name: "client_id",
+				Type: "String!",
+			}},
+			Permission: acls.READ_RESULTS,
+			Resolve:    resolveClient,
+		}, {
+			Name:        "flow",
+			Type:        "Flow",
+			Description: "A single collection. Server collections use the client id \"server\".",
+			Arguments: []*Argument{{
+				Name: "client_id",
+				Type: "String!",
+			}, {
+				Name: "flow_id",
+				Type: "String!",
+			}},
+			Permission: acls.READ_RESULTS,
+			Resolve:    resolveFlow,
+		}, {
+			Name:        "hunts",
+			Type:        "HuntConnection",
+			Description: "All hunts, most recent first.",
+			Arguments:   pagingArguments(),
+			Permission:  acls.READ_RESULTS,
+			Resolve:     resolveHunts,
+		}, {
+			Name: "hunt",
+			Type: "Hunt",
+			Arguments: []*Argument{{
+				Name: "hunt_id",
+				Type: "String!",
+			}},
+			Permission: acls.READ_RESULTS,
+			Resolve:    resolveHunt,
+		}},
+	}
+}
+
+// Connections page through rows with the same semantics as the
+// GetTable API: start is the first row and rows is the page size. A
+// cursor continues from where the previous page ended.
+type connection struct {
+	start int64
+	total int64
+	items interface{}
+	count int
+
+	// Only used for result sets.
+	columns []string
+}
+
+func pagingArguments() []*Argument {
+	return []*Argument{{
+		Name:        "start",
+		Type:        Int,
+		Description: "The first row to return.",
+	}, {
+		Name:        "rows",
+		Type:        Int,
+		Description: fmt.Sprintf("The number of rows to return (default %v, at most %v).", defaultRows, maxRows),
+	}, {
+		Name:        "cursor",
+		Type:        String,
+		Description: "The next_cursor of the previous page.",
+	}}
+}
+
+func getPaging(args *ordereddict.Dict) (start int64, rows int64, err error) {
+	start, _ = getInt(args, "start")
+	rows, pres := getInt(args, "rows")
+	if !pres {
+		rows = defaultRows
+	}
+
+	if start < 0 {
+		return 0, 0, fmt.Errorf("start must not be negative")
+	}
+
+	if rows <= 0 {
+		return 0, 0, fmt.Errorf("rows must be positive")
+	}
+
+	if rows > maxRows {
+		return 0, 0, fmt.Errorf("At most %v rows may be requested", maxRows)
+	}
+
+	cursor, _ := getString(args, "cursor")
+	if cursor != "" {
+		start, err = decodeCursor(cursor)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	return start, rows, nil
+}
+
+func encodeCursor(start int64) string {
+	return base64.RawURLEncoding.EncodeToString(
+		[]byte(fmt.Sprintf("start:%d", start)))
+}
+
+func decodeCursor(cursor string) (int64, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err == nil && strings.HasPrefix(string(decoded), "start:") {
+		start, err := strconv.ParseInt(
+			strings.TrimPrefix(string(decoded), "start:"), 10, 64)
+		if err == nil && start >= 0 {
+			return start, nil
+		}
+	}
+	return 0, fmt.Errorf("Invalid cursor %q", cursor)
+}
+
+func connectionType(name, item_type string) *Object {
+	return &Object{
+		Name:        name,
+		Description: "A page of " + item_type + " items.",
+		Fields: []*Field{{
+			Name:        "total",
+			Type:        Int,
+			Description: "The total number of items.",
+			Resolve: connectionField(func(c *connection) interface{} {
+				return c.total
+			}),
+		}, {
+			Name:        "next_cursor",
+			Type:        String,
+			Description: "Pass as the cursor to get the next page. Null on the last page.",
+			Resolve:     connectionField(nextCursor),
+		}, {
+			Name: "items",
+			Type: "[" + item_type + "]",
+			Resolve: connectionField(func(c *connection) interface{} {
+				return c.items
+			}),
+		}},
+	}
+}
+
+func nextCursor(c *connection) interface{} {
+	next := c.start + int64(c.count)
+	if c.count == 0 || next >= c.total {
+		return nil
+	}
+	return encodeCursor(next)
+}
+
+func connectionField(getter func(c *connection) interface{}) Resolver {
+	return func(ctx context.Context,
+		parent interface{}, args *ordereddict.Dict) (interface{}, error) {
+		c, ok := parent.(*connection)
+		if !ok {
+			return nil, unexpectedParent(parent)
+		}
+		return getter(c), nil
+	}
+}
+
+func unexpectedParent(parent interface{}) error {
+	return fmt.Errorf("GraphQL: unexpected object %T", parent)
+}
+
+func getString(args *ordereddict.Dict, name string) (string, bool) {
+	value, pres := args.Get(name)
+	if !pres {
+		return "", false
+	}
+	result, ok := value.(string)
+	return result, ok
+}
+
+func getInt(args *ordereddict.Dict, name string) (int64, bool) {
+	value, pres := args.Get(name)
+	if !pres {
+		return 0, false
+	}
+	result, ok := value.(int64)
+	return result, ok
+}
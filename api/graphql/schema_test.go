@@ -0,0 +1,177 @@
+package graphql_test
+
+import (
+	"testing"
+
+	"github.com/Velocidex/ordereddict"
+	"github.com/stretchr/testify/suite"
+	actions_proto "www.velocidex.com/golang/velociraptor/actions/proto"
+	"www.velocidex.com/golang/velociraptor/api/graphql"
+	api_proto "www.velocidex.com/golang/velociraptor/api/proto"
+	file_store "www.velocidex.com/golang/velociraptor/file_store"
+	"www.velocidex.com/golang/velociraptor/file_store/test_utils"
+	flows_proto "www.velocidex.com/golang/velociraptor/flows/proto"
+	"www.velocidex.com/golang/velociraptor/json"
+	"www.velocidex.com/golang/velociraptor/paths/artifact_modes"
+	artifact_paths "www.velocidex.com/golang/velociraptor/paths/artifacts"
+	"www.velocidex.com/golang/velociraptor/result_sets"
+	"www.velocidex.com/golang/velociraptor/services"
+	"www.velocidex.com/golang/velociraptor/utils"
+	"www.velocidex.com/golang/velociraptor/vql/acl_managers"
+	"www.velocidex.com/golang/velociraptor/vtesting/assert"
+	"www.velocidex.com/golang/velociraptor/vtesting/goldie"
+)
+
+const (
+	client_id = "C.123"
+	flow_id   = "F.1234"
+	source    = "Generic.Client.Info/BasicInformation"
+)
+
+type SchemaTestSuite struct {
+	test_utils.TestSuite
+}
+
+func (self *SchemaTestSuite) SetupTest() {
+	self.ConfigObj = self.TestSuite.LoadConfig()
+	self.ConfigObj.Services.FrontendServer = true
+	self.ConfigObj.Services.HuntDispatcher = true
+
+	self.TestSuite.SetupTest()
+
+	client_info_manager, err := services.GetClientInfoManager(self.ConfigObj)
+	assert.NoError(self.T(), err)
+
+	err = client_info_manager.Set(self.Ctx, &services.ClientInfo{
+		ClientInfo: &actions_proto.ClientInfo{
+			ClientId: client_id,
+			Hostname: "workstation",
+			System:   "windows",
+		}})
+	assert.NoError(self.T(), err)
+
+	self.CreateFlow(client_id, flow_id)
+
+	// Write some results into the flow.
+	path_manager := artifact_paths.NewArtifactPathManagerWithMode(
+		self.ConfigObj, client_id, flow_id, source,
+		artifact_modes.MODE_CLIENT)
+
+	rs_writer, err := result_sets.NewResultSetWriter(
+		file_store.GetFileStore(self.ConfigObj), path_manager.Path(),
+		json.DefaultEncOpts(), utils.SyncCompleter, result_sets.TruncateMode)
+	assert.NoError(self.T(), err)
+
+	for i := 0; i < 3; i++ {
+		rs_writer.Write(ordereddict.NewDict().
+			Set("Row", i).
+			Set("Hostname", "workstation"))
+	}
+	rs_writer.Close()
+
+	hunt_dispatcher, err := services.GetHuntDispatcher(self.ConfigObj)
+	assert.NoError(self.T(), err)
+
+	_, err = hunt_dispatcher.CreateHunt(self.Ctx, self.ConfigObj,
+		acl_managers.NullACLManager{}, &api_proto.Hunt{
+			HuntId:          "H.1234",
+			HuntDescription: "A test hunt",
+			State:           api_proto.Hunt_RUNNING,
+			StartRequest: &flows_proto.ArtifactCollectorArgs{
+				Artifacts: []string{"Generic.Client.Info"},
+			},
+		})
+	assert.NoError(self.T(), err)
+
+	err = services.GrantRoles(self.ConfigObj, "admin", []string{"administrator"})
+	assert.NoError(self.T(), err)
+}
+
+func (self *SchemaTestSuite) TestQueries() {
+	golden := ordereddict.NewDict()
+
+	query := func(name, principal, query string) *graphql.Response {
+		response := graphql.Execute(self.Ctx, self.ConfigObj, principal,
+			&graphql.Request{Query: query})
+		golden.Set(name, response)
+		return response
+	}
+
+	query("Client with flows and results", "admin", `
+{
+  client(client_id: "C.123") {
+    client_id hostname os
+    flows {
+      total
+      items {
+        flow_id client_id state artifacts
+        client { hostname }
+        results(artifact: "Generic.Client.Info/BasicInformation", rows: 2) {
+          total columns rows next_cursor
+        }
+      }
+    }
+  }
+}`)
+
+	response := query("Flow results first page", "admin", `
+{
+  flow(client_id: "C.123", flow_id: "F.1234") {
+    results(artifact: "Generic.Client.Info/BasicInformation", rows: 2) {
+      next_cursor
+    }
+  }
+}`)
+
+	cursor := utils.GetString(response.Data, "flow.results.next_cursor")
+	assert.NotEmpty(self.T(), cursor)
+
+	query("Flow results next page", "admin", `
+{
+  flow(client_id: "C.123", flow_id: "F.1234") {
+    results(artifact: "Generic.Client.Info/BasicInformation",
+            rows: 2, cursor: "`+cursor+`") {
+      total rows next_cursor
+    }
+  }
+}`)
+
+	query("Hunts", "admin", `
+{
+  hunts { total items { hunt_id description state artifacts } }
+  hunt(hunt_id: "H.1234") { hunt_id }
+}`)
+
+	query("Unknown objects", "admin", `
+{
+  client(client_id: "C.Missing") { client_id }
+  flow(client_id: "C.123", flow_id: "F.Missing") { flow_id }
+  hunt(hunt_id: "H.Missing") { hunt_id }
+}`)
+
+	query("Too many rows", "admin", `
+{ client(client_id: "C.123") { flows(rows: 5000) { total } } }`)
+
+	query("Too expensive", "admin", `
+{
+  clients(rows: 1000) {
+    items { flows(rows: 1000) { items { flow_id } } }
+  }
+}`)
+
+	query("No permissions", "nobody", `
+{ client(client_id: "C.123") { client_id } }`)
+
+	goldie.Assert(self.T(), "TestQueries", json.MustMarshalIndent(golden))
+}
+
+func (self *SchemaTestSuite) TestSchema() {
+	schema, err := graphql.GetSchema()
+	assert.NoError(self.T(), err)
+
+	goldie.Assert(self.T(), "TestSchema", []byte(schema.SDL()))
+}
+
+func TestSchemaTestSuite(t *testing.T) {
+	suite.Run(t, &SchemaTestSuite{})
+}
@@ -0,0 +1,239 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Velocidex/ordereddict"
+	"www.velocidex.com/golang/velociraptor/acls"
+)
+
+// The built in scalar types. JSON holds arbitrary data such as
+// result rows.
+const (
+	String  = "String"
+	Int     = "Int"
+	Float   = "Float"
+	Boolean = "Boolean"
+	JSON    = "JSON"
+)
+
+var scalars = []string{String, Int, Float, Boolean, JSON}
+
+type Location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+type Error struct {
+	Message   string        `json:"message"`
+	Locations []Location    `json:"locations,omitempty"`
+	Path      []interface{} `json:"path,omitempty"`
+}
+
+func (self *Error) Error() string {
+	return self.Message
+}
+
+func newError(location Location, format string, args ...interface{}) *Error {
+	return &Error{
+		Message:   fmt.Sprintf(format, args...),
+		Locations: []Location{location},
+	}
+}
+
+// Resolvers receive the object the field belongs to (nil for the
+// Query type) and the coerced arguments.
+type Resolver func(ctx context.Context,
+	parent interface{}, args *ordereddict.Dict) (interface{}, error)
+
+type Argument struct {
+	Name        string
+	Type        string
+	Description string
+	Default     interface{}
+
+	typ *typeRef
+}
+
+type Field struct {
+	Name        string
+	Type        string
+	Description string
+	Arguments   []*Argument
+
+	// The caller must hold this permission to resolve the field.
+	Permission acls.ACL_PERMISSION
+
+	Resolve Resolver
+
+	typ *typeRef
+}
+
+func (self *Field) argument(name string) *Argument {
+	for _, arg := range self.Arguments {
+		if arg.Name == name {
+			return arg
+		}
+	}
+	return nil
+}
+
+type Object struct {
+	Name        string
+	Description string
+	Fields      []*Field
+}
+
+func (self *Object) field(name string) *Field {
+	for _, f := range self.Fields {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+type Schema struct {
+	query   *Object
+	objects map[string]*Object
+}
+
+func NewSchema(query *Object, objects ...*Object) (*Schema, error) {
+	result := &Schema{
+		query:   query,
+		objects: make(map[string]*Object),
+	}
+
+	for _, obj := range append([]*Object{query}, objects...) {
+		if result.isScalar(obj.Name) {
+			return nil, fmt.Errorf("GraphQL: %v is a scalar type", obj.Name)
+		}
+		result.objects[obj.Name] = obj
+	}
+
+	var err error
+	for _, obj := range result.objects {
+		for _, f := range obj.Fields {
+			f.typ, err = parseTypeString(f.Type)
+			if err != nil {
+				return nil, fmt.Errorf("GraphQL: %v.%v: %w", obj.Name, f.Name, err)
+			}
+
+			// Output types are always nullable so a failed field does
+			// not null out its parent.
+			if f.typ.non_null || (f.typ.elem != nil && f.typ.elem.non_null) {
+				return nil, fmt.Errorf("GraphQL: %v.%v: output types may not be non null",
+					obj.Name, f.Name)
+			}
+
+			if !result.isScalar(f.typ.namedType()) &&
+				result.objects[f.typ.namedType()] == nil {
+				return nil, fmt.Errorf("GraphQL: %v.%v: unknown type %v",
+					obj.Name, f.Name, f.Type)
+			}
+
+			if f.Resolve == nil {
+				return nil, fmt.Errorf("GraphQL: %v.%v: no resolver",
+					obj.Name, f.Name)
+			}
+
+			for _, arg := range f.Arguments {
+				arg.typ, err = parseTypeString(arg.Type)
+				if err != nil {
+					return nil, fmt.Errorf("GraphQL: %v.%v(%v): %w",
+						obj.Name, f.Name, arg.Name, err)
+				}
+
+				// Only scalars are accepted as input.
+				if !result.isScalar(arg.typ.namedType()) {
+					return nil, fmt.Errorf("GraphQL: %v.%v(%v): unknown type %v",
+						obj.Name, f.Name, arg.Name, arg.Type)
+				}
+			}
+		}
+	}
+
+	return result, nil
+}
+
+func (self *Schema) isScalar(name string) bool {
+	for _, s := range scalars {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Describe the schema in the GraphQL schema definition language.
+func (self *Schema) SDL() string {
+	var b strings.Builder
+
+	names := []string{}
+	for name := range self.objects {
+		if name != self.query.Name {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	writeDescription := func(indent, description string) {
+		if description != "" {
+			fmt.Fprintf(&b, "%v\"\"\"%v\"\"\"\n", indent, description)
+		}
+	}
+
+	for _, name := range append([]string{self.query.Name}, names...) {
+		obj := self.objects[name]
+		writeDescription("", obj.Description)
+		fmt.Fprintf(&b, "type %v {\n", obj.Name)
+		for _, f := range obj.Fields {
+			writeDescription("  ", f.Description)
+			fmt.Fprintf(&b, "  %v", f.Name)
+			if len(f.Arguments) > 0 {
+				args := []string{}
+				for _, arg := range f.Arguments {
+					desc := arg.Name + ": " + arg.Type
+					if arg.Default != nil {
+						desc += fmt.Sprintf(" = %v", arg.Default)
+					}
+					args = append(args, desc)
+				}
+				fmt.Fprintf(&b, "(%v)", strings.Join(args, ", "))
+			}
+			fmt.Fprintf(&b, ": %v\n", f.Type)
+		}
+		b.WriteString("}\n\n")
+	}
+
+	b.WriteString("scalar JSON\n\nschema {\n  query: " + self.query.Name + "\n}\n")
+	return b.String()
+}
+
+func parseTypeString(typ string) (*typeRef, error) {
+	p := &parser{lexer: newLexer(typ)}
+	err := p.advance()
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := p.parseTypeRef()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.token.kind != tokenEOF {
+		return nil, p.unexpected()
+	}
+	return result, nil
+}
+
+func (self *typeRef) namedType() string {
+	if self.elem != nil {
+		return self.elem.namedType()
+	}
+	return self.name
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"context"
+
+	"google.golang.org/protobuf/types/known/emptypb"
+	api_proto "www.velocidex.com/golang/velociraptor/api/proto"
+	"www.velocidex.com/golang/velociraptor/services"
+)
+
+func (self *ApiServer) ListGUISessions(
+	ctx context.Context,
+	in *api_proto.GUISessionRequest) (*api_proto.GUISessions, error) {
+
+	defer Instrument("ListGUISessions")()
+
+	users_manager := services.GetUserManager()
+	user_record, _, err := users_manager.GetUserFromContext(ctx)
+	if err != nil {
+		return nil, Status(self.verbose, err)
+	}
+	principal := user_record.Name
+
+	username := in.Username
+	if username == "" && !in.All {
+		username = principal
+	}
+
+	sessions, err := users_manager.ListGUISessions(ctx, principal, username)
+	if err != nil {
+		return nil, Status(self.verbose, err)
+	}
+
+	return &api_proto.GUISessions{Items: sessions}, nil
+}
+
+func (self *ApiServer) RevokeGUISession(
+	ctx context.Context,
+	in *api_proto.GUISessionRequest) (*emptypb.Empty, error) {
+
+	defer Instrument("RevokeGUISession")()
+
+	users_manager := services.GetUserManager()
+	user_record, _, err := users_manager.GetUserFromContext(ctx)
+	if err != nil {
+		return nil, Status(self.verbose, err)
+	}
+	principal := user_record.Name
+
+	username := in.Username
+	if username == "" {
+		username = principal
+	}
+
+	// Without a session id all the user's sessions are revoked.
+	err = users_manager.RevokeGUISession(ctx, principal, username,
+		in.SessionId, "Forced logout")
+	if err != nil {
+		return nil, Status(self.verbose, err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
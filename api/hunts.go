@@ -549,6 +549,49 @@ func (self *ApiServer) GetHuntResults(
 	return result, nil
 }
 
+// Return the stacking index of a hunt column - the counts and client
+// ids for each distinct value.
+func (self *ApiServer) GetHuntStack(
+	ctx context.Context,
+	in *api_proto.GetHuntStackRequest) (*api_proto.GetTableResponse, error) {
+
+	defer Instrument("GetHuntStack")()
+
+	users := services.GetUserManager()
+	user_record, org_config_obj, err := users.GetUserFromContext(ctx)
+	if err != nil {
+		return nil, Status(self.verbose, err)
+	}
+	principal := user_record.Name
+
+	permissions := acls.READ_RESULTS
+	perm, err := services.CheckAccess(org_config_obj, principal, permissions)
+	if !perm || err != nil {
+		return nil, PermissionDenied(err,
+			"User is not allowed to view results.")
+	}
+
+	limit := in.Limit
+	if limit == 0 {
+		limit = 100
+	}
+
+	env := ordereddict.NewDict().
+		Set("HuntID", in.HuntId).
+		Set("ArtifactName", in.Artifact).
+		Set("Column", in.Column).
+		Set("Limit", limit)
+
+	result, err := RunVQL(ctx, org_config_obj, principal, env,
+		"SELECT * FROM hunt_stack(hunt_id=HuntID, "+
+			"artifact=ArtifactName, column=Column, limit=Limit)")
+	if err != nil {
+		return nil, Status(self.verbose, err)
+	}
+
+	return result, nil
+}
+
 func (self *ApiServer) EstimateHunt(
 	ctx context.Context,
 	in *api_proto.HuntEstimateRequest) (*api_proto.HuntStats, error) {
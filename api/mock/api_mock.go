@@ -161,6 +161,26 @@ func (mr *MockAPIClientMockRecorder) CollectArtifact(arg0, arg1 interface{}, arg
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CollectArtifact", reflect.TypeOf((*MockAPIClient)(nil).CollectArtifact), varargs...)
 }
 
+// CreateApiToken mocks base method.
+func (m *MockAPIClient) CreateApiToken(arg0 context.Context, arg1 *proto0.CreateApiTokenRequest, arg2 ...grpc.CallOption) (*proto0.CreateApiTokenResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "CreateApiToken", varargs...)
+	ret0, _ := ret[0].(*proto0.CreateApiTokenResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateApiToken indicates an expected call of CreateApiToken.
+func (mr *MockAPIClientMockRecorder) CreateApiToken(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateApiToken", reflect.TypeOf((*MockAPIClient)(nil).CreateApiToken), varargs...)
+}
+
 // CreateDownloadFile mocks base method.
 func (m *MockAPIClient) CreateDownloadFile(arg0 context.Context, arg1 *proto0.CreateDownloadRequest, arg2 ...grpc.CallOption) (*proto0.CreateDownloadResponse, error) {
 	m.ctrl.T.Helper()
@@ -541,6 +561,26 @@ func (mr *MockAPIClientMockRecorder) GetHuntResults(arg0, arg1 interface{}, arg2
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHuntResults", reflect.TypeOf((*MockAPIClient)(nil).GetHuntResults), varargs...)
 }
 
+// GetHuntStack mocks base method.
+func (m *MockAPIClient) GetHuntStack(arg0 context.Context, arg1 *proto0.GetHuntStackRequest, arg2 ...grpc.CallOption) (*proto0.GetTableResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetHuntStack", varargs...)
+	ret0, _ := ret[0].(*proto0.GetTableResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHuntStack indicates an expected call of GetHuntStack.
+func (mr *MockAPIClientMockRecorder) GetHuntStack(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHuntStack", reflect.TypeOf((*MockAPIClient)(nil).GetHuntStack), varargs...)
+}
+
 // GetHuntTable mocks base method.
 func (m *MockAPIClient) GetHuntTable(arg0 context.Context, arg1 *proto0.GetTableRequest, arg2 ...grpc.CallOption) (*proto0.GetTableResponse, error) {
 	m.ctrl.T.Helper()
@@ -921,6 +961,26 @@ func (mr *MockAPIClientMockRecorder) LabelClients(arg0, arg1 interface{}, arg2 .
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LabelClients", reflect.TypeOf((*MockAPIClient)(nil).LabelClients), varargs...)
 }
 
+// ListApiTokens mocks base method.
+func (m *MockAPIClient) ListApiTokens(arg0 context.Context, arg1 *proto0.ApiTokenRequest, arg2 ...grpc.CallOption) (*proto0.ApiTokens, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ListApiTokens", varargs...)
+	ret0, _ := ret[0].(*proto0.ApiTokens)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListApiTokens indicates an expected call of ListApiTokens.
+func (mr *MockAPIClientMockRecorder) ListApiTokens(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListApiTokens", reflect.TypeOf((*MockAPIClient)(nil).ListApiTokens), varargs...)
+}
+
 // ListAvailableEventResults mocks base method.
 func (m *MockAPIClient) ListAvailableEventResults(arg0 context.Context, arg1 *proto0.ListAvailableEventResultsRequest, arg2 ...grpc.CallOption) (*proto0.ListAvailableEventResultsResponse, error) {
 	m.ctrl.T.Helper()
@@ -981,6 +1041,26 @@ func (mr *MockAPIClientMockRecorder) ListClients(arg0, arg1 interface{}, arg2 ..
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListClients", reflect.TypeOf((*MockAPIClient)(nil).ListClients), varargs...)
 }
 
+// ListGUISessions mocks base method.
+func (m *MockAPIClient) ListGUISessions(arg0 context.Context, arg1 *proto0.GUISessionRequest, arg2 ...grpc.CallOption) (*proto0.GUISessions, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ListGUISessions", varargs...)
+	ret0, _ := ret[0].(*proto0.GUISessions)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListGUISessions indicates an expected call of ListGUISessions.
+func (mr *MockAPIClientMockRecorder) ListGUISessions(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListGUISessions", reflect.TypeOf((*MockAPIClient)(nil).ListGUISessions), varargs...)
+}
+
 // ListHunts mocks base method.
 func (m *MockAPIClient) ListHunts(arg0 context.Context, arg1 *proto0.ListHuntsRequest, arg2 ...grpc.CallOption) (*proto0.ListHuntsResponse, error) {
 	m.ctrl.T.Helper()
@@ -1241,6 +1321,46 @@ func (mr *MockAPIClientMockRecorder) RevertNotebookCell(arg0, arg1 interface{},
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevertNotebookCell", reflect.TypeOf((*MockAPIClient)(nil).RevertNotebookCell), varargs...)
 }
 
+// RevokeApiToken mocks base method.
+func (m *MockAPIClient) RevokeApiToken(arg0 context.Context, arg1 *proto0.ApiTokenRequest, arg2 ...grpc.CallOption) (*emptypb.Empty, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "RevokeApiToken", varargs...)
+	ret0, _ := ret[0].(*emptypb.Empty)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeApiToken indicates an expected call of RevokeApiToken.
+func (mr *MockAPIClientMockRecorder) RevokeApiToken(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeApiToken", reflect.TypeOf((*MockAPIClient)(nil).RevokeApiToken), varargs...)
+}
+
+// RevokeGUISession mocks base method.
+func (m *MockAPIClient) RevokeGUISession(arg0 context.Context, arg1 *proto0.GUISessionRequest, arg2 ...grpc.CallOption) (*emptypb.Empty, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "RevokeGUISession", varargs...)
+	ret0, _ := ret[0].(*emptypb.Empty)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeGUISession indicates an expected call of RevokeGUISession.
+func (mr *MockAPIClientMockRecorder) RevokeGUISession(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeGUISession", reflect.TypeOf((*MockAPIClient)(nil).RevokeGUISession), varargs...)
+}
+
 // Scheduler mocks base method.
 func (m *MockAPIClient) Scheduler(arg0 context.Context, arg1 ...grpc.CallOption) (proto0.API_SchedulerClient, error) {
 	m.ctrl.T.Helper()
@@ -0,0 +1,34 @@
+package api
+
+import (
+	"net/http"
+
+	"www.velocidex.com/golang/velociraptor/api/openapi"
+	api_utils "www.velocidex.com/golang/velociraptor/api/utils"
+	config_proto "www.velocidex.com/golang/velociraptor/config/proto"
+	"www.velocidex.com/golang/velociraptor/constants"
+	"www.velocidex.com/golang/velociraptor/json"
+)
+
+// Serve the OpenAPI specification of the REST API. The specification
+// is generated once since it only depends on the compiled protobufs.
+func openAPIHandler(config_obj *config_proto.Config) (http.Handler, error) {
+	spec, err := openapi.Generate(openapi.Options{
+		Version:   constants.VERSION,
+		ServerUrl: api_utils.GetBasePath(config_obj),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	serialized, err := json.MarshalIndent(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	return api_utils.HandlerFunc(nil,
+		func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write(serialized)
+		}), nil
+}
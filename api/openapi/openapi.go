@@ -0,0 +1,475 @@
+/*
+  Generate an OpenAPI 3 specification for the REST API.
+
+  The REST API is served by the grpc-gateway which maps HTTP requests
+  to the gRPC API service using the google.api.http annotations in
+  api.proto. We generate the specification directly from the compiled
+  descriptors so it always matches the running server.
+
+  The JSON encoding follows the gateway's marshaler settings: fields
+  use their proto names and 64 bit integers are encoded as strings.
+*/
+
+package openapi
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Velocidex/ordereddict"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	api_proto "www.velocidex.com/golang/velociraptor/api/proto"
+	semantic_proto "www.velocidex.com/golang/velociraptor/proto"
+)
+
+const (
+	OPENAPI_VERSION = "3.0.3"
+
+	// The gateway allows callers to select the org with this header.
+	ORG_ID_HEADER = "Grpc-Metadata-OrgId"
+)
+
+var (
+	// Path templates may contain a pattern e.g. {name=foo/*}
+	pathParamRegex = regexp.MustCompile(`\{([^}=]+)(=[^}]*)?\}`)
+)
+
+type Options struct {
+	// The version of the server (reported in the info section).
+	Version string
+
+	// The url the API is served under (usually the GUI base path).
+	ServerUrl string
+}
+
+type generator struct {
+	schemas *ordereddict.Dict
+}
+
+// Generate the specification for the main API service.
+func Generate(opts Options) (*ordereddict.Dict, error) {
+	service := api_proto.File_api_proto.Services().ByName("API")
+	if service == nil {
+		return nil, fmt.Errorf("API service not found")
+	}
+
+	return GenerateForService(service, opts)
+}
+
+func GenerateForService(
+	service protoreflect.ServiceDescriptor,
+	opts Options) (*ordereddict.Dict, error) {
+	self := &generator{
+		schemas: ordereddict.NewDict(),
+	}
+
+	paths := ordereddict.NewDict()
+
+	methods := service.Methods()
+	for i := 0; i < methods.Len(); i++ {
+		method := methods.Get(i)
+
+		rule, ok := proto.GetExtension(
+			method.Options(), annotations.E_Http).(*annotations.HttpRule)
+		if !ok || rule == nil {
+			// Methods without annotations (e.g. streaming methods)
+			// are only available over gRPC.
+			continue
+		}
+
+		for _, binding := range append([]*annotations.HttpRule{rule},
+			rule.AdditionalBindings...) {
+			http_method, template := getPattern(binding)
+			if template == "" {
+				continue
+			}
+
+			operation, err := self.getOperation(
+				service, method, binding, template)
+			if err != nil {
+				return nil, err
+			}
+
+			path := pathParamRegex.ReplaceAllString(template, "{$1}")
+			path_item, pres := paths.Get(path)
+			if !pres {
+				path_item = ordereddict.NewDict()
+				paths.Set(path, path_item)
+			}
+			path_item.(*ordereddict.Dict).Set(http_method, operation)
+		}
+	}
+
+	server_url := opts.ServerUrl
+	if server_url == "" {
+		server_url = "/"
+	}
+
+	return ordereddict.NewDict().
+		Set("openapi", OPENAPI_VERSION).
+		Set("info", ordereddict.NewDict().
+			Set("title", "Velociraptor API").
+			Set("description", "The Velociraptor REST API. Requests may "+
+				"be authenticated with an API token in the Authorization "+
+				"header.").
+			Set("version", opts.Version)).
+		Set("servers", []*ordereddict.Dict{
+			ordereddict.NewDict().Set("url", server_url)}).
+		Set("security", []*ordereddict.Dict{
+			ordereddict.NewDict().Set("bearerAuth", []string{})}).
+		Set("paths", sortedDict(paths)).
+		Set("components", ordereddict.NewDict().
+			Set("schemas", sortedDict(self.schemas)).
+			Set("parameters", ordereddict.NewDict().
+				Set("OrgId", ordereddict.NewDict().
+					Set("name", ORG_ID_HEADER).
+					Set("in", "header").
+					Set("description", "The org to operate on (default root org).").
+					Set("schema", ordereddict.NewDict().Set("type", "string")))).
+			Set("securitySchemes", ordereddict.NewDict().
+				Set("bearerAuth", ordereddict.NewDict().
+					Set("type", "http").
+					Set("scheme", "bearer")))), nil
+}
+
+func (self *generator) getOperation(
+	service protoreflect.ServiceDescriptor,
+	method protoreflect.MethodDescriptor,
+	rule *annotations.HttpRule, template string) (*ordereddict.Dict, error) {
+
+	input := method.Input()
+	parameters := []interface{}{
+		ordereddict.NewDict().Set("$ref", "#/components/parameters/OrgId"),
+	}
+
+	// Fields bound to the path can not also be sent in the query
+	// string.
+	bound := make(map[string]bool)
+	for _, match := range pathParamRegex.FindAllStringSubmatch(template, -1) {
+		name := match[1]
+		field, err := findField(input, name)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %w", method.FullName(), err)
+		}
+		bound[name] = true
+
+		parameters = append(parameters, ordereddict.NewDict().
+			Set("name", name).
+			Set("in", "path").
+			Set("required", true).
+			Set("schema", self.getFieldSchema(field)))
+	}
+
+	operation := ordereddict.NewDict().
+		Set("operationId", string(method.Name())).
+		Set("tags", []string{string(service.Name())})
+
+	switch rule.Body {
+	case "":
+		// Without a body the remaining simple fields can be given
+		// as query parameters.
+		fields := input.Fields()
+		for i := 0; i < fields.Len(); i++ {
+			field := fields.Get(i)
+			name := string(field.Name())
+			if bound[name] || field.IsMap() ||
+				field.Kind() == protoreflect.MessageKind ||
+				field.Kind() == protoreflect.GroupKind {
+				continue
+			}
+
+			parameter := ordereddict.NewDict().
+				Set("name", name).
+				Set("in", "query").
+				Set("schema", self.getFieldSchema(field))
+			description := getFieldDescription(field)
+			if description != "" {
+				parameter.Set("description", description)
+			}
+			parameters = append(parameters, parameter)
+		}
+
+	case "*":
+		operation.Set("requestBody", self.getRequestBody(
+			self.getMessageSchema(input)))
+
+	default:
+		field := input.Fields().ByName(protoreflect.Name(rule.Body))
+		if field == nil {
+			return nil, fmt.Errorf("%v: body field %v not found",
+				method.FullName(), rule.Body)
+		}
+		operation.Set("requestBody", self.getRequestBody(
+			self.getFieldSchema(field)))
+	}
+
+	operation.Set("parameters", parameters)
+
+	response_schema := self.getMessageSchema(method.Output())
+	if rule.ResponseBody != "" {
+		field := method.Output().Fields().ByName(
+			protoreflect.Name(rule.ResponseBody))
+		if field == nil {
+			return nil, fmt.Errorf("%v: response body field %v not found",
+				method.FullName(), rule.ResponseBody)
+		}
+		response_schema = self.getFieldSchema(field)
+	}
+
+	operation.Set("responses", ordereddict.NewDict().
+		Set("200", ordereddict.NewDict().
+			Set("description", "A successful response.").
+			Set("content", jsonContent(response_schema))).
+		Set("default", ordereddict.NewDict().
+			Set("description", "An error message.").
+			Set("content", ordereddict.NewDict().
+				Set("text/plain", ordereddict.NewDict().
+					Set("schema", ordereddict.NewDict().
+						Set("type", "string"))))))
+
+	return operation, nil
+}
+
+func (self *generator) getRequestBody(schema *ordereddict.Dict) *ordereddict.Dict {
+	return ordereddict.NewDict().
+		Set("required", true).
+		Set("content", jsonContent(schema))
+}
+
+// Get a reference to the message's schema, adding it to the
+// components if needed.
+func (self *generator) getMessageSchema(
+	message protoreflect.MessageDescriptor) *ordereddict.Dict {
+
+	switch message.FullName() {
+	case "google.protobuf.Empty":
+		return ordereddict.NewDict().Set("type", "object")
+	}
+
+	name := schemaName(message.FullName())
+	ref := ordereddict.NewDict().Set("$ref", "#/components/schemas/"+name)
+
+	_, pres := self.schemas.Get(name)
+	if pres {
+		return ref
+	}
+
+	// Reserve the name first so recursive messages terminate.
+	schema := ordereddict.NewDict().Set("type", "object")
+	self.schemas.Set(name, schema)
+
+	semantic, ok := proto.GetExtension(message.Options(),
+		semantic_proto.E_Semantic).(*semantic_proto.SemanticMessageDescriptor)
+	if ok && semantic.GetDescription() != "" {
+		schema.Set("description", semantic.GetDescription())
+	}
+
+	properties := ordereddict.NewDict()
+	fields := message.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		properties.Set(string(field.Name()), self.getFieldSchema(field))
+	}
+	schema.Set("properties", properties)
+
+	return ref
+}
+
+func (self *generator) getEnumSchema(
+	enum protoreflect.EnumDescriptor) *ordereddict.Dict {
+	name := schemaName(enum.FullName())
+	ref := ordereddict.NewDict().Set("$ref", "#/components/schemas/"+name)
+
+	_, pres := self.schemas.Get(name)
+	if pres {
+		return ref
+	}
+
+	var names, descriptions []string
+	values := enum.Values()
+	for i := 0; i < values.Len(); i++ {
+		value := values.Get(i)
+		names = append(names, string(value.Name()))
+
+		description, _ := proto.GetExtension(value.Options(),
+			semantic_proto.E_Description).(string)
+		if description != "" {
+			descriptions = append(descriptions,
+				fmt.Sprintf("%v: %v", value.Name(), description))
+		}
+	}
+
+	schema := ordereddict.NewDict().
+		Set("type", "string").
+		Set("enum", names)
+	if len(descriptions) > 0 {
+		schema.Set("description", strings.Join(descriptions, "\n"))
+	}
+	self.schemas.Set(name, schema)
+
+	return ref
+}
+
+func (self *generator) getFieldSchema(
+	field protoreflect.FieldDescriptor) *ordereddict.Dict {
+
+	var schema *ordereddict.Dict
+	switch {
+	case field.IsMap():
+		schema = ordereddict.NewDict().
+			Set("type", "object").
+			Set("additionalProperties", self.getKindSchema(field.MapValue()))
+
+	case field.IsList():
+		schema = ordereddict.NewDict().
+			Set("type", "array").
+			Set("items", self.getKindSchema(field))
+
+	default:
+		schema = self.getKindSchema(field)
+	}
+
+	description := getFieldDescription(field)
+	if description == "" {
+		return schema
+	}
+
+	// Siblings of $ref are ignored so we need to wrap it.
+	_, pres := schema.Get("$ref")
+	if pres {
+		schema = ordereddict.NewDict().Set("allOf", []interface{}{schema})
+	}
+	return schema.Set("description", description)
+}
+
+// The schema of a single value of the field (i.e. ignoring if the
+// field is repeated).
+func (self *generator) getKindSchema(
+	field protoreflect.FieldDescriptor) *ordereddict.Dict {
+	switch field.Kind() {
+	case protoreflect.BoolKind:
+		return typeSchema("boolean", "")
+
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind,
+		protoreflect.Sfixed32Kind:
+		return typeSchema("integer", "int32")
+
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return typeSchema("integer", "int64")
+
+	// protojson encodes 64 bit integers as strings.
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind,
+		protoreflect.Sfixed64Kind:
+		return typeSchema("string", "int64")
+
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return typeSchema("string", "uint64")
+
+	case protoreflect.FloatKind:
+		return typeSchema("number", "float")
+
+	case protoreflect.DoubleKind:
+		return typeSchema("number", "double")
+
+	case protoreflect.StringKind:
+		return typeSchema("string", "")
+
+	case protoreflect.BytesKind:
+		return typeSchema("string", "byte")
+
+	case protoreflect.EnumKind:
+		return self.getEnumSchema(field.Enum())
+
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return self.getMessageSchema(field.Message())
+	}
+
+	return ordereddict.NewDict()
+}
+
+func getFieldDescription(field protoreflect.FieldDescriptor) string {
+	sem_type, ok := proto.GetExtension(field.Options(),
+		semantic_proto.E_SemType).(*semantic_proto.SemanticDescriptor)
+	if !ok {
+		return ""
+	}
+	return sem_type.GetDescription()
+}
+
+// Find the field referenced by a path parameter. Parameters may refer
+// to nested fields e.g. {request.client_id}
+func findField(message protoreflect.MessageDescriptor,
+	name string) (protoreflect.FieldDescriptor, error) {
+	var field protoreflect.FieldDescriptor
+
+	for _, part := range strings.Split(name, ".") {
+		if message == nil {
+			return nil, fmt.Errorf("path parameter %v is not a message", name)
+		}
+
+		field = message.Fields().ByName(protoreflect.Name(part))
+		if field == nil {
+			return nil, fmt.Errorf("path parameter %v not found in %v",
+				name, message.FullName())
+		}
+		message = field.Message()
+	}
+
+	return field, nil
+}
+
+func getPattern(rule *annotations.HttpRule) (method string, template string) {
+	switch t := rule.Pattern.(type) {
+	case *annotations.HttpRule_Get:
+		return "get", t.Get
+	case *annotations.HttpRule_Post:
+		return "post", t.Post
+	case *annotations.HttpRule_Put:
+		return "put", t.Put
+	case *annotations.HttpRule_Delete:
+		return "delete", t.Delete
+	case *annotations.HttpRule_Patch:
+		return "patch", t.Patch
+	case *annotations.HttpRule_Custom:
+		if t.Custom != nil {
+			return strings.ToLower(t.Custom.Kind), t.Custom.Path
+		}
+	}
+	return "", ""
+}
+
+// Most of our messages are in the "proto" package so we drop it to
+// get nicer names in generated clients.
+func schemaName(name protoreflect.FullName) string {
+	return strings.TrimPrefix(string(name), "proto.")
+}
+
+func typeSchema(type_name, format string) *ordereddict.Dict {
+	result := ordereddict.NewDict().Set("type", type_name)
+	if format != "" {
+		result.Set("format", format)
+	}
+	return result
+}
+
+func jsonContent(schema *ordereddict.Dict) *ordereddict.Dict {
+	return ordereddict.NewDict().
+		Set("application/json", ordereddict.NewDict().
+			Set("schema", schema))
+}
+
+func sortedDict(in *ordereddict.Dict) *ordereddict.Dict {
+	keys := in.Keys()
+	sort.Strings(keys)
+
+	result := ordereddict.NewDict()
+	for _, k := range keys {
+		v, _ := in.Get(k)
+		result.Set(k, v)
+	}
+	return result
+}
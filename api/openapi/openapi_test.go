@@ -0,0 +1,165 @@
+package openapi_test
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/Velocidex/ordereddict"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/proto"
+	"www.velocidex.com/golang/velociraptor/api/openapi"
+	api_proto "www.velocidex.com/golang/velociraptor/api/proto"
+	"www.velocidex.com/golang/velociraptor/json"
+	"www.velocidex.com/golang/velociraptor/vtesting/assert"
+	"www.velocidex.com/golang/velociraptor/vtesting/goldie"
+)
+
+var (
+	refRegex       = regexp.MustCompile(`"\$ref":\s*"#/components/([^/]+)/([^"]+)"`)
+	pathParamRegex = regexp.MustCompile(`\{([^}]+)\}`)
+)
+
+func getSpec(t *testing.T) map[string]interface{} {
+	spec, err := openapi.Generate(openapi.Options{
+		Version:   "0.1.0",
+		ServerUrl: "/velociraptor",
+	})
+	assert.NoError(t, err)
+
+	// Round trip through JSON so we check what clients will see.
+	serialized := json.MustMarshalIndent(spec)
+	result := make(map[string]interface{})
+	err = json.Unmarshal(serialized, &result)
+	assert.NoError(t, err)
+
+	return result
+}
+
+func getDict(t *testing.T, in interface{}, path ...string) map[string]interface{} {
+	for _, p := range path {
+		dict, ok := in.(map[string]interface{})
+		if !ok {
+			t.Fatalf("%v is not an object", strings.Join(path, "."))
+		}
+		in = dict[p]
+	}
+
+	result, ok := in.(map[string]interface{})
+	if !ok {
+		t.Fatalf("%v is not an object", strings.Join(path, "."))
+	}
+	return result
+}
+
+// Make sure the spec is self consistent.
+func TestSpecIsValid(t *testing.T) {
+	spec := getSpec(t)
+
+	assert.Equal(t, openapi.OPENAPI_VERSION, spec["openapi"])
+	assert.Equal(t, "0.1.0", getDict(t, spec, "info")["version"])
+
+	// All references must resolve.
+	serialized := json.MustMarshalString(spec)
+	matches := refRegex.FindAllStringSubmatch(serialized, -1)
+	assert.True(t, len(matches) > 0)
+
+	for _, match := range matches {
+		_, pres := getDict(t, spec, "components", match[1])[match[2]]
+		assert.True(t, pres, "Unresolved reference %v", match[0])
+	}
+
+	// Every path parameter must be declared and operation ids
+	// must be unique.
+	operation_ids := make(map[string]bool)
+	for path, item := range getDict(t, spec, "paths") {
+		for method, operation_any := range item.(map[string]interface{}) {
+			operation := operation_any.(map[string]interface{})
+
+			operation_id := operation["operationId"].(string)
+			assert.False(t, operation_ids[operation_id],
+				"Duplicate operationId %v", operation_id)
+			operation_ids[operation_id] = true
+
+			declared := make(map[string]bool)
+			for _, p := range operation["parameters"].([]interface{}) {
+				param := p.(map[string]interface{})
+				if param["in"] == "path" {
+					declared[param["name"].(string)] = true
+					assert.Equal(t, true, param["required"])
+				}
+			}
+
+			for _, match := range pathParamRegex.FindAllStringSubmatch(path, -1) {
+				assert.True(t, declared[match[1]],
+					"%v %v: path parameter %v not declared",
+					method, path, match[1])
+			}
+
+			_, pres := getDict(t, operation, "responses")["200"]
+			assert.True(t, pres)
+		}
+	}
+}
+
+// All the annotated RPCs must be in the spec.
+func TestAllMethodsPresent(t *testing.T) {
+	spec := getSpec(t)
+	paths := getDict(t, spec, "paths")
+
+	var operations []string
+	methods := api_proto.File_api_proto.Services().ByName("API").Methods()
+	for i := 0; i < methods.Len(); i++ {
+		method := methods.Get(i)
+		rule, _ := proto.GetExtension(
+			method.Options(), annotations.E_Http).(*annotations.HttpRule)
+		if rule == nil {
+			continue
+		}
+
+		var found bool
+		for path, item := range paths {
+			for http_method, operation := range item.(map[string]interface{}) {
+				if operation.(map[string]interface{})["operationId"] ==
+					string(method.Name()) {
+					found = true
+					operations = append(operations, fmt.Sprintf(
+						"%v %v %v", strings.ToUpper(http_method),
+						path, method.Name()))
+				}
+			}
+		}
+		assert.True(t, found, "Method %v not found", method.Name())
+	}
+
+	// Changes to the REST API should be deliberate so keep track of
+	// all the operations.
+	sort.Strings(operations)
+	goldie.Assert(t, "TestAllMethodsPresent",
+		json.MustMarshalIndent(operations))
+}
+
+func TestSchemas(t *testing.T) {
+	spec, err := openapi.Generate(openapi.Options{})
+	assert.NoError(t, err)
+
+	schemas, _ := ordereddict.GetMap(spec, "components.schemas")
+	getSchema := func(name string) string {
+		schema, _ := schemas.Get(name)
+		return json.MustMarshalString(schema)
+	}
+
+	// sem_type descriptions are carried over.
+	assert.Contains(t, getSchema("VFSRefreshDirectoryRequest"),
+		`"depth":{"type":"string","format":"uint64","description":"Depth of directory refresh"}`)
+
+	// Enums are encoded by name.
+	assert.Contains(t, getSchema("Hunt.State"),
+		`"enum":["UNSET","PAUSED","RUNNING","STOPPED","ARCHIVED","DELETED"]`)
+
+	// Repeated messages
+	assert.Contains(t, getSchema("ListHuntsResponse"),
+		`"items":{"type":"array","items":{"$ref":"#/components/schemas/Hunt"}}`)
+}
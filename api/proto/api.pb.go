@@ -638,7 +638,7 @@ const file_api_proto_rawDesc = "" +
 	"\x04rows\x18\x05 \x01(\x03R\x04rows\x12\x15\n" +
 	"\x06org_id\x18\x06 \x01(\tR\x05orgId\x12\x14\n" +
 	"\x05write\x18\a \x01(\bR\x05write\x12\x1a\n" +
-	"\busername\x18\b \x01(\tR\busername2\xdb?\n" +
+	"\busername\x18\b \x01(\tR\busername2\xbcD\n" +
 	"\x03API\x12R\n" +
 	"\n" +
 	"CreateHunt\x12\v.proto.Hunt\x1a\x18.proto.StartFlowResponse\"\x1d\x82\xd3\xe4\x93\x02\x17:\x01*\"\x12/api/v1/CreateHunt\x12]\n" +
@@ -650,7 +650,8 @@ const file_api_proto_rawDesc = "" +
 	"\n" +
 	"ModifyHunt\x12\x13.proto.HuntMutation\x1a\x16.google.protobuf.Empty\"\x1d\x82\xd3\xe4\x93\x02\x17:\x01*\"\x12/api/v1/ModifyHunt\x12]\n" +
 	"\fGetHuntFlows\x12\x16.proto.GetTableRequest\x1a\x17.proto.GetTableResponse\"\x1c\x82\xd3\xe4\x93\x02\x16\x12\x14/api/v1/GetHuntFlows\x12g\n" +
-	"\x0eGetHuntResults\x12\x1c.proto.GetHuntResultsRequest\x1a\x17.proto.GetTableResponse\"\x1e\x82\xd3\xe4\x93\x02\x18\x12\x16/api/v1/GetHuntResults\x12d\n" +
+	"\x0eGetHuntResults\x12\x1c.proto.GetHuntResultsRequest\x1a\x17.proto.GetTableResponse\"\x1e\x82\xd3\xe4\x93\x02\x18\x12\x16/api/v1/GetHuntResults\x12a\n" +
+	"\fGetHuntStack\x12\x1a.proto.GetHuntStackRequest\x1a\x17.proto.GetTableResponse\"\x1c\x82\xd3\xe4\x93\x02\x16\x12\x14/api/v1/GetHuntStack\x12d\n" +
 	"\rNotifyClients\x12\x1a.proto.NotificationRequest\x1a\x16.google.protobuf.Empty\"\x1f\x82\xd3\xe4\x93\x02\x19:\x01*\"\x14/api/v1/NotifyClient\x12_\n" +
 	"\fLabelClients\x12\x1a.proto.LabelClientsRequest\x1a\x12.proto.APIResponse\"\x1f\x82\xd3\xe4\x93\x02\x19:\x01*\"\x14/api/v1/LabelClients\x12g\n" +
 	"\vListClients\x12\x1b.proto.SearchClientsRequest\x1a\x1c.proto.SearchClientsResponse\"\x1d\x82\xd3\xe4\x93\x02\x17\x12\x15/api/v1/SearchClients\x12]\n" +
@@ -668,7 +669,12 @@ const file_api_proto_rawDesc = "" +
 	"\n" +
 	"CreateUser\x12\x18.proto.UpdateUserRequest\x1a\x16.google.protobuf.Empty\"\x1d\x82\xd3\xe4\x93\x02\x17:\x01*\"\x12/api/v1/CreateUser\x12W\n" +
 	"\x10GetUserFavorites\x12\x0f.proto.Favorite\x1a\x10.proto.Favorites\" \x82\xd3\xe4\x93\x02\x1a\x12\x18/api/v1/GetUserFavorites\x12`\n" +
-	"\vSetPassword\x12\x19.proto.SetPasswordRequest\x1a\x16.google.protobuf.Empty\"\x1e\x82\xd3\xe4\x93\x02\x18:\x01*\"\x13/api/v1/SetPassword\x12o\n" +
+	"\vSetPassword\x12\x19.proto.SetPasswordRequest\x1a\x16.google.protobuf.Empty\"\x1e\x82\xd3\xe4\x93\x02\x18:\x01*\"\x13/api/v1/SetPassword\x12p\n" +
+	"\x0eCreateApiToken\x12\x1c.proto.CreateApiTokenRequest\x1a\x1d.proto.CreateApiTokenResponse\"!\x82\xd3\xe4\x93\x02\x1b:\x01*\"\x16/api/v1/CreateApiToken\x12X\n" +
+	"\rListApiTokens\x12\x16.proto.ApiTokenRequest\x1a\x10.proto.ApiTokens\"\x1d\x82\xd3\xe4\x93\x02\x17\x12\x15/api/v1/ListApiTokens\x12c\n" +
+	"\x0eRevokeApiToken\x12\x16.proto.ApiTokenRequest\x1a\x16.google.protobuf.Empty\"!\x82\xd3\xe4\x93\x02\x1b:\x01*\"\x16/api/v1/RevokeApiToken\x12`\n" +
+	"\x0fListGUISessions\x12\x18.proto.GUISessionRequest\x1a\x12.proto.GUISessions\"\x1f\x82\xd3\xe4\x93\x02\x19\x12\x17/api/v1/ListGUISessions\x12i\n" +
+	"\x10RevokeGUISession\x12\x18.proto.GUISessionRequest\x1a\x16.google.protobuf.Empty\"#\x82\xd3\xe4\x93\x02\x1d:\x01*\"\x18/api/v1/RevokeGUISession\x12o\n" +
 	"\x10VFSListDirectory\x12\x15.proto.VFSListRequest\x1a\x16.proto.VFSListResponse\",\x82\xd3\xe4\x93\x02&\x12$/api/v1/VFSListDirectory/{client_id}\x12o\n" +
 	"\x15VFSListDirectoryFiles\x12\x16.proto.GetTableRequest\x1a\x17.proto.GetTableResponse\"%\x82\xd3\xe4\x93\x02\x1f\x12\x1d/api/v1/VFSListDirectoryFiles\x12\x82\x01\n" +
 	"\x13VFSRefreshDirectory\x12!.proto.VFSRefreshDirectoryRequest\x1a .proto.ArtifactCollectorResponse\"&\x82\xd3\xe4\x93\x02 :\x01*\"\x1b/api/v1/VFSRefreshDirectory\x12c\n" +
@@ -768,259 +774,278 @@ var file_api_proto_goTypes = []any{
 	(*emptypb.Empty)(nil),                         // 14: google.protobuf.Empty
 	(*HuntMutation)(nil),                          // 15: proto.HuntMutation
 	(*GetHuntResultsRequest)(nil),                 // 16: proto.GetHuntResultsRequest
-	(*LabelClientsRequest)(nil),                   // 17: proto.LabelClientsRequest
-	(*SearchClientsRequest)(nil),                  // 18: proto.SearchClientsRequest
-	(*GetClientRequest)(nil),                      // 19: proto.GetClientRequest
-	(*SetClientMetadataRequest)(nil),              // 20: proto.SetClientMetadataRequest
-	(*SetGUIOptionsRequest)(nil),                  // 21: proto.SetGUIOptionsRequest
-	(*UserRequest)(nil),                           // 22: proto.UserRequest
-	(*UserRoles)(nil),                             // 23: proto.UserRoles
-	(*UpdateUserRequest)(nil),                     // 24: proto.UpdateUserRequest
-	(*Favorite)(nil),                              // 25: proto.Favorite
-	(*SetPasswordRequest)(nil),                    // 26: proto.SetPasswordRequest
-	(*VFSListRequest)(nil),                        // 27: proto.VFSListRequest
-	(*VFSStatDownloadRequest)(nil),                // 28: proto.VFSStatDownloadRequest
-	(*SearchFileRequest)(nil),                     // 29: proto.SearchFileRequest
-	(*proto.ArtifactCollectorArgs)(nil),           // 30: proto.ArtifactCollectorArgs
-	(*ApiFlowRequest)(nil),                        // 31: proto.ApiFlowRequest
-	(*ReformatVQLMessage)(nil),                    // 32: proto.ReformatVQLMessage
-	(*GetArtifactsRequest)(nil),                   // 33: proto.GetArtifactsRequest
-	(*GetArtifactRequest)(nil),                    // 34: proto.GetArtifactRequest
-	(*SetArtifactRequest)(nil),                    // 35: proto.SetArtifactRequest
-	(*LoadArtifactPackRequest)(nil),               // 36: proto.LoadArtifactPackRequest
-	(*DocSearchRequest)(nil),                      // 37: proto.DocSearchRequest
-	(*proto1.Tool)(nil),                           // 38: proto.Tool
-	(*GetReportRequest)(nil),                      // 39: proto.GetReportRequest
-	(*proto.GetClientMonitoringStateRequest)(nil), // 40: proto.GetClientMonitoringStateRequest
-	(*proto.ClientEventTable)(nil),                // 41: proto.ClientEventTable
-	(*ListAvailableEventResultsRequest)(nil),      // 42: proto.ListAvailableEventResultsRequest
-	(*CreateDownloadRequest)(nil),                 // 43: proto.CreateDownloadRequest
-	(*NotebookCellRequest)(nil),                   // 44: proto.NotebookCellRequest
-	(*NotebookMetadata)(nil),                      // 45: proto.NotebookMetadata
-	(*NotebookExportRequest)(nil),                 // 46: proto.NotebookExportRequest
-	(*NotebookFileUploadRequest)(nil),             // 47: proto.NotebookFileUploadRequest
-	(*AnnotationRequest)(nil),                     // 48: proto.AnnotationRequest
-	(*Secret)(nil),                                // 49: proto.Secret
-	(*ModifySecretRequest)(nil),                   // 50: proto.ModifySecretRequest
-	(*proto2.VQLCollectorArgs)(nil),               // 51: proto.VQLCollectorArgs
-	(*proto2.VQLResponse)(nil),                    // 52: proto.VQLResponse
-	(*ScheduleRequest)(nil),                       // 53: proto.ScheduleRequest
-	(*DataRequest)(nil),                           // 54: proto.DataRequest
-	(*HealthCheckRequest)(nil),                    // 55: proto.HealthCheckRequest
-	(*LSPRequest)(nil),                            // 56: proto.LSPRequest
-	(*HuntStats)(nil),                             // 57: proto.HuntStats
-	(*GetTableResponse)(nil),                      // 58: proto.GetTableResponse
-	(*ListHuntsResponse)(nil),                     // 59: proto.ListHuntsResponse
-	(*HuntTags)(nil),                              // 60: proto.HuntTags
-	(*APIResponse)(nil),                           // 61: proto.APIResponse
-	(*SearchClientsResponse)(nil),                 // 62: proto.SearchClientsResponse
-	(*ApiClient)(nil),                             // 63: proto.ApiClient
-	(*ClientMetadata)(nil),                        // 64: proto.ClientMetadata
-	(*ApiUser)(nil),                               // 65: proto.ApiUser
-	(*SetGUIOptionsResponse)(nil),                 // 66: proto.SetGUIOptionsResponse
-	(*Users)(nil),                                 // 67: proto.Users
-	(*VelociraptorUser)(nil),                      // 68: proto.VelociraptorUser
-	(*Favorites)(nil),                             // 69: proto.Favorites
-	(*VFSListResponse)(nil),                       // 70: proto.VFSListResponse
-	(*proto.ArtifactCollectorResponse)(nil),       // 71: proto.ArtifactCollectorResponse
-	(*proto.VFSDownloadInfo)(nil),                 // 72: proto.VFSDownloadInfo
-	(*SearchFileResponse)(nil),                    // 73: proto.SearchFileResponse
-	(*FlowDetails)(nil),                           // 74: proto.FlowDetails
-	(*ApiFlowRequestDetails)(nil),                 // 75: proto.ApiFlowRequestDetails
-	(*KeywordCompletions)(nil),                    // 76: proto.KeywordCompletions
-	(*proto1.ArtifactDescriptors)(nil),            // 77: proto.ArtifactDescriptors
-	(*GetArtifactResponse)(nil),                   // 78: proto.GetArtifactResponse
-	(*SetArtifactResponse)(nil),                   // 79: proto.SetArtifactResponse
-	(*LoadArtifactPackResponse)(nil),              // 80: proto.LoadArtifactPackResponse
-	(*DocSearchResponses)(nil),                    // 81: proto.DocSearchResponses
-	(*GetReportResponse)(nil),                     // 82: proto.GetReportResponse
-	(*ListAvailableEventResultsResponse)(nil),     // 83: proto.ListAvailableEventResultsResponse
-	(*CreateDownloadResponse)(nil),                // 84: proto.CreateDownloadResponse
-	(*Notebooks)(nil),                             // 85: proto.Notebooks
-	(*NotebookCell)(nil),                          // 86: proto.NotebookCell
-	(*NotebookFileUploadResponse)(nil),            // 87: proto.NotebookFileUploadResponse
-	(*SecretDefinitionList)(nil),                  // 88: proto.SecretDefinitionList
-	(*ScheduleResponse)(nil),                      // 89: proto.ScheduleResponse
-	(*DataResponse)(nil),                          // 90: proto.DataResponse
-	(*ListChildrenResponse)(nil),                  // 91: proto.ListChildrenResponse
-	(*HealthCheckResponse)(nil),                   // 92: proto.HealthCheckResponse
-	(*LSPResponse)(nil),                           // 93: proto.LSPResponse
+	(*GetHuntStackRequest)(nil),                   // 17: proto.GetHuntStackRequest
+	(*LabelClientsRequest)(nil),                   // 18: proto.LabelClientsRequest
+	(*SearchClientsRequest)(nil),                  // 19: proto.SearchClientsRequest
+	(*GetClientRequest)(nil),                      // 20: proto.GetClientRequest
+	(*SetClientMetadataRequest)(nil),              // 21: proto.SetClientMetadataRequest
+	(*SetGUIOptionsRequest)(nil),                  // 22: proto.SetGUIOptionsRequest
+	(*UserRequest)(nil),                           // 23: proto.UserRequest
+	(*UserRoles)(nil),                             // 24: proto.UserRoles
+	(*UpdateUserRequest)(nil),                     // 25: proto.UpdateUserRequest
+	(*Favorite)(nil),                              // 26: proto.Favorite
+	(*SetPasswordRequest)(nil),                    // 27: proto.SetPasswordRequest
+	(*CreateApiTokenRequest)(nil),                 // 28: proto.CreateApiTokenRequest
+	(*ApiTokenRequest)(nil),                       // 29: proto.ApiTokenRequest
+	(*GUISessionRequest)(nil),                     // 30: proto.GUISessionRequest
+	(*VFSListRequest)(nil),                        // 31: proto.VFSListRequest
+	(*VFSStatDownloadRequest)(nil),                // 32: proto.VFSStatDownloadRequest
+	(*SearchFileRequest)(nil),                     // 33: proto.SearchFileRequest
+	(*proto.ArtifactCollectorArgs)(nil),           // 34: proto.ArtifactCollectorArgs
+	(*ApiFlowRequest)(nil),                        // 35: proto.ApiFlowRequest
+	(*ReformatVQLMessage)(nil),                    // 36: proto.ReformatVQLMessage
+	(*GetArtifactsRequest)(nil),                   // 37: proto.GetArtifactsRequest
+	(*GetArtifactRequest)(nil),                    // 38: proto.GetArtifactRequest
+	(*SetArtifactRequest)(nil),                    // 39: proto.SetArtifactRequest
+	(*LoadArtifactPackRequest)(nil),               // 40: proto.LoadArtifactPackRequest
+	(*DocSearchRequest)(nil),                      // 41: proto.DocSearchRequest
+	(*proto1.Tool)(nil),                           // 42: proto.Tool
+	(*GetReportRequest)(nil),                      // 43: proto.GetReportRequest
+	(*proto.GetClientMonitoringStateRequest)(nil), // 44: proto.GetClientMonitoringStateRequest
+	(*proto.ClientEventTable)(nil),                // 45: proto.ClientEventTable
+	(*ListAvailableEventResultsRequest)(nil),      // 46: proto.ListAvailableEventResultsRequest
+	(*CreateDownloadRequest)(nil),                 // 47: proto.CreateDownloadRequest
+	(*NotebookCellRequest)(nil),                   // 48: proto.NotebookCellRequest
+	(*NotebookMetadata)(nil),                      // 49: proto.NotebookMetadata
+	(*NotebookExportRequest)(nil),                 // 50: proto.NotebookExportRequest
+	(*NotebookFileUploadRequest)(nil),             // 51: proto.NotebookFileUploadRequest
+	(*AnnotationRequest)(nil),                     // 52: proto.AnnotationRequest
+	(*Secret)(nil),                                // 53: proto.Secret
+	(*ModifySecretRequest)(nil),                   // 54: proto.ModifySecretRequest
+	(*proto2.VQLCollectorArgs)(nil),               // 55: proto.VQLCollectorArgs
+	(*proto2.VQLResponse)(nil),                    // 56: proto.VQLResponse
+	(*ScheduleRequest)(nil),                       // 57: proto.ScheduleRequest
+	(*DataRequest)(nil),                           // 58: proto.DataRequest
+	(*HealthCheckRequest)(nil),                    // 59: proto.HealthCheckRequest
+	(*LSPRequest)(nil),                            // 60: proto.LSPRequest
+	(*HuntStats)(nil),                             // 61: proto.HuntStats
+	(*GetTableResponse)(nil),                      // 62: proto.GetTableResponse
+	(*ListHuntsResponse)(nil),                     // 63: proto.ListHuntsResponse
+	(*HuntTags)(nil),                              // 64: proto.HuntTags
+	(*APIResponse)(nil),                           // 65: proto.APIResponse
+	(*SearchClientsResponse)(nil),                 // 66: proto.SearchClientsResponse
+	(*ApiClient)(nil),                             // 67: proto.ApiClient
+	(*ClientMetadata)(nil),                        // 68: proto.ClientMetadata
+	(*ApiUser)(nil),                               // 69: proto.ApiUser
+	(*SetGUIOptionsResponse)(nil),                 // 70: proto.SetGUIOptionsResponse
+	(*Users)(nil),                                 // 71: proto.Users
+	(*VelociraptorUser)(nil),                      // 72: proto.VelociraptorUser
+	(*Favorites)(nil),                             // 73: proto.Favorites
+	(*CreateApiTokenResponse)(nil),                // 74: proto.CreateApiTokenResponse
+	(*ApiTokens)(nil),                             // 75: proto.ApiTokens
+	(*GUISessions)(nil),                           // 76: proto.GUISessions
+	(*VFSListResponse)(nil),                       // 77: proto.VFSListResponse
+	(*proto.ArtifactCollectorResponse)(nil),       // 78: proto.ArtifactCollectorResponse
+	(*proto.VFSDownloadInfo)(nil),                 // 79: proto.VFSDownloadInfo
+	(*SearchFileResponse)(nil),                    // 80: proto.SearchFileResponse
+	(*FlowDetails)(nil),                           // 81: proto.FlowDetails
+	(*ApiFlowRequestDetails)(nil),                 // 82: proto.ApiFlowRequestDetails
+	(*KeywordCompletions)(nil),                    // 83: proto.KeywordCompletions
+	(*proto1.ArtifactDescriptors)(nil),            // 84: proto.ArtifactDescriptors
+	(*GetArtifactResponse)(nil),                   // 85: proto.GetArtifactResponse
+	(*SetArtifactResponse)(nil),                   // 86: proto.SetArtifactResponse
+	(*LoadArtifactPackResponse)(nil),              // 87: proto.LoadArtifactPackResponse
+	(*DocSearchResponses)(nil),                    // 88: proto.DocSearchResponses
+	(*GetReportResponse)(nil),                     // 89: proto.GetReportResponse
+	(*ListAvailableEventResultsResponse)(nil),     // 90: proto.ListAvailableEventResultsResponse
+	(*CreateDownloadResponse)(nil),                // 91: proto.CreateDownloadResponse
+	(*Notebooks)(nil),                             // 92: proto.Notebooks
+	(*NotebookCell)(nil),                          // 93: proto.NotebookCell
+	(*NotebookFileUploadResponse)(nil),            // 94: proto.NotebookFileUploadResponse
+	(*SecretDefinitionList)(nil),                  // 95: proto.SecretDefinitionList
+	(*ScheduleResponse)(nil),                      // 96: proto.ScheduleResponse
+	(*DataResponse)(nil),                          // 97: proto.DataResponse
+	(*ListChildrenResponse)(nil),                  // 98: proto.ListChildrenResponse
+	(*HealthCheckResponse)(nil),                   // 99: proto.HealthCheckResponse
+	(*LSPResponse)(nil),                           // 100: proto.LSPResponse
 }
 var file_api_proto_depIdxs = []int32{
-	1,  // 0: proto.ApprovalList.items:type_name -> proto.Approval
-	9,  // 1: proto.API.CreateHunt:input_type -> proto.Hunt
-	10, // 2: proto.API.EstimateHunt:input_type -> proto.HuntEstimateRequest
-	11, // 3: proto.API.GetHuntTable:input_type -> proto.GetTableRequest
-	12, // 4: proto.API.ListHunts:input_type -> proto.ListHuntsRequest
-	13, // 5: proto.API.GetHunt:input_type -> proto.GetHuntRequest
-	14, // 6: proto.API.GetHuntTags:input_type -> google.protobuf.Empty
-	15, // 7: proto.API.ModifyHunt:input_type -> proto.HuntMutation
-	11, // 8: proto.API.GetHuntFlows:input_type -> proto.GetTableRequest
-	16, // 9: proto.API.GetHuntResults:input_type -> proto.GetHuntResultsRequest
-	5,  // 10: proto.API.NotifyClients:input_type -> proto.NotificationRequest
-	17, // 11: proto.API.LabelClients:input_type -> proto.LabelClientsRequest
-	18, // 12: proto.API.ListClients:input_type -> proto.SearchClientsRequest
-	19, // 13: proto.API.GetClient:input_type -> proto.GetClientRequest
-	19, // 14: proto.API.GetClientMetadata:input_type -> proto.GetClientRequest
-	20, // 15: proto.API.SetClientMetadata:input_type -> proto.SetClientMetadataRequest
-	11, // 16: proto.API.GetClientFlows:input_type -> proto.GetTableRequest
-	14, // 17: proto.API.GetUserUITraits:input_type -> google.protobuf.Empty
-	21, // 18: proto.API.SetGUIOptions:input_type -> proto.SetGUIOptionsRequest
-	14, // 19: proto.API.GetUsers:input_type -> google.protobuf.Empty
-	14, // 20: proto.API.GetGlobalUsers:input_type -> google.protobuf.Empty
-	22, // 21: proto.API.GetUserRoles:input_type -> proto.UserRequest
-	23, // 22: proto.API.SetUserRoles:input_type -> proto.UserRoles
-	22, // 23: proto.API.GetUser:input_type -> proto.UserRequest
-	24, // 24: proto.API.CreateUser:input_type -> proto.UpdateUserRequest
-	25, // 25: proto.API.GetUserFavorites:input_type -> proto.Favorite
-	26, // 26: proto.API.SetPassword:input_type -> proto.SetPasswordRequest
-	27, // 27: proto.API.VFSListDirectory:input_type -> proto.VFSListRequest
-	11, // 28: proto.API.VFSListDirectoryFiles:input_type -> proto.GetTableRequest
-	3,  // 29: proto.API.VFSRefreshDirectory:input_type -> proto.VFSRefreshDirectoryRequest
-	27, // 30: proto.API.VFSStatDirectory:input_type -> proto.VFSListRequest
-	28, // 31: proto.API.VFSStatDownload:input_type -> proto.VFSStatDownloadRequest
-	28, // 32: proto.API.VFSDownloadFile:input_type -> proto.VFSStatDownloadRequest
-	11, // 33: proto.API.GetTable:input_type -> proto.GetTableRequest
-	29, // 34: proto.API.SearchFile:input_type -> proto.SearchFileRequest
-	30, // 35: proto.API.CollectArtifact:input_type -> proto.ArtifactCollectorArgs
-	31, // 36: proto.API.CancelFlow:input_type -> proto.ApiFlowRequest
-	31, // 37: proto.API.ResumeFlow:input_type -> proto.ApiFlowRequest
-	31, // 38: proto.API.GetFlowDetails:input_type -> proto.ApiFlowRequest
-	31, // 39: proto.API.GetFlowRequests:input_type -> proto.ApiFlowRequest
-	14, // 40: proto.API.GetKeywordCompletions:input_type -> google.protobuf.Empty
-	32, // 41: proto.API.ReformatVQL:input_type -> proto.ReformatVQLMessage
-	33, // 42: proto.API.GetArtifacts:input_type -> proto.GetArtifactsRequest
-	34, // 43: proto.API.GetArtifactFile:input_type -> proto.GetArtifactRequest
-	35, // 44: proto.API.SetArtifactFile:input_type -> proto.SetArtifactRequest
-	36, // 45: proto.API.LoadArtifactPack:input_type -> proto.LoadArtifactPackRequest
-	37, // 46: proto.API.SearchDocs:input_type -> proto.DocSearchRequest
-	38, // 47: proto.API.GetToolInfo:input_type -> proto.Tool
-	38, // 48: proto.API.SetToolInfo:input_type -> proto.Tool
-	39, // 49: proto.API.GetReport:input_type -> proto.GetReportRequest
-	14, // 50: proto.API.GetServerMonitoringState:input_type -> google.protobuf.Empty
-	30, // 51: proto.API.SetServerMonitoringState:input_type -> proto.ArtifactCollectorArgs
-	40, // 52: proto.API.GetClientMonitoringState:input_type -> proto.GetClientMonitoringStateRequest
-	41, // 53: proto.API.SetClientMonitoringState:input_type -> proto.ClientEventTable
-	42, // 54: proto.API.ListAvailableEventResults:input_type -> proto.ListAvailableEventResultsRequest
-	43, // 55: proto.API.CreateDownloadFile:input_type -> proto.CreateDownloadRequest
-	44, // 56: proto.API.GetNotebooks:input_type -> proto.NotebookCellRequest
-	45, // 57: proto.API.NewNotebook:input_type -> proto.NotebookMetadata
-	45, // 58: proto.API.UpdateNotebook:input_type -> proto.NotebookMetadata
-	45, // 59: proto.API.DeleteNotebook:input_type -> proto.NotebookMetadata
-	44, // 60: proto.API.NewNotebookCell:input_type -> proto.NotebookCellRequest
-	44, // 61: proto.API.GetNotebookCell:input_type -> proto.NotebookCellRequest
-	44, // 62: proto.API.UpdateNotebookCell:input_type -> proto.NotebookCellRequest
-	44, // 63: proto.API.RevertNotebookCell:input_type -> proto.NotebookCellRequest
-	44, // 64: proto.API.CancelNotebookCell:input_type -> proto.NotebookCellRequest
-	46, // 65: proto.API.CreateNotebookDownloadFile:input_type -> proto.NotebookExportRequest
-	47, // 66: proto.API.UploadNotebookAttachment:input_type -> proto.NotebookFileUploadRequest
-	47, // 67: proto.API.RemoveNotebookAttachment:input_type -> proto.NotebookFileUploadRequest
-	48, // 68: proto.API.AnnotateTimeline:input_type -> proto.AnnotationRequest
-	14, // 69: proto.API.GetSecretDefinitions:input_type -> google.protobuf.Empty
-	49, // 70: proto.API.AddSecret:input_type -> proto.Secret
-	50, // 71: proto.API.ModifySecret:input_type -> proto.ModifySecretRequest
-	49, // 72: proto.API.GetSecret:input_type -> proto.Secret
-	4,  // 73: proto.API.VFSGetBuffer:input_type -> proto.VFSFileBuffer
-	51, // 74: proto.API.Query:input_type -> proto.VQLCollectorArgs
-	6,  // 75: proto.API.WatchEvent:input_type -> proto.EventRequest
-	8,  // 76: proto.API.PushEvents:input_type -> proto.PushEventRequest
-	52, // 77: proto.API.WriteEvent:input_type -> proto.VQLResponse
-	53, // 78: proto.API.Scheduler:input_type -> proto.ScheduleRequest
-	54, // 79: proto.API.GetSubject:input_type -> proto.DataRequest
-	54, // 80: proto.API.SetSubject:input_type -> proto.DataRequest
-	54, // 81: proto.API.DeleteSubject:input_type -> proto.DataRequest
-	54, // 82: proto.API.ListChildren:input_type -> proto.DataRequest
-	55, // 83: proto.API.Check:input_type -> proto.HealthCheckRequest
-	56, // 84: proto.API.LSP:input_type -> proto.LSPRequest
-	0,  // 85: proto.API.CreateHunt:output_type -> proto.StartFlowResponse
-	57, // 86: proto.API.EstimateHunt:output_type -> proto.HuntStats
-	58, // 87: proto.API.GetHuntTable:output_type -> proto.GetTableResponse
-	59, // 88: proto.API.ListHunts:output_type -> proto.ListHuntsResponse
-	9,  // 89: proto.API.GetHunt:output_type -> proto.Hunt
-	60, // 90: proto.API.GetHuntTags:output_type -> proto.HuntTags
-	14, // 91: proto.API.ModifyHunt:output_type -> google.protobuf.Empty
-	58, // 92: proto.API.GetHuntFlows:output_type -> proto.GetTableResponse
-	58, // 93: proto.API.GetHuntResults:output_type -> proto.GetTableResponse
-	14, // 94: proto.API.NotifyClients:output_type -> google.protobuf.Empty
-	61, // 95: proto.API.LabelClients:output_type -> proto.APIResponse
-	62, // 96: proto.API.ListClients:output_type -> proto.SearchClientsResponse
-	63, // 97: proto.API.GetClient:output_type -> proto.ApiClient
-	64, // 98: proto.API.GetClientMetadata:output_type -> proto.ClientMetadata
-	14, // 99: proto.API.SetClientMetadata:output_type -> google.protobuf.Empty
-	58, // 100: proto.API.GetClientFlows:output_type -> proto.GetTableResponse
-	65, // 101: proto.API.GetUserUITraits:output_type -> proto.ApiUser
-	66, // 102: proto.API.SetGUIOptions:output_type -> proto.SetGUIOptionsResponse
-	67, // 103: proto.API.GetUsers:output_type -> proto.Users
-	67, // 104: proto.API.GetGlobalUsers:output_type -> proto.Users
-	23, // 105: proto.API.GetUserRoles:output_type -> proto.UserRoles
-	14, // 106: proto.API.SetUserRoles:output_type -> google.protobuf.Empty
-	68, // 107: proto.API.GetUser:output_type -> proto.VelociraptorUser
-	14, // 108: proto.API.CreateUser:output_type -> google.protobuf.Empty
-	69, // 109: proto.API.GetUserFavorites:output_type -> proto.Favorites
-	14, // 110: proto.API.SetPassword:output_type -> google.protobuf.Empty
-	70, // 111: proto.API.VFSListDirectory:output_type -> proto.VFSListResponse
-	58, // 112: proto.API.VFSListDirectoryFiles:output_type -> proto.GetTableResponse
-	71, // 113: proto.API.VFSRefreshDirectory:output_type -> proto.ArtifactCollectorResponse
-	70, // 114: proto.API.VFSStatDirectory:output_type -> proto.VFSListResponse
-	72, // 115: proto.API.VFSStatDownload:output_type -> proto.VFSDownloadInfo
-	0,  // 116: proto.API.VFSDownloadFile:output_type -> proto.StartFlowResponse
-	58, // 117: proto.API.GetTable:output_type -> proto.GetTableResponse
-	73, // 118: proto.API.SearchFile:output_type -> proto.SearchFileResponse
-	71, // 119: proto.API.CollectArtifact:output_type -> proto.ArtifactCollectorResponse
-	0,  // 120: proto.API.CancelFlow:output_type -> proto.StartFlowResponse
-	14, // 121: proto.API.ResumeFlow:output_type -> google.protobuf.Empty
-	74, // 122: proto.API.GetFlowDetails:output_type -> proto.FlowDetails
-	75, // 123: proto.API.GetFlowRequests:output_type -> proto.ApiFlowRequestDetails
-	76, // 124: proto.API.GetKeywordCompletions:output_type -> proto.KeywordCompletions
-	32, // 125: proto.API.ReformatVQL:output_type -> proto.ReformatVQLMessage
-	77, // 126: proto.API.GetArtifacts:output_type -> proto.ArtifactDescriptors
-	78, // 127: proto.API.GetArtifactFile:output_type -> proto.GetArtifactResponse
-	79, // 128: proto.API.SetArtifactFile:output_type -> proto.SetArtifactResponse
-	80, // 129: proto.API.LoadArtifactPack:output_type -> proto.LoadArtifactPackResponse
-	81, // 130: proto.API.SearchDocs:output_type -> proto.DocSearchResponses
-	38, // 131: proto.API.GetToolInfo:output_type -> proto.Tool
-	38, // 132: proto.API.SetToolInfo:output_type -> proto.Tool
-	82, // 133: proto.API.GetReport:output_type -> proto.GetReportResponse
-	30, // 134: proto.API.GetServerMonitoringState:output_type -> proto.ArtifactCollectorArgs
-	30, // 135: proto.API.SetServerMonitoringState:output_type -> proto.ArtifactCollectorArgs
-	41, // 136: proto.API.GetClientMonitoringState:output_type -> proto.ClientEventTable
-	14, // 137: proto.API.SetClientMonitoringState:output_type -> google.protobuf.Empty
-	83, // 138: proto.API.ListAvailableEventResults:output_type -> proto.ListAvailableEventResultsResponse
-	84, // 139: proto.API.CreateDownloadFile:output_type -> proto.CreateDownloadResponse
-	85, // 140: proto.API.GetNotebooks:output_type -> proto.Notebooks
-	45, // 141: proto.API.NewNotebook:output_type -> proto.NotebookMetadata
-	45, // 142: proto.API.UpdateNotebook:output_type -> proto.NotebookMetadata
-	14, // 143: proto.API.DeleteNotebook:output_type -> google.protobuf.Empty
-	45, // 144: proto.API.NewNotebookCell:output_type -> proto.NotebookMetadata
-	86, // 145: proto.API.GetNotebookCell:output_type -> proto.NotebookCell
-	86, // 146: proto.API.UpdateNotebookCell:output_type -> proto.NotebookCell
-	86, // 147: proto.API.RevertNotebookCell:output_type -> proto.NotebookCell
-	14, // 148: proto.API.CancelNotebookCell:output_type -> google.protobuf.Empty
-	14, // 149: proto.API.CreateNotebookDownloadFile:output_type -> google.protobuf.Empty
-	87, // 150: proto.API.UploadNotebookAttachment:output_type -> proto.NotebookFileUploadResponse
-	14, // 151: proto.API.RemoveNotebookAttachment:output_type -> google.protobuf.Empty
-	14, // 152: proto.API.AnnotateTimeline:output_type -> google.protobuf.Empty
-	88, // 153: proto.API.GetSecretDefinitions:output_type -> proto.SecretDefinitionList
-	14, // 154: proto.API.AddSecret:output_type -> google.protobuf.Empty
-	14, // 155: proto.API.ModifySecret:output_type -> google.protobuf.Empty
-	49, // 156: proto.API.GetSecret:output_type -> proto.Secret
-	4,  // 157: proto.API.VFSGetBuffer:output_type -> proto.VFSFileBuffer
-	52, // 158: proto.API.Query:output_type -> proto.VQLResponse
-	7,  // 159: proto.API.WatchEvent:output_type -> proto.EventResponse
-	14, // 160: proto.API.PushEvents:output_type -> google.protobuf.Empty
-	14, // 161: proto.API.WriteEvent:output_type -> google.protobuf.Empty
-	89, // 162: proto.API.Scheduler:output_type -> proto.ScheduleResponse
-	90, // 163: proto.API.GetSubject:output_type -> proto.DataResponse
-	90, // 164: proto.API.SetSubject:output_type -> proto.DataResponse
-	14, // 165: proto.API.DeleteSubject:output_type -> google.protobuf.Empty
-	91, // 166: proto.API.ListChildren:output_type -> proto.ListChildrenResponse
-	92, // 167: proto.API.Check:output_type -> proto.HealthCheckResponse
-	93, // 168: proto.API.LSP:output_type -> proto.LSPResponse
-	85, // [85:169] is the sub-list for method output_type
-	1,  // [1:85] is the sub-list for method input_type
-	1,  // [1:1] is the sub-list for extension type_name
-	1,  // [1:1] is the sub-list for extension extendee
-	0,  // [0:1] is the sub-list for field type_name
+	1,   // 0: proto.ApprovalList.items:type_name -> proto.Approval
+	9,   // 1: proto.API.CreateHunt:input_type -> proto.Hunt
+	10,  // 2: proto.API.EstimateHunt:input_type -> proto.HuntEstimateRequest
+	11,  // 3: proto.API.GetHuntTable:input_type -> proto.GetTableRequest
+	12,  // 4: proto.API.ListHunts:input_type -> proto.ListHuntsRequest
+	13,  // 5: proto.API.GetHunt:input_type -> proto.GetHuntRequest
+	14,  // 6: proto.API.GetHuntTags:input_type -> google.protobuf.Empty
+	15,  // 7: proto.API.ModifyHunt:input_type -> proto.HuntMutation
+	11,  // 8: proto.API.GetHuntFlows:input_type -> proto.GetTableRequest
+	16,  // 9: proto.API.GetHuntResults:input_type -> proto.GetHuntResultsRequest
+	17,  // 10: proto.API.GetHuntStack:input_type -> proto.GetHuntStackRequest
+	5,   // 11: proto.API.NotifyClients:input_type -> proto.NotificationRequest
+	18,  // 12: proto.API.LabelClients:input_type -> proto.LabelClientsRequest
+	19,  // 13: proto.API.ListClients:input_type -> proto.SearchClientsRequest
+	20,  // 14: proto.API.GetClient:input_type -> proto.GetClientRequest
+	20,  // 15: proto.API.GetClientMetadata:input_type -> proto.GetClientRequest
+	21,  // 16: proto.API.SetClientMetadata:input_type -> proto.SetClientMetadataRequest
+	11,  // 17: proto.API.GetClientFlows:input_type -> proto.GetTableRequest
+	14,  // 18: proto.API.GetUserUITraits:input_type -> google.protobuf.Empty
+	22,  // 19: proto.API.SetGUIOptions:input_type -> proto.SetGUIOptionsRequest
+	14,  // 20: proto.API.GetUsers:input_type -> google.protobuf.Empty
+	14,  // 21: proto.API.GetGlobalUsers:input_type -> google.protobuf.Empty
+	23,  // 22: proto.API.GetUserRoles:input_type -> proto.UserRequest
+	24,  // 23: proto.API.SetUserRoles:input_type -> proto.UserRoles
+	23,  // 24: proto.API.GetUser:input_type -> proto.UserRequest
+	25,  // 25: proto.API.CreateUser:input_type -> proto.UpdateUserRequest
+	26,  // 26: proto.API.GetUserFavorites:input_type -> proto.Favorite
+	27,  // 27: proto.API.SetPassword:input_type -> proto.SetPasswordRequest
+	28,  // 28: proto.API.CreateApiToken:input_type -> proto.CreateApiTokenRequest
+	29,  // 29: proto.API.ListApiTokens:input_type -> proto.ApiTokenRequest
+	29,  // 30: proto.API.RevokeApiToken:input_type -> proto.ApiTokenRequest
+	30,  // 31: proto.API.ListGUISessions:input_type -> proto.GUISessionRequest
+	30,  // 32: proto.API.RevokeGUISession:input_type -> proto.GUISessionRequest
+	31,  // 33: proto.API.VFSListDirectory:input_type -> proto.VFSListRequest
+	11,  // 34: proto.API.VFSListDirectoryFiles:input_type -> proto.GetTableRequest
+	3,   // 35: proto.API.VFSRefreshDirectory:input_type -> proto.VFSRefreshDirectoryRequest
+	31,  // 36: proto.API.VFSStatDirectory:input_type -> proto.VFSListRequest
+	32,  // 37: proto.API.VFSStatDownload:input_type -> proto.VFSStatDownloadRequest
+	32,  // 38: proto.API.VFSDownloadFile:input_type -> proto.VFSStatDownloadRequest
+	11,  // 39: proto.API.GetTable:input_type -> proto.GetTableRequest
+	33,  // 40: proto.API.SearchFile:input_type -> proto.SearchFileRequest
+	34,  // 41: proto.API.CollectArtifact:input_type -> proto.ArtifactCollectorArgs
+	35,  // 42: proto.API.CancelFlow:input_type -> proto.ApiFlowRequest
+	35,  // 43: proto.API.ResumeFlow:input_type -> proto.ApiFlowRequest
+	35,  // 44: proto.API.GetFlowDetails:input_type -> proto.ApiFlowRequest
+	35,  // 45: proto.API.GetFlowRequests:input_type -> proto.ApiFlowRequest
+	14,  // 46: proto.API.GetKeywordCompletions:input_type -> google.protobuf.Empty
+	36,  // 47: proto.API.ReformatVQL:input_type -> proto.ReformatVQLMessage
+	37,  // 48: proto.API.GetArtifacts:input_type -> proto.GetArtifactsRequest
+	38,  // 49: proto.API.GetArtifactFile:input_type -> proto.GetArtifactRequest
+	39,  // 50: proto.API.SetArtifactFile:input_type -> proto.SetArtifactRequest
+	40,  // 51: proto.API.LoadArtifactPack:input_type -> proto.LoadArtifactPackRequest
+	41,  // 52: proto.API.SearchDocs:input_type -> proto.DocSearchRequest
+	42,  // 53: proto.API.GetToolInfo:input_type -> proto.Tool
+	42,  // 54: proto.API.SetToolInfo:input_type -> proto.Tool
+	43,  // 55: proto.API.GetReport:input_type -> proto.GetReportRequest
+	14,  // 56: proto.API.GetServerMonitoringState:input_type -> google.protobuf.Empty
+	34,  // 57: proto.API.SetServerMonitoringState:input_type -> proto.ArtifactCollectorArgs
+	44,  // 58: proto.API.GetClientMonitoringState:input_type -> proto.GetClientMonitoringStateRequest
+	45,  // 59: proto.API.SetClientMonitoringState:input_type -> proto.ClientEventTable
+	46,  // 60: proto.API.ListAvailableEventResults:input_type -> proto.ListAvailableEventResultsRequest
+	47,  // 61: proto.API.CreateDownloadFile:input_type -> proto.CreateDownloadRequest
+	48,  // 62: proto.API.GetNotebooks:input_type -> proto.NotebookCellRequest
+	49,  // 63: proto.API.NewNotebook:input_type -> proto.NotebookMetadata
+	49,  // 64: proto.API.UpdateNotebook:input_type -> proto.NotebookMetadata
+	49,  // 65: proto.API.DeleteNotebook:input_type -> proto.NotebookMetadata
+	48,  // 66: proto.API.NewNotebookCell:input_type -> proto.NotebookCellRequest
+	48,  // 67: proto.API.GetNotebookCell:input_type -> proto.NotebookCellRequest
+	48,  // 68: proto.API.UpdateNotebookCell:input_type -> proto.NotebookCellRequest
+	48,  // 69: proto.API.RevertNotebookCell:input_type -> proto.NotebookCellRequest
+	48,  // 70: proto.API.CancelNotebookCell:input_type -> proto.NotebookCellRequest
+	50,  // 71: proto.API.CreateNotebookDownloadFile:input_type -> proto.NotebookExportRequest
+	51,  // 72: proto.API.UploadNotebookAttachment:input_type -> proto.NotebookFileUploadRequest
+	51,  // 73: proto.API.RemoveNotebookAttachment:input_type -> proto.NotebookFileUploadRequest
+	52,  // 74: proto.API.AnnotateTimeline:input_type -> proto.AnnotationRequest
+	14,  // 75: proto.API.GetSecretDefinitions:input_type -> google.protobuf.Empty
+	53,  // 76: proto.API.AddSecret:input_type -> proto.Secret
+	54,  // 77: proto.API.ModifySecret:input_type -> proto.ModifySecretRequest
+	53,  // 78: proto.API.GetSecret:input_type -> proto.Secret
+	4,   // 79: proto.API.VFSGetBuffer:input_type -> proto.VFSFileBuffer
+	55,  // 80: proto.API.Query:input_type -> proto.VQLCollectorArgs
+	6,   // 81: proto.API.WatchEvent:input_type -> proto.EventRequest
+	8,   // 82: proto.API.PushEvents:input_type -> proto.PushEventRequest
+	56,  // 83: proto.API.WriteEvent:input_type -> proto.VQLResponse
+	57,  // 84: proto.API.Scheduler:input_type -> proto.ScheduleRequest
+	58,  // 85: proto.API.GetSubject:input_type -> proto.DataRequest
+	58,  // 86: proto.API.SetSubject:input_type -> proto.DataRequest
+	58,  // 87: proto.API.DeleteSubject:input_type -> proto.DataRequest
+	58,  // 88: proto.API.ListChildren:input_type -> proto.DataRequest
+	59,  // 89: proto.API.Check:input_type -> proto.HealthCheckRequest
+	60,  // 90: proto.API.LSP:input_type -> proto.LSPRequest
+	0,   // 91: proto.API.CreateHunt:output_type -> proto.StartFlowResponse
+	61,  // 92: proto.API.EstimateHunt:output_type -> proto.HuntStats
+	62,  // 93: proto.API.GetHuntTable:output_type -> proto.GetTableResponse
+	63,  // 94: proto.API.ListHunts:output_type -> proto.ListHuntsResponse
+	9,   // 95: proto.API.GetHunt:output_type -> proto.Hunt
+	64,  // 96: proto.API.GetHuntTags:output_type -> proto.HuntTags
+	14,  // 97: proto.API.ModifyHunt:output_type -> google.protobuf.Empty
+	62,  // 98: proto.API.GetHuntFlows:output_type -> proto.GetTableResponse
+	62,  // 99: proto.API.GetHuntResults:output_type -> proto.GetTableResponse
+	62,  // 100: proto.API.GetHuntStack:output_type -> proto.GetTableResponse
+	14,  // 101: proto.API.NotifyClients:output_type -> google.protobuf.Empty
+	65,  // 102: proto.API.LabelClients:output_type -> proto.APIResponse
+	66,  // 103: proto.API.ListClients:output_type -> proto.SearchClientsResponse
+	67,  // 104: proto.API.GetClient:output_type -> proto.ApiClient
+	68,  // 105: proto.API.GetClientMetadata:output_type -> proto.ClientMetadata
+	14,  // 106: proto.API.SetClientMetadata:output_type -> google.protobuf.Empty
+	62,  // 107: proto.API.GetClientFlows:output_type -> proto.GetTableResponse
+	69,  // 108: proto.API.GetUserUITraits:output_type -> proto.ApiUser
+	70,  // 109: proto.API.SetGUIOptions:output_type -> proto.SetGUIOptionsResponse
+	71,  // 110: proto.API.GetUsers:output_type -> proto.Users
+	71,  // 111: proto.API.GetGlobalUsers:output_type -> proto.Users
+	24,  // 112: proto.API.GetUserRoles:output_type -> proto.UserRoles
+	14,  // 113: proto.API.SetUserRoles:output_type -> google.protobuf.Empty
+	72,  // 114: proto.API.GetUser:output_type -> proto.VelociraptorUser
+	14,  // 115: proto.API.CreateUser:output_type -> google.protobuf.Empty
+	73,  // 116: proto.API.GetUserFavorites:output_type -> proto.Favorites
+	14,  // 117: proto.API.SetPassword:output_type -> google.protobuf.Empty
+	74,  // 118: proto.API.CreateApiToken:output_type -> proto.CreateApiTokenResponse
+	75,  // 119: proto.API.ListApiTokens:output_type -> proto.ApiTokens
+	14,  // 120: proto.API.RevokeApiToken:output_type -> google.protobuf.Empty
+	76,  // 121: proto.API.ListGUISessions:output_type -> proto.GUISessions
+	14,  // 122: proto.API.RevokeGUISession:output_type -> google.protobuf.Empty
+	77,  // 123: proto.API.VFSListDirectory:output_type -> proto.VFSListResponse
+	62,  // 124: proto.API.VFSListDirectoryFiles:output_type -> proto.GetTableResponse
+	78,  // 125: proto.API.VFSRefreshDirectory:output_type -> proto.ArtifactCollectorResponse
+	77,  // 126: proto.API.VFSStatDirectory:output_type -> proto.VFSListResponse
+	79,  // 127: proto.API.VFSStatDownload:output_type -> proto.VFSDownloadInfo
+	0,   // 128: proto.API.VFSDownloadFile:output_type -> proto.StartFlowResponse
+	62,  // 129: proto.API.GetTable:output_type -> proto.GetTableResponse
+	80,  // 130: proto.API.SearchFile:output_type -> proto.SearchFileResponse
+	78,  // 131: proto.API.CollectArtifact:output_type -> proto.ArtifactCollectorResponse
+	0,   // 132: proto.API.CancelFlow:output_type -> proto.StartFlowResponse
+	14,  // 133: proto.API.ResumeFlow:output_type -> google.protobuf.Empty
+	81,  // 134: proto.API.GetFlowDetails:output_type -> proto.FlowDetails
+	82,  // 135: proto.API.GetFlowRequests:output_type -> proto.ApiFlowRequestDetails
+	83,  // 136: proto.API.GetKeywordCompletions:output_type -> proto.KeywordCompletions
+	36,  // 137: proto.API.ReformatVQL:output_type -> proto.ReformatVQLMessage
+	84,  // 138: proto.API.GetArtifacts:output_type -> proto.ArtifactDescriptors
+	85,  // 139: proto.API.GetArtifactFile:output_type -> proto.GetArtifactResponse
+	86,  // 140: proto.API.SetArtifactFile:output_type -> proto.SetArtifactResponse
+	87,  // 141: proto.API.LoadArtifactPack:output_type -> proto.LoadArtifactPackResponse
+	88,  // 142: proto.API.SearchDocs:output_type -> proto.DocSearchResponses
+	42,  // 143: proto.API.GetToolInfo:output_type -> proto.Tool
+	42,  // 144: proto.API.SetToolInfo:output_type -> proto.Tool
+	89,  // 145: proto.API.GetReport:output_type -> proto.GetReportResponse
+	34,  // 146: proto.API.GetServerMonitoringState:output_type -> proto.ArtifactCollectorArgs
+	34,  // 147: proto.API.SetServerMonitoringState:output_type -> proto.ArtifactCollectorArgs
+	45,  // 148: proto.API.GetClientMonitoringState:output_type -> proto.ClientEventTable
+	14,  // 149: proto.API.SetClientMonitoringState:output_type -> google.protobuf.Empty
+	90,  // 150: proto.API.ListAvailableEventResults:output_type -> proto.ListAvailableEventResultsResponse
+	91,  // 151: proto.API.CreateDownloadFile:output_type -> proto.CreateDownloadResponse
+	92,  // 152: proto.API.GetNotebooks:output_type -> proto.Notebooks
+	49,  // 153: proto.API.NewNotebook:output_type -> proto.NotebookMetadata
+	49,  // 154: proto.API.UpdateNotebook:output_type -> proto.NotebookMetadata
+	14,  // 155: proto.API.DeleteNotebook:output_type -> google.protobuf.Empty
+	49,  // 156: proto.API.NewNotebookCell:output_type -> proto.NotebookMetadata
+	93,  // 157: proto.API.GetNotebookCell:output_type -> proto.NotebookCell
+	93,  // 158: proto.API.UpdateNotebookCell:output_type -> proto.NotebookCell
+	93,  // 159: proto.API.RevertNotebookCell:output_type -> proto.NotebookCell
+	14,  // 160: proto.API.CancelNotebookCell:output_type -> google.protobuf.Empty
+	14,  // 161: proto.API.CreateNotebookDownloadFile:output_type -> google.protobuf.Empty
+	94,  // 162: proto.API.UploadNotebookAttachment:output_type -> proto.NotebookFileUploadResponse
+	14,  // 163: proto.API.RemoveNotebookAttachment:output_type -> google.protobuf.Empty
+	14,  // 164: proto.API.AnnotateTimeline:output_type -> google.protobuf.Empty
+	95,  // 165: proto.API.GetSecretDefinitions:output_type -> proto.SecretDefinitionList
+	14,  // 166: proto.API.AddSecret:output_type -> google.protobuf.Empty
+	14,  // 167: proto.API.ModifySecret:output_type -> google.protobuf.Empty
+	53,  // 168: proto.API.GetSecret:output_type -> proto.Secret
+	4,   // 169: proto.API.VFSGetBuffer:output_type -> proto.VFSFileBuffer
+	56,  // 170: proto.API.Query:output_type -> proto.VQLResponse
+	7,   // 171: proto.API.WatchEvent:output_type -> proto.EventResponse
+	14,  // 172: proto.API.PushEvents:output_type -> google.protobuf.Empty
+	14,  // 173: proto.API.WriteEvent:output_type -> google.protobuf.Empty
+	96,  // 174: proto.API.Scheduler:output_type -> proto.ScheduleResponse
+	97,  // 175: proto.API.GetSubject:output_type -> proto.DataResponse
+	97,  // 176: proto.API.SetSubject:output_type -> proto.DataResponse
+	14,  // 177: proto.API.DeleteSubject:output_type -> google.protobuf.Empty
+	98,  // 178: proto.API.ListChildren:output_type -> proto.ListChildrenResponse
+	99,  // 179: proto.API.Check:output_type -> proto.HealthCheckResponse
+	100, // 180: proto.API.LSP:output_type -> proto.LSPResponse
+	91,  // [91:181] is the sub-list for method output_type
+	1,   // [1:91] is the sub-list for method input_type
+	1,   // [1:1] is the sub-list for extension type_name
+	1,   // [1:1] is the sub-list for extension extendee
+	0,   // [0:1] is the sub-list for field type_name
 }
 
 func init() { file_api_proto_init() }
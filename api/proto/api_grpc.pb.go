@@ -36,6 +36,7 @@ type APIClient interface {
 	ModifyHunt(ctx context.Context, in *HuntMutation, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	GetHuntFlows(ctx context.Context, in *GetTableRequest, opts ...grpc.CallOption) (*GetTableResponse, error)
 	GetHuntResults(ctx context.Context, in *GetHuntResultsRequest, opts ...grpc.CallOption) (*GetTableResponse, error)
+	GetHuntStack(ctx context.Context, in *GetHuntStackRequest, opts ...grpc.CallOption) (*GetTableResponse, error)
 	// Clients.
 	NotifyClients(ctx context.Context, in *NotificationRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	LabelClients(ctx context.Context, in *LabelClientsRequest, opts ...grpc.CallOption) (*APIResponse, error)
@@ -57,6 +58,13 @@ type APIClient interface {
 	CreateUser(ctx context.Context, in *UpdateUserRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	GetUserFavorites(ctx context.Context, in *Favorite, opts ...grpc.CallOption) (*Favorites, error)
 	SetPassword(ctx context.Context, in *SetPasswordRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// API Tokens
+	CreateApiToken(ctx context.Context, in *CreateApiTokenRequest, opts ...grpc.CallOption) (*CreateApiTokenResponse, error)
+	ListApiTokens(ctx context.Context, in *ApiTokenRequest, opts ...grpc.CallOption) (*ApiTokens, error)
+	RevokeApiToken(ctx context.Context, in *ApiTokenRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// GUI sessions
+	ListGUISessions(ctx context.Context, in *GUISessionRequest, opts ...grpc.CallOption) (*GUISessions, error)
+	RevokeGUISession(ctx context.Context, in *GUISessionRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	// VFS
 	VFSListDirectory(ctx context.Context, in *VFSListRequest, opts ...grpc.CallOption) (*VFSListResponse, error)
 	VFSListDirectoryFiles(ctx context.Context, in *GetTableRequest, opts ...grpc.CallOption) (*GetTableResponse, error)
@@ -236,6 +244,15 @@ func (c *aPIClient) GetHuntResults(ctx context.Context, in *GetHuntResultsReques
 	return out, nil
 }
 
+func (c *aPIClient) GetHuntStack(ctx context.Context, in *GetHuntStackRequest, opts ...grpc.CallOption) (*GetTableResponse, error) {
+	out := new(GetTableResponse)
+	err := c.cc.Invoke(ctx, "/proto.API/GetHuntStack", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *aPIClient) NotifyClients(ctx context.Context, in *NotificationRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
 	out := new(emptypb.Empty)
 	err := c.cc.Invoke(ctx, "/proto.API/NotifyClients", in, out, opts...)
@@ -389,6 +406,51 @@ func (c *aPIClient) SetPassword(ctx context.Context, in *SetPasswordRequest, opt
 	return out, nil
 }
 
+func (c *aPIClient) CreateApiToken(ctx context.Context, in *CreateApiTokenRequest, opts ...grpc.CallOption) (*CreateApiTokenResponse, error) {
+	out := new(CreateApiTokenResponse)
+	err := c.cc.Invoke(ctx, "/proto.API/CreateApiToken", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) ListApiTokens(ctx context.Context, in *ApiTokenRequest, opts ...grpc.CallOption) (*ApiTokens, error) {
+	out := new(ApiTokens)
+	err := c.cc.Invoke(ctx, "/proto.API/ListApiTokens", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) RevokeApiToken(ctx context.Context, in *ApiTokenRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/proto.API/RevokeApiToken", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) ListGUISessions(ctx context.Context, in *GUISessionRequest, opts ...grpc.CallOption) (*GUISessions, error) {
+	out := new(GUISessions)
+	err := c.cc.Invoke(ctx, "/proto.API/ListGUISessions", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) RevokeGUISession(ctx context.Context, in *GUISessionRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/proto.API/RevokeGUISession", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *aPIClient) VFSListDirectory(ctx context.Context, in *VFSListRequest, opts ...grpc.CallOption) (*VFSListResponse, error) {
 	out := new(VFSListResponse)
 	err := c.cc.Invoke(ctx, "/proto.API/VFSListDirectory", in, out, opts...)
@@ -996,6 +1058,7 @@ type APIServer interface {
 	ModifyHunt(context.Context, *HuntMutation) (*emptypb.Empty, error)
 	GetHuntFlows(context.Context, *GetTableRequest) (*GetTableResponse, error)
 	GetHuntResults(context.Context, *GetHuntResultsRequest) (*GetTableResponse, error)
+	GetHuntStack(context.Context, *GetHuntStackRequest) (*GetTableResponse, error)
 	// Clients.
 	NotifyClients(context.Context, *NotificationRequest) (*emptypb.Empty, error)
 	LabelClients(context.Context, *LabelClientsRequest) (*APIResponse, error)
@@ -1017,6 +1080,13 @@ type APIServer interface {
 	CreateUser(context.Context, *UpdateUserRequest) (*emptypb.Empty, error)
 	GetUserFavorites(context.Context, *Favorite) (*Favorites, error)
 	SetPassword(context.Context, *SetPasswordRequest) (*emptypb.Empty, error)
+	// API Tokens
+	CreateApiToken(context.Context, *CreateApiTokenRequest) (*CreateApiTokenResponse, error)
+	ListApiTokens(context.Context, *ApiTokenRequest) (*ApiTokens, error)
+	RevokeApiToken(context.Context, *ApiTokenRequest) (*emptypb.Empty, error)
+	// GUI sessions
+	ListGUISessions(context.Context, *GUISessionRequest) (*GUISessions, error)
+	RevokeGUISession(context.Context, *GUISessionRequest) (*emptypb.Empty, error)
 	// VFS
 	VFSListDirectory(context.Context, *VFSListRequest) (*VFSListResponse, error)
 	VFSListDirectoryFiles(context.Context, *GetTableRequest) (*GetTableResponse, error)
@@ -1139,6 +1209,9 @@ func (UnimplementedAPIServer) GetHuntFlows(context.Context, *GetTableRequest) (*
 func (UnimplementedAPIServer) GetHuntResults(context.Context, *GetHuntResultsRequest) (*GetTableResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetHuntResults not implemented")
 }
+func (UnimplementedAPIServer) GetHuntStack(context.Context, *GetHuntStackRequest) (*GetTableResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetHuntStack not implemented")
+}
 func (UnimplementedAPIServer) NotifyClients(context.Context, *NotificationRequest) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method NotifyClients not implemented")
 }
@@ -1190,6 +1263,21 @@ func (UnimplementedAPIServer) GetUserFavorites(context.Context, *Favorite) (*Fav
 func (UnimplementedAPIServer) SetPassword(context.Context, *SetPasswordRequest) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method SetPassword not implemented")
 }
+func (UnimplementedAPIServer) CreateApiToken(context.Context, *CreateApiTokenRequest) (*CreateApiTokenResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateApiToken not implemented")
+}
+func (UnimplementedAPIServer) ListApiTokens(context.Context, *ApiTokenRequest) (*ApiTokens, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListApiTokens not implemented")
+}
+func (UnimplementedAPIServer) RevokeApiToken(context.Context, *ApiTokenRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokeApiToken not implemented")
+}
+func (UnimplementedAPIServer) ListGUISessions(context.Context, *GUISessionRequest) (*GUISessions, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListGUISessions not implemented")
+}
+func (UnimplementedAPIServer) RevokeGUISession(context.Context, *GUISessionRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokeGUISession not implemented")
+}
 func (UnimplementedAPIServer) VFSListDirectory(context.Context, *VFSListRequest) (*VFSListResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method VFSListDirectory not implemented")
 }
@@ -1539,6 +1627,24 @@ func _API_GetHuntResults_Handler(srv interface{}, ctx context.Context, dec func(
 	return interceptor(ctx, in, info, handler)
 }
 
+func _API_GetHuntStack_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetHuntStackRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).GetHuntStack(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.API/GetHuntStack",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).GetHuntStack(ctx, req.(*GetHuntStackRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _API_NotifyClients_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(NotificationRequest)
 	if err := dec(in); err != nil {
@@ -1845,6 +1951,96 @@ func _API_SetPassword_Handler(srv interface{}, ctx context.Context, dec func(int
 	return interceptor(ctx, in, info, handler)
 }
 
+func _API_CreateApiToken_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateApiTokenRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).CreateApiToken(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.API/CreateApiToken",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).CreateApiToken(ctx, req.(*CreateApiTokenRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_ListApiTokens_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ApiTokenRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).ListApiTokens(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.API/ListApiTokens",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).ListApiTokens(ctx, req.(*ApiTokenRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_RevokeApiToken_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ApiTokenRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).RevokeApiToken(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.API/RevokeApiToken",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).RevokeApiToken(ctx, req.(*ApiTokenRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_ListGUISessions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GUISessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).ListGUISessions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.API/ListGUISessions",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).ListGUISessions(ctx, req.(*GUISessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_RevokeGUISession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GUISessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).RevokeGUISession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.API/RevokeGUISession",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).RevokeGUISession(ctx, req.(*GUISessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _API_VFSListDirectory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(VFSListRequest)
 	if err := dec(in); err != nil {
@@ -2946,6 +3142,10 @@ var API_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetHuntResults",
 			Handler:    _API_GetHuntResults_Handler,
 		},
+		{
+			MethodName: "GetHuntStack",
+			Handler:    _API_GetHuntStack_Handler,
+		},
 		{
 			MethodName: "NotifyClients",
 			Handler:    _API_NotifyClients_Handler,
@@ -3014,6 +3214,26 @@ var API_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "SetPassword",
 			Handler:    _API_SetPassword_Handler,
 		},
+		{
+			MethodName: "CreateApiToken",
+			Handler:    _API_CreateApiToken_Handler,
+		},
+		{
+			MethodName: "ListApiTokens",
+			Handler:    _API_ListApiTokens_Handler,
+		},
+		{
+			MethodName: "RevokeApiToken",
+			Handler:    _API_RevokeApiToken_Handler,
+		},
+		{
+			MethodName: "ListGUISessions",
+			Handler:    _API_ListGUISessions_Handler,
+		},
+		{
+			MethodName: "RevokeGUISession",
+			Handler:    _API_RevokeGUISession_Handler,
+		},
 		{
 			MethodName: "VFSListDirectory",
 			Handler:    _API_VFSListDirectory_Handler,
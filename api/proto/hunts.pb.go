@@ -904,6 +904,208 @@ func (x *GetHuntResultsRequest) GetArtifact() string {
 	return ""
 }
 
+type GetHuntStackRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	HuntId   string                 `protobuf:"bytes,1,opt,name=hunt_id,json=huntId,proto3" json:"hunt_id,omitempty"`
+	Artifact string                 `protobuf:"bytes,2,opt,name=artifact,proto3" json:"artifact,omitempty"`
+	Column   string                 `protobuf:"bytes,3,opt,name=column,proto3" json:"column,omitempty"`
+	// Maximum number of client ids to return for each value.
+	Limit         uint64 `protobuf:"varint,4,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetHuntStackRequest) Reset() {
+	*x = GetHuntStackRequest{}
+	mi := &file_hunts_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetHuntStackRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetHuntStackRequest) ProtoMessage() {}
+
+func (x *GetHuntStackRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_hunts_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetHuntStackRequest.ProtoReflect.Descriptor instead.
+func (*GetHuntStackRequest) Descriptor() ([]byte, []int) {
+	return file_hunts_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *GetHuntStackRequest) GetHuntId() string {
+	if x != nil {
+		return x.HuntId
+	}
+	return ""
+}
+
+func (x *GetHuntStackRequest) GetArtifact() string {
+	if x != nil {
+		return x.Artifact
+	}
+	return ""
+}
+
+func (x *GetHuntStackRequest) GetColumn() string {
+	if x != nil {
+		return x.Column
+	}
+	return ""
+}
+
+func (x *GetHuntStackRequest) GetLimit() uint64 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+// The stacking index counts each distinct value of a column across
+// all the clients in the hunt. It is maintained by the hunt manager
+// as flows complete.
+type HuntStackValue struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Value string                 `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
+	// Total number of rows with this value.
+	Count uint64 `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	// The clients which returned this value.
+	ClientIds     []string `protobuf:"bytes,3,rep,name=client_ids,json=clientIds,proto3" json:"client_ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HuntStackValue) Reset() {
+	*x = HuntStackValue{}
+	mi := &file_hunts_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HuntStackValue) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HuntStackValue) ProtoMessage() {}
+
+func (x *HuntStackValue) ProtoReflect() protoreflect.Message {
+	mi := &file_hunts_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HuntStackValue.ProtoReflect.Descriptor instead.
+func (*HuntStackValue) Descriptor() ([]byte, []int) {
+	return file_hunts_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *HuntStackValue) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *HuntStackValue) GetCount() uint64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *HuntStackValue) GetClientIds() []string {
+	if x != nil {
+		return x.ClientIds
+	}
+	return nil
+}
+
+type HuntStack struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	HuntId        string                 `protobuf:"bytes,1,opt,name=hunt_id,json=huntId,proto3" json:"hunt_id,omitempty"`
+	Artifact      string                 `protobuf:"bytes,2,opt,name=artifact,proto3" json:"artifact,omitempty"`
+	Column        string                 `protobuf:"bytes,3,opt,name=column,proto3" json:"column,omitempty"`
+	Values        []*HuntStackValue      `protobuf:"bytes,4,rep,name=values,proto3" json:"values,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HuntStack) Reset() {
+	*x = HuntStack{}
+	mi := &file_hunts_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HuntStack) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HuntStack) ProtoMessage() {}
+
+func (x *HuntStack) ProtoReflect() protoreflect.Message {
+	mi := &file_hunts_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HuntStack.ProtoReflect.Descriptor instead.
+func (*HuntStack) Descriptor() ([]byte, []int) {
+	return file_hunts_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *HuntStack) GetHuntId() string {
+	if x != nil {
+		return x.HuntId
+	}
+	return ""
+}
+
+func (x *HuntStack) GetArtifact() string {
+	if x != nil {
+		return x.Artifact
+	}
+	return ""
+}
+
+func (x *HuntStack) GetColumn() string {
+	if x != nil {
+		return x.Column
+	}
+	return ""
+}
+
+func (x *HuntStack) GetValues() []*HuntStackValue {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
 type FlowAssignment struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	ClientId      string                 `protobuf:"bytes,1,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`
@@ -914,7 +1116,7 @@ type FlowAssignment struct {
 
 func (x *FlowAssignment) Reset() {
 	*x = FlowAssignment{}
-	mi := &file_hunts_proto_msgTypes[10]
+	mi := &file_hunts_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -926,7 +1128,7 @@ func (x *FlowAssignment) String() string {
 func (*FlowAssignment) ProtoMessage() {}
 
 func (x *FlowAssignment) ProtoReflect() protoreflect.Message {
-	mi := &file_hunts_proto_msgTypes[10]
+	mi := &file_hunts_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -939,7 +1141,7 @@ func (x *FlowAssignment) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use FlowAssignment.ProtoReflect.Descriptor instead.
 func (*FlowAssignment) Descriptor() ([]byte, []int) {
-	return file_hunts_proto_rawDescGZIP(), []int{10}
+	return file_hunts_proto_rawDescGZIP(), []int{13}
 }
 
 func (x *FlowAssignment) GetClientId() string {
@@ -979,7 +1181,7 @@ type HuntMutation struct {
 
 func (x *HuntMutation) Reset() {
 	*x = HuntMutation{}
-	mi := &file_hunts_proto_msgTypes[11]
+	mi := &file_hunts_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -991,7 +1193,7 @@ func (x *HuntMutation) String() string {
 func (*HuntMutation) ProtoMessage() {}
 
 func (x *HuntMutation) ProtoReflect() protoreflect.Message {
-	mi := &file_hunts_proto_msgTypes[11]
+	mi := &file_hunts_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1004,7 +1206,7 @@ func (x *HuntMutation) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use HuntMutation.ProtoReflect.Descriptor instead.
 func (*HuntMutation) Descriptor() ([]byte, []int) {
-	return file_hunts_proto_rawDescGZIP(), []int{11}
+	return file_hunts_proto_rawDescGZIP(), []int{14}
 }
 
 func (x *HuntMutation) GetHuntId() string {
@@ -1086,7 +1288,7 @@ type HuntTags struct {
 
 func (x *HuntTags) Reset() {
 	*x = HuntTags{}
-	mi := &file_hunts_proto_msgTypes[12]
+	mi := &file_hunts_proto_msgTypes[15]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1098,7 +1300,7 @@ func (x *HuntTags) String() string {
 func (*HuntTags) ProtoMessage() {}
 
 func (x *HuntTags) ProtoReflect() protoreflect.Message {
-	mi := &file_hunts_proto_msgTypes[12]
+	mi := &file_hunts_proto_msgTypes[15]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1111,7 +1313,7 @@ func (x *HuntTags) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use HuntTags.ProtoReflect.Descriptor instead.
 func (*HuntTags) Descriptor() ([]byte, []int) {
-	return file_hunts_proto_rawDescGZIP(), []int{12}
+	return file_hunts_proto_rawDescGZIP(), []int{15}
 }
 
 func (x *HuntTags) GetTags() []string {
@@ -1205,7 +1407,22 @@ const file_hunts_proto_rawDesc = "" +
 	"\x06offset\x18\x01 \x01(\x04R\x06offset\x12\x14\n" +
 	"\x05count\x18\x02 \x01(\x04R\x05count\x12\x17\n" +
 	"\ahunt_id\x18\x03 \x01(\tR\x06huntId\x12\x1a\n" +
-	"\bartifact\x18\x04 \x01(\tR\bartifact\"F\n" +
+	"\bartifact\x18\x04 \x01(\tR\bartifact\"x\n" +
+	"\x13GetHuntStackRequest\x12\x17\n" +
+	"\ahunt_id\x18\x01 \x01(\tR\x06huntId\x12\x1a\n" +
+	"\bartifact\x18\x02 \x01(\tR\bartifact\x12\x16\n" +
+	"\x06column\x18\x03 \x01(\tR\x06column\x12\x14\n" +
+	"\x05limit\x18\x04 \x01(\x04R\x05limit\"[\n" +
+	"\x0eHuntStackValue\x12\x14\n" +
+	"\x05value\x18\x01 \x01(\tR\x05value\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\x04R\x05count\x12\x1d\n" +
+	"\n" +
+	"client_ids\x18\x03 \x03(\tR\tclientIds\"\x87\x01\n" +
+	"\tHuntStack\x12\x17\n" +
+	"\ahunt_id\x18\x01 \x01(\tR\x06huntId\x12\x1a\n" +
+	"\bartifact\x18\x02 \x01(\tR\bartifact\x12\x16\n" +
+	"\x06column\x18\x03 \x01(\tR\x06column\x12-\n" +
+	"\x06values\x18\x04 \x03(\v2\x15.proto.HuntStackValueR\x06values\"F\n" +
 	"\x0eFlowAssignment\x12\x1b\n" +
 	"\tclient_id\x18\x01 \x01(\tR\bclientId\x12\x17\n" +
 	"\aflow_id\x18\x02 \x01(\tR\x06flowId\"\xd4\x02\n" +
@@ -1240,7 +1457,7 @@ func file_hunts_proto_rawDescGZIP() []byte {
 }
 
 var file_hunts_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
-var file_hunts_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
+var file_hunts_proto_msgTypes = make([]protoimpl.MessageInfo, 16)
 var file_hunts_proto_goTypes = []any{
 	(HuntOsCondition_OS)(0),             // 0: proto.HuntOsCondition.OS
 	(Hunt_State)(0),                     // 1: proto.Hunt.State
@@ -1254,32 +1471,36 @@ var file_hunts_proto_goTypes = []any{
 	(*ListHuntsResponse)(nil),           // 9: proto.ListHuntsResponse
 	(*GetHuntRequest)(nil),              // 10: proto.GetHuntRequest
 	(*GetHuntResultsRequest)(nil),       // 11: proto.GetHuntResultsRequest
-	(*FlowAssignment)(nil),              // 12: proto.FlowAssignment
-	(*HuntMutation)(nil),                // 13: proto.HuntMutation
-	(*HuntTags)(nil),                    // 14: proto.HuntTags
-	(*AvailableDownloads)(nil),          // 15: proto.AvailableDownloads
-	(*proto.ArtifactCollectorArgs)(nil), // 16: proto.ArtifactCollectorArgs
+	(*GetHuntStackRequest)(nil),         // 12: proto.GetHuntStackRequest
+	(*HuntStackValue)(nil),              // 13: proto.HuntStackValue
+	(*HuntStack)(nil),                   // 14: proto.HuntStack
+	(*FlowAssignment)(nil),              // 15: proto.FlowAssignment
+	(*HuntMutation)(nil),                // 16: proto.HuntMutation
+	(*HuntTags)(nil),                    // 17: proto.HuntTags
+	(*AvailableDownloads)(nil),          // 18: proto.AvailableDownloads
+	(*proto.ArtifactCollectorArgs)(nil), // 19: proto.ArtifactCollectorArgs
 }
 var file_hunts_proto_depIdxs = []int32{
 	0,  // 0: proto.HuntOsCondition.os:type_name -> proto.HuntOsCondition.OS
 	2,  // 1: proto.HuntCondition.excluded_labels:type_name -> proto.HuntLabelCondition
 	2,  // 2: proto.HuntCondition.labels:type_name -> proto.HuntLabelCondition
 	3,  // 3: proto.HuntCondition.os:type_name -> proto.HuntOsCondition
-	15, // 4: proto.HuntStats.available_downloads:type_name -> proto.AvailableDownloads
-	16, // 5: proto.Hunt.start_request:type_name -> proto.ArtifactCollectorArgs
+	18, // 4: proto.HuntStats.available_downloads:type_name -> proto.AvailableDownloads
+	19, // 5: proto.Hunt.start_request:type_name -> proto.ArtifactCollectorArgs
 	4,  // 6: proto.Hunt.condition:type_name -> proto.HuntCondition
 	5,  // 7: proto.Hunt.stats:type_name -> proto.HuntStats
 	1,  // 8: proto.Hunt.state:type_name -> proto.Hunt.State
 	4,  // 9: proto.HuntEstimateRequest.condition:type_name -> proto.HuntCondition
 	6,  // 10: proto.ListHuntsResponse.items:type_name -> proto.Hunt
-	5,  // 11: proto.HuntMutation.stats:type_name -> proto.HuntStats
-	1,  // 12: proto.HuntMutation.state:type_name -> proto.Hunt.State
-	12, // 13: proto.HuntMutation.assignment:type_name -> proto.FlowAssignment
-	14, // [14:14] is the sub-list for method output_type
-	14, // [14:14] is the sub-list for method input_type
-	14, // [14:14] is the sub-list for extension type_name
-	14, // [14:14] is the sub-list for extension extendee
-	0,  // [0:14] is the sub-list for field type_name
+	13, // 11: proto.HuntStack.values:type_name -> proto.HuntStackValue
+	5,  // 12: proto.HuntMutation.stats:type_name -> proto.HuntStats
+	1,  // 13: proto.HuntMutation.state:type_name -> proto.Hunt.State
+	15, // 14: proto.HuntMutation.assignment:type_name -> proto.FlowAssignment
+	15, // [15:15] is the sub-list for method output_type
+	15, // [15:15] is the sub-list for method input_type
+	15, // [15:15] is the sub-list for extension type_name
+	15, // [15:15] is the sub-list for extension extendee
+	0,  // [0:15] is the sub-list for field type_name
 }
 
 func init() { file_hunts_proto_init() }
@@ -1298,7 +1519,7 @@ func file_hunts_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_hunts_proto_rawDesc), len(file_hunts_proto_rawDesc)),
 			NumEnums:      2,
-			NumMessages:   13,
+			NumMessages:   16,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
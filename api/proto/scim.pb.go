@@ -0,0 +1,179 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: scim.proto
+
+package proto
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// SCIM attributes of a user provisioned by an identity provider. The
+// Velociraptor user record remains the source of truth for the
+// account itself.
+type SCIMUser struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	UserName    string                 `protobuf:"bytes,1,opt,name=user_name,json=userName,proto3" json:"user_name,omitempty"`
+	ExternalId  string                 `protobuf:"bytes,2,opt,name=external_id,json=externalId,proto3" json:"external_id,omitempty"`
+	DisplayName string                 `protobuf:"bytes,3,opt,name=display_name,json=displayName,proto3" json:"display_name,omitempty"`
+	Emails      []string               `protobuf:"bytes,4,rep,name=emails,proto3" json:"emails,omitempty"`
+	// The SCIM groups the user is a member of.
+	Groups        []string `protobuf:"bytes,5,rep,name=groups,proto3" json:"groups,omitempty"`
+	Created       uint64   `protobuf:"varint,6,opt,name=created,proto3" json:"created,omitempty"`
+	Modified      uint64   `protobuf:"varint,7,opt,name=modified,proto3" json:"modified,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SCIMUser) Reset() {
+	*x = SCIMUser{}
+	mi := &file_scim_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SCIMUser) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SCIMUser) ProtoMessage() {}
+
+func (x *SCIMUser) ProtoReflect() protoreflect.Message {
+	mi := &file_scim_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SCIMUser.ProtoReflect.Descriptor instead.
+func (*SCIMUser) Descriptor() ([]byte, []int) {
+	return file_scim_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *SCIMUser) GetUserName() string {
+	if x != nil {
+		return x.UserName
+	}
+	return ""
+}
+
+func (x *SCIMUser) GetExternalId() string {
+	if x != nil {
+		return x.ExternalId
+	}
+	return ""
+}
+
+func (x *SCIMUser) GetDisplayName() string {
+	if x != nil {
+		return x.DisplayName
+	}
+	return ""
+}
+
+func (x *SCIMUser) GetEmails() []string {
+	if x != nil {
+		return x.Emails
+	}
+	return nil
+}
+
+func (x *SCIMUser) GetGroups() []string {
+	if x != nil {
+		return x.Groups
+	}
+	return nil
+}
+
+func (x *SCIMUser) GetCreated() uint64 {
+	if x != nil {
+		return x.Created
+	}
+	return 0
+}
+
+func (x *SCIMUser) GetModified() uint64 {
+	if x != nil {
+		return x.Modified
+	}
+	return 0
+}
+
+var File_scim_proto protoreflect.FileDescriptor
+
+const file_scim_proto_rawDesc = "" +
+	"\n" +
+	"\n" +
+	"scim.proto\x12\x05proto\"\xd1\x01\n" +
+	"\bSCIMUser\x12\x1b\n" +
+	"\tuser_name\x18\x01 \x01(\tR\buserName\x12\x1f\n" +
+	"\vexternal_id\x18\x02 \x01(\tR\n" +
+	"externalId\x12!\n" +
+	"\fdisplay_name\x18\x03 \x01(\tR\vdisplayName\x12\x16\n" +
+	"\x06emails\x18\x04 \x03(\tR\x06emails\x12\x16\n" +
+	"\x06groups\x18\x05 \x03(\tR\x06groups\x12\x18\n" +
+	"\acreated\x18\x06 \x01(\x04R\acreated\x12\x1a\n" +
+	"\bmodified\x18\a \x01(\x04R\bmodifiedB1Z/www.velocidex.com/golang/velociraptor/api/protob\x06proto3"
+
+var (
+	file_scim_proto_rawDescOnce sync.Once
+	file_scim_proto_rawDescData []byte
+)
+
+func file_scim_proto_rawDescGZIP() []byte {
+	file_scim_proto_rawDescOnce.Do(func() {
+		file_scim_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_scim_proto_rawDesc), len(file_scim_proto_rawDesc)))
+	})
+	return file_scim_proto_rawDescData
+}
+
+var file_scim_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_scim_proto_goTypes = []any{
+	(*SCIMUser)(nil), // 0: proto.SCIMUser
+}
+var file_scim_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_scim_proto_init() }
+func file_scim_proto_init() {
+	if File_scim_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_scim_proto_rawDesc), len(file_scim_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_scim_proto_goTypes,
+		DependencyIndexes: file_scim_proto_depIdxs,
+		MessageInfos:      file_scim_proto_msgTypes,
+	}.Build()
+	File_scim_proto = out.File
+	file_scim_proto_goTypes = nil
+	file_scim_proto_depIdxs = nil
+}
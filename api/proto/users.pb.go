@@ -68,7 +68,7 @@ func (x ApiUser_UserType) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use ApiUser_UserType.Descriptor instead.
 func (ApiUser_UserType) EnumDescriptor() ([]byte, []int) {
-	return file_users_proto_rawDescGZIP(), []int{7, 0}
+	return file_users_proto_rawDescGZIP(), []int{11, 0}
 }
 
 type Strings struct {
@@ -168,6 +168,517 @@ func (x *UserStats) GetLastIpAddress() string {
 	return ""
 }
 
+// A registered WebAuthn security key.
+type WebAuthnCredential struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Name            string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Id              []byte                 `protobuf:"bytes,2,opt,name=id,proto3" json:"id,omitempty"`
+	PublicKey       []byte                 `protobuf:"bytes,3,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
+	AttestationType string                 `protobuf:"bytes,4,opt,name=attestation_type,json=attestationType,proto3" json:"attestation_type,omitempty"`
+	Aaguid          []byte                 `protobuf:"bytes,5,opt,name=aaguid,proto3" json:"aaguid,omitempty"`
+	SignCount       uint32                 `protobuf:"varint,6,opt,name=sign_count,json=signCount,proto3" json:"sign_count,omitempty"`
+	Transports      []string               `protobuf:"bytes,7,rep,name=transports,proto3" json:"transports,omitempty"`
+	BackupEligible  bool                   `protobuf:"varint,8,opt,name=backup_eligible,json=backupEligible,proto3" json:"backup_eligible,omitempty"`
+	BackupState     bool                   `protobuf:"varint,9,opt,name=backup_state,json=backupState,proto3" json:"backup_state,omitempty"`
+	Created         int64                  `protobuf:"varint,10,opt,name=created,proto3" json:"created,omitempty"`
+	LastUsed        int64                  `protobuf:"varint,11,opt,name=last_used,json=lastUsed,proto3" json:"last_used,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *WebAuthnCredential) Reset() {
+	*x = WebAuthnCredential{}
+	mi := &file_users_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WebAuthnCredential) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WebAuthnCredential) ProtoMessage() {}
+
+func (x *WebAuthnCredential) ProtoReflect() protoreflect.Message {
+	mi := &file_users_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WebAuthnCredential.ProtoReflect.Descriptor instead.
+func (*WebAuthnCredential) Descriptor() ([]byte, []int) {
+	return file_users_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *WebAuthnCredential) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *WebAuthnCredential) GetId() []byte {
+	if x != nil {
+		return x.Id
+	}
+	return nil
+}
+
+func (x *WebAuthnCredential) GetPublicKey() []byte {
+	if x != nil {
+		return x.PublicKey
+	}
+	return nil
+}
+
+func (x *WebAuthnCredential) GetAttestationType() string {
+	if x != nil {
+		return x.AttestationType
+	}
+	return ""
+}
+
+func (x *WebAuthnCredential) GetAaguid() []byte {
+	if x != nil {
+		return x.Aaguid
+	}
+	return nil
+}
+
+func (x *WebAuthnCredential) GetSignCount() uint32 {
+	if x != nil {
+		return x.SignCount
+	}
+	return 0
+}
+
+func (x *WebAuthnCredential) GetTransports() []string {
+	if x != nil {
+		return x.Transports
+	}
+	return nil
+}
+
+func (x *WebAuthnCredential) GetBackupEligible() bool {
+	if x != nil {
+		return x.BackupEligible
+	}
+	return false
+}
+
+func (x *WebAuthnCredential) GetBackupState() bool {
+	if x != nil {
+		return x.BackupState
+	}
+	return false
+}
+
+func (x *WebAuthnCredential) GetCreated() int64 {
+	if x != nil {
+		return x.Created
+	}
+	return 0
+}
+
+func (x *WebAuthnCredential) GetLastUsed() int64 {
+	if x != nil {
+		return x.LastUsed
+	}
+	return 0
+}
+
+// Second factor enrollment for the basic authenticator.
+type UserMFA struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The TOTP secret is only enabled after the user proves they
+	// can produce a valid code.
+	TotpSecret  []byte `protobuf:"bytes,1,opt,name=totp_secret,json=totpSecret,proto3" json:"totp_secret,omitempty"`
+	TotpEnabled bool   `protobuf:"varint,2,opt,name=totp_enabled,json=totpEnabled,proto3" json:"totp_enabled,omitempty"`
+	// The last TOTP time step used - codes may not be replayed.
+	TotpLastStep int64 `protobuf:"varint,3,opt,name=totp_last_step,json=totpLastStep,proto3" json:"totp_last_step,omitempty"`
+	// The opaque WebAuthn user handle.
+	WebauthnUserId      []byte                `protobuf:"bytes,4,opt,name=webauthn_user_id,json=webauthnUserId,proto3" json:"webauthn_user_id,omitempty"`
+	WebauthnCredentials []*WebAuthnCredential `protobuf:"bytes,5,rep,name=webauthn_credentials,json=webauthnCredentials,proto3" json:"webauthn_credentials,omitempty"`
+	// A TOTP secret being enrolled.
+	PendingTotpSecret []byte `protobuf:"bytes,6,opt,name=pending_totp_secret,json=pendingTotpSecret,proto3" json:"pending_totp_secret,omitempty"`
+	// Consecutive invalid TOTP codes and the time (seconds since
+	// epoch) until which TOTP verification is locked out.
+	TotpFailedAttempts int64 `protobuf:"varint,7,opt,name=totp_failed_attempts,json=totpFailedAttempts,proto3" json:"totp_failed_attempts,omitempty"`
+	TotpLockedUntil    int64 `protobuf:"varint,8,opt,name=totp_locked_until,json=totpLockedUntil,proto3" json:"totp_locked_until,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *UserMFA) Reset() {
+	*x = UserMFA{}
+	mi := &file_users_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserMFA) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserMFA) ProtoMessage() {}
+
+func (x *UserMFA) ProtoReflect() protoreflect.Message {
+	mi := &file_users_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserMFA.ProtoReflect.Descriptor instead.
+func (*UserMFA) Descriptor() ([]byte, []int) {
+	return file_users_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *UserMFA) GetTotpSecret() []byte {
+	if x != nil {
+		return x.TotpSecret
+	}
+	return nil
+}
+
+func (x *UserMFA) GetTotpEnabled() bool {
+	if x != nil {
+		return x.TotpEnabled
+	}
+	return false
+}
+
+func (x *UserMFA) GetTotpLastStep() int64 {
+	if x != nil {
+		return x.TotpLastStep
+	}
+	return 0
+}
+
+func (x *UserMFA) GetWebauthnUserId() []byte {
+	if x != nil {
+		return x.WebauthnUserId
+	}
+	return nil
+}
+
+func (x *UserMFA) GetWebauthnCredentials() []*WebAuthnCredential {
+	if x != nil {
+		return x.WebauthnCredentials
+	}
+	return nil
+}
+
+func (x *UserMFA) GetPendingTotpSecret() []byte {
+	if x != nil {
+		return x.PendingTotpSecret
+	}
+	return nil
+}
+
+func (x *UserMFA) GetTotpFailedAttempts() int64 {
+	if x != nil {
+		return x.TotpFailedAttempts
+	}
+	return 0
+}
+
+func (x *UserMFA) GetTotpLockedUntil() int64 {
+	if x != nil {
+		return x.TotpLockedUntil
+	}
+	return 0
+}
+
+// A bearer token for programmatic API access. The token acts with a
+// subset of its owner's permissions.
+type ApiToken struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	TokenId string                 `protobuf:"bytes,1,opt,name=token_id,json=tokenId,proto3" json:"token_id,omitempty"`
+	// The user who owns the token.
+	Username string `protobuf:"bytes,2,opt,name=username,proto3" json:"username,omitempty"`
+	// A description of what the token is for.
+	Name string `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	// The permissions the token carries. These are further limited
+	// by the owner's permissions at the time of use.
+	Permissions []string `protobuf:"bytes,4,rep,name=permissions,proto3" json:"permissions,omitempty"`
+	// The orgs the token may be used in.
+	Orgs         []string `protobuf:"bytes,5,rep,name=orgs,proto3" json:"orgs,omitempty"`
+	Created      uint64   `protobuf:"varint,6,opt,name=created,proto3" json:"created,omitempty"`
+	Expires      uint64   `protobuf:"varint,7,opt,name=expires,proto3" json:"expires,omitempty"`
+	LastUsed     uint64   `protobuf:"varint,8,opt,name=last_used,json=lastUsed,proto3" json:"last_used,omitempty"`
+	LastUsedFrom string   `protobuf:"bytes,9,opt,name=last_used_from,json=lastUsedFrom,proto3" json:"last_used_from,omitempty"`
+	// SHA256 of the token secret.
+	Hash          []byte `protobuf:"bytes,10,opt,name=hash,proto3" json:"hash,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ApiToken) Reset() {
+	*x = ApiToken{}
+	mi := &file_users_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ApiToken) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ApiToken) ProtoMessage() {}
+
+func (x *ApiToken) ProtoReflect() protoreflect.Message {
+	mi := &file_users_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ApiToken.ProtoReflect.Descriptor instead.
+func (*ApiToken) Descriptor() ([]byte, []int) {
+	return file_users_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ApiToken) GetTokenId() string {
+	if x != nil {
+		return x.TokenId
+	}
+	return ""
+}
+
+func (x *ApiToken) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *ApiToken) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ApiToken) GetPermissions() []string {
+	if x != nil {
+		return x.Permissions
+	}
+	return nil
+}
+
+func (x *ApiToken) GetOrgs() []string {
+	if x != nil {
+		return x.Orgs
+	}
+	return nil
+}
+
+func (x *ApiToken) GetCreated() uint64 {
+	if x != nil {
+		return x.Created
+	}
+	return 0
+}
+
+func (x *ApiToken) GetExpires() uint64 {
+	if x != nil {
+		return x.Expires
+	}
+	return 0
+}
+
+func (x *ApiToken) GetLastUsed() uint64 {
+	if x != nil {
+		return x.LastUsed
+	}
+	return 0
+}
+
+func (x *ApiToken) GetLastUsedFrom() string {
+	if x != nil {
+		return x.LastUsedFrom
+	}
+	return ""
+}
+
+func (x *ApiToken) GetHash() []byte {
+	if x != nil {
+		return x.Hash
+	}
+	return nil
+}
+
+// A GUI session tracked by the server. The session is identified by
+// a hash of the cookie the browser presents on each request.
+type GUISession struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	SessionId string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	Username  string                 `protobuf:"bytes,2,opt,name=username,proto3" json:"username,omitempty"`
+	// The authenticator type the user logged in with.
+	Authenticator  string `protobuf:"bytes,3,opt,name=authenticator,proto3" json:"authenticator,omitempty"`
+	LoginTime      uint64 `protobuf:"varint,4,opt,name=login_time,json=loginTime,proto3" json:"login_time,omitempty"`
+	LoginFrom      string `protobuf:"bytes,5,opt,name=login_from,json=loginFrom,proto3" json:"login_from,omitempty"`
+	LastActive     uint64 `protobuf:"varint,6,opt,name=last_active,json=lastActive,proto3" json:"last_active,omitempty"`
+	LastActiveFrom string `protobuf:"bytes,7,opt,name=last_active_from,json=lastActiveFrom,proto3" json:"last_active_from,omitempty"`
+	UserAgent      string `protobuf:"bytes,8,opt,name=user_agent,json=userAgent,proto3" json:"user_agent,omitempty"`
+	Expires        uint64 `protobuf:"varint,9,opt,name=expires,proto3" json:"expires,omitempty"`
+	// Revoked sessions are kept until they expire so the cookie is
+	// rejected.
+	Revoked       bool   `protobuf:"varint,10,opt,name=revoked,proto3" json:"revoked,omitempty"`
+	RevokedTime   uint64 `protobuf:"varint,11,opt,name=revoked_time,json=revokedTime,proto3" json:"revoked_time,omitempty"`
+	RevokedBy     string `protobuf:"bytes,12,opt,name=revoked_by,json=revokedBy,proto3" json:"revoked_by,omitempty"`
+	RevokedReason string `protobuf:"bytes,13,opt,name=revoked_reason,json=revokedReason,proto3" json:"revoked_reason,omitempty"`
+	// SHA256 of the session cookie.
+	Hash          []byte `protobuf:"bytes,14,opt,name=hash,proto3" json:"hash,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GUISession) Reset() {
+	*x = GUISession{}
+	mi := &file_users_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GUISession) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GUISession) ProtoMessage() {}
+
+func (x *GUISession) ProtoReflect() protoreflect.Message {
+	mi := &file_users_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GUISession.ProtoReflect.Descriptor instead.
+func (*GUISession) Descriptor() ([]byte, []int) {
+	return file_users_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GUISession) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *GUISession) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *GUISession) GetAuthenticator() string {
+	if x != nil {
+		return x.Authenticator
+	}
+	return ""
+}
+
+func (x *GUISession) GetLoginTime() uint64 {
+	if x != nil {
+		return x.LoginTime
+	}
+	return 0
+}
+
+func (x *GUISession) GetLoginFrom() string {
+	if x != nil {
+		return x.LoginFrom
+	}
+	return ""
+}
+
+func (x *GUISession) GetLastActive() uint64 {
+	if x != nil {
+		return x.LastActive
+	}
+	return 0
+}
+
+func (x *GUISession) GetLastActiveFrom() string {
+	if x != nil {
+		return x.LastActiveFrom
+	}
+	return ""
+}
+
+func (x *GUISession) GetUserAgent() string {
+	if x != nil {
+		return x.UserAgent
+	}
+	return ""
+}
+
+func (x *GUISession) GetExpires() uint64 {
+	if x != nil {
+		return x.Expires
+	}
+	return 0
+}
+
+func (x *GUISession) GetRevoked() bool {
+	if x != nil {
+		return x.Revoked
+	}
+	return false
+}
+
+func (x *GUISession) GetRevokedTime() uint64 {
+	if x != nil {
+		return x.RevokedTime
+	}
+	return 0
+}
+
+func (x *GUISession) GetRevokedBy() string {
+	if x != nil {
+		return x.RevokedBy
+	}
+	return ""
+}
+
+func (x *GUISession) GetRevokedReason() string {
+	if x != nil {
+		return x.RevokedReason
+	}
+	return ""
+}
+
+func (x *GUISession) GetHash() []byte {
+	if x != nil {
+		return x.Hash
+	}
+	return nil
+}
+
 type VelociraptorUser struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
@@ -188,15 +699,18 @@ type VelociraptorUser struct {
 	Orgs []*OrgRecord `protobuf:"bytes,11,rep,name=orgs,proto3" json:"orgs,omitempty"`
 	// Only used by the GUI/API to determine the currently selected
 	// org the user wants to see.
-	CurrentOrg    string     `protobuf:"bytes,12,opt,name=current_org,json=currentOrg,proto3" json:"current_org,omitempty"`
-	Stats         *UserStats `protobuf:"bytes,13,opt,name=stats,proto3" json:"stats,omitempty"`
+	CurrentOrg    string        `protobuf:"bytes,12,opt,name=current_org,json=currentOrg,proto3" json:"current_org,omitempty"`
+	Stats         *UserStats    `protobuf:"bytes,13,opt,name=stats,proto3" json:"stats,omitempty"`
+	Mfa           *UserMFA      `protobuf:"bytes,15,opt,name=mfa,proto3" json:"mfa,omitempty"`
+	ApiTokens     []*ApiToken   `protobuf:"bytes,16,rep,name=api_tokens,json=apiTokens,proto3" json:"api_tokens,omitempty"`
+	GuiSessions   []*GUISession `protobuf:"bytes,17,rep,name=gui_sessions,json=guiSessions,proto3" json:"gui_sessions,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *VelociraptorUser) Reset() {
 	*x = VelociraptorUser{}
-	mi := &file_users_proto_msgTypes[2]
+	mi := &file_users_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -208,7 +722,7 @@ func (x *VelociraptorUser) String() string {
 func (*VelociraptorUser) ProtoMessage() {}
 
 func (x *VelociraptorUser) ProtoReflect() protoreflect.Message {
-	mi := &file_users_proto_msgTypes[2]
+	mi := &file_users_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -221,7 +735,7 @@ func (x *VelociraptorUser) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use VelociraptorUser.ProtoReflect.Descriptor instead.
 func (*VelociraptorUser) Descriptor() ([]byte, []int) {
-	return file_users_proto_rawDescGZIP(), []int{2}
+	return file_users_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *VelociraptorUser) GetName() string {
@@ -315,6 +829,27 @@ func (x *VelociraptorUser) GetStats() *UserStats {
 	return nil
 }
 
+func (x *VelociraptorUser) GetMfa() *UserMFA {
+	if x != nil {
+		return x.Mfa
+	}
+	return nil
+}
+
+func (x *VelociraptorUser) GetApiTokens() []*ApiToken {
+	if x != nil {
+		return x.ApiTokens
+	}
+	return nil
+}
+
+func (x *VelociraptorUser) GetGuiSessions() []*GUISession {
+	if x != nil {
+		return x.GuiSessions
+	}
+	return nil
+}
+
 type UpdateUserRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
@@ -328,7 +863,7 @@ type UpdateUserRequest struct {
 
 func (x *UpdateUserRequest) Reset() {
 	*x = UpdateUserRequest{}
-	mi := &file_users_proto_msgTypes[3]
+	mi := &file_users_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -340,7 +875,7 @@ func (x *UpdateUserRequest) String() string {
 func (*UpdateUserRequest) ProtoMessage() {}
 
 func (x *UpdateUserRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_users_proto_msgTypes[3]
+	mi := &file_users_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -353,7 +888,7 @@ func (x *UpdateUserRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UpdateUserRequest.ProtoReflect.Descriptor instead.
 func (*UpdateUserRequest) Descriptor() ([]byte, []int) {
-	return file_users_proto_rawDescGZIP(), []int{3}
+	return file_users_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *UpdateUserRequest) GetName() string {
@@ -401,7 +936,7 @@ type DeleteUserRequest struct {
 
 func (x *DeleteUserRequest) Reset() {
 	*x = DeleteUserRequest{}
-	mi := &file_users_proto_msgTypes[4]
+	mi := &file_users_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -413,7 +948,7 @@ func (x *DeleteUserRequest) String() string {
 func (*DeleteUserRequest) ProtoMessage() {}
 
 func (x *DeleteUserRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_users_proto_msgTypes[4]
+	mi := &file_users_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -426,7 +961,7 @@ func (x *DeleteUserRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DeleteUserRequest.ProtoReflect.Descriptor instead.
 func (*DeleteUserRequest) Descriptor() ([]byte, []int) {
-	return file_users_proto_rawDescGZIP(), []int{4}
+	return file_users_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *DeleteUserRequest) GetName() string {
@@ -453,7 +988,7 @@ type UserRequest struct {
 
 func (x *UserRequest) Reset() {
 	*x = UserRequest{}
-	mi := &file_users_proto_msgTypes[5]
+	mi := &file_users_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -465,7 +1000,7 @@ func (x *UserRequest) String() string {
 func (*UserRequest) ProtoMessage() {}
 
 func (x *UserRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_users_proto_msgTypes[5]
+	mi := &file_users_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -478,7 +1013,7 @@ func (x *UserRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UserRequest.ProtoReflect.Descriptor instead.
 func (*UserRequest) Descriptor() ([]byte, []int) {
-	return file_users_proto_rawDescGZIP(), []int{5}
+	return file_users_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *UserRequest) GetName() string {
@@ -533,7 +1068,7 @@ type ApiUserInterfaceTraits struct {
 
 func (x *ApiUserInterfaceTraits) Reset() {
 	*x = ApiUserInterfaceTraits{}
-	mi := &file_users_proto_msgTypes[6]
+	mi := &file_users_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -545,7 +1080,7 @@ func (x *ApiUserInterfaceTraits) String() string {
 func (*ApiUserInterfaceTraits) ProtoMessage() {}
 
 func (x *ApiUserInterfaceTraits) ProtoReflect() protoreflect.Message {
-	mi := &file_users_proto_msgTypes[6]
+	mi := &file_users_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -558,7 +1093,7 @@ func (x *ApiUserInterfaceTraits) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ApiUserInterfaceTraits.ProtoReflect.Descriptor instead.
 func (*ApiUserInterfaceTraits) Descriptor() ([]byte, []int) {
-	return file_users_proto_rawDescGZIP(), []int{6}
+	return file_users_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *ApiUserInterfaceTraits) GetPermissions() *proto.ApiClientACL {
@@ -697,7 +1232,7 @@ type ApiUser struct {
 
 func (x *ApiUser) Reset() {
 	*x = ApiUser{}
-	mi := &file_users_proto_msgTypes[7]
+	mi := &file_users_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -709,7 +1244,7 @@ func (x *ApiUser) String() string {
 func (*ApiUser) ProtoMessage() {}
 
 func (x *ApiUser) ProtoReflect() protoreflect.Message {
-	mi := &file_users_proto_msgTypes[7]
+	mi := &file_users_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -722,7 +1257,7 @@ func (x *ApiUser) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ApiUser.ProtoReflect.Descriptor instead.
 func (*ApiUser) Descriptor() ([]byte, []int) {
-	return file_users_proto_rawDescGZIP(), []int{7}
+	return file_users_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *ApiUser) GetUsername() string {
@@ -782,7 +1317,7 @@ type GUICustomizations struct {
 
 func (x *GUICustomizations) Reset() {
 	*x = GUICustomizations{}
-	mi := &file_users_proto_msgTypes[8]
+	mi := &file_users_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -794,7 +1329,7 @@ func (x *GUICustomizations) String() string {
 func (*GUICustomizations) ProtoMessage() {}
 
 func (x *GUICustomizations) ProtoReflect() protoreflect.Message {
-	mi := &file_users_proto_msgTypes[8]
+	mi := &file_users_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -807,7 +1342,7 @@ func (x *GUICustomizations) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GUICustomizations.ProtoReflect.Descriptor instead.
 func (*GUICustomizations) Descriptor() ([]byte, []int) {
-	return file_users_proto_rawDescGZIP(), []int{8}
+	return file_users_proto_rawDescGZIP(), []int{12}
 }
 
 func (x *GUICustomizations) GetDisableServerEvents() bool {
@@ -874,7 +1409,7 @@ type SetGUIOptionsRequest struct {
 
 func (x *SetGUIOptionsRequest) Reset() {
 	*x = SetGUIOptionsRequest{}
-	mi := &file_users_proto_msgTypes[9]
+	mi := &file_users_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -886,7 +1421,7 @@ func (x *SetGUIOptionsRequest) String() string {
 func (*SetGUIOptionsRequest) ProtoMessage() {}
 
 func (x *SetGUIOptionsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_users_proto_msgTypes[9]
+	mi := &file_users_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -899,7 +1434,7 @@ func (x *SetGUIOptionsRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SetGUIOptionsRequest.ProtoReflect.Descriptor instead.
 func (*SetGUIOptionsRequest) Descriptor() ([]byte, []int) {
-	return file_users_proto_rawDescGZIP(), []int{9}
+	return file_users_proto_rawDescGZIP(), []int{13}
 }
 
 func (x *SetGUIOptionsRequest) GetTheme() string {
@@ -1006,7 +1541,7 @@ type SetGUIOptionsResponse struct {
 
 func (x *SetGUIOptionsResponse) Reset() {
 	*x = SetGUIOptionsResponse{}
-	mi := &file_users_proto_msgTypes[10]
+	mi := &file_users_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1018,7 +1553,7 @@ func (x *SetGUIOptionsResponse) String() string {
 func (*SetGUIOptionsResponse) ProtoMessage() {}
 
 func (x *SetGUIOptionsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_users_proto_msgTypes[10]
+	mi := &file_users_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1031,7 +1566,7 @@ func (x *SetGUIOptionsResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SetGUIOptionsResponse.ProtoReflect.Descriptor instead.
 func (*SetGUIOptionsResponse) Descriptor() ([]byte, []int) {
-	return file_users_proto_rawDescGZIP(), []int{10}
+	return file_users_proto_rawDescGZIP(), []int{14}
 }
 
 func (x *SetGUIOptionsResponse) GetRedirectUrl() string {
@@ -1050,7 +1585,7 @@ type Users struct {
 
 func (x *Users) Reset() {
 	*x = Users{}
-	mi := &file_users_proto_msgTypes[11]
+	mi := &file_users_proto_msgTypes[15]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1062,7 +1597,7 @@ func (x *Users) String() string {
 func (*Users) ProtoMessage() {}
 
 func (x *Users) ProtoReflect() protoreflect.Message {
-	mi := &file_users_proto_msgTypes[11]
+	mi := &file_users_proto_msgTypes[15]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1075,7 +1610,7 @@ func (x *Users) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Users.ProtoReflect.Descriptor instead.
 func (*Users) Descriptor() ([]byte, []int) {
-	return file_users_proto_rawDescGZIP(), []int{11}
+	return file_users_proto_rawDescGZIP(), []int{15}
 }
 
 func (x *Users) GetUsers() []*VelociraptorUser {
@@ -1103,7 +1638,7 @@ type UserRoles struct {
 
 func (x *UserRoles) Reset() {
 	*x = UserRoles{}
-	mi := &file_users_proto_msgTypes[12]
+	mi := &file_users_proto_msgTypes[16]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1115,7 +1650,7 @@ func (x *UserRoles) String() string {
 func (*UserRoles) ProtoMessage() {}
 
 func (x *UserRoles) ProtoReflect() protoreflect.Message {
-	mi := &file_users_proto_msgTypes[12]
+	mi := &file_users_proto_msgTypes[16]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1128,7 +1663,7 @@ func (x *UserRoles) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UserRoles.ProtoReflect.Descriptor instead.
 func (*UserRoles) Descriptor() ([]byte, []int) {
-	return file_users_proto_rawDescGZIP(), []int{12}
+	return file_users_proto_rawDescGZIP(), []int{16}
 }
 
 func (x *UserRoles) GetName() string {
@@ -1197,7 +1732,7 @@ type SetPasswordRequest struct {
 
 func (x *SetPasswordRequest) Reset() {
 	*x = SetPasswordRequest{}
-	mi := &file_users_proto_msgTypes[13]
+	mi := &file_users_proto_msgTypes[17]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1209,7 +1744,7 @@ func (x *SetPasswordRequest) String() string {
 func (*SetPasswordRequest) ProtoMessage() {}
 
 func (x *SetPasswordRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_users_proto_msgTypes[13]
+	mi := &file_users_proto_msgTypes[17]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1222,7 +1757,7 @@ func (x *SetPasswordRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SetPasswordRequest.ProtoReflect.Descriptor instead.
 func (*SetPasswordRequest) Descriptor() ([]byte, []int) {
-	return file_users_proto_rawDescGZIP(), []int{13}
+	return file_users_proto_rawDescGZIP(), []int{17}
 }
 
 func (x *SetPasswordRequest) GetPassword() string {
@@ -1252,7 +1787,7 @@ type Favorite struct {
 
 func (x *Favorite) Reset() {
 	*x = Favorite{}
-	mi := &file_users_proto_msgTypes[14]
+	mi := &file_users_proto_msgTypes[18]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1264,7 +1799,7 @@ func (x *Favorite) String() string {
 func (*Favorite) ProtoMessage() {}
 
 func (x *Favorite) ProtoReflect() protoreflect.Message {
-	mi := &file_users_proto_msgTypes[14]
+	mi := &file_users_proto_msgTypes[18]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1277,7 +1812,7 @@ func (x *Favorite) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Favorite.ProtoReflect.Descriptor instead.
 func (*Favorite) Descriptor() ([]byte, []int) {
-	return file_users_proto_rawDescGZIP(), []int{14}
+	return file_users_proto_rawDescGZIP(), []int{18}
 }
 
 func (x *Favorite) GetName() string {
@@ -1317,7 +1852,7 @@ type Favorites struct {
 
 func (x *Favorites) Reset() {
 	*x = Favorites{}
-	mi := &file_users_proto_msgTypes[15]
+	mi := &file_users_proto_msgTypes[19]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1329,7 +1864,7 @@ func (x *Favorites) String() string {
 func (*Favorites) ProtoMessage() {}
 
 func (x *Favorites) ProtoReflect() protoreflect.Message {
-	mi := &file_users_proto_msgTypes[15]
+	mi := &file_users_proto_msgTypes[19]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1342,7 +1877,7 @@ func (x *Favorites) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Favorites.ProtoReflect.Descriptor instead.
 func (*Favorites) Descriptor() ([]byte, []int) {
-	return file_users_proto_rawDescGZIP(), []int{15}
+	return file_users_proto_rawDescGZIP(), []int{19}
 }
 
 func (x *Favorites) GetItems() []*Favorite {
@@ -1352,6 +1887,334 @@ func (x *Favorites) GetItems() []*Favorite {
 	return nil
 }
 
+type CreateApiTokenRequest struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Name        string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Permissions []string               `protobuf:"bytes,2,rep,name=permissions,proto3" json:"permissions,omitempty"`
+	// The orgs the token may be used in. Defaults to the current
+	// org.
+	Orgs []string `protobuf:"bytes,3,rep,name=orgs,proto3" json:"orgs,omitempty"`
+	// Seconds until the token expires.
+	Expiry        uint64 `protobuf:"varint,4,opt,name=expiry,proto3" json:"expiry,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateApiTokenRequest) Reset() {
+	*x = CreateApiTokenRequest{}
+	mi := &file_users_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateApiTokenRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateApiTokenRequest) ProtoMessage() {}
+
+func (x *CreateApiTokenRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_users_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateApiTokenRequest.ProtoReflect.Descriptor instead.
+func (*CreateApiTokenRequest) Descriptor() ([]byte, []int) {
+	return file_users_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *CreateApiTokenRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CreateApiTokenRequest) GetPermissions() []string {
+	if x != nil {
+		return x.Permissions
+	}
+	return nil
+}
+
+func (x *CreateApiTokenRequest) GetOrgs() []string {
+	if x != nil {
+		return x.Orgs
+	}
+	return nil
+}
+
+func (x *CreateApiTokenRequest) GetExpiry() uint64 {
+	if x != nil {
+		return x.Expiry
+	}
+	return 0
+}
+
+type CreateApiTokenResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Token *ApiToken              `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	// The bearer token. This is only shown once.
+	Secret        string `protobuf:"bytes,2,opt,name=secret,proto3" json:"secret,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateApiTokenResponse) Reset() {
+	*x = CreateApiTokenResponse{}
+	mi := &file_users_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateApiTokenResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateApiTokenResponse) ProtoMessage() {}
+
+func (x *CreateApiTokenResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_users_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateApiTokenResponse.ProtoReflect.Descriptor instead.
+func (*CreateApiTokenResponse) Descriptor() ([]byte, []int) {
+	return file_users_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *CreateApiTokenResponse) GetToken() *ApiToken {
+	if x != nil {
+		return x.Token
+	}
+	return nil
+}
+
+func (x *CreateApiTokenResponse) GetSecret() string {
+	if x != nil {
+		return x.Secret
+	}
+	return ""
+}
+
+type ApiTokens struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Items         []*ApiToken            `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ApiTokens) Reset() {
+	*x = ApiTokens{}
+	mi := &file_users_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ApiTokens) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ApiTokens) ProtoMessage() {}
+
+func (x *ApiTokens) ProtoReflect() protoreflect.Message {
+	mi := &file_users_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ApiTokens.ProtoReflect.Descriptor instead.
+func (*ApiTokens) Descriptor() ([]byte, []int) {
+	return file_users_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *ApiTokens) GetItems() []*ApiToken {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+type ApiTokenRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The owner of the tokens. Defaults to the calling user.
+	Username      string `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	TokenId       string `protobuf:"bytes,2,opt,name=token_id,json=tokenId,proto3" json:"token_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ApiTokenRequest) Reset() {
+	*x = ApiTokenRequest{}
+	mi := &file_users_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ApiTokenRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ApiTokenRequest) ProtoMessage() {}
+
+func (x *ApiTokenRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_users_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ApiTokenRequest.ProtoReflect.Descriptor instead.
+func (*ApiTokenRequest) Descriptor() ([]byte, []int) {
+	return file_users_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *ApiTokenRequest) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *ApiTokenRequest) GetTokenId() string {
+	if x != nil {
+		return x.TokenId
+	}
+	return ""
+}
+
+type GUISessions struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Items         []*GUISession          `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GUISessions) Reset() {
+	*x = GUISessions{}
+	mi := &file_users_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GUISessions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GUISessions) ProtoMessage() {}
+
+func (x *GUISessions) ProtoReflect() protoreflect.Message {
+	mi := &file_users_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GUISessions.ProtoReflect.Descriptor instead.
+func (*GUISessions) Descriptor() ([]byte, []int) {
+	return file_users_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *GUISessions) GetItems() []*GUISession {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+type GUISessionRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The owner of the sessions. Defaults to the calling user.
+	Username string `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	// Revoke this session or all the user's sessions if not set.
+	SessionId string `protobuf:"bytes,2,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	// List everyone's sessions (requires ORG_ADMIN).
+	All           bool `protobuf:"varint,3,opt,name=all,proto3" json:"all,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GUISessionRequest) Reset() {
+	*x = GUISessionRequest{}
+	mi := &file_users_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GUISessionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GUISessionRequest) ProtoMessage() {}
+
+func (x *GUISessionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_users_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GUISessionRequest.ProtoReflect.Descriptor instead.
+func (*GUISessionRequest) Descriptor() ([]byte, []int) {
+	return file_users_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *GUISessionRequest) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *GUISessionRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *GUISessionRequest) GetAll() bool {
+	if x != nil {
+		return x.All
+	}
+	return false
+}
+
 var File_users_proto protoreflect.FileDescriptor
 
 const file_users_proto_rawDesc = "" +
@@ -1362,7 +2225,69 @@ const file_users_proto_rawDesc = "" +
 	"\astrings\x18\x01 \x03(\tR\astrings\"]\n" +
 	"\tUserStats\x12(\n" +
 	"\x10last_active_time\x18\x01 \x01(\x03R\x0elastActiveTime\x12&\n" +
-	"\x0flast_ip_address\x18\x02 \x01(\tR\rlastIpAddress\"\xfa\x04\n" +
+	"\x0flast_ip_address\x18\x02 \x01(\tR\rlastIpAddress\"\xdc\x02\n" +
+	"\x12WebAuthnCredential\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x0e\n" +
+	"\x02id\x18\x02 \x01(\fR\x02id\x12\x1d\n" +
+	"\n" +
+	"public_key\x18\x03 \x01(\fR\tpublicKey\x12)\n" +
+	"\x10attestation_type\x18\x04 \x01(\tR\x0fattestationType\x12\x16\n" +
+	"\x06aaguid\x18\x05 \x01(\fR\x06aaguid\x12\x1d\n" +
+	"\n" +
+	"sign_count\x18\x06 \x01(\rR\tsignCount\x12\x1e\n" +
+	"\n" +
+	"transports\x18\a \x03(\tR\n" +
+	"transports\x12'\n" +
+	"\x0fbackup_eligible\x18\b \x01(\bR\x0ebackupEligible\x12!\n" +
+	"\fbackup_state\x18\t \x01(\bR\vbackupState\x12\x18\n" +
+	"\acreated\x18\n" +
+	" \x01(\x03R\acreated\x12\x1b\n" +
+	"\tlast_used\x18\v \x01(\x03R\blastUsed\"\xf9\x02\n" +
+	"\aUserMFA\x12\x1f\n" +
+	"\vtotp_secret\x18\x01 \x01(\fR\n" +
+	"totpSecret\x12!\n" +
+	"\ftotp_enabled\x18\x02 \x01(\bR\vtotpEnabled\x12$\n" +
+	"\x0etotp_last_step\x18\x03 \x01(\x03R\ftotpLastStep\x12(\n" +
+	"\x10webauthn_user_id\x18\x04 \x01(\fR\x0ewebauthnUserId\x12L\n" +
+	"\x14webauthn_credentials\x18\x05 \x03(\v2\x19.proto.WebAuthnCredentialR\x13webauthnCredentials\x12.\n" +
+	"\x13pending_totp_secret\x18\x06 \x01(\fR\x11pendingTotpSecret\x120\n" +
+	"\x14totp_failed_attempts\x18\a \x01(\x03R\x12totpFailedAttempts\x12*\n" +
+	"\x11totp_locked_until\x18\b \x01(\x03R\x0ftotpLockedUntil\"\x96\x02\n" +
+	"\bApiToken\x12\x19\n" +
+	"\btoken_id\x18\x01 \x01(\tR\atokenId\x12\x1a\n" +
+	"\busername\x18\x02 \x01(\tR\busername\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12 \n" +
+	"\vpermissions\x18\x04 \x03(\tR\vpermissions\x12\x12\n" +
+	"\x04orgs\x18\x05 \x03(\tR\x04orgs\x12\x18\n" +
+	"\acreated\x18\x06 \x01(\x04R\acreated\x12\x18\n" +
+	"\aexpires\x18\a \x01(\x04R\aexpires\x12\x1b\n" +
+	"\tlast_used\x18\b \x01(\x04R\blastUsed\x12$\n" +
+	"\x0elast_used_from\x18\t \x01(\tR\flastUsedFrom\x12\x12\n" +
+	"\x04hash\x18\n" +
+	" \x01(\fR\x04hash\"\xc6\x03\n" +
+	"\n" +
+	"GUISession\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x1a\n" +
+	"\busername\x18\x02 \x01(\tR\busername\x12$\n" +
+	"\rauthenticator\x18\x03 \x01(\tR\rauthenticator\x12\x1d\n" +
+	"\n" +
+	"login_time\x18\x04 \x01(\x04R\tloginTime\x12\x1d\n" +
+	"\n" +
+	"login_from\x18\x05 \x01(\tR\tloginFrom\x12\x1f\n" +
+	"\vlast_active\x18\x06 \x01(\x04R\n" +
+	"lastActive\x12(\n" +
+	"\x10last_active_from\x18\a \x01(\tR\x0elastActiveFrom\x12\x1d\n" +
+	"\n" +
+	"user_agent\x18\b \x01(\tR\tuserAgent\x12\x18\n" +
+	"\aexpires\x18\t \x01(\x04R\aexpires\x12\x18\n" +
+	"\arevoked\x18\n" +
+	" \x01(\bR\arevoked\x12!\n" +
+	"\frevoked_time\x18\v \x01(\x04R\vrevokedTime\x12\x1d\n" +
+	"\n" +
+	"revoked_by\x18\f \x01(\tR\trevokedBy\x12%\n" +
+	"\x0erevoked_reason\x18\r \x01(\tR\rrevokedReason\x12\x12\n" +
+	"\x04hash\x18\x0e \x01(\fR\x04hash\"\x8c\b\n" +
 	"\x10VelociraptorUser\x12(\n" +
 	"\x04name\x18\x01 \x01(\tB\x14\xe2\xfc\xe3\xc4\x01\x0e\x12\fThe usernameR\x04name\x12I\n" +
 	"\rpassword_hash\x18\x02 \x01(\fB$\xe2\xfc\xe3\xc4\x01\x1e\x12\x1cSHA256 hash of the password.R\fpasswordHash\x12#\n" +
@@ -1377,7 +2302,11 @@ const file_users_proto_rawDesc = "" +
 	"\x04orgs\x18\v \x03(\v2\x10.proto.OrgRecordR\x04orgs\x12\x1f\n" +
 	"\vcurrent_org\x18\f \x01(\tR\n" +
 	"currentOrg\x12&\n" +
-	"\x05stats\x18\r \x01(\v2\x10.proto.UserStatsR\x05stats\"\xc5\x01\n" +
+	"\x05stats\x18\r \x01(\v2\x10.proto.UserStatsR\x05stats\x12\x8d\x01\n" +
+	"\x03mfa\x18\x0f \x01(\v2\x0e.proto.UserMFABk\xe2\xfc\xe3\xc4\x01e\x12cSecond factor enrollment. Like the password hashes this is only returned with the full user record.R\x03mfa\x12\x7f\n" +
+	"\n" +
+	"api_tokens\x18\x10 \x03(\v2\x0f.proto.ApiTokenBO\xe2\xfc\xe3\xc4\x01I\x12GAPI tokens minted by the user. Only returned with the full user record.R\tapiTokens\x12\x7f\n" +
+	"\fgui_sessions\x18\x11 \x03(\v2\x11.proto.GUISessionBI\xe2\xfc\xe3\xc4\x01C\x12AThe user's GUI sessions. Only returned with the full user record.R\vguiSessions\"\xc5\x01\n" +
 	"\x11UpdateUserRequest\x12(\n" +
 	"\x04name\x18\x01 \x01(\tB\x14\xe2\xfc\xe3\xc4\x01\x0e\x12\fThe usernameR\x04name\x12:\n" +
 	"\bpassword\x18\x02 \x01(\tB\x1e\xe2\xfc\xe3\xc4\x01\x18\x12\x16The cleartext passwordR\bpassword\x12\x12\n" +
@@ -1464,7 +2393,27 @@ const file_users_proto_rawDesc = "" +
 	"\x04spec\x18\x03 \x03(\v2\x13.proto.ArtifactSpecR\x04spec\x12\x12\n" +
 	"\x04type\x18\x04 \x01(\tR\x04type\"2\n" +
 	"\tFavorites\x12%\n" +
-	"\x05items\x18\x01 \x03(\v2\x0f.proto.FavoriteR\x05itemsB1Z/www.velocidex.com/golang/velociraptor/api/protob\x06proto3"
+	"\x05items\x18\x01 \x03(\v2\x0f.proto.FavoriteR\x05items\"y\n" +
+	"\x15CreateApiTokenRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12 \n" +
+	"\vpermissions\x18\x02 \x03(\tR\vpermissions\x12\x12\n" +
+	"\x04orgs\x18\x03 \x03(\tR\x04orgs\x12\x16\n" +
+	"\x06expiry\x18\x04 \x01(\x04R\x06expiry\"W\n" +
+	"\x16CreateApiTokenResponse\x12%\n" +
+	"\x05token\x18\x01 \x01(\v2\x0f.proto.ApiTokenR\x05token\x12\x16\n" +
+	"\x06secret\x18\x02 \x01(\tR\x06secret\"2\n" +
+	"\tApiTokens\x12%\n" +
+	"\x05items\x18\x01 \x03(\v2\x0f.proto.ApiTokenR\x05items\"H\n" +
+	"\x0fApiTokenRequest\x12\x1a\n" +
+	"\busername\x18\x01 \x01(\tR\busername\x12\x19\n" +
+	"\btoken_id\x18\x02 \x01(\tR\atokenId\"6\n" +
+	"\vGUISessions\x12'\n" +
+	"\x05items\x18\x01 \x03(\v2\x11.proto.GUISessionR\x05items\"`\n" +
+	"\x11GUISessionRequest\x12\x1a\n" +
+	"\busername\x18\x01 \x01(\tR\busername\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x02 \x01(\tR\tsessionId\x12\x10\n" +
+	"\x03all\x18\x03 \x01(\bR\x03allB1Z/www.velocidex.com/golang/velociraptor/api/protob\x06proto3"
 
 var (
 	file_users_proto_rawDescOnce sync.Once
@@ -1479,50 +2428,67 @@ func file_users_proto_rawDescGZIP() []byte {
 }
 
 var file_users_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_users_proto_msgTypes = make([]protoimpl.MessageInfo, 16)
+var file_users_proto_msgTypes = make([]protoimpl.MessageInfo, 26)
 var file_users_proto_goTypes = []any{
 	(ApiUser_UserType)(0),          // 0: proto.ApiUser.UserType
 	(*Strings)(nil),                // 1: proto.Strings
 	(*UserStats)(nil),              // 2: proto.UserStats
-	(*VelociraptorUser)(nil),       // 3: proto.VelociraptorUser
-	(*UpdateUserRequest)(nil),      // 4: proto.UpdateUserRequest
-	(*DeleteUserRequest)(nil),      // 5: proto.DeleteUserRequest
-	(*UserRequest)(nil),            // 6: proto.UserRequest
-	(*ApiUserInterfaceTraits)(nil), // 7: proto.ApiUserInterfaceTraits
-	(*ApiUser)(nil),                // 8: proto.ApiUser
-	(*GUICustomizations)(nil),      // 9: proto.GUICustomizations
-	(*SetGUIOptionsRequest)(nil),   // 10: proto.SetGUIOptionsRequest
-	(*SetGUIOptionsResponse)(nil),  // 11: proto.SetGUIOptionsResponse
-	(*Users)(nil),                  // 12: proto.Users
-	(*UserRoles)(nil),              // 13: proto.UserRoles
-	(*SetPasswordRequest)(nil),     // 14: proto.SetPasswordRequest
-	(*Favorite)(nil),               // 15: proto.Favorite
-	(*Favorites)(nil),              // 16: proto.Favorites
-	(*proto.ApiClientACL)(nil),     // 17: proto.ApiClientACL
-	(*OrgRecord)(nil),              // 18: proto.OrgRecord
-	(*proto1.GUILink)(nil),         // 19: proto.GUILink
-	(*proto2.ArtifactSpec)(nil),    // 20: proto.ArtifactSpec
+	(*WebAuthnCredential)(nil),     // 3: proto.WebAuthnCredential
+	(*UserMFA)(nil),                // 4: proto.UserMFA
+	(*ApiToken)(nil),               // 5: proto.ApiToken
+	(*GUISession)(nil),             // 6: proto.GUISession
+	(*VelociraptorUser)(nil),       // 7: proto.VelociraptorUser
+	(*UpdateUserRequest)(nil),      // 8: proto.UpdateUserRequest
+	(*DeleteUserRequest)(nil),      // 9: proto.DeleteUserRequest
+	(*UserRequest)(nil),            // 10: proto.UserRequest
+	(*ApiUserInterfaceTraits)(nil), // 11: proto.ApiUserInterfaceTraits
+	(*ApiUser)(nil),                // 12: proto.ApiUser
+	(*GUICustomizations)(nil),      // 13: proto.GUICustomizations
+	(*SetGUIOptionsRequest)(nil),   // 14: proto.SetGUIOptionsRequest
+	(*SetGUIOptionsResponse)(nil),  // 15: proto.SetGUIOptionsResponse
+	(*Users)(nil),                  // 16: proto.Users
+	(*UserRoles)(nil),              // 17: proto.UserRoles
+	(*SetPasswordRequest)(nil),     // 18: proto.SetPasswordRequest
+	(*Favorite)(nil),               // 19: proto.Favorite
+	(*Favorites)(nil),              // 20: proto.Favorites
+	(*CreateApiTokenRequest)(nil),  // 21: proto.CreateApiTokenRequest
+	(*CreateApiTokenResponse)(nil), // 22: proto.CreateApiTokenResponse
+	(*ApiTokens)(nil),              // 23: proto.ApiTokens
+	(*ApiTokenRequest)(nil),        // 24: proto.ApiTokenRequest
+	(*GUISessions)(nil),            // 25: proto.GUISessions
+	(*GUISessionRequest)(nil),      // 26: proto.GUISessionRequest
+	(*proto.ApiClientACL)(nil),     // 27: proto.ApiClientACL
+	(*OrgRecord)(nil),              // 28: proto.OrgRecord
+	(*proto1.GUILink)(nil),         // 29: proto.GUILink
+	(*proto2.ArtifactSpec)(nil),    // 30: proto.ArtifactSpec
 }
 var file_users_proto_depIdxs = []int32{
-	17, // 0: proto.VelociraptorUser.Permissions:type_name -> proto.ApiClientACL
-	18, // 1: proto.VelociraptorUser.orgs:type_name -> proto.OrgRecord
-	2,  // 2: proto.VelociraptorUser.stats:type_name -> proto.UserStats
-	17, // 3: proto.ApiUserInterfaceTraits.Permissions:type_name -> proto.ApiClientACL
-	9,  // 4: proto.ApiUserInterfaceTraits.customizations:type_name -> proto.GUICustomizations
-	19, // 5: proto.ApiUserInterfaceTraits.links:type_name -> proto.GUILink
-	7,  // 6: proto.ApiUser.interface_traits:type_name -> proto.ApiUserInterfaceTraits
-	0,  // 7: proto.ApiUser.user_type:type_name -> proto.ApiUser.UserType
-	18, // 8: proto.ApiUser.orgs:type_name -> proto.OrgRecord
-	9,  // 9: proto.SetGUIOptionsRequest.customizations:type_name -> proto.GUICustomizations
-	19, // 10: proto.SetGUIOptionsRequest.links:type_name -> proto.GUILink
-	3,  // 11: proto.Users.users:type_name -> proto.VelociraptorUser
-	20, // 12: proto.Favorite.spec:type_name -> proto.ArtifactSpec
-	15, // 13: proto.Favorites.items:type_name -> proto.Favorite
-	14, // [14:14] is the sub-list for method output_type
-	14, // [14:14] is the sub-list for method input_type
-	14, // [14:14] is the sub-list for extension type_name
-	14, // [14:14] is the sub-list for extension extendee
-	0,  // [0:14] is the sub-list for field type_name
+	3,  // 0: proto.UserMFA.webauthn_credentials:type_name -> proto.WebAuthnCredential
+	27, // 1: proto.VelociraptorUser.Permissions:type_name -> proto.ApiClientACL
+	28, // 2: proto.VelociraptorUser.orgs:type_name -> proto.OrgRecord
+	2,  // 3: proto.VelociraptorUser.stats:type_name -> proto.UserStats
+	4,  // 4: proto.VelociraptorUser.mfa:type_name -> proto.UserMFA
+	5,  // 5: proto.VelociraptorUser.api_tokens:type_name -> proto.ApiToken
+	6,  // 6: proto.VelociraptorUser.gui_sessions:type_name -> proto.GUISession
+	27, // 7: proto.ApiUserInterfaceTraits.Permissions:type_name -> proto.ApiClientACL
+	13, // 8: proto.ApiUserInterfaceTraits.customizations:type_name -> proto.GUICustomizations
+	29, // 9: proto.ApiUserInterfaceTraits.links:type_name -> proto.GUILink
+	11, // 10: proto.ApiUser.interface_traits:type_name -> proto.ApiUserInterfaceTraits
+	0,  // 11: proto.ApiUser.user_type:type_name -> proto.ApiUser.UserType
+	28, // 12: proto.ApiUser.orgs:type_name -> proto.OrgRecord
+	13, // 13: proto.SetGUIOptionsRequest.customizations:type_name -> proto.GUICustomizations
+	29, // 14: proto.SetGUIOptionsRequest.links:type_name -> proto.GUILink
+	7,  // 15: proto.Users.users:type_name -> proto.VelociraptorUser
+	30, // 16: proto.Favorite.spec:type_name -> proto.ArtifactSpec
+	19, // 17: proto.Favorites.items:type_name -> proto.Favorite
+	5,  // 18: proto.CreateApiTokenResponse.token:type_name -> proto.ApiToken
+	5,  // 19: proto.ApiTokens.items:type_name -> proto.ApiToken
+	6,  // 20: proto.GUISessions.items:type_name -> proto.GUISession
+	21, // [21:21] is the sub-list for method output_type
+	21, // [21:21] is the sub-list for method input_type
+	21, // [21:21] is the sub-list for extension type_name
+	21, // [21:21] is the sub-list for extension extendee
+	0,  // [0:21] is the sub-list for field type_name
 }
 
 func init() { file_users_proto_init() }
@@ -1537,7 +2503,7 @@ func file_users_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_users_proto_rawDesc), len(file_users_proto_rawDesc)),
 			NumEnums:      1,
-			NumMessages:   16,
+			NumMessages:   26,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -0,0 +1,272 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: webhooks.proto
+
+package proto
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// An outbound webhook registered by an administrator. The webhook
+// service delivers matching server events to the url.
+type Webhook struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Url   string                 `protobuf:"bytes,2,opt,name=url,proto3" json:"url,omitempty"`
+	// The events that trigger this webhook. One or more of
+	// FLOW_COMPLETED, HUNT_STATE_CHANGED, ALERT and CLIENT_ENROLLED.
+	Events []string `protobuf:"bytes,3,rep,name=events,proto3" json:"events,omitempty"`
+	// If set, only deliver flow completions and alerts from
+	// artifacts matching this regex.
+	ArtifactRegex string `protobuf:"bytes,4,opt,name=artifact_regex,json=artifactRegex,proto3" json:"artifact_regex,omitempty"`
+	// A Go template rendered with the event to produce the request
+	// body. If not set the event is sent as JSON.
+	Template    string `protobuf:"bytes,5,opt,name=template,proto3" json:"template,omitempty"`
+	ContentType string `protobuf:"bytes,6,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	// The name of a "Webhook Secrets" secret holding the HMAC key
+	// and any extra headers. The secret must be shared with the
+	// user that created the webhook.
+	Secret string `protobuf:"bytes,7,opt,name=secret,proto3" json:"secret,omitempty"`
+	// How many times to retry a failed delivery (default 5).
+	MaxRetries    int64  `protobuf:"varint,8,opt,name=max_retries,json=maxRetries,proto3" json:"max_retries,omitempty"`
+	Disabled      bool   `protobuf:"varint,9,opt,name=disabled,proto3" json:"disabled,omitempty"`
+	CreatedBy     string `protobuf:"bytes,10,opt,name=created_by,json=createdBy,proto3" json:"created_by,omitempty"`
+	Created       uint64 `protobuf:"varint,11,opt,name=created,proto3" json:"created,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Webhook) Reset() {
+	*x = Webhook{}
+	mi := &file_webhooks_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Webhook) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Webhook) ProtoMessage() {}
+
+func (x *Webhook) ProtoReflect() protoreflect.Message {
+	mi := &file_webhooks_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Webhook.ProtoReflect.Descriptor instead.
+func (*Webhook) Descriptor() ([]byte, []int) {
+	return file_webhooks_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Webhook) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Webhook) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *Webhook) GetEvents() []string {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+func (x *Webhook) GetArtifactRegex() string {
+	if x != nil {
+		return x.ArtifactRegex
+	}
+	return ""
+}
+
+func (x *Webhook) GetTemplate() string {
+	if x != nil {
+		return x.Template
+	}
+	return ""
+}
+
+func (x *Webhook) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *Webhook) GetSecret() string {
+	if x != nil {
+		return x.Secret
+	}
+	return ""
+}
+
+func (x *Webhook) GetMaxRetries() int64 {
+	if x != nil {
+		return x.MaxRetries
+	}
+	return 0
+}
+
+func (x *Webhook) GetDisabled() bool {
+	if x != nil {
+		return x.Disabled
+	}
+	return false
+}
+
+func (x *Webhook) GetCreatedBy() string {
+	if x != nil {
+		return x.CreatedBy
+	}
+	return ""
+}
+
+func (x *Webhook) GetCreated() uint64 {
+	if x != nil {
+		return x.Created
+	}
+	return 0
+}
+
+type Webhooks struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Items         []*Webhook             `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Webhooks) Reset() {
+	*x = Webhooks{}
+	mi := &file_webhooks_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Webhooks) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Webhooks) ProtoMessage() {}
+
+func (x *Webhooks) ProtoReflect() protoreflect.Message {
+	mi := &file_webhooks_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Webhooks.ProtoReflect.Descriptor instead.
+func (*Webhooks) Descriptor() ([]byte, []int) {
+	return file_webhooks_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Webhooks) GetItems() []*Webhook {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+var File_webhooks_proto protoreflect.FileDescriptor
+
+const file_webhooks_proto_rawDesc = "" +
+	"\n" +
+	"\x0ewebhooks.proto\x12\x05proto\"\xbb\x02\n" +
+	"\aWebhook\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x10\n" +
+	"\x03url\x18\x02 \x01(\tR\x03url\x12\x16\n" +
+	"\x06events\x18\x03 \x03(\tR\x06events\x12%\n" +
+	"\x0eartifact_regex\x18\x04 \x01(\tR\rartifactRegex\x12\x1a\n" +
+	"\btemplate\x18\x05 \x01(\tR\btemplate\x12!\n" +
+	"\fcontent_type\x18\x06 \x01(\tR\vcontentType\x12\x16\n" +
+	"\x06secret\x18\a \x01(\tR\x06secret\x12\x1f\n" +
+	"\vmax_retries\x18\b \x01(\x03R\n" +
+	"maxRetries\x12\x1a\n" +
+	"\bdisabled\x18\t \x01(\bR\bdisabled\x12\x1d\n" +
+	"\n" +
+	"created_by\x18\n" +
+	" \x01(\tR\tcreatedBy\x12\x18\n" +
+	"\acreated\x18\v \x01(\x04R\acreated\"0\n" +
+	"\bWebhooks\x12$\n" +
+	"\x05items\x18\x01 \x03(\v2\x0e.proto.WebhookR\x05itemsB1Z/www.velocidex.com/golang/velociraptor/api/protob\x06proto3"
+
+var (
+	file_webhooks_proto_rawDescOnce sync.Once
+	file_webhooks_proto_rawDescData []byte
+)
+
+func file_webhooks_proto_rawDescGZIP() []byte {
+	file_webhooks_proto_rawDescOnce.Do(func() {
+		file_webhooks_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_webhooks_proto_rawDesc), len(file_webhooks_proto_rawDesc)))
+	})
+	return file_webhooks_proto_rawDescData
+}
+
+var file_webhooks_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_webhooks_proto_goTypes = []any{
+	(*Webhook)(nil),  // 0: proto.Webhook
+	(*Webhooks)(nil), // 1: proto.Webhooks
+}
+var file_webhooks_proto_depIdxs = []int32{
+	0, // 0: proto.Webhooks.items:type_name -> proto.Webhook
+	1, // [1:1] is the sub-list for method output_type
+	1, // [1:1] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_webhooks_proto_init() }
+func file_webhooks_proto_init() {
+	if File_webhooks_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_webhooks_proto_rawDesc), len(file_webhooks_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_webhooks_proto_goTypes,
+		DependencyIndexes: file_webhooks_proto_depIdxs,
+		MessageInfos:      file_webhooks_proto_msgTypes,
+	}.Build()
+	File_webhooks_proto = out.File
+	file_webhooks_proto_goTypes = nil
+	file_webhooks_proto_depIdxs = nil
+}
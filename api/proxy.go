@@ -39,6 +39,7 @@ import (
 	"www.velocidex.com/golang/velociraptor/acls"
 	"www.velocidex.com/golang/velociraptor/api/authenticators"
 	api_proto "www.velocidex.com/golang/velociraptor/api/proto"
+	"www.velocidex.com/golang/velociraptor/api/scim"
 	api_utils "www.velocidex.com/golang/velociraptor/api/utils"
 	config_proto "www.velocidex.com/golang/velociraptor/config/proto"
 	"www.velocidex.com/golang/velociraptor/constants"
@@ -151,60 +152,93 @@ func PrepareGUIMux(
 	base_path := api_utils.GetBasePath(config_obj)
 
 	mux.Handle(api_utils.GetBasePath(config_obj, "/api/"),
-		ipFilter(config_obj,
+		ipFilter(config_obj, apiTokenHandler(config_obj, h,
 			csrfProtect(config_obj,
-				auther.AuthenticateUserHandler(h, acls.READ_RESULTS))))
+				auther.AuthenticateUserHandler(h, acls.READ_RESULTS)))))
 
+	h = rateLimit(config_obj, "DownloadTable", rateClassDownload,
+		downloadTable(config_obj))
 	mux.Handle(api_utils.GetBasePath(config_obj, "/api/v1/DownloadTable"),
-		ipFilter(config_obj, csrfProtect(config_obj,
-			auther.AuthenticateUserHandler(
-				downloadTable(config_obj), acls.READ_RESULTS))))
+		ipFilter(config_obj, apiTokenHandler(config_obj, h,
+			csrfProtect(config_obj,
+				auther.AuthenticateUserHandler(h, acls.READ_RESULTS)))))
 
+	h = rateLimit(config_obj, "DownloadVFSFile", rateClassDownload,
+		vfsFileDownloadHandler(config_obj))
 	mux.Handle(api_utils.GetBasePath(config_obj, "/api/v1/DownloadVFSFile"),
-		ipFilter(config_obj, csrfProtect(config_obj,
-			auther.AuthenticateUserHandler(
-				vfsFileDownloadHandler(config_obj), acls.READ_RESULTS))))
+		ipFilter(config_obj, apiTokenHandler(config_obj, h,
+			csrfProtect(config_obj,
+				auther.AuthenticateUserHandler(h, acls.READ_RESULTS)))))
+
+	h, err = openAPIHandler(config_obj)
+	if err != nil {
+		return nil, err
+	}
+	mux.Handle(api_utils.GetBasePath(config_obj, "/api/v1/openapi.json"),
+		ipFilter(config_obj, apiTokenHandler(config_obj, h,
+			csrfProtect(config_obj,
+				auther.AuthenticateUserHandler(h, acls.READ_RESULTS)))))
+
+	h = rateLimit(config_obj, "GraphQL", rateClassQuery,
+		graphQLHandler(config_obj))
+	mux.Handle(api_utils.GetBasePath(config_obj, "/api/v1/graphql"),
+		ipFilter(config_obj, apiTokenHandler(config_obj, h,
+			csrfProtect(config_obj,
+				auther.AuthenticateUserHandler(h, acls.READ_RESULTS)))))
+
+	// The SCIM endpoint does its own API token authentication.
+	if scim.IsEnabled(config_obj) {
+		mux.Handle(api_utils.GetBasePath(config_obj, "/scim/v2/"),
+			ipFilter(config_obj, scim.NewSCIMHandler(config_obj,
+				api_utils.GetBasePath(config_obj, "/scim/v2"))))
+	}
 
 	mux.Handle(api_utils.GetBasePath(config_obj, "/api/v1/UploadTool"),
 		ipFilter(config_obj, csrfProtect(config_obj,
 			auther.AuthenticateUserHandler(
-				toolUploadHandler(config_obj), acls.READ_RESULTS))))
+				rateLimit(config_obj, "UploadTool", rateClassWrite,
+					toolUploadHandler(config_obj)), acls.READ_RESULTS))))
 
 	mux.Handle(api_utils.GetBasePath(config_obj, "/api/v1/UploadFormFile"),
 		ipFilter(config_obj, csrfProtect(config_obj,
 			auther.AuthenticateUserHandler(
-				formUploadHandler(config_obj), acls.READ_RESULTS))))
+				rateLimit(config_obj, "UploadFormFile", rateClassWrite,
+					formUploadHandler(config_obj)), acls.READ_RESULTS))))
 
 	// Serve prepared zip files.
 	mux.Handle(api_utils.GetBasePath(config_obj, "/downloads/"),
 		ipFilter(config_obj, csrfProtect(config_obj,
 			auther.AuthenticateUserHandler(
-				api_utils.StripPrefix(base_path,
-					downloadFileStore(config_obj, []string{"downloads"})),
+				rateLimit(config_obj, "DownloadFile", rateClassDownload,
+					api_utils.StripPrefix(base_path,
+						downloadFileStore(config_obj, []string{"downloads"}))),
 				acls.READ_RESULTS))))
 
 	// Serve notebook items
 	mux.Handle(api_utils.GetBasePath(config_obj, "/notebooks/"),
 		ipFilter(config_obj, csrfProtect(config_obj,
 			auther.AuthenticateUserHandler(
-				api_utils.StripPrefix(base_path,
-					downloadFileStore(config_obj, []string{"notebooks"})),
+				rateLimit(config_obj, "DownloadFile", rateClassDownload,
+					api_utils.StripPrefix(base_path,
+						downloadFileStore(config_obj, []string{"notebooks"}))),
 				acls.READ_RESULTS))))
 
 	// Serve files from hunt notebooks
 	mux.Handle(api_utils.GetBasePath(config_obj, "/hunts/"),
 		ipFilter(config_obj, csrfProtect(config_obj,
 			auther.AuthenticateUserHandler(
-				api_utils.StripPrefix(base_path,
-					downloadFileStore(config_obj, []string{"hunts"})),
+				rateLimit(config_obj, "DownloadFile", rateClassDownload,
+					api_utils.StripPrefix(base_path,
+						downloadFileStore(config_obj, []string{"hunts"}))),
 				acls.READ_RESULTS))))
 
 	// Serve files from client notebooks
 	mux.Handle(api_utils.GetBasePath(config_obj, "/clients/"),
 		ipFilter(config_obj, csrfProtect(config_obj,
 			auther.AuthenticateUserHandler(
-				api_utils.StripPrefix(base_path,
-					downloadFileStore(config_obj, []string{"clients"})),
+				rateLimit(config_obj, "DownloadFile", rateClassDownload,
+					api_utils.StripPrefix(base_path,
+						downloadFileStore(config_obj, []string{"clients"}))),
 				acls.READ_RESULTS))))
 
 	// Enable debug endpoints but only for users with SERVER_ADMIN on
@@ -373,6 +407,11 @@ func ipFilter(config_obj *config_proto.Config,
 	return authenticators.IpFilter(config_obj, parent)
 }
 
+func apiTokenHandler(config_obj *config_proto.Config,
+	parent http.Handler, fallback http.Handler) http.Handler {
+	return authenticators.APITokenHandler(config_obj, parent, fallback)
+}
+
 var (
 	rpcSizeError = regexp.MustCompile(`received message larger than max`)
 )
@@ -0,0 +1,299 @@
+package api
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"math"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	api_utils "www.velocidex.com/golang/velociraptor/api/utils"
+	config_proto "www.velocidex.com/golang/velociraptor/config/proto"
+	"www.velocidex.com/golang/velociraptor/logging"
+	"www.velocidex.com/golang/velociraptor/services"
+	"www.velocidex.com/golang/velociraptor/services/users"
+	"www.velocidex.com/golang/velociraptor/utils"
+)
+
+// Classes of API calls that may be limited together.
+const (
+	rateClassQuery    = "query"
+	rateClassRead     = "read"
+	rateClassWrite    = "write"
+	rateClassDownload = "download"
+
+	// How often to forget the buckets of idle principals.
+	rateLimitExpiry = time.Minute
+)
+
+var (
+	rateLimitedCounter = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gui_api_rate_limited",
+			Help: "Number of API calls rejected by rate limits.",
+		},
+		[]string{"class"},
+	)
+
+	// Methods which run arbitrary VQL on the server.
+	queryMethods = []string{
+		"Query", "WatchEvent", "NewNotebookCell", "UpdateNotebookCell",
+		"GetReport",
+	}
+
+	// Methods which prepare export files.
+	downloadMethods = []string{
+		"CreateDownloadFile", "CreateNotebookDownloadFile",
+	}
+
+	// Methods which only read data. Everything else is a write.
+	readPrefixes = []string{
+		"Get", "List", "VFSList", "VFSStat", "VFSGetBuffer", "Search",
+		"Estimate", "ReformatVQL", "Check", "LSP",
+	}
+
+	// The gRPC server and the GUI share the limits of the process.
+	rate_limiter_mu sync.Mutex
+	rate_limiter    *rateLimiter
+)
+
+func getRateClass(method string) string {
+	if utils.InString(queryMethods, method) {
+		return rateClassQuery
+	}
+
+	if utils.InString(downloadMethods, method) {
+		return rateClassDownload
+	}
+
+	for _, prefix := range readPrefixes {
+		if strings.HasPrefix(method, prefix) {
+			return rateClassRead
+		}
+	}
+	return rateClassWrite
+}
+
+type rateLimiter struct {
+	mu sync.Mutex
+
+	config_obj *config_proto.Config
+	api_config *config_proto.APIConfig
+	superuser  string
+
+	// Keyed by the limit's index and the principal.
+	buckets     map[string]*rate.Limiter
+	last_expiry time.Time
+}
+
+// Returns nil when no limits are configured.
+func getRateLimiter(config_obj *config_proto.Config) *rateLimiter {
+	if config_obj.API == nil || len(config_obj.API.RateLimits) == 0 {
+		return nil
+	}
+
+	rate_limiter_mu.Lock()
+	defer rate_limiter_mu.Unlock()
+
+	if rate_limiter == nil || rate_limiter.api_config != config_obj.API {
+		rate_limiter = newRateLimiter(config_obj)
+	}
+	return rate_limiter
+}
+
+func newRateLimiter(config_obj *config_proto.Config) *rateLimiter {
+	return &rateLimiter{
+		config_obj: config_obj,
+		api_config: config_obj.API,
+		superuser:  utils.GetSuperuserName(config_obj),
+		buckets:    make(map[string]*rate.Limiter),
+	}
+}
+
+// Find the most specific limit for the call. Returns -1 if the call
+// is not limited.
+func (self *rateLimiter) getLimit(principal, method, class string) int {
+	owner := principal
+	username, _, ok := services.ParseAPITokenPrincipal(principal)
+	if ok {
+		owner = username
+	}
+
+	for _, p := range []string{principal, owner, ""} {
+		for _, c := range []string{method, class, ""} {
+			for idx, limit := range self.api_config.RateLimits {
+				if strings.EqualFold(limit.Principal, p) &&
+					strings.EqualFold(limit.Class, c) {
+					return idx
+				}
+			}
+		}
+	}
+	return -1
+}
+
+// Take a token from the principal's bucket. Returns how long the
+// caller should wait before retrying or 0 if the call may proceed.
+func (self *rateLimiter) Check(principal, method, class string) time.Duration {
+	// Internal calls between server components are never limited.
+	if principal == self.superuser {
+		return 0
+	}
+
+	idx := self.getLimit(principal, method, class)
+	if idx < 0 {
+		return 0
+	}
+
+	limit := self.api_config.RateLimits[idx]
+	if limit.Rate <= 0 {
+		return 0
+	}
+
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	now := utils.GetTime().Now()
+	self.expireBuckets(now)
+
+	key := fmt.Sprintf("%d/%s", idx, principal)
+	bucket, pres := self.buckets[key]
+	if !pres {
+		burst := int(limit.Burst)
+		if burst == 0 {
+			burst = int(math.Ceil(limit.Rate))
+		}
+		bucket = rate.NewLimiter(rate.Limit(limit.Rate), burst)
+		self.buckets[key] = bucket
+	}
+
+	reservation := bucket.ReserveN(now, 1)
+	delay := reservation.DelayFrom(now)
+	if reservation.OK() && delay == 0 {
+		return 0
+	}
+
+	// Do not hold on to the token since the call is rejected.
+	reservation.CancelAt(now)
+	rateLimitedCounter.WithLabelValues(class).Inc()
+
+	logger := logging.GetLogger(self.config_obj, &logging.FrontendComponent)
+	logger.Info("<yellow>Rate limit</> exceeded by %v for %v (%v calls)",
+		principal, method, class)
+
+	return delay
+}
+
+// A full bucket is the same as a new one so it can be dropped.
+func (self *rateLimiter) expireBuckets(now time.Time) {
+	if now.Sub(self.last_expiry) < rateLimitExpiry {
+		return
+	}
+	self.last_expiry = now
+
+	for key, bucket := range self.buckets {
+		if bucket.TokensAt(now) >= float64(bucket.Burst()) {
+			delete(self.buckets, key)
+		}
+	}
+}
+
+func retryAfter(delay time.Duration) int {
+	return int(math.Ceil(delay.Seconds()))
+}
+
+func checkGRPCRateLimit(
+	ctx context.Context,
+	config_obj *config_proto.Config,
+	ca_pool *x509.CertPool, full_method string) error {
+
+	limiter := getRateLimiter(config_obj)
+	if limiter == nil {
+		return nil
+	}
+
+	user_info := users.GetGRPCUserInfo(config_obj, ctx, ca_pool)
+	method := path.Base(full_method)
+	class := getRateClass(method)
+
+	delay := limiter.Check(user_info.Name, method, class)
+	if delay > 0 {
+		return status.Errorf(codes.ResourceExhausted,
+			"Rate limit exceeded for %v calls. Retry in %v seconds.",
+			class, retryAfter(delay))
+	}
+	return nil
+}
+
+func rateLimitUnaryInterceptor(
+	config_obj *config_proto.Config,
+	ca_pool *x509.CertPool) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (interface{}, error) {
+
+		err := checkGRPCRateLimit(ctx, config_obj, ca_pool, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// Streams are charged once when they start.
+func rateLimitStreamInterceptor(
+	config_obj *config_proto.Config,
+	ca_pool *x509.CertPool) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream,
+		info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+
+		err := checkGRPCRateLimit(stream.Context(),
+			config_obj, ca_pool, info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, stream)
+	}
+}
+
+// Limit HTTP handlers which do not go through the gRPC API. Must be
+// called after the user is authenticated.
+func rateLimit(config_obj *config_proto.Config,
+	method, class string, parent http.Handler) http.Handler {
+
+	limiter := getRateLimiter(config_obj)
+	if limiter == nil {
+		return parent
+	}
+
+	return api_utils.HandlerFunc(parent,
+		func(w http.ResponseWriter, r *http.Request) {
+			principal := ""
+			users_manager := services.GetUserManager()
+			user_record, err := users_manager.GetUserFromHTTPContext(r.Context())
+			if err == nil {
+				principal = user_record.Name
+			}
+
+			delay := limiter.Check(principal, method, class)
+			if delay > 0 {
+				w.Header().Set("Retry-After", fmt.Sprintf("%d", retryAfter(delay)))
+				http.Error(w, fmt.Sprintf(
+					"Rate limit exceeded for %v calls", class),
+					http.StatusTooManyRequests)
+				return
+			}
+
+			parent.ServeHTTP(w, r)
+		})
+}
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/protobuf/proto"
+	api_utils "www.velocidex.com/golang/velociraptor/api/utils"
+	config_proto "www.velocidex.com/golang/velociraptor/config/proto"
+	"www.velocidex.com/golang/velociraptor/constants"
+	"www.velocidex.com/golang/velociraptor/file_store/test_utils"
+	"www.velocidex.com/golang/velociraptor/services"
+	"www.velocidex.com/golang/velociraptor/utils"
+	"www.velocidex.com/golang/velociraptor/vtesting/assert"
+)
+
+type RateLimitTestSuite struct {
+	test_utils.TestSuite
+}
+
+func (self *RateLimitTestSuite) TestRateClasses() {
+	assert.Equal(self.T(), rateClassQuery, getRateClass("Query"))
+	assert.Equal(self.T(), rateClassRead, getRateClass("GetTable"))
+	assert.Equal(self.T(), rateClassRead, getRateClass("VFSListDirectory"))
+	assert.Equal(self.T(), rateClassDownload, getRateClass("CreateDownloadFile"))
+	assert.Equal(self.T(), rateClassWrite, getRateClass("CollectArtifact"))
+}
+
+func (self *RateLimitTestSuite) TestLimits() {
+	clock := utils.NewMockClock(time.Unix(1800000000, 0))
+	closer := utils.MockTime(clock)
+	defer closer()
+
+	config_obj := proto.Clone(self.ConfigObj).(*config_proto.Config)
+	config_obj.API.RateLimits = []*config_proto.APIRateLimit{
+		// Everyone may run a query every 10 seconds.
+		{Class: "query", Rate: 0.1},
+
+		// But GetTable is limited separately.
+		{Class: "GetTable", Rate: 2, Burst: 2},
+
+		// The automation user is not limited.
+		{Principal: "automation", Rate: 0},
+
+		// Neither are tokens owned by admin.
+		{Principal: "admin", Class: "query", Rate: 100},
+	}
+	limiter := newRateLimiter(config_obj)
+
+	// The first query is allowed, the next must wait.
+	assert.Equal(self.T(), time.Duration(0),
+		limiter.Check("mic", "Query", rateClassQuery))
+	assert.Equal(self.T(), 10*time.Second,
+		limiter.Check("mic", "Query", rateClassQuery))
+
+	// Each principal has their own bucket.
+	assert.Equal(self.T(), time.Duration(0),
+		limiter.Check("fred", "Query", rateClassQuery))
+
+	// Unlimited classes are not affected.
+	for i := 0; i < 10; i++ {
+		assert.Equal(self.T(), time.Duration(0),
+			limiter.Check("mic", "CollectArtifact", rateClassWrite))
+	}
+
+	assert.Equal(self.T(), time.Duration(0),
+		limiter.Check("mic", "GetTable", rateClassRead))
+	assert.Equal(self.T(), time.Duration(0),
+		limiter.Check("mic", "GetTable", rateClassRead))
+	assert.Equal(self.T(), 500*time.Millisecond,
+		limiter.Check("mic", "GetTable", rateClassRead))
+
+	// Rejected calls do not use up tokens.
+	clock.Set(clock.Now().Add(10 * time.Second))
+	assert.Equal(self.T(), time.Duration(0),
+		limiter.Check("mic", "Query", rateClassQuery))
+
+	for i := 0; i < 10; i++ {
+		assert.Equal(self.T(), time.Duration(0),
+			limiter.Check("automation", "Query", rateClassQuery))
+		assert.Equal(self.T(), time.Duration(0),
+			limiter.Check(services.APITokenPrincipal("admin", "T.1234"),
+				"Query", rateClassQuery))
+		assert.Equal(self.T(), time.Duration(0),
+			limiter.Check(utils.GetSuperuserName(config_obj),
+				"Query", rateClassQuery))
+	}
+}
+
+func (self *RateLimitTestSuite) TestExpireIdleBuckets() {
+	clock := utils.NewMockClock(time.Unix(1800000000, 0))
+	closer := utils.MockTime(clock)
+	defer closer()
+
+	config_obj := proto.Clone(self.ConfigObj).(*config_proto.Config)
+	config_obj.API.RateLimits = []*config_proto.APIRateLimit{
+		{Class: "query", Rate: 0.01},
+	}
+	limiter := newRateLimiter(config_obj)
+
+	limiter.Check("mic", "Query", rateClassQuery)
+	limiter.Check("fred", "Query", rateClassQuery)
+	assert.Equal(self.T(), 2, len(limiter.buckets))
+
+	// Buckets which are still refilling are kept.
+	clock.Set(clock.Now().Add(rateLimitExpiry))
+	limiter.Check("bob", "Query", rateClassQuery)
+	assert.Equal(self.T(), 3, len(limiter.buckets))
+
+	// Once the buckets are full again they are forgotten.
+	clock.Set(clock.Now().Add(2 * time.Minute))
+	limiter.Check("mic", "Query", rateClassQuery)
+	assert.Equal(self.T(), 1, len(limiter.buckets))
+
+	// The new bucket starts over.
+	assert.Equal(self.T(), 100*time.Second,
+		limiter.Check("mic", "Query", rateClassQuery))
+}
+
+func (self *RateLimitTestSuite) TestHTTPHandler() {
+	config_obj := proto.Clone(self.ConfigObj).(*config_proto.Config)
+	config_obj.API.RateLimits = []*config_proto.APIRateLimit{
+		{Class: "download", Rate: 0.5},
+	}
+
+	h := rateLimit(config_obj, "DownloadFile", rateClassDownload,
+		api_utils.HandlerFunc(nil,
+			func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+	ctx := context.WithValue(self.Ctx,
+		constants.GRPC_USER_CONTEXT, `{"name":"mic"}`)
+	request := func() *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", "/downloads/foo.zip", nil).
+			WithContext(ctx)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	assert.Equal(self.T(), http.StatusOK, request().Code)
+
+	w := request()
+	assert.Equal(self.T(), http.StatusTooManyRequests, w.Code)
+	assert.Equal(self.T(), "2", w.Header().Get("Retry-After"))
+}
+
+func TestRateLimit(t *testing.T) {
+	suite.Run(t, &RateLimitTestSuite{})
+}
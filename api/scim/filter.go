@@ -0,0 +1,130 @@
+package scim
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Velocidex/ordereddict"
+	api_proto "www.velocidex.com/golang/velociraptor/api/proto"
+)
+
+const (
+	MAX_RESULTS = 1000
+)
+
+var (
+	// Identity providers only use simple equality filters
+	// e.g. userName eq "bob"
+	filterRegex = regexp.MustCompile(`^\s*([a-zA-Z.]+)\s+(?i:eq)\s+"((?:[^"\\]|\\.)*)"\s*$`)
+
+	// Value filters in patch paths e.g. members[value eq "bob"]
+	valuePathRegex = regexp.MustCompile(`^([a-zA-Z]+)\[(.+)\]$`)
+)
+
+type scimFilter struct {
+	// Attribute names are case insensitive so they are lower cased.
+	attr  string
+	value string
+}
+
+func parseFilter(filter string) (*scimFilter, error) {
+	if filter == "" {
+		return nil, nil
+	}
+
+	match := filterRegex.FindStringSubmatch(filter)
+	if match == nil {
+		return nil, newError(http.StatusBadRequest, "invalidFilter",
+			"Unsupported filter %v: only the eq operator is supported", filter)
+	}
+
+	value, err := strconv.Unquote(`"` + match[2] + `"`)
+	if err != nil {
+		return nil, newError(http.StatusBadRequest, "invalidFilter",
+			"Invalid filter %v: %v", filter, err)
+	}
+
+	return &scimFilter{
+		attr:  strings.ToLower(match[1]),
+		value: value,
+	}, nil
+}
+
+func (self *scimFilter) matchUser(
+	user_record *api_proto.VelociraptorUser,
+	record *api_proto.SCIMUser) bool {
+	switch self.attr {
+	case "id", "username":
+		return user_record.Name == self.value
+	case "externalid":
+		return record.ExternalId == self.value
+	case "displayname":
+		return record.DisplayName == self.value
+	case "emails", "emails.value":
+		for _, email := range record.Emails {
+			if strings.EqualFold(email, self.value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (self *scimFilter) matchGroup(name string) bool {
+	switch self.attr {
+	case "id", "displayname":
+		return name == self.value
+	}
+	return false
+}
+
+// Apply startIndex and count to the results.
+func paginate(r *http.Request,
+	resources []*ordereddict.Dict) (*ordereddict.Dict, error) {
+	query := r.URL.Query()
+
+	start_index := 1
+	if query.Get("startIndex") != "" {
+		value, err := strconv.Atoi(query.Get("startIndex"))
+		if err != nil {
+			return nil, newError(http.StatusBadRequest, "invalidValue",
+				"Invalid startIndex: %v", err)
+		}
+
+		// Values less than 1 are interpreted as 1
+		if value > 1 {
+			start_index = value
+		}
+	}
+
+	count := MAX_RESULTS
+	if query.Get("count") != "" {
+		value, err := strconv.Atoi(query.Get("count"))
+		if err != nil {
+			return nil, newError(http.StatusBadRequest, "invalidValue",
+				"Invalid count: %v", err)
+		}
+
+		if value < 0 {
+			value = 0
+		}
+		if value < count {
+			count = value
+		}
+	}
+
+	total := len(resources)
+	start := start_index - 1
+	if start > total {
+		start = total
+	}
+
+	end := start + count
+	if end > total {
+		end = total
+	}
+
+	return listResponse(resources[start:end], total, start_index), nil
+}
@@ -0,0 +1,260 @@
+package scim
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/Velocidex/ordereddict"
+	"www.velocidex.com/golang/velociraptor/utils"
+)
+
+type scimGroup struct {
+	Schemas     []string    `json:"schemas"`
+	DisplayName string      `json:"displayName"`
+	Members     []scimValue `json:"members"`
+}
+
+func (self *SCIMHandler) groupResource(
+	name string, members []string, exclude_members bool) *ordereddict.Dict {
+
+	result := ordereddict.NewDict().
+		Set("schemas", []string{SCHEMA_GROUP}).
+		Set("id", name).
+		Set("displayName", name)
+
+	if !exclude_members {
+		values := []*ordereddict.Dict{}
+		for _, member := range members {
+			values = append(values, ordereddict.NewDict().
+				Set("value", member).
+				Set("display", member).
+				Set("$ref", self.location("Users", member)))
+		}
+		result.Set("members", values)
+	}
+
+	return result.Set("meta", ordereddict.NewDict().
+		Set("resourceType", "Group").
+		Set("location", self.location("Groups", name)))
+}
+
+// All the users that are members of the group.
+func (self *SCIMHandler) getGroupMembers(name string) ([]string, error) {
+	records, err := self.listSCIMUsers()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []string
+	for _, record := range records {
+		if utils.InString(record.Groups, name) {
+			result = append(result, record.UserName)
+		}
+	}
+	return result, nil
+}
+
+func (self *SCIMHandler) getGroup(
+	ctx context.Context, name string) (*ordereddict.Dict, error) {
+	group, err := self.getGroupMapping(name)
+	if err != nil {
+		return nil, err
+	}
+
+	members, err := self.getGroupMembers(group.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	return self.groupResource(group.Name, members, false), nil
+}
+
+func (self *SCIMHandler) listGroups(
+	ctx context.Context, r *http.Request) (*ordereddict.Dict, error) {
+
+	filter, err := parseFilter(r.URL.Query().Get("filter"))
+	if err != nil {
+		return nil, err
+	}
+
+	// Identity providers often exclude members when looking up
+	// groups by name.
+	exclude_members := strings.Contains(
+		strings.ToLower(r.URL.Query().Get("excludedAttributes")), "members")
+
+	var resources []*ordereddict.Dict
+	for _, group := range self.getGroupMappings() {
+		if filter != nil && !filter.matchGroup(group.Name) {
+			continue
+		}
+
+		members, err := self.getGroupMembers(group.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		resources = append(resources,
+			self.groupResource(group.Name, members, exclude_members))
+	}
+
+	return paginate(r, resources)
+}
+
+// Groups can only be created if they are mapped in the config. The
+// members in the request are added to the group.
+func (self *SCIMHandler) createGroup(ctx context.Context,
+	principal string, r *http.Request) (*ordereddict.Dict, error) {
+
+	request := &scimGroup{}
+	err := readRequest(r, request)
+	if err != nil {
+		return nil, err
+	}
+
+	group, err := self.getGroupMapping(request.DisplayName)
+	if err != nil {
+		return nil, newError(http.StatusBadRequest, "invalidValue",
+			"Group %v is not mapped in the SCIM configuration",
+			request.DisplayName)
+	}
+
+	err = self.setGroupMembers(ctx, principal, group.Name,
+		getValues(request.Members))
+	if err != nil {
+		return nil, err
+	}
+
+	return self.getGroup(ctx, group.Name)
+}
+
+func (self *SCIMHandler) replaceGroup(ctx context.Context,
+	principal, name string, r *http.Request) (*ordereddict.Dict, error) {
+
+	request := &scimGroup{}
+	err := readRequest(r, request)
+	if err != nil {
+		return nil, err
+	}
+
+	group, err := self.getGroupMapping(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.DisplayName != "" && request.DisplayName != group.Name {
+		return nil, newError(http.StatusBadRequest, "mutability",
+			"Groups can not be renamed")
+	}
+
+	err = self.setGroupMembers(ctx, principal, group.Name,
+		getValues(request.Members))
+	if err != nil {
+		return nil, err
+	}
+
+	return self.getGroup(ctx, group.Name)
+}
+
+// The group mapping remains in the config but all its members are
+// removed.
+func (self *SCIMHandler) deleteGroup(ctx context.Context,
+	principal, name string) error {
+
+	group, err := self.getGroupMapping(name)
+	if err != nil {
+		return err
+	}
+
+	return self.setGroupMembers(ctx, principal, group.Name, nil)
+}
+
+// Set the exact membership of the group and recalculate the roles of
+// all users that were added or removed.
+func (self *SCIMHandler) setGroupMembers(ctx context.Context,
+	principal, name string, members []string) error {
+
+	members = utils.DeduplicateStringSlice(members)
+	current, err := self.getGroupMembers(name)
+	if err != nil {
+		return err
+	}
+
+	var added, removed []string
+	for _, member := range members {
+		if !utils.InString(current, member) {
+			added = append(added, member)
+		}
+	}
+
+	for _, member := range current {
+		if !utils.InString(members, member) {
+			removed = append(removed, member)
+		}
+	}
+
+	// Check all new members exist before changing anything.
+	for _, username := range added {
+		_, _, err := self.getUserRecords(ctx, principal, username)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, username := range added {
+		record, err := self.getSCIMUser(username)
+		if err != nil {
+			return err
+		}
+
+		record.Groups = append(record.Groups, name)
+		err = self.setSCIMUser(record)
+		if err != nil {
+			return err
+		}
+
+		err = self.updateRoles(ctx, principal, record)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, username := range removed {
+		record, err := self.getSCIMUser(username)
+		if err != nil {
+			return err
+		}
+
+		record.Groups = removeMembers(record.Groups, name)
+		err = self.setSCIMUser(record)
+		if err != nil {
+			return err
+		}
+
+		// Users deleted outside SCIM should not be recreated.
+		_, _, err = self.getUserRecords(ctx, principal, username)
+		var scim_err *scimError
+		if errors.As(err, &scim_err) &&
+			scim_err.status == http.StatusNotFound {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		err = self.updateRoles(ctx, principal, record)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(added) > 0 || len(removed) > 0 {
+		self.audit(ctx, principal, "SCIMGroupMembership", ordereddict.NewDict().
+			Set("group", name).
+			Set("added", added).
+			Set("removed", removed))
+	}
+
+	return nil
+}
@@ -0,0 +1,317 @@
+package scim
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Velocidex/ordereddict"
+	api_proto "www.velocidex.com/golang/velociraptor/api/proto"
+	"www.velocidex.com/golang/velociraptor/json"
+	"www.velocidex.com/golang/velociraptor/utils"
+)
+
+// RFC 7644 section 3.5.2
+type patchRequest struct {
+	Schemas    []string         `json:"schemas"`
+	Operations []patchOperation `json:"Operations"`
+}
+
+type patchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value"`
+}
+
+func readPatchRequest(r *http.Request) (*patchRequest, error) {
+	request := &patchRequest{}
+	err := readRequest(r, request)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, op := range request.Operations {
+		switch strings.ToLower(op.Op) {
+		case "add", "replace", "remove":
+		default:
+			return nil, newError(http.StatusBadRequest, "invalidSyntax",
+				"Unsupported patch operation %v", op.Op)
+		}
+	}
+
+	return request, nil
+}
+
+func (self *SCIMHandler) patchUser(ctx context.Context,
+	principal, username string, r *http.Request) (*ordereddict.Dict, error) {
+
+	request, err := readPatchRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	user_record, record, err := self.getUserRecords(ctx, principal, username)
+	if err != nil {
+		return nil, err
+	}
+
+	active := !user_record.Locked
+	var paths []string
+
+	for _, op := range request.Operations {
+		if strings.ToLower(op.Op) == "remove" {
+			removeUserAttribute(record, op.Path)
+			paths = append(paths, op.Path)
+			continue
+		}
+
+		// Without a path the value contains the attributes to set.
+		if op.Path == "" {
+			attributes := make(map[string]json.RawMessage)
+			err := json.Unmarshal(op.Value, &attributes)
+			if err != nil {
+				return nil, newError(http.StatusBadRequest, "invalidValue",
+					"Invalid patch value: %v", err)
+			}
+
+			for k, v := range attributes {
+				err := setUserAttribute(record, &active, k, v)
+				if err != nil {
+					return nil, err
+				}
+				paths = append(paths, k)
+			}
+			continue
+		}
+
+		err := setUserAttribute(record, &active, op.Path, op.Value)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, op.Path)
+	}
+
+	err = self.setSCIMUser(record)
+	if err != nil {
+		return nil, err
+	}
+
+	err = self.setActive(ctx, principal, username, active)
+	if err != nil {
+		return nil, err
+	}
+
+	self.audit(ctx, principal, "SCIMPatchUser", ordereddict.NewDict().
+		Set("username", username).
+		Set("paths", paths))
+
+	return self.getUser(ctx, principal, username)
+}
+
+// Attributes we do not store (e.g. name.givenName) are ignored.
+func setUserAttribute(record *api_proto.SCIMUser, active *bool,
+	path string, value json.RawMessage) error {
+
+	attr := strings.ToLower(path)
+	switch {
+	case attr == "active":
+		result, err := parseBool(value)
+		if err != nil {
+			return err
+		}
+		*active = result
+
+	case attr == "username":
+		name, err := parseString(value)
+		if err != nil {
+			return err
+		}
+		if name != record.UserName {
+			return newError(http.StatusBadRequest, "mutability",
+				"Users can not be renamed")
+		}
+
+	case attr == "displayname":
+		result, err := parseString(value)
+		if err != nil {
+			return err
+		}
+		record.DisplayName = result
+
+	case attr == "externalid":
+		result, err := parseString(value)
+		if err != nil {
+			return err
+		}
+		record.ExternalId = result
+
+	case attr == "emails":
+		var emails []scimValue
+		err := json.Unmarshal(value, &emails)
+		if err != nil {
+			return newError(http.StatusBadRequest, "invalidValue",
+				"Invalid emails: %v", err)
+		}
+		record.Emails = getValues(emails)
+
+	// e.g. emails[type eq "work"].value replaces the primary email.
+	case strings.HasPrefix(attr, "emails["):
+		email, err := parseString(value)
+		if err != nil {
+			return err
+		}
+		if len(record.Emails) == 0 {
+			record.Emails = []string{email}
+		} else {
+			record.Emails[0] = email
+		}
+	}
+
+	return nil
+}
+
+func removeUserAttribute(record *api_proto.SCIMUser, path string) {
+	attr := strings.ToLower(path)
+	switch {
+	case attr == "displayname":
+		record.DisplayName = ""
+	case attr == "externalid":
+		record.ExternalId = ""
+	case strings.HasPrefix(attr, "emails"):
+		record.Emails = nil
+	}
+}
+
+func (self *SCIMHandler) patchGroup(ctx context.Context,
+	principal, name string, r *http.Request) (*ordereddict.Dict, error) {
+
+	request, err := readPatchRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	group, err := self.getGroupMapping(name)
+	if err != nil {
+		return nil, err
+	}
+
+	members, err := self.getGroupMembers(name)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, op := range request.Operations {
+		path := op.Path
+		value := op.Value
+
+		// Without a path the value contains the attributes to set.
+		if path == "" {
+			attributes := make(map[string]json.RawMessage)
+			err := json.Unmarshal(op.Value, &attributes)
+			if err != nil {
+				return nil, newError(http.StatusBadRequest, "invalidValue",
+					"Invalid patch value: %v", err)
+			}
+
+			value, _ = attributes["members"]
+			if value == nil {
+				continue
+			}
+			path = "members"
+		}
+
+		// Remove a single member e.g. members[value eq "bob"]
+		match := valuePathRegex.FindStringSubmatch(path)
+		if match != nil && strings.ToLower(match[1]) == "members" {
+			filter, err := parseFilter(match[2])
+			if err != nil {
+				return nil, err
+			}
+
+			if strings.ToLower(op.Op) != "remove" || filter.attr != "value" {
+				return nil, newError(http.StatusBadRequest, "invalidPath",
+					"Unsupported path %v", op.Path)
+			}
+			members = removeMembers(members, filter.value)
+			continue
+		}
+
+		// The group's other attributes can not be changed.
+		if strings.ToLower(path) != "members" {
+			continue
+		}
+
+		var values []scimValue
+		if len(value) > 0 {
+			err := json.Unmarshal(value, &values)
+			if err != nil {
+				return nil, newError(http.StatusBadRequest, "invalidValue",
+					"Invalid members: %v", err)
+			}
+		}
+
+		switch strings.ToLower(op.Op) {
+		case "add":
+			members = append(members, getValues(values)...)
+
+		case "replace":
+			members = getValues(values)
+
+		case "remove":
+			// Without a value all members are removed.
+			if len(values) == 0 {
+				members = nil
+			} else {
+				members = removeMembers(members, getValues(values)...)
+			}
+		}
+	}
+
+	err = self.setGroupMembers(ctx, principal, group.Name, members)
+	if err != nil {
+		return nil, err
+	}
+
+	return self.getGroup(ctx, name)
+}
+
+func removeMembers(members []string, remove ...string) []string {
+	var result []string
+	for _, m := range members {
+		if !utils.InString(remove, m) {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+// Some identity providers send booleans as strings.
+func parseBool(value json.RawMessage) (bool, error) {
+	var result interface{}
+	err := json.Unmarshal(value, &result)
+	if err == nil {
+		switch t := result.(type) {
+		case bool:
+			return t, nil
+		case string:
+			b, err := strconv.ParseBool(t)
+			if err == nil {
+				return b, nil
+			}
+		}
+	}
+
+	return false, newError(http.StatusBadRequest, "invalidValue",
+		"Expected a boolean not %v", string(value))
+}
+
+func parseString(value json.RawMessage) (string, error) {
+	var result string
+	err := json.Unmarshal(value, &result)
+	if err != nil {
+		return "", newError(http.StatusBadRequest, "invalidValue",
+			"Expected a string not %v", string(value))
+	}
+	return result, nil
+}
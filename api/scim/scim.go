@@ -0,0 +1,396 @@
+/*
+  A SCIM 2.0 (RFC 7643/7644) endpoint allowing an identity provider
+  to provision users.
+
+  Users are mapped to Velociraptor users with the same name:
+
+  - Creating a user creates the Velociraptor account.
+  - Deactivating a user (active=false) locks the account.
+  - Deleting a user removes the account from all orgs.
+
+  Groups are not created by the identity provider. Instead the
+  administrator maps group names to roles in the config
+  (GUI.scim.groups). Group membership is stored with the SCIM
+  attributes of the user and the user's roles in the mapped orgs are
+  recalculated each time it changes.
+
+  The identity provider authenticates with an API token which must
+  carry ORG_ADMIN in the root org. All changes are made through the
+  user manager as the token's principal and are audited.
+*/
+
+package scim
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/Velocidex/ordereddict"
+	"www.velocidex.com/golang/velociraptor/acls"
+	api_utils "www.velocidex.com/golang/velociraptor/api/utils"
+	config_proto "www.velocidex.com/golang/velociraptor/config/proto"
+	"www.velocidex.com/golang/velociraptor/json"
+	"www.velocidex.com/golang/velociraptor/logging"
+	"www.velocidex.com/golang/velociraptor/services"
+	"www.velocidex.com/golang/velociraptor/utils"
+)
+
+const (
+	SCHEMA_USER                    = "urn:ietf:params:scim:schemas:core:2.0:User"
+	SCHEMA_GROUP                   = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	SCHEMA_LIST_RESPONSE           = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	SCHEMA_PATCH_OP                = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	SCHEMA_ERROR                   = "urn:ietf:params:scim:api:messages:2.0:Error"
+	SCHEMA_SERVICE_PROVIDER_CONFIG = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	SCHEMA_RESOURCE_TYPE           = "urn:ietf:params:scim:schemas:core:2.0:ResourceType"
+
+	CONTENT_TYPE = "application/scim+json"
+
+	// Limit the size of request bodies.
+	MAX_REQUEST_SIZE = 1024 * 1024
+)
+
+// A SCIM error response (RFC 7644 section 3.12)
+type scimError struct {
+	status    int
+	scim_type string
+	detail    string
+}
+
+func (self *scimError) Error() string {
+	return self.detail
+}
+
+func newError(status int, scim_type, format string, args ...interface{}) error {
+	return &scimError{
+		status:    status,
+		scim_type: scim_type,
+		detail:    fmt.Sprintf(format, args...),
+	}
+}
+
+type SCIMHandler struct {
+	// Modifications are serialized so group membership changes are
+	// not lost.
+	mu sync.Mutex
+
+	config_obj *config_proto.Config
+
+	// The url path the endpoint is served under.
+	base_path string
+}
+
+func NewSCIMHandler(
+	config_obj *config_proto.Config, base_path string) http.Handler {
+	self := &SCIMHandler{
+		config_obj: config_obj,
+		base_path:  strings.TrimSuffix(base_path, "/"),
+	}
+	return api_utils.HandlerFunc(nil, self.ServeHTTP)
+}
+
+func (self *SCIMHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	principal, err := self.authenticate(ctx, r)
+	if err != nil {
+		self.writeError(w, err)
+		return
+	}
+
+	result, status, err := self.dispatch(ctx, principal, r)
+	if err != nil {
+		self.writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", CONTENT_TYPE)
+	if result == nil {
+		w.WriteHeader(status)
+		return
+	}
+
+	serialized, err := json.MarshalIndent(result)
+	if err != nil {
+		self.writeError(w, err)
+		return
+	}
+
+	location, pres := ordereddict.GetString(result, "meta.location")
+	if pres && status == http.StatusCreated {
+		w.Header().Set("Location", location)
+	}
+
+	w.WriteHeader(status)
+	_, _ = w.Write(serialized)
+}
+
+func (self *SCIMHandler) dispatch(
+	ctx context.Context, principal string,
+	r *http.Request) (*ordereddict.Dict, int, error) {
+
+	path := strings.TrimPrefix(r.URL.Path, self.base_path)
+	components := utils.FilterSlice(strings.Split(path, "/"), "")
+
+	if len(components) == 0 {
+		return nil, 0, newError(http.StatusNotFound, "", "Not found")
+	}
+
+	resource := components[0]
+	id := ""
+	if len(components) == 2 {
+		id = components[1]
+	} else if len(components) > 2 {
+		return nil, 0, newError(http.StatusNotFound, "", "Not found")
+	}
+
+	// Only reads are allowed without locking.
+	if r.Method != "GET" {
+		self.mu.Lock()
+		defer self.mu.Unlock()
+	}
+
+	switch resource {
+	case "ServiceProviderConfig":
+		return self.serviceProviderConfig(), http.StatusOK, nil
+
+	case "ResourceTypes":
+		return self.resourceTypes(), http.StatusOK, nil
+
+	case "Users":
+		switch {
+		case r.Method == "GET" && id == "":
+			result, err := self.listUsers(ctx, principal, r)
+			return result, http.StatusOK, err
+
+		case r.Method == "GET":
+			result, err := self.getUser(ctx, principal, id)
+			return result, http.StatusOK, err
+
+		case r.Method == "POST" && id == "":
+			result, err := self.createUser(ctx, principal, r)
+			return result, http.StatusCreated, err
+
+		case r.Method == "PUT" && id != "":
+			result, err := self.replaceUser(ctx, principal, id, r)
+			return result, http.StatusOK, err
+
+		case r.Method == "PATCH" && id != "":
+			result, err := self.patchUser(ctx, principal, id, r)
+			return result, http.StatusOK, err
+
+		case r.Method == "DELETE" && id != "":
+			err := self.deleteUser(ctx, principal, id)
+			return nil, http.StatusNoContent, err
+		}
+
+	case "Groups":
+		switch {
+		case r.Method == "GET" && id == "":
+			result, err := self.listGroups(ctx, r)
+			return result, http.StatusOK, err
+
+		case r.Method == "GET":
+			result, err := self.getGroup(ctx, id)
+			return result, http.StatusOK, err
+
+		case r.Method == "POST" && id == "":
+			result, err := self.createGroup(ctx, principal, r)
+			return result, http.StatusCreated, err
+
+		case r.Method == "PUT" && id != "":
+			result, err := self.replaceGroup(ctx, principal, id, r)
+			return result, http.StatusOK, err
+
+		case r.Method == "PATCH" && id != "":
+			result, err := self.patchGroup(ctx, principal, id, r)
+			return result, http.StatusOK, err
+
+		case r.Method == "DELETE" && id != "":
+			err := self.deleteGroup(ctx, principal, id)
+			return nil, http.StatusNoContent, err
+		}
+
+	default:
+		return nil, 0, newError(http.StatusNotFound, "", "Not found")
+	}
+
+	return nil, 0, newError(http.StatusMethodNotAllowed, "",
+		"Method %v not supported", r.Method)
+}
+
+// Only API tokens are accepted. The token must be able to manage
+// users in all orgs.
+func (self *SCIMHandler) authenticate(
+	ctx context.Context, r *http.Request) (string, error) {
+	bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return "", newError(http.StatusUnauthorized, "",
+			"API token required")
+	}
+
+	users_manager := services.GetUserManager()
+	token, err := users_manager.VerifyAPIToken(ctx,
+		bearer, r.RemoteAddr, "SCIM "+r.Method+" "+r.URL.Path)
+	if err != nil {
+		return "", newError(http.StatusUnauthorized, "", "%v", err)
+	}
+
+	principal := services.APITokenPrincipal(token.Username, token.TokenId)
+
+	root_config_obj, err := self.getRootConfig()
+	if err != nil {
+		return "", err
+	}
+
+	ok, err = services.CheckAccess(root_config_obj, principal, acls.ORG_ADMIN)
+	if err != nil || !ok {
+		return "", newError(http.StatusForbidden, "",
+			"%v: %v requires ORG_ADMIN", acls.PermissionDenied, principal)
+	}
+
+	return principal, nil
+}
+
+func (self *SCIMHandler) getRootConfig() (*config_proto.Config, error) {
+	org_manager, err := services.GetOrgManager()
+	if err != nil {
+		return nil, err
+	}
+
+	return org_manager.GetOrgConfig(services.ROOT_ORG_ID)
+}
+
+func (self *SCIMHandler) writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	scim_type := ""
+
+	var scim_err *scimError
+	switch {
+	case errors.As(err, &scim_err):
+		status = scim_err.status
+		scim_type = scim_err.scim_type
+
+	case errors.Is(err, utils.NotFoundError):
+		status = http.StatusNotFound
+
+	case errors.Is(err, acls.PermissionDenied):
+		status = http.StatusForbidden
+	}
+
+	serialized := json.MustMarshalIndent(ordereddict.NewDict().
+		Set("schemas", []string{SCHEMA_ERROR}).
+		Set("status", fmt.Sprintf("%d", status)).
+		Set("scimType", scim_type).
+		Set("detail", err.Error()))
+
+	w.Header().Set("Content-Type", CONTENT_TYPE)
+	w.WriteHeader(status)
+	_, _ = w.Write(serialized)
+}
+
+func (self *SCIMHandler) audit(ctx context.Context,
+	principal, operation string, details *ordereddict.Dict) {
+	root_config_obj, err := self.getRootConfig()
+	if err == nil {
+		err = services.LogAudit(ctx,
+			root_config_obj, principal, operation, details)
+	}
+	if err != nil {
+		logger := logging.GetLogger(self.config_obj, &logging.FrontendComponent)
+		logger.Error("<red>%v</> %v %v", operation, principal, details)
+	}
+}
+
+func (self *SCIMHandler) location(parts ...string) string {
+	return self.base_path + "/" + strings.Join(parts, "/")
+}
+
+func readRequest(r *http.Request, target interface{}) error {
+	body, err := io.ReadAll(io.LimitReader(r.Body, MAX_REQUEST_SIZE))
+	if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(body, target)
+	if err != nil {
+		return newError(http.StatusBadRequest, "invalidSyntax",
+			"Invalid request: %v", err)
+	}
+	return nil
+}
+
+func listResponse(resources []*ordereddict.Dict,
+	total, start_index int) *ordereddict.Dict {
+	if resources == nil {
+		resources = []*ordereddict.Dict{}
+	}
+
+	return ordereddict.NewDict().
+		Set("schemas", []string{SCHEMA_LIST_RESPONSE}).
+		Set("totalResults", total).
+		Set("startIndex", start_index).
+		Set("itemsPerPage", len(resources)).
+		Set("Resources", resources)
+}
+
+func (self *SCIMHandler) serviceProviderConfig() *ordereddict.Dict {
+	supported := func(value bool) *ordereddict.Dict {
+		return ordereddict.NewDict().Set("supported", value)
+	}
+
+	return ordereddict.NewDict().
+		Set("schemas", []string{SCHEMA_SERVICE_PROVIDER_CONFIG}).
+		Set("patch", supported(true)).
+		Set("bulk", supported(false).
+			Set("maxOperations", 0).
+			Set("maxPayloadSize", 0)).
+		Set("filter", supported(true).
+			Set("maxResults", MAX_RESULTS)).
+		Set("changePassword", supported(false)).
+		Set("sort", supported(false)).
+		Set("etag", supported(false)).
+		Set("authenticationSchemes", []*ordereddict.Dict{
+			ordereddict.NewDict().
+				Set("type", "oauthbearertoken").
+				Set("name", "API Token").
+				Set("description", "A Velociraptor API token with ORG_ADMIN."),
+		}).
+		Set("meta", ordereddict.NewDict().
+			Set("resourceType", "ServiceProviderConfig").
+			Set("location", self.location("ServiceProviderConfig")))
+}
+
+func (self *SCIMHandler) resourceTypes() *ordereddict.Dict {
+	return listResponse([]*ordereddict.Dict{
+		ordereddict.NewDict().
+			Set("schemas", []string{SCHEMA_RESOURCE_TYPE}).
+			Set("id", "User").
+			Set("name", "User").
+			Set("endpoint", "/Users").
+			Set("schema", SCHEMA_USER).
+			Set("meta", ordereddict.NewDict().
+				Set("resourceType", "ResourceType").
+				Set("location", self.location("ResourceTypes", "User"))),
+		ordereddict.NewDict().
+			Set("schemas", []string{SCHEMA_RESOURCE_TYPE}).
+			Set("id", "Group").
+			Set("name", "Group").
+			Set("endpoint", "/Groups").
+			Set("schema", SCHEMA_GROUP).
+			Set("meta", ordereddict.NewDict().
+				Set("resourceType", "ResourceType").
+				Set("location", self.location("ResourceTypes", "Group"))),
+	}, 2, 1)
+}
+
+// Make sure the handler is not exposed by accident.
+func IsEnabled(config_obj *config_proto.Config) bool {
+	return config_obj.GUI != nil && config_obj.GUI.Scim != nil &&
+		config_obj.GUI.Scim.Enabled
+}
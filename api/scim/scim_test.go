@@ -0,0 +1,293 @@
+package scim
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Velocidex/ordereddict"
+	"github.com/stretchr/testify/suite"
+	api_proto "www.velocidex.com/golang/velociraptor/api/proto"
+	config_proto "www.velocidex.com/golang/velociraptor/config/proto"
+	"www.velocidex.com/golang/velociraptor/file_store/test_utils"
+	"www.velocidex.com/golang/velociraptor/json"
+	"www.velocidex.com/golang/velociraptor/services"
+	"www.velocidex.com/golang/velociraptor/services/users"
+	"www.velocidex.com/golang/velociraptor/utils"
+	"www.velocidex.com/golang/velociraptor/vtesting/assert"
+)
+
+type SCIMTestSuite struct {
+	test_utils.TestSuite
+
+	handler      http.Handler
+	admin_token  string
+	reader_token string
+}
+
+func (self *SCIMTestSuite) SetupTest() {
+	self.ConfigObj = self.TestSuite.LoadConfig()
+	self.ConfigObj.GUI.Scim = &config_proto.SCIMConfig{
+		Enabled: true,
+		Groups: []*config_proto.SCIMGroupMapping{{
+			Name:  "Analysts",
+			Roles: []string{"analyst"},
+			Orgs:  []string{"O1"},
+		}, {
+			Name:  "Readers",
+			Roles: []string{"reader"},
+			Orgs:  []string{"O1", "root"},
+		}},
+	}
+
+	self.TestSuite.SetupTest()
+
+	org_manager, err := services.GetOrgManager()
+	assert.NoError(self.T(), err)
+
+	_, err = org_manager.CreateNewOrg("O1", "O1", services.RandomNonce)
+	assert.NoError(self.T(), err)
+
+	self.admin_token = self.createToken("admin", "administrator", "ORG_ADMIN")
+	self.reader_token = self.createToken("reader", "reader", "READ_RESULTS")
+
+	self.handler = NewSCIMHandler(self.ConfigObj, "/scim/v2")
+}
+
+func (self *SCIMTestSuite) createToken(
+	username, role, permission string) string {
+	t := self.T()
+
+	user_record, err := users.NewUserRecord(self.ConfigObj, username)
+	assert.NoError(t, err)
+
+	users_manager := services.GetUserManager()
+	err = users_manager.SetUser(self.Ctx, user_record)
+	assert.NoError(t, err)
+
+	err = services.GrantRoles(self.ConfigObj, username, []string{role})
+	assert.NoError(t, err)
+
+	response, err := users_manager.CreateAPIToken(self.Ctx, username,
+		&api_proto.CreateApiTokenRequest{
+			Name:        "SCIM",
+			Permissions: []string{permission},
+			Orgs:        []string{"root"},
+		})
+	assert.NoError(t, err)
+
+	return response.Secret
+}
+
+func (self *SCIMTestSuite) request(method, path, body string) (
+	int, *ordereddict.Dict) {
+	req := httptest.NewRequest(method, "/scim/v2"+path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+self.admin_token)
+	req.Header.Set("Content-Type", CONTENT_TYPE)
+
+	w := httptest.NewRecorder()
+	self.handler.ServeHTTP(w, req)
+
+	result := ordereddict.NewDict()
+	if w.Body.Len() > 0 {
+		assert.NoError(self.T(), json.Unmarshal(w.Body.Bytes(), result))
+	}
+	return w.Code, result
+}
+
+func (self *SCIMTestSuite) getRoles(org_id, username string) []string {
+	org_manager, err := services.GetOrgManager()
+	assert.NoError(self.T(), err)
+
+	org_config_obj, err := org_manager.GetOrgConfig(org_id)
+	assert.NoError(self.T(), err)
+
+	policy, err := services.GetPolicy(org_config_obj, username)
+	if err != nil {
+		return nil
+	}
+	return policy.Roles
+}
+
+func (self *SCIMTestSuite) TestAuthentication() {
+	t := self.T()
+
+	for _, authorization := range []string{"", "Bearer vrt_invalid",
+		"Basic YWRtaW46cGFzc3dvcmQ="} {
+		req := httptest.NewRequest("GET", "/scim/v2/Users", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		w := httptest.NewRecorder()
+		self.handler.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	}
+
+	// A valid token without ORG_ADMIN is rejected.
+	req := httptest.NewRequest("GET", "/scim/v2/Users", nil)
+	req.Header.Set("Authorization", "Bearer "+self.reader_token)
+	w := httptest.NewRecorder()
+	self.handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), SCHEMA_ERROR)
+
+	status, result := self.request("GET", "/ServiceProviderConfig", "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, true, utils.GetAny(result, "patch.supported"))
+}
+
+func (self *SCIMTestSuite) TestUserLifecycle() {
+	t := self.T()
+
+	status, result := self.request("POST", "/Users", `{
+  "schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+  "userName": "bob@example.com",
+  "externalId": "1234",
+  "displayName": "Bob",
+  "active": true,
+  "emails": [{"value": "bob@example.com", "primary": true}]
+}`)
+	assert.Equal(t, http.StatusCreated, status)
+	assert.Equal(t, "bob@example.com", utils.GetString(result, "id"))
+	assert.Equal(t, "1234", utils.GetString(result, "externalId"))
+
+	// Creating the same user again is a conflict.
+	status, result = self.request("POST", "/Users",
+		`{"userName": "bob@example.com"}`)
+	assert.Equal(t, http.StatusConflict, status)
+	assert.Equal(t, "uniqueness", utils.GetString(result, "scimType"))
+
+	// Usernames may not contain a #.
+	status, result = self.request("POST", "/Users",
+		`{"userName": "bob#example.com"}`)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, "invalidValue", utils.GetString(result, "scimType"))
+
+	// Identity providers look up users by filter.
+	status, result = self.request("GET",
+		`/Users?filter=userName+eq+%22bob@example.com%22`, "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, int64(1), utils.GetInt64(result, "totalResults"))
+
+	status, result = self.request("GET",
+		`/Users?filter=externalId+eq+%221234%22`, "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, int64(1), utils.GetInt64(result, "totalResults"))
+
+	status, result = self.request("GET",
+		`/Users?filter=userName+eq+%22nobody%22`, "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, int64(0), utils.GetInt64(result, "totalResults"))
+
+	status, _ = self.request("GET", `/Users?filter=userName+co+%22bob%22`, "")
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	// Deactivate the user as Azure does.
+	status, result = self.request("PATCH", "/Users/bob@example.com", `{
+  "schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+  "Operations": [
+    {"op": "Replace", "path": "active", "value": "False"},
+    {"op": "Replace", "path": "name.givenName", "value": "Robert"},
+    {"op": "Replace", "path": "displayName", "value": "Robert"}
+  ]
+}`)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, false, utils.GetAny(result, "active"))
+	assert.Equal(t, "Robert", utils.GetString(result, "displayName"))
+
+	users_manager := services.GetUserManager()
+	user_record, err := users_manager.GetUserWithHashes(
+		self.Ctx, "admin", "bob@example.com")
+	assert.NoError(t, err)
+	assert.True(t, user_record.Locked)
+
+	// Okta reactivates with a path-less replace.
+	status, result = self.request("PATCH", "/Users/bob@example.com", `{
+  "Operations": [{"op": "replace", "value": {"active": true}}]
+}`)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, true, utils.GetAny(result, "active"))
+
+	// Users can not be renamed.
+	status, _ = self.request("PUT", "/Users/bob@example.com",
+		`{"userName": "alice@example.com"}`)
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	status, _ = self.request("DELETE", "/Users/bob@example.com", "")
+	assert.Equal(t, http.StatusNoContent, status)
+
+	status, _ = self.request("GET", "/Users/bob@example.com", "")
+	assert.Equal(t, http.StatusNotFound, status)
+}
+
+func (self *SCIMTestSuite) TestGroupMembership() {
+	t := self.T()
+
+	for _, name := range []string{"bob", "alice"} {
+		status, _ := self.request("POST", "/Users",
+			`{"userName": "`+name+`"}`)
+		assert.Equal(t, http.StatusCreated, status)
+	}
+
+	status, result := self.request("GET", "/Groups?excludedAttributes=members", "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, int64(2), utils.GetInt64(result, "totalResults"))
+
+	// Only mapped groups can be created.
+	status, _ = self.request("POST", "/Groups", `{"displayName": "Other"}`)
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	status, result = self.request("POST", "/Groups", `{
+  "displayName": "Analysts",
+  "members": [{"value": "bob"}]
+}`)
+	assert.Equal(t, http.StatusCreated, status)
+	assert.Equal(t, "Analysts", utils.GetString(result, "id"))
+	assert.Equal(t, []string{"analyst"}, self.getRoles("O1", "bob"))
+
+	status, _ = self.request("PATCH", "/Groups/Readers", `{
+  "Operations": [{"op": "add", "path": "members",
+                  "value": [{"value": "bob"}, {"value": "alice"}]}]
+}`)
+	assert.Equal(t, http.StatusOK, status)
+
+	// Roles are the union of all the groups.
+	assert.Equal(t, []string{"analyst", "reader"}, self.getRoles("O1", "bob"))
+	assert.Equal(t, []string{"reader"}, self.getRoles("root", "bob"))
+	assert.Equal(t, []string{"reader"}, self.getRoles("O1", "alice"))
+
+	// Unknown users can not be added.
+	status, _ = self.request("PATCH", "/Groups/Readers", `{
+  "Operations": [{"op": "add", "path": "members", "value": [{"value": "eve"}]}]
+}`)
+	assert.Equal(t, http.StatusNotFound, status)
+
+	status, _ = self.request("PATCH", "/Groups/Readers", `{
+  "Operations": [{"op": "remove", "path": "members[value eq \"bob\"]"}]
+}`)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, []string{"analyst"}, self.getRoles("O1", "bob"))
+	assert.Equal(t, 0, len(self.getRoles("root", "bob")))
+
+	status, result = self.request("GET", "/Users/bob", "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, `[{"value":"Analysts","display":"Analysts","$ref":"/scim/v2/Groups/Analysts"}]`,
+		json.MustMarshalString(utils.GetAny(result, "groups")))
+
+	status, _ = self.request("DELETE", "/Groups/Analysts", "")
+	assert.Equal(t, http.StatusNoContent, status)
+	assert.Equal(t, 0, len(self.getRoles("O1", "bob")))
+
+	status, result = self.request("GET", "/Groups/Readers", "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, `[{"value":"alice","display":"alice","$ref":"/scim/v2/Users/alice"}]`,
+		json.MustMarshalString(utils.GetAny(result, "members")))
+
+	status, _ = self.request("GET", "/Groups/Other", "")
+	assert.Equal(t, http.StatusNotFound, status)
+}
+
+func TestSCIM(t *testing.T) {
+	suite.Run(t, &SCIMTestSuite{})
+}
@@ -0,0 +1,172 @@
+package scim
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"sort"
+
+	"github.com/Velocidex/ordereddict"
+	acl_proto "www.velocidex.com/golang/velociraptor/acls/proto"
+	api_proto "www.velocidex.com/golang/velociraptor/api/proto"
+	config_proto "www.velocidex.com/golang/velociraptor/config/proto"
+	"www.velocidex.com/golang/velociraptor/datastore"
+	"www.velocidex.com/golang/velociraptor/paths"
+	"www.velocidex.com/golang/velociraptor/services"
+	"www.velocidex.com/golang/velociraptor/utils"
+)
+
+// Get the SCIM attributes of the user. Users not provisioned through
+// SCIM get an empty record.
+func (self *SCIMHandler) getSCIMUser(username string) (*api_proto.SCIMUser, error) {
+	db, err := datastore.GetDB(self.config_obj)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &api_proto.SCIMUser{}
+	path_manager := paths.SCIMPathManager{}
+	err = db.GetSubject(self.config_obj, path_manager.User(username), result)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	result.UserName = username
+	return result, nil
+}
+
+func (self *SCIMHandler) setSCIMUser(record *api_proto.SCIMUser) error {
+	db, err := datastore.GetDB(self.config_obj)
+	if err != nil {
+		return err
+	}
+
+	now := uint64(utils.GetTime().Now().Unix())
+	if record.Created == 0 {
+		record.Created = now
+	}
+	record.Modified = now
+
+	path_manager := paths.SCIMPathManager{}
+	return db.SetSubject(self.config_obj, path_manager.User(record.UserName), record)
+}
+
+func (self *SCIMHandler) deleteSCIMUser(username string) error {
+	db, err := datastore.GetDB(self.config_obj)
+	if err != nil {
+		return err
+	}
+
+	path_manager := paths.SCIMPathManager{}
+	return db.DeleteSubject(self.config_obj, path_manager.User(username))
+}
+
+// All the users with SCIM attributes.
+func (self *SCIMHandler) listSCIMUsers() ([]*api_proto.SCIMUser, error) {
+	db, err := datastore.GetDB(self.config_obj)
+	if err != nil {
+		return nil, err
+	}
+
+	path_manager := paths.SCIMPathManager{}
+	children, err := db.ListChildren(self.config_obj, path_manager.UsersDir())
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*api_proto.SCIMUser
+	for _, child := range children {
+		if child.IsDir() {
+			continue
+		}
+
+		record := &api_proto.SCIMUser{}
+		err := db.GetSubject(self.config_obj, child, record)
+		if err != nil || record.UserName == "" {
+			continue
+		}
+		result = append(result, record)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].UserName < result[j].UserName
+	})
+
+	return result, nil
+}
+
+func (self *SCIMHandler) getGroupMappings() []*config_proto.SCIMGroupMapping {
+	if self.config_obj.GUI == nil || self.config_obj.GUI.Scim == nil {
+		return nil
+	}
+	return self.config_obj.GUI.Scim.Groups
+}
+
+func (self *SCIMHandler) getGroupMapping(
+	name string) (*config_proto.SCIMGroupMapping, error) {
+	for _, group := range self.getGroupMappings() {
+		if group.Name == name {
+			return group, nil
+		}
+	}
+	return nil, newError(http.StatusNotFound, "",
+		"Group %v is not mapped in the SCIM configuration", name)
+}
+
+func getMappingOrgs(group *config_proto.SCIMGroupMapping) []string {
+	if len(group.Orgs) == 0 {
+		return []string{services.ROOT_ORG_ID}
+	}
+	return group.Orgs
+}
+
+// Recalculate the user's roles in all the orgs managed by SCIM from
+// their group membership. The user account is created if needed.
+func (self *SCIMHandler) updateRoles(ctx context.Context,
+	principal string, record *api_proto.SCIMUser) error {
+
+	// The roles for each managed org. Orgs without roles remain in
+	// the map so the user's roles are removed there.
+	org_roles := ordereddict.NewDict()
+	for _, group := range self.getGroupMappings() {
+		member := utils.InString(record.Groups, group.Name)
+		for _, org_id := range getMappingOrgs(group) {
+			roles_any, _ := org_roles.Get(org_id)
+			roles, _ := roles_any.([]string)
+			if member {
+				roles = append(roles, group.Roles...)
+			}
+			org_roles.Set(org_id, roles)
+		}
+	}
+
+	// Without any mapped groups we still need to create the account
+	// in the root org.
+	if org_roles.Len() == 0 {
+		org_roles.Set(services.ROOT_ORG_ID, []string{})
+	}
+
+	users_manager := services.GetUserManager()
+	for _, org_id := range org_roles.Keys() {
+		roles_any, _ := org_roles.Get(org_id)
+		roles, _ := roles_any.([]string)
+
+		roles = utils.DeduplicateStringSlice(roles)
+		sort.Strings(roles)
+
+		err := users_manager.AddUserToOrg(ctx, services.AddNewUser,
+			principal, record.UserName, []string{org_id},
+			&acl_proto.ApiClientACL{Roles: roles})
+		if err != nil {
+			return err
+		}
+	}
+
+	self.audit(ctx, principal, "SCIMUpdateRoles", ordereddict.NewDict().
+		Set("username", record.UserName).
+		Set("groups", record.Groups).
+		Set("roles", org_roles))
+
+	return nil
+}
@@ -0,0 +1,344 @@
+package scim
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Velocidex/ordereddict"
+	api_proto "www.velocidex.com/golang/velociraptor/api/proto"
+	"www.velocidex.com/golang/velociraptor/services"
+	"www.velocidex.com/golang/velociraptor/services/users"
+	"www.velocidex.com/golang/velociraptor/utils"
+)
+
+type scimValue struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+type scimUser struct {
+	Schemas     []string    `json:"schemas"`
+	UserName    string      `json:"userName"`
+	ExternalId  string      `json:"externalId"`
+	DisplayName string      `json:"displayName"`
+	Active      *bool       `json:"active"`
+	Emails      []scimValue `json:"emails"`
+}
+
+func (self *SCIMHandler) userResource(
+	user_record *api_proto.VelociraptorUser,
+	record *api_proto.SCIMUser) *ordereddict.Dict {
+
+	display_name := record.DisplayName
+	if display_name == "" {
+		display_name = user_record.Name
+	}
+
+	emails := []*ordereddict.Dict{}
+	for idx, email := range record.Emails {
+		emails = append(emails, ordereddict.NewDict().
+			Set("value", email).
+			Set("primary", idx == 0))
+	}
+
+	groups := []*ordereddict.Dict{}
+	for _, group := range record.Groups {
+		groups = append(groups, ordereddict.NewDict().
+			Set("value", group).
+			Set("display", group).
+			Set("$ref", self.location("Groups", group)))
+	}
+
+	meta := ordereddict.NewDict().
+		Set("resourceType", "User")
+	if record.Created > 0 {
+		meta.Set("created", formatTime(record.Created)).
+			Set("lastModified", formatTime(record.Modified))
+	}
+	meta.Set("location", self.location("Users", user_record.Name))
+
+	result := ordereddict.NewDict().
+		Set("schemas", []string{SCHEMA_USER}).
+		Set("id", user_record.Name)
+	if record.ExternalId != "" {
+		result.Set("externalId", record.ExternalId)
+	}
+
+	return result.
+		Set("userName", user_record.Name).
+		Set("displayName", display_name).
+		Set("active", !user_record.Locked).
+		Set("emails", emails).
+		Set("groups", groups).
+		Set("meta", meta)
+}
+
+// Get the Velociraptor user record and the SCIM attributes.
+func (self *SCIMHandler) getUserRecords(
+	ctx context.Context, principal, username string) (
+	*api_proto.VelociraptorUser, *api_proto.SCIMUser, error) {
+
+	users_manager := services.GetUserManager()
+	user_record, err := users_manager.GetUserWithHashes(ctx, principal, username)
+	if err != nil {
+		if errors.Is(err, utils.NotFoundError) {
+			return nil, nil, newError(http.StatusNotFound, "",
+				"User %v not found", username)
+		}
+		return nil, nil, err
+	}
+
+	record, err := self.getSCIMUser(username)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return user_record, record, nil
+}
+
+func (self *SCIMHandler) getUser(ctx context.Context,
+	principal, username string) (*ordereddict.Dict, error) {
+	user_record, record, err := self.getUserRecords(ctx, principal, username)
+	if err != nil {
+		return nil, err
+	}
+	return self.userResource(user_record, record), nil
+}
+
+func (self *SCIMHandler) listUsers(ctx context.Context,
+	principal string, r *http.Request) (*ordereddict.Dict, error) {
+
+	filter, err := parseFilter(r.URL.Query().Get("filter"))
+	if err != nil {
+		return nil, err
+	}
+
+	// Fast path for the most common lookup.
+	if filter != nil && (filter.attr == "username" || filter.attr == "id") {
+		resource, err := self.getUser(ctx, principal, filter.value)
+		if err != nil {
+			var scim_err *scimError
+			if errors.As(err, &scim_err) &&
+				scim_err.status == http.StatusNotFound {
+				return listResponse(nil, 0, 1), nil
+			}
+			return nil, err
+		}
+		return listResponse([]*ordereddict.Dict{resource}, 1, 1), nil
+	}
+
+	// Users not in any org are only known from their SCIM records.
+	users_manager := services.GetUserManager()
+	users, err := users_manager.ListUsers(ctx, principal, services.LIST_ALL_ORGS)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]bool)
+	for _, u := range users {
+		names[u.Name] = true
+	}
+
+	records, err := self.listSCIMUsers()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, record := range records {
+		names[record.UserName] = true
+	}
+
+	var resources []*ordereddict.Dict
+	for _, name := range utils.Sort(names) {
+		user_record, record, err := self.getUserRecords(ctx, principal, name)
+		if err != nil {
+			continue
+		}
+
+		if filter != nil && !filter.matchUser(user_record, record) {
+			continue
+		}
+
+		resources = append(resources, self.userResource(user_record, record))
+	}
+
+	return paginate(r, resources)
+}
+
+func (self *SCIMHandler) createUser(ctx context.Context,
+	principal string, r *http.Request) (*ordereddict.Dict, error) {
+
+	request := &scimUser{}
+	err := readRequest(r, request)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.UserName == "" {
+		return nil, newError(http.StatusBadRequest, "invalidValue",
+			"userName is required")
+	}
+
+	err = users.ValidateUsername(self.config_obj, request.UserName)
+	if err != nil {
+		return nil, newError(http.StatusBadRequest, "invalidValue",
+			"%v", err)
+	}
+
+	users_manager := services.GetUserManager()
+	_, err = users_manager.GetUserWithHashes(ctx, principal, request.UserName)
+	if err == nil {
+		return nil, newError(http.StatusConflict, "uniqueness",
+			"User %v already exists", request.UserName)
+	}
+
+	record := &api_proto.SCIMUser{
+		UserName:    request.UserName,
+		ExternalId:  request.ExternalId,
+		DisplayName: request.DisplayName,
+		Emails:      getValues(request.Emails),
+	}
+
+	// Creates the account.
+	err = self.updateRoles(ctx, principal, record)
+	if err != nil {
+		return nil, err
+	}
+
+	err = self.setSCIMUser(record)
+	if err != nil {
+		return nil, err
+	}
+
+	err = self.setActive(ctx, principal, record.UserName,
+		request.Active == nil || *request.Active)
+	if err != nil {
+		return nil, err
+	}
+
+	self.audit(ctx, principal, "SCIMCreateUser", ordereddict.NewDict().
+		Set("username", record.UserName).
+		Set("external_id", record.ExternalId))
+
+	return self.getUser(ctx, principal, record.UserName)
+}
+
+func (self *SCIMHandler) replaceUser(ctx context.Context,
+	principal, username string, r *http.Request) (*ordereddict.Dict, error) {
+
+	request := &scimUser{}
+	err := readRequest(r, request)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.UserName != "" && request.UserName != username {
+		return nil, newError(http.StatusBadRequest, "mutability",
+			"Users can not be renamed")
+	}
+
+	_, record, err := self.getUserRecords(ctx, principal, username)
+	if err != nil {
+		return nil, err
+	}
+
+	record.ExternalId = request.ExternalId
+	record.DisplayName = request.DisplayName
+	record.Emails = getValues(request.Emails)
+
+	err = self.setSCIMUser(record)
+	if err != nil {
+		return nil, err
+	}
+
+	err = self.setActive(ctx, principal, username,
+		request.Active == nil || *request.Active)
+	if err != nil {
+		return nil, err
+	}
+
+	self.audit(ctx, principal, "SCIMReplaceUser", ordereddict.NewDict().
+		Set("username", username).
+		Set("active", request.Active == nil || *request.Active))
+
+	return self.getUser(ctx, principal, username)
+}
+
+func (self *SCIMHandler) deleteUser(ctx context.Context,
+	principal, username string) error {
+
+	_, _, err := self.getUserRecords(ctx, principal, username)
+	if err != nil {
+		return err
+	}
+
+	users_manager := services.GetUserManager()
+	err = users_manager.DeleteUser(ctx, principal, username,
+		services.LIST_ALL_ORGS)
+	if err != nil {
+		return err
+	}
+
+	err = self.deleteSCIMUser(username)
+	if err != nil {
+		return err
+	}
+
+	self.audit(ctx, principal, "SCIMDeleteUser",
+		ordereddict.NewDict().Set("username", username))
+
+	return nil
+}
+
+// Deactivated users are locked out.
+func (self *SCIMHandler) setActive(ctx context.Context,
+	principal, username string, active bool) error {
+	users_manager := services.GetUserManager()
+	user_record, err := users_manager.GetUserWithHashes(ctx, principal, username)
+	if err != nil {
+		return err
+	}
+
+	if user_record.Locked == !active {
+		return nil
+	}
+
+	user_record.Locked = !active
+	err = users_manager.SetUser(ctx, user_record)
+	if err != nil {
+		return err
+	}
+
+	operation := "SCIMActivateUser"
+	if !active {
+		operation = "SCIMDeactivateUser"
+	}
+	self.audit(ctx, principal, operation,
+		ordereddict.NewDict().Set("username", username))
+
+	return nil
+}
+
+func getValues(values []scimValue) []string {
+	var result []string
+	for _, v := range values {
+		if v.Value == "" {
+			continue
+		}
+
+		// The primary value goes first.
+		if v.Primary {
+			result = append([]string{v.Value}, result...)
+		} else {
+			result = append(result, v.Value)
+		}
+	}
+	return result
+}
+
+func formatTime(timestamp uint64) string {
+	return time.Unix(int64(timestamp), 0).UTC().Format(time.RFC3339)
+}
@@ -329,6 +329,8 @@ type ArtifactSource struct {
 	Queries []string `protobuf:"bytes,2,rep,name=queries,proto3" json:"queries,omitempty"`
 	// Notebook cells that will be added as part of the source.
 	Notebook      []*NotebookSourceCell `protobuf:"bytes,5,rep,name=notebook,proto3" json:"notebook,omitempty"`
+	KeyColumn     string                `protobuf:"bytes,7,opt,name=key_column,json=keyColumn,proto3" json:"key_column,omitempty"`
+	StackColumns  []string              `protobuf:"bytes,8,rep,name=stack_columns,json=stackColumns,proto3" json:"stack_columns,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -405,6 +407,20 @@ func (x *ArtifactSource) GetNotebook() []*NotebookSourceCell {
 	return nil
 }
 
+func (x *ArtifactSource) GetKeyColumn() string {
+	if x != nil {
+		return x.KeyColumn
+	}
+	return ""
+}
+
+func (x *ArtifactSource) GetStackColumns() []string {
+	if x != nil {
+		return x.StackColumns
+	}
+	return nil
+}
+
 // Deprecated - Reports have been replaced by notebooks.
 type Report struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
@@ -559,6 +575,7 @@ type Artifact struct {
 	// Not read from the artifact definition but it is managed by the
 	// repository.
 	Metadata      *ArtifactMetadata `protobuf:"bytes,25,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	Tests         []*ArtifactTest   `protobuf:"bytes,29,rep,name=tests,proto3" json:"tests,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -775,6 +792,184 @@ func (x *Artifact) GetMetadata() *ArtifactMetadata {
 	return nil
 }
 
+func (x *Artifact) GetTests() []*ArtifactTest {
+	if x != nil {
+		return x.Tests
+	}
+	return nil
+}
+
+// A plugin, function or artifact replaced for the duration of an
+// artifact test. Only one of plugin, function or artifact should be
+// set.
+type ArtifactTestMock struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Plugin   string                 `protobuf:"bytes,1,opt,name=plugin,proto3" json:"plugin,omitempty"`
+	Function string                 `protobuf:"bytes,2,opt,name=function,proto3" json:"function,omitempty"`
+	Artifact string                 `protobuf:"bytes,3,opt,name=artifact,proto3" json:"artifact,omitempty"`
+	// The results as JSON. A list of rows is emitted for each call,
+	// while a list of lists provides the rows for successive calls.
+	Results string `protobuf:"bytes,4,opt,name=results,proto3" json:"results,omitempty"`
+	// Alternatively a VQL query to produce the rows (for example
+	// when the rows need to contain OSPath objects).
+	Query         string `protobuf:"bytes,5,opt,name=query,proto3" json:"query,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ArtifactTestMock) Reset() {
+	*x = ArtifactTestMock{}
+	mi := &file_artifact_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ArtifactTestMock) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ArtifactTestMock) ProtoMessage() {}
+
+func (x *ArtifactTestMock) ProtoReflect() protoreflect.Message {
+	mi := &file_artifact_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ArtifactTestMock.ProtoReflect.Descriptor instead.
+func (*ArtifactTestMock) Descriptor() ([]byte, []int) {
+	return file_artifact_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ArtifactTestMock) GetPlugin() string {
+	if x != nil {
+		return x.Plugin
+	}
+	return ""
+}
+
+func (x *ArtifactTestMock) GetFunction() string {
+	if x != nil {
+		return x.Function
+	}
+	return ""
+}
+
+func (x *ArtifactTestMock) GetArtifact() string {
+	if x != nil {
+		return x.Artifact
+	}
+	return ""
+}
+
+func (x *ArtifactTestMock) GetResults() string {
+	if x != nil {
+		return x.Results
+	}
+	return ""
+}
+
+func (x *ArtifactTestMock) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+type ArtifactTest struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Name        string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Description string                 `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	// Parameters passed to the artifact.
+	Parameters []*ArtifactEnv      `protobuf:"bytes,3,rep,name=parameters,proto3" json:"parameters,omitempty"`
+	Mocks      []*ArtifactTestMock `protobuf:"bytes,4,rep,name=mocks,proto3" json:"mocks,omitempty"`
+	// The source to test for multi source artifacts.
+	Source string `protobuf:"bytes,5,opt,name=source,proto3" json:"source,omitempty"`
+	// The expected rows as a JSON list. This is filled in by
+	// `artifacts test --update`.
+	Expected      string `protobuf:"bytes,6,opt,name=expected,proto3" json:"expected,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ArtifactTest) Reset() {
+	*x = ArtifactTest{}
+	mi := &file_artifact_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ArtifactTest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ArtifactTest) ProtoMessage() {}
+
+func (x *ArtifactTest) ProtoReflect() protoreflect.Message {
+	mi := &file_artifact_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ArtifactTest.ProtoReflect.Descriptor instead.
+func (*ArtifactTest) Descriptor() ([]byte, []int) {
+	return file_artifact_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ArtifactTest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ArtifactTest) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *ArtifactTest) GetParameters() []*ArtifactEnv {
+	if x != nil {
+		return x.Parameters
+	}
+	return nil
+}
+
+func (x *ArtifactTest) GetMocks() []*ArtifactTestMock {
+	if x != nil {
+		return x.Mocks
+	}
+	return nil
+}
+
+func (x *ArtifactTest) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *ArtifactTest) GetExpected() string {
+	if x != nil {
+		return x.Expected
+	}
+	return ""
+}
+
 type ArtifactDescriptors struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Items         []*Artifact            `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
@@ -785,7 +980,7 @@ type ArtifactDescriptors struct {
 
 func (x *ArtifactDescriptors) Reset() {
 	*x = ArtifactDescriptors{}
-	mi := &file_artifact_proto_msgTypes[7]
+	mi := &file_artifact_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -797,7 +992,7 @@ func (x *ArtifactDescriptors) String() string {
 func (*ArtifactDescriptors) ProtoMessage() {}
 
 func (x *ArtifactDescriptors) ProtoReflect() protoreflect.Message {
-	mi := &file_artifact_proto_msgTypes[7]
+	mi := &file_artifact_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -810,7 +1005,7 @@ func (x *ArtifactDescriptors) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ArtifactDescriptors.ProtoReflect.Descriptor instead.
 func (*ArtifactDescriptors) Descriptor() ([]byte, []int) {
-	return file_artifact_proto_rawDescGZIP(), []int{7}
+	return file_artifact_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *ArtifactDescriptors) GetItems() []*Artifact {
@@ -841,7 +1036,7 @@ type ArtifactMetadata struct {
 
 func (x *ArtifactMetadata) Reset() {
 	*x = ArtifactMetadata{}
-	mi := &file_artifact_proto_msgTypes[8]
+	mi := &file_artifact_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -853,7 +1048,7 @@ func (x *ArtifactMetadata) String() string {
 func (*ArtifactMetadata) ProtoMessage() {}
 
 func (x *ArtifactMetadata) ProtoReflect() protoreflect.Message {
-	mi := &file_artifact_proto_msgTypes[8]
+	mi := &file_artifact_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -866,7 +1061,7 @@ func (x *ArtifactMetadata) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ArtifactMetadata.ProtoReflect.Descriptor instead.
 func (*ArtifactMetadata) Descriptor() ([]byte, []int) {
-	return file_artifact_proto_rawDescGZIP(), []int{8}
+	return file_artifact_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *ArtifactMetadata) GetHidden() bool {
@@ -899,7 +1094,7 @@ type ArtifactMetadataStorage struct {
 
 func (x *ArtifactMetadataStorage) Reset() {
 	*x = ArtifactMetadataStorage{}
-	mi := &file_artifact_proto_msgTypes[9]
+	mi := &file_artifact_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -911,7 +1106,7 @@ func (x *ArtifactMetadataStorage) String() string {
 func (*ArtifactMetadataStorage) ProtoMessage() {}
 
 func (x *ArtifactMetadataStorage) ProtoReflect() protoreflect.Message {
-	mi := &file_artifact_proto_msgTypes[9]
+	mi := &file_artifact_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -924,7 +1119,7 @@ func (x *ArtifactMetadataStorage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ArtifactMetadataStorage.ProtoReflect.Descriptor instead.
 func (*ArtifactMetadataStorage) Descriptor() ([]byte, []int) {
-	return file_artifact_proto_rawDescGZIP(), []int{9}
+	return file_artifact_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *ArtifactMetadataStorage) GetMetadata() map[string]*ArtifactMetadata {
@@ -1004,7 +1199,7 @@ type Tool struct {
 
 func (x *Tool) Reset() {
 	*x = Tool{}
-	mi := &file_artifact_proto_msgTypes[10]
+	mi := &file_artifact_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1016,7 +1211,7 @@ func (x *Tool) String() string {
 func (*Tool) ProtoMessage() {}
 
 func (x *Tool) ProtoReflect() protoreflect.Message {
-	mi := &file_artifact_proto_msgTypes[10]
+	mi := &file_artifact_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1029,7 +1224,7 @@ func (x *Tool) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Tool.ProtoReflect.Descriptor instead.
 func (*Tool) Descriptor() ([]byte, []int) {
-	return file_artifact_proto_rawDescGZIP(), []int{10}
+	return file_artifact_proto_rawDescGZIP(), []int{12}
 }
 
 func (x *Tool) GetName() string {
@@ -1169,7 +1364,7 @@ type ThirdParty struct {
 
 func (x *ThirdParty) Reset() {
 	*x = ThirdParty{}
-	mi := &file_artifact_proto_msgTypes[11]
+	mi := &file_artifact_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1181,7 +1376,7 @@ func (x *ThirdParty) String() string {
 func (*ThirdParty) ProtoMessage() {}
 
 func (x *ThirdParty) ProtoReflect() protoreflect.Message {
-	mi := &file_artifact_proto_msgTypes[11]
+	mi := &file_artifact_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1194,7 +1389,7 @@ func (x *ThirdParty) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ThirdParty.ProtoReflect.Descriptor instead.
 func (*ThirdParty) Descriptor() ([]byte, []int) {
-	return file_artifact_proto_rawDescGZIP(), []int{11}
+	return file_artifact_proto_rawDescGZIP(), []int{13}
 }
 
 func (x *ThirdParty) GetTools() []*Tool {
@@ -1231,7 +1426,7 @@ type Resources struct {
 
 func (x *Resources) Reset() {
 	*x = Resources{}
-	mi := &file_artifact_proto_msgTypes[12]
+	mi := &file_artifact_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1243,7 +1438,7 @@ func (x *Resources) String() string {
 func (*Resources) ProtoMessage() {}
 
 func (x *Resources) ProtoReflect() protoreflect.Message {
-	mi := &file_artifact_proto_msgTypes[12]
+	mi := &file_artifact_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1256,7 +1451,7 @@ func (x *Resources) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Resources.ProtoReflect.Descriptor instead.
 func (*Resources) Descriptor() ([]byte, []int) {
-	return file_artifact_proto_rawDescGZIP(), []int{12}
+	return file_artifact_proto_rawDescGZIP(), []int{14}
 }
 
 func (x *Resources) GetTimeout() uint64 {
@@ -1351,14 +1546,17 @@ const file_artifact_proto_rawDesc = "" +
 	"\x04type\x18\x02 \x01(\tR\x04type\x12\x12\n" +
 	"\x04name\x18\x04 \x01(\tR\x04name\x12\x16\n" +
 	"\x06output\x18\x05 \x01(\tR\x06output\x12$\n" +
-	"\x03env\x18\x03 \x03(\v2\x12.proto.ArtifactEnvR\x03env\"\xca\x05\n" +
+	"\x03env\x18\x03 \x03(\v2\x12.proto.ArtifactEnvR\x03env\"\xc9\b\n" +
 	"\x0eArtifactSource\x12\xaf\x01\n" +
 	"\x04name\x18\x03 \x01(\tB\x9a\x01\xe2\xfc\xe3\xc4\x01\x93\x01\x12\x90\x01The name of this artifact source. If not set we use the same of the artifact itself. The artifact compiler will generate a query with this name.R\x04name\x12\x8d\x01\n" +
 	"\vdescription\x18\x04 \x01(\tBk\xe2\xfc\xe3\xc4\x01e\x12cA description string for this source. Note it can be interpolated with other artifact descriptions.R\vdescription\x12h\n" +
 	"\fprecondition\x18\x01 \x01(\tBD\xe2\xfc\xe3\xc4\x01>\x12<A VQL expression to be evaluated prior to using this source.R\fprecondition\x124\n" +
 	"\x05query\x18\x06 \x01(\tB\x1e\xe2\xfc\xe3\xc4\x01\x18\x12\x16A multi-line VQL queryR\x05query\x12t\n" +
 	"\aqueries\x18\x02 \x03(\tBZ\xe2\xfc\xe3\xc4\x01T\x12RQueries that will run in order. Only output from the last query will be collected.R\aqueries\x125\n" +
-	"\bnotebook\x18\x05 \x03(\v2\x19.proto.NotebookSourceCellR\bnotebook:)\xda\xfc\xe3\xc4\x01#\n" +
+	"\bnotebook\x18\x05 \x03(\v2\x19.proto.NotebookSourceCellR\bnotebook\x12\xbf\x01\n" +
+	"\n" +
+	"key_column\x18\a \x01(\tB\x9f\x01\xe2\xfc\xe3\xc4\x01\x98\x01\x12\x95\x01A column which uniquely identifies each row. When set, incremental collections only send rows which are new or changed since the previous collection.R\tkeyColumn\x12\xba\x01\n" +
+	"\rstack_columns\x18\b \x03(\tB\x94\x01\xe2\xfc\xe3\xc4\x01\x8d\x01\x12\x8a\x01Columns to maintain a stacking index for when this source is collected in a hunt. The index counts the distinct values across all clients.R\fstackColumns:)\xda\xfc\xe3\xc4\x01#\n" +
 	"!Where the artifact gets its data.\"\xd8\x02\n" +
 	"\x06Report\x12:\n" +
 	"\x04type\x18\x01 \x01(\tB&\xe2\xfc\xe3\xc4\x01 \x12\x1eType of report: CLIENT, SERVERR\x04type\x12\x18\n" +
@@ -1368,7 +1566,7 @@ const file_artifact_proto_rawDesc = "" +
 	"\n" +
 	"parameters\x18\x03 \x03(\v2\x18.proto.ArtifactParameterR\n" +
 	"parameters:\x8d\x01\xda\xfc\xe3\xc4\x01\x86\x01\n" +
-	"\x83\x01A report is generated from the output of the artifact collected. There can be multiple report types generated depending on context.\"\xc5\x0f\n" +
+	"\x83\x01A report is generated from the output of the artifact collected. There can be multiple report types generated depending on context.\"\xc6\x10\n" +
 	"\bArtifact\x12\xb1\x01\n" +
 	"\x04name\x18\x01 \x01(\tB\x9c\x01\xe2\xfc\xe3\xc4\x01\x95\x01\x12\x92\x01The name of the artifact. Should be unique and may contain dots. A useful scheme is to break categories with dot e.g. Linux.Browsers.ChromeHistoryR\x04name\x12\x18\n" +
 	"\aaliases\x18\x15 \x03(\tR\aaliases\x12Q\n" +
@@ -1401,8 +1599,24 @@ const file_artifact_proto_rawDesc = "" +
 	"compiledIn\x12\x19\n" +
 	"\bis_alias\x18\x16 \x01(\bR\aisAlias\x12!\n" +
 	"\fis_inherited\x18\x1a \x01(\bR\visInherited\x123\n" +
-	"\bmetadata\x18\x19 \x01(\v2\x17.proto.ArtifactMetadataR\bmetadata:\x7f\xda\xfc\xe3\xc4\x01y\n" +
-	"wAn artifact wraps a VQL query in reusable, documented way.Artifacts are all about collecting things not analyzing them.\"P\n" +
+	"\bmetadata\x18\x19 \x01(\v2\x17.proto.ArtifactMetadataR\bmetadata\x12\x7f\n" +
+	"\x05tests\x18\x1d \x03(\v2\x13.proto.ArtifactTestBT\xe2\xfc\xe3\xc4\x01N\x12LUnit tests for this artifact. These are run by the `artifacts test` command.R\x05tests:\x7f\xda\xfc\xe3\xc4\x01y\n" +
+	"wAn artifact wraps a VQL query in reusable, documented way.Artifacts are all about collecting things not analyzing them.\"\x92\x01\n" +
+	"\x10ArtifactTestMock\x12\x16\n" +
+	"\x06plugin\x18\x01 \x01(\tR\x06plugin\x12\x1a\n" +
+	"\bfunction\x18\x02 \x01(\tR\bfunction\x12\x1a\n" +
+	"\bartifact\x18\x03 \x01(\tR\bartifact\x12\x18\n" +
+	"\aresults\x18\x04 \x01(\tR\aresults\x12\x14\n" +
+	"\x05query\x18\x05 \x01(\tR\x05query\"\xdb\x01\n" +
+	"\fArtifactTest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12 \n" +
+	"\vdescription\x18\x02 \x01(\tR\vdescription\x122\n" +
+	"\n" +
+	"parameters\x18\x03 \x03(\v2\x12.proto.ArtifactEnvR\n" +
+	"parameters\x12-\n" +
+	"\x05mocks\x18\x04 \x03(\v2\x17.proto.ArtifactTestMockR\x05mocks\x12\x16\n" +
+	"\x06source\x18\x05 \x01(\tR\x06source\x12\x1a\n" +
+	"\bexpected\x18\x06 \x01(\tR\bexpected\"P\n" +
 	"\x13ArtifactDescriptors\x12%\n" +
 	"\x05items\x18\x01 \x03(\v2\x0f.proto.ArtifactR\x05items\x12\x12\n" +
 	"\x04tags\x18\x02 \x03(\tR\x04tags\"T\n" +
@@ -1464,7 +1678,7 @@ func file_artifact_proto_rawDescGZIP() []byte {
 	return file_artifact_proto_rawDescData
 }
 
-var file_artifact_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
+var file_artifact_proto_msgTypes = make([]protoimpl.MessageInfo, 16)
 var file_artifact_proto_goTypes = []any{
 	(*ArtifactEnv)(nil),             // 0: proto.ArtifactEnv
 	(*ColumnType)(nil),              // 1: proto.ColumnType
@@ -1473,35 +1687,40 @@ var file_artifact_proto_goTypes = []any{
 	(*ArtifactSource)(nil),          // 4: proto.ArtifactSource
 	(*Report)(nil),                  // 5: proto.Report
 	(*Artifact)(nil),                // 6: proto.Artifact
-	(*ArtifactDescriptors)(nil),     // 7: proto.ArtifactDescriptors
-	(*ArtifactMetadata)(nil),        // 8: proto.ArtifactMetadata
-	(*ArtifactMetadataStorage)(nil), // 9: proto.ArtifactMetadataStorage
-	(*Tool)(nil),                    // 10: proto.Tool
-	(*ThirdParty)(nil),              // 11: proto.third_party
-	(*Resources)(nil),               // 12: proto.Resources
-	nil,                             // 13: proto.ArtifactMetadataStorage.MetadataEntry
+	(*ArtifactTestMock)(nil),        // 7: proto.ArtifactTestMock
+	(*ArtifactTest)(nil),            // 8: proto.ArtifactTest
+	(*ArtifactDescriptors)(nil),     // 9: proto.ArtifactDescriptors
+	(*ArtifactMetadata)(nil),        // 10: proto.ArtifactMetadata
+	(*ArtifactMetadataStorage)(nil), // 11: proto.ArtifactMetadataStorage
+	(*Tool)(nil),                    // 12: proto.Tool
+	(*ThirdParty)(nil),              // 13: proto.third_party
+	(*Resources)(nil),               // 14: proto.Resources
+	nil,                             // 15: proto.ArtifactMetadataStorage.MetadataEntry
 }
 var file_artifact_proto_depIdxs = []int32{
 	0,  // 0: proto.NotebookSourceCell.env:type_name -> proto.ArtifactEnv
 	3,  // 1: proto.ArtifactSource.notebook:type_name -> proto.NotebookSourceCell
 	2,  // 2: proto.Report.parameters:type_name -> proto.ArtifactParameter
-	12, // 3: proto.Artifact.resources:type_name -> proto.Resources
-	10, // 4: proto.Artifact.tools:type_name -> proto.Tool
+	14, // 3: proto.Artifact.resources:type_name -> proto.Resources
+	12, // 4: proto.Artifact.tools:type_name -> proto.Tool
 	2,  // 5: proto.Artifact.parameters:type_name -> proto.ArtifactParameter
 	4,  // 6: proto.Artifact.sources:type_name -> proto.ArtifactSource
 	5,  // 7: proto.Artifact.reports:type_name -> proto.Report
 	1,  // 8: proto.Artifact.column_types:type_name -> proto.ColumnType
-	8,  // 9: proto.Artifact.metadata:type_name -> proto.ArtifactMetadata
-	6,  // 10: proto.ArtifactDescriptors.items:type_name -> proto.Artifact
-	13, // 11: proto.ArtifactMetadataStorage.metadata:type_name -> proto.ArtifactMetadataStorage.MetadataEntry
-	10, // 12: proto.Tool.versions:type_name -> proto.Tool
-	10, // 13: proto.third_party.tools:type_name -> proto.Tool
-	8,  // 14: proto.ArtifactMetadataStorage.MetadataEntry.value:type_name -> proto.ArtifactMetadata
-	15, // [15:15] is the sub-list for method output_type
-	15, // [15:15] is the sub-list for method input_type
-	15, // [15:15] is the sub-list for extension type_name
-	15, // [15:15] is the sub-list for extension extendee
-	0,  // [0:15] is the sub-list for field type_name
+	10, // 9: proto.Artifact.metadata:type_name -> proto.ArtifactMetadata
+	8,  // 10: proto.Artifact.tests:type_name -> proto.ArtifactTest
+	0,  // 11: proto.ArtifactTest.parameters:type_name -> proto.ArtifactEnv
+	7,  // 12: proto.ArtifactTest.mocks:type_name -> proto.ArtifactTestMock
+	6,  // 13: proto.ArtifactDescriptors.items:type_name -> proto.Artifact
+	15, // 14: proto.ArtifactMetadataStorage.metadata:type_name -> proto.ArtifactMetadataStorage.MetadataEntry
+	12, // 15: proto.Tool.versions:type_name -> proto.Tool
+	12, // 16: proto.third_party.tools:type_name -> proto.Tool
+	10, // 17: proto.ArtifactMetadataStorage.MetadataEntry.value:type_name -> proto.ArtifactMetadata
+	18, // [18:18] is the sub-list for method output_type
+	18, // [18:18] is the sub-list for method input_type
+	18, // [18:18] is the sub-list for extension type_name
+	18, // [18:18] is the sub-list for extension extendee
+	0,  // [0:18] is the sub-list for field type_name
 }
 
 func init() { file_artifact_proto_init() }
@@ -1515,7 +1734,7 @@ func file_artifact_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_artifact_proto_rawDesc), len(file_artifact_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   14,
+			NumMessages:   16,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/Velocidex/ordereddict"
+	logging "www.velocidex.com/golang/velociraptor/logging"
+	"www.velocidex.com/golang/velociraptor/services"
+	"www.velocidex.com/golang/velociraptor/startup"
+	"www.velocidex.com/golang/velociraptor/vql/acl_managers"
+	"www.velocidex.com/golang/velociraptor/vql/tools/artifact_tests"
+	"www.velocidex.com/golang/vfilter"
+)
+
+var (
+	artifact_test_cmd = artifact_command.Command(
+		"test", "Run the tests defined in the tests section of artifacts")
+	artifact_test_cmd_args   = artifact_test_cmd.Arg("paths", "Paths to artifact yaml files. This can also be a glob. If the path is a directory we recursively search it for `.yaml` files.").Required().Strings()
+	artifact_test_cmd_update = artifact_test_cmd.Flag(
+		"update", "Record the actual rows as the expected rows of failing tests").Bool()
+	artifact_test_cmd_names = artifact_test_cmd.Flag(
+		"test", "Only run tests with these names").Strings()
+	artifact_test_cmd_format = artifact_test_cmd.Flag("format", "Output format").
+					Default("text").Enum("text", "json", "jsonl")
+	artifact_test_cmd_max_length = artifact_test_cmd.Flag(
+		"max_length", "Maximum length of artifact to read").Default("100000").Int64()
+)
+
+func doArtifactTest() error {
+	logging.DisableLogging()
+
+	config_obj, err := makeDefaultConfigLoader().
+		WithNullLoader().LoadAndValidate()
+	if err != nil {
+		return fmt.Errorf("Unable to create config: %w", err)
+	}
+
+	config_obj.Services = services.GenericToolServices()
+
+	ctx, cancel := Install_sig_handler()
+	defer cancel()
+
+	sm, err := startup.StartToolServices(ctx, config_obj)
+	if err != nil {
+		return err
+	}
+	defer sm.Close()
+
+	manager, err := services.GetRepositoryManager(config_obj)
+	if err != nil {
+		return err
+	}
+
+	logger := logging.GetLogger(config_obj, &logging.ToolComponent)
+
+	var artifact_paths []string
+	for _, artifact_path := range expandGlobs(*artifact_test_cmd_args) {
+		abs, err := filepath.Abs(artifact_path)
+		if err != nil {
+			logger.Error("test: could not get absolute path for %v", artifact_path)
+			continue
+		}
+
+		artifact_paths = append(artifact_paths, abs)
+	}
+
+	test_names := []string{}
+	if artifact_test_cmd_names != nil {
+		test_names = append(test_names, *artifact_test_cmd_names...)
+	}
+
+	artifact_logger := &LogWriter{config_obj: sm.Config}
+	builder := services.ScopeBuilder{
+		Config:     sm.Config,
+		ACLManager: acl_managers.NewRoleACLManager(sm.Config, "administrator"),
+		Logger:     log.New(artifact_logger, "", 0),
+		Env: ordereddict.NewDict().
+			Set("Artifacts", artifact_paths).
+			Set("Tests", test_names).
+			Set("MaxLength", *artifact_test_cmd_max_length),
+	}
+
+	// All artifacts are loaded into a local repository first so
+	// tested artifacts may call each other.
+	query := `
+        LET Globs = SELECT if(condition=stat(filename=_value).IsDir,
+                              then=_value + "/**/*.yaml",
+                              else=_value) AS Glob
+          FROM foreach(row=Artifacts)
+
+        LET Definitions <= SELECT
+            OSPath.String AS Filename,
+            read_file(filename=OSPath, length=MaxLength) AS Data,
+            artifact_set(definition=read_file(filename=OSPath, length=MaxLength),
+                         repository="local") AS Definition
+        FROM glob(globs=Globs.Glob)
+        WHERE NOT IsDir
+
+        SELECT * FROM foreach(row=Definitions, query={
+            SELECT Filename, *
+            FROM artifact_test(artifact=Data, repository="local", tests=Tests)
+        })
+	`
+
+	scope := manager.BuildScope(builder)
+	defer scope.Close()
+
+	statements, err := vfilter.MultiParse(query)
+	if err != nil {
+		return err
+	}
+
+	// Actual rows to record, by filename and test name.
+	updates := make(map[string]map[string]string)
+
+	reporter := newTestReporter("artifact", *artifact_test_cmd_format)
+	for _, vql := range statements {
+		for row := range vql.Eval(sm.Ctx, scope) {
+			dict := vfilter.RowToDict(sm.Ctx, scope, row)
+			if reporter.Report(dict, describeArtifactTest) {
+				continue
+			}
+
+			// Only record tests which ran without errors.
+			diff, _ := dict.GetString("Diff")
+			error_message, _ := dict.GetString("Error")
+			if diff == "" && error_message != artifact_tests.NO_EXPECTED_ROWS {
+				continue
+			}
+
+			filename, _ := dict.GetString("Filename")
+			name, _ := dict.GetString("Name")
+			actual, _ := dict.GetString("Actual")
+
+			file_updates, pres := updates[filename]
+			if !pres {
+				file_updates = make(map[string]string)
+				updates[filename] = file_updates
+			}
+			file_updates[name] = actual
+		}
+	}
+
+	reporter.PrintSummary()
+
+	if *artifact_test_cmd_update {
+		return updateArtifactTests(updates)
+	}
+
+	err = reporter.Error()
+	if err != nil {
+		return err
+	}
+
+	return artifact_logger.Error
+}
+
+func updateArtifactTests(updates map[string]map[string]string) error {
+	var filenames []string
+	for filename := range updates {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+
+	for _, filename := range filenames {
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			return err
+		}
+
+		updated, err := artifact_tests.UpdateExpectations(
+			string(data), updates[filename])
+		if err != nil {
+			return fmt.Errorf("Updating %v: %w", filename, err)
+		}
+
+		err = os.WriteFile(filename, []byte(updated), 0644)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Updated %v tests in %v\n",
+			len(updates[filename]), filename)
+	}
+
+	return nil
+}
+
+func describeArtifactTest(row *ordereddict.Dict) string {
+	artifact, _ := row.GetString("Artifact")
+	name, _ := row.GetString("Name")
+	rows, _ := row.GetInt64("Rows")
+	return fmt.Sprintf("%v: %v: %v rows", artifact, name, rows)
+}
+
+func init() {
+	command_handlers = append(command_handlers, func(command string) bool {
+		switch command {
+		case artifact_test_cmd.FullCommand():
+			FatalIfError(artifact_test_cmd, doArtifactTest)
+
+		default:
+			return false
+		}
+		return true
+	})
+}
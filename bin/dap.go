@@ -0,0 +1,110 @@
+/*
+Velociraptor - Dig Deeper
+Copyright (C) 2019-2025 Rapid7 Inc.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/Velocidex/ordereddict"
+	"www.velocidex.com/golang/velociraptor/logging"
+	"www.velocidex.com/golang/velociraptor/services"
+	"www.velocidex.com/golang/velociraptor/startup"
+	"www.velocidex.com/golang/velociraptor/vql/acl_managers"
+	"www.velocidex.com/golang/velociraptor/vql/debugger"
+	"www.velocidex.com/golang/vfilter"
+)
+
+var (
+	dap_cmd = app.Command("dap",
+		"Run a VQL debug adapter (DAP over stdio) for IDEs.")
+)
+
+func init() {
+	command_handlers = append(command_handlers, func(command string) bool {
+		switch command {
+		case dap_cmd.FullCommand():
+			FatalIfError(dap_cmd, doDAP)
+		default:
+			return false
+		}
+		return true
+	})
+}
+
+func doDAP() error {
+	logging.DisableLogging()
+
+	config_obj, err := APIConfigLoader.WithNullLoader().
+		LoadAndValidate()
+	if err != nil {
+		return err
+	}
+
+	config_obj.Services = services.GenericToolServices()
+
+	ctx, cancel := Install_sig_handler()
+	defer cancel()
+
+	sm, err := startup.StartToolServices(ctx, config_obj)
+	if err != nil {
+		return err
+	}
+	defer sm.Close()
+
+	// Initialize the repository in case the artifacts use it
+	_, err = getRepository(config_obj)
+	if err != nil {
+		return fmt.Errorf("Artifact GetGlobalRepository: %w ", err)
+	}
+
+	manager, err := services.GetRepositoryManager(config_obj)
+	if err != nil {
+		return err
+	}
+
+	// Each debug session runs the program in a fresh scope.
+	factory := func(ctx context.Context, logger *log.Logger) (
+		vfilter.Scope, error) {
+		builder := services.ScopeBuilder{
+			Config:     config_obj,
+			ACLManager: acl_managers.NullACLManager{},
+			Logger:     logger,
+			Env:        ordereddict.NewDict(),
+		}
+
+		if *run_as != "" {
+			builder.ACLManager = acl_managers.NewServerACLManager(
+				config_obj, *run_as)
+		}
+
+		return manager.BuildScope(builder), nil
+	}
+
+	// The DAP protocol runs over stdio so nothing else may write to
+	// stdout.
+	stdio := stdioConn{
+		reader: os.Stdin,
+		writer: os.Stdout,
+	}
+	defer stdio.Close()
+
+	return debugger.NewDAPServer(ctx, factory).Serve(stdio)
+}
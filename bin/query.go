@@ -43,6 +43,8 @@ import (
 	"www.velocidex.com/golang/velociraptor/utils"
 	vql_subsystem "www.velocidex.com/golang/velociraptor/vql"
 	"www.velocidex.com/golang/velociraptor/vql/acl_managers"
+	"www.velocidex.com/golang/velociraptor/vql/debugger"
+	vql_profiler "www.velocidex.com/golang/velociraptor/vql/profiler"
 	"www.velocidex.com/golang/vfilter"
 )
 
@@ -85,6 +87,17 @@ var (
 
 	do_not_update = query.Flag("do_not_update_scope_file",
 		"Do not update the scope file with the new scope").Bool()
+
+	query_debugger = query.Flag("debugger",
+		"Run the query under the interactive debugger").Bool()
+
+	query_breakpoints = query.Flag("break",
+		"Stop the debugger at this line, plugin or LET name "+
+			"(optionally followed by 'if <condition>')").Strings()
+
+	query_profile = query.Flag("profile_vql",
+		"Print the time spent in each plugin, function and LET "+
+			"to stderr when the query is done").Bool()
 )
 
 func outputJSON(ctx context.Context,
@@ -433,28 +446,88 @@ func doQuery() error {
 	if *trace_vql_flag {
 		scope.SetTracer(log.New(os.Stderr, "VQL Trace: ", 0))
 	}
+
+	if *query_profile {
+		scope.AppendVars(ordereddict.NewDict().
+			Set(constants.VQL_PROFILE, true))
+	}
+
+	profiler := vql_profiler.MaybeInstall(scope, "Query")
+	if profiler != nil {
+		defer func() {
+			for _, row := range profiler.Rows() {
+				fmt.Fprintln(os.Stderr, vql_profiler.FormatRow(row))
+			}
+			profiler.Close()
+		}()
+	}
+
+	// The debugger reads commands from stdin and writes to stderr so
+	// it does not interfere with the query output.
+	query_ctx := ctx
+	var vql_debugger *debugger.Debugger
+	if *query_debugger || len(*query_breakpoints) > 0 {
+		vql_debugger = debugger.NewDebugger(ctx,
+			debugger.NewConsoleFrontend(os.Stdin, os.Stderr))
+		for _, spec := range *query_breakpoints {
+			bp, err := debugger.ParseBreakpoint(spec)
+			if err != nil {
+				return err
+			}
+			err = vql_debugger.AddBreakpoint(bp)
+			if err != nil {
+				return err
+			}
+		}
+
+		if *query_debugger {
+			vql_debugger.StopOnEntry()
+		}
+
+		vql_debugger.Install(scope)
+		query_ctx = vql_debugger.Context()
+
+		defer func() {
+			for _, item := range vql_debugger.Stats().Get() {
+				fmt.Fprintln(os.Stderr, item.String())
+			}
+		}()
+	}
+
 	for _, query := range vql_queries {
 		statements, err := vfilter.MultiParse(query)
 		kingpin.FatalIfError(err, "Unable to parse VQL Query")
 
 		for _, vql := range statements {
+			if vql_debugger != nil {
+				vql_debugger.BeforeStatement(scope, vql)
+			}
+
+			// LET statements produce no output but the profiler
+			// needs to see them to time the LET queries.
+			if profiler != nil && vql.Let != "" {
+				for range profiler.Eval(query_ctx, scope, vql) {
+				}
+				continue
+			}
+
 			switch *format {
 			case "text":
-				table := reporting.EvalQueryToTable(ctx, scope, vql, out_fd)
+				table := reporting.EvalQueryToTable(query_ctx, scope, vql, out_fd)
 				table.Render()
 			case "json":
-				err = outputJSON(ctx, scope, vql, out_fd)
+				err = outputJSON(query_ctx, scope, vql, out_fd)
 				if err != nil {
 					return err
 				}
 
 			case "jsonl":
-				err = outputJSONL(ctx, scope, vql, out_fd)
+				err = outputJSONL(query_ctx, scope, vql, out_fd)
 				if err != nil {
 					return err
 				}
 			case "csv":
-				err = outputCSV(ctx, builder.Config, scope, vql, out_fd)
+				err = outputCSV(query_ctx, builder.Config, scope, vql, out_fd)
 				if err != nil {
 					return err
 				}
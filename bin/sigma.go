@@ -0,0 +1,210 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/Velocidex/ordereddict"
+	logging "www.velocidex.com/golang/velociraptor/logging"
+	"www.velocidex.com/golang/velociraptor/services"
+	"www.velocidex.com/golang/velociraptor/startup"
+	"www.velocidex.com/golang/velociraptor/utils"
+	"www.velocidex.com/golang/velociraptor/vql/acl_managers"
+	"www.velocidex.com/golang/velociraptor/vql/sigma"
+	"www.velocidex.com/golang/vfilter"
+)
+
+var (
+	sigma_cmd = app.Command("sigma", "Work with Sigma rules")
+
+	sigma_test_cmd = sigma_cmd.Command("test",
+		"Test Sigma rules against positive and negative sample events")
+
+	sigma_test_cmd_format = sigma_test_cmd.Flag("format", "Output format").
+				Default("text").Enum("text", "json", "jsonl")
+
+	sigma_test_cmd_fixtures = sigma_test_cmd.Arg("fixtures",
+		"Test fixture YAML files").Required().ExistingFiles()
+
+	sigma_compile_cmd = sigma_cmd.Command("compile",
+		"Compile Sigma rules into a client event artifact")
+
+	sigma_compile_cmd_output = sigma_compile_cmd.Flag("output",
+		"Write the artifact to this file (default stdout)").String()
+
+	sigma_compile_cmd_spec = sigma_compile_cmd.Arg("spec",
+		"Compile spec YAML file").Required().ExistingFile()
+)
+
+func doSigmaTest() error {
+	logging.DisableLogging()
+
+	config_obj, err := makeDefaultConfigLoader().
+		WithNullLoader().LoadAndValidate()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := Install_sig_handler()
+	defer cancel()
+
+	config_obj.Services = services.GenericToolServices()
+	sm, err := startup.StartToolServices(ctx, config_obj)
+	if err != nil {
+		return err
+	}
+	defer sm.Close()
+
+	vql, err := vfilter.Parse(`
+SELECT * FROM sigma_test(rules=rules, filters=filters,
+   field_mapping=field_mapping, log_sources=log_sources, tests=tests)`)
+	if err != nil {
+		return err
+	}
+
+	reporter := newTestReporter("sigma", *sigma_test_cmd_format)
+	for _, fixture := range *sigma_test_cmd_fixtures {
+		env, err := sigma.LoadSigmaTestFixture(fixture)
+		if err != nil {
+			return err
+		}
+
+		logger := &LogWriter{config_obj: config_obj}
+		builder := services.ScopeBuilder{
+			Config:     config_obj,
+			ACLManager: acl_managers.NewRoleACLManager(config_obj, "administrator"),
+			Logger:     log.New(logger, "", 0),
+			Env:        env,
+		}
+
+		manager, err := services.GetRepositoryManager(config_obj)
+		if err != nil {
+			return err
+		}
+
+		scope := manager.BuildScope(builder)
+
+		describe := func(row *ordereddict.Dict) string {
+			return describeSigmaTest(fixture, row)
+		}
+
+		for row := range vql.Eval(ctx, scope) {
+			reporter.Report(vfilter.RowToDict(ctx, scope, row), describe)
+		}
+		scope.Close()
+
+		if logger.Error != nil {
+			return logger.Error
+		}
+	}
+
+	reporter.PrintSummary()
+
+	return reporter.Error()
+}
+
+func describeSigmaTest(fixture string, row *ordereddict.Dict) string {
+	get := func(field string) interface{} {
+		value, _ := row.Get(field)
+		return value
+	}
+
+	result := fmt.Sprintf("%v: %v (%v): %v/%v events hit",
+		fixture, get("Name"), get("Kind"), get("Hits"), get("Events"))
+
+	searches, pres := row.Get("Searches")
+	if pres {
+		searches := utils.ConvertToStringSlice(searches)
+		if len(searches) > 0 {
+			result += fmt.Sprintf(" %v", searches)
+		}
+	}
+	return result
+}
+
+func doSigmaCompile() error {
+	logging.DisableLogging()
+
+	config_obj, err := makeDefaultConfigLoader().
+		WithNullLoader().LoadAndValidate()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := Install_sig_handler()
+	defer cancel()
+
+	config_obj.Services = services.GenericToolServices()
+	sm, err := startup.StartToolServices(ctx, config_obj)
+	if err != nil {
+		return err
+	}
+	defer sm.Close()
+
+	env, err := sigma.LoadSigmaCompileSpec(*sigma_compile_cmd_spec)
+	if err != nil {
+		return err
+	}
+
+	vql, err := vfilter.Parse(`
+SELECT sigma_compile(rules=rules, filters=filters,
+   field_mapping=field_mapping, log_sources=log_sources,
+   name=name, description=description) AS Artifact
+FROM scope()`)
+	if err != nil {
+		return err
+	}
+
+	logger := &LogWriter{config_obj: config_obj}
+	builder := services.ScopeBuilder{
+		Config:     config_obj,
+		ACLManager: acl_managers.NullACLManager{},
+		Logger:     log.New(logger, "", 0),
+		Env:        env,
+	}
+
+	manager, err := services.GetRepositoryManager(config_obj)
+	if err != nil {
+		return err
+	}
+
+	scope := manager.BuildScope(builder)
+	defer scope.Close()
+
+	for row := range vql.Eval(ctx, scope) {
+		artifact, _ := scope.Associative(row, "Artifact")
+		artifact_str, ok := artifact.(string)
+		if !ok {
+			return fmt.Errorf("Unable to compile %v", *sigma_compile_cmd_spec)
+		}
+
+		if *sigma_compile_cmd_output == "" {
+			fmt.Print(artifact_str)
+			continue
+		}
+
+		err := os.WriteFile(*sigma_compile_cmd_output, []byte(artifact_str), 0644)
+		if err != nil {
+			return err
+		}
+	}
+
+	return logger.Error
+}
+
+func init() {
+	command_handlers = append(command_handlers, func(command string) bool {
+		switch command {
+		case sigma_test_cmd.FullCommand():
+			FatalIfError(sigma_test_cmd, doSigmaTest)
+
+		case sigma_compile_cmd.FullCommand():
+			FatalIfError(sigma_compile_cmd, doSigmaCompile)
+
+		default:
+			return false
+		}
+		return true
+	})
+}
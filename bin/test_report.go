@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Velocidex/ordereddict"
+	"www.velocidex.com/golang/velociraptor/json"
+)
+
+// Reports the result rows of the test commands. Each row has a
+// Passed column and optionally Error and Diff columns.
+type testReporter struct {
+	// The kind of tests for the summary (e.g. "artifact")
+	kind string
+
+	// One of text, json or jsonl
+	format string
+
+	total  int
+	failed int
+}
+
+func newTestReporter(kind, format string) *testReporter {
+	return &testReporter{
+		kind:   kind,
+		format: format,
+	}
+}
+
+// Print the result row. In text format describe() provides the line
+// for the test. Returns true if the test passed.
+func (self *testReporter) Report(row *ordereddict.Dict,
+	describe func(row *ordereddict.Dict) string) bool {
+	self.total++
+
+	passed, _ := row.Get("Passed")
+	if passed != true {
+		self.failed++
+	}
+
+	switch self.format {
+	case "json":
+		fmt.Println(string(json.MustMarshalIndent(row)))
+
+	case "jsonl":
+		fmt.Println(json.MustMarshalString(row))
+
+	default:
+		status := "PASS"
+		if passed != true {
+			status = "FAIL"
+		}
+
+		fmt.Printf("%v %v", status, describe(row))
+
+		error_message, _ := row.GetString("Error")
+		if error_message != "" {
+			fmt.Printf(" Error: %v", error_message)
+		}
+		fmt.Println()
+
+		diff, _ := row.GetString("Diff")
+		if diff != "" {
+			for _, line := range strings.Split(diff, "\n") {
+				fmt.Printf("    %v\n", line)
+			}
+		}
+	}
+
+	return passed == true
+}
+
+func (self *testReporter) PrintSummary() {
+	if self.format == "text" {
+		fmt.Printf("\n%v tests, %v failed\n", self.total, self.failed)
+	}
+}
+
+func (self *testReporter) Error() error {
+	if self.failed > 0 {
+		return fmt.Errorf("%v of %v %v tests failed",
+			self.failed, self.total, self.kind)
+	}
+	return nil
+}
@@ -35,6 +35,10 @@ var (
 	unzip_cmd_print    = unzip_cmd.Flag("print", "Dump out the files in the zip").Short('p').Bool()
 	unzip_cmd_password = unzip_cmd.Flag("password", "Use this password to extract ZIP").String()
 
+	unzip_cmd_private_keys = unzip_cmd.Flag("private_key",
+		"A PEM file with a private key to open collections encrypted to multiple recipients (can be repeated)").
+		ExistingFiles()
+
 	unzip_cmd_file = unzip_cmd.Arg("file", "Zip file to parse").Required().String()
 
 	unzip_cmd_member = unzip_cmd.Arg("members", "Members glob to extract").Default("/**").String()
@@ -72,6 +76,15 @@ func doUnzip() error {
 		return err
 	}
 
+	private_keys := make([]string, 0, len(*unzip_cmd_private_keys))
+	for _, key_path := range *unzip_cmd_private_keys {
+		data, err := os.ReadFile(key_path)
+		if err != nil {
+			return fmt.Errorf("Reading private key %v: %w", key_path, err)
+		}
+		private_keys = append(private_keys, string(data))
+	}
+
 	logger := &LogWriter{config_obj: sm.Config}
 	builder := services.ScopeBuilder{
 		Config:     sm.Config,
@@ -82,7 +95,8 @@ func doUnzip() error {
 			Set("DumpDir", *unzip_path).
 			Set("MemberGlob", *unzip_cmd_member).
 			Set(constants.ZIP_PASSWORDS, *unzip_cmd_password).
-			Set(constants.REPORT_ZIP_PASSWORD, *unzip_cmd_report_password),
+			Set(constants.REPORT_ZIP_PASSWORD, *unzip_cmd_report_password).
+			Set(constants.COLLECTION_PRIVATE_KEYS, private_keys),
 	}
 
 	if *unzip_cmd_list {
@@ -61,6 +61,11 @@ var (
 		"username", "Username to show").Required().String()
 	user_show_hashes = user_show.Flag("with_hashes", "Displays the password hashes too.").
 				Bool()
+
+	user_mfa_reset = user_command.Command("mfa_reset",
+		"Reset a user's second factor so they must enroll again.")
+	user_mfa_reset_name = user_mfa_reset.Arg(
+		"username", "Username to reset").Required().String()
 )
 
 func doAddUser() error {
@@ -186,6 +191,42 @@ func doShowUser() error {
 	return nil
 }
 
+func doResetUserMFA() error {
+	logging.DisableLogging()
+
+	config_obj, err := makeDefaultConfigLoader().
+		WithRequiredFrontend().
+		WithRequiredUser().LoadAndValidate()
+	if err != nil {
+		return fmt.Errorf("Unable to load config file: %w", err)
+	}
+
+	config_obj.Services = services.GenericToolServices()
+
+	ctx, cancel := Install_sig_handler()
+	defer cancel()
+
+	sm, err := startup.StartToolServices(ctx, config_obj)
+	if err != nil {
+		return fmt.Errorf("Starting services: %w", err)
+	}
+	defer sm.Close()
+
+	err = sm.Start(users.StartUserManager)
+	if err != nil {
+		return err
+	}
+
+	users_manager := services.GetUserManager()
+	err = users_manager.SetUserMFA(ctx, utils.GetSuperuserName(config_obj),
+		*user_mfa_reset_name, nil)
+	if err != nil {
+		return fmt.Errorf("Unable to reset second factor: %w", err)
+	}
+	fmt.Println(ServerChangeWarning)
+	return nil
+}
+
 func init() {
 	command_handlers = append(command_handlers, func(command string) bool {
 		switch command {
@@ -195,6 +236,9 @@ func init() {
 		case user_show.FullCommand():
 			FatalIfError(user_show, doShowUser)
 
+		case user_mfa_reset.FullCommand():
+			FatalIfError(user_mfa_reset, doResetUserMFA)
+
 		default:
 			return false
 		}
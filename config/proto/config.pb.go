@@ -642,10 +642,9 @@ type ClientConfig struct {
 	// include the port after a colon (see net.Dial).
 	//
 	// Example (yaml):
-	//
-	//	Client:
-	//	  fallback_addresses:
-	//	    "my-velociraptor-server.com:443": "123.123.123.123:443"
+	//  Client:
+	//    fallback_addresses:
+	//      "my-velociraptor-server.com:443": "123.123.123.123:443"
 	//
 	// This makes that if https://my-velociraptor-server.com is not
 	// reachable (for example if DNS is not available due to a network
@@ -1049,11 +1048,15 @@ func (x *ClientConfig) GetLogging() *LoggingConfig {
 type APIConfig struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	// Publicly accessible hostname.
-	Hostname      string `protobuf:"bytes,5,opt,name=hostname,proto3" json:"hostname,omitempty"`
-	BindAddress   string `protobuf:"bytes,1,opt,name=bind_address,json=bindAddress,proto3" json:"bind_address,omitempty"`
-	BindPort      uint32 `protobuf:"varint,2,opt,name=bind_port,json=bindPort,proto3" json:"bind_port,omitempty"`
-	BindScheme    string `protobuf:"bytes,3,opt,name=bind_scheme,json=bindScheme,proto3" json:"bind_scheme,omitempty"`
-	PinnedGwName  string `protobuf:"bytes,4,opt,name=pinned_gw_name,json=pinnedGwName,proto3" json:"pinned_gw_name,omitempty"`
+	Hostname     string          `protobuf:"bytes,5,opt,name=hostname,proto3" json:"hostname,omitempty"`
+	BindAddress  string          `protobuf:"bytes,1,opt,name=bind_address,json=bindAddress,proto3" json:"bind_address,omitempty"`
+	BindPort     uint32          `protobuf:"varint,2,opt,name=bind_port,json=bindPort,proto3" json:"bind_port,omitempty"`
+	BindScheme   string          `protobuf:"bytes,3,opt,name=bind_scheme,json=bindScheme,proto3" json:"bind_scheme,omitempty"`
+	PinnedGwName string          `protobuf:"bytes,4,opt,name=pinned_gw_name,json=pinnedGwName,proto3" json:"pinned_gw_name,omitempty"`
+	Tokens       *APITokenConfig `protobuf:"bytes,6,opt,name=tokens,proto3" json:"tokens,omitempty"`
+	// Token bucket limits on API calls. Each principal has its own
+	// bucket for each limit. Without limits calls are not limited.
+	RateLimits    []*APIRateLimit `protobuf:"bytes,7,rep,name=rate_limits,json=rateLimits,proto3" json:"rate_limits,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -1123,6 +1126,174 @@ func (x *APIConfig) GetPinnedGwName() string {
 	return ""
 }
 
+func (x *APIConfig) GetTokens() *APITokenConfig {
+	if x != nil {
+		return x.Tokens
+	}
+	return nil
+}
+
+func (x *APIConfig) GetRateLimits() []*APIRateLimit {
+	if x != nil {
+		return x.RateLimits
+	}
+	return nil
+}
+
+// The most specific limit applies to a call: limits for the
+// principal are preferred over limits for everyone, and limits for
+// the method over limits for its class.
+type APIRateLimit struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The user or API client this applies to. API tokens also match
+	// the user that owns them. Empty applies to all principals.
+	Principal string `protobuf:"bytes,1,opt,name=principal,proto3" json:"principal,omitempty"`
+	// A class of calls (query, read, write, download) or the name of
+	// an API method (e.g. GetTable). Empty applies to all calls.
+	Class string `protobuf:"bytes,2,opt,name=class,proto3" json:"class,omitempty"`
+	// Sustained calls per second. 0 means unlimited.
+	Rate float64 `protobuf:"fixed64,3,opt,name=rate,proto3" json:"rate,omitempty"`
+	// The largest burst of calls allowed (default the rate rounded
+	// up).
+	Burst         uint64 `protobuf:"varint,4,opt,name=burst,proto3" json:"burst,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *APIRateLimit) Reset() {
+	*x = APIRateLimit{}
+	mi := &file_config_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *APIRateLimit) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*APIRateLimit) ProtoMessage() {}
+
+func (x *APIRateLimit) ProtoReflect() protoreflect.Message {
+	mi := &file_config_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use APIRateLimit.ProtoReflect.Descriptor instead.
+func (*APIRateLimit) Descriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *APIRateLimit) GetPrincipal() string {
+	if x != nil {
+		return x.Principal
+	}
+	return ""
+}
+
+func (x *APIRateLimit) GetClass() string {
+	if x != nil {
+		return x.Class
+	}
+	return ""
+}
+
+func (x *APIRateLimit) GetRate() float64 {
+	if x != nil {
+		return x.Rate
+	}
+	return 0
+}
+
+func (x *APIRateLimit) GetBurst() uint64 {
+	if x != nil {
+		return x.Burst
+	}
+	return 0
+}
+
+// Users can mint bearer tokens for programmatic API access.
+type APITokenConfig struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Do not accept API tokens.
+	Disabled bool `protobuf:"varint,1,opt,name=disabled,proto3" json:"disabled,omitempty"`
+	// By default tokens are only accepted by the GUI's API gateway
+	// (as an Authorization: Bearer header). When set, the gRPC API
+	// also accepts tokens in the "authorization" metadata. This
+	// allows clients to connect without a client certificate.
+	AllowGrpc bool `protobuf:"varint,2,opt,name=allow_grpc,json=allowGrpc,proto3" json:"allow_grpc,omitempty"`
+	// Expiry of new tokens when not specified (default 30 days).
+	DefaultExpiryDays uint64 `protobuf:"varint,3,opt,name=default_expiry_days,json=defaultExpiryDays,proto3" json:"default_expiry_days,omitempty"`
+	// The longest allowed expiry (default 365 days).
+	MaxExpiryDays uint64 `protobuf:"varint,4,opt,name=max_expiry_days,json=maxExpiryDays,proto3" json:"max_expiry_days,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *APITokenConfig) Reset() {
+	*x = APITokenConfig{}
+	mi := &file_config_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *APITokenConfig) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*APITokenConfig) ProtoMessage() {}
+
+func (x *APITokenConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_config_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use APITokenConfig.ProtoReflect.Descriptor instead.
+func (*APITokenConfig) Descriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *APITokenConfig) GetDisabled() bool {
+	if x != nil {
+		return x.Disabled
+	}
+	return false
+}
+
+func (x *APITokenConfig) GetAllowGrpc() bool {
+	if x != nil {
+		return x.AllowGrpc
+	}
+	return false
+}
+
+func (x *APITokenConfig) GetDefaultExpiryDays() uint64 {
+	if x != nil {
+		return x.DefaultExpiryDays
+	}
+	return 0
+}
+
+func (x *APITokenConfig) GetMaxExpiryDays() uint64 {
+	if x != nil {
+		return x.MaxExpiryDays
+	}
+	return 0
+}
+
 // Configuration to be consumed by api clients.
 type ApiClientConfig struct {
 	state               protoimpl.MessageState `protogen:"open.v1"`
@@ -1140,7 +1311,7 @@ type ApiClientConfig struct {
 
 func (x *ApiClientConfig) Reset() {
 	*x = ApiClientConfig{}
-	mi := &file_config_proto_msgTypes[9]
+	mi := &file_config_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1152,7 +1323,7 @@ func (x *ApiClientConfig) String() string {
 func (*ApiClientConfig) ProtoMessage() {}
 
 func (x *ApiClientConfig) ProtoReflect() protoreflect.Message {
-	mi := &file_config_proto_msgTypes[9]
+	mi := &file_config_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1165,7 +1336,7 @@ func (x *ApiClientConfig) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ApiClientConfig.ProtoReflect.Descriptor instead.
 func (*ApiClientConfig) Descriptor() ([]byte, []int) {
-	return file_config_proto_rawDescGZIP(), []int{9}
+	return file_config_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *ApiClientConfig) GetCaCertificate() string {
@@ -1217,6 +1388,118 @@ func (x *ApiClientConfig) GetMaxGrpcRecvSize() uint64 {
 	return 0
 }
 
+// A regional server can forward selected server events and flow
+// completion summaries to a central Velociraptor server. Events are
+// pushed with the central server's PushEvents API, so the API client
+// needs the PUBLISH permission on each forwarded artifact there.
+type FederationConfig struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// An API client config issued by the central server (from
+	// `velociraptor config api_client`).
+	Central *ApiClientConfig `protobuf:"bytes,1,opt,name=central,proto3" json:"central,omitempty"`
+	// The name of this deployment, added to forwarded rows as
+	// _Region (default Frontend.hostname).
+	Name string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	// Server event artifacts to forward. The central server must
+	// have artifacts with the same names.
+	Artifacts []string `protobuf:"bytes,3,rep,name=artifacts,proto3" json:"artifacts,omitempty"`
+	// Forward a summary of each completed collection to the
+	// Server.Federation.FlowCompletion artifact.
+	ForwardFlowCompletions bool `protobuf:"varint,4,opt,name=forward_flow_completions,json=forwardFlowCompletions,proto3" json:"forward_flow_completions,omitempty"`
+	// The org on the central server to forward the root org's
+	// events into (default the central root org).
+	OrgId string `protobuf:"bytes,5,opt,name=org_id,json=orgId,proto3" json:"org_id,omitempty"`
+	// Only the root org is forwarded by default. Other orgs are
+	// forwarded when they are mapped here from the local org id to
+	// the org on the central server.
+	OrgMapping map[string]string `protobuf:"bytes,7,rep,name=org_mapping,json=orgMapping,proto3" json:"org_mapping,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Events are buffered on disk while the central server is
+	// unreachable. Once the buffer reaches this size (default 100mb)
+	// new events are dropped.
+	MaxBufferSize uint64 `protobuf:"varint,6,opt,name=max_buffer_size,json=maxBufferSize,proto3" json:"max_buffer_size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FederationConfig) Reset() {
+	*x = FederationConfig{}
+	mi := &file_config_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FederationConfig) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FederationConfig) ProtoMessage() {}
+
+func (x *FederationConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_config_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FederationConfig.ProtoReflect.Descriptor instead.
+func (*FederationConfig) Descriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *FederationConfig) GetCentral() *ApiClientConfig {
+	if x != nil {
+		return x.Central
+	}
+	return nil
+}
+
+func (x *FederationConfig) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *FederationConfig) GetArtifacts() []string {
+	if x != nil {
+		return x.Artifacts
+	}
+	return nil
+}
+
+func (x *FederationConfig) GetForwardFlowCompletions() bool {
+	if x != nil {
+		return x.ForwardFlowCompletions
+	}
+	return false
+}
+
+func (x *FederationConfig) GetOrgId() string {
+	if x != nil {
+		return x.OrgId
+	}
+	return ""
+}
+
+func (x *FederationConfig) GetOrgMapping() map[string]string {
+	if x != nil {
+		return x.OrgMapping
+	}
+	return nil
+}
+
+func (x *FederationConfig) GetMaxBufferSize() uint64 {
+	if x != nil {
+		return x.MaxBufferSize
+	}
+	return 0
+}
+
 // Fine grained configuration of proxies for http connections.
 type ProxyConfig struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
@@ -1225,8 +1508,7 @@ type ProxyConfig struct {
 	// A list of url regexp to match the url and connect to the
 	// target. Use and empty string to denote direct connection.  For
 	// example: {"^https://localhost/": "",
-	//
-	//	"^https://www.example.com/": "http://localhost:3182"}
+	//           "^https://www.example.com/": "http://localhost:3182"}
 	ProxyUrlRegexp map[string]string `protobuf:"bytes,3,rep,name=proxy_url_regexp,json=proxyUrlRegexp,proto3" json:"proxy_url_regexp,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
 	// Location of a PAC file (overrides the above settings).
 	Pac string `protobuf:"bytes,4,opt,name=pac,proto3" json:"pac,omitempty"`
@@ -1239,7 +1521,7 @@ type ProxyConfig struct {
 
 func (x *ProxyConfig) Reset() {
 	*x = ProxyConfig{}
-	mi := &file_config_proto_msgTypes[10]
+	mi := &file_config_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1251,7 +1533,7 @@ func (x *ProxyConfig) String() string {
 func (*ProxyConfig) ProtoMessage() {}
 
 func (x *ProxyConfig) ProtoReflect() protoreflect.Message {
-	mi := &file_config_proto_msgTypes[10]
+	mi := &file_config_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1264,7 +1546,7 @@ func (x *ProxyConfig) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ProxyConfig.ProtoReflect.Descriptor instead.
 func (*ProxyConfig) Descriptor() ([]byte, []int) {
-	return file_config_proto_rawDescGZIP(), []int{10}
+	return file_config_proto_rawDescGZIP(), []int{13}
 }
 
 func (x *ProxyConfig) GetHttps() string {
@@ -1324,7 +1606,7 @@ type GUILink struct {
 
 func (x *GUILink) Reset() {
 	*x = GUILink{}
-	mi := &file_config_proto_msgTypes[11]
+	mi := &file_config_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1336,7 +1618,7 @@ func (x *GUILink) String() string {
 func (*GUILink) ProtoMessage() {}
 
 func (x *GUILink) ProtoReflect() protoreflect.Message {
-	mi := &file_config_proto_msgTypes[11]
+	mi := &file_config_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1349,7 +1631,7 @@ func (x *GUILink) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GUILink.ProtoReflect.Descriptor instead.
 func (*GUILink) Descriptor() ([]byte, []int) {
-	return file_config_proto_rawDescGZIP(), []int{11}
+	return file_config_proto_rawDescGZIP(), []int{14}
 }
 
 func (x *GUILink) GetText() string {
@@ -1425,7 +1707,7 @@ type OIDCACL struct {
 
 func (x *OIDCACL) Reset() {
 	*x = OIDCACL{}
-	mi := &file_config_proto_msgTypes[12]
+	mi := &file_config_proto_msgTypes[15]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1437,7 +1719,7 @@ func (x *OIDCACL) String() string {
 func (*OIDCACL) ProtoMessage() {}
 
 func (x *OIDCACL) ProtoReflect() protoreflect.Message {
-	mi := &file_config_proto_msgTypes[12]
+	mi := &file_config_proto_msgTypes[15]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1450,7 +1732,7 @@ func (x *OIDCACL) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use OIDCACL.ProtoReflect.Descriptor instead.
 func (*OIDCACL) Descriptor() ([]byte, []int) {
-	return file_config_proto_rawDescGZIP(), []int{12}
+	return file_config_proto_rawDescGZIP(), []int{15}
 }
 
 func (x *OIDCACL) GetRoles() []string {
@@ -1482,10 +1764,9 @@ type OIDCClaims struct {
 	// A mapping between OIDC claim roles and Velociraptor roles.
 	// For example:
 	// role_map:
-	//
-	//	Velociraptor.Reader:
-	//	  roles:
-	//	    - reader
+	//    Velociraptor.Reader:
+	//      roles:
+	//        - reader
 	RoleMap map[string]*OIDCACL `protobuf:"bytes,3,rep,name=role_map,json=roleMap,proto3" json:"role_map,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
 	// Velociraptor usually requires the email_verified claim before
 	// we can trust the email claim and use it as the
@@ -1503,7 +1784,7 @@ type OIDCClaims struct {
 
 func (x *OIDCClaims) Reset() {
 	*x = OIDCClaims{}
-	mi := &file_config_proto_msgTypes[13]
+	mi := &file_config_proto_msgTypes[16]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1515,7 +1796,7 @@ func (x *OIDCClaims) String() string {
 func (*OIDCClaims) ProtoMessage() {}
 
 func (x *OIDCClaims) ProtoReflect() protoreflect.Message {
-	mi := &file_config_proto_msgTypes[13]
+	mi := &file_config_proto_msgTypes[16]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1528,42 +1809,432 @@ func (x *OIDCClaims) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use OIDCClaims.ProtoReflect.Descriptor instead.
 func (*OIDCClaims) Descriptor() ([]byte, []int) {
-	return file_config_proto_rawDescGZIP(), []int{13}
+	return file_config_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *OIDCClaims) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *OIDCClaims) GetRoles() string {
+	if x != nil {
+		return x.Roles
+	}
+	return ""
+}
+
+func (x *OIDCClaims) GetRoleMap() map[string]*OIDCACL {
+	if x != nil {
+		return x.RoleMap
+	}
+	return nil
+}
+
+func (x *OIDCClaims) GetAllowUnverifiedEmail() bool {
+	if x != nil {
+		return x.AllowUnverifiedEmail
+	}
+	return false
+}
+
+func (x *OIDCClaims) GetOverrideAcls() bool {
+	if x != nil {
+		return x.OverrideAcls
+	}
+	return false
+}
+
+type LDAPGroupACL struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Roles       []string               `protobuf:"bytes,1,rep,name=roles,proto3" json:"roles,omitempty"`
+	Permissions []string               `protobuf:"bytes,2,rep,name=permissions,proto3" json:"permissions,omitempty"`
+	// The orgs the roles are granted in. If not set, the roles are
+	// granted in all orgs.
+	Orgs          []string `protobuf:"bytes,3,rep,name=orgs,proto3" json:"orgs,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LDAPGroupACL) Reset() {
+	*x = LDAPGroupACL{}
+	mi := &file_config_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LDAPGroupACL) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LDAPGroupACL) ProtoMessage() {}
+
+func (x *LDAPGroupACL) ProtoReflect() protoreflect.Message {
+	mi := &file_config_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LDAPGroupACL.ProtoReflect.Descriptor instead.
+func (*LDAPGroupACL) Descriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *LDAPGroupACL) GetRoles() []string {
+	if x != nil {
+		return x.Roles
+	}
+	return nil
+}
+
+func (x *LDAPGroupACL) GetPermissions() []string {
+	if x != nil {
+		return x.Permissions
+	}
+	return nil
+}
+
+func (x *LDAPGroupACL) GetOrgs() []string {
+	if x != nil {
+		return x.Orgs
+	}
+	return nil
+}
+
+type LDAPConfig struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The server to connect to, e.g. ldaps://dc.example.com:636 or
+	// ldap://dc.example.com:389
+	Url string `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	// Upgrade a plain ldap:// connection using StartTLS.
+	StartTls bool `protobuf:"varint,2,opt,name=start_tls,json=startTls,proto3" json:"start_tls,omitempty"`
+	// A PEM encoded CA bundle to verify the server certificate. If
+	// not set, the system roots are used.
+	RootCa             string `protobuf:"bytes,3,opt,name=root_ca,json=rootCa,proto3" json:"root_ca,omitempty"`
+	InsecureSkipVerify bool   `protobuf:"varint,4,opt,name=insecure_skip_verify,json=insecureSkipVerify,proto3" json:"insecure_skip_verify,omitempty"`
+	// A service account used to search for the user's DN. If not set
+	// we bind directly as the user using user_dn_template.
+	BindDn       string `protobuf:"bytes,5,opt,name=bind_dn,json=bindDn,proto3" json:"bind_dn,omitempty"`
+	BindPassword string `protobuf:"bytes,6,opt,name=bind_password,json=bindPassword,proto3" json:"bind_password,omitempty"`
+	// Used when there is no service account, e.g.
+	// "CN=%s,OU=Users,DC=example,DC=com" or "%s@example.com" for
+	// Active Directory UPN binds.
+	UserDnTemplate string `protobuf:"bytes,7,opt,name=user_dn_template,json=userDnTemplate,proto3" json:"user_dn_template,omitempty"`
+	// Where to search for users. The user_filter is formatted with the
+	// escaped username (default "(sAMAccountName=%s)").
+	BaseDn     string `protobuf:"bytes,8,opt,name=base_dn,json=baseDn,proto3" json:"base_dn,omitempty"`
+	UserFilter string `protobuf:"bytes,9,opt,name=user_filter,json=userFilter,proto3" json:"user_filter,omitempty"`
+	// The attribute of the user entry holding the Velociraptor
+	// username. If not set, the login name is used.
+	UsernameAttribute string `protobuf:"bytes,10,opt,name=username_attribute,json=usernameAttribute,proto3" json:"username_attribute,omitempty"`
+	// Where to search for groups. The group_filter is formatted with
+	// the escaped member DN (default "(member=%s)"). Nested groups are
+	// resolved up to max_nesting_depth levels (default 10).
+	GroupBaseDn     string `protobuf:"bytes,11,opt,name=group_base_dn,json=groupBaseDn,proto3" json:"group_base_dn,omitempty"`
+	GroupFilter     string `protobuf:"bytes,12,opt,name=group_filter,json=groupFilter,proto3" json:"group_filter,omitempty"`
+	MaxNestingDepth uint64 `protobuf:"varint,13,opt,name=max_nesting_depth,json=maxNestingDepth,proto3" json:"max_nesting_depth,omitempty"`
+	// A mapping between LDAP groups and Velociraptor roles. Groups
+	// may be given by their DN or CN. For example:
+	// role_map:
+	//    "CN=DFIR,OU=Groups,DC=example,DC=com":
+	//      roles:
+	//        - investigator
+	//      orgs:
+	//        - root
+	RoleMap map[string]*LDAPGroupACL `protobuf:"bytes,14,rep,name=role_map,json=roleMap,proto3" json:"role_map,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// When this is set, the roles from the groups override (clear)
+	// existing velociraptor roles. The default behavior is to ensure
+	// the user's ACL contains at least the mapped roles.
+	OverrideAcls bool `protobuf:"varint,15,opt,name=override_acls,json=overrideAcls,proto3" json:"override_acls,omitempty"`
+	// Successful logins are cached for this long to avoid querying
+	// the server on every request (default 300 seconds).
+	CacheExpirySec uint64 `protobuf:"varint,16,opt,name=cache_expiry_sec,json=cacheExpirySec,proto3" json:"cache_expiry_sec,omitempty"`
+	// Network timeout for LDAP operations (default 10 seconds).
+	TimeoutSec    uint64 `protobuf:"varint,17,opt,name=timeout_sec,json=timeoutSec,proto3" json:"timeout_sec,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LDAPConfig) Reset() {
+	*x = LDAPConfig{}
+	mi := &file_config_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LDAPConfig) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LDAPConfig) ProtoMessage() {}
+
+func (x *LDAPConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_config_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LDAPConfig.ProtoReflect.Descriptor instead.
+func (*LDAPConfig) Descriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *LDAPConfig) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *LDAPConfig) GetStartTls() bool {
+	if x != nil {
+		return x.StartTls
+	}
+	return false
+}
+
+func (x *LDAPConfig) GetRootCa() string {
+	if x != nil {
+		return x.RootCa
+	}
+	return ""
+}
+
+func (x *LDAPConfig) GetInsecureSkipVerify() bool {
+	if x != nil {
+		return x.InsecureSkipVerify
+	}
+	return false
+}
+
+func (x *LDAPConfig) GetBindDn() string {
+	if x != nil {
+		return x.BindDn
+	}
+	return ""
+}
+
+func (x *LDAPConfig) GetBindPassword() string {
+	if x != nil {
+		return x.BindPassword
+	}
+	return ""
+}
+
+func (x *LDAPConfig) GetUserDnTemplate() string {
+	if x != nil {
+		return x.UserDnTemplate
+	}
+	return ""
+}
+
+func (x *LDAPConfig) GetBaseDn() string {
+	if x != nil {
+		return x.BaseDn
+	}
+	return ""
+}
+
+func (x *LDAPConfig) GetUserFilter() string {
+	if x != nil {
+		return x.UserFilter
+	}
+	return ""
+}
+
+func (x *LDAPConfig) GetUsernameAttribute() string {
+	if x != nil {
+		return x.UsernameAttribute
+	}
+	return ""
+}
+
+func (x *LDAPConfig) GetGroupBaseDn() string {
+	if x != nil {
+		return x.GroupBaseDn
+	}
+	return ""
+}
+
+func (x *LDAPConfig) GetGroupFilter() string {
+	if x != nil {
+		return x.GroupFilter
+	}
+	return ""
+}
+
+func (x *LDAPConfig) GetMaxNestingDepth() uint64 {
+	if x != nil {
+		return x.MaxNestingDepth
+	}
+	return 0
+}
+
+func (x *LDAPConfig) GetRoleMap() map[string]*LDAPGroupACL {
+	if x != nil {
+		return x.RoleMap
+	}
+	return nil
+}
+
+func (x *LDAPConfig) GetOverrideAcls() bool {
+	if x != nil {
+		return x.OverrideAcls
+	}
+	return false
+}
+
+func (x *LDAPConfig) GetCacheExpirySec() uint64 {
+	if x != nil {
+		return x.CacheExpirySec
+	}
+	return 0
+}
+
+func (x *LDAPConfig) GetTimeoutSec() uint64 {
+	if x != nil {
+		return x.TimeoutSec
+	}
+	return 0
+}
+
+type BasicMFAConfig struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// If set, users must enroll a second factor before they can use
+	// the GUI. Otherwise only enrolled users are asked for one.
+	Required bool `protobuf:"varint,1,opt,name=required,proto3" json:"required,omitempty"`
+	// Shown in authenticator apps and on the security key prompt
+	// (default "Velociraptor").
+	Issuer string `protobuf:"bytes,2,opt,name=issuer,proto3" json:"issuer,omitempty"`
+	// The WebAuthn relying party ID and allowed origins. By default
+	// these are derived from the GUI public url.
+	RpId      string   `protobuf:"bytes,3,opt,name=rp_id,json=rpId,proto3" json:"rp_id,omitempty"`
+	RpOrigins []string `protobuf:"bytes,4,rep,name=rp_origins,json=rpOrigins,proto3" json:"rp_origins,omitempty"`
+	// How long a second factor login lasts (default
+	// default_session_expiry_min or 1 day).
+	SessionExpiryMin uint64 `protobuf:"varint,5,opt,name=session_expiry_min,json=sessionExpiryMin,proto3" json:"session_expiry_min,omitempty"`
+	// Sensitive actions require the second factor to have been
+	// presented within this many seconds (default 300).
+	ReauthMaxAgeSec uint64 `protobuf:"varint,6,opt,name=reauth_max_age_sec,json=reauthMaxAgeSec,proto3" json:"reauth_max_age_sec,omitempty"`
+	// API paths considered sensitive. If not set, a default list
+	// covering collections, hunts, users, artifacts, notebooks, API
+	// tokens and GUI sessions is used.
+	SensitivePaths []string `protobuf:"bytes,7,rep,name=sensitive_paths,json=sensitivePaths,proto3" json:"sensitive_paths,omitempty"`
+	// Users are locked out of TOTP verification after this many
+	// consecutive invalid codes (default 5) for totp_lockout_sec
+	// (default 300). Each further lockout doubles in length up to a
+	// day.
+	MaxTotpAttempts uint64 `protobuf:"varint,8,opt,name=max_totp_attempts,json=maxTotpAttempts,proto3" json:"max_totp_attempts,omitempty"`
+	TotpLockoutSec  uint64 `protobuf:"varint,9,opt,name=totp_lockout_sec,json=totpLockoutSec,proto3" json:"totp_lockout_sec,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *BasicMFAConfig) Reset() {
+	*x = BasicMFAConfig{}
+	mi := &file_config_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BasicMFAConfig) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BasicMFAConfig) ProtoMessage() {}
+
+func (x *BasicMFAConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_config_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BasicMFAConfig.ProtoReflect.Descriptor instead.
+func (*BasicMFAConfig) Descriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *BasicMFAConfig) GetRequired() bool {
+	if x != nil {
+		return x.Required
+	}
+	return false
+}
+
+func (x *BasicMFAConfig) GetIssuer() string {
+	if x != nil {
+		return x.Issuer
+	}
+	return ""
+}
+
+func (x *BasicMFAConfig) GetRpId() string {
+	if x != nil {
+		return x.RpId
+	}
+	return ""
+}
+
+func (x *BasicMFAConfig) GetRpOrigins() []string {
+	if x != nil {
+		return x.RpOrigins
+	}
+	return nil
 }
 
-func (x *OIDCClaims) GetUsername() string {
+func (x *BasicMFAConfig) GetSessionExpiryMin() uint64 {
 	if x != nil {
-		return x.Username
+		return x.SessionExpiryMin
 	}
-	return ""
+	return 0
 }
 
-func (x *OIDCClaims) GetRoles() string {
+func (x *BasicMFAConfig) GetReauthMaxAgeSec() uint64 {
 	if x != nil {
-		return x.Roles
+		return x.ReauthMaxAgeSec
 	}
-	return ""
+	return 0
 }
 
-func (x *OIDCClaims) GetRoleMap() map[string]*OIDCACL {
+func (x *BasicMFAConfig) GetSensitivePaths() []string {
 	if x != nil {
-		return x.RoleMap
+		return x.SensitivePaths
 	}
 	return nil
 }
 
-func (x *OIDCClaims) GetAllowUnverifiedEmail() bool {
+func (x *BasicMFAConfig) GetMaxTotpAttempts() uint64 {
 	if x != nil {
-		return x.AllowUnverifiedEmail
+		return x.MaxTotpAttempts
 	}
-	return false
+	return 0
 }
 
-func (x *OIDCClaims) GetOverrideAcls() bool {
+func (x *BasicMFAConfig) GetTotpLockoutSec() uint64 {
 	if x != nil {
-		return x.OverrideAcls
+		return x.TotpLockoutSec
 	}
-	return false
+	return 0
 }
 
 type Authenticator struct {
@@ -1601,6 +2272,10 @@ type Authenticator struct {
 	SamlUserAttribute     string   `protobuf:"bytes,16,opt,name=saml_user_attribute,json=samlUserAttribute,proto3" json:"saml_user_attribute,omitempty"`
 	SamlUserRoles         []string `protobuf:"bytes,23,rep,name=saml_user_roles,json=samlUserRoles,proto3" json:"saml_user_roles,omitempty"`
 	SamlAllowIdpInitiated bool     `protobuf:"varint,27,opt,name=saml_allow_idp_initiated,json=samlAllowIdpInitiated,proto3" json:"saml_allow_idp_initiated,omitempty"`
+	// LDAP / Active Directory Authenticator
+	Ldap *LDAPConfig `protobuf:"bytes,29,opt,name=ldap,proto3" json:"ldap,omitempty"`
+	// Second factor for the basic authenticator.
+	Mfa *BasicMFAConfig `protobuf:"bytes,30,opt,name=mfa,proto3" json:"mfa,omitempty"`
 	// MultiAuthenticator delegates to multiple other authenticators.
 	SubAuthenticators    []*Authenticator `protobuf:"bytes,17,rep,name=sub_authenticators,json=subAuthenticators,proto3" json:"sub_authenticators,omitempty"`
 	AuthRedirectTemplate string           `protobuf:"bytes,21,opt,name=auth_redirect_template,json=authRedirectTemplate,proto3" json:"auth_redirect_template,omitempty"`
@@ -1617,7 +2292,7 @@ type Authenticator struct {
 
 func (x *Authenticator) Reset() {
 	*x = Authenticator{}
-	mi := &file_config_proto_msgTypes[14]
+	mi := &file_config_proto_msgTypes[20]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1629,7 +2304,7 @@ func (x *Authenticator) String() string {
 func (*Authenticator) ProtoMessage() {}
 
 func (x *Authenticator) ProtoReflect() protoreflect.Message {
-	mi := &file_config_proto_msgTypes[14]
+	mi := &file_config_proto_msgTypes[20]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1642,7 +2317,7 @@ func (x *Authenticator) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Authenticator.ProtoReflect.Descriptor instead.
 func (*Authenticator) Descriptor() ([]byte, []int) {
-	return file_config_proto_rawDescGZIP(), []int{14}
+	return file_config_proto_rawDescGZIP(), []int{20}
 }
 
 func (x *Authenticator) GetType() string {
@@ -1771,6 +2446,20 @@ func (x *Authenticator) GetSamlAllowIdpInitiated() bool {
 	return false
 }
 
+func (x *Authenticator) GetLdap() *LDAPConfig {
+	if x != nil {
+		return x.Ldap
+	}
+	return nil
+}
+
+func (x *Authenticator) GetMfa() *BasicMFAConfig {
+	if x != nil {
+		return x.Mfa
+	}
+	return nil
+}
+
 func (x *Authenticator) GetSubAuthenticators() []*Authenticator {
 	if x != nil {
 		return x.SubAuthenticators
@@ -1842,13 +2531,15 @@ type GUIConfig struct {
 	// token. Use this only when serving the JS from a different
 	// domain than the API server.
 	TrustedOrigins []string `protobuf:"bytes,25,rep,name=trusted_origins,json=trustedOrigins,proto3" json:"trusted_origins,omitempty"`
-	unknownFields  protoimpl.UnknownFields
-	sizeCache      protoimpl.SizeCache
+	// Allow an identity provider to provision users.
+	Scim          *SCIMConfig `protobuf:"bytes,26,opt,name=scim,proto3" json:"scim,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *GUIConfig) Reset() {
 	*x = GUIConfig{}
-	mi := &file_config_proto_msgTypes[15]
+	mi := &file_config_proto_msgTypes[21]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1860,7 +2551,7 @@ func (x *GUIConfig) String() string {
 func (*GUIConfig) ProtoMessage() {}
 
 func (x *GUIConfig) ProtoReflect() protoreflect.Message {
-	mi := &file_config_proto_msgTypes[15]
+	mi := &file_config_proto_msgTypes[21]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1873,7 +2564,7 @@ func (x *GUIConfig) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GUIConfig.ProtoReflect.Descriptor instead.
 func (*GUIConfig) Descriptor() ([]byte, []int) {
-	return file_config_proto_rawDescGZIP(), []int{15}
+	return file_config_proto_rawDescGZIP(), []int{21}
 }
 
 func (x *GUIConfig) GetBindAddress() string {
@@ -1988,6 +2679,135 @@ func (x *GUIConfig) GetTrustedOrigins() []string {
 	return nil
 }
 
+func (x *GUIConfig) GetScim() *SCIMConfig {
+	if x != nil {
+		return x.Scim
+	}
+	return nil
+}
+
+// Serve a SCIM 2.0 endpoint under /scim/v2/ on the GUI listener. The
+// identity provider authenticates with an API token which must carry
+// ORG_ADMIN in the root org.
+type SCIMConfig struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Enabled bool                   `protobuf:"varint,1,opt,name=enabled,proto3" json:"enabled,omitempty"`
+	// SCIM groups grant roles. Only groups listed here can be
+	// provisioned.
+	Groups        []*SCIMGroupMapping `protobuf:"bytes,2,rep,name=groups,proto3" json:"groups,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SCIMConfig) Reset() {
+	*x = SCIMConfig{}
+	mi := &file_config_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SCIMConfig) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SCIMConfig) ProtoMessage() {}
+
+func (x *SCIMConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_config_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SCIMConfig.ProtoReflect.Descriptor instead.
+func (*SCIMConfig) Descriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *SCIMConfig) GetEnabled() bool {
+	if x != nil {
+		return x.Enabled
+	}
+	return false
+}
+
+func (x *SCIMConfig) GetGroups() []*SCIMGroupMapping {
+	if x != nil {
+		return x.Groups
+	}
+	return nil
+}
+
+type SCIMGroupMapping struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The group's displayName in the identity provider.
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// Roles granted to members of the group.
+	Roles []string `protobuf:"bytes,2,rep,name=roles,proto3" json:"roles,omitempty"`
+	// The orgs the roles apply in (default the root org). Roles in
+	// these orgs are managed by SCIM and are replaced whenever the
+	// user's group membership changes.
+	Orgs          []string `protobuf:"bytes,3,rep,name=orgs,proto3" json:"orgs,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SCIMGroupMapping) Reset() {
+	*x = SCIMGroupMapping{}
+	mi := &file_config_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SCIMGroupMapping) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SCIMGroupMapping) ProtoMessage() {}
+
+func (x *SCIMGroupMapping) ProtoReflect() protoreflect.Message {
+	mi := &file_config_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SCIMGroupMapping.ProtoReflect.Descriptor instead.
+func (*SCIMGroupMapping) Descriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *SCIMGroupMapping) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *SCIMGroupMapping) GetRoles() []string {
+	if x != nil {
+		return x.Roles
+	}
+	return nil
+}
+
+func (x *SCIMGroupMapping) GetOrgs() []string {
+	if x != nil {
+		return x.Orgs
+	}
+	return nil
+}
+
 type GUIUser struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
@@ -1999,7 +2819,7 @@ type GUIUser struct {
 
 func (x *GUIUser) Reset() {
 	*x = GUIUser{}
-	mi := &file_config_proto_msgTypes[16]
+	mi := &file_config_proto_msgTypes[24]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2011,7 +2831,7 @@ func (x *GUIUser) String() string {
 func (*GUIUser) ProtoMessage() {}
 
 func (x *GUIUser) ProtoReflect() protoreflect.Message {
-	mi := &file_config_proto_msgTypes[16]
+	mi := &file_config_proto_msgTypes[24]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2024,7 +2844,7 @@ func (x *GUIUser) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GUIUser.ProtoReflect.Descriptor instead.
 func (*GUIUser) Descriptor() ([]byte, []int) {
-	return file_config_proto_rawDescGZIP(), []int{16}
+	return file_config_proto_rawDescGZIP(), []int{24}
 }
 
 func (x *GUIUser) GetName() string {
@@ -2057,7 +2877,7 @@ type CAConfig struct {
 
 func (x *CAConfig) Reset() {
 	*x = CAConfig{}
-	mi := &file_config_proto_msgTypes[17]
+	mi := &file_config_proto_msgTypes[25]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2069,7 +2889,7 @@ func (x *CAConfig) String() string {
 func (*CAConfig) ProtoMessage() {}
 
 func (x *CAConfig) ProtoReflect() protoreflect.Message {
-	mi := &file_config_proto_msgTypes[17]
+	mi := &file_config_proto_msgTypes[25]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2082,7 +2902,7 @@ func (x *CAConfig) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CAConfig.ProtoReflect.Descriptor instead.
 func (*CAConfig) Descriptor() ([]byte, []int) {
-	return file_config_proto_rawDescGZIP(), []int{17}
+	return file_config_proto_rawDescGZIP(), []int{25}
 }
 
 func (x *CAConfig) GetPrivateKey() string {
@@ -2103,7 +2923,7 @@ type ReverseProxyConfig struct {
 
 func (x *ReverseProxyConfig) Reset() {
 	*x = ReverseProxyConfig{}
-	mi := &file_config_proto_msgTypes[18]
+	mi := &file_config_proto_msgTypes[26]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2115,7 +2935,7 @@ func (x *ReverseProxyConfig) String() string {
 func (*ReverseProxyConfig) ProtoMessage() {}
 
 func (x *ReverseProxyConfig) ProtoReflect() protoreflect.Message {
-	mi := &file_config_proto_msgTypes[18]
+	mi := &file_config_proto_msgTypes[26]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2128,7 +2948,7 @@ func (x *ReverseProxyConfig) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ReverseProxyConfig.ProtoReflect.Descriptor instead.
 func (*ReverseProxyConfig) Descriptor() ([]byte, []int) {
-	return file_config_proto_rawDescGZIP(), []int{18}
+	return file_config_proto_rawDescGZIP(), []int{26}
 }
 
 func (x *ReverseProxyConfig) GetRoute() string {
@@ -2182,7 +3002,7 @@ type DynDNSConfig struct {
 
 func (x *DynDNSConfig) Reset() {
 	*x = DynDNSConfig{}
-	mi := &file_config_proto_msgTypes[19]
+	mi := &file_config_proto_msgTypes[27]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2194,7 +3014,7 @@ func (x *DynDNSConfig) String() string {
 func (*DynDNSConfig) ProtoMessage() {}
 
 func (x *DynDNSConfig) ProtoReflect() protoreflect.Message {
-	mi := &file_config_proto_msgTypes[19]
+	mi := &file_config_proto_msgTypes[27]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2207,7 +3027,7 @@ func (x *DynDNSConfig) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DynDNSConfig.ProtoReflect.Descriptor instead.
 func (*DynDNSConfig) Descriptor() ([]byte, []int) {
-	return file_config_proto_rawDescGZIP(), []int{19}
+	return file_config_proto_rawDescGZIP(), []int{27}
 }
 
 func (x *DynDNSConfig) GetType() string {
@@ -2364,7 +3184,7 @@ type FrontendResourceControl struct {
 
 func (x *FrontendResourceControl) Reset() {
 	*x = FrontendResourceControl{}
-	mi := &file_config_proto_msgTypes[20]
+	mi := &file_config_proto_msgTypes[28]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2376,7 +3196,7 @@ func (x *FrontendResourceControl) String() string {
 func (*FrontendResourceControl) ProtoMessage() {}
 
 func (x *FrontendResourceControl) ProtoReflect() protoreflect.Message {
-	mi := &file_config_proto_msgTypes[20]
+	mi := &file_config_proto_msgTypes[28]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2389,7 +3209,7 @@ func (x *FrontendResourceControl) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use FrontendResourceControl.ProtoReflect.Descriptor instead.
 func (*FrontendResourceControl) Descriptor() ([]byte, []int) {
-	return file_config_proto_rawDescGZIP(), []int{20}
+	return file_config_proto_rawDescGZIP(), []int{28}
 }
 
 func (x *FrontendResourceControl) GetConnectionsPerSecond() uint64 {
@@ -2613,7 +3433,7 @@ type FrontendConfig struct {
 
 func (x *FrontendConfig) Reset() {
 	*x = FrontendConfig{}
-	mi := &file_config_proto_msgTypes[21]
+	mi := &file_config_proto_msgTypes[29]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2625,7 +3445,7 @@ func (x *FrontendConfig) String() string {
 func (*FrontendConfig) ProtoMessage() {}
 
 func (x *FrontendConfig) ProtoReflect() protoreflect.Message {
-	mi := &file_config_proto_msgTypes[21]
+	mi := &file_config_proto_msgTypes[29]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2638,7 +3458,7 @@ func (x *FrontendConfig) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use FrontendConfig.ProtoReflect.Descriptor instead.
 func (*FrontendConfig) Descriptor() ([]byte, []int) {
-	return file_config_proto_rawDescGZIP(), []int{21}
+	return file_config_proto_rawDescGZIP(), []int{29}
 }
 
 func (x *FrontendConfig) GetHostname() string {
@@ -2874,22 +3694,22 @@ func (x *FrontendConfig) GetDoNotRedirect() bool {
 
 type DatastoreConfig struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
-	//  4. FileBasedWithRPC - Large files are written to disk (File
-	//     store) but small files are accessed via RPC to a local
-	//     memcache server. This configuration is suitable for the
-	//     Minion node on a slow EFS backed filesystem. All data store
-	//     access will go through to the master memcache using gRPC.
+	// 4. FileBasedWithRPC - Large files are written to disk (File
+	//    store) but small files are accessed via RPC to a local
+	//    memcache server. This configuration is suitable for the
+	//    Minion node on a slow EFS backed filesystem. All data store
+	//    access will go through to the master memcache using gRPC.
 	Implementation string `protobuf:"bytes,1,opt,name=implementation,proto3" json:"implementation,omitempty"`
 	// For FileBaseDataStore
 	Location           string `protobuf:"bytes,2,opt,name=location,proto3" json:"location,omitempty"`
 	FilestoreDirectory string `protobuf:"bytes,3,opt,name=filestore_directory,json=filestoreDirectory,proto3" json:"filestore_directory,omitempty"`
 	// Allowed settings:
-	//   - none: No compression - disable compression in client
-	//     transmission. Note that for older clients (prior to 0.75),
-	//     compression is not supported anyway, but this setting will
-	//     disable compression on new clients as well.
-	//   - zlib: Zlib compression enabled on collections. This is the
-	//     default setting when communicating with newer clients.
+	// - none: No compression - disable compression in client
+	//   transmission. Note that for older clients (prior to 0.75),
+	//   compression is not supported anyway, but this setting will
+	//   disable compression on new clients as well.
+	// - zlib: Zlib compression enabled on collections. This is the
+	//   default setting when communicating with newer clients.
 	Compression string `protobuf:"bytes,19,opt,name=compression,proto3" json:"compression,omitempty"`
 	// Set to the min required disk space. When we fall below this
 	// available disk space, we refuse to write files. This avoids the
@@ -2947,7 +3767,7 @@ type DatastoreConfig struct {
 
 func (x *DatastoreConfig) Reset() {
 	*x = DatastoreConfig{}
-	mi := &file_config_proto_msgTypes[22]
+	mi := &file_config_proto_msgTypes[30]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2959,7 +3779,7 @@ func (x *DatastoreConfig) String() string {
 func (*DatastoreConfig) ProtoMessage() {}
 
 func (x *DatastoreConfig) ProtoReflect() protoreflect.Message {
-	mi := &file_config_proto_msgTypes[22]
+	mi := &file_config_proto_msgTypes[30]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2972,7 +3792,7 @@ func (x *DatastoreConfig) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DatastoreConfig.ProtoReflect.Descriptor instead.
 func (*DatastoreConfig) Descriptor() ([]byte, []int) {
-	return file_config_proto_rawDescGZIP(), []int{22}
+	return file_config_proto_rawDescGZIP(), []int{30}
 }
 
 func (x *DatastoreConfig) GetImplementation() string {
@@ -3128,7 +3948,7 @@ type MinionConfig struct {
 
 func (x *MinionConfig) Reset() {
 	*x = MinionConfig{}
-	mi := &file_config_proto_msgTypes[23]
+	mi := &file_config_proto_msgTypes[31]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3140,7 +3960,7 @@ func (x *MinionConfig) String() string {
 func (*MinionConfig) ProtoMessage() {}
 
 func (x *MinionConfig) ProtoReflect() protoreflect.Message {
-	mi := &file_config_proto_msgTypes[23]
+	mi := &file_config_proto_msgTypes[31]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3153,7 +3973,7 @@ func (x *MinionConfig) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use MinionConfig.ProtoReflect.Descriptor instead.
 func (*MinionConfig) Descriptor() ([]byte, []int) {
-	return file_config_proto_rawDescGZIP(), []int{23}
+	return file_config_proto_rawDescGZIP(), []int{31}
 }
 
 func (x *MinionConfig) GetNotebookNumberOfLocalWorkers() int64 {
@@ -3185,7 +4005,7 @@ type MailConfig struct {
 
 func (x *MailConfig) Reset() {
 	*x = MailConfig{}
-	mi := &file_config_proto_msgTypes[24]
+	mi := &file_config_proto_msgTypes[32]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3197,7 +4017,7 @@ func (x *MailConfig) String() string {
 func (*MailConfig) ProtoMessage() {}
 
 func (x *MailConfig) ProtoReflect() protoreflect.Message {
-	mi := &file_config_proto_msgTypes[24]
+	mi := &file_config_proto_msgTypes[32]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3210,7 +4030,7 @@ func (x *MailConfig) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use MailConfig.ProtoReflect.Descriptor instead.
 func (*MailConfig) Descriptor() ([]byte, []int) {
-	return file_config_proto_rawDescGZIP(), []int{24}
+	return file_config_proto_rawDescGZIP(), []int{32}
 }
 
 func (x *MailConfig) GetFrom() string {
@@ -3269,7 +4089,7 @@ type LoggingRetentionConfig struct {
 
 func (x *LoggingRetentionConfig) Reset() {
 	*x = LoggingRetentionConfig{}
-	mi := &file_config_proto_msgTypes[25]
+	mi := &file_config_proto_msgTypes[33]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3281,7 +4101,7 @@ func (x *LoggingRetentionConfig) String() string {
 func (*LoggingRetentionConfig) ProtoMessage() {}
 
 func (x *LoggingRetentionConfig) ProtoReflect() protoreflect.Message {
-	mi := &file_config_proto_msgTypes[25]
+	mi := &file_config_proto_msgTypes[33]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3294,7 +4114,7 @@ func (x *LoggingRetentionConfig) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use LoggingRetentionConfig.ProtoReflect.Descriptor instead.
 func (*LoggingRetentionConfig) Descriptor() ([]byte, []int) {
-	return file_config_proto_rawDescGZIP(), []int{25}
+	return file_config_proto_rawDescGZIP(), []int{33}
 }
 
 func (x *LoggingRetentionConfig) GetRotationTime() uint64 {
@@ -3336,7 +4156,7 @@ type LoggingConfig struct {
 
 func (x *LoggingConfig) Reset() {
 	*x = LoggingConfig{}
-	mi := &file_config_proto_msgTypes[26]
+	mi := &file_config_proto_msgTypes[34]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3348,7 +4168,7 @@ func (x *LoggingConfig) String() string {
 func (*LoggingConfig) ProtoMessage() {}
 
 func (x *LoggingConfig) ProtoReflect() protoreflect.Message {
-	mi := &file_config_proto_msgTypes[26]
+	mi := &file_config_proto_msgTypes[34]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3361,7 +4181,7 @@ func (x *LoggingConfig) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use LoggingConfig.ProtoReflect.Descriptor instead.
 func (*LoggingConfig) Descriptor() ([]byte, []int) {
-	return file_config_proto_rawDescGZIP(), []int{26}
+	return file_config_proto_rawDescGZIP(), []int{34}
 }
 
 func (x *LoggingConfig) GetOutputDirectory() string {
@@ -3447,7 +4267,7 @@ type MonitoringConfig struct {
 
 func (x *MonitoringConfig) Reset() {
 	*x = MonitoringConfig{}
-	mi := &file_config_proto_msgTypes[27]
+	mi := &file_config_proto_msgTypes[35]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3459,7 +4279,7 @@ func (x *MonitoringConfig) String() string {
 func (*MonitoringConfig) ProtoMessage() {}
 
 func (x *MonitoringConfig) ProtoReflect() protoreflect.Message {
-	mi := &file_config_proto_msgTypes[27]
+	mi := &file_config_proto_msgTypes[35]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3472,7 +4292,7 @@ func (x *MonitoringConfig) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use MonitoringConfig.ProtoReflect.Descriptor instead.
 func (*MonitoringConfig) Descriptor() ([]byte, []int) {
-	return file_config_proto_rawDescGZIP(), []int{27}
+	return file_config_proto_rawDescGZIP(), []int{35}
 }
 
 func (x *MonitoringConfig) GetBindAddress() string {
@@ -3506,7 +4326,7 @@ type AutoExecConfig struct {
 
 func (x *AutoExecConfig) Reset() {
 	*x = AutoExecConfig{}
-	mi := &file_config_proto_msgTypes[28]
+	mi := &file_config_proto_msgTypes[36]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3518,7 +4338,7 @@ func (x *AutoExecConfig) String() string {
 func (*AutoExecConfig) ProtoMessage() {}
 
 func (x *AutoExecConfig) ProtoReflect() protoreflect.Message {
-	mi := &file_config_proto_msgTypes[28]
+	mi := &file_config_proto_msgTypes[36]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3531,7 +4351,7 @@ func (x *AutoExecConfig) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use AutoExecConfig.ProtoReflect.Descriptor instead.
 func (*AutoExecConfig) Descriptor() ([]byte, []int) {
-	return file_config_proto_rawDescGZIP(), []int{28}
+	return file_config_proto_rawDescGZIP(), []int{36}
 }
 
 func (x *AutoExecConfig) GetArgv() []string {
@@ -3579,6 +4399,8 @@ type ServerServicesConfig struct {
 	NotebookService       bool                   `protobuf:"varint,24,opt,name=notebook_service,json=notebookService,proto3" json:"notebook_service,omitempty"`
 	SchedulerService      bool                   `protobuf:"varint,29,opt,name=scheduler_service,json=schedulerService,proto3" json:"scheduler_service,omitempty"`
 	BackupService         bool                   `protobuf:"varint,30,opt,name=backup_service,json=backupService,proto3" json:"backup_service,omitempty"`
+	WebhookService        bool                   `protobuf:"varint,31,opt,name=webhook_service,json=webhookService,proto3" json:"webhook_service,omitempty"`
+	FederationService     bool                   `protobuf:"varint,32,opt,name=federation_service,json=federationService,proto3" json:"federation_service,omitempty"`
 	// Client services
 	HttpCommunicator bool `protobuf:"varint,27,opt,name=http_communicator,json=httpCommunicator,proto3" json:"http_communicator,omitempty"`
 	ClientEventTable bool `protobuf:"varint,28,opt,name=client_event_table,json=clientEventTable,proto3" json:"client_event_table,omitempty"`
@@ -3588,7 +4410,7 @@ type ServerServicesConfig struct {
 
 func (x *ServerServicesConfig) Reset() {
 	*x = ServerServicesConfig{}
-	mi := &file_config_proto_msgTypes[29]
+	mi := &file_config_proto_msgTypes[37]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3600,7 +4422,7 @@ func (x *ServerServicesConfig) String() string {
 func (*ServerServicesConfig) ProtoMessage() {}
 
 func (x *ServerServicesConfig) ProtoReflect() protoreflect.Message {
-	mi := &file_config_proto_msgTypes[29]
+	mi := &file_config_proto_msgTypes[37]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3613,7 +4435,7 @@ func (x *ServerServicesConfig) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ServerServicesConfig.ProtoReflect.Descriptor instead.
 func (*ServerServicesConfig) Descriptor() ([]byte, []int) {
-	return file_config_proto_rawDescGZIP(), []int{29}
+	return file_config_proto_rawDescGZIP(), []int{37}
 }
 
 func (x *ServerServicesConfig) GetHuntManager() bool {
@@ -3812,6 +4634,20 @@ func (x *ServerServicesConfig) GetBackupService() bool {
 	return false
 }
 
+func (x *ServerServicesConfig) GetWebhookService() bool {
+	if x != nil {
+		return x.WebhookService
+	}
+	return false
+}
+
+func (x *ServerServicesConfig) GetFederationService() bool {
+	if x != nil {
+		return x.FederationService
+	}
+	return false
+}
+
 func (x *ServerServicesConfig) GetHttpCommunicator() bool {
 	if x != nil {
 		return x.HttpCommunicator
@@ -3921,7 +4757,7 @@ type Defaults struct {
 	ReindexPeriodSeconds int64 `protobuf:"varint,50,opt,name=reindex_period_seconds,json=reindexPeriodSeconds,proto3" json:"reindex_period_seconds,omitempty"`
 	// For example, define here:
 	// indexed_client_metadata:
-	//   - department
+	//  - department
 	//
 	// Then a search for `department:accounting` will match all
 	// clients with the key department and value contains accounting
@@ -3953,7 +4789,7 @@ type Defaults struct {
 
 func (x *Defaults) Reset() {
 	*x = Defaults{}
-	mi := &file_config_proto_msgTypes[30]
+	mi := &file_config_proto_msgTypes[38]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3965,7 +4801,7 @@ func (x *Defaults) String() string {
 func (*Defaults) ProtoMessage() {}
 
 func (x *Defaults) ProtoReflect() protoreflect.Message {
-	mi := &file_config_proto_msgTypes[30]
+	mi := &file_config_proto_msgTypes[38]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3978,7 +4814,7 @@ func (x *Defaults) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Defaults.ProtoReflect.Descriptor instead.
 func (*Defaults) Descriptor() ([]byte, []int) {
-	return file_config_proto_rawDescGZIP(), []int{30}
+	return file_config_proto_rawDescGZIP(), []int{38}
 }
 
 func (x *Defaults) GetHuntExpiryHours() int64 {
@@ -4342,29 +5178,27 @@ type CryptoConfig struct {
 	// which are used to validate TLS server certificates.
 	//
 	// Fingerprints can be generated with the OpenSSL command line utility:
-	//
-	//	openssl s_client -connect www.google.com:443 < /dev/null | openssl x509 -fingerprint -sha256 -noout
+	//   openssl s_client -connect www.google.com:443 < /dev/null | openssl x509 -fingerprint -sha256 -noout
 	//
 	// Certificate thumbprints may or may not include colon characters. Capitalization
 	// of the hex digits is ignored by Velociraptor. A thumbprint of any of the
 	// following forms (or combinations thereof) is fine:
-	//
-	//	E6:E2:8B:35:CE:C5:BA:C4:53:C5:AF:BF:2B:76:34:62:40:5C:D0:60:80:E1:30:1A:A7:A5:A9:DA:0C:8B:11:E1
-	//	E6E28B35CEC5BAC453C5AFBF2B763462405CD06080E1301AA7A5A9DA0C8B11E1
-	//	e6e28b35cec5bac453c5afbf2b763462405cd06080e1301aa7a5a9da0c8b11e1
+	//   E6:E2:8B:35:CE:C5:BA:C4:53:C5:AF:BF:2B:76:34:62:40:5C:D0:60:80:E1:30:1A:A7:A5:A9:DA:0C:8B:11:E1
+	//   E6E28B35CEC5BAC453C5AFBF2B763462405CD06080E1301AA7A5A9DA0C8B11E1
+	//   e6e28b35cec5bac453c5afbf2b763462405cd06080e1301aa7a5a9da0c8b11e1
 	CertificateThumbprints []string `protobuf:"bytes,2,rep,name=certificate_thumbprints,json=certificateThumbprints,proto3" json:"certificate_thumbprints,omitempty"`
 	// Velociraptor supports several ways of verifying TLS certificates. The
 	// certificate_verification_mode specifies which of the three modes is applied.
 	// Currently, three modes are available:
 	//   - PKI (the default): verify TLS certs against public CA lists, the list
-	//     of additional root_certs, and the built-in CA cert
+	//                        of additional root_certs, and the built-in CA cert
 	//   - PKI_OR_THUMBPRINT: the same as PKI with the addition that certificates
-	//     which have a thumbprint that is present in
-	//     certificate_thumbprints will be accepted as well
+	//                        which have a thumbprint that is present in
+	//                        certificate_thumbprints will be accepted as well
 	//   - THUMBPRINT_ONLY: Velociraptor only accepts certificates which have a
-	//     matching thumbprint in certificate_thumbprints. All
-	//     other certificates will be rejected. This mode is
-	//     also known as certificate pinning.
+	//                      matching thumbprint in certificate_thumbprints. All
+	//                      other certificates will be rejected. This mode is
+	//                      also known as certificate pinning.
 	CertificateVerificationMode string `protobuf:"bytes,3,opt,name=certificate_verification_mode,json=certificateVerificationMode,proto3" json:"certificate_verification_mode,omitempty"`
 	// If this is set we do not enforce minimum configuration for TLS
 	// servers. This is required when connecting to Velociraptor with
@@ -4382,7 +5216,7 @@ type CryptoConfig struct {
 
 func (x *CryptoConfig) Reset() {
 	*x = CryptoConfig{}
-	mi := &file_config_proto_msgTypes[31]
+	mi := &file_config_proto_msgTypes[39]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -4394,7 +5228,7 @@ func (x *CryptoConfig) String() string {
 func (*CryptoConfig) ProtoMessage() {}
 
 func (x *CryptoConfig) ProtoReflect() protoreflect.Message {
-	mi := &file_config_proto_msgTypes[31]
+	mi := &file_config_proto_msgTypes[39]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4407,7 +5241,7 @@ func (x *CryptoConfig) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CryptoConfig.ProtoReflect.Descriptor instead.
 func (*CryptoConfig) Descriptor() ([]byte, []int) {
-	return file_config_proto_rawDescGZIP(), []int{31}
+	return file_config_proto_rawDescGZIP(), []int{39}
 }
 
 func (x *CryptoConfig) GetRootCerts() string {
@@ -4463,7 +5297,7 @@ type MountPoint struct {
 
 func (x *MountPoint) Reset() {
 	*x = MountPoint{}
-	mi := &file_config_proto_msgTypes[32]
+	mi := &file_config_proto_msgTypes[40]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -4475,7 +5309,7 @@ func (x *MountPoint) String() string {
 func (*MountPoint) ProtoMessage() {}
 
 func (x *MountPoint) ProtoReflect() protoreflect.Message {
-	mi := &file_config_proto_msgTypes[32]
+	mi := &file_config_proto_msgTypes[40]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4488,7 +5322,7 @@ func (x *MountPoint) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use MountPoint.ProtoReflect.Descriptor instead.
 func (*MountPoint) Descriptor() ([]byte, []int) {
-	return file_config_proto_rawDescGZIP(), []int{32}
+	return file_config_proto_rawDescGZIP(), []int{40}
 }
 
 func (x *MountPoint) GetAccessor() string {
@@ -4542,7 +5376,7 @@ type RemappingConfig struct {
 
 func (x *RemappingConfig) Reset() {
 	*x = RemappingConfig{}
-	mi := &file_config_proto_msgTypes[33]
+	mi := &file_config_proto_msgTypes[41]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -4554,7 +5388,7 @@ func (x *RemappingConfig) String() string {
 func (*RemappingConfig) ProtoMessage() {}
 
 func (x *RemappingConfig) ProtoReflect() protoreflect.Message {
-	mi := &file_config_proto_msgTypes[33]
+	mi := &file_config_proto_msgTypes[41]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4567,7 +5401,7 @@ func (x *RemappingConfig) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use RemappingConfig.ProtoReflect.Descriptor instead.
 func (*RemappingConfig) Descriptor() ([]byte, []int) {
-	return file_config_proto_rawDescGZIP(), []int{33}
+	return file_config_proto_rawDescGZIP(), []int{41}
 }
 
 func (x *RemappingConfig) GetType() string {
@@ -4701,10 +5535,10 @@ type Security struct {
 	// Normally the inventory service attempts to download tools in
 	// its own but if this is set, we prevent any external access.
 	DisableInventoryServiceExternalAccess bool `protobuf:"varint,34,opt,name=disable_inventory_service_external_access,json=disableInventoryServiceExternalAccess,proto3" json:"disable_inventory_service_external_access,omitempty"`
-	//  1. If it starts with env:// the secret will be taken from an
-	//     Environment variable.
-	//  2. If empty the secret is taken from obfuscation_nonce (which
-	//     by default is the hash of the private key).
+	// 1. If it starts with env:// the secret will be taken from an
+	//    Environment variable.
+	// 2. If empty the secret is taken from obfuscation_nonce (which
+	//    by default is the hash of the private key).
 	//
 	// In future further methods may be implemented (e.g. EKMS).
 	SecretsDek string `protobuf:"bytes,3,opt,name=secrets_dek,json=secretsDek,proto3" json:"secrets_dek,omitempty"`
@@ -4727,7 +5561,7 @@ type Security struct {
 
 func (x *Security) Reset() {
 	*x = Security{}
-	mi := &file_config_proto_msgTypes[34]
+	mi := &file_config_proto_msgTypes[42]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -4739,7 +5573,7 @@ func (x *Security) String() string {
 func (*Security) ProtoMessage() {}
 
 func (x *Security) ProtoReflect() protoreflect.Message {
-	mi := &file_config_proto_msgTypes[34]
+	mi := &file_config_proto_msgTypes[42]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4752,7 +5586,7 @@ func (x *Security) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Security.ProtoReflect.Descriptor instead.
 func (*Security) Descriptor() ([]byte, []int) {
-	return file_config_proto_rawDescGZIP(), []int{34}
+	return file_config_proto_rawDescGZIP(), []int{42}
 }
 
 func (x *Security) GetAllowedFileAccessorPrefix() []string {
@@ -4929,6 +5763,8 @@ type Config struct {
 	// Specifies various security related configuration options for
 	// the server.
 	Security *Security `protobuf:"bytes,42,opt,name=security,proto3" json:"security,omitempty"`
+	// Forward events to a central server.
+	Federation *FederationConfig `protobuf:"bytes,43,opt,name=federation,proto3" json:"federation,omitempty"`
 	// The Velociraptor server may be placed into "lockdown"
 	// mode. While in lockdown mode certain permissions are denied -
 	// even for administrators. This additional protection mode helps
@@ -4942,7 +5778,7 @@ type Config struct {
 
 func (x *Config) Reset() {
 	*x = Config{}
-	mi := &file_config_proto_msgTypes[35]
+	mi := &file_config_proto_msgTypes[43]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -4954,7 +5790,7 @@ func (x *Config) String() string {
 func (*Config) ProtoMessage() {}
 
 func (x *Config) ProtoReflect() protoreflect.Message {
-	mi := &file_config_proto_msgTypes[35]
+	mi := &file_config_proto_msgTypes[43]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4967,7 +5803,7 @@ func (x *Config) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Config.ProtoReflect.Descriptor instead.
 func (*Config) Descriptor() ([]byte, []int) {
-	return file_config_proto_rawDescGZIP(), []int{35}
+	return file_config_proto_rawDescGZIP(), []int{43}
 }
 
 func (x *Config) GetVersion() *Version {
@@ -5160,6 +5996,13 @@ func (x *Config) GetSecurity() *Security {
 	return nil
 }
 
+func (x *Config) GetFederation() *FederationConfig {
+	if x != nil {
+		return x.Federation
+	}
+	return nil
+}
+
 func (x *Config) GetLockdown() bool {
 	if x != nil {
 		return x.Lockdown
@@ -5270,14 +6113,28 @@ const file_config_proto_rawDesc = "" +
 	"\aLogging\x187 \x01(\v2\x14.proto.LoggingConfigR\aLogging\x1aD\n" +
 	"\x16FallbackAddressesEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xad\x04\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x92\x05\n" +
 	"\tAPIConfig\x12\x1a\n" +
 	"\bhostname\x18\x05 \x01(\tR\bhostname\x12\x99\x01\n" +
 	"\fbind_address\x18\x01 \x01(\tBv\xe2\xfc\xe3\xc4\x01p\x12nAddress to bind gRPC endpoint. This should usually only be 127.0.0.1, otherwise be sure to properly secure it.R\vbindAddress\x125\n" +
 	"\tbind_port\x18\x02 \x01(\rB\x18\xe2\xfc\xe3\xc4\x01\x12\x12\x10Port to bind to.R\bbindPort\x12b\n" +
 	"\vbind_scheme\x18\x03 \x01(\tBA\xe2\xfc\xe3\xc4\x01;\x123A scheme for the listening socket (e.g. tcp, unix).2\x04unixR\n" +
 	"bindScheme\x12\xcc\x01\n" +
-	"\x0epinned_gw_name\x18\x04 \x01(\tB\xa5\x01\xe2\xfc\xe3\xc4\x01\x9e\x01\x12\x9b\x01Gateway certificate will carry this common name. Note that this name is special because it allows auth bypass for internal gateway calls. Default (GRPC_GW)R\fpinnedGwName\"\xf9\x04\n" +
+	"\x0epinned_gw_name\x18\x04 \x01(\tB\xa5\x01\xe2\xfc\xe3\xc4\x01\x9e\x01\x12\x9b\x01Gateway certificate will carry this common name. Note that this name is special because it allows auth bypass for internal gateway calls. Default (GRPC_GW)R\fpinnedGwName\x12-\n" +
+	"\x06tokens\x18\x06 \x01(\v2\x15.proto.APITokenConfigR\x06tokens\x124\n" +
+	"\vrate_limits\x18\a \x03(\v2\x13.proto.APIRateLimitR\n" +
+	"rateLimits\"l\n" +
+	"\fAPIRateLimit\x12\x1c\n" +
+	"\tprincipal\x18\x01 \x01(\tR\tprincipal\x12\x14\n" +
+	"\x05class\x18\x02 \x01(\tR\x05class\x12\x12\n" +
+	"\x04rate\x18\x03 \x01(\x01R\x04rate\x12\x14\n" +
+	"\x05burst\x18\x04 \x01(\x04R\x05burst\"\xa3\x01\n" +
+	"\x0eAPITokenConfig\x12\x1a\n" +
+	"\bdisabled\x18\x01 \x01(\bR\bdisabled\x12\x1d\n" +
+	"\n" +
+	"allow_grpc\x18\x02 \x01(\bR\tallowGrpc\x12.\n" +
+	"\x13default_expiry_days\x18\x03 \x01(\x04R\x11defaultExpiryDays\x12&\n" +
+	"\x0fmax_expiry_days\x18\x04 \x01(\x04R\rmaxExpiryDays\"\xf9\x04\n" +
 	"\x0fApiClientConfig\x12a\n" +
 	"\x0eca_certificate\x18\x01 \x01(\tB:\xe2\xfc\xe3\xc4\x014\x122The CA certificate used to verify API connections.R\rcaCertificate\x12\x97\x01\n" +
 	"\vclient_cert\x18\x02 \x01(\tBv\xe2\xfc\xe3\xc4\x01p\x12nA client certificate that belongs to this client. Generated from the 'velociraptor config api_client' command.R\n" +
@@ -5286,7 +6143,19 @@ const file_config_proto_rawDesc = "" +
 	"\x15api_connection_string\x18\x04 \x01(\tB:\xe2\xfc\xe3\xc4\x014\x122Location where the api endpoint will be available.R\x13apiConnectionString\x12?\n" +
 	"\x04name\x18\x05 \x01(\tB+\xe2\xfc\xe3\xc4\x01%\x12#The CommonName for this API client.R\x04name\x12,\n" +
 	"\x12pinned_server_name\x18\x06 \x01(\tR\x10pinnedServerName\x12+\n" +
-	"\x12max_grpc_recv_size\x18\a \x01(\x04R\x0fmaxGrpcRecvSize\"\x8d\x02\n" +
+	"\x12max_grpc_recv_size\x18\a \x01(\x04R\x0fmaxGrpcRecvSize\"\xf8\x02\n" +
+	"\x10FederationConfig\x120\n" +
+	"\acentral\x18\x01 \x01(\v2\x16.proto.ApiClientConfigR\acentral\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x1c\n" +
+	"\tartifacts\x18\x03 \x03(\tR\tartifacts\x128\n" +
+	"\x18forward_flow_completions\x18\x04 \x01(\bR\x16forwardFlowCompletions\x12\x15\n" +
+	"\x06org_id\x18\x05 \x01(\tR\x05orgId\x12H\n" +
+	"\vorg_mapping\x18\a \x03(\v2'.proto.FederationConfig.OrgMappingEntryR\n" +
+	"orgMapping\x12&\n" +
+	"\x0fmax_buffer_size\x18\x06 \x01(\x04R\rmaxBufferSize\x1a=\n" +
+	"\x0fOrgMappingEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x8d\x02\n" +
 	"\vProxyConfig\x12\x14\n" +
 	"\x05https\x18\x01 \x01(\tR\x05https\x12\x12\n" +
 	"\x04http\x18\x02 \x01(\tR\x04http\x12P\n" +
@@ -5318,7 +6187,47 @@ const file_config_proto_rawDesc = "" +
 	"\roverride_acls\x18\x05 \x01(\bR\foverrideAcls\x1aJ\n" +
 	"\fRoleMapEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12$\n" +
-	"\x05value\x18\x02 \x01(\v2\x0e.proto.OIDCACLR\x05value:\x028\x01\"\xe6\r\n" +
+	"\x05value\x18\x02 \x01(\v2\x0e.proto.OIDCACLR\x05value:\x028\x01\"Z\n" +
+	"\fLDAPGroupACL\x12\x14\n" +
+	"\x05roles\x18\x01 \x03(\tR\x05roles\x12 \n" +
+	"\vpermissions\x18\x02 \x03(\tR\vpermissions\x12\x12\n" +
+	"\x04orgs\x18\x03 \x03(\tR\x04orgs\"\xc6\x05\n" +
+	"\n" +
+	"LDAPConfig\x12\x10\n" +
+	"\x03url\x18\x01 \x01(\tR\x03url\x12\x1b\n" +
+	"\tstart_tls\x18\x02 \x01(\bR\bstartTls\x12\x17\n" +
+	"\aroot_ca\x18\x03 \x01(\tR\x06rootCa\x120\n" +
+	"\x14insecure_skip_verify\x18\x04 \x01(\bR\x12insecureSkipVerify\x12\x17\n" +
+	"\abind_dn\x18\x05 \x01(\tR\x06bindDn\x12#\n" +
+	"\rbind_password\x18\x06 \x01(\tR\fbindPassword\x12(\n" +
+	"\x10user_dn_template\x18\a \x01(\tR\x0euserDnTemplate\x12\x17\n" +
+	"\abase_dn\x18\b \x01(\tR\x06baseDn\x12\x1f\n" +
+	"\vuser_filter\x18\t \x01(\tR\n" +
+	"userFilter\x12-\n" +
+	"\x12username_attribute\x18\n" +
+	" \x01(\tR\x11usernameAttribute\x12\"\n" +
+	"\rgroup_base_dn\x18\v \x01(\tR\vgroupBaseDn\x12!\n" +
+	"\fgroup_filter\x18\f \x01(\tR\vgroupFilter\x12*\n" +
+	"\x11max_nesting_depth\x18\r \x01(\x04R\x0fmaxNestingDepth\x129\n" +
+	"\brole_map\x18\x0e \x03(\v2\x1e.proto.LDAPConfig.RoleMapEntryR\aroleMap\x12#\n" +
+	"\roverride_acls\x18\x0f \x01(\bR\foverrideAcls\x12(\n" +
+	"\x10cache_expiry_sec\x18\x10 \x01(\x04R\x0ecacheExpirySec\x12\x1f\n" +
+	"\vtimeout_sec\x18\x11 \x01(\x04R\n" +
+	"timeoutSec\x1aO\n" +
+	"\fRoleMapEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12)\n" +
+	"\x05value\x18\x02 \x01(\v2\x13.proto.LDAPGroupACLR\x05value:\x028\x01\"\xd2\x02\n" +
+	"\x0eBasicMFAConfig\x12\x1a\n" +
+	"\brequired\x18\x01 \x01(\bR\brequired\x12\x16\n" +
+	"\x06issuer\x18\x02 \x01(\tR\x06issuer\x12\x13\n" +
+	"\x05rp_id\x18\x03 \x01(\tR\x04rpId\x12\x1d\n" +
+	"\n" +
+	"rp_origins\x18\x04 \x03(\tR\trpOrigins\x12,\n" +
+	"\x12session_expiry_min\x18\x05 \x01(\x04R\x10sessionExpiryMin\x12+\n" +
+	"\x12reauth_max_age_sec\x18\x06 \x01(\x04R\x0freauthMaxAgeSec\x12'\n" +
+	"\x0fsensitive_paths\x18\a \x03(\tR\x0esensitivePaths\x12*\n" +
+	"\x11max_totp_attempts\x18\b \x01(\x04R\x0fmaxTotpAttempts\x12(\n" +
+	"\x10totp_lockout_sec\x18\t \x01(\x04R\x0etotpLockoutSec\"\xb6\x0e\n" +
 	"\rAuthenticator\x12\x12\n" +
 	"\x04type\x18\x01 \x01(\tR\x04type\x12\xb9\x01\n" +
 	"\voidc_issuer\x18\x04 \x01(\tB\x97\x01\xe2\xfc\xe3\xc4\x01\x90\x01\x12\x8d\x01URL to OIDC Configuration Document. The configuration should be available in the 'oidc_issuer + /.well-known/openid-configuration' endpoint. R\n" +
@@ -5339,14 +6248,16 @@ const file_config_proto_rawDesc = "" +
 	"\rsaml_root_url\x18\x0f \x01(\tB\x16\xe2\xfc\xe3\xc4\x01\x10\x12\x0eSAML root URL.R\vsamlRootUrl\x12p\n" +
 	"\x13saml_user_attribute\x18\x10 \x01(\tB@\xe2\xfc\xe3\xc4\x01:\x128SAML attribute containing value for user identification.R\x11samlUserAttribute\x12\xa9\x01\n" +
 	"\x0fsaml_user_roles\x18\x17 \x03(\tB\x80\x01\xe2\xfc\xe3\xc4\x01z\x12xList of roles to assign authenticated SAML users. If this option is not set then no users will be created automatically.R\rsamlUserRoles\x12_\n" +
-	"\x18saml_allow_idp_initiated\x18\x1b \x01(\bB&\xe2\xfc\xe3\xc4\x01 \x12\x1eAllow IdP-initiated SAML flow.R\x15samlAllowIdpInitiated\x12C\n" +
+	"\x18saml_allow_idp_initiated\x18\x1b \x01(\bB&\xe2\xfc\xe3\xc4\x01 \x12\x1eAllow IdP-initiated SAML flow.R\x15samlAllowIdpInitiated\x12%\n" +
+	"\x04ldap\x18\x1d \x01(\v2\x11.proto.LDAPConfigR\x04ldap\x12'\n" +
+	"\x03mfa\x18\x1e \x01(\v2\x15.proto.BasicMFAConfigR\x03mfa\x12C\n" +
 	"\x12sub_authenticators\x18\x11 \x03(\v2\x14.proto.AuthenticatorR\x11subAuthenticators\x12i\n" +
 	"\x16auth_redirect_template\x18\x15 \x01(\tB3\xe2\xfc\xe3\xc4\x01-\x12+URL to redirect to on Unauthorized API callR\x14authRedirectTemplate\x12B\n" +
 	"\x1edefault_roles_for_unknown_user\x18\x16 \x03(\tR\x1adefaultRolesForUnknownUser\x12;\n" +
 	"\x1adefault_session_expiry_min\x18\x14 \x01(\x04R\x17defaultSessionExpiryMin\x1aD\n" +
 	"\x16OidcAuthUrlParamsEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xbb\t\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xe2\t\n" +
 	"\tGUIConfig\x12\x98\x01\n" +
 	"\fbind_address\x18\x01 \x01(\tBu\xe2\xfc\xe3\xc4\x01o\x12mAddress to bind GUI endpoint. This should usually only be 127.0.0.1, otherwise be sure to properly secure it.R\vbindAddress\x125\n" +
 	"\tbind_port\x18\x02 \x01(\rB\x18\xe2\xfc\xe3\xc4\x01\x12\x12\x10Port to bind to.R\bbindPort\x12!\n" +
@@ -5365,7 +6276,16 @@ const file_config_proto_rawDesc = "" +
 	"\finitial_orgs\x18\x16 \x03(\v2\x17.proto.InitialOrgRecordR\vinitialOrgs\x12:\n" +
 	"\rauthenticator\x18\x13 \x01(\v2\x14.proto.AuthenticatorR\rauthenticator\x124\n" +
 	"\x16artifact_search_filter\x18\x12 \x01(\tR\x14artifactSearchFilter\x12'\n" +
-	"\x0ftrusted_origins\x18\x19 \x03(\tR\x0etrustedOrigins\"g\n" +
+	"\x0ftrusted_origins\x18\x19 \x03(\tR\x0etrustedOrigins\x12%\n" +
+	"\x04scim\x18\x1a \x01(\v2\x11.proto.SCIMConfigR\x04scim\"W\n" +
+	"\n" +
+	"SCIMConfig\x12\x18\n" +
+	"\aenabled\x18\x01 \x01(\bR\aenabled\x12/\n" +
+	"\x06groups\x18\x02 \x03(\v2\x17.proto.SCIMGroupMappingR\x06groups\"P\n" +
+	"\x10SCIMGroupMapping\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x14\n" +
+	"\x05roles\x18\x02 \x03(\tR\x05roles\x12\x12\n" +
+	"\x04orgs\x18\x03 \x03(\tR\x04orgs\"g\n" +
 	"\aGUIUser\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x12#\n" +
 	"\rpassword_hash\x18\x02 \x01(\tR\fpasswordHash\x12#\n" +
@@ -5509,7 +6429,8 @@ const file_config_proto_rawDesc = "" +
 	"metricsUrl\"h\n" +
 	"\x0eAutoExecConfig\x12\x12\n" +
 	"\x04argv\x18\x01 \x03(\tR\x04argv\x12B\n" +
-	"\x14artifact_definitions\x18\x02 \x03(\v2\x0f.proto.ArtifactR\x13artifactDefinitions\"\xc1\t\n" +
+	"\x14artifact_definitions\x18\x02 \x03(\v2\x0f.proto.ArtifactR\x13artifactDefinitions\"\x99\n" +
+	"\n" +
 	"\x14ServerServicesConfig\x12!\n" +
 	"\fhunt_manager\x18\x01 \x01(\bR\vhuntManager\x12'\n" +
 	"\x0fhunt_dispatcher\x18\x02 \x01(\bR\x0ehuntDispatcher\x12'\n" +
@@ -5543,7 +6464,9 @@ const file_config_proto_rawDesc = "" +
 	"\blauncher\x18\x17 \x01(\bR\blauncher\x12)\n" +
 	"\x10notebook_service\x18\x18 \x01(\bR\x0fnotebookService\x12+\n" +
 	"\x11scheduler_service\x18\x1d \x01(\bR\x10schedulerService\x12%\n" +
-	"\x0ebackup_service\x18\x1e \x01(\bR\rbackupService\x12+\n" +
+	"\x0ebackup_service\x18\x1e \x01(\bR\rbackupService\x12'\n" +
+	"\x0fwebhook_service\x18\x1f \x01(\bR\x0ewebhookService\x12-\n" +
+	"\x12federation_service\x18  \x01(\bR\x11federationService\x12+\n" +
 	"\x11http_communicator\x18\x1b \x01(\bR\x10httpCommunicator\x12,\n" +
 	"\x12client_event_table\x18\x1c \x01(\bR\x10clientEventTable\"\xa4\x15\n" +
 	"\bDefaults\x12*\n" +
@@ -5643,7 +6566,7 @@ const file_config_proto_rawDesc = "" +
 	"secretsDek\x12/\n" +
 	"\x14vql_must_use_secrets\x18\x05 \x01(\bR\x11vqlMustUseSecrets\x12*\n" +
 	"\x11shadowed_env_vars\x18\x04 \x03(\tR\x0fshadowedEnvVars\x122\n" +
-	"\x15allow_ancient_clients\x18= \x01(\bR\x13allowAncientClients\"\x96\r\n" +
+	"\x15allow_ancient_clients\x18= \x01(\bR\x13allowAncientClients\"\xcf\r\n" +
 	"\x06Config\x12F\n" +
 	"\aversion\x18\b \x01(\v2\x0e.proto.VersionB\x1c\xe2\xfc\xe3\xc4\x01\x16\x12\x14Version information.R\aversion\x12J\n" +
 	"\x06Client\x18\x01 \x01(\v2\x13.proto.ClientConfigB\x1d\xe2\xfc\xe3\xc4\x01\x17\x12\x15Client configuration.R\x06Client\x12P\n" +
@@ -5678,7 +6601,10 @@ const file_config_proto_rawDesc = "" +
 	"\n" +
 	"debug_mode\x18) \x01(\bR\tdebugMode\x127\n" +
 	"\bservices\x18& \x01(\v2\x1b.proto.ServerServicesConfigR\bservices\x12+\n" +
-	"\bsecurity\x18* \x01(\v2\x0f.proto.SecurityR\bsecurity\x12\x1a\n" +
+	"\bsecurity\x18* \x01(\v2\x0f.proto.SecurityR\bsecurity\x127\n" +
+	"\n" +
+	"federation\x18+ \x01(\v2\x17.proto.FederationConfigR\n" +
+	"federation\x12\x1a\n" +
 	"\blockdown\x18' \x01(\bR\blockdownB4Z2www.velocidex.com/golang/velociraptor/config/protob\x06proto3"
 
 var (
@@ -5693,7 +6619,7 @@ func file_config_proto_rawDescGZIP() []byte {
 	return file_config_proto_rawDescData
 }
 
-var file_config_proto_msgTypes = make([]protoimpl.MessageInfo, 40)
+var file_config_proto_msgTypes = make([]protoimpl.MessageInfo, 50)
 var file_config_proto_goTypes = []any{
 	(*Version)(nil),                 // 0: proto.Version
 	(*FlowCheckPoint)(nil),          // 1: proto.FlowCheckPoint
@@ -5704,98 +6630,119 @@ var file_config_proto_goTypes = []any{
 	(*RingBufferConfig)(nil),        // 6: proto.RingBufferConfig
 	(*ClientConfig)(nil),            // 7: proto.ClientConfig
 	(*APIConfig)(nil),               // 8: proto.APIConfig
-	(*ApiClientConfig)(nil),         // 9: proto.ApiClientConfig
-	(*ProxyConfig)(nil),             // 10: proto.ProxyConfig
-	(*GUILink)(nil),                 // 11: proto.GUILink
-	(*OIDCACL)(nil),                 // 12: proto.OIDCACL
-	(*OIDCClaims)(nil),              // 13: proto.OIDCClaims
-	(*Authenticator)(nil),           // 14: proto.Authenticator
-	(*GUIConfig)(nil),               // 15: proto.GUIConfig
-	(*GUIUser)(nil),                 // 16: proto.GUIUser
-	(*CAConfig)(nil),                // 17: proto.CAConfig
-	(*ReverseProxyConfig)(nil),      // 18: proto.ReverseProxyConfig
-	(*DynDNSConfig)(nil),            // 19: proto.DynDNSConfig
-	(*FrontendResourceControl)(nil), // 20: proto.FrontendResourceControl
-	(*FrontendConfig)(nil),          // 21: proto.FrontendConfig
-	(*DatastoreConfig)(nil),         // 22: proto.DatastoreConfig
-	(*MinionConfig)(nil),            // 23: proto.MinionConfig
-	(*MailConfig)(nil),              // 24: proto.MailConfig
-	(*LoggingRetentionConfig)(nil),  // 25: proto.LoggingRetentionConfig
-	(*LoggingConfig)(nil),           // 26: proto.LoggingConfig
-	(*MonitoringConfig)(nil),        // 27: proto.MonitoringConfig
-	(*AutoExecConfig)(nil),          // 28: proto.AutoExecConfig
-	(*ServerServicesConfig)(nil),    // 29: proto.ServerServicesConfig
-	(*Defaults)(nil),                // 30: proto.Defaults
-	(*CryptoConfig)(nil),            // 31: proto.CryptoConfig
-	(*MountPoint)(nil),              // 32: proto.MountPoint
-	(*RemappingConfig)(nil),         // 33: proto.RemappingConfig
-	(*Security)(nil),                // 34: proto.Security
-	(*Config)(nil),                  // 35: proto.Config
-	nil,                             // 36: proto.ClientConfig.FallbackAddressesEntry
-	nil,                             // 37: proto.ProxyConfig.ProxyUrlRegexpEntry
-	nil,                             // 38: proto.OIDCClaims.RoleMapEntry
-	nil,                             // 39: proto.Authenticator.OidcAuthUrlParamsEntry
-	(*proto.VQLEventTable)(nil),     // 40: proto.VQLEventTable
-	(*proto1.Artifact)(nil),         // 41: proto.Artifact
-	(*proto.VQLEnv)(nil),            // 42: proto.VQLEnv
+	(*APIRateLimit)(nil),            // 9: proto.APIRateLimit
+	(*APITokenConfig)(nil),          // 10: proto.APITokenConfig
+	(*ApiClientConfig)(nil),         // 11: proto.ApiClientConfig
+	(*FederationConfig)(nil),        // 12: proto.FederationConfig
+	(*ProxyConfig)(nil),             // 13: proto.ProxyConfig
+	(*GUILink)(nil),                 // 14: proto.GUILink
+	(*OIDCACL)(nil),                 // 15: proto.OIDCACL
+	(*OIDCClaims)(nil),              // 16: proto.OIDCClaims
+	(*LDAPGroupACL)(nil),            // 17: proto.LDAPGroupACL
+	(*LDAPConfig)(nil),              // 18: proto.LDAPConfig
+	(*BasicMFAConfig)(nil),          // 19: proto.BasicMFAConfig
+	(*Authenticator)(nil),           // 20: proto.Authenticator
+	(*GUIConfig)(nil),               // 21: proto.GUIConfig
+	(*SCIMConfig)(nil),              // 22: proto.SCIMConfig
+	(*SCIMGroupMapping)(nil),        // 23: proto.SCIMGroupMapping
+	(*GUIUser)(nil),                 // 24: proto.GUIUser
+	(*CAConfig)(nil),                // 25: proto.CAConfig
+	(*ReverseProxyConfig)(nil),      // 26: proto.ReverseProxyConfig
+	(*DynDNSConfig)(nil),            // 27: proto.DynDNSConfig
+	(*FrontendResourceControl)(nil), // 28: proto.FrontendResourceControl
+	(*FrontendConfig)(nil),          // 29: proto.FrontendConfig
+	(*DatastoreConfig)(nil),         // 30: proto.DatastoreConfig
+	(*MinionConfig)(nil),            // 31: proto.MinionConfig
+	(*MailConfig)(nil),              // 32: proto.MailConfig
+	(*LoggingRetentionConfig)(nil),  // 33: proto.LoggingRetentionConfig
+	(*LoggingConfig)(nil),           // 34: proto.LoggingConfig
+	(*MonitoringConfig)(nil),        // 35: proto.MonitoringConfig
+	(*AutoExecConfig)(nil),          // 36: proto.AutoExecConfig
+	(*ServerServicesConfig)(nil),    // 37: proto.ServerServicesConfig
+	(*Defaults)(nil),                // 38: proto.Defaults
+	(*CryptoConfig)(nil),            // 39: proto.CryptoConfig
+	(*MountPoint)(nil),              // 40: proto.MountPoint
+	(*RemappingConfig)(nil),         // 41: proto.RemappingConfig
+	(*Security)(nil),                // 42: proto.Security
+	(*Config)(nil),                  // 43: proto.Config
+	nil,                             // 44: proto.ClientConfig.FallbackAddressesEntry
+	nil,                             // 45: proto.FederationConfig.OrgMappingEntry
+	nil,                             // 46: proto.ProxyConfig.ProxyUrlRegexpEntry
+	nil,                             // 47: proto.OIDCClaims.RoleMapEntry
+	nil,                             // 48: proto.LDAPConfig.RoleMapEntry
+	nil,                             // 49: proto.Authenticator.OidcAuthUrlParamsEntry
+	(*proto.VQLEventTable)(nil),     // 50: proto.VQLEventTable
+	(*proto1.Artifact)(nil),         // 51: proto.Artifact
+	(*proto.VQLEnv)(nil),            // 52: proto.VQLEnv
 }
 var file_config_proto_depIdxs = []int32{
-	40, // 0: proto.Writeback.event_queries:type_name -> proto.VQLEventTable
+	50, // 0: proto.Writeback.event_queries:type_name -> proto.VQLEventTable
 	1,  // 1: proto.Writeback.checkpoints:type_name -> proto.FlowCheckPoint
-	10, // 2: proto.ClientConfig.proxy_config:type_name -> proto.ProxyConfig
+	13, // 2: proto.ClientConfig.proxy_config:type_name -> proto.ProxyConfig
 	4,  // 3: proto.ClientConfig.windows_installer:type_name -> proto.WindowsInstallerConfig
 	5,  // 4: proto.ClientConfig.darwin_installer:type_name -> proto.DarwinInstallerConfig
 	0,  // 5: proto.ClientConfig.version:type_name -> proto.Version
 	0,  // 6: proto.ClientConfig.server_version:type_name -> proto.Version
 	6,  // 7: proto.ClientConfig.local_buffer:type_name -> proto.RingBufferConfig
-	31, // 8: proto.ClientConfig.Crypto:type_name -> proto.CryptoConfig
-	36, // 9: proto.ClientConfig.fallback_addresses:type_name -> proto.ClientConfig.FallbackAddressesEntry
-	26, // 10: proto.ClientConfig.Logging:type_name -> proto.LoggingConfig
-	37, // 11: proto.ProxyConfig.proxy_url_regexp:type_name -> proto.ProxyConfig.ProxyUrlRegexpEntry
-	38, // 12: proto.OIDCClaims.role_map:type_name -> proto.OIDCClaims.RoleMapEntry
-	39, // 13: proto.Authenticator.oidc_auth_url_params:type_name -> proto.Authenticator.OidcAuthUrlParamsEntry
-	13, // 14: proto.Authenticator.claims:type_name -> proto.OIDCClaims
-	14, // 15: proto.Authenticator.sub_authenticators:type_name -> proto.Authenticator
-	18, // 16: proto.GUIConfig.reverse_proxy:type_name -> proto.ReverseProxyConfig
-	11, // 17: proto.GUIConfig.links:type_name -> proto.GUILink
-	16, // 18: proto.GUIConfig.initial_users:type_name -> proto.GUIUser
-	3,  // 19: proto.GUIConfig.initial_orgs:type_name -> proto.InitialOrgRecord
-	14, // 20: proto.GUIConfig.authenticator:type_name -> proto.Authenticator
-	10, // 21: proto.FrontendConfig.proxy_config:type_name -> proto.ProxyConfig
-	19, // 22: proto.FrontendConfig.dyn_dns:type_name -> proto.DynDNSConfig
-	20, // 23: proto.FrontendConfig.resources:type_name -> proto.FrontendResourceControl
-	25, // 24: proto.LoggingConfig.debug:type_name -> proto.LoggingRetentionConfig
-	25, // 25: proto.LoggingConfig.info:type_name -> proto.LoggingRetentionConfig
-	25, // 26: proto.LoggingConfig.error:type_name -> proto.LoggingRetentionConfig
-	41, // 27: proto.AutoExecConfig.artifact_definitions:type_name -> proto.Artifact
-	32, // 28: proto.RemappingConfig.from:type_name -> proto.MountPoint
-	32, // 29: proto.RemappingConfig.on:type_name -> proto.MountPoint
-	42, // 30: proto.RemappingConfig.env:type_name -> proto.VQLEnv
-	0,  // 31: proto.Config.version:type_name -> proto.Version
-	7,  // 32: proto.Config.Client:type_name -> proto.ClientConfig
-	8,  // 33: proto.Config.API:type_name -> proto.APIConfig
-	15, // 34: proto.Config.GUI:type_name -> proto.GUIConfig
-	17, // 35: proto.Config.CA:type_name -> proto.CAConfig
-	21, // 36: proto.Config.Frontend:type_name -> proto.FrontendConfig
-	21, // 37: proto.Config.ExtraFrontends:type_name -> proto.FrontendConfig
-	22, // 38: proto.Config.Datastore:type_name -> proto.DatastoreConfig
-	2,  // 39: proto.Config.Writeback:type_name -> proto.Writeback
-	24, // 40: proto.Config.Mail:type_name -> proto.MailConfig
-	26, // 41: proto.Config.Logging:type_name -> proto.LoggingConfig
-	23, // 42: proto.Config.Minion:type_name -> proto.MinionConfig
-	27, // 43: proto.Config.Monitoring:type_name -> proto.MonitoringConfig
-	9,  // 44: proto.Config.api_config:type_name -> proto.ApiClientConfig
-	28, // 45: proto.Config.autoexec:type_name -> proto.AutoExecConfig
-	30, // 46: proto.Config.defaults:type_name -> proto.Defaults
-	33, // 47: proto.Config.remappings:type_name -> proto.RemappingConfig
-	29, // 48: proto.Config.services:type_name -> proto.ServerServicesConfig
-	34, // 49: proto.Config.security:type_name -> proto.Security
-	12, // 50: proto.OIDCClaims.RoleMapEntry.value:type_name -> proto.OIDCACL
-	51, // [51:51] is the sub-list for method output_type
-	51, // [51:51] is the sub-list for method input_type
-	51, // [51:51] is the sub-list for extension type_name
-	51, // [51:51] is the sub-list for extension extendee
-	0,  // [0:51] is the sub-list for field type_name
+	39, // 8: proto.ClientConfig.Crypto:type_name -> proto.CryptoConfig
+	44, // 9: proto.ClientConfig.fallback_addresses:type_name -> proto.ClientConfig.FallbackAddressesEntry
+	34, // 10: proto.ClientConfig.Logging:type_name -> proto.LoggingConfig
+	10, // 11: proto.APIConfig.tokens:type_name -> proto.APITokenConfig
+	9,  // 12: proto.APIConfig.rate_limits:type_name -> proto.APIRateLimit
+	11, // 13: proto.FederationConfig.central:type_name -> proto.ApiClientConfig
+	45, // 14: proto.FederationConfig.org_mapping:type_name -> proto.FederationConfig.OrgMappingEntry
+	46, // 15: proto.ProxyConfig.proxy_url_regexp:type_name -> proto.ProxyConfig.ProxyUrlRegexpEntry
+	47, // 16: proto.OIDCClaims.role_map:type_name -> proto.OIDCClaims.RoleMapEntry
+	48, // 17: proto.LDAPConfig.role_map:type_name -> proto.LDAPConfig.RoleMapEntry
+	49, // 18: proto.Authenticator.oidc_auth_url_params:type_name -> proto.Authenticator.OidcAuthUrlParamsEntry
+	16, // 19: proto.Authenticator.claims:type_name -> proto.OIDCClaims
+	18, // 20: proto.Authenticator.ldap:type_name -> proto.LDAPConfig
+	19, // 21: proto.Authenticator.mfa:type_name -> proto.BasicMFAConfig
+	20, // 22: proto.Authenticator.sub_authenticators:type_name -> proto.Authenticator
+	26, // 23: proto.GUIConfig.reverse_proxy:type_name -> proto.ReverseProxyConfig
+	14, // 24: proto.GUIConfig.links:type_name -> proto.GUILink
+	24, // 25: proto.GUIConfig.initial_users:type_name -> proto.GUIUser
+	3,  // 26: proto.GUIConfig.initial_orgs:type_name -> proto.InitialOrgRecord
+	20, // 27: proto.GUIConfig.authenticator:type_name -> proto.Authenticator
+	22, // 28: proto.GUIConfig.scim:type_name -> proto.SCIMConfig
+	23, // 29: proto.SCIMConfig.groups:type_name -> proto.SCIMGroupMapping
+	13, // 30: proto.FrontendConfig.proxy_config:type_name -> proto.ProxyConfig
+	27, // 31: proto.FrontendConfig.dyn_dns:type_name -> proto.DynDNSConfig
+	28, // 32: proto.FrontendConfig.resources:type_name -> proto.FrontendResourceControl
+	33, // 33: proto.LoggingConfig.debug:type_name -> proto.LoggingRetentionConfig
+	33, // 34: proto.LoggingConfig.info:type_name -> proto.LoggingRetentionConfig
+	33, // 35: proto.LoggingConfig.error:type_name -> proto.LoggingRetentionConfig
+	51, // 36: proto.AutoExecConfig.artifact_definitions:type_name -> proto.Artifact
+	40, // 37: proto.RemappingConfig.from:type_name -> proto.MountPoint
+	40, // 38: proto.RemappingConfig.on:type_name -> proto.MountPoint
+	52, // 39: proto.RemappingConfig.env:type_name -> proto.VQLEnv
+	0,  // 40: proto.Config.version:type_name -> proto.Version
+	7,  // 41: proto.Config.Client:type_name -> proto.ClientConfig
+	8,  // 42: proto.Config.API:type_name -> proto.APIConfig
+	21, // 43: proto.Config.GUI:type_name -> proto.GUIConfig
+	25, // 44: proto.Config.CA:type_name -> proto.CAConfig
+	29, // 45: proto.Config.Frontend:type_name -> proto.FrontendConfig
+	29, // 46: proto.Config.ExtraFrontends:type_name -> proto.FrontendConfig
+	30, // 47: proto.Config.Datastore:type_name -> proto.DatastoreConfig
+	2,  // 48: proto.Config.Writeback:type_name -> proto.Writeback
+	32, // 49: proto.Config.Mail:type_name -> proto.MailConfig
+	34, // 50: proto.Config.Logging:type_name -> proto.LoggingConfig
+	31, // 51: proto.Config.Minion:type_name -> proto.MinionConfig
+	35, // 52: proto.Config.Monitoring:type_name -> proto.MonitoringConfig
+	11, // 53: proto.Config.api_config:type_name -> proto.ApiClientConfig
+	36, // 54: proto.Config.autoexec:type_name -> proto.AutoExecConfig
+	38, // 55: proto.Config.defaults:type_name -> proto.Defaults
+	41, // 56: proto.Config.remappings:type_name -> proto.RemappingConfig
+	37, // 57: proto.Config.services:type_name -> proto.ServerServicesConfig
+	42, // 58: proto.Config.security:type_name -> proto.Security
+	12, // 59: proto.Config.federation:type_name -> proto.FederationConfig
+	15, // 60: proto.OIDCClaims.RoleMapEntry.value:type_name -> proto.OIDCACL
+	17, // 61: proto.LDAPConfig.RoleMapEntry.value:type_name -> proto.LDAPGroupACL
+	62, // [62:62] is the sub-list for method output_type
+	62, // [62:62] is the sub-list for method input_type
+	62, // [62:62] is the sub-list for extension type_name
+	62, // [62:62] is the sub-list for extension extendee
+	0,  // [0:62] is the sub-list for field type_name
 }
 
 func init() { file_config_proto_init() }
@@ -5809,7 +6756,7 @@ func file_config_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_config_proto_rawDesc), len(file_config_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   40,
+			NumMessages:   50,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -69,6 +69,7 @@ const (
 	SCOPE_REPOSITORY        = "$repository"
 	SCOPE_RESPONDER_CONTEXT = "_Context"
 	SCOPE_QUERY_NAME        = "$query_name"
+	SCOPE_PROFILER          = "$profiler"
 
 	// Artifact names from packs should start with this
 	ARTIFACT_PACK_NAME_PREFIX   = "Packs."
@@ -157,6 +158,11 @@ const (
 	// If this is set, the logs will report the decrypted password
 	REPORT_ZIP_PASSWORD = "REPORT_ZIP_PASSWORD"
 
+	// Set in the scope with one or more PEM encoded private keys. Used
+	// by the collector accessor to open containers encrypted for
+	// multiple recipients.
+	COLLECTION_PRIVATE_KEYS = "COLLECTION_PRIVATE_KEYS"
+
 	// If this is set we always copy SQLite files to a tempfile. Used
 	// by the sqlite() plugin.
 	SQLITE_ALWAYS_MAKE_TEMPFILE = "SQLITE_ALWAYS_MAKE_TEMPFILE"
@@ -181,6 +187,16 @@ const (
 	// Set this to see extended debug messages of various LRU
 	LRU_DEBUG = "LRU_DEBUG"
 
+	// Set this in a notebook cell to log row counts for each query
+	// and the rows at these comma separated breakpoints (line
+	// numbers, plugin or LET names).
+	DEBUG_VQL = "DEBUG_VQL"
+
+	// Set this in a collection's environment to record the time
+	// spent in each plugin, function and LET of the query. The
+	// profile is stored with the flow.
+	VQL_PROFILE = "VQL_PROFILE"
+
 	PinnedServerName = "VelociraptorServer"
 
 	// Default gateway identity. This is only used when creating the
@@ -196,6 +212,10 @@ const (
 	//   reducing server load.
 	CLIENT_API_VERSION_0_6_8 = uint32(4)
 
+	// Older clients ignore the dry_run field of a collection and
+	// would run it for real.
+	DRY_RUN_MIN_CLIENT_VERSION = "0.77.2"
+
 	DISABLE_DANGEROUS_API_CALLS = "DISABLE_DANGEROUS_API_CALLS"
 
 	// Fixed secret types - definitions in the sanity service
@@ -207,6 +227,7 @@ const (
 	ADX_CREDS       = "ADX Creds"
 	SMTP_CREDS      = "SMTP Creds"
 	EXECVE_SECRET   = "Execve Secrets"
+	WEBHOOK_SECRET  = "Webhook Secrets"
 
 	// The name of the annotation timeline
 	TIMELINE_ANNOTATION      = "Annotation"
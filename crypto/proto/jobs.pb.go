@@ -1255,8 +1255,14 @@ type VeloStatus struct {
 	QueryId                 int64  `protobuf:"varint,8,opt,name=query_id,json=queryId,proto3" json:"query_id,omitempty"`
 	TotalQueries            int64  `protobuf:"varint,9,opt,name=total_queries,json=totalQueries,proto3" json:"total_queries,omitempty"`
 	TransactionsOutstanding uint64 `protobuf:"varint,16,opt,name=transactions_outstanding,json=transactionsOutstanding,proto3" json:"transactions_outstanding,omitempty"`
-	unknownFields           protoimpl.UnknownFields
-	sizeCache               protoimpl.SizeCache
+	// In dry run mode, the number of files the query would have
+	// uploaded, written or removed.
+	FilesTouched int64 `protobuf:"varint,17,opt,name=files_touched,json=filesTouched,proto3" json:"files_touched,omitempty"`
+	// The sources whose results are only the rows which changed
+	// since the incremental base flow.
+	IncrementalSources []string `protobuf:"bytes,18,rep,name=incremental_sources,json=incrementalSources,proto3" json:"incremental_sources,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
 }
 
 func (x *VeloStatus) Reset() {
@@ -1401,6 +1407,20 @@ func (x *VeloStatus) GetTransactionsOutstanding() uint64 {
 	return 0
 }
 
+func (x *VeloStatus) GetFilesTouched() int64 {
+	if x != nil {
+		return x.FilesTouched
+	}
+	return 0
+}
+
+func (x *VeloStatus) GetIncrementalSources() []string {
+	if x != nil {
+		return x.IncrementalSources
+	}
+	return nil
+}
+
 // This is a list of job messages.
 type MessageList struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -2130,7 +2150,7 @@ const file_jobs_proto_rawDesc = "" +
 	"\x10FlowStatsRequest\x12\x17\n" +
 	"\aflow_id\x18\x01 \x03(\tR\x06flowId\"/\n" +
 	"\x14FlowStatsSummaryItem\x12\x17\n" +
-	"\aflow_id\x18\x01 \x01(\tR\x06flowId\"\xbf\x05\n" +
+	"\aflow_id\x18\x01 \x01(\tR\x06flowId\"\x95\x06\n" +
 	"\n" +
 	"VeloStatus\x128\n" +
 	"\x06status\x18\x01 \x01(\x0e2 .proto.VeloStatus.ReturnedStatusR\x06status\x12#\n" +
@@ -2151,7 +2171,9 @@ const file_jobs_proto_rawDesc = "" +
 	"resultRows\x12\x19\n" +
 	"\bquery_id\x18\b \x01(\x03R\aqueryId\x12#\n" +
 	"\rtotal_queries\x18\t \x01(\x03R\ftotalQueries\x129\n" +
-	"\x18transactions_outstanding\x18\x10 \x01(\x04R\x17transactionsOutstanding\"K\n" +
+	"\x18transactions_outstanding\x18\x10 \x01(\x04R\x17transactionsOutstanding\x12#\n" +
+	"\rfiles_touched\x18\x11 \x01(\x03R\ffilesTouched\x12/\n" +
+	"\x13incremental_sources\x18\x12 \x03(\tR\x12incrementalSources\"K\n" +
 	"\x0eReturnedStatus\x12\x06\n" +
 	"\x02OK\x10\x00\x12\f\n" +
 	"\bPROGRESS\x10\x04\x12\x11\n" +
@@ -0,0 +1,313 @@
+package storage
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	crypto_utils "www.velocidex.com/golang/velociraptor/crypto/utils"
+)
+
+const (
+	// The metadata scheme used for containers encrypted to multiple
+	// recipients.
+	RECIPIENTS_SCHEME = "recipients"
+
+	RECIPIENT_RSA    = "rsa"
+	RECIPIENT_X25519 = "x25519"
+
+	x25519_info = "velociraptor/collection/x25519"
+)
+
+var (
+	NoMatchingRecipient = errors.New("No private key matches any of the recipients")
+)
+
+// A Recipient carries the container session key wrapped for a single
+// public key. Much like age stanzas, a container may carry many
+// recipients and any one of the matching private keys can recover
+// the session key.
+type Recipient struct {
+	Type string `json:"Type"`
+
+	// Hex encoded SHA256 of the DER encoded public key. Used to find
+	// the right recipient without trial decryption.
+	KeyId string `json:"KeyId"`
+
+	// For X509 certificates this is the subject to help the user
+	// identify the key.
+	Name string `json:"Name,omitempty"`
+
+	// Base64 encoded wrapped session key.
+	EncryptedPass string `json:"EncryptedPass"`
+
+	// For X25519 recipients the ephemeral public key (base64).
+	EphemeralKey string `json:"EphemeralKey,omitempty"`
+}
+
+// Parse all public keys in the PEM data. Accepts X509 certificates,
+// PKIX public keys (RSA or X25519) and PKCS1 RSA public keys.
+func ParseRecipientKeys(pem_data []byte) ([]crypto.PublicKey, []string, error) {
+	var keys []crypto.PublicKey
+	var names []string
+
+	for {
+		block, rest := pem.Decode(pem_data)
+		if block == nil {
+			break
+		}
+		pem_data = rest
+
+		switch block.Type {
+		case "CERTIFICATE":
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, nil, err
+			}
+			keys = append(keys, cert.PublicKey)
+			names = append(names, crypto_utils.GetSubjectName(cert))
+
+		case "PUBLIC KEY":
+			key, err := x509.ParsePKIXPublicKey(block.Bytes)
+			if err != nil {
+				return nil, nil, err
+			}
+			keys = append(keys, key)
+			names = append(names, "")
+
+		case "RSA PUBLIC KEY":
+			key, err := x509.ParsePKCS1PublicKey(block.Bytes)
+			if err != nil {
+				return nil, nil, err
+			}
+			keys = append(keys, key)
+			names = append(names, "")
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil, nil, errors.New("No public keys found in PEM data")
+	}
+
+	return keys, names, nil
+}
+
+// Parse all private keys in the PEM data. Accepts PKCS1 RSA keys and
+// PKCS8 RSA or X25519 keys.
+func ParsePrivateKeys(pem_data []byte) ([]crypto.PrivateKey, error) {
+	var keys []crypto.PrivateKey
+
+	for {
+		block, rest := pem.Decode(pem_data)
+		if block == nil {
+			break
+		}
+		pem_data = rest
+
+		switch block.Type {
+		case "RSA PRIVATE KEY":
+			key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, key)
+
+		case "PRIVATE KEY":
+			key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, key)
+		}
+	}
+
+	return keys, nil
+}
+
+func keyId(key crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(der)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// Wrap the session key for each of the public keys in the PEM data.
+func WrapSessionKey(session_key []byte, pem_data []byte) ([]*Recipient, error) {
+	keys, names, err := ParseRecipientKeys(pem_data)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*Recipient, 0, len(keys))
+	for idx, key := range keys {
+		recipient, err := wrapForKey(session_key, key)
+		if err != nil {
+			return nil, err
+		}
+		recipient.Name = names[idx]
+		result = append(result, recipient)
+	}
+
+	return result, nil
+}
+
+func wrapForKey(session_key []byte, key crypto.PublicKey) (*Recipient, error) {
+	id, err := keyId(key)
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := key.(type) {
+	case *rsa.PublicKey:
+		encrypted, err := crypto_utils.EncryptRSAOAEP(session_key, t)
+		if err != nil {
+			return nil, err
+		}
+		return &Recipient{
+			Type:          RECIPIENT_RSA,
+			KeyId:         id,
+			EncryptedPass: base64.StdEncoding.EncodeToString(encrypted),
+		}, nil
+
+	case *ecdh.PublicKey:
+		if t.Curve() != ecdh.X25519() {
+			return nil, errors.New("Only X25519 ECDH recipients are supported")
+		}
+
+		ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+
+		shared, err := ephemeral.ECDH(t)
+		if err != nil {
+			return nil, err
+		}
+
+		aead, err := x25519AEAD(shared, ephemeral.PublicKey(), t)
+		if err != nil {
+			return nil, err
+		}
+
+		// The wrapping key is only ever used once so a zero nonce
+		// is safe.
+		nonce := make([]byte, aead.NonceSize())
+		return &Recipient{
+			Type:  RECIPIENT_X25519,
+			KeyId: id,
+			EncryptedPass: base64.StdEncoding.EncodeToString(
+				aead.Seal(nil, nonce, session_key, nil)),
+			EphemeralKey: base64.StdEncoding.EncodeToString(
+				ephemeral.PublicKey().Bytes()),
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("Unsupported recipient key type %T", key)
+	}
+}
+
+// Derive the wrapping key from the shared secret. Both public keys
+// are mixed into the salt so the wrapped key is bound to this
+// recipient.
+func x25519AEAD(shared []byte,
+	ephemeral *ecdh.PublicKey, recipient *ecdh.PublicKey) (cipher.AEAD, error) {
+
+	salt := append(ephemeral.Bytes(), recipient.Bytes()...)
+	wrapping_key, err := hkdf.Key(sha256.New, shared, salt, x25519_info, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(wrapping_key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Recover the session key using any of the private keys that
+// matches one of the recipients.
+func UnwrapSessionKey(
+	recipients []*Recipient, private_keys []crypto.PrivateKey) ([]byte, error) {
+
+	for _, private_key := range private_keys {
+		signer, ok := private_key.(interface{ Public() crypto.PublicKey })
+		if !ok {
+			continue
+		}
+
+		id, err := keyId(signer.Public())
+		if err != nil {
+			continue
+		}
+
+		for _, recipient := range recipients {
+			if recipient.KeyId != id {
+				continue
+			}
+			return unwrapForKey(recipient, private_key)
+		}
+	}
+
+	return nil, NoMatchingRecipient
+}
+
+func unwrapForKey(recipient *Recipient, key crypto.PrivateKey) ([]byte, error) {
+	encrypted, err := base64.StdEncoding.DecodeString(recipient.EncryptedPass)
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := key.(type) {
+	case *rsa.PrivateKey:
+		if recipient.Type != RECIPIENT_RSA {
+			break
+		}
+		return crypto_utils.DecryptRSAOAEP(t, encrypted)
+
+	case *ecdh.PrivateKey:
+		if recipient.Type != RECIPIENT_X25519 {
+			break
+		}
+
+		ephemeral_bytes, err := base64.StdEncoding.DecodeString(
+			recipient.EphemeralKey)
+		if err != nil {
+			return nil, err
+		}
+
+		ephemeral, err := ecdh.X25519().NewPublicKey(ephemeral_bytes)
+		if err != nil {
+			return nil, err
+		}
+
+		shared, err := t.ECDH(ephemeral)
+		if err != nil {
+			return nil, err
+		}
+
+		aead, err := x25519AEAD(shared, ephemeral, t.PublicKey())
+		if err != nil {
+			return nil, err
+		}
+
+		nonce := make([]byte, aead.NonceSize())
+		return aead.Open(nil, nonce, encrypted, nil)
+	}
+
+	return nil, fmt.Errorf("Recipient type %v does not match key type %T",
+		recipient.Type, key)
+}
@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"testing"
+
+	"www.velocidex.com/golang/velociraptor/vtesting/assert"
+)
+
+func makeRSAKeyPair(t *testing.T) (string, string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	public_der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	assert.NoError(t, err)
+
+	return string(pem.EncodeToMemory(&pem.Block{
+			Type: "PUBLIC KEY", Bytes: public_der})),
+		string(pem.EncodeToMemory(&pem.Block{
+			Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+}
+
+func makeX25519KeyPair(t *testing.T) (string, string) {
+	key, err := ecdh.X25519().GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	public_der, err := x509.MarshalPKIXPublicKey(key.PublicKey())
+	assert.NoError(t, err)
+
+	private_der, err := x509.MarshalPKCS8PrivateKey(key)
+	assert.NoError(t, err)
+
+	return string(pem.EncodeToMemory(&pem.Block{
+			Type: "PUBLIC KEY", Bytes: public_der})),
+		string(pem.EncodeToMemory(&pem.Block{
+			Type: "PRIVATE KEY", Bytes: private_der}))
+}
+
+func TestRecipients(t *testing.T) {
+	rsa_public, rsa_private := makeRSAKeyPair(t)
+	x25519_public, x25519_private := makeX25519KeyPair(t)
+	_, other_private := makeX25519KeyPair(t)
+
+	session_key := []byte("0123456789abcdef0123456789abcdef")
+
+	recipients, err := WrapSessionKey(session_key,
+		[]byte(rsa_public+x25519_public))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(recipients))
+	assert.Equal(t, RECIPIENT_RSA, recipients[0].Type)
+	assert.Equal(t, RECIPIENT_X25519, recipients[1].Type)
+
+	// Each private key on its own can recover the session key.
+	for _, private_pem := range []string{rsa_private, x25519_private} {
+		keys, err := ParsePrivateKeys([]byte(private_pem))
+		assert.NoError(t, err)
+
+		unwrapped, err := UnwrapSessionKey(recipients, keys)
+		assert.NoError(t, err)
+		assert.Equal(t, session_key, unwrapped)
+	}
+
+	// A key which is not a recipient is rejected.
+	keys, err := ParsePrivateKeys([]byte(other_private))
+	assert.NoError(t, err)
+
+	_, err = UnwrapSessionKey(recipients, keys)
+	assert.True(t, errors.Is(err, NoMatchingRecipient))
+
+	// Tampering with the wrapped key is detected.
+	keys, err = ParsePrivateKeys([]byte(x25519_private))
+	assert.NoError(t, err)
+
+	recipients[1].EncryptedPass = recipients[0].EncryptedPass
+	_, err = UnwrapSessionKey(recipients, keys)
+	assert.Error(t, err)
+}
@@ -104,6 +104,12 @@ var (
 		"defaults.disable_unicode_usernames",
 		"Client.panic_file",
 		"GUI.authenticator.saml_allow_idp_initiated",
+		"GUI.authenticator.ldap.start_tls",
+		"GUI.authenticator.ldap.insecure_skip_verify",
+		"GUI.authenticator.ldap.override_acls",
+		"GUI.authenticator.mfa.required",
+		"API.tokens.disabled",
+		"API.tokens.allow_grpc",
 
 		"Client.nanny_max_connection_delay",
 		"Client.prevent_execve",
@@ -193,6 +199,10 @@ func is_set(a reflect.Value) bool {
 		return a.Interface().(float32) != 0
 	}
 
+	if a.Kind() == reflect.Float64 {
+		return a.Interface().(float64) != 0
+	}
+
 	if a.Kind() == reflect.Map {
 		return a.Len() > 0
 	}
@@ -602,6 +602,11 @@ func (self *ClientFlowRunner) FlowStats(
 			s.NamesWithResponse = deobfuscateNames(
 				self.config_obj, s.NamesWithResponse)
 		}
+
+		if len(s.IncrementalSources) > 0 {
+			s.IncrementalSources = deobfuscateNames(
+				self.config_obj, s.IncrementalSources)
+		}
 	}
 
 	// Recompose the flow context from the QueryStats
@@ -832,10 +837,34 @@ func (self *ClientFlowRunner) processAlert(
 		serialized, 1, artifact_paths.ALERT_QUEUE)
 }
 
+// Each query of a profiled collection sends its profile when it is
+// done so we just append them to the flow's profile.
+func (self *ClientFlowRunner) profileMessage(
+	client_id, flow_id string, msg *crypto_proto.LogMessage) error {
+
+	file_store_factory := file_store.GetFileStore(self.config_obj)
+	rs_writer, err := result_sets.NewResultSetWriter(
+		file_store_factory,
+		paths.NewFlowPathManager(client_id, flow_id).Profile(),
+		json.DefaultEncOpts(), self.completer.GetCompletionFunc(),
+		result_sets.AppendMode)
+	if err != nil {
+		return err
+	}
+	defer rs_writer.Close()
+
+	payload := artifacts.DeobfuscateString(self.config_obj, msg.Jsonl)
+	return rs_writer.WriteJSONL([]byte(payload), uint64(msg.NumberOfRows))
+}
+
 func (self *ClientFlowRunner) LogMessage(
 	ctx context.Context, client_id, flow_id, child_flow_id string,
 	msg *crypto_proto.LogMessage) error {
 
+	if msg.Level == logging.PROFILE {
+		return self.profileMessage(client_id, flow_id, msg)
+	}
+
 	flow_path_manager := paths.NewFlowPathManager(client_id, flow_id).Log()
 
 	// Append logs to messages from previous packets.
@@ -23,6 +23,7 @@ import (
 	crypto_client "www.velocidex.com/golang/velociraptor/crypto/client"
 	crypto_proto "www.velocidex.com/golang/velociraptor/crypto/proto"
 	"www.velocidex.com/golang/velociraptor/datastore"
+	"www.velocidex.com/golang/velociraptor/file_store"
 	file_store_api "www.velocidex.com/golang/velociraptor/file_store/api"
 	"www.velocidex.com/golang/velociraptor/file_store/test_utils"
 	"www.velocidex.com/golang/velociraptor/flows"
@@ -693,6 +694,36 @@ func (self *ServerTestSuite) TestLog() {
 	self.RequiredFilestoreContains(path_spec, "ZooBar")
 }
 
+// Test that profile messages are written to the flow's profile and
+// not its logs.
+func (self *ServerTestSuite) TestProfile() {
+	t := self.T()
+
+	flow_id, err := self.createArtifactCollection()
+	require.NoError(t, err)
+
+	runner := flows.NewFlowRunner(self.Ctx, self.ConfigObj)
+	err = runner.ProcessSingleMessage(self.Ctx,
+		&crypto_proto.VeloMessage{
+			Source:    self.client_id,
+			SessionId: flow_id,
+			LogMessage: &crypto_proto.LogMessage{
+				Jsonl:        "{\"Stack\":\"Test;SELECT;glob()\"}\n",
+				NumberOfRows: 1,
+				Level:        logging.PROFILE,
+			},
+		})
+	assert.NoError(self.T(), err)
+	runner.Close(self.Ctx)
+
+	flow_path_manager := paths.NewFlowPathManager(self.client_id, flow_id)
+	self.RequiredFilestoreContains(flow_path_manager.Profile(), "glob()")
+
+	file_store_factory := file_store.GetFileStore(self.ConfigObj)
+	_, err = file_store_factory.StatFile(flow_path_manager.Log())
+	assert.Error(t, err)
+}
+
 func (self *ServerTestSuite) TestScheduleCollection() {
 	t := self.T()
 	request := &flows_proto.ArtifactCollectorArgs{
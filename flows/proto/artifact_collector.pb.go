@@ -267,6 +267,16 @@ type ArtifactCollectorArgs struct {
 	// when exceeded. This might result is a partial file upload. It
 	// is possible to exceed the limit a bit.
 	MaxUploadBytes uint64 `protobuf:"varint,23,opt,name=max_upload_bytes,json=maxUploadBytes,proto3" json:"max_upload_bytes,omitempty"`
+	// Run the collection on the client without uploading any files or
+	// running side effecting plugins (execve, write_file, rm, copy,
+	// upload_* etc). The flow stats then estimate the cost of the
+	// real collection. Only clients from 0.77.2 support this.
+	DryRun bool `protobuf:"varint,34,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
+	// Only send rows which changed since the last collection of the
+	// same artifacts from this client. The server fills in the flow
+	// id of the collection the client should compare against.
+	Incremental     bool   `protobuf:"varint,35,opt,name=incremental,proto3" json:"incremental,omitempty"`
+	IncrementalBase string `protobuf:"bytes,36,opt,name=incremental_base,json=incrementalBase,proto3" json:"incremental_base,omitempty"`
 	// Request a trace of the collection on the endpoint, will upload
 	// a snapshot every trace seconds.
 	TraceFreqSec         uint64 `protobuf:"varint,29,opt,name=trace_freq_sec,json=traceFreqSec,proto3" json:"trace_freq_sec,omitempty"`
@@ -418,6 +428,27 @@ func (x *ArtifactCollectorArgs) GetMaxUploadBytes() uint64 {
 	return 0
 }
 
+func (x *ArtifactCollectorArgs) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+func (x *ArtifactCollectorArgs) GetIncremental() bool {
+	if x != nil {
+		return x.Incremental
+	}
+	return false
+}
+
+func (x *ArtifactCollectorArgs) GetIncrementalBase() string {
+	if x != nil {
+		return x.IncrementalBase
+	}
+	return ""
+}
+
 func (x *ArtifactCollectorArgs) GetTraceFreqSec() uint64 {
 	if x != nil {
 		return x.TraceFreqSec
@@ -668,9 +699,12 @@ type ArtifactCollectorContext struct {
 	TotalUploadedBytes         uint64 `protobuf:"varint,26,opt,name=total_uploaded_bytes,json=totalUploadedBytes,proto3" json:"total_uploaded_bytes,omitempty"`
 	TotalCollectedRows         uint64 `protobuf:"varint,28,opt,name=total_collected_rows,json=totalCollectedRows,proto3" json:"total_collected_rows,omitempty"`
 	TotalLogs                  uint64 `protobuf:"varint,32,opt,name=total_logs,json=totalLogs,proto3" json:"total_logs,omitempty"`
-	TotalRequests              int64  `protobuf:"varint,35,opt,name=total_requests,json=totalRequests,proto3" json:"total_requests,omitempty"`
-	OutstandingRequests        int64  `protobuf:"varint,31,opt,name=outstanding_requests,json=outstandingRequests,proto3" json:"outstanding_requests,omitempty"`
-	TransactionsOutstanding    uint64 `protobuf:"varint,37,opt,name=transactions_outstanding,json=transactionsOutstanding,proto3" json:"transactions_outstanding,omitempty"`
+	// For dry run collections, the number of files the collection
+	// would have uploaded, written or removed.
+	TotalFilesTouched       uint64 `protobuf:"varint,39,opt,name=total_files_touched,json=totalFilesTouched,proto3" json:"total_files_touched,omitempty"`
+	TotalRequests           int64  `protobuf:"varint,35,opt,name=total_requests,json=totalRequests,proto3" json:"total_requests,omitempty"`
+	OutstandingRequests     int64  `protobuf:"varint,31,opt,name=outstanding_requests,json=outstandingRequests,proto3" json:"outstanding_requests,omitempty"`
+	TransactionsOutstanding uint64 `protobuf:"varint,37,opt,name=transactions_outstanding,json=transactionsOutstanding,proto3" json:"transactions_outstanding,omitempty"`
 	// We expect the next response from the client to have this id.
 	NextResponseId uint64 `protobuf:"varint,30,opt,name=next_response_id,json=nextResponseId,proto3" json:"next_response_id,omitempty"`
 	// Total time the query took to run on the client (reported by the
@@ -825,6 +859,13 @@ func (x *ArtifactCollectorContext) GetTotalLogs() uint64 {
 	return 0
 }
 
+func (x *ArtifactCollectorContext) GetTotalFilesTouched() uint64 {
+	if x != nil {
+		return x.TotalFilesTouched
+	}
+	return 0
+}
+
 func (x *ArtifactCollectorContext) GetTotalRequests() int64 {
 	if x != nil {
 		return x.TotalRequests
@@ -1170,7 +1211,7 @@ const file_artifact_collector_proto_rawDesc = "" +
 	"\x0emax_batch_wait\x18\a \x01(\x04R\fmaxBatchWait\x12$\n" +
 	"\x0emax_batch_rows\x18\b \x01(\x04R\fmaxBatchRows\x121\n" +
 	"\x15max_batch_rows_buffer\x18\t \x01(\x04R\x12maxBatchRowsBuffer\x12\x18\n" +
-	"\atimeout\x18\v \x01(\x04R\atimeout\"\xf0\a\n" +
+	"\atimeout\x18\v \x01(\x04R\atimeout\"\xd6\b\n" +
 	"\x15ArtifactCollectorArgs\x12\x18\n" +
 	"\acreator\x18\x01 \x01(\tR\acreator\x12\x1b\n" +
 	"\tuser_data\x18\x1e \x01(\tR\buserData\x12\x1b\n" +
@@ -1187,7 +1228,10 @@ const file_artifact_collector_proto_rawDesc = "" +
 	"\atimeout\x18\a \x01(\x04BK\xe2\xfc\xe3\xc4\x01E\x125Number of seconds to run before cancelling the query.\"\aTimeout2\x03600R\atimeout\x12\x19\n" +
 	"\bmax_rows\x18\x16 \x01(\x04R\amaxRows\x12\x19\n" +
 	"\bmax_logs\x18  \x01(\x04R\amaxLogs\x12(\n" +
-	"\x10max_upload_bytes\x18\x17 \x01(\x04R\x0emaxUploadBytes\x12$\n" +
+	"\x10max_upload_bytes\x18\x17 \x01(\x04R\x0emaxUploadBytes\x12\x17\n" +
+	"\adry_run\x18\" \x01(\bR\x06dryRun\x12 \n" +
+	"\vincremental\x18# \x01(\bR\vincremental\x12)\n" +
+	"\x10incremental_base\x18$ \x01(\tR\x0fincrementalBase\x12$\n" +
 	"\x0etrace_freq_sec\x18\x1d \x01(\x04R\ftraceFreqSec\x12\x8d\x01\n" +
 	"\x16allow_custom_overrides\x18\b \x01(\bBW\xe2\xfc\xe3\xc4\x01Q\x12OIf true we will use a custom artifact if present instead of the named artifact.R\x14allowCustomOverrides\x12$\n" +
 	"\x0elog_batch_time\x18\x1c \x01(\x04R\flogBatchTime\x12O\n" +
@@ -1208,7 +1252,7 @@ const file_artifact_collector_proto_rawDesc = "" +
 	"\x04type\x18\x06 \x01(\tR\x04type\".\n" +
 	"\vPingContext\x12\x1f\n" +
 	"\vactive_time\x18\x01 \x01(\x04R\n" +
-	"activeTime\"\xf9\v\n" +
+	"activeTime\"\xa9\f\n" +
 	"\x18ArtifactCollectorContext\x12\x1b\n" +
 	"\tclient_id\x18\x1b \x01(\tR\bclientId\x12\x1d\n" +
 	"\n" +
@@ -1228,7 +1272,8 @@ const file_artifact_collector_proto_rawDesc = "" +
 	"\x14total_uploaded_bytes\x18\x1a \x01(\x04R\x12totalUploadedBytes\x120\n" +
 	"\x14total_collected_rows\x18\x1c \x01(\x04R\x12totalCollectedRows\x12\x1d\n" +
 	"\n" +
-	"total_logs\x18  \x01(\x04R\ttotalLogs\x12%\n" +
+	"total_logs\x18  \x01(\x04R\ttotalLogs\x12.\n" +
+	"\x13total_files_touched\x18' \x01(\x04R\x11totalFilesTouched\x12%\n" +
 	"\x0etotal_requests\x18# \x01(\x03R\rtotalRequests\x121\n" +
 	"\x14outstanding_requests\x18\x1f \x01(\x03R\x13outstandingRequests\x129\n" +
 	"\x18transactions_outstanding\x18% \x01(\x04R\x17transactionsOutstanding\x12(\n" +
@@ -102,6 +102,8 @@ export default class FlowOverview extends React.Component {
                         <dt className="col-4">{T("Flow ID")}</dt>
                         <dd className="col-8">
                           { flow.session_id } { flow.request.urgent && "( " + T("Urgent") + " )" }
+                          { flow.request.dry_run && " ( " + T("Dry Run") + " )" }
+                          { flow.request.incremental && " ( " + T("Incremental") + " )" }
                         </dd>
 
                         <dt className="col-4">{T("Creator")}</dt>
@@ -207,6 +209,14 @@ export default class FlowOverview extends React.Component {
                         <dd className="col-8">
                           {uploaded_files.length || flow.total_uploaded_files || 0 }
                         </dd>
+                        { flow.request.dry_run &&
+                          <>
+                            <dt className="col-4">{T("Files Touched")}</dt>
+                            <dd className="col-8">
+                              { flow.total_files_touched || 0 }
+                            </dd>
+                          </>
+                        }
                         { flow.transactions_outstanding &&
                           <>
                             <dt className="col-4">{T("Transactions")}</dt>
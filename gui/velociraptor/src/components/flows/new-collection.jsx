@@ -679,6 +679,34 @@ class NewCollectionResources extends React.Component {
                     </Col>
                   </Form.Group>
 
+                  <Form.Group as={Row}>
+                    <Form.Label column sm="3">
+                      {T("Dry Run")}
+                    </Form.Label>
+                    <Col sm="8">
+                      <Form.Check
+                        type="checkbox"
+                        checked={resources.dry_run || false}
+                        label={T("Only estimate the cost without uploading files or running commands")}
+                        onChange={e=>this.props.setResources({dry_run: e.currentTarget.checked})}
+                      />
+                    </Col>
+                  </Form.Group>
+
+                  <Form.Group as={Row}>
+                    <Form.Label column sm="3">
+                      {T("Incremental")}
+                    </Form.Label>
+                    <Col sm="8">
+                      <Form.Check
+                        type="checkbox"
+                        checked={resources.incremental || false}
+                        label={T("Only send rows changed since the last incremental collection")}
+                        onChange={e=>this.props.setResources({incremental: e.currentTarget.checked})}
+                      />
+                    </Col>
+                  </Form.Group>
+
                 </Form>
               </Modal.Body>
               <Modal.Footer>
@@ -990,6 +1018,8 @@ class NewCollectionWizard extends React.Component {
         };
 
         result.urgent = this.state.resources.urgent;
+        result.dry_run = this.state.resources.dry_run;
+        result.incremental = this.state.resources.incremental;
 
         if (this.state.resources.ops_per_second) {
             result.ops_per_second = this.state.resources.ops_per_second;
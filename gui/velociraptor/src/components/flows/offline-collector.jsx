@@ -102,7 +102,7 @@ class OfflineCollectorParameters  extends React.Component {
                      <Col sm="8">
                        <Form.Control
                          as="textarea"
-                         placeholder={T("Public Key/Certificate To Encrypt With. If empty, defaults To Frontend Cert")}
+                         placeholder={T("Public Key/Certificate To Encrypt With. If empty, defaults To Frontend Cert. Paste several keys to encrypt for multiple recipients")}
                          spellCheck="false"
                          value={this.props.parameters.encryption_args.public_key}
                          onChange={e => {
@@ -412,6 +412,22 @@ class OfflineCollectorParameters  extends React.Component {
                           </Form.Control>
                         </Col>
                       </Form.Group>
+                      <Form.Group as={Row}>
+                        <Form.Label column sm="3">{T("Stream Upload")}</Form.Label>
+                        <Col sm="8">
+                          <Form.Control
+                            as="select"
+                            value={this.props.parameters.target_args.streaming}
+                            onChange={(e) => {
+                                this.props.parameters.target_args.streaming = e.target.value;
+                                this.props.setParameters(this.props.parameters);
+                            }}
+                          >
+                            <option value="N">{T("N (write a local zip then upload)")}</option>
+                            <option value="Y">{T("Y (upload while collecting, no local copy)")}</option>
+                          </Form.Control>
+                        </Col>
+                      </Form.Group>
                     </>
                   }
 
@@ -496,6 +512,22 @@ class OfflineCollectorParameters  extends React.Component {
                           />
                         </Col>
                       </Form.Group>
+                      <Form.Group as={Row}>
+                        <Form.Label column sm="3">{T("Stream Upload")}</Form.Label>
+                        <Col sm="8">
+                          <Form.Control
+                            as="select"
+                            value={this.props.parameters.target_args.streaming}
+                            onChange={(e) => {
+                                this.props.parameters.target_args.streaming = e.target.value;
+                                this.props.setParameters(this.props.parameters);
+                            }}
+                          >
+                            <option value="N">{T("N (write a local zip then upload)")}</option>
+                            <option value="Y">{T("Y (upload while collecting, no local copy)")}</option>
+                          </Form.Control>
+                        </Col>
+                      </Form.Group>
                     </>
                   }
 
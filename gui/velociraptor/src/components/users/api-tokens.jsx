@@ -0,0 +1,198 @@
+import _ from 'lodash';
+import React from 'react';
+import PropTypes from 'prop-types';
+import Button from 'react-bootstrap/Button';
+import Form from 'react-bootstrap/Form';
+import Col from 'react-bootstrap/Col';
+import Row from 'react-bootstrap/Row';
+import Table from 'react-bootstrap/Table';
+import Accordion from 'react-bootstrap/Accordion';
+import InputGroup from 'react-bootstrap/InputGroup';
+import Select from 'react-select';
+import { FontAwesomeIcon } from '@fortawesome/react-fontawesome';
+import {CancelToken} from 'axios';
+
+import api from '../core/api-service.jsx';
+import T from '../i8n/i8n.jsx';
+import ToolTip from '../widgets/tooltip.jsx';
+import VeloTimestamp from "../utils/time.jsx";
+
+
+// Allows the user to mint and revoke their own API tokens.
+export default class ApiTokensForm extends React.Component {
+    static propTypes = {
+        username: PropTypes.string,
+    }
+
+    state = {
+        tokens: [],
+        available_permissions: [],
+        name: "",
+        permissions: [],
+        expiry_days: "",
+        secret: "",
+    }
+
+    componentDidMount() {
+        this.source = CancelToken.source();
+        this.fetchTokens();
+        this.fetchPermissions();
+    }
+
+    componentWillUnmount() {
+        this.source.cancel("unmounted");
+    }
+
+    fetchTokens = ()=>{
+        api.get("v1/ListApiTokens", {
+            username: this.props.username,
+        }, this.source.token).then(response=>{
+            if (response.cancel)
+                return;
+            this.setState({tokens: response.data.items || []});
+        });
+    }
+
+    // Tokens can only carry permissions the user already has.
+    fetchPermissions = ()=>{
+        api.get("v1/GetUserRoles", {
+            name: this.props.username,
+            org: window.globals.OrgId || "root",
+        }, this.source.token).then(response=>{
+            if (response.cancel)
+                return;
+            this.setState({
+                available_permissions: response.data.effective_permissions || []});
+        });
+    }
+
+    createToken = ()=>{
+        let expiry = parseInt(this.state.expiry_days) || 0;
+        api.post("v1/CreateApiToken", {
+            name: this.state.name,
+            permissions: this.state.permissions,
+            expiry: expiry * 24 * 60 * 60,
+        }, this.source.token).then(response=>{
+            if (response.cancel)
+                return;
+            this.setState({secret: response.data.secret,
+                           name: "", permissions: [], expiry_days: ""});
+            this.fetchTokens();
+        });
+    }
+
+    revokeToken = token_id=>{
+        api.post("v1/RevokeApiToken", {
+            username: this.props.username,
+            token_id: token_id,
+        }, this.source.token).then(response=>{
+            if (response.cancel)
+                return;
+            this.fetchTokens();
+        });
+    }
+
+    renderTokens = ()=>{
+        if (_.isEmpty(this.state.tokens)) {
+            return <></>;
+        }
+
+        return (
+            <Table size="sm">
+              <thead>
+                <tr>
+                  <th>{T("Name")}</th>
+                  <th>{T("Permissions")}</th>
+                  <th>{T("Expires")}</th>
+                  <th>{T("Last Used")}</th>
+                  <th></th>
+                </tr>
+              </thead>
+              <tbody>
+                { _.map(this.state.tokens, t=>(
+                    <tr key={t.token_id}>
+                      <td>{t.name}</td>
+                      <td>{_.join(t.permissions, ", ")}</td>
+                      <td><VeloTimestamp usec={parseInt(t.expires)}/></td>
+                      <td>
+                        { parseInt(t.last_used) > 0 &&
+                          <ToolTip tooltip={t.last_used_from}>
+                            <span>
+                              <VeloTimestamp usec={parseInt(t.last_used)}/>
+                            </span>
+                          </ToolTip> }
+                      </td>
+                      <td>
+                        <ToolTip tooltip={T("Revoke token")}>
+                          <Button variant="default" size="sm"
+                                  onClick={()=>this.revokeToken(t.token_id)}>
+                            <FontAwesomeIcon icon="trash"/>
+                          </Button>
+                        </ToolTip>
+                      </td>
+                    </tr>
+                ))}
+              </tbody>
+            </Table>
+        );
+    }
+
+    render() {
+        let options = _.map(this.state.available_permissions, x=>{
+            return {value: x, label: x, isFixed: true, color: "#00B8D9"};
+        });
+
+        return (
+            <Form.Group as={Row}>
+              <Form.Label column sm="3">
+                <ToolTip tooltip={T("Tokens allow scripts to call the API with a subset of your permissions")}>
+                  <div>{T("API Tokens")}</div>
+                </ToolTip>
+              </Form.Label>
+              <Col sm="8">
+                <Accordion>
+                  <Accordion.Item eventKey="0">
+                    <Accordion.Header>
+                      {T("Manage API Tokens")}
+                    </Accordion.Header>
+                    <Accordion.Body>
+                      { this.renderTokens() }
+                      { this.state.secret &&
+                        <InputGroup className="mb-3">
+                          <ToolTip tooltip={T("Copy the token now. It will not be shown again.")}>
+                            <Form.Control readOnly value={this.state.secret}
+                                          spellCheck="false"/>
+                          </ToolTip>
+                        </InputGroup> }
+                      <Form.Control value={this.state.name}
+                                    placeholder={T("Token name")}
+                                    spellCheck="false"
+                                    onChange={e=>this.setState({
+                                        name: e.currentTarget.value})}/>
+                      <Select
+                        isMulti
+                        classNamePrefix="velo"
+                        placeholder={T("Permissions")}
+                        value={_.filter(options, x=>_.includes(
+                            this.state.permissions, x.value))}
+                        onChange={e=>this.setState({
+                            permissions: _.map(e, x=>x.value)})}
+                        options={options}/>
+                      <Form.Control value={this.state.expiry_days}
+                                    placeholder={T("Expiry (days)")}
+                                    onChange={e=>this.setState({
+                                        expiry_days: e.currentTarget.value})}/>
+                      <Button variant="default" size="sm"
+                              disabled={!this.state.name ||
+                                        _.isEmpty(this.state.permissions)}
+                              onClick={this.createToken}>
+                        {T("Create Token")}
+                      </Button>
+                    </Accordion.Body>
+                  </Accordion.Item>
+                </Accordion>
+              </Col>
+            </Form.Group>
+        );
+    }
+}
@@ -25,6 +25,7 @@ import Select from 'react-select';
 import ToolTip from '../widgets/tooltip.jsx';
 
 import { JSONparse } from '../utils/json_parse.jsx';
+import ApiTokensForm from './api-tokens.jsx';
 
 class _PasswordChange extends React.Component {
     static propTypes = {
@@ -260,6 +261,8 @@ class UserSettingsDialog extends React.PureComponent {
                     onClose={this.props.onClose}
                     >
                   </PasswordChangeForm> }
+                <ApiTokensForm
+                  username={this.context.traits.username}/>
                 <Form.Group as={Row}>
                   <Form.Label column sm="3">
                     {T("Theme")}
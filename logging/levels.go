@@ -12,4 +12,8 @@ const (
 	// An alert is a special type of log message which is routed by
 	// the server into the alert queue.
 	ALERT = "ALERT"
+
+	// A profile message carries the JSONL rows of a query profile
+	// which the server stores with the flow instead of the logs.
+	PROFILE = "PROFILE"
 )
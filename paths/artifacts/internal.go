@@ -264,6 +264,15 @@ var (
 		EventFilter:  ServerOnlyFilter,
 	}
 
+	// Records every webhook delivery attempt.
+	WEBHOOK_DELIVERIES = services.JournalOptions{
+		ArtifactName: "Server.Internal.WebhookDeliveries",
+		ArtifactType: artifact_modes.MODE_SERVER_EVENT,
+		ClientId:     constants.VELOCIRAPTOR_SERVER_CLIENT_ID,
+		Username:     constants.VELOCIRAPTOR_SERVER_CLIENT_ID,
+		EventFilter:  ServerOnlyFilter,
+	}
+
 	// All well known queues.
 	WELL_KNOWN_QUEUES = []services.JournalOptions{
 		ALERT_QUEUE, ARTIFACT_MODIFICATION, LABEL_QUEUE,
@@ -275,7 +284,7 @@ var (
 		CLIENT_DELETE_QUEUE, CLIENT_METADATA_MODIFICATION,
 		CLIENT_INFO_SNAPSHOT_READY, CLIENT_INFO_TASK,
 		CLIENT_INFO_SCHEDULED, INVENTORY_UPDATED,
-		MASTER_REGISTRATIONS, USER_MANAGER,
+		MASTER_REGISTRATIONS, USER_MANAGER, WEBHOOK_DELIVERIES,
 	}
 
 	WELL_KNOWN_QUEUES_MAP = make(map[string]services.JournalOptions)
@@ -70,6 +70,14 @@ func (self FlowPathManager) Log() api.FSPathSpec {
 		SetType(api.PATH_TYPE_FILESTORE_JSON)
 }
 
+// Gets the flow's VQL profile.
+func (self FlowPathManager) Profile() api.FSPathSpec {
+	return self.Path().AddChild("profile").
+		AsFilestorePath().
+		SetTag("Profile").
+		SetType(api.PATH_TYPE_FILESTORE_JSON)
+}
+
 func (self FlowPathManager) LogLegacy() api.FSPathSpec {
 	return self.Path().AddChild("logs").
 		AsFilestorePath().
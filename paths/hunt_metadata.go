@@ -85,3 +85,17 @@ func (self HuntPathManager) ClientErrors() api.FSPathSpec {
 	return HUNTS_ROOT.AddChild(self.hunt_id + "_errors").
 		AsFilestorePath()
 }
+
+// The stacking index for a column of an artifact source collected
+// in the hunt.
+func (self HuntPathManager) Stack(artifact, column string) api.FSPathSpec {
+	return HUNTS_ROOT.AddChild(self.hunt_id, "stack", artifact, column).
+		AsFilestorePath()
+}
+
+// The values each client contributed to the stacking index.
+func (self HuntPathManager) StackClient(
+	artifact, column, client_id string) api.FSPathSpec {
+	return HUNTS_ROOT.AddChild(self.hunt_id, "stack_clients",
+		artifact, column, client_id).AsFilestorePath()
+}
@@ -0,0 +1,14 @@
+package paths
+
+import "www.velocidex.com/golang/velociraptor/file_store/api"
+
+// SCIM attributes of provisioned users.
+type SCIMPathManager struct{}
+
+func (self SCIMPathManager) UsersDir() api.DSPathSpec {
+	return CONFIG_ROOT.AddUnsafeChild("scim", "users")
+}
+
+func (self SCIMPathManager) User(username string) api.DSPathSpec {
+	return self.UsersDir().AddChild(username)
+}
@@ -0,0 +1,21 @@
+package paths
+
+import "www.velocidex.com/golang/velociraptor/file_store/api"
+
+type WebhooksPathManager struct{}
+
+func (self WebhooksPathManager) WebhooksDir() api.DSPathSpec {
+	return CONFIG_ROOT.AddUnsafeChild("webhooks")
+}
+
+func (self WebhooksPathManager) Webhook(name string) api.DSPathSpec {
+	return self.WebhooksDir().AddChild(name)
+}
+
+// Deliveries waiting to be sent to the webhook are kept on disk so
+// they survive a restart.
+func (self WebhooksPathManager) Queue(name string) api.FSPathSpec {
+	return CONFIG_ROOT.AsFilestorePath().
+		AddUnsafeChild("webhook_queues", name).
+		SetType(api.PATH_TYPE_FILESTORE_ANY)
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/olekukonko/tablewriter"
 	"www.velocidex.com/golang/velociraptor/utils"
+	vql_profiler "www.velocidex.com/golang/velociraptor/vql/profiler"
 	"www.velocidex.com/golang/vfilter"
 )
 
@@ -16,7 +17,7 @@ func EvalQueryToTable(ctx context.Context,
 	vql *vfilter.VQL,
 	out io.Writer) *tablewriter.Table {
 
-	output_chan := vql.Eval(ctx, scope)
+	output_chan := vql_profiler.Eval(ctx, scope, vql)
 	table := tablewriter.NewWriter(out)
 
 	columns := []string{}
@@ -33,6 +33,7 @@ import (
 	"www.velocidex.com/golang/velociraptor/services"
 	"www.velocidex.com/golang/velociraptor/utils"
 	vql_subsystem "www.velocidex.com/golang/velociraptor/vql"
+	vql_profiler "www.velocidex.com/golang/velociraptor/vql/profiler"
 	"www.velocidex.com/golang/vfilter"
 	"www.velocidex.com/golang/vfilter/types"
 )
@@ -707,7 +708,7 @@ func (self *GuiTemplateEngine) RunQuery(vql *vfilter.VQL,
 
 	// Ignore LET queries but still run them.
 	if vql.Let != "" {
-		for range vql.Eval(self.ctx, self.Scope) {
+		for range vql_profiler.Eval(self.ctx, self.Scope, vql) {
 		}
 		return result, nil
 	}
@@ -736,7 +737,7 @@ func (self *GuiTemplateEngine) RunQuery(vql *vfilter.VQL,
 
 	row_idx := 0
 	next_progress := time.Now().Add(4 * time.Second)
-	eval_chan := vql.Eval(self.ctx, self.Scope)
+	eval_chan := vql_profiler.Eval(self.ctx, self.Scope, vql)
 
 	if self.Progress != nil {
 		defer self.Progress.Report("Completed query")
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -385,6 +386,23 @@ func (self *FlowContext) sendAlertMessage(
 		}}
 }
 
+// Profile messages are sent immediately and are not subject to the
+// log limits. The server stores them in the flow's profile.
+func (self *FlowContext) sendProfileMessage(
+	ctx context.Context,
+	// msg contains the profile rows as JSONL
+	msg string) {
+
+	self.output <- &crypto_proto.VeloMessage{
+		SessionId: self.flow_id,
+		RequestId: constants.LOG_SINK,
+		LogMessage: &crypto_proto.LogMessage{
+			NumberOfRows: uint64(strings.Count(msg, "\n")),
+			Jsonl:        msg,
+			Level:        logging.PROFILE,
+		}}
+}
+
 func (self *FlowContext) AddLogMessage(
 	ctx context.Context, level string, msg string) {
 	if level == logging.ALERT {
@@ -392,6 +410,11 @@ func (self *FlowContext) AddLogMessage(
 		return
 	}
 
+	if level == logging.PROFILE {
+		self.sendProfileMessage(ctx, msg)
+		return
+	}
+
 	self.mu.Lock()
 	defer self.mu.Unlock()
 
@@ -507,7 +530,9 @@ func (self *FlowContext) GetStatsDicts() *ordereddict.Dict {
 			Set("LogRows", status.LogRows).
 			Set("UploadedFiles", status.UploadedFiles).
 			Set("UploadedBytes", status.UploadedBytes).
-			Set("ExpectedUploadedBytes", status.ExpectedUploadedBytes))
+			Set("ExpectedUploadedBytes", status.ExpectedUploadedBytes).
+			Set("FilesTouched", status.FilesTouched).
+			Set("IncrementalSources", status.IncrementalSources))
 	}
 
 	return ordereddict.NewDict().
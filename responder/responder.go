@@ -104,6 +104,27 @@ func (self *FlowResponder) SetStatus(s *crypto_proto.VeloStatus) {
 	self.status = proto.Clone(s).(*crypto_proto.VeloStatus)
 }
 
+// In dry run mode nothing is sent to the server so we account for
+// what the query would have uploaded directly in the status.
+func (self *FlowResponder) ChargeDryRun(
+	uploaded_files, expected_bytes, files_touched int64) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	self.status.UploadedFiles += uploaded_files
+	self.status.ExpectedUploadedBytes += expected_bytes
+	self.status.FilesTouched += files_touched
+}
+
+// Mark the results of this source as the rows which changed since the
+// incremental base flow.
+func (self *FlowResponder) AddIncrementalSource(name string) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	self.status.IncrementalSources = append(self.status.IncrementalSources, name)
+}
+
 func (self *FlowResponder) Close() {
 	self.cancel()
 	self.wg.Done()
@@ -351,6 +372,13 @@ func (self *FlowResponder) Return(ctx context.Context) {
 // right away, but queue it locally and combine with other log
 // messages for self.flushLogMessages() to send.
 func (self *FlowResponder) Log(ctx context.Context, level string, msg string) {
+	// Profiles are not log messages so they are not counted or
+	// checked for errors.
+	if level == logging.PROFILE {
+		self.flow_context.AddLogMessage(ctx, level, msg)
+		return
+	}
+
 	// If the log message looks like an error then mark it as an
 	// error.
 	if level != logging.ERROR &&
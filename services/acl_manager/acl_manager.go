@@ -169,6 +169,12 @@ func (self *ACLManager) GetEffectivePolicy(
 		return &acl_proto.ApiClientACL{SuperUser: true}, nil
 	}
 
+	// API tokens carry a subset of their owner's permissions.
+	username, _, ok := services.ParseAPITokenPrincipal(principal)
+	if ok {
+		return self.getAPITokenPolicy(config_obj, principal, username)
+	}
+
 	policy, err := self.GetPolicy(config_obj, principal)
 	if err != nil {
 		return nil, err
@@ -194,6 +200,34 @@ func (self *ACLManager) SetPolicy(
 	config_obj *config_proto.Config,
 	principal string, acl_obj *acl_proto.ApiClientACL) error {
 
+	removed, err := self.setPolicy(config_obj, principal, acl_obj)
+	if err != nil {
+		return err
+	}
+
+	// Removing roles ends the user's GUI sessions so the change
+	// takes effect immediately.
+	users_manager := services.GetUserManager()
+	if len(removed) > 0 && users_manager != nil {
+		err = users_manager.RevokeGUISession(context.Background(),
+			utils.GetSuperuserName(config_obj), principal, "",
+			fmt.Sprintf("Roles removed in org %v: %v",
+				utils.NormalizedOrgId(config_obj.OrgId), removed))
+		if err != nil && !errors.Is(err, utils.NotFoundError) {
+			logger := logging.GetLogger(config_obj, &logging.FrontendComponent)
+			logger.Error("ACLManager: Revoking GUI sessions of %v: %v",
+				principal, err)
+		}
+	}
+
+	return nil
+}
+
+// Returns the roles removed from the principal.
+func (self *ACLManager) setPolicy(
+	config_obj *config_proto.Config,
+	principal string, acl_obj *acl_proto.ApiClientACL) ([]string, error) {
+
 	self.mu.Lock()
 	defer self.mu.Unlock()
 
@@ -206,11 +240,34 @@ func (self *ACLManager) SetPolicy(
 		acl_obj.Roles = utils.FilterSlice(acl_obj.Roles, "org_admin")
 	}
 
+	db, err := datastore.GetDB(config_obj)
+	if err != nil {
+		return nil, err
+	}
+
 	// Normalize the username casing.
 	lower_user_name := utils.ToLower(principal)
 	cache, pres := self.cache[lower_user_name]
+	old_policy := &acl_proto.ApiClientACL{}
 	if pres {
 		principal = cache.username
+		old_policy = cache.policy
+
+	} else {
+		// The cache may not have caught up with the stored policy
+		// yet (e.g. it was written by another frontend).
+		err1 := db.GetSubject(config_obj,
+			paths.UserPathManager{Name: principal}.ACL(), old_policy)
+		if err1 != nil {
+			old_policy = &acl_proto.ApiClientACL{}
+		}
+	}
+
+	var removed []string
+	for _, role := range old_policy.Roles {
+		if !utils.InString(acl_obj.Roles, role) {
+			removed = append(removed, role)
+		}
 	}
 
 	self.cache[lower_user_name] = &_CachedACLObject{
@@ -218,14 +275,9 @@ func (self *ACLManager) SetPolicy(
 		username: principal,
 	}
 
-	db, err := datastore.GetDB(config_obj)
-	if err != nil {
-		return err
-	}
-
 	// Store the ACL with the original user casing.
 	user_path_manager := paths.UserPathManager{Name: principal}
-	return db.SetSubject(config_obj, user_path_manager.ACL(), acl_obj)
+	return removed, db.SetSubject(config_obj, user_path_manager.ACL(), acl_obj)
 }
 
 func (self *ACLManager) handleLockdown(
@@ -0,0 +1,53 @@
+package acl_manager
+
+import (
+	"context"
+	"fmt"
+
+	"www.velocidex.com/golang/velociraptor/acls"
+	acl_proto "www.velocidex.com/golang/velociraptor/acls/proto"
+	config_proto "www.velocidex.com/golang/velociraptor/config/proto"
+	"www.velocidex.com/golang/velociraptor/services"
+	"www.velocidex.com/golang/velociraptor/utils"
+)
+
+// The token's policy is the intersection of the token's permissions
+// and the owner's current policy in this org. This way removing
+// permissions from the owner also removes them from their tokens.
+func (self *ACLManager) getAPITokenPolicy(
+	config_obj *config_proto.Config,
+	principal, username string) (*acl_proto.ApiClientACL, error) {
+
+	users_manager := services.GetUserManager()
+	if users_manager == nil {
+		return nil, utils.NotFoundError
+	}
+
+	token, err := users_manager.GetAPIToken(context.Background(), principal)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", utils.NotFoundError, err)
+	}
+
+	result := &acl_proto.ApiClientACL{}
+	if !utils.OrgIdInList(config_obj.OrgId, token.Orgs) {
+		return result, nil
+	}
+
+	owner_policy, err := self.GetEffectivePolicy(config_obj, username)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, perm := range token.Permissions {
+		ok, _ := services.CheckAccessWithToken(
+			owner_policy, acls.GetPermission(perm))
+		if ok {
+			err := acls.SetTokenPermission(result, perm)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return result, nil
+}
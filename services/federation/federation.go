@@ -0,0 +1,426 @@
+// The federation service forwards selected server events from a
+// regional deployment to a central Velociraptor server.
+//
+// Events are pushed with the central server's PushEvents API using an
+// API client certificate issued by the central server. While the
+// central server is unreachable, events are buffered on disk and
+// delivered in order once the link comes back.
+//
+// Only the root org is forwarded by default. Other orgs must be
+// mapped to an org on the central server so events from different
+// orgs are not mixed together.
+package federation
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Velocidex/ordereddict"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	api_proto "www.velocidex.com/golang/velociraptor/api/proto"
+	config_proto "www.velocidex.com/golang/velociraptor/config/proto"
+	"www.velocidex.com/golang/velociraptor/grpc_client"
+	"www.velocidex.com/golang/velociraptor/json"
+	"www.velocidex.com/golang/velociraptor/logging"
+	"www.velocidex.com/golang/velociraptor/paths/artifacts"
+	"www.velocidex.com/golang/velociraptor/services"
+	"www.velocidex.com/golang/velociraptor/services/journal"
+	"www.velocidex.com/golang/velociraptor/utils"
+	utils_tempfile "www.velocidex.com/golang/velociraptor/utils/tempfile"
+)
+
+const (
+	FLOW_COMPLETION_ARTIFACT = "Server.Federation.FlowCompletion"
+
+	defaultMaxBufferSize = 100 * 1024 * 1024
+	sendTimeout          = 30 * time.Second
+)
+
+var (
+	// How long to wait before pushing again when the central server
+	// is unreachable.
+	retryDelay = 5 * time.Second
+
+	// Builds the function which pushes to the central server.
+	newPusher = newCentralPusher
+
+	federationSent = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "federation_events_sent",
+			Help: "Number of events forwarded to the central server.",
+		},
+		[]string{"artifact"},
+	)
+
+	federationDropped = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "federation_events_dropped",
+			Help: "Number of events which could not be forwarded to the central server.",
+		},
+		[]string{"reason"},
+	)
+
+	federationSendErrors = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "federation_send_errors",
+		Help: "Number of failed attempts to push events to the central server.",
+	})
+)
+
+type FederationService struct {
+	config_obj *config_proto.Config
+
+	// The deployment name added to every row.
+	name string
+
+	// The org on the central server we forward into.
+	central_org_id  string
+	max_buffer_size int64
+
+	// Events wait here until they are delivered.
+	buffer *journal.BufferFile
+
+	// Wakes the sender when new events are buffered.
+	notify chan bool
+
+	retry_delay time.Duration
+
+	push Pusher
+}
+
+// Pushes a request to the central server.
+type Pusher func(ctx context.Context, request *api_proto.PushEventRequest) error
+
+func SetRetryDelayForTests(delay time.Duration) func() {
+	old_delay := retryDelay
+	retryDelay = delay
+	return func() {
+		retryDelay = old_delay
+	}
+}
+
+// Replace the connection to the central server.
+func SetPusherForTests(push Pusher) func() {
+	old_pusher := newPusher
+	newPusher = func(federation *config_proto.FederationConfig) (Pusher, error) {
+		return push, nil
+	}
+	return func() {
+		newPusher = old_pusher
+	}
+}
+
+// Queue the rows for delivery to the artifact on the central server.
+func (self *FederationService) Forward(
+	artifact string, rows []*ordereddict.Dict) error {
+	serialized, err := json.MarshalJsonl(rows)
+	if err != nil {
+		return err
+	}
+
+	header := self.buffer.GetHeader()
+	if header.WritePointer-header.ReadPointer > self.max_buffer_size {
+		federationDropped.WithLabelValues("buffer_full").Add(float64(len(rows)))
+		return errors.New("Federation: buffer is full")
+	}
+
+	serialized = json.AppendJsonlItem(serialized, "_Region", self.name)
+	serialized = json.AppendJsonlItem(serialized, "_OrgId",
+		utils.NormalizedOrgId(self.config_obj.OrgId))
+
+	err = self.buffer.Enqueue(&api_proto.PushEventRequest{
+		Artifact: artifact,
+		Jsonl:    serialized,
+		Rows:     int64(len(rows)),
+		OrgId:    self.central_org_id,
+	})
+	if err != nil {
+		return err
+	}
+
+	select {
+	case self.notify <- true:
+	default:
+	}
+
+	return nil
+}
+
+// Deliver buffered events in order. Each event is retried until the
+// central server accepts it, unless the central server rejects it
+// outright.
+func (self *FederationService) sendLoop(ctx context.Context) {
+	logger := logging.GetLogger(self.config_obj, &logging.FrontendComponent)
+
+	for {
+		request, err := self.buffer.Lease()
+		if err != nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-self.notify:
+			case <-time.After(utils.Jitter(self.retry_delay)):
+			}
+			continue
+		}
+
+		for {
+			err = self.send(ctx, request)
+			if err == nil {
+				federationSent.WithLabelValues(request.Artifact).Add(
+					float64(request.Rows))
+				break
+			}
+
+			if isPermanentError(err) {
+				logger.Error("<red>FederationService</> Central server rejected events for %v: %v",
+					request.Artifact, err)
+				federationDropped.WithLabelValues("rejected").Add(
+					float64(request.Rows))
+				break
+			}
+
+			federationSendErrors.Inc()
+			logger.Error("<red>FederationService</> Unable to push events for %v: %v",
+				request.Artifact, err)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(utils.Jitter(self.retry_delay)):
+			}
+		}
+	}
+}
+
+func (self *FederationService) send(
+	ctx context.Context, request *api_proto.PushEventRequest) error {
+	sub_ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+
+	return self.push(sub_ctx, request)
+}
+
+// Retrying will not help if the central server does not accept the
+// events.
+func isPermanentError(err error) bool {
+	switch status.Code(err) {
+	case codes.PermissionDenied, codes.InvalidArgument, codes.Unauthenticated,
+		codes.NotFound, codes.FailedPrecondition:
+		return true
+	}
+	return false
+}
+
+// Find the org on the central server the org's events are forwarded
+// into. Returns false if the org is not forwarded.
+func getCentralOrgId(
+	federation *config_proto.FederationConfig, org_id string) (string, bool) {
+	if utils.IsRootOrg(org_id) {
+		return federation.OrgId, true
+	}
+
+	central_org_id, pres := federation.OrgMapping[org_id]
+	return central_org_id, pres
+}
+
+func (self *FederationService) processFlowCompletion(
+	ctx context.Context, config_obj *config_proto.Config,
+	row *ordereddict.Dict) error {
+
+	flow, err := journal.GetFlowFromQueue(ctx, config_obj, row)
+	if err != nil {
+		return err
+	}
+
+	var artifact_names []string
+	var creator string
+	if flow.Request != nil {
+		artifact_names = flow.Request.Artifacts
+		creator = flow.Request.Creator
+	}
+
+	hunt_id, _ := utils.ExtractHuntId(flow.SessionId)
+
+	return self.Forward(FLOW_COMPLETION_ARTIFACT, []*ordereddict.Dict{
+		ordereddict.NewDict().
+			Set("ClientId", flow.ClientId).
+			Set("Hostname", self.getHostname(ctx, flow.ClientId)).
+			Set("FlowId", flow.SessionId).
+			Set("HuntId", hunt_id).
+			Set("Artifacts", artifact_names).
+			Set("ArtifactsWithResults", flow.ArtifactsWithResults).
+			Set("State", flow.State.String()).
+			Set("Status", flow.Status).
+			Set("Creator", creator).
+			Set("TotalCollectedRows", flow.TotalCollectedRows).
+			Set("TotalUploadedBytes", flow.TotalUploadedBytes).
+			Set("CreateTime", flow.CreateTime).
+			Set("ActiveTime", flow.ActiveTime),
+	})
+}
+
+func (self *FederationService) getHostname(
+	ctx context.Context, client_id string) string {
+	client_info_manager, err := services.GetClientInfoManager(self.config_obj)
+	if err != nil {
+		return ""
+	}
+
+	info, err := client_info_manager.Get(ctx, client_id)
+	if err != nil {
+		return ""
+	}
+	return info.Hostname
+}
+
+func (self *FederationService) watchEvents(
+	ctx context.Context, wg *sync.WaitGroup,
+	federation *config_proto.FederationConfig) error {
+
+	for _, artifact := range federation.Artifacts {
+		err := journal.WatchQueueWithCB(ctx, self.config_obj, wg,
+			services.JournalOptions{ArtifactName: artifact},
+			"FederationService",
+			func(ctx context.Context, config_obj *config_proto.Config,
+				row *ordereddict.Dict) error {
+				return self.Forward(artifact, []*ordereddict.Dict{row})
+			})
+		if err != nil {
+			return err
+		}
+	}
+
+	if federation.ForwardFlowCompletions {
+		err := journal.WatchQueueWithCB(ctx, self.config_obj, wg,
+			artifacts.FLOW_COMPLETION, "FederationService",
+			self.processFlowCompletion)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Connect to the central server with the API client config it
+// issued. This is independent of our own API config.
+func newCentralPusher(federation *config_proto.FederationConfig) (Pusher, error) {
+
+	if federation.Central == nil ||
+		federation.Central.ApiConnectionString == "" {
+		return nil, errors.New("Federation: central.api_connection_string must be specified")
+	}
+
+	central_config := &config_proto.Config{
+		ApiConfig: federation.Central,
+		Client: &config_proto.ClientConfig{
+			PinnedServerName: federation.Central.PinnedServerName,
+		},
+	}
+
+	api_client, err := grpc_client.NewGRPCAPIClient(central_config)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(ctx context.Context, request *api_proto.PushEventRequest) error {
+		client, closer, err := api_client.GetAPIClient(
+			ctx, grpc_client.API_User, central_config)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = closer() }()
+
+		_, err = client.PushEvents(ctx, request)
+		return err
+	}, nil
+}
+
+func newFederationService(
+	ctx context.Context, wg *sync.WaitGroup,
+	config_obj *config_proto.Config, push Pusher,
+	central_org_id string) (*FederationService, error) {
+	federation := config_obj.Federation
+
+	result := &FederationService{
+		config_obj:      config_obj,
+		push:            push,
+		name:            federation.Name,
+		central_org_id:  central_org_id,
+		max_buffer_size: int64(federation.MaxBufferSize),
+		notify:          make(chan bool, 1),
+		retry_delay:     retryDelay,
+	}
+
+	if result.name == "" && config_obj.Frontend != nil {
+		result.name = config_obj.Frontend.Hostname
+	}
+
+	if result.max_buffer_size == 0 {
+		result.max_buffer_size = defaultMaxBufferSize
+	}
+
+	tmpfile, err := utils_tempfile.TempFile("federation")
+	if err != nil {
+		return nil, err
+	}
+	utils_tempfile.AddTmpFile(tmpfile.Name())
+
+	result.buffer, err = journal.NewBufferFile(config_obj, tmpfile)
+	if err != nil {
+		tmpfile.Close()
+		os.Remove(tmpfile.Name())
+		return nil, err
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer result.buffer.Close()
+
+		result.sendLoop(ctx)
+	}()
+
+	return result, nil
+}
+
+func StartFederationService(
+	ctx context.Context, wg *sync.WaitGroup,
+	config_obj *config_proto.Config) error {
+
+	// Each org forwards its own events. Only the master sees all
+	// the events.
+	if config_obj.Federation == nil || services.IsMinion(config_obj) {
+		return nil
+	}
+
+	central_org_id, pres := getCentralOrgId(
+		config_obj.Federation, config_obj.OrgId)
+	if !pres {
+		return nil
+	}
+
+	push, err := newPusher(config_obj.Federation)
+	if err != nil {
+		return err
+	}
+
+	result, err := newFederationService(ctx, wg, config_obj, push,
+		central_org_id)
+	if err != nil {
+		return err
+	}
+
+	logger := logging.GetLogger(config_obj, &logging.FrontendComponent)
+	logger.Info("<green>Starting</> federation service for %v: forwarding events as %v to org %v on the central server",
+		services.GetOrgName(config_obj), result.name,
+		utils.NormalizedOrgId(central_org_id))
+
+	return result.watchEvents(ctx, wg, config_obj.Federation)
+}
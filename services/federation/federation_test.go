@@ -0,0 +1,260 @@
+package federation_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Velocidex/ordereddict"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	actions_proto "www.velocidex.com/golang/velociraptor/actions/proto"
+	api_proto "www.velocidex.com/golang/velociraptor/api/proto"
+	config_proto "www.velocidex.com/golang/velociraptor/config/proto"
+	"www.velocidex.com/golang/velociraptor/file_store/test_utils"
+	"www.velocidex.com/golang/velociraptor/services"
+	"www.velocidex.com/golang/velociraptor/services/federation"
+	"www.velocidex.com/golang/velociraptor/utils"
+	"www.velocidex.com/golang/velociraptor/vtesting"
+	"www.velocidex.com/golang/velociraptor/vtesting/assert"
+)
+
+var federationDefinitions = []string{`
+name: Server.Internal.Alerts
+type: SERVER_EVENT
+`, `
+name: Server.Audit.Logs
+type: SERVER_EVENT
+`}
+
+type FederationTestSuite struct {
+	test_utils.TestSuite
+
+	mu       sync.Mutex
+	requests []*api_proto.PushEventRequest
+
+	// The errors to return for successive pushes. Once exhausted
+	// pushes succeed.
+	responses []error
+
+	closers []func()
+}
+
+func (self *FederationTestSuite) SetupTest() {
+	self.ConfigObj = self.TestSuite.LoadConfig()
+	self.ConfigObj.Frontend.Hostname = "emea.example.com"
+	self.ConfigObj.Services.FederationService = true
+	self.ConfigObj.Federation = &config_proto.FederationConfig{
+		Central: &config_proto.ApiClientConfig{
+			ApiConnectionString: "central.example.com:8001",
+		},
+		Artifacts:              []string{"Server.Internal.Alerts"},
+		ForwardFlowCompletions: true,
+		OrgId:                  "O123",
+		OrgMapping:             map[string]string{"O1": "O456"},
+	}
+	self.LoadArtifactsIntoConfig(federationDefinitions)
+
+	self.requests = nil
+	self.responses = nil
+	self.closers = []func(){
+		federation.SetRetryDelayForTests(10 * time.Millisecond),
+		federation.SetPusherForTests(self.push),
+	}
+
+	self.TestSuite.SetupTest()
+}
+
+func (self *FederationTestSuite) TearDownTest() {
+	self.TestSuite.TearDownTest()
+	for _, closer := range self.closers {
+		closer()
+	}
+}
+
+func (self *FederationTestSuite) push(
+	ctx context.Context, request *api_proto.PushEventRequest) error {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	if len(self.responses) > 0 {
+		err := self.responses[0]
+		self.responses = self.responses[1:]
+		if err != nil {
+			return err
+		}
+	}
+
+	self.requests = append(self.requests, request)
+	return nil
+}
+
+func (self *FederationTestSuite) getRequests() []*api_proto.PushEventRequest {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	return append([]*api_proto.PushEventRequest{}, self.requests...)
+}
+
+func (self *FederationTestSuite) pushRow(
+	client_id, artifact string, row *ordereddict.Dict) {
+	self.pushRowToOrg(self.ConfigObj, client_id, artifact, row)
+}
+
+func (self *FederationTestSuite) pushRowToOrg(
+	config_obj *config_proto.Config,
+	client_id, artifact string, row *ordereddict.Dict) {
+	journal, err := services.GetJournal(config_obj)
+	assert.NoError(self.T(), err)
+
+	err = journal.PushRowsToArtifact(self.Ctx, config_obj,
+		[]*ordereddict.Dict{row},
+		services.JournalOptions{
+			ArtifactName: artifact,
+			ClientId:     client_id,
+		})
+	assert.NoError(self.T(), err)
+}
+
+func (self *FederationTestSuite) parseRow(
+	request *api_proto.PushEventRequest) *ordereddict.Dict {
+	rows, err := utils.ParseJsonToDicts([]byte(request.Jsonl))
+	assert.NoError(self.T(), err)
+	assert.Equal(self.T(), 1, len(rows))
+	return rows[0]
+}
+
+func (self *FederationTestSuite) TestForwardEvents() {
+	// The central server is down for the first two attempts.
+	self.mu.Lock()
+	self.responses = []error{
+		status.Error(codes.Unavailable, "connection refused"),
+		status.Error(codes.Unavailable, "connection refused"),
+	}
+	self.mu.Unlock()
+
+	for _, name := range []string{"First", "Second"} {
+		self.pushRow("server", "Server.Internal.Alerts",
+			ordereddict.NewDict().Set("name", name))
+	}
+
+	// Not configured for forwarding.
+	self.pushRow("server", "Server.Audit.Logs", ordereddict.NewDict().Set("name", "Audit"))
+
+	vtesting.WaitUntil(5*time.Second, self.T(), func() bool {
+		return len(self.getRequests()) == 2
+	})
+
+	// Buffered events are delivered in order.
+	requests := self.getRequests()
+	for idx, name := range []string{"First", "Second"} {
+		request := requests[idx]
+		assert.Equal(self.T(), "Server.Internal.Alerts", request.Artifact)
+		assert.Equal(self.T(), "O123", request.OrgId)
+		assert.Equal(self.T(), int64(1), request.Rows)
+
+		row := self.parseRow(request)
+		assert.Equal(self.T(), name, getString(row, "name"))
+		assert.Equal(self.T(), "emea.example.com", getString(row, "_Region"))
+		assert.Equal(self.T(), "root", getString(row, "_OrgId"))
+	}
+}
+
+func (self *FederationTestSuite) TestForwardEventsFromOrgs() {
+	org_manager, err := services.GetOrgManager()
+	assert.NoError(self.T(), err)
+
+	// Only O1 is mapped to an org on the central server.
+	for _, org_id := range []string{"O1", "O2"} {
+		_, err = org_manager.CreateNewOrg("Regional "+org_id, org_id,
+			services.RandomNonce)
+		assert.NoError(self.T(), err)
+
+		org_config_obj, err := org_manager.GetOrgConfig(org_id)
+		assert.NoError(self.T(), err)
+
+		self.pushRowToOrg(org_config_obj, "server", "Server.Internal.Alerts",
+			ordereddict.NewDict().Set("name", "From"+org_id))
+	}
+
+	vtesting.WaitUntil(5*time.Second, self.T(), func() bool {
+		return len(self.getRequests()) == 1
+	})
+
+	// Give the unmapped org a chance to be forwarded.
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(self.T(), 1, len(self.getRequests()))
+
+	request := self.getRequests()[0]
+	assert.Equal(self.T(), "O456", request.OrgId)
+
+	row := self.parseRow(request)
+	assert.Equal(self.T(), "FromO1", getString(row, "name"))
+	assert.Equal(self.T(), "emea.example.com", getString(row, "_Region"))
+	assert.Equal(self.T(), "O1", getString(row, "_OrgId"))
+}
+
+func (self *FederationTestSuite) TestRejectedEventsAreDropped() {
+	self.mu.Lock()
+	self.responses = []error{
+		status.Error(codes.PermissionDenied, "Permission denied: PUBLISH"),
+		status.Error(codes.NotFound, "Artifact not found"),
+		status.Error(codes.FailedPrecondition, "Not a server event artifact"),
+	}
+	self.mu.Unlock()
+
+	for _, name := range []string{
+		"Denied", "NotFound", "FailedPrecondition", "Accepted"} {
+		self.pushRow("server", "Server.Internal.Alerts",
+			ordereddict.NewDict().Set("name", name))
+	}
+
+	vtesting.WaitUntil(5*time.Second, self.T(), func() bool {
+		return len(self.getRequests()) == 1
+	})
+
+	row := self.parseRow(self.getRequests()[0])
+	assert.Equal(self.T(), "Accepted", getString(row, "name"))
+}
+
+func (self *FederationTestSuite) TestFlowCompletion() {
+	client_info_manager, err := services.GetClientInfoManager(self.ConfigObj)
+	assert.NoError(self.T(), err)
+
+	err = client_info_manager.Set(self.Ctx, &services.ClientInfo{
+		ClientInfo: &actions_proto.ClientInfo{
+			ClientId: "C.1234",
+			Hostname: "workstation",
+		}})
+	assert.NoError(self.T(), err)
+
+	self.CreateFlow("C.1234", "F.1234")
+
+	self.pushRow("C.1234", "System.Flow.Completion", ordereddict.NewDict().
+		Set("ClientId", "C.1234").
+		Set("FlowId", "F.1234"))
+
+	vtesting.WaitUntil(5*time.Second, self.T(), func() bool {
+		return len(self.getRequests()) == 1
+	})
+
+	request := self.getRequests()[0]
+	assert.Equal(self.T(), federation.FLOW_COMPLETION_ARTIFACT, request.Artifact)
+
+	row := self.parseRow(request)
+	assert.Equal(self.T(), "C.1234", getString(row, "ClientId"))
+	assert.Equal(self.T(), "workstation", getString(row, "Hostname"))
+	assert.Equal(self.T(), "F.1234", getString(row, "FlowId"))
+	assert.Equal(self.T(), "emea.example.com", getString(row, "_Region"))
+}
+
+func getString(row *ordereddict.Dict, field string) string {
+	value, _ := row.GetString(field)
+	return value
+}
+
+func TestFederationService(t *testing.T) {
+	suite.Run(t, &FederationTestSuite{})
+}
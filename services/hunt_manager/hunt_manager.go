@@ -37,7 +37,8 @@
    schedules collection on the client.
 
 4) Hunt manager watches for flow completions and updates hunt stats re
-   success or error of flow completion.
+   success or error of flow completion. It also updates the stacking
+   index of artifact sources which declare stack_columns.
 
 Note that steps 1 & 2 are on the critical path (and may be on a minion
 frontend) and 3-4 are run on the master node.
@@ -140,7 +141,33 @@ func (self *HuntManager) Start(
 	err = journal.WatchQueueWithCB(ctx, config_obj, wg,
 		artifacts.FLOW_COMPLETION, "HuntManager",
 		self.ProcessFlowCompletion)
-	return err
+	if err != nil {
+		return err
+	}
+
+	// Stacking reads the flow's results so it gets its own watcher.
+	return journal.WatchQueueWithCB(ctx, config_obj, wg,
+		artifacts.FLOW_COMPLETION, "HuntStacker",
+		NewHuntStacker().ProcessFlowCompletion)
+}
+
+// The flow object is embedded in the System.Flow.Completion row.
+func getFlowFromRow(
+	row *ordereddict.Dict) (*flows_proto.ArtifactCollectorContext, error) {
+	flow_any, pres := row.Get("Flow")
+	if !pres {
+		return nil, nil
+	}
+
+	flow_obj, ok := flow_any.(*flows_proto.ArtifactCollectorContext)
+	if !ok || flow_obj == nil {
+		flow_obj = &flows_proto.ArtifactCollectorContext{}
+		err := utils.ParseIntoProtobuf(flow_any, flow_obj)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return flow_obj, nil
 }
 
 // Watch for an interrogate completion and re-check all the hunts on
@@ -178,18 +205,9 @@ func (self *HuntManager) ProcessFlowCompletion(
 	config_obj *config_proto.Config,
 	row *ordereddict.Dict) error {
 
-	flow_any, pres := row.Get("Flow")
-	if !pres {
-		return nil
-	}
-
-	flow_obj, ok := flow_any.(*flows_proto.ArtifactCollectorContext)
-	if !ok || flow_obj == nil {
-		flow_obj = &flows_proto.ArtifactCollectorContext{}
-		err := utils.ParseIntoProtobuf(flow_any, flow_obj)
-		if err != nil {
-			return err
-		}
+	flow_obj, err := getFlowFromRow(row)
+	if err != nil || flow_obj == nil {
+		return err
 	}
 
 	// Sessions IDs that come from a hunt have a special format with
@@ -229,7 +247,7 @@ func (self *HuntManager) ProcessFlowCompletion(
 	// status, so we don't bother broadcasting a mutation for them. We
 	// only need to update the local hunt dispatcher on the master
 	// node which will flush to disk eventually.
-	err := self.processMutation(ctx, config_obj, mutation)
+	err = self.processMutation(ctx, config_obj, mutation)
 	if err != nil {
 		return err
 	}
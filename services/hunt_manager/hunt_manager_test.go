@@ -13,10 +13,12 @@ import (
 	actions_proto "www.velocidex.com/golang/velociraptor/actions/proto"
 	api_proto "www.velocidex.com/golang/velociraptor/api/proto"
 	crypto_proto "www.velocidex.com/golang/velociraptor/crypto/proto"
+	"www.velocidex.com/golang/velociraptor/file_store"
 	"www.velocidex.com/golang/velociraptor/file_store/test_utils"
 	flows_proto "www.velocidex.com/golang/velociraptor/flows/proto"
 	"www.velocidex.com/golang/velociraptor/paths"
 	"www.velocidex.com/golang/velociraptor/paths/artifacts"
+	"www.velocidex.com/golang/velociraptor/result_sets"
 	"www.velocidex.com/golang/velociraptor/services"
 	"www.velocidex.com/golang/velociraptor/services/hunt_dispatcher"
 	"www.velocidex.com/golang/velociraptor/services/hunt_manager"
@@ -855,6 +857,95 @@ func (self *HuntTestSuite) TestHuntManagerErrors() {
 	})
 }
 
+func (self *HuntTestSuite) TestHuntStacking() {
+	self.LoadArtifacts(`
+name: Test.Stack
+sources:
+- stack_columns:
+  - Hash
+  query: SELECT * FROM info()
+`)
+
+	request := proto.Clone(self.expected).(*flows_proto.ArtifactCollectorArgs)
+	request.Artifacts = []string{"Test.Stack"}
+
+	hunt_obj := &api_proto.Hunt{
+		HuntId:       self.hunt_id,
+		StartRequest: request,
+		State:        api_proto.Hunt_RUNNING,
+		Stats:        &api_proto.HuntStats{},
+		Expires:      uint64(time.Now().Add(7*24*time.Hour).UTC().UnixNano() / 1000),
+	}
+
+	dispatcher, err := services.GetHuntDispatcher(self.ConfigObj)
+	assert.NoError(self.T(), err)
+
+	_, err = dispatcher.CreateHunt(
+		self.Ctx, self.ConfigObj, acl_managers.NullACLManager{}, hunt_obj)
+	assert.NoError(self.T(), err)
+
+	journal, err := services.GetJournal(self.ConfigObj)
+	assert.NoError(self.T(), err)
+
+	flow_id := request.FlowId
+	complete := func(client_id string, hashes ...string) {
+		path_manager, err := artifacts.NewArtifactPathManager(self.Ctx,
+			self.ConfigObj, client_id, flow_id, "Test.Stack")
+		assert.NoError(self.T(), err)
+
+		rs_writer, err := result_sets.NewResultSetWriter(
+			file_store.GetFileStore(self.ConfigObj), path_manager.Path(),
+			nil, utils.SyncCompleter, result_sets.TruncateMode)
+		assert.NoError(self.T(), err)
+
+		for _, hash := range hashes {
+			rs_writer.Write(ordereddict.NewDict().Set("Hash", hash))
+		}
+		rs_writer.Close()
+
+		assert.NoError(self.T(), journal.PushRowsToArtifact(self.Ctx, self.ConfigObj,
+			[]*ordereddict.Dict{ordereddict.NewDict().
+				Set("Flow", &flows_proto.ArtifactCollectorContext{
+					ClientId:             client_id,
+					SessionId:            flow_id,
+					ArtifactsWithResults: []string{"Test.Stack"},
+					State:                flows_proto.ArtifactCollectorContext_FINISHED,
+				}).
+				Set("FlowId", flow_id).
+				Set("ClientId", client_id),
+			},
+			artifacts.FLOW_COMPLETION.WithClientId(client_id)))
+	}
+
+	complete("C.1", "A", "B", "A")
+	complete("C.2", "A")
+	complete("C.3", "C")
+
+	// The flows on C.2 and C.3 complete again (e.g. they were
+	// resumed). Their results replace the earlier ones rather than
+	// adding to them.
+	complete("C.2", "A", "A")
+	complete("C.3", "A")
+
+	var stack *api_proto.HuntStack
+	vtesting.WaitUntil(5*time.Second, self.T(), func() bool {
+		stack, err = hunt_manager.ReadHuntStack(self.Ctx, self.ConfigObj,
+			self.hunt_id, "Test.Stack", "Hash")
+		return err == nil && len(stack.Values) == 2 &&
+			stack.Values[0].Count == 5
+	})
+
+	// A is seen 5 times on 3 clients, B once on one client and C is
+	// no longer seen.
+	assert.Equal(self.T(), "A", stack.Values[0].Value)
+	assert.Equal(self.T(), uint64(5), stack.Values[0].Count)
+	assert.Equal(self.T(), []string{"C.1", "C.2", "C.3"}, stack.Values[0].ClientIds)
+
+	assert.Equal(self.T(), "B", stack.Values[1].Value)
+	assert.Equal(self.T(), uint64(1), stack.Values[1].Count)
+	assert.Equal(self.T(), []string{"C.1"}, stack.Values[1].ClientIds)
+}
+
 func TestHuntTestSuite(t *testing.T) {
 	suite.Run(t, &HuntTestSuite{
 		client_id: "C.234",
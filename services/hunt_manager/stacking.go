@@ -0,0 +1,316 @@
+package hunt_manager
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/Velocidex/ordereddict"
+	api_proto "www.velocidex.com/golang/velociraptor/api/proto"
+	artifacts_proto "www.velocidex.com/golang/velociraptor/artifacts/proto"
+	config_proto "www.velocidex.com/golang/velociraptor/config/proto"
+	"www.velocidex.com/golang/velociraptor/file_store"
+	"www.velocidex.com/golang/velociraptor/file_store/api"
+	flows_proto "www.velocidex.com/golang/velociraptor/flows/proto"
+	"www.velocidex.com/golang/velociraptor/json"
+	"www.velocidex.com/golang/velociraptor/paths"
+	"www.velocidex.com/golang/velociraptor/paths/artifacts"
+	"www.velocidex.com/golang/velociraptor/result_sets"
+	"www.velocidex.com/golang/velociraptor/services"
+	"www.velocidex.com/golang/velociraptor/utils"
+)
+
+// The stacking index of a hunt column holds the count and clients of
+// each distinct value across the hunt. It is updated as each flow
+// completes so reading it does not depend on the number of flows in
+// the hunt. We also keep the counts each client contributed so a
+// later flow on the same client replaces them rather than adding to
+// them.
+type HuntStacker struct {
+	// Serializes the read-modify-write of the indexes.
+	mu sync.Mutex
+}
+
+func NewHuntStacker() *HuntStacker {
+	return &HuntStacker{}
+}
+
+// Update the stacking indexes of the hunt with the results of a
+// completed flow. This runs on its own watcher so it does not delay
+// the hunt manager's processing of the completion.
+func (self *HuntStacker) ProcessFlowCompletion(
+	ctx context.Context,
+	config_obj *config_proto.Config,
+	row *ordereddict.Dict) error {
+
+	flow_obj, err := getFlowFromRow(row)
+	if err != nil || flow_obj == nil {
+		return err
+	}
+
+	hunt_id, ok := utils.ExtractHuntId(flow_obj.SessionId)
+	if !ok || len(flow_obj.ArtifactsWithResults) == 0 {
+		return nil
+	}
+
+	manager, err := services.GetRepositoryManager(config_obj)
+	if err != nil {
+		return err
+	}
+
+	repository, err := manager.GetGlobalRepository(config_obj)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range flow_obj.ArtifactsWithResults {
+		columns := getStackColumns(ctx, config_obj, repository, name)
+		if len(columns) == 0 {
+			continue
+		}
+
+		err := self.stackFlowResults(ctx, config_obj, hunt_id,
+			flow_obj, name, columns)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func getStackColumns(
+	ctx context.Context,
+	config_obj *config_proto.Config,
+	repository services.Repository, name string) []string {
+	artifact_name, source_name := paths.SplitFullSourceName(name)
+	artifact, pres := repository.Get(ctx, config_obj, artifact_name)
+	if !pres {
+		return nil
+	}
+
+	var source *artifacts_proto.ArtifactSource
+	for _, s := range artifact.Sources {
+		if s.Name == source_name {
+			source = s
+			break
+		}
+	}
+	if source == nil {
+		return nil
+	}
+	return source.StackColumns
+}
+
+func (self *HuntStacker) stackFlowResults(
+	ctx context.Context,
+	config_obj *config_proto.Config,
+	hunt_id string,
+	flow_obj *flows_proto.ArtifactCollectorContext,
+	name string, columns []string) error {
+
+	path_manager, err := artifacts.NewArtifactPathManager(ctx, config_obj,
+		flow_obj.ClientId, flow_obj.SessionId, name)
+	if err != nil {
+		return err
+	}
+
+	file_store_factory := file_store.GetFileStore(config_obj)
+	rs_reader, err := result_sets.NewResultSetReader(
+		file_store_factory, path_manager.Path())
+	if err != nil {
+		return err
+	}
+	defer rs_reader.Close()
+
+	// Count the values in this flow: column -> value -> count
+	counts := make(map[string]map[string]uint64)
+	for _, column := range columns {
+		counts[column] = make(map[string]uint64)
+	}
+
+	for row := range rs_reader.Rows(ctx) {
+		for _, column := range columns {
+			value, pres := row.Get(column)
+			if !pres {
+				continue
+			}
+			counts[column][stackValue(value)]++
+		}
+	}
+
+	for _, column := range columns {
+		err := self.updateStack(ctx, config_obj, hunt_id, name, column,
+			flow_obj.ClientId, counts[column])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Replace the client's contribution to the stacking index with the
+// counts from its latest flow.
+func (self *HuntStacker) updateStack(
+	ctx context.Context,
+	config_obj *config_proto.Config,
+	hunt_id, name, column, client_id string,
+	counts map[string]uint64) error {
+
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	hunt_path_manager := paths.NewHuntPathManager(hunt_id)
+	stack_path := hunt_path_manager.Stack(name, column)
+	client_path := hunt_path_manager.StackClient(name, column, client_id)
+
+	stack, err := readStack(ctx, config_obj, stack_path)
+	if err != nil {
+		return err
+	}
+
+	previous, err := readStack(ctx, config_obj, client_path)
+	if err != nil {
+		return err
+	}
+
+	if len(counts) == 0 && len(previous) == 0 {
+		return nil
+	}
+
+	lookup := make(map[string]*api_proto.HuntStackValue)
+	for _, item := range stack {
+		lookup[item.Value] = item
+	}
+
+	// Remove the counts of the client's earlier flow.
+	for _, item := range previous {
+		existing, pres := lookup[item.Value]
+		if !pres {
+			continue
+		}
+		if existing.Count > item.Count {
+			existing.Count -= item.Count
+		} else {
+			existing.Count = 0
+		}
+		existing.ClientIds = utils.FilterSlice(existing.ClientIds, client_id)
+	}
+
+	values := make([]string, 0, len(counts))
+	for value := range counts {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+
+	current := make([]*api_proto.HuntStackValue, 0, len(values))
+	for _, value := range values {
+		count := counts[value]
+		current = append(current, &api_proto.HuntStackValue{
+			Value: value,
+			Count: count,
+		})
+
+		existing, pres := lookup[value]
+		if !pres {
+			existing = &api_proto.HuntStackValue{Value: value}
+			stack = append(stack, existing)
+			lookup[value] = existing
+		}
+		existing.Count += count
+		existing.ClientIds = append(existing.ClientIds, client_id)
+	}
+
+	// Drop values no client has any more.
+	result := make([]*api_proto.HuntStackValue, 0, len(stack))
+	for _, item := range stack {
+		if len(item.ClientIds) > 0 {
+			result = append(result, item)
+		}
+	}
+
+	err = writeStack(config_obj, stack_path, result)
+	if err != nil {
+		return err
+	}
+
+	return writeStack(config_obj, client_path, current)
+}
+
+func readStack(ctx context.Context,
+	config_obj *config_proto.Config,
+	stack_path api.FSPathSpec) ([]*api_proto.HuntStackValue, error) {
+	rs_reader, err := result_sets.NewResultSetReader(
+		file_store.GetFileStore(config_obj), stack_path)
+	if err != nil {
+		return nil, err
+	}
+	defer rs_reader.Close()
+
+	var result []*api_proto.HuntStackValue
+	for row := range rs_reader.Rows(ctx) {
+		item := &api_proto.HuntStackValue{}
+		err := utils.ParseIntoProtobuf(row, item)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, item)
+	}
+	return result, nil
+}
+
+func writeStack(
+	config_obj *config_proto.Config,
+	stack_path api.FSPathSpec, values []*api_proto.HuntStackValue) error {
+	rs_writer, err := result_sets.NewResultSetWriter(
+		file_store.GetFileStore(config_obj), stack_path,
+		json.DefaultEncOpts(), utils.SyncCompleter, result_sets.TruncateMode)
+	if err != nil {
+		return err
+	}
+	defer rs_writer.Close()
+
+	for _, item := range values {
+		err := rs_writer.WriteJSONL([]byte(json.MustMarshalString(item)), 1)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Read the stacking index of a hunt column. Each client is counted
+// once per value, using only its latest flow.
+func ReadHuntStack(ctx context.Context,
+	config_obj *config_proto.Config,
+	hunt_id, artifact, column string) (*api_proto.HuntStack, error) {
+
+	values, err := readStack(ctx, config_obj,
+		paths.NewHuntPathManager(hunt_id).Stack(artifact, column))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(values) == 0 {
+		return nil, errors.New("No stacking index")
+	}
+
+	return &api_proto.HuntStack{
+		HuntId:   hunt_id,
+		Artifact: artifact,
+		Column:   column,
+		Values:   values,
+	}, nil
+}
+
+// Stack strings by their value and everything else by its JSON
+// encoding.
+func stackValue(value interface{}) string {
+	str, ok := value.(string)
+	if ok {
+		return str
+	}
+	return json.MustMarshalString(value)
+}
@@ -74,6 +74,10 @@ type BufferFile struct {
 	read_buf  []byte
 	write_buf []byte
 
+	// Persistent buffers record the read pointer as items are leased
+	// and keep the file when closed.
+	persistent bool
+
 	log_ctx *logging.LogContext
 }
 
@@ -179,6 +183,17 @@ func (self *BufferFile) Lease() (*api_proto.PushEventRequest, error) {
 	// now.
 	if self.Header.ReadPointer == self.Header.WritePointer {
 		self._Truncate()
+
+	} else if self.persistent {
+		serialized, err := self.Header.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		_, err = self.fd.WriteAt(serialized, 0)
+		if err != nil {
+			self._Truncate()
+			return nil, err
+		}
 	}
 
 	return result, nil
@@ -204,7 +219,9 @@ func (self *BufferFile) Reset() {
 // Closes the underlying file and shut down the readers.
 func (self *BufferFile) Close() {
 	self.fd.Close()
-	os.Remove(self.fd.Name())
+	if !self.persistent {
+		os.Remove(self.fd.Name())
+	}
 }
 
 func NewBufferFile(
@@ -251,3 +268,15 @@ func NewBufferFile(
 
 	return result, nil
 }
+
+// A buffer file which survives restarts: Items remaining in the file
+// are leased again when it is reopened.
+func NewPersistentBufferFile(
+	config_obj *config_proto.Config, fd *os.File) (*BufferFile, error) {
+	result, err := NewBufferFile(config_obj, fd)
+	if err != nil {
+		return nil, err
+	}
+	result.persistent = true
+	return result, nil
+}
@@ -193,8 +193,9 @@ func mergeSources(
 
 		if last_query != nil {
 			result.Query = append(result.Query, &actions_proto.VQLRequest{
-				Name: name,
-				VQL:  vfilter.FormatToString(scope, last_query),
+				Name:      name,
+				VQL:       vfilter.FormatToString(scope, last_query),
+				KeyColumn: source.KeyColumn,
 			})
 		}
 	}
@@ -122,6 +122,12 @@ func (self *FlowStorageManager) DeleteFlow(
 
 	r.emit_result_set("Log", flow_path_manager.Log())
 
+	// Only profiled collections have a profile.
+	_, err1 := file_store_factory.StatFile(flow_path_manager.Profile())
+	if err1 == nil {
+		r.emit_result_set("Profile", flow_path_manager.Profile())
+	}
+
 	r.emit_ds("CollectionContext", flow_path_manager.Path())
 	r.emit_ds("Task", flow_path_manager.Task())
 	r.emit_ds("Stats", flow_path_manager.Stats())
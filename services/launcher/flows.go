@@ -264,6 +264,7 @@ func UpdateFlowStats(collection_context *flows_proto.ArtifactCollectorContext) {
 	collection_context.TotalUploadedBytes = 0
 	collection_context.TotalExpectedUploadedBytes = 0
 	collection_context.TotalUploadedFiles = 0
+	collection_context.TotalFilesTouched = 0
 	collection_context.TotalCollectedRows = 0
 	collection_context.TotalLogs = 0
 	collection_context.ActiveTime = 0
@@ -281,6 +282,7 @@ func UpdateFlowStats(collection_context *flows_proto.ArtifactCollectorContext) {
 		collection_context.TotalUploadedBytes += uint64(s.UploadedBytes)
 		collection_context.TotalExpectedUploadedBytes += uint64(s.ExpectedUploadedBytes)
 		collection_context.TotalUploadedFiles += uint64(s.UploadedFiles)
+		collection_context.TotalFilesTouched += uint64(s.FilesTouched)
 		collection_context.TotalCollectedRows += uint64(s.ResultRows)
 		collection_context.TotalLogs += uint64(s.LogRows)
 
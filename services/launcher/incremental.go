@@ -0,0 +1,136 @@
+package launcher
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	actions_proto "www.velocidex.com/golang/velociraptor/actions/proto"
+	config_proto "www.velocidex.com/golang/velociraptor/config/proto"
+	flows_proto "www.velocidex.com/golang/velociraptor/flows/proto"
+	"www.velocidex.com/golang/velociraptor/result_sets"
+	"www.velocidex.com/golang/velociraptor/services"
+	"www.velocidex.com/golang/velociraptor/utils"
+)
+
+const (
+	// How many of the client's flows we consider when looking for
+	// the incremental base.
+	MAX_INCREMENTAL_CANDIDATES = 1000
+)
+
+// Find the most recent incremental collection of the same artifacts
+// and parameters from this client which completed successfully. The
+// client only sends the changes relative to this flow. Returns an
+// empty string if there is no suitable flow, in which case the client
+// sends all rows.
+func (self *Launcher) findIncrementalBase(
+	ctx context.Context,
+	config_obj *config_proto.Config,
+	client_id string, artifacts []string,
+	args []*actions_proto.VQLCollectorArgs) (string, error) {
+
+	summaries, _, err := self.Storage().ListFlows(ctx, config_obj, client_id,
+		result_sets.ResultSetOptions{}, 0, MAX_INCREMENTAL_CANDIDATES)
+	if err != nil {
+		return "", err
+	}
+
+	wanted := sortedCopy(artifacts)
+	candidates := make([]*services.FlowSummary, 0, len(summaries))
+	for _, summary := range summaries {
+		if utils.StringSliceEq(sortedCopy(summary.Artifacts), wanted) {
+			candidates = append(candidates, summary)
+		}
+	}
+
+	// Most recent first.
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].Created > candidates[j].Created
+	})
+
+	wanted_key := incrementalKey(args)
+	for _, candidate := range candidates {
+		flow, err := self.Storage().LoadCollectionContext(ctx, config_obj,
+			client_id, candidate.FlowId, services.GetFlowOptions{})
+		if err != nil || flow.Request == nil || !flow.Request.Incremental ||
+			flow.State != flows_proto.ArtifactCollectorContext_FINISHED {
+			continue
+		}
+
+		// The client keeps separate state for each set of
+		// parameters so only a flow with the same key can be used.
+		key, err := self.flowIncrementalKey(ctx, config_obj,
+			client_id, flow.SessionId)
+		if err != nil || key != wanted_key {
+			continue
+		}
+		return flow.SessionId, nil
+	}
+
+	return "", nil
+}
+
+// The key of the queries the client actually received for this flow.
+func (self *Launcher) flowIncrementalKey(
+	ctx context.Context,
+	config_obj *config_proto.Config,
+	client_id, flow_id string) (string, error) {
+	tasks, err := self.Storage().GetFlowTasks(ctx, config_obj,
+		client_id, flow_id, 0, MAX_INCREMENTAL_CANDIDATES)
+	if err != nil {
+		return "", err
+	}
+
+	var args []*actions_proto.VQLCollectorArgs
+	for _, task := range tasks.Items {
+		if task.FlowRequest != nil {
+			args = append(args, task.FlowRequest.VQLClientActions...)
+		}
+	}
+	return incrementalKey(args), nil
+}
+
+// The client keeps the state of each keyed query under the query
+// name, key column and parameters (see actions.NewIncrementalTracker)
+// so a flow can only be a base for a collection with the same key.
+func incrementalKey(args []*actions_proto.VQLCollectorArgs) string {
+	var parts []string
+	for _, arg := range args {
+		for _, query := range arg.Query {
+			if query.KeyColumn == "" || query.Name == "" {
+				continue
+			}
+
+			part := []string{query.Name, query.KeyColumn}
+			for _, env := range arg.Env {
+				part = append(part, env.Key, env.Value)
+			}
+			parts = append(parts, strings.Join(part, "\x00"))
+		}
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, "\n")
+}
+
+func sortedCopy(in []string) []string {
+	result := append([]string{}, in...)
+	sort.Strings(result)
+	return result
+}
+
+// Incremental requests carry the base flow so the client can tell if
+// its local state is still valid.
+func markIncremental(
+	args []*actions_proto.VQLCollectorArgs,
+	base string) []*actions_proto.VQLCollectorArgs {
+	result := make([]*actions_proto.VQLCollectorArgs, 0, len(args))
+	for _, arg := range args {
+		arg = proto.Clone(arg).(*actions_proto.VQLCollectorArgs)
+		arg.Incremental = true
+		arg.IncrementalBase = base
+		result = append(result, arg)
+	}
+	return result
+}
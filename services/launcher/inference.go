@@ -0,0 +1,624 @@
+package launcher
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/alecthomas/participle/v2/lexer"
+	"www.velocidex.com/golang/velociraptor/constants"
+	"www.velocidex.com/golang/vfilter"
+	"www.velocidex.com/golang/vfilter/types"
+	vfilter_utils "www.velocidex.com/golang/vfilter/utils"
+)
+
+var (
+	// Variables which are commonly present in the scope when VQL
+	// runs on the server or the client.
+	wellKnownScopeVars = []string{
+		"ClientId", "FlowId", "HuntId", "OrgId", "ArtifactName",
+		"NotebookId", "NotebookCellId",
+		constants.SCOPE_CONFIG, constants.SCOPE_SERVER_CONFIG,
+	}
+)
+
+// A column in the rows emitted by a query.
+type Column struct {
+	Name string
+	Type string
+}
+
+// Describes the rows emitted by a query. When the schema is Open,
+// the rows may contain other columns we do not know about.
+type RowSchema struct {
+	Columns []Column
+	Open    bool
+}
+
+func NewOpenRowSchema() *RowSchema {
+	return &RowSchema{Open: true}
+}
+
+// Parse a column specification of the form Name:Type,Name:Type as
+// declared in the plugin's metadata.
+func ParseRowSchema(spec string) *RowSchema {
+	res := &RowSchema{}
+	for _, item := range strings.Split(spec, ",") {
+		name, column_type, _ := strings.Cut(strings.TrimSpace(item), ":")
+		if name != "" {
+			res.Add(Column{Name: name, Type: column_type})
+		}
+	}
+	return res
+}
+
+func (self *RowSchema) Get(name string) (Column, bool) {
+	for _, c := range self.Columns {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return Column{}, false
+}
+
+func (self *RowSchema) Add(column Column) {
+	_, pres := self.Get(column.Name)
+	if !pres {
+		self.Columns = append(self.Columns, column)
+	}
+}
+
+// Merge the other schema into this one. The result is only closed
+// if both schemas are closed.
+func (self *RowSchema) Merge(other *RowSchema) {
+	for _, c := range other.Columns {
+		self.Add(c)
+	}
+	if other.Open {
+		self.Open = true
+	}
+}
+
+func (self *RowSchema) Names() (res []string) {
+	for _, c := range self.Columns {
+		res = append(res, c.Name)
+	}
+	return res
+}
+
+func (self *RowSchema) String() string {
+	var res []string
+	for _, c := range self.Columns {
+		if c.Type == "" {
+			res = append(res, c.Name)
+		} else {
+			res = append(res, c.Name+":"+c.Type)
+		}
+	}
+	if self.Open {
+		res = append(res, "...")
+	}
+	return strings.Join(res, ", ")
+}
+
+// The names visible to expressions evaluated on a row. Scopes are
+// nested: a subquery can see the columns of the row of the enclosing
+// query.
+type nameScope struct {
+	parent *nameScope
+
+	// The row the expressions are evaluated on and a description of
+	// where it came from.
+	row  *RowSchema
+	from string
+
+	// Column aliases declared in the SELECT and LET parameters.
+	names map[string]bool
+
+	// A LET stored query is evaluated in the scope of its caller so
+	// the names visible at each call site are also visible.
+	callers []*nameScope
+}
+
+func newNameScope(parent *nameScope, row *RowSchema, from string) *nameScope {
+	return &nameScope{
+		parent: parent,
+		row:    row,
+		from:   from,
+		names:  make(map[string]bool),
+	}
+}
+
+func (self *nameScope) has(name string) bool {
+	if self.names[name] {
+		return true
+	}
+
+	if self.row != nil {
+		_, pres := self.row.Get(name)
+		if pres {
+			return true
+		}
+	}
+
+	if self.parent != nil {
+		return self.parent.has(name)
+	}
+
+	for _, caller := range self.callers {
+		if caller.has(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// If any of the rows in scope are open we can not tell if a name is
+// valid or not.
+func (self *nameScope) isOpen() bool {
+	if self.row != nil && self.row.Open {
+		return true
+	}
+
+	if self.parent != nil {
+		return self.parent.isOpen()
+	}
+
+	for _, caller := range self.callers {
+		if caller.isOpen() {
+			return true
+		}
+	}
+	return false
+}
+
+// Infers the row schemas of queries and warns about references to
+// columns which are not present in the rows.
+type typeInferrer struct {
+	api   *ApiDescription
+	state *AnalysisState
+	scope types.Scope
+
+	// Warnings are only emitted on the final pass.
+	warn bool
+
+	// The scopes where each LET definition is used.
+	callers map[string][]*nameScope
+}
+
+func (self *typeInferrer) inferVQL(vql *vfilter.VQL) {
+	root := newNameScope(nil, nil, "")
+	if vql.Let != "" {
+		root.callers = self.callers[vql.Let]
+	}
+	for _, p := range vql.LetParameters {
+		if p.Name != nil {
+			root.names[*p.Name] = true
+		}
+		if p.DefaultArg != nil {
+			root.names[p.DefaultArg.Left] = true
+		}
+	}
+
+	switch {
+	case vql.StoredQuery != nil:
+		self.state.Schemas[vql.Let] = self.inferSelect(
+			reflect.ValueOf(vql.StoredQuery), root)
+
+	case vql.Expression != nil:
+		expression := reflect.ValueOf(vql.Expression)
+
+		// LET X = Y makes X an alias for the stored query Y.
+		name, ok := bareSymbol(expression)
+		if ok {
+			schema, pres := self.state.Schemas[name]
+			if pres {
+				self.state.Schemas[vql.Let] = schema
+			}
+		}
+		self.walkExpression(expression, root, false)
+
+	case vql.Query != nil:
+		self.inferSelect(reflect.ValueOf(vql.Query), root)
+	}
+}
+
+func (self *typeInferrer) inferSelect(
+	node reflect.Value, outer *nameScope) *RowSchema {
+
+	plugin := astField(astField(node, "From"), "Plugin")
+	plugin_name := astString(plugin, "Name")
+	row := self.inferPlugin(plugin, outer)
+
+	scope := newNameScope(outer, row, plugin_name+"()")
+	result := &RowSchema{}
+
+	// Column aliases may be referred to in other columns and in the
+	// WHERE clause.
+	select_expression := astField(node, "SelectExpression")
+	columns := astField(select_expression, "Expressions")
+	for i := 0; i < astLen(columns); i++ {
+		alias := astString(columns.Index(i), "As")
+		if alias != "" {
+			scope.names[vfilter_utils.Unquote_ident(alias)] = true
+		}
+	}
+
+	if astBool(select_expression, "All") {
+		result.Merge(row)
+	}
+
+	for i := 0; i < astLen(columns); i++ {
+		column := columns.Index(i)
+		if astField(column, "Star").IsValid() {
+			result.Merge(row)
+			continue
+		}
+
+		column_type := "Any"
+		sub_select := astField(column, "SubSelect")
+		if sub_select.IsValid() {
+			self.inferSelect(sub_select, scope)
+		} else {
+			expression := astField(column, "Expression")
+			self.walkExpression(expression, scope, true)
+			column_type = self.expressionType(expression, row)
+		}
+
+		namer, ok := column.Interface().(interface {
+			GetName(scope types.Scope) string
+		})
+		if ok {
+			result.Add(Column{
+				Name: namer.GetName(self.scope),
+				Type: column_type,
+			})
+		}
+	}
+
+	self.walkExpression(astField(node, "Where"), scope, true)
+	self.walkExpression(astField(node, "GroupBy"), scope, true)
+
+	return result
+}
+
+// Infer the schema of the rows emitted by the plugin. Plugin args
+// are evaluated in the enclosing scope.
+func (self *typeInferrer) inferPlugin(
+	node reflect.Value, outer *nameScope) *RowSchema {
+	name := astString(node, "Name")
+	args := astField(node, "Args")
+
+	switch name {
+	case "foreach":
+		row := NewOpenRowSchema()
+		var query reflect.Value
+
+		for i := 0; i < astLen(args); i++ {
+			arg := args.Index(i)
+			switch astString(arg, "Left") {
+			case "row":
+				row = self.argSchema(arg, outer)
+			case "query":
+				query = arg
+			case "column":
+				// Expanding a column produces arbitrary rows.
+				self.walkExpression(arg, outer, false)
+				row = NewOpenRowSchema()
+			default:
+				self.walkExpression(arg, outer, false)
+			}
+		}
+
+		if !query.IsValid() {
+			return row
+		}
+
+		// The query runs with the row's columns in scope.
+		sub_select := astField(query, "SubSelect")
+		if sub_select.IsValid() {
+			return self.inferSelect(sub_select,
+				newNameScope(outer, row, "foreach() row"))
+		}
+		return self.argSchema(query, outer)
+
+	case "chain", "if":
+		result := &RowSchema{}
+		for i := 0; i < astLen(args); i++ {
+			arg := args.Index(i)
+			switch astString(arg, "Left") {
+			case "async", "condition":
+				self.walkExpression(arg, outer, false)
+			default:
+				result.Merge(self.argSchema(arg, outer))
+			}
+		}
+		return result
+	}
+
+	self.walkExpression(args, outer, false)
+	self.addCaller(name, outer)
+
+	// A LET stored query.
+	schema, pres := self.state.Schemas[name]
+	if pres {
+		return schema
+	}
+
+	// A plugin which declares its columns. Accessors may add their
+	// own columns so we only know the columns with the default
+	// accessor.
+	desc, pres := self.api.plugins[name]
+	if pres && desc.Columns != nil && !hasArgNamed(args, "accessor") {
+		return desc.Columns
+	}
+
+	return NewOpenRowSchema()
+}
+
+// The schema of a plugin arg which receives a query.
+func (self *typeInferrer) argSchema(
+	node reflect.Value, outer *nameScope) *RowSchema {
+	sub_select := astField(node, "SubSelect")
+	if sub_select.IsValid() {
+		return self.inferSelect(sub_select, outer)
+	}
+
+	self.walkExpression(node, outer, false)
+
+	// Passing a stored query to a plugin.
+	name, ok := bareSymbol(astField(node, "Right"))
+	if ok {
+		schema, pres := self.state.Schemas[name]
+		if pres {
+			return schema
+		}
+	}
+	return NewOpenRowSchema()
+}
+
+// Walk the expression looking for subqueries and symbol
+// references. Symbols are only checked against the row when check is
+// set.
+func (self *typeInferrer) walkExpression(
+	node reflect.Value, scope *nameScope, check bool) {
+	if !node.IsValid() {
+		return
+	}
+
+	switch node.Kind() {
+	case reflect.Ptr:
+		if !node.IsNil() {
+			self.walkExpression(node.Elem(), scope, check)
+		}
+
+	case reflect.Slice:
+		for i := 0; i < node.Len(); i++ {
+			self.walkExpression(node.Index(i), scope, check)
+		}
+
+	case reflect.Struct:
+		switch node.Type().Name() {
+		case "_Select":
+			self.inferSelect(node, scope)
+			return
+
+		case "_SymbolRef":
+			name := vfilter_utils.Unquote_ident(astString(node, "Symbol"))
+			name, _, _ = strings.Cut(name, ".")
+			self.addCaller(name, scope)
+			self.checkSymbol(name, node, scope, check)
+		}
+
+		node_type := node.Type()
+		for i := 0; i < node.NumField(); i++ {
+			field := node_type.Field(i)
+			if field.IsExported() && field.Name != "Comments" {
+				self.walkExpression(node.Field(i), scope, check)
+			}
+		}
+	}
+}
+
+// Remember the scope a LET definition is used from. Only done on
+// the first pass.
+func (self *typeInferrer) addCaller(name string, scope *nameScope) {
+	if self.warn {
+		return
+	}
+
+	_, pres := self.state.Definitions[name]
+	if pres {
+		self.callers[name] = append(self.callers[name], scope)
+	}
+}
+
+func (self *typeInferrer) checkSymbol(name string,
+	node reflect.Value, scope *nameScope, check bool) {
+	if !check || !self.warn || astBool(node, "Called") {
+		return
+	}
+
+	if scope.has(name) || scope.isOpen() || self.isKnownSymbol(name) {
+		return
+	}
+
+	// Find the row the expression is evaluated on.
+	for scope.row == nil && scope.parent != nil {
+		scope = scope.parent
+	}
+	if scope.row == nil {
+		return
+	}
+
+	emitWarning(UNKNOWN_COLUMN, astRange(node), self.state,
+		UNKNOWN_COLUMN_MSG, name, scope.from,
+		strings.Join(scope.row.Names(), ", "))
+}
+
+// Symbols which do not refer to columns.
+func (self *typeInferrer) isKnownSymbol(name string) bool {
+	if strings.HasPrefix(name, "_") || strings.HasPrefix(name, "Tool_") {
+		return true
+	}
+
+	for _, v := range wellKnownScopeVars {
+		if v == name {
+			return true
+		}
+	}
+
+	for _, v := range self.state.ScopeVars {
+		if v == name {
+			return true
+		}
+	}
+
+	_, pres := self.state.Definitions[name]
+	if pres {
+		return true
+	}
+
+	_, pres = self.api.functions[name]
+	if pres {
+		return true
+	}
+
+	_, pres = self.api.plugins[name]
+	return pres
+}
+
+// A rough type for simple expressions.
+func (self *typeInferrer) expressionType(
+	node reflect.Value, row *RowSchema) string {
+	value := bareValue(node)
+
+	symbol := astField(value, "SymbolRef")
+	if symbol.IsValid() {
+		if astBool(symbol, "Called") {
+			return "Any"
+		}
+		column, pres := row.Get(
+			vfilter_utils.Unquote_ident(astString(symbol, "Symbol")))
+		if pres && column.Type != "" {
+			return column.Type
+		}
+		return "Any"
+	}
+
+	switch {
+	case astField(value, "String").IsValid():
+		return "string"
+	case astField(value, "Boolean").IsValid():
+		return "bool"
+	case astField(value, "StrNumber").IsValid():
+		if strings.Contains(astField(value, "StrNumber").Elem().String(), ".") {
+			return "float64"
+		}
+		return "int64"
+	}
+	return "Any"
+}
+
+// Run the type inference on the parsed VQL. Stored queries may be
+// defined after they are used, so we make a first pass to infer
+// their schemas and find their callers before checking the queries.
+func (self *ApiDescription) inferTypes(
+	vqls []*vfilter.VQL, state *AnalysisState) {
+
+	inferrer := &typeInferrer{
+		api:     self,
+		state:   state,
+		scope:   vfilter.NewScope(),
+		callers: make(map[string][]*nameScope),
+	}
+
+	for _, vql := range vqls {
+		inferrer.inferVQL(vql)
+	}
+
+	inferrer.warn = true
+	for _, vql := range vqls {
+		inferrer.inferVQL(vql)
+	}
+}
+
+// Helpers to walk the vfilter AST: The AST types are not exported
+// but their fields are, so we access them using reflection.
+func astField(node reflect.Value, name string) reflect.Value {
+	node = reflect.Indirect(node)
+	if !node.IsValid() || node.Kind() != reflect.Struct {
+		return reflect.Value{}
+	}
+
+	field := node.FieldByName(name)
+	if field.Kind() == reflect.Ptr && field.IsNil() {
+		return reflect.Value{}
+	}
+	return field
+}
+
+func astString(node reflect.Value, name string) string {
+	field := astField(node, name)
+	if field.Kind() == reflect.String {
+		return field.String()
+	}
+	return ""
+}
+
+func astBool(node reflect.Value, name string) bool {
+	field := reflect.Indirect(astField(node, name))
+	return field.Kind() == reflect.Bool && field.Bool()
+}
+
+func astLen(node reflect.Value) int {
+	if node.Kind() == reflect.Slice {
+		return node.Len()
+	}
+	return 0
+}
+
+func astRange(node reflect.Value) (res vfilter.RangePosition) {
+	res.Pos, _ = astField(node, "Pos").Interface().(lexer.Position)
+	res.EndPos, _ = astField(node, "EndPos").Interface().(lexer.Position)
+	return res
+}
+
+// Descend through an expression which consists of a single value
+// (i.e. no operators).
+func bareValue(node reflect.Value) reflect.Value {
+	for {
+		node = reflect.Indirect(node)
+		if !node.IsValid() || node.Type().Name() == "_Value" {
+			return node
+		}
+
+		if astField(node, "Not").IsValid() {
+			return reflect.Value{}
+		}
+
+		right := astField(node, "Right")
+		if right.IsValid() && (right.Kind() != reflect.Slice || right.Len() > 0) {
+			return reflect.Value{}
+		}
+
+		node = astField(node, "Left")
+	}
+}
+
+func hasArgNamed(args reflect.Value, name string) bool {
+	for i := 0; i < astLen(args); i++ {
+		if astString(args.Index(i), "Left") == name {
+			return true
+		}
+	}
+	return false
+}
+
+// If the expression is a single symbol return its name.
+func bareSymbol(node reflect.Value) (string, bool) {
+	symbol := astField(bareValue(node), "SymbolRef")
+	if !symbol.IsValid() || astBool(symbol, "Called") {
+		return "", false
+	}
+	return vfilter_utils.Unquote_ident(astString(symbol, "Symbol")), true
+}
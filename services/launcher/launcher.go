@@ -629,6 +629,38 @@ func (self *Launcher) ScheduleArtifactCollection(
 		args = append(args, compiled...)
 	}
 
+	if collector_request.DryRun {
+		if collector_request.ClientId == constants.VELOCIRAPTOR_SERVER_CLIENT_ID {
+			return "", errors.New(
+				"ScheduleArtifactCollection: Dry run is only supported on clients")
+		}
+
+		err := checkDryRunSupported(ctx, config_obj, collector_request.ClientId)
+		if err != nil {
+			return "", err
+		}
+		args = markDryRun(args)
+	}
+
+	if collector_request.Incremental {
+		if collector_request.ClientId == constants.VELOCIRAPTOR_SERVER_CLIENT_ID {
+			return "", errors.New(
+				"ScheduleArtifactCollection: Incremental collections are only supported on clients")
+		}
+
+		base, err := self.findIncrementalBase(ctx, config_obj,
+			collector_request.ClientId, collector_request.Artifacts, args)
+		if err != nil {
+			return "", err
+		}
+
+		// The request may be shared (e.g. by hunts) so record the
+		// base on a copy.
+		collector_request = proto.Clone(collector_request).(*flows_proto.ArtifactCollectorArgs)
+		collector_request.IncrementalBase = base
+		args = markIncremental(args, base)
+	}
+
 	return self.WriteArtifactCollectionRecord(
 		ctx, config_obj, collector_request, args,
 		func(task *crypto_proto.VeloMessage) {
@@ -644,6 +676,46 @@ func (self *Launcher) ScheduleArtifactCollection(
 		})
 }
 
+// Refuse to send a dry run to a client which would not honor it. The
+// version is only known after the client was interrogated.
+func checkDryRunSupported(
+	ctx context.Context, config_obj *config_proto.Config,
+	client_id string) error {
+	client_manager, err := services.GetClientInfoManager(config_obj)
+	if err != nil {
+		return err
+	}
+
+	client_info, err := client_manager.Get(ctx, client_id)
+	if err != nil {
+		return err
+	}
+
+	if client_info.ClientVersion == "" ||
+		utils.CompareVersions("velociraptor", client_info.ClientVersion,
+			constants.DRY_RUN_MIN_CLIENT_VERSION) < 0 {
+		return fmt.Errorf(
+			"ScheduleArtifactCollection: Client %v (version %q) does not support dry runs. Version %v or later is required.",
+			client_id, client_info.ClientVersion,
+			constants.DRY_RUN_MIN_CLIENT_VERSION)
+	}
+	return nil
+}
+
+// Dry run requests are sent to the client with uploads and side
+// effects disabled. The compiled args may be cached in the request
+// (e.g. for hunts) so we mark copies of them.
+func markDryRun(
+	args []*actions_proto.VQLCollectorArgs) []*actions_proto.VQLCollectorArgs {
+	result := make([]*actions_proto.VQLCollectorArgs, 0, len(args))
+	for _, arg := range args {
+		arg = proto.Clone(arg).(*actions_proto.VQLCollectorArgs)
+		arg.DryRun = true
+		result = append(result, arg)
+	}
+	return result
+}
+
 func (self *Launcher) WriteArtifactCollectionRecord(
 	ctx context.Context,
 	config_obj *config_proto.Config,
@@ -1569,6 +1569,177 @@ func (self *LauncherTestSuite) TestScheduleHugeArtifact() {
 	assert.True(self.T(), errors.Is(err, utils.MemoryError))
 }
 
+func (self *LauncherTestSuite) TestScheduleDryRun() {
+	t := self.T()
+
+	client_id := "C.1234"
+	self.CreateClient(client_id)
+
+	launcher, err := services.GetLauncher(self.ConfigObj)
+	assert.NoError(t, err)
+
+	repository := self.LoadArtifacts(DependentArtifacts...)
+	acl_manager := acl_managers.NullACLManager{}
+
+	request := &flows_proto.ArtifactCollectorArgs{
+		Creator:   "admin",
+		ClientId:  client_id,
+		Artifacts: []string{"Test.Artifact"},
+		DryRun:    true,
+	}
+
+	// The client was never interrogated so we do not know if it
+	// supports dry runs.
+	_, err = launcher.ScheduleArtifactCollection(
+		self.Ctx, self.ConfigObj, acl_manager,
+		repository, request, utils.SyncCompleter)
+	assert.ErrorContains(t, err, "does not support dry runs")
+
+	client_info_manager, err := services.GetClientInfoManager(self.ConfigObj)
+	assert.NoError(t, err)
+
+	// Older clients would ignore the dry run and collect for real.
+	setVersion := func(version string) {
+		err := client_info_manager.Set(self.Ctx, &services.ClientInfo{
+			ClientInfo: &actions_proto.ClientInfo{
+				ClientId:      client_id,
+				ClientVersion: version,
+			}})
+		assert.NoError(t, err)
+	}
+
+	setVersion("0.7.1")
+	_, err = launcher.ScheduleArtifactCollection(
+		self.Ctx, self.ConfigObj, acl_manager,
+		repository, request, utils.SyncCompleter)
+	assert.ErrorContains(t, err, "does not support dry runs")
+
+	tasks, err := client_info_manager.PeekClientTasks(self.Ctx, client_id)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(tasks))
+
+	setVersion(constants.DRY_RUN_MIN_CLIENT_VERSION)
+	_, err = launcher.ScheduleArtifactCollection(
+		self.Ctx, self.ConfigObj, acl_manager,
+		repository, request, utils.SyncCompleter)
+	assert.NoError(t, err)
+
+	tasks, err = client_info_manager.PeekClientTasks(self.Ctx, client_id)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(tasks))
+
+	// All queries sent to the client are marked as dry run.
+	actions := tasks[0].FlowRequest.VQLClientActions
+	assert.True(t, len(actions) > 0)
+	for _, action := range actions {
+		assert.True(t, action.DryRun)
+	}
+
+	// Dry runs are not supported on the server.
+	request.ClientId = constants.VELOCIRAPTOR_SERVER_CLIENT_ID
+	_, err = launcher.ScheduleArtifactCollection(
+		self.Ctx, self.ConfigObj, acl_manager,
+		repository, request, utils.SyncCompleter)
+	assert.Error(t, err)
+}
+
+var incrementalArtifact = `
+name: Test.Incremental
+parameters:
+ - name: Foo
+   default: Bar
+
+sources:
+- key_column: Key
+  query:  |
+    SELECT Foo AS Key FROM scope()
+`
+
+func (self *LauncherTestSuite) TestScheduleIncremental() {
+	t := self.T()
+
+	client_id := "C.1234"
+	self.CreateClient(client_id)
+
+	launcher, err := services.GetLauncher(self.ConfigObj)
+	assert.NoError(t, err)
+
+	repository := self.LoadArtifacts(incrementalArtifact)
+	acl_manager := acl_managers.NullACLManager{}
+
+	client_info_manager, err := services.GetClientInfoManager(self.ConfigObj)
+	assert.NoError(t, err)
+
+	schedule := func(foo string) (string, *actions_proto.VQLCollectorArgs) {
+		request := &flows_proto.ArtifactCollectorArgs{
+			Creator:     "admin",
+			ClientId:    client_id,
+			Artifacts:   []string{"Test.Incremental"},
+			Incremental: true,
+			Specs: []*flows_proto.ArtifactSpec{{
+				Artifact: "Test.Incremental",
+				Parameters: &flows_proto.ArtifactParameters{
+					Env: []*actions_proto.VQLEnv{{Key: "Foo", Value: foo}},
+				},
+			}},
+		}
+
+		flow_id, err := launcher.ScheduleArtifactCollection(
+			self.Ctx, self.ConfigObj, acl_manager,
+			repository, request, utils.SyncCompleter)
+		assert.NoError(t, err)
+
+		tasks, err := client_info_manager.PeekClientTasks(self.Ctx, client_id)
+		assert.NoError(t, err)
+		assert.True(t, len(tasks) > 0)
+
+		actions := tasks[len(tasks)-1].FlowRequest.VQLClientActions
+		assert.True(t, len(actions) > 0)
+		return flow_id, actions[0]
+	}
+
+	finish := func(flow_id string) {
+		flow, err := launcher.Storage().LoadCollectionContext(self.Ctx,
+			self.ConfigObj, client_id, flow_id, services.GetFlowOptions{})
+		assert.NoError(t, err)
+
+		flow.State = flows_proto.ArtifactCollectorContext_FINISHED
+		err = launcher.Storage().WriteFlow(self.Ctx, self.ConfigObj, flow,
+			services.GetFlowOptions{}, utils.SyncCompleter)
+		assert.NoError(t, err)
+	}
+
+	// The first collection has nothing to compare against.
+	first_flow_id, action := schedule("Bar")
+	assert.True(t, action.Incremental)
+	assert.Equal(t, "", action.IncrementalBase)
+
+	// Until the first collection is finished it can not be a base.
+	_, action = schedule("Bar")
+	assert.Equal(t, "", action.IncrementalBase)
+
+	finish(first_flow_id)
+
+	_, action = schedule("Bar")
+	assert.True(t, action.Incremental)
+	assert.Equal(t, first_flow_id, action.IncrementalBase)
+
+	// The client keeps separate state for different parameters so a
+	// collection with other parameters starts from scratch.
+	other_flow_id, action := schedule("Baz")
+	assert.True(t, action.Incremental)
+	assert.Equal(t, "", action.IncrementalBase)
+
+	// Even when it is the most recent finished collection.
+	finish(other_flow_id)
+
+	_, action = schedule("Bar")
+	assert.Equal(t, first_flow_id, action.IncrementalBase)
+
+	_, action = schedule("Baz")
+	assert.Equal(t, other_flow_id, action.IncrementalBase)
+}
+
 func TestLauncher(t *testing.T) {
 	suite.Run(t, &LauncherTestSuite{})
 }
@@ -48,6 +48,9 @@ const (
 	SYMBOL_MASK_WARN     = "symbol_mask_warn"
 	SYMBOL_MASK_WARN_MSG = "Use of symbol `%[1]v` which might mask a %[2]v of the same name"
 
+	UNKNOWN_COLUMN     = "unknown_column"
+	UNKNOWN_COLUMN_MSG = "Column %[1]v is not known in rows from %[2]v (known columns: %[3]v)"
+
 	INVALID_SUPPRESSION             = "invalid_suppression"
 	INVALID_SUPPRESSION_MSG         = "Suppression %[1]v not valid: %[2]v"
 	INVALID_SUPPRESSION_UNKNOWN_MSG = "Suppression %[1]v not known"
@@ -60,7 +63,8 @@ func validLinterDirectives(name string) bool {
 	case UNKNOWN_PARAMETER_IN_CALL, UNKNOWN_ARTIFACT_IN_QUERY,
 		UNKNOWN_PLUGIN, KWARGS_MIXED_CALL, CALL_AS_FUNCTION, INVALID_ARG,
 		REQUIRED_ARG_MISSING, INVALID_IMPORT, ARTIFACT_VQL_ERROR, YAML_ERROR,
-		REQUIRED_PERMISSIONS, SYMBOL_MASK_WARN, INVALID_SUPPRESSION:
+		REQUIRED_PERMISSIONS, SYMBOL_MASK_WARN, INVALID_SUPPRESSION,
+		UNKNOWN_COLUMN:
 		return true
 	}
 	return false
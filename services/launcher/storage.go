@@ -80,6 +80,10 @@ func (self *FlowStorageManager) WriteFlow(
 			MaxRows:         flow.Request.MaxRows,
 			MaxLogs:         flow.Request.MaxLogs,
 			MaxUploadBytes:  flow.Request.MaxUploadBytes,
+
+			// Needed to stitch incremental results together.
+			Incremental:     flow.Request.Incremental,
+			IncrementalBase: flow.Request.IncrementalBase,
 		}
 		reducted.PreviousFlows = nil
 
@@ -47,6 +47,13 @@ type AnalysisState struct {
 	// Keep track of existing definitions in LET queries.
 	Definitions  map[string]vfilter.DefinitionSite
 	Suppressions []Suppression
+
+	// The inferred row schemas of LET stored queries.
+	Schemas map[string]*RowSchema
+
+	// Other variables known to be in scope (e.g. artifact
+	// parameters).
+	ScopeVars []string
 }
 
 func (self *AnalysisState) SetError(
@@ -152,6 +159,7 @@ func NewAnalysisState(artifact string) *AnalysisState {
 	return &AnalysisState{
 		Artifact:    artifact,
 		Definitions: make(map[string]vfilter.DefinitionSite),
+		Schemas:     make(map[string]*RowSchema),
 	}
 }
 
@@ -162,6 +170,9 @@ type CallDescriptor struct {
 	Permissions  []string
 
 	FreeFormArgs bool
+
+	// The columns emitted by the plugin if declared.
+	Columns *RowSchema
 }
 
 func (self *CallDescriptor) SetPermissions(api *api_proto.Completion) {
@@ -173,6 +184,15 @@ func (self *CallDescriptor) SetPermissions(api *api_proto.Completion) {
 	}
 }
 
+func (self *CallDescriptor) SetColumns(api *api_proto.Completion) {
+	if api.Metadata != nil {
+		columns, pres := api.Metadata["columns"]
+		if pres {
+			self.Columns = ParseRowSchema(columns)
+		}
+	}
+}
+
 func NewCallDescriptor(api *api_proto.Completion) CallDescriptor {
 	res := &CallDescriptor{
 		ArgsRequired: make(map[string]Required),
@@ -180,6 +200,7 @@ func NewCallDescriptor(api *api_proto.Completion) CallDescriptor {
 	}
 
 	res.SetPermissions(api)
+	res.SetColumns(api)
 	return *res
 }
 
@@ -528,6 +549,13 @@ func VerifyVQL(ctx context.Context, config_obj *config_proto.Config,
 		}
 	}
 
+	// Check the columns referenced by the queries.
+	err = api_description.init()
+	if err != nil {
+		return append(res, err)
+	}
+	api_description.inferTypes(vqls, state)
+
 	return res
 }
 
@@ -550,6 +578,11 @@ func VerifyArtifact(
 	// sections in this artifact.
 	gatherSuppressions(scope, state, artifact)
 
+	// Parameters are available in the scope.
+	for _, p := range artifact.Parameters {
+		state.ScopeVars = append(state.ScopeVars, p.Name)
+	}
+
 	preamble := ""
 	for _, imp := range artifact.Imports {
 		dep, pres := repository.Get(ctx, config_obj, imp)
@@ -235,3 +235,103 @@ func (self *LauncherTestSuite) TestVerifyArtifact() {
 		}
 	}
 }
+
+var (
+	verifier_test_cases_columns = []struct {
+		desc          string
+		artifact      string
+		warning_regex string
+	}{
+		{"Known plugin column", `
+name: Test
+sources:
+- query: SELECT OSPath, Size FROM glob(globs="/*")
+`, ""},
+
+		{"Unknown plugin column", `
+name: Test
+sources:
+- query: SELECT Fullpath FROM glob(globs="/*")
+`, "unknown_column: Column Fullpath is not known in rows from glob()"},
+
+		{"Column from LET", `
+name: Test
+sources:
+- query: |
+    LET X = SELECT OSPath AS Path FROM glob(globs="/*")
+    SELECT Path, OSPath FROM X
+`, "Column OSPath is not known in rows from X()"},
+
+		{"Column from foreach row", `
+name: Test
+sources:
+- query: |
+    LET X = SELECT OSPath AS Path FROM glob(globs="/*")
+    SELECT * FROM foreach(row=X, query={
+       SELECT Path, Hostname, Foo FROM info()
+    })
+`, "Column Foo is not known in rows from info()"},
+
+		{"Parameters and aliases", `
+name: Test
+parameters:
+- name: Glob
+sources:
+- query: |
+    SELECT OSPath AS Path FROM glob(globs=Glob)
+    WHERE Path =~ "foo"
+`, ""},
+
+		{"Columns of protobuf rows", `
+name: Test
+implied_permissions:
+- READ_RESULTS
+sources:
+- query: |
+    SELECT client_id, os_info.hostname AS Hostname FROM clients()
+`, ""},
+
+		{"Unknown column of protobuf rows", `
+name: Test
+implied_permissions:
+- READ_RESULTS
+sources:
+- query: SELECT OsInfo FROM clients()
+`, "Column OsInfo is not known in rows from clients()"},
+
+		{"Suppress unknown column", `
+name: Test
+sources:
+- query: |
+    // linter: unknown_column:Fullpath
+    SELECT Fullpath FROM glob(globs="/*")
+`, ""},
+	}
+)
+
+func (self *LauncherTestSuite) TestVerifyColumns() {
+	manager, err := services.GetRepositoryManager(self.ConfigObj)
+	assert.NoError(self.T(), err)
+
+	repository, err := manager.GetGlobalRepository(self.ConfigObj)
+	assert.NoError(self.T(), err)
+
+	for _, tc := range verifier_test_cases_columns {
+		state := launcher.NewAnalysisState("")
+
+		artifact, err := repository.LoadYaml(tc.artifact, services.ArtifactOptions{
+			ValidateArtifact: true,
+		})
+		assert.NoError(self.T(), err)
+
+		launcher.VerifyArtifact(self.Ctx, self.ConfigObj, repository, artifact, state)
+		assert.Empty(self.T(), state.Errors, tc.desc)
+
+		if tc.warning_regex == "" {
+			assert.Empty(self.T(), state.Warnings, tc.desc)
+			continue
+		}
+		assert.Regexp(self.T(), tc.warning_regex,
+			fmt.Sprintf("%v", state.Warnings), tc.desc)
+	}
+}
@@ -0,0 +1,417 @@
+package lsp
+
+import (
+	"context"
+	"errors"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/alecthomas/participle/v2"
+	"github.com/alecthomas/participle/v2/lexer"
+	"go.lsp.dev/uri"
+	config_proto "www.velocidex.com/golang/velociraptor/config/proto"
+	"www.velocidex.com/golang/velociraptor/services"
+	"www.velocidex.com/golang/velociraptor/services/launcher"
+	"www.velocidex.com/golang/velociraptor/utils/yaml"
+	"www.velocidex.com/golang/vfilter"
+)
+
+// Artifact YAML documents embed VQL in a number of fields. We present
+// the rest of the lsp server with a view of the document where
+// everything except the VQL is blanked out. Since the VQL remains at
+// the same line and column, all positions reported by the VQL
+// analysis map directly onto the YAML document.
+
+var (
+	yamlErrorLineRegex = regexp.MustCompile(`line (\d+)`)
+
+	emptyDocumentError = errors.New("Artifact document is empty")
+
+	// The verifier message used for each type of VQL block.
+	vqlBlockMessages = map[string]string{
+		"export":       launcher.ARTIFACT_VQL_EXPORT_MSG,
+		"precondition": launcher.ARTIFACT_VQL_PRECOND_MSG,
+		"query":        launcher.ARTIFACT_VQL_QUERY_MSG,
+	}
+)
+
+func isArtifactDocument(url uri.URI) bool {
+	switch strings.ToLower(path.Ext(string(url))) {
+	case ".yaml", ".yml":
+		return true
+	}
+	return false
+}
+
+// Blank out everything in the text except the VQL within the
+// node. Byte offsets, lines and columns of the VQL are preserved.
+func maskYamlNode(text string, node yaml.NodeContext) string {
+	out := []byte(text)
+	keep := make([]bool, len(out))
+
+	lines := strings.SplitAfter(text, "\n")
+	line_offsets := make([]int, len(lines))
+	offset := 0
+	for idx, line := range lines {
+		line_offsets[idx] = offset
+		offset += len(line)
+	}
+
+	// Continuation lines are indented deeper than the key.
+	key_indent := 0
+	if node.Parent != nil && node.Parent.Column > 0 {
+		key_indent = node.Parent.Column - 1
+	}
+
+	keep_range := func(start, end int) {
+		for i := start; i < end && i < len(keep); i++ {
+			keep[i] = true
+		}
+	}
+
+	line_idx := node.Line - 1
+	if line_idx < 0 || line_idx >= len(lines) {
+		return maskAll(text)
+	}
+
+	first_kept := -1
+	last_kept := -1
+
+	// Block scalars start on the line after the indicator
+	// (e.g. query: |), other scalars start at the node's column.
+	if node.Style&(yaml.LiteralStyle|yaml.FoldedStyle) == 0 {
+		first_kept = line_offsets[line_idx] + node.Column - 1
+		last_kept = line_offsets[line_idx] + len(lines[line_idx])
+		keep_range(first_kept, last_kept)
+	}
+
+	for line_idx++; line_idx < len(lines); line_idx++ {
+		line := lines[line_idx]
+		trimmed := strings.TrimLeft(line, " \t")
+		if strings.TrimSpace(line) != "" &&
+			len(line)-len(trimmed) <= key_indent {
+			break
+		}
+		if first_kept < 0 {
+			first_kept = line_offsets[line_idx]
+		}
+		last_kept = line_offsets[line_idx] + len(line)
+		keep_range(line_offsets[line_idx], last_kept)
+	}
+
+	// Remove the quotes from quoted scalars.
+	if node.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle) != 0 &&
+		first_kept >= 0 {
+		keep[first_kept] = false
+		for i := last_kept - 1; i > first_kept; i-- {
+			if out[i] == '"' || out[i] == '\'' {
+				keep[i] = false
+				break
+			}
+			if out[i] != ' ' && out[i] != '\n' && out[i] != '\r' {
+				break
+			}
+		}
+	}
+
+	for i := range out {
+		if !keep[i] && out[i] != '\n' {
+			out[i] = ' '
+		}
+	}
+	return string(out)
+}
+
+func maskAll(text string) string {
+	out := []byte(text)
+	for i := range out {
+		if out[i] != '\n' {
+			out[i] = ' '
+		}
+	}
+	return string(out)
+}
+
+// Overlay the VQL from the masked text b over the masked text a.
+func mergeMasked(a, b string) string {
+	out := []byte(a)
+	for i := 0; i < len(out) && i < len(b); i++ {
+		if b[i] != ' ' {
+			out[i] = b[i]
+		}
+	}
+	return string(out)
+}
+
+// The range of a scalar node in the document.
+func yamlNodeRange(node *yaml.Node) vfilter.RangePosition {
+	length := len(node.Value)
+	if node.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle) != 0 {
+		length += 2
+	}
+	return vfilter.RangePosition{
+		Pos:    lexer.Position{Line: node.Line, Column: node.Column},
+		EndPos: lexer.Position{Line: node.Line, Column: node.Column + length},
+	}
+}
+
+func getYamlNodes(root *yaml.Node, field string) (nodes []yaml.NodeContext) {
+	yaml.GetYamlNodes(root, root, strings.Split(field, "."), &nodes)
+	return nodes
+}
+
+// An embedded VQL block in the artifact.
+type vqlBlock struct {
+	// The artifact or source name and the field name.
+	name  string
+	field string
+
+	node   yaml.NodeContext
+	masked string
+}
+
+func (self *vqlBlock) newError(
+	message string, pos vfilter.RangePosition) *launcher.VerifierError {
+	return &launcher.VerifierError{
+		Name:    launcher.ARTIFACT_VQL_ERROR,
+		Message: vqlBlockMessages[self.field],
+		Args:    []interface{}{self.name, message},
+		Pos:     pos,
+	}
+}
+
+func (self *vqlBlock) Pos() vfilter.RangePosition {
+	return yamlNodeRange(self.node.Node)
+}
+
+// Convert the errors from VerifyVQL into positioned errors.
+func (self *vqlBlock) verifierErrors(errs []error) (
+	res []*launcher.VerifierError) {
+	for _, err := range errs {
+		verifier_error, ok := err.(*launcher.VerifierError)
+		if ok {
+			res = append(res, verifier_error)
+			continue
+		}
+
+		// Parse errors carry their position in the (masked) text
+		// which is also the position in the document.
+		var parse_error participle.Error
+		if errors.As(err, &parse_error) {
+			pos := parse_error.Position()
+			res = append(res, self.newError(parse_error.Message(),
+				vfilter.RangePosition{Pos: pos, EndPos: pos}))
+			continue
+		}
+
+		res = append(res, self.newError(err.Error(), self.Pos()))
+	}
+	return res
+}
+
+func NewArtifactDocument(
+	ctx context.Context,
+	config_obj *config_proto.Config,
+	url uri.URI,
+	text string) (*Document, error) {
+
+	manager, err := services.GetRepositoryManager(config_obj)
+	if err != nil {
+		return nil, err
+	}
+
+	repository, err := manager.GetGlobalRepository(config_obj)
+	if err != nil {
+		return nil, err
+	}
+
+	state := launcher.NewAnalysisState("")
+	res := &Document{
+		URI:           url,
+		Text:          maskAll(text),
+		YAML:          text,
+		AnalysisState: state,
+	}
+
+	var node yaml.Node
+	err = yaml.Unmarshal([]byte(text), &node)
+	if err != nil || len(node.Content) == 0 {
+		state.FailedToParse = true
+		res.Errors = append(res.Errors,
+			yamlError(err, vfilter.RangePosition{}))
+		return res, nil
+	}
+	root := node.Content[0]
+
+	// Where to report errors that relate to the whole artifact.
+	artifact_pos := vfilter.RangePosition{}
+	name := ""
+	for _, n := range getYamlNodes(root, "name") {
+		artifact_pos = yamlNodeRange(n.Node)
+		name = n.Value
+	}
+	state.Artifact = name
+
+	// Validate the artifact against the schema. Load it into a local
+	// repository so the global repository is not modified. We do not
+	// ask the repository to validate the VQL because we do that
+	// below with more precise positions.
+	local_repository := manager.NewRepository()
+	local_repository.SetParent(repository, config_obj)
+
+	artifact, err := local_repository.LoadYaml(text,
+		services.ArtifactOptions{})
+	if err != nil {
+		res.Errors = append(res.Errors, yamlError(err, artifact_pos))
+		artifact = nil
+	}
+
+	// Parameters are visible to all the VQL as scope variables.
+	definitions := make(map[string]vfilter.DefinitionSite)
+	for _, n := range getYamlNodes(root, "parameters.[].name") {
+		definitions[n.Value] = vfilter.DefinitionSite{
+			Name: n.Value,
+			Type: "parameter",
+			Pos:  yamlNodeRange(n.Node),
+		}
+	}
+
+	// Run the artifact verifier to get artifact level errors and
+	// warnings (e.g. missing imports and permissions). VQL errors
+	// are reported separately below with precise positions.
+	var suppressions []launcher.Suppression
+	if artifact != nil {
+		verifier_state := launcher.NewAnalysisState(artifact.Name)
+		launcher.VerifyArtifact(ctx, config_obj, local_repository,
+			artifact, verifier_state)
+
+		for _, e := range verifier_state.Errors {
+			if e.Name == launcher.ARTIFACT_VQL_ERROR {
+				continue
+			}
+			e.Pos = artifact_pos
+			res.Errors = append(res.Errors, e)
+		}
+
+		for _, w := range verifier_state.Warnings {
+			if w.Pos.Pos.Line != 0 {
+				continue
+			}
+			w.Pos = artifact_pos
+			res.Warnings = append(res.Warnings, w)
+		}
+		suppressions = verifier_state.Suppressions
+
+		// Definitions exported by imported artifacts are also
+		// visible but they are not in this document.
+		for _, imp := range artifact.Imports {
+			dep, pres := local_repository.Get(ctx, config_obj, imp)
+			if !pres || dep.Export == "" {
+				continue
+			}
+
+			dep_state := launcher.NewAnalysisState(imp)
+			launcher.VerifyVQL(ctx, config_obj, dep.Export,
+				local_repository, dep_state)
+			for k, def := range dep_state.Definitions {
+				def.Pos = vfilter.RangePosition{}
+				definitions[k] = def
+			}
+		}
+	}
+
+	// The export block is analysed first because its definitions
+	// are visible to all other blocks.
+	var blocks []*vqlBlock
+	for _, field := range []string{"export", "precondition"} {
+		for _, n := range getYamlNodes(root, field) {
+			blocks = append(blocks, &vqlBlock{
+				name:  name,
+				field: field,
+				node:  n,
+			})
+		}
+	}
+
+	for _, source := range getYamlNodes(root, "sources.[]") {
+		source_name := name
+		for _, n := range getYamlNodes(source.Node, "name") {
+			source_name += "/" + n.Value
+		}
+		for _, field := range []string{"precondition", "query"} {
+			for _, n := range getYamlNodes(source.Node, field) {
+				blocks = append(blocks, &vqlBlock{
+					name:  source_name,
+					field: field,
+					node:  n,
+				})
+			}
+		}
+	}
+
+	for _, block := range blocks {
+		block.masked = maskYamlNode(text, block.node)
+		res.Text = mergeMasked(res.Text, block.masked)
+
+		block_state := launcher.NewAnalysisState(name)
+		block_state.Suppressions = suppressions
+		for k, v := range definitions {
+			block_state.Definitions[k] = v
+		}
+
+		errs := launcher.VerifyVQL(ctx, config_obj,
+			block.masked, local_repository, block_state)
+		res.Errors = append(res.Errors, block.verifierErrors(errs)...)
+		res.Warnings = append(res.Warnings, block_state.Warnings...)
+
+		if block_state.FailedToParse {
+			state.FailedToParse = true
+		}
+		state.Callsites = append(state.Callsites, block_state.Callsites...)
+		state.TopLevelQueries = append(state.TopLevelQueries,
+			block_state.TopLevelQueries...)
+		for k, v := range block_state.Definitions {
+			state.Definitions[k] = v
+		}
+
+		// Export definitions are visible to the following blocks.
+		if block.field == "export" {
+			definitions = block_state.Definitions
+		}
+	}
+
+	for k, v := range definitions {
+		_, pres := state.Definitions[k]
+		if !pres {
+			state.Definitions[k] = v
+		}
+	}
+
+	return res, nil
+}
+
+// Try to find the line of the YAML error, otherwise report it at pos.
+func yamlError(err error, pos vfilter.RangePosition) *launcher.VerifierError {
+	if err == nil {
+		err = emptyDocumentError
+	}
+
+	res := &launcher.VerifierError{
+		Name:    launcher.YAML_ERROR,
+		Message: launcher.YAML_ERROR_MSG,
+		Args:    []interface{}{err},
+		Pos:     pos,
+	}
+
+	m := yamlErrorLineRegex.FindStringSubmatch(err.Error())
+	if m != nil {
+		line, _ := strconv.Atoi(m[1])
+		res.Pos = vfilter.RangePosition{
+			Pos:    lexer.Position{Line: line, Column: 1},
+			EndPos: lexer.Position{Line: line, Column: 1},
+		}
+	}
+	return res
+}
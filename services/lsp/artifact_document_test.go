@@ -0,0 +1,116 @@
+package lsp_test
+
+import (
+	"fmt"
+	"strings"
+
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+	"www.velocidex.com/golang/velociraptor/services/lsp"
+	"www.velocidex.com/golang/velociraptor/vtesting/assert"
+	"www.velocidex.com/golang/velociraptor/vtesting/goldie"
+)
+
+var (
+	artifactDocumentTC = []struct {
+		Name string
+		Text string
+	}{{
+		Name: "Valid artifact with parameters and export",
+		Text: `name: Custom.LSP.Valid
+parameters:
+- name: Glob
+  default: /tmp/*
+export: |
+  LET Files(G) = SELECT * FROM glob(globs=G)
+sources:
+- precondition: SELECT OS FROM info() WHERE OS = 'linux'
+  query: |
+    SELECT * FROM Files(G=Glob)
+`,
+	}, {
+		Name: "Errors are reported at their position in the YAML",
+		Text: `name: Custom.LSP.Errors
+sources:
+- name: First
+  query: |
+    SELECT * FROM glob(globz="*")
+
+- name: Second
+  query: |
+    LET X = SELECT * FROM Artifact.Unknown.Artifact()
+    SELECT * FROM
+`,
+	}, {
+		Name: "Verifier warnings are reported inline",
+		Text: `name: Custom.LSP.Permissions
+sources:
+- query: "SELECT * FROM execve(argv=['ls'])"
+`,
+	}, {
+		Name: "Schema errors",
+		Text: `name: Custom.LSP.Schema
+sourcez:
+- query: SELECT * FROM info()
+`,
+	}}
+)
+
+func (self *LSPTestSuite) TestArtifactDocument() {
+	lsp_service := lsp.NewLSPServer(self.ConfigObj).(*lsp.LSPServer)
+
+	var golden []string
+
+	for idx, tc := range artifactDocumentTC {
+		doc := uri.URI(fmt.Sprintf("file:///artifact%d.yaml", idx))
+
+		golden = append(golden, fmt.Sprintf(
+			"\nTest case %d: %s\n%v", idx, tc.Name, tc.Text))
+
+		diagnostics, err := lsp_service.DidOpen(self.Ctx,
+			&protocol.DidOpenTextDocumentParams{
+				TextDocument: protocol.TextDocumentItem{
+					URI:  doc,
+					Text: tc.Text,
+				},
+			})
+		assert.NoError(self.T(), err)
+
+		golden = append(golden, "Diagnostics:")
+		golden = append(golden, lsp.DumpProtool(diagnostics))
+	}
+
+	// The parameter used in the query resolves to its definition in
+	// the YAML.
+	definition, err := lsp_service.Definition(self.Ctx,
+		&protocol.DefinitionParams{
+			TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+				TextDocument: protocol.TextDocumentIdentifier{
+					URI: "file:///artifact0.yaml",
+				},
+				Position: protocol.Position{Line: 9, Character: 27},
+			},
+		})
+	assert.NoError(self.T(), err)
+
+	golden = append(golden, "\nDefinition of parameter Glob:")
+	golden = append(golden, lsp.DumpProtool(definition))
+
+	// Hover works on the embedded VQL.
+	hover, err := lsp_service.Hover(self.Ctx,
+		&protocol.HoverParams{
+			TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+				TextDocument: protocol.TextDocumentIdentifier{
+					URI: "file:///artifact0.yaml",
+				},
+				Position: protocol.Position{Line: 5, Character: 33},
+			},
+		})
+	assert.NoError(self.T(), err)
+
+	golden = append(golden, "\nHover on glob() in export:")
+	golden = append(golden, lsp.DumpProtool(hover))
+
+	goldie.Assert(self.T(), "TestArtifactDocument",
+		[]byte(strings.Join(golden, "\n")))
+}
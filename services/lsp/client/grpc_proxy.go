@@ -22,6 +22,10 @@ const (
 	SymbolOp            = "SymbolOp"
 	DocumentHighlightOp = "DocumentHighlighOp"
 	SemanticTokensOp    = "SemanticTokensOp"
+	DefinitionOp        = "DefinitionOp"
+	ReferencesOp        = "ReferencesOp"
+	RenameOp            = "RenameOp"
+	SignatureHelpOp     = "SignatureHelpOp"
 )
 
 var (
@@ -211,6 +215,50 @@ func (self *LSPProxy) SemanticTokensFull(
 		ctx, SemanticTokensOp, id, params, result)
 }
 
+func (self *LSPProxy) Definition(
+	ctx context.Context, params *protocol.DefinitionParams) (
+	protocol.DefinitionResult, error) {
+
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	result := protocol.LocationSlice{}
+	return result, self.forwardCall(ctx, DefinitionOp, 0, params, &result)
+}
+
+func (self *LSPProxy) References(
+	ctx context.Context, params *protocol.ReferenceParams) (
+	[]protocol.Location, error) {
+
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	result := []protocol.Location{}
+	return result, self.forwardCall(ctx, ReferencesOp, 0, params, &result)
+}
+
+func (self *LSPProxy) Rename(
+	ctx context.Context, params *protocol.RenameParams) (
+	*protocol.WorkspaceEdit, error) {
+
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	result := &protocol.WorkspaceEdit{}
+	return result, self.forwardCall(ctx, RenameOp, 0, params, result)
+}
+
+func (self *LSPProxy) SignatureHelp(
+	ctx context.Context, params *protocol.SignatureHelpParams) (
+	*protocol.SignatureHelp, error) {
+
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	result := &protocol.SignatureHelp{}
+	return result, self.forwardCall(ctx, SignatureHelpOp, 0, params, result)
+}
+
 func (self *LSPProxy) WorkDoneProgressCancel(
 	ctx context.Context,
 	params *protocol.WorkDoneProgressCancelParams) error {
@@ -0,0 +1,176 @@
+package lsp
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+	"www.velocidex.com/golang/velociraptor/services"
+	"www.velocidex.com/golang/velociraptor/utils"
+)
+
+// Artifacts stored in the repository do not have a file on disk so
+// we refer to them with this scheme.
+const artifactURIScheme = "artifact"
+
+func artifactURI(name string) uri.URI {
+	return uri.URI(fmt.Sprintf("%s:///%s.yaml", artifactURIScheme, name))
+}
+
+// Find the range of the first group of the regex in the text.
+func findYamlValue(text string, re *regexp.Regexp, start int) (
+	*protocol.Range, bool) {
+	if start > len(text) {
+		return nil, false
+	}
+
+	match := re.FindStringSubmatchIndex(text[start:])
+	if match == nil {
+		return nil, false
+	}
+
+	offset := start + match[2]
+	line_start := strings.LastIndex(text[:offset], "\n") + 1
+	line := uint32(strings.Count(text[:offset], "\n"))
+	character := uint32(offset - line_start)
+
+	return &protocol.Range{
+		Start: protocol.Position{Line: line, Character: character},
+		End: protocol.Position{
+			Line:      line,
+			Character: character + uint32(match[3]-match[2]),
+		},
+	}, true
+}
+
+// Find the range of the artifact name or one of its parameters in
+// the artifact YAML.
+func findArtifactRange(
+	text, name, parameter string) (*protocol.Range, bool) {
+	name_re := regexp.MustCompile(`(?m)^name:\s*['"]?(` +
+		regexp.QuoteMeta(name) + `)['"]?\s*$`)
+
+	name_range, ok := findYamlValue(text, name_re, 0)
+	if !ok {
+		return nil, false
+	}
+
+	if parameter == "" {
+		return name_range, true
+	}
+
+	// Only look at the parameters section of the artifact (up to
+	// the next top level key) - sources also have names.
+	parameters := regexp.MustCompile(`(?m)^parameters:`).FindStringIndex(text)
+	if parameters == nil {
+		return name_range, true
+	}
+
+	section := text
+	end := regexp.MustCompile(`(?m)^[a-zA-Z_]`).FindStringIndex(text[parameters[1]:])
+	if end != nil {
+		section = text[:parameters[1]+end[0]]
+	}
+
+	param_re := regexp.MustCompile(`(?m)^\s*-?\s*name:\s*['"]?(` +
+		regexp.QuoteMeta(parameter) + `)['"]?\s*$`)
+	param_range, ok := findYamlValue(section, param_re, parameters[1])
+	if !ok {
+		return name_range, true
+	}
+	return param_range, true
+}
+
+// Resolve an artifact (or one of its parameters) to a location. If
+// the artifact definition is open in the editor we point there,
+// otherwise we point into the definition stored in the artifact
+// repository.
+func (self *LSPServer) artifactLocation(
+	ctx context.Context, name, parameter string) (*protocol.Location, error) {
+
+	for _, doc := range self.allDocs() {
+		rng, ok := findArtifactRange(doc.YAML, name, parameter)
+		if ok {
+			return &protocol.Location{URI: doc.URI, Range: *rng}, nil
+		}
+	}
+
+	manager, err := services.GetRepositoryManager(self.config_obj)
+	if err != nil {
+		return nil, err
+	}
+
+	repository, err := manager.GetGlobalRepository(self.config_obj)
+	if err != nil {
+		return nil, err
+	}
+
+	artifact, pres := repository.Get(ctx, self.config_obj, name)
+	if !pres {
+		return nil, utils.Wrap(utils.NotFoundError,
+			"artifactLocation: Artifact %v not found", name)
+	}
+
+	result := &protocol.Location{URI: artifactURI(artifact.Name)}
+	rng, ok := findArtifactRange(artifact.Raw, artifact.Name, parameter)
+	if ok {
+		result.Range = *rng
+	}
+	return result, nil
+}
+
+func (self *LSPServer) Definition(
+	ctx context.Context,
+	params *protocol.DefinitionParams) (protocol.DefinitionResult, error) {
+
+	result := protocol.LocationSlice{}
+
+	doc, err := self.getDoc(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	ref, err := doc.symbolAt(lexerPositionFromProtocol(params.Position))
+	if err != nil {
+		return result, nil
+	}
+
+	switch {
+	// An artifact parameter resolves to the parameter definition.
+	case ref.IsArg && strings.HasPrefix(ref.Callee, "Artifact."):
+		location, err := self.artifactLocation(ctx,
+			strings.TrimPrefix(ref.Callee, "Artifact."), ref.Name)
+		if err == nil {
+			result = append(result, *location)
+		}
+
+	case strings.HasPrefix(ref.Name, "Artifact."):
+		location, err := self.artifactLocation(ctx,
+			strings.TrimPrefix(ref.Name, "Artifact."), "")
+		if err == nil {
+			result = append(result, *location)
+		}
+
+	case !ref.IsArg:
+		def, pres := doc.AnalysisState.Definitions[ref.Head]
+		if !pres {
+			return result, nil
+		}
+
+		// Parameters are defined in the artifact YAML.
+		if def.Type == "parameter" && def.Pos.Pos.Line > 0 {
+			result = append(result, refLocation(doc.URI, def.Pos))
+		}
+
+		for _, r := range doc.letReferences(ref.Head, true) {
+			if r.IsDefinition {
+				result = append(result, refLocation(doc.URI, r.HeadPos))
+			}
+		}
+	}
+
+	return result, nil
+}
@@ -20,10 +20,18 @@ import (
 type Document struct {
 	mu sync.Mutex
 
-	URI           uri.URI
-	Text          string
+	URI uri.URI
+
+	// The VQL text of the document. For artifact documents this is
+	// the YAML with everything except the VQL blanked out.
+	Text string
+
+	// The original YAML for artifact documents.
+	YAML string
+
 	AnalysisState *launcher.AnalysisState
 	Errors        []*launcher.VerifierError
+	Warnings      []*launcher.VerifierError
 	tokens        []vfilter.Token
 }
 
@@ -99,6 +107,7 @@ func (self *Document) UpdateTextFromDocument(other *Document) {
 	state.Callsites = new_state.Callsites
 	state.Definitions = new_state.Definitions
 	self.Text = other.Text
+	self.YAML = other.YAML
 }
 
 func (self *Document) Diagnostics() (res []*protocol.Diagnostic) {
@@ -112,6 +121,16 @@ func (self *Document) Diagnostics() (res []*protocol.Diagnostic) {
 		res = append(res, diag)
 	}
 
+	for _, verify_error := range self.Warnings {
+		diag := &protocol.Diagnostic{
+			Severity: protocol.DiagnosticSeverityWarning,
+			Source:   protocol.NewOptional("vql"),
+			Message:  protocol.String(verify_error.Error()),
+			Range:    *protocolRange(verify_error.Pos),
+		}
+		res = append(res, diag)
+	}
+
 	return res
 }
 
@@ -121,6 +140,10 @@ func NewDocument(
 	url uri.URI,
 	text string) (*Document, error) {
 
+	if isArtifactDocument(url) {
+		return NewArtifactDocument(ctx, config_obj, url, text)
+	}
+
 	repo_manager, err := services.GetRepositoryManager(config_obj)
 	if err != nil {
 		return nil, err
@@ -146,6 +169,7 @@ func NewDocument(
 		}
 		res.Errors = append(res.Errors, verify_error)
 	}
+	res.Warnings = state.Warnings
 
 	return res, nil
 }
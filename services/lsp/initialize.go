@@ -35,6 +35,19 @@ func (self *LSPServer) Initialize(
 				TriggerCharacters: []string{".", "(", "?"},
 			},
 
+			// The server resolves LET symbols and artifacts to their
+			// definitions and finds their references.
+			DefinitionProvider: protocol.Boolean(true),
+			ReferencesProvider: protocol.Boolean(true),
+
+			// LET variables and artifact parameters can be renamed.
+			RenameProvider: protocol.Boolean(true),
+
+			// Show the callable's signature while typing args.
+			SignatureHelpProvider: &protocol.SignatureHelpOptions{
+				TriggerCharacters: []string{"(", ","},
+			},
+
 			// The server provides semantic highlighting.
 			SemanticTokensProvider: &protocol.SemanticTokensOptions{
 				Legend: protocol.SemanticTokensLegend{
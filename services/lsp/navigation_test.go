@@ -0,0 +1,205 @@
+package lsp_test
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+	"www.velocidex.com/golang/velociraptor/services/lsp"
+	"www.velocidex.com/golang/velociraptor/vtesting/assert"
+	"www.velocidex.com/golang/velociraptor/vtesting/goldie"
+)
+
+var (
+	navigationArtifact = `
+name: Custom.Navigation.Test
+parameters:
+- name: Param1
+  description: The first parameter
+- name: Param2
+  description: The second parameter
+sources:
+- name: Param1
+  query: SELECT * FROM info()
+`
+
+	navigationDocs = map[uri.URI]string{
+		"file:///main.vql": `LET X = SELECT * FROM info()
+LET Y(A) = A + 1
+SELECT Y(A=X.OS) AS Foo
+FROM Artifact.Custom.Navigation.Test(Param1='a', Param2=X)
+WHERE X.OS = 'linux'
+`,
+		"file:///other.vql": `SELECT * FROM Artifact.Custom.Navigation.Test(Param1=1)
+`,
+	}
+
+	// Each test case selects the position of Match within Line
+	// (plus Offset characters) in the main document.
+	navigationTC = []struct {
+		Name   string
+		Op     string
+		Line   uint32
+		Match  string
+		Offset uint32
+	}{{
+		Name:  "Definition of LET symbol used as a member",
+		Op:    "Definition",
+		Line:  2,
+		Match: "X.OS",
+	}, {
+		Name:  "Definition of LET function",
+		Op:    "Definition",
+		Line:  2,
+		Match: "Y(",
+	}, {
+		Name:   "Definition of artifact",
+		Op:     "Definition",
+		Line:   3,
+		Match:  "Custom",
+		Offset: 2,
+	}, {
+		Name:  "Definition of artifact parameter",
+		Op:    "Definition",
+		Line:  3,
+		Match: "Param2",
+	}, {
+		Name:  "References to LET symbol",
+		Op:    "References",
+		Line:  0,
+		Match: "X",
+	}, {
+		Name:  "References to artifact across documents",
+		Op:    "References",
+		Line:  3,
+		Match: "Artifact",
+	}, {
+		Name:  "References to artifact parameter across documents",
+		Op:    "References",
+		Line:  3,
+		Match: "Param1",
+	}, {
+		Name:  "Rename LET symbol",
+		Op:    "Rename",
+		Line:  4,
+		Match: "X",
+	}, {
+		Name:  "Rename artifact parameter",
+		Op:    "Rename",
+		Line:  3,
+		Match: "Param1",
+	}, {
+		Name:  "Rename a call arg is not allowed",
+		Op:    "Rename",
+		Line:  2,
+		Match: "A=",
+	}, {
+		Name:  "Signature of artifact",
+		Op:    "SignatureHelp",
+		Line:  3,
+		Match: "Param2",
+	}, {
+		Name:  "Signature of LET function",
+		Op:    "SignatureHelp",
+		Line:  2,
+		Match: "A=",
+	}}
+)
+
+func (self *LSPTestSuite) TestNavigation() {
+	self.LoadArtifacts(navigationArtifact)
+
+	lsp_service := lsp.NewLSPServer(self.ConfigObj).(*lsp.LSPServer)
+
+	for doc, text := range navigationDocs {
+		_, err := lsp_service.DidOpen(self.Ctx,
+			&protocol.DidOpenTextDocumentParams{
+				TextDocument: protocol.TextDocumentItem{
+					URI:  doc,
+					Text: text,
+				},
+			})
+		assert.NoError(self.T(), err)
+	}
+
+	doc := uri.URI("file:///main.vql")
+	lines := strings.Split(navigationDocs[doc], "\n")
+
+	var golden []string
+
+	for idx, tc := range navigationTC {
+		line := lines[tc.Line]
+		column := uint32(strings.Index(line, tc.Match)) + tc.Offset
+
+		golden = append(golden, fmt.Sprintf(
+			"\nTest case %d: %s\n%v\n%v<--",
+			idx, tc.Name, line, line[:column]))
+
+		position := protocol.TextDocumentPositionParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: doc},
+			Position: protocol.Position{
+				Line:      tc.Line,
+				Character: column,
+			},
+		}
+
+		var result any
+		var err error
+
+		switch tc.Op {
+		case "Definition":
+			result, err = lsp_service.Definition(self.Ctx,
+				&protocol.DefinitionParams{
+					TextDocumentPositionParams: position,
+				})
+
+		case "References":
+			result, err = lsp_service.References(self.Ctx,
+				&protocol.ReferenceParams{
+					TextDocumentPositionParams: position,
+					Context: protocol.ReferenceContext{
+						IncludeDeclaration: true,
+					},
+				})
+
+		case "Rename":
+			var edit *protocol.WorkspaceEdit
+			edit, err = lsp_service.Rename(self.Ctx,
+				&protocol.RenameParams{
+					TextDocumentPositionParams: position,
+					NewName:                    "NewName",
+				})
+
+			// Changes is a map so sort it for stable output.
+			if err == nil {
+				var changes []string
+				for _, k := range slices.Sorted(maps.Keys(edit.Changes)) {
+					changes = append(changes, fmt.Sprintf(
+						"%v: %v", k, lsp.DumpProtool(edit.Changes[k])))
+				}
+				result = changes
+			}
+
+		case "SignatureHelp":
+			result, err = lsp_service.SignatureHelp(self.Ctx,
+				&protocol.SignatureHelpParams{
+					TextDocumentPositionParams: position,
+				})
+		}
+
+		golden = append(golden, tc.Op+":")
+		if err != nil {
+			golden = append(golden, "Error: "+err.Error())
+		} else if changes, ok := result.([]string); ok {
+			golden = append(golden, changes...)
+		} else {
+			golden = append(golden, lsp.DumpProtool(result))
+		}
+	}
+
+	goldie.Assert(self.T(), "TestNavigation",
+		[]byte(strings.Join(golden, "\n")))
+}
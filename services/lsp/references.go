@@ -0,0 +1,296 @@
+package lsp
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/alecthomas/participle/v2/lexer"
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+	"www.velocidex.com/golang/velociraptor/utils"
+	"www.velocidex.com/golang/vfilter"
+	vfilter_utils "www.velocidex.com/golang/vfilter/utils"
+)
+
+// A symbolRef is a single use of a (possibly dotted) name in the
+// document text. Dotted names like Artifact.Generic.Client.Info or
+// X.Foo are collapsed into a single reference.
+type symbolRef struct {
+	// The full dotted name with backtick quoting removed.
+	Name string
+
+	// The first component of the name (e.g. X in X.Foo). LET
+	// symbols are matched against this.
+	Head string
+
+	// The span of the full name and of the first component.
+	Pos     vfilter.RangePosition
+	HeadPos vfilter.RangePosition
+
+	// This reference is the name of a LET definition.
+	IsDefinition bool
+
+	// This reference is a keyword arg name inside a call
+	// (e.g. Foo in bar(Foo=1)) and Callee is the name of the
+	// function or plugin called.
+	IsArg  bool
+	Callee string
+}
+
+func (self *symbolRef) contains(pos lexer.Position) bool {
+	return self.Pos.Pos.Line == pos.Line &&
+		self.Pos.Pos.Column <= pos.Column &&
+		pos.Column <= self.Pos.EndPos.Column
+}
+
+func isOperator(token vfilter.Token, op string) bool {
+	return token.Type == "Operators" && token.Value == op
+}
+
+// Walk the token stream and collect all the names used in the
+// document. We use the raw tokens rather than the parsed AST because
+// the AST does not retain positions for every symbol reference.
+func (self *Document) symbolRefs() (res []*symbolRef) {
+	tokens := self.Tokenize()
+
+	// A stack of open parens: for a call paren we keep the callee
+	// name, otherwise an empty string.
+	var parens []string
+	var last *symbolRef
+	last_idx := -1
+
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+
+		switch {
+		case isOperator(token, "("):
+			callee := ""
+			if last != nil && last_idx == i-1 {
+				callee = last.Name
+			}
+			parens = append(parens, callee)
+
+		case isOperator(token, ")"):
+			if len(parens) > 0 {
+				parens = parens[:len(parens)-1]
+			}
+
+		case token.Type == "Ident":
+			start := i
+			parts := []string{vfilter_utils.Unquote_ident(token.Value)}
+			ref := &symbolRef{
+				Head: parts[0],
+				HeadPos: vfilter.RangePosition{
+					Pos: token.Pos, EndPos: token.EndPos},
+				Pos: vfilter.RangePosition{
+					Pos: token.Pos, EndPos: token.EndPos},
+				IsDefinition: i > 0 && tokens[i-1].Type == "LET",
+			}
+
+			// Consume the rest of the dotted name.
+			for i+2 < len(tokens) &&
+				isOperator(tokens[i+1], ".") &&
+				tokens[i+2].Type == "Ident" {
+				i += 2
+				parts = append(parts,
+					vfilter_utils.Unquote_ident(tokens[i].Value))
+				ref.Pos.EndPos = tokens[i].EndPos
+			}
+			ref.Name = strings.Join(parts, ".")
+
+			// A keyword arg is followed by = and sits directly
+			// inside a call paren.
+			if i+1 < len(tokens) && isOperator(tokens[i+1], "=") &&
+				len(parens) > 0 && parens[len(parens)-1] != "" &&
+				start > 0 && (isOperator(tokens[start-1], "(") ||
+				isOperator(tokens[start-1], ",")) {
+				ref.IsArg = true
+				ref.Callee = parens[len(parens)-1]
+			}
+
+			res = append(res, ref)
+			last = ref
+			last_idx = i
+		}
+	}
+
+	return res
+}
+
+// Find the symbol at the point.
+func (self *Document) symbolAt(pos lexer.Position) (*symbolRef, error) {
+	for _, ref := range self.symbolRefs() {
+		if ref.contains(pos) {
+			return ref, nil
+		}
+	}
+	return nil, utils.Wrap(utils.NotFoundError,
+		"symbolAt: No symbol at %v", pos)
+}
+
+// All the references to the LET symbol name in this document.
+func (self *Document) letReferences(
+	name string, include_declaration bool) (res []*symbolRef) {
+	for _, ref := range self.symbolRefs() {
+		if ref.IsArg || ref.Head != name {
+			continue
+		}
+		if ref.IsDefinition && !include_declaration {
+			continue
+		}
+		res = append(res, ref)
+	}
+	return res
+}
+
+// All the calls to the named artifact in this document.
+func (self *Document) artifactReferences(name string) (res []*symbolRef) {
+	for _, ref := range self.symbolRefs() {
+		if !ref.IsArg && ref.Name == name {
+			res = append(res, ref)
+		}
+	}
+	return res
+}
+
+// All the keyword args called parameter passed to the artifact.
+func (self *Document) artifactParameterReferences(
+	name, parameter string) (res []*symbolRef) {
+	for _, ref := range self.symbolRefs() {
+		if ref.IsArg && ref.Callee == name && ref.Name == parameter {
+			res = append(res, ref)
+		}
+	}
+	return res
+}
+
+// Artifacts are global so references to them may appear in any of
+// the open documents. Return the documents sorted by URI so results
+// are stable.
+func (self *LSPServer) allDocs() []*Document {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	res := make([]*Document, 0, len(self.documents))
+	for _, doc := range self.documents {
+		res = append(res, doc)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].URI < res[j].URI
+	})
+	return res
+}
+
+func refLocation(id uri.URI, rng vfilter.RangePosition) protocol.Location {
+	return protocol.Location{
+		URI:   id,
+		Range: *protocolRange(rng),
+	}
+}
+
+// All the locations which refer to an artifact parameter: the args
+// in calls to the artifact and, if the artifact definition is open,
+// the uses of the parameter within its VQL.
+func (self *LSPServer) artifactParameterLocations(
+	name, parameter string,
+	include_declaration bool) (res []protocol.Location) {
+
+	for _, doc := range self.allDocs() {
+		for _, r := range doc.artifactParameterReferences(
+			"Artifact."+name, parameter) {
+			res = append(res, refLocation(doc.URI, r.Pos))
+		}
+
+		if doc.AnalysisState.Artifact != name {
+			continue
+		}
+
+		def, pres := doc.AnalysisState.Definitions[parameter]
+		if !pres || def.Type != "parameter" {
+			continue
+		}
+
+		if include_declaration {
+			res = append(res, refLocation(doc.URI, def.Pos))
+		}
+
+		for _, r := range doc.letReferences(parameter, false) {
+			res = append(res, refLocation(doc.URI, r.HeadPos))
+		}
+	}
+	return res
+}
+
+// Find all the locations referring to the same symbol as ref.
+func (self *LSPServer) findReferences(
+	doc *Document, ref *symbolRef,
+	include_declaration bool) ([]protocol.Location, error) {
+
+	result := []protocol.Location{}
+
+	switch {
+	// An artifact parameter passed to an artifact call.
+	case ref.IsArg && strings.HasPrefix(ref.Callee, "Artifact."):
+		return self.artifactParameterLocations(
+			strings.TrimPrefix(ref.Callee, "Artifact."), ref.Name,
+			include_declaration), nil
+
+	// A call to an artifact.
+	case strings.HasPrefix(ref.Name, "Artifact."):
+		for _, other := range self.allDocs() {
+			for _, r := range other.artifactReferences(ref.Name) {
+				result = append(result, refLocation(other.URI, r.Pos))
+			}
+		}
+		return result, nil
+
+	case !ref.IsArg:
+		def, pres := doc.AnalysisState.Definitions[ref.Head]
+		if !pres {
+			break
+		}
+
+		// Within an artifact document parameters are scope
+		// variables.
+		if def.Type == "parameter" && doc.AnalysisState.Artifact != "" {
+			return self.artifactParameterLocations(
+				doc.AnalysisState.Artifact, ref.Head,
+				include_declaration), nil
+		}
+
+		// LET symbols are local to the document.
+		for _, r := range doc.letReferences(
+			ref.Head, include_declaration) {
+			result = append(result, refLocation(doc.URI, r.HeadPos))
+		}
+		return result, nil
+	}
+
+	return nil, utils.Wrap(utils.NotFoundError,
+		"findReferences: %v is not defined", ref.Name)
+}
+
+func (self *LSPServer) References(
+	ctx context.Context,
+	params *protocol.ReferenceParams) ([]protocol.Location, error) {
+
+	doc, err := self.getDoc(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	ref, err := doc.symbolAt(lexerPositionFromProtocol(params.Position))
+	if err != nil {
+		return []protocol.Location{}, nil
+	}
+
+	result, err := self.findReferences(
+		doc, ref, params.Context.IncludeDeclaration)
+	if err != nil {
+		return []protocol.Location{}, nil
+	}
+
+	return result, nil
+}
@@ -0,0 +1,63 @@
+package lsp
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+	"www.velocidex.com/golang/velociraptor/utils"
+)
+
+var (
+	identifierRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// Rename supports LET variables (within the document) and artifact
+// parameters (at all calls to the artifact in the open documents and
+// in the artifact definition if it is open).
+func (self *LSPServer) Rename(
+	ctx context.Context,
+	params *protocol.RenameParams) (*protocol.WorkspaceEdit, error) {
+
+	if !identifierRegex.MatchString(params.NewName) {
+		return nil, utils.Wrap(utils.InvalidArgError,
+			"Rename: %v is not a valid identifier", params.NewName)
+	}
+
+	doc, err := self.getDoc(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	ref, err := doc.symbolAt(lexerPositionFromProtocol(params.Position))
+	if err != nil {
+		return nil, err
+	}
+
+	// Artifact names are not identifiers and other call args belong
+	// to built in plugins.
+	if strings.HasPrefix(ref.Name, "Artifact.") ||
+		ref.IsArg && !strings.HasPrefix(ref.Callee, "Artifact.") {
+		return nil, utils.Wrap(utils.InvalidArgError,
+			"Rename: Can not rename %v", ref.Name)
+	}
+
+	locations, err := self.findReferences(doc, ref, true)
+	if err != nil {
+		return nil, utils.Wrap(utils.InvalidArgError,
+			"Rename: %v is not defined in this document", ref.Head)
+	}
+
+	changes := make(map[uri.URI][]protocol.TextEdit)
+	for _, location := range locations {
+		changes[location.URI] = append(changes[location.URI],
+			protocol.TextEdit{
+				Range:   location.Range,
+				NewText: params.NewName,
+			})
+	}
+
+	return &protocol.WorkspaceEdit{Changes: changes}, nil
+}
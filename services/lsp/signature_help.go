@@ -0,0 +1,123 @@
+package lsp
+
+import (
+	"context"
+	"strings"
+
+	"go.lsp.dev/protocol"
+	api_proto "www.velocidex.com/golang/velociraptor/api/proto"
+	"www.velocidex.com/golang/velociraptor/services"
+	"www.velocidex.com/golang/vfilter"
+)
+
+// Get the description of the callable at the callsite. Artifacts are
+// looked up in the repository, everything else in the api
+// descriptions or the document's LET definitions.
+func (self *LSPServer) getCallableDescription(
+	ctx context.Context, doc *Document,
+	cs *vfilter.CallSite) *api_proto.Completion {
+
+	if !strings.HasPrefix(cs.Name, "Artifact.") {
+		return doc.getVQLFunctionDescription(cs.Name, cs.Type)
+	}
+
+	manager, err := services.GetRepositoryManager(self.config_obj)
+	if err != nil {
+		return nil
+	}
+
+	repository, err := manager.GetGlobalRepository(self.config_obj)
+	if err != nil {
+		return nil
+	}
+
+	artifact, pres := repository.Get(ctx, self.config_obj,
+		strings.TrimPrefix(cs.Name, "Artifact."))
+	if !pres {
+		return nil
+	}
+
+	return &api_proto.Completion{
+		Name:        cs.Name,
+		Type:        "Artifact",
+		Description: elideDescription(artifact.Description),
+		Args:        getArtifactParamDescriptors(artifact),
+	}
+}
+
+func (self *LSPServer) SignatureHelp(
+	ctx context.Context,
+	params *protocol.SignatureHelpParams) (*protocol.SignatureHelp, error) {
+
+	result := &protocol.SignatureHelp{
+		Signatures: []protocol.SignatureInformation{},
+	}
+
+	doc, err := self.getDoc(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	pos := lexerPositionFromProtocol(params.Position)
+	cs, _, err := doc.matchCallsite(pos)
+	if err != nil {
+		return result, nil
+	}
+
+	desc := self.getCallableDescription(ctx, doc, cs)
+	if desc == nil {
+		return result, nil
+	}
+
+	// Build a label like name(arg1, arg2) and record the offsets of
+	// each parameter within it.
+	label := desc.Name + "("
+	parameters := []protocol.ParameterInformation{}
+	for idx, arg := range desc.Args {
+		if idx > 0 {
+			label += ", "
+		}
+		start := uint32(len(label))
+		label += arg.Name
+		parameter := protocol.ParameterInformation{
+			Label: protocol.ParameterInformationLabelTuple{
+				start, uint32(len(label))},
+		}
+		if arg.Description != "" {
+			parameter.Documentation = protocol.String(arg.Description)
+		}
+		parameters = append(parameters, parameter)
+	}
+	label += ")"
+
+	signature := protocol.SignatureInformation{
+		Label:      label,
+		Parameters: parameters,
+	}
+	if desc.Description != "" {
+		signature.Documentation = protocol.String(desc.Description)
+	}
+
+	// The active parameter is the last arg that starts before the
+	// point.
+	var active_arg string
+	for _, arg := range cs.Args {
+		start := arg.Pos.Pos
+		if start.Line < pos.Line ||
+			start.Line == pos.Line && start.Column <= pos.Column {
+			active_arg = arg.Name
+		}
+	}
+
+	for idx, arg := range desc.Args {
+		if arg.Name == active_arg {
+			signature.ActiveParameter = protocol.NewNullable(uint32(idx))
+			result.ActiveParameter = protocol.NewNullable(uint32(idx))
+		}
+	}
+
+	result.Signatures = append(result.Signatures, signature)
+	result.ActiveSignature = new(uint32(0))
+
+	return result, nil
+}
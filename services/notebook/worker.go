@@ -22,7 +22,9 @@ import (
 	"www.velocidex.com/golang/velociraptor/services"
 	"www.velocidex.com/golang/velociraptor/utils"
 	"www.velocidex.com/golang/velociraptor/vql/acl_managers"
+	"www.velocidex.com/golang/velociraptor/vql/debugger"
 	"www.velocidex.com/golang/velociraptor/vql/functions"
+	vql_profiler "www.velocidex.com/golang/velociraptor/vql/profiler"
 	"www.velocidex.com/golang/vfilter"
 	"www.velocidex.com/golang/vfilter/types"
 )
@@ -319,6 +321,11 @@ func (self *NotebookWorker) updateCellContents(
 				}
 			}
 
+			vql_debugger := installDebugger(query_ctx, tmpl.Scope)
+
+			// Cells have nowhere to store a profile so we just log it.
+			profiler := vql_profiler.MaybeInstall(tmpl.Scope, "Cell")
+
 			no_query := true
 			for _, vql := range vqls {
 				if vql.Comments != nil {
@@ -336,6 +343,10 @@ func (self *NotebookWorker) updateCellContents(
 				}
 				if vql.Let != "" || vql.Query != nil || vql.StoredQuery != nil {
 					no_query = false
+					if vql_debugger != nil {
+						vql_debugger.BeforeStatement(tmpl.Scope, vql)
+					}
+
 					rows, err := tmpl.RunQuery(vql, nil)
 
 					if err != nil {
@@ -355,6 +366,15 @@ func (self *NotebookWorker) updateCellContents(
 			if no_query {
 				tmpl.Error("Please specify a query to run")
 			}
+
+			if vql_debugger != nil {
+				debugger.LogStats(tmpl.Scope, vql_debugger.Stats())
+			}
+
+			if profiler != nil {
+				profiler.Log(tmpl.Scope)
+				profiler.Close()
+			}
 		}
 
 	default:
@@ -367,6 +387,35 @@ func (self *NotebookWorker) updateCellContents(
 	return notebook_cell, store.SetNotebookCell(notebook_id, notebook_cell)
 }
 
+// Cells can not be debugged interactively, but if DEBUG_VQL is set
+// we log the row counts of each query and the rows at the
+// breakpoints.
+func installDebugger(
+	ctx context.Context, scope vfilter.Scope) *debugger.Debugger {
+	specs, pres := scope.Resolve(constants.DEBUG_VQL)
+	if !pres {
+		return nil
+	}
+
+	result := debugger.NewDebugger(ctx, debugger.NewLoggingFrontend(scope))
+	for _, spec := range strings.Split(utils.ToString(specs), ",") {
+		if strings.TrimSpace(spec) == "" {
+			continue
+		}
+
+		bp, err := debugger.ParseBreakpoint(spec)
+		if err == nil {
+			err = result.AddBreakpoint(bp)
+		}
+		if err != nil {
+			scope.Log("DEBUG_VQL: %v", err)
+		}
+	}
+
+	result.Install(scope)
+	return result
+}
+
 func multiLineCommentsToString(vql *vfilter.VQL) string {
 	output := ""
 
@@ -69,6 +69,7 @@ type ServiceContainer interface {
 	ExportManager() (ExportManager, error)
 	DocManager() (DocManager, error)
 	LSPServer() (LSPServer, error)
+	WebhookManager() (WebhookManager, error)
 }
 
 // The org manager manages multi-tenancies.
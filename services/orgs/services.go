@@ -20,6 +20,7 @@ import (
 	"www.velocidex.com/golang/velociraptor/services/ddclient"
 	"www.velocidex.com/golang/velociraptor/services/docs"
 	"www.velocidex.com/golang/velociraptor/services/exports"
+	"www.velocidex.com/golang/velociraptor/services/federation"
 	"www.velocidex.com/golang/velociraptor/services/frontend"
 	"www.velocidex.com/golang/velociraptor/services/hunt_dispatcher"
 	"www.velocidex.com/golang/velociraptor/services/hunt_manager"
@@ -40,6 +41,7 @@ import (
 	"www.velocidex.com/golang/velociraptor/services/server_monitoring"
 	"www.velocidex.com/golang/velociraptor/services/users"
 	"www.velocidex.com/golang/velociraptor/services/vfs_service"
+	"www.velocidex.com/golang/velociraptor/services/webhooks"
 	"www.velocidex.com/golang/velociraptor/utils"
 )
 
@@ -69,6 +71,7 @@ type ServiceContainer struct {
 	export_manager          services.ExportManager
 	doc_manager             services.DocManager
 	lsp_server              services.LSPServer
+	webhook_manager         services.WebhookManager
 }
 
 func (self *ServiceContainer) MockFrontendManager(svc services.FrontendManager) {
@@ -202,6 +205,17 @@ func (self *ServiceContainer) LSPServer() (services.LSPServer, error) {
 	return self.lsp_server, nil
 }
 
+func (self *ServiceContainer) WebhookManager() (services.WebhookManager, error) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	if self.webhook_manager == nil {
+		return nil, errors.New("Webhook service not initialized")
+	}
+
+	return self.webhook_manager, nil
+}
+
 func (self *ServiceContainer) RepositoryManager() (services.RepositoryManager, error) {
 	self.mu.Lock()
 	defer self.mu.Unlock()
@@ -726,6 +740,24 @@ func (self *OrgManager) startOrgFromContext(org_ctx *OrgContext) (err error) {
 		service_container.mu.Unlock()
 	}
 
+	if spec.WebhookService {
+		webhook_manager, err := webhooks.NewWebhookManager(ctx, wg, org_config)
+		if err != nil {
+			return err
+		}
+
+		service_container.mu.Lock()
+		service_container.webhook_manager = webhook_manager
+		service_container.mu.Unlock()
+	}
+
+	if spec.FederationService {
+		err = federation.StartFederationService(ctx, wg, org_config)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Must be run after all the other services are up
 	if spec.SanityChecker {
 		err = sanity.NewSanityCheckService(ctx, wg, org_config)
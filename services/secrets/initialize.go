@@ -158,6 +158,23 @@ var (
 		Template: map[string]string{
 			"env": "# Add extra parameters as YAML strings\n#Foo: Value\n#Baz:Value2\n",
 		},
+	}, {
+		TypeName:    constants.WEBHOOK_SECRET,
+		Description: "Keys used to sign webhook deliveries.",
+		Verifier:    "x=>x.hmac_key",
+		Fields: []string{
+			"hmac_key",
+			"extra_headers",
+			"skip_verify",
+			"root_ca",
+		},
+		YamlFields: []string{
+			"extra_headers",
+		},
+		Template: map[string]string{
+			"extra_headers": "# Add extra headers as YAML strings\n#Authorization: Value\n",
+			"skip_verify":   "FALSE",
+		},
 	}}
 )
 
@@ -20,6 +20,7 @@ import (
 	"www.velocidex.com/golang/velociraptor/executor/throttler"
 	"www.velocidex.com/golang/velociraptor/file_store"
 	flows_proto "www.velocidex.com/golang/velociraptor/flows/proto"
+	"www.velocidex.com/golang/velociraptor/json"
 	"www.velocidex.com/golang/velociraptor/logging"
 	"www.velocidex.com/golang/velociraptor/paths"
 	"www.velocidex.com/golang/velociraptor/paths/artifact_modes"
@@ -30,6 +31,7 @@ import (
 	"www.velocidex.com/golang/velociraptor/utils"
 	vql_subsystem "www.velocidex.com/golang/velociraptor/vql"
 	"www.velocidex.com/golang/velociraptor/vql/acl_managers"
+	vql_profiler "www.velocidex.com/golang/velociraptor/vql/profiler"
 	"www.velocidex.com/golang/vfilter"
 )
 
@@ -439,6 +441,11 @@ func (self *contextManager) RunQuery(
 	}
 	scope.AppendVars(env)
 
+	profiler := vql_profiler.MaybeInstall(scope, artifact_name)
+	if profiler != nil {
+		defer self.writeProfile(flow_path_manager, profiler)
+	}
+
 	// If we panic below we need to recover and report this to the
 	// server.
 	defer func() {
@@ -489,13 +496,13 @@ func (self *contextManager) RunQuery(
 		if query.Name == "" {
 			// Drain the query but do not relay any data back. These
 			// are normally LET queries.
-			for range vql.Eval(sub_ctx, scope) {
+			for range vql_profiler.Eval(sub_ctx, scope, vql) {
 			}
 			query_log.Close()
 			continue
 		}
 
-		read_chan := vql.Eval(sub_ctx, scope)
+		read_chan := vql_profiler.Eval(sub_ctx, scope, vql)
 
 		// Write result set into table with this name
 		name := artifacts.DeobfuscateString(self.config_obj, query.Name)
@@ -567,6 +574,27 @@ func (self *contextManager) RunQuery(
 	return nil
 }
 
+// Append the profile of a query to the flow's profile.
+func (self *contextManager) writeProfile(
+	flow_path_manager *paths.FlowPathManager,
+	profiler *vql_profiler.Profiler) {
+	defer profiler.Close()
+
+	file_store_factory := file_store.GetFileStore(self.config_obj)
+	rs_writer, err := result_sets.NewResultSetWriter(
+		file_store_factory, flow_path_manager.Profile(),
+		json.DefaultEncOpts(), utils.BackgroundWriter,
+		result_sets.AppendMode)
+	if err != nil {
+		return
+	}
+	defer rs_writer.Close()
+
+	for _, row := range profiler.Rows() {
+		rs_writer.Write(row)
+	}
+}
+
 func (self *contextManager) Logger() LogWriter {
 	self.mu.Lock()
 	defer self.mu.Unlock()
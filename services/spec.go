@@ -94,5 +94,7 @@ func AllServerServicesSpec() *config_proto.ServerServicesConfig {
 		NotebookService:     true,
 		SchedulerService:    true,
 		BackupService:       true,
+		WebhookService:      true,
+		FederationService:   true,
 	}
 }
@@ -19,6 +19,7 @@ package services
 
 import (
 	"context"
+	"strings"
 
 	"github.com/Velocidex/ordereddict"
 	acl_proto "www.velocidex.com/golang/velociraptor/acls/proto"
@@ -119,6 +120,70 @@ type UserManager interface {
 		principal, username string,
 		password, current_org string) error
 
+	// Update the user's second factor enrollment. Setting it to nil
+	// resets it.
+	// A user may update their own second factor.
+	// A ServerAdmin in any of the orgs the user belongs to can reset it.
+	// An OrgAdmin can reset everyone's second factor.
+	SetUserMFA(
+		ctx context.Context,
+		principal, username string,
+		mfa *api_proto.UserMFA) error
+
+	// Mint a new API token for the principal. The token can only
+	// carry permissions the principal holds in each of the orgs.
+	CreateAPIToken(
+		ctx context.Context,
+		principal string,
+		request *api_proto.CreateApiTokenRequest) (
+		*api_proto.CreateApiTokenResponse, error)
+
+	// List or revoke the user's API tokens.
+	// A user may manage their own tokens.
+	// A ServerAdmin in any of the orgs the user belongs to can manage them.
+	// An OrgAdmin can manage everyone's tokens.
+	ListAPITokens(
+		ctx context.Context,
+		principal, username string) ([]*api_proto.ApiToken, error)
+
+	RevokeAPIToken(
+		ctx context.Context,
+		principal, username, token_id string) error
+
+	// Check a bearer token presented by a client and record its
+	// use. The caller should act as the principal returned by
+	// APITokenPrincipal().
+	VerifyAPIToken(
+		ctx context.Context,
+		bearer, remote, operation string) (*api_proto.ApiToken, error)
+
+	// Get the valid token for a principal from APITokenPrincipal()
+	GetAPIToken(
+		ctx context.Context, principal string) (*api_proto.ApiToken, error)
+
+	// Track the GUI session identified by the cookie the browser
+	// presents. Sessions are recorded the first time the cookie is
+	// seen. The session describes the request (username,
+	// authenticator, last_active_from, user_agent and expires).
+	// Revoked sessions are rejected.
+	VerifyGUISession(
+		ctx context.Context,
+		cookie string, session *api_proto.GUISession) (
+		*api_proto.GUISession, error)
+
+	// List the user's GUI sessions, or everyone's if username is
+	// empty. The same permissions apply as for API tokens while
+	// listing everyone's sessions requires ORG_ADMIN.
+	ListGUISessions(
+		ctx context.Context,
+		principal, username string) ([]*api_proto.GUISession, error)
+
+	// Revoke one of the user's GUI sessions, or all of them if
+	// session_id is empty.
+	RevokeGUISession(
+		ctx context.Context,
+		principal, username, session_id, reason string) error
+
 	SetUserStats(
 		ctx context.Context,
 		org_config_obj *config_proto.Config,
@@ -142,6 +207,21 @@ type UserManager interface {
 		message *ordereddict.Dict) error
 }
 
+// API tokens act as their own principal so the ACL manager can
+// limit them to the token's permissions.
+func APITokenPrincipal(username, token_id string) string {
+	return username + "#" + token_id
+}
+
+func ParseAPITokenPrincipal(principal string) (
+	username, token_id string, ok bool) {
+	idx := strings.LastIndex(principal, "#T.")
+	if idx <= 0 {
+		return "", "", false
+	}
+	return principal[:idx], principal[idx+1:], true
+}
+
 // A helper
 func GrantUserToOrg(
 	ctx context.Context,
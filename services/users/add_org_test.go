@@ -82,5 +82,13 @@ func (self *UserManagerTestSuite) TestAddUserToOrg() {
 		[]string{"O2"}, reader_policy)
 	assert.ErrorContains(self.T(), err, "reserved")
 
+	// Names with a # could be mistaken for API token principals.
+	for _, username := range []string{"alice#T.x", "alice#bob"} {
+		err = users_manager.AddUserToOrg(
+			self.Ctx, services.AddNewUser,
+			"AdminO2", username, []string{"O2"}, reader_policy)
+		assert.ErrorContains(self.T(), err, "# is not allowed")
+	}
+
 	goldie.Assert(self.T(), "TestAddUserToOrg", json.MustMarshalIndent(golden))
 }
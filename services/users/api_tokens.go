@@ -0,0 +1,400 @@
+package users
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Velocidex/ordereddict"
+	"google.golang.org/protobuf/proto"
+	"www.velocidex.com/golang/velociraptor/acls"
+	acl_proto "www.velocidex.com/golang/velociraptor/acls/proto"
+	api_proto "www.velocidex.com/golang/velociraptor/api/proto"
+	config_proto "www.velocidex.com/golang/velociraptor/config/proto"
+	"www.velocidex.com/golang/velociraptor/logging"
+	"www.velocidex.com/golang/velociraptor/services"
+	"www.velocidex.com/golang/velociraptor/utils"
+)
+
+const (
+	apiTokenPrefix = "vrt_"
+
+	// Only record the last use of a token this often to avoid
+	// writing the user record on every request.
+	apiTokenUsageResolution = 60
+)
+
+var (
+	invalidAPITokenError = errors.New("Invalid API token")
+)
+
+func getAPITokenConfig(config_obj *config_proto.Config) *config_proto.APITokenConfig {
+	if config_obj.API == nil || config_obj.API.Tokens == nil {
+		return &config_proto.APITokenConfig{}
+	}
+	return config_obj.API.Tokens
+}
+
+func hashAPITokenSecret(secret string) []byte {
+	hash := sha256.Sum256([]byte(secret))
+	return hash[:]
+}
+
+// The bearer token encodes the token's principal so we can find it
+// without an index.
+func encodeAPIToken(principal, secret string) string {
+	return apiTokenPrefix +
+		base64.RawURLEncoding.EncodeToString([]byte(principal)) +
+		"." + secret
+}
+
+func decodeAPIToken(bearer string) (principal, secret string, err error) {
+	encoded, secret, ok := strings.Cut(
+		strings.TrimPrefix(bearer, apiTokenPrefix), ".")
+	if !ok || !strings.HasPrefix(bearer, apiTokenPrefix) {
+		return "", "", invalidAPITokenError
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", "", invalidAPITokenError
+	}
+	return string(decoded), secret, nil
+}
+
+// Strip the hash before the token leaves the user manager.
+func cleanAPIToken(token *api_proto.ApiToken) *api_proto.ApiToken {
+	result := proto.Clone(token).(*api_proto.ApiToken)
+	result.Hash = nil
+	return result
+}
+
+func (self *UserManager) audit(
+	ctx context.Context, principal, operation string,
+	details *ordereddict.Dict) {
+	err := services.LogAudit(ctx,
+		self.config_obj, principal, operation, details)
+	if err != nil {
+		logger := logging.GetLogger(self.config_obj, &logging.FrontendComponent)
+		logger.Error("<red>%v</> %v %v", operation, principal, details)
+	}
+}
+
+func (self *UserManager) CreateAPIToken(
+	ctx context.Context,
+	principal string,
+	request *api_proto.CreateApiTokenRequest) (
+	*api_proto.CreateApiTokenResponse, error) {
+
+	token_config := getAPITokenConfig(self.config_obj)
+	if token_config.Disabled {
+		return nil, errors.New("API tokens are disabled")
+	}
+
+	// Tokens can not be used to mint more tokens.
+	_, _, ok := services.ParseAPITokenPrincipal(principal)
+	if ok {
+		return nil, acls.PermissionDenied
+	}
+
+	if request.Name == "" {
+		return nil, errors.New("A name is required for the token")
+	}
+
+	if len(request.Permissions) == 0 {
+		return nil, errors.New("At least one permission is required")
+	}
+
+	if len(request.Orgs) == 0 {
+		return nil, errors.New("At least one org is required")
+	}
+
+	// Check the permission names are valid.
+	err := acls.SetTokenPermission(&acl_proto.ApiClientACL{},
+		request.Permissions...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", err, request.Permissions)
+	}
+
+	org_manager, err := services.GetOrgManager()
+	if err != nil {
+		return nil, err
+	}
+
+	// The principal must hold all the permissions in all the orgs.
+	orgs := []string{}
+	for _, org_id := range request.Orgs {
+		org_config_obj, err := org_manager.GetOrgConfig(org_id)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, perm := range request.Permissions {
+			ok, _ := services.CheckAccess(
+				org_config_obj, principal, acls.GetPermission(perm))
+			if !ok {
+				return nil, fmt.Errorf("%w: %v does not have %v in org %v",
+					acls.PermissionDenied, principal, perm,
+					utils.NormalizedOrgId(org_id))
+			}
+		}
+
+		orgs = append(orgs, utils.NormalizedOrgId(org_id))
+	}
+
+	default_expiry := token_config.DefaultExpiryDays
+	if default_expiry == 0 {
+		default_expiry = 30
+	}
+
+	max_expiry := token_config.MaxExpiryDays
+	if max_expiry == 0 {
+		max_expiry = 365
+	}
+
+	expiry := time.Duration(request.Expiry) * time.Second
+	if expiry == 0 {
+		expiry = time.Duration(default_expiry) * 24 * time.Hour
+	}
+
+	if expiry > time.Duration(max_expiry)*24*time.Hour {
+		return nil, fmt.Errorf(
+			"Token expiry may not be longer than %v days", max_expiry)
+	}
+
+	secret_bytes := make([]byte, 32)
+	_, err = rand.Read(secret_bytes)
+	if err != nil {
+		return nil, err
+	}
+	secret := base64.RawURLEncoding.EncodeToString(secret_bytes)
+
+	user_record, err := self.storage.GetUserWithHashes(ctx, principal)
+	if err != nil {
+		return nil, err
+	}
+
+	now := utils.GetTime().Now()
+	token := &api_proto.ApiToken{
+		TokenId:     "T." + utils.NextId(),
+		Username:    user_record.Name,
+		Name:        request.Name,
+		Permissions: request.Permissions,
+		Orgs:        orgs,
+		Created:     uint64(now.Unix()),
+		Expires:     uint64(now.Add(expiry).Unix()),
+		Hash:        hashAPITokenSecret(secret),
+	}
+
+	err = self.storage.AddAPIToken(ctx, user_record.Name, token)
+	if err != nil {
+		return nil, err
+	}
+
+	self.audit(ctx, principal, "CreateApiToken",
+		ordereddict.NewDict().
+			Set("token_id", token.TokenId).
+			Set("name", token.Name).
+			Set("permissions", token.Permissions).
+			Set("orgs", token.Orgs).
+			Set("expires", time.Unix(int64(token.Expires), 0).UTC()))
+
+	return &api_proto.CreateApiTokenResponse{
+		Token: cleanAPIToken(token),
+		Secret: encodeAPIToken(
+			services.APITokenPrincipal(user_record.Name, token.TokenId),
+			secret),
+	}, nil
+}
+
+// Users may manage their own credentials, admins may manage those
+// of users in their orgs.
+func (self *UserManager) canManageUser(
+	ctx context.Context, principal, username string) (bool, error) {
+
+	user_record, err := self.GetUser(ctx, principal, username)
+	if err != nil {
+		return false, err
+	}
+
+	if principal == user_record.Name {
+		return true, nil
+	}
+
+	org_manager, err := services.GetOrgManager()
+	if err != nil {
+		return false, err
+	}
+
+	root_config_obj, err := org_manager.GetOrgConfig(services.ROOT_ORG_ID)
+	if err != nil {
+		return false, err
+	}
+
+	ok, _ := services.CheckAccess(root_config_obj, principal, acls.ORG_ADMIN)
+	if ok {
+		return true, nil
+	}
+
+	for _, user_org := range user_record.Orgs {
+		org_config_obj, err := org_manager.GetOrgConfig(user_org.Id)
+		if err != nil {
+			continue
+		}
+
+		ok, _ := services.CheckAccess(
+			org_config_obj, principal, acls.SERVER_ADMIN)
+		if ok {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (self *UserManager) ListAPITokens(
+	ctx context.Context,
+	principal, username string) ([]*api_proto.ApiToken, error) {
+
+	ok, err := self.canManageUser(ctx, principal, username)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return nil, acls.PermissionDenied
+	}
+
+	user_record, err := self.storage.GetUserWithHashes(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []*api_proto.ApiToken{}
+	for _, t := range user_record.ApiTokens {
+		result = append(result, cleanAPIToken(t))
+	}
+	return result, nil
+}
+
+func (self *UserManager) RevokeAPIToken(
+	ctx context.Context,
+	principal, username, token_id string) error {
+
+	ok, err := self.canManageUser(ctx, principal, username)
+	if err != nil {
+		return err
+	}
+
+	details := ordereddict.NewDict().
+		Set("user", username).
+		Set("token_id", token_id)
+
+	if !ok {
+		details.Set("error", acls.PermissionDenied.Error())
+		self.audit(ctx, principal, "RevokeApiToken", details)
+		return acls.PermissionDenied
+	}
+
+	user_record, err := self.storage.GetUserWithHashes(ctx, username)
+	if err != nil {
+		return err
+	}
+
+	err = self.storage.RemoveAPIToken(ctx, user_record.Name, token_id)
+	if err != nil {
+		return err
+	}
+
+	self.audit(ctx, principal, "RevokeApiToken", details)
+	return nil
+}
+
+// Find the unexpired token for the principal.
+func (self *UserManager) getAPIToken(
+	ctx context.Context, principal string) (
+	*api_proto.VelociraptorUser, *api_proto.ApiToken, error) {
+
+	if getAPITokenConfig(self.config_obj).Disabled {
+		return nil, nil, invalidAPITokenError
+	}
+
+	username, token_id, ok := services.ParseAPITokenPrincipal(principal)
+	if !ok {
+		return nil, nil, invalidAPITokenError
+	}
+
+	user_record, err := self.storage.GetUserWithHashes(ctx, username)
+	if err != nil || user_record.Locked {
+		return nil, nil, invalidAPITokenError
+	}
+
+	now := uint64(utils.GetTime().Now().Unix())
+	for _, t := range user_record.ApiTokens {
+		if t.TokenId == token_id && t.Expires > now {
+			return user_record, t, nil
+		}
+	}
+
+	return nil, nil, invalidAPITokenError
+}
+
+func (self *UserManager) GetAPIToken(
+	ctx context.Context, principal string) (*api_proto.ApiToken, error) {
+	_, token, err := self.getAPIToken(ctx, principal)
+	if err != nil {
+		return nil, err
+	}
+	return cleanAPIToken(token), nil
+}
+
+func (self *UserManager) VerifyAPIToken(
+	ctx context.Context,
+	bearer, remote, operation string) (*api_proto.ApiToken, error) {
+
+	principal, secret, err := decodeAPIToken(bearer)
+	if err != nil {
+		return nil, err
+	}
+
+	user_record, token, err := self.getAPIToken(ctx, principal)
+	if err == nil && subtle.ConstantTimeCompare(
+		hashAPITokenSecret(secret), token.Hash) != 1 {
+		err = invalidAPITokenError
+	}
+
+	details := ordereddict.NewDict().
+		Set("operation", operation).
+		Set("remote", remote)
+	if err != nil {
+		details.Set("error", err.Error())
+		self.audit(ctx, principal, "ApiTokenUse", details)
+		return nil, err
+	}
+
+	self.audit(ctx, principal, "ApiTokenUse", details)
+
+	now := uint64(utils.GetTime().Now().Unix())
+	if token.LastUsed+apiTokenUsageResolution < now {
+		token.LastUsed = now
+		token.LastUsedFrom = remote
+
+		// The token may have been revoked since we read it.
+		err = self.storage.SetAPITokenLastUsed(
+			ctx, user_record.Name, token.TokenId, now, remote)
+		if errors.Is(err, utils.NotFoundError) {
+			return nil, invalidAPITokenError
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return cleanAPIToken(token), nil
+}
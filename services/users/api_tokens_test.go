@@ -0,0 +1,198 @@
+package users_test
+
+import (
+	"sync"
+	"time"
+
+	"www.velocidex.com/golang/velociraptor/acls"
+	api_proto "www.velocidex.com/golang/velociraptor/api/proto"
+	"www.velocidex.com/golang/velociraptor/services"
+	"www.velocidex.com/golang/velociraptor/utils"
+	"www.velocidex.com/golang/velociraptor/vtesting/assert"
+)
+
+func (self *UserManagerTestSuite) TestAPITokens() {
+	self.makeUsers()
+
+	clock := utils.NewMockClock(time.Unix(1800000000, 0))
+	closer := utils.MockTime(clock)
+	defer closer()
+
+	users_manager := services.GetUserManager()
+	org_manager, err := services.GetOrgManager()
+	assert.NoError(self.T(), err)
+
+	o1_config_obj, err := org_manager.GetOrgConfig("O1")
+	assert.NoError(self.T(), err)
+
+	o2_config_obj, err := org_manager.GetOrgConfig("O2")
+	assert.NoError(self.T(), err)
+
+	// Tokens can only carry permissions the user has.
+	_, err = users_manager.CreateAPIToken(self.Ctx, "UserO1",
+		&api_proto.CreateApiTokenRequest{
+			Name:        "Collector",
+			Permissions: []string{"COLLECT_CLIENT"},
+			Orgs:        []string{"O1"},
+		})
+	assert.ErrorContains(self.T(), err, "PermissionDenied")
+
+	// Even when the user has them in another org.
+	_, err = users_manager.CreateAPIToken(self.Ctx, "AdminO1",
+		&api_proto.CreateApiTokenRequest{
+			Name:        "Reader",
+			Permissions: []string{"READ_RESULTS"},
+			Orgs:        []string{"O2"},
+		})
+	assert.ErrorContains(self.T(), err, "PermissionDenied")
+
+	_, err = users_manager.CreateAPIToken(self.Ctx, "AdminO1",
+		&api_proto.CreateApiTokenRequest{
+			Name:        "Reader",
+			Permissions: []string{"READ_RESULTS"},
+			Orgs:        []string{"O1"},
+			Expiry:      uint64(400 * 24 * time.Hour / time.Second),
+		})
+	assert.ErrorContains(self.T(), err, "365 days")
+
+	response, err := users_manager.CreateAPIToken(self.Ctx, "AdminO1",
+		&api_proto.CreateApiTokenRequest{
+			Name:        "Reader",
+			Permissions: []string{"READ_RESULTS"},
+			Orgs:        []string{"O1"},
+		})
+	assert.NoError(self.T(), err)
+	assert.Nil(self.T(), response.Token.Hash)
+	assert.Equal(self.T(), uint64(1800000000+30*24*60*60), response.Token.Expires)
+
+	token, err := users_manager.VerifyAPIToken(
+		self.Ctx, response.Secret, "127.0.0.1", "test")
+	assert.NoError(self.T(), err)
+	assert.Equal(self.T(), response.Token.TokenId, token.TokenId)
+	assert.Equal(self.T(), "127.0.0.1", token.LastUsedFrom)
+
+	// A forged secret is rejected.
+	_, err = users_manager.VerifyAPIToken(
+		self.Ctx, response.Secret+"A", "127.0.0.1", "test")
+	assert.ErrorContains(self.T(), err, "Invalid API token")
+
+	// The token is restricted to its permissions and orgs.
+	principal := services.APITokenPrincipal("AdminO1", token.TokenId)
+	ok, _ := services.CheckAccess(o1_config_obj, principal, acls.READ_RESULTS)
+	assert.True(self.T(), ok)
+
+	ok, _ = services.CheckAccess(o1_config_obj, principal, acls.COLLECT_CLIENT)
+	assert.False(self.T(), ok)
+
+	ok, _ = services.CheckAccess(o2_config_obj, principal, acls.READ_RESULTS)
+	assert.False(self.T(), ok)
+
+	// Tokens can not mint more tokens.
+	_, err = users_manager.CreateAPIToken(self.Ctx, principal,
+		&api_proto.CreateApiTokenRequest{
+			Name:        "Reader",
+			Permissions: []string{"READ_RESULTS"},
+			Orgs:        []string{"O1"},
+		})
+	assert.ErrorContains(self.T(), err, "PermissionDenied")
+
+	// Users in other orgs can not see the tokens but org admins can.
+	_, err = users_manager.ListAPITokens(self.Ctx, "UserO2", "AdminO1")
+	assert.ErrorContains(self.T(), err, "PermissionDenied")
+
+	tokens, err := users_manager.ListAPITokens(self.Ctx, "OrgAdmin", "AdminO1")
+	assert.NoError(self.T(), err)
+	assert.Equal(self.T(), 1, len(tokens))
+	assert.Nil(self.T(), tokens[0].Hash)
+
+	// Updating the user record does not remove the tokens.
+	err = users_manager.SetUserPassword(
+		self.Ctx, self.ConfigObj, "AdminO1", "AdminO1", "MyPassword", "")
+	assert.NoError(self.T(), err)
+
+	_, err = users_manager.VerifyAPIToken(
+		self.Ctx, response.Secret, "127.0.0.1", "test")
+	assert.NoError(self.T(), err)
+
+	// Revoked tokens are no longer accepted.
+	err = users_manager.RevokeAPIToken(
+		self.Ctx, "UserO2", "AdminO1", token.TokenId)
+	assert.ErrorContains(self.T(), err, "PermissionDenied")
+
+	err = users_manager.RevokeAPIToken(
+		self.Ctx, "AdminO1", "AdminO1", token.TokenId)
+	assert.NoError(self.T(), err)
+
+	_, err = users_manager.VerifyAPIToken(
+		self.Ctx, response.Secret, "127.0.0.1", "test")
+	assert.ErrorContains(self.T(), err, "Invalid API token")
+
+	ok, _ = services.CheckAccess(o1_config_obj, principal, acls.READ_RESULTS)
+	assert.False(self.T(), ok)
+
+	// Expired tokens are not accepted.
+	response, err = users_manager.CreateAPIToken(self.Ctx, "AdminO1",
+		&api_proto.CreateApiTokenRequest{
+			Name:        "Short",
+			Permissions: []string{"READ_RESULTS"},
+			Orgs:        []string{"O1"},
+			Expiry:      3600,
+		})
+	assert.NoError(self.T(), err)
+
+	_, err = users_manager.VerifyAPIToken(
+		self.Ctx, response.Secret, "127.0.0.1", "test")
+	assert.NoError(self.T(), err)
+
+	clock.Set(clock.Now().Add(2 * time.Hour))
+	_, err = users_manager.VerifyAPIToken(
+		self.Ctx, response.Secret, "127.0.0.1", "test")
+	assert.ErrorContains(self.T(), err, "Invalid API token")
+}
+
+func (self *UserManagerTestSuite) TestAPITokensConcurrentUpdates() {
+	self.makeUsers()
+
+	users_manager := services.GetUserManager()
+
+	// Concurrent updates must not lose any tokens.
+	wg := &sync.WaitGroup{}
+	secrets := make([]string, 10)
+	for i := range secrets {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			response, err := users_manager.CreateAPIToken(self.Ctx, "AdminO1",
+				&api_proto.CreateApiTokenRequest{
+					Name:        "Reader",
+					Permissions: []string{"READ_RESULTS"},
+					Orgs:        []string{"O1"},
+				})
+			assert.NoError(self.T(), err)
+			secrets[i] = response.Secret
+
+			_, err = users_manager.VerifyAPIToken(
+				self.Ctx, response.Secret, "127.0.0.1", "test")
+			assert.NoError(self.T(), err)
+		}(i)
+	}
+	wg.Wait()
+
+	tokens, err := users_manager.ListAPITokens(self.Ctx, "AdminO1", "AdminO1")
+	assert.NoError(self.T(), err)
+	assert.Equal(self.T(), len(secrets), len(tokens))
+
+	// Revoking one token leaves the others alone.
+	err = users_manager.RevokeAPIToken(
+		self.Ctx, "AdminO1", "AdminO1", tokens[0].TokenId)
+	assert.NoError(self.T(), err)
+
+	err = users_manager.RevokeAPIToken(
+		self.Ctx, "AdminO1", "AdminO1", tokens[0].TokenId)
+	assert.ErrorContains(self.T(), err, "NotFoundError")
+
+	tokens, err = users_manager.ListAPITokens(self.Ctx, "AdminO1", "AdminO1")
+	assert.NoError(self.T(), err)
+	assert.Equal(self.T(), len(secrets)-1, len(tokens))
+}
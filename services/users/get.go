@@ -39,6 +39,9 @@ func (self *UserManager) GetUser(
 	// Clear the hashes
 	result.PasswordHash = nil
 	result.PasswordSalt = nil
+	result.Mfa = nil
+	result.ApiTokens = nil
+	result.GuiSessions = nil
 
 	return result, nil
 }
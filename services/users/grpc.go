@@ -4,7 +4,9 @@ import (
 	"context"
 	"crypto/x509"
 	"errors"
+	"strings"
 
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/peer"
@@ -29,6 +31,19 @@ func (self UserManager) GetUserFromContext(ctx context.Context) (
 			Name: grpc_user_info.Name,
 		}
 
+	} else if isAPITokenPrincipal(grpc_user_info.Name) {
+		// API tokens have no user record of their own. The ACL
+		// manager limits the token to its permissions and orgs.
+		_, err = self.GetAPIToken(ctx, grpc_user_info.Name)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		user_record = &api_proto.VelociraptorUser{
+			Name: grpc_user_info.Name,
+		}
+		self.normalizeOrgList(ctx, user_record)
+
 	} else {
 		user_record, err = self.storage.GetUserWithHashes(ctx, grpc_user_info.Name)
 		if err != nil {
@@ -41,6 +56,9 @@ func (self UserManager) GetUserFromContext(ctx context.Context) (
 	user_record.CurrentOrg = grpc_user_info.CurrentOrg
 	user_record.PasswordSalt = nil
 	user_record.PasswordHash = nil
+	user_record.Mfa = nil
+	user_record.ApiTokens = nil
+	user_record.GuiSessions = nil
 
 	// Fetch the appropriate config file from the org manager.
 	org_manager, err := services.GetOrgManager()
@@ -140,5 +158,53 @@ func GetGRPCUserInfo(
 		}
 	}
 
+	// Clients without a certificate may present an API token
+	// instead.
+	if result.Name == "" && config_obj.API != nil &&
+		config_obj.API.Tokens != nil && config_obj.API.Tokens.AllowGrpc {
+		getGRPCTokenUserInfo(ctx, result)
+	}
+
 	return result
 }
+
+func isAPITokenPrincipal(principal string) bool {
+	_, _, ok := services.ParseAPITokenPrincipal(principal)
+	return ok
+}
+
+func getGRPCTokenUserInfo(
+	ctx context.Context, result *api_proto.VelociraptorUser) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return
+	}
+
+	authorization := md.Get("authorization")
+	if len(authorization) != 1 ||
+		!strings.HasPrefix(authorization[0], "Bearer ") {
+		return
+	}
+
+	remote := ""
+	peer, ok := peer.FromContext(ctx)
+	if ok {
+		remote = peer.Addr.String()
+	}
+
+	method, _ := grpc.Method(ctx)
+
+	users_manager := services.GetUserManager()
+	token, err := users_manager.VerifyAPIToken(ctx,
+		strings.TrimPrefix(authorization[0], "Bearer "), remote, method)
+	if err != nil {
+		return
+	}
+
+	result.Name = services.APITokenPrincipal(token.Username, token.TokenId)
+
+	org_id := md.Get("OrgId")
+	if len(org_id) == 1 && !utils.IsRootOrg(org_id[0]) {
+		result.CurrentOrg = org_id[0]
+	}
+}
@@ -0,0 +1,309 @@
+package users
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/Velocidex/ordereddict"
+	"google.golang.org/protobuf/proto"
+	"www.velocidex.com/golang/velociraptor/acls"
+	api_proto "www.velocidex.com/golang/velociraptor/api/proto"
+	"www.velocidex.com/golang/velociraptor/services"
+	"www.velocidex.com/golang/velociraptor/utils"
+)
+
+const (
+	// Only record the activity of a session this often to avoid
+	// writing the user record on every request.
+	guiSessionUsageResolution = 60
+
+	// Keep at most this many active sessions per user. The oldest
+	// sessions are forgotten first.
+	maxGUISessions = 50
+)
+
+var (
+	RevokedGUISessionError = errors.New("The session was revoked")
+)
+
+func hashGUISessionCookie(cookie string) []byte {
+	hash := sha256.Sum256([]byte(cookie))
+	return hash[:]
+}
+
+// Strip the hash before the session leaves the user manager.
+func cleanGUISession(session *api_proto.GUISession) *api_proto.GUISession {
+	result := proto.Clone(session).(*api_proto.GUISession)
+	result.Hash = nil
+	return result
+}
+
+func revokedGUISessionError(session *api_proto.GUISession) error {
+	return fmt.Errorf("%w by %v: %v",
+		RevokedGUISessionError, session.RevokedBy, session.RevokedReason)
+}
+
+// Find the unexpired session with the cookie hash. Expired sessions
+// are replaced by a new session.
+func findGUISession(sessions []*api_proto.GUISession,
+	hash []byte, now uint64) *api_proto.GUISession {
+	for _, s := range sessions {
+		if subtle.ConstantTimeCompare(hash, s.Hash) != 1 {
+			continue
+		}
+
+		if s.Expires > 0 && s.Expires < now {
+			return nil
+		}
+		return s
+	}
+	return nil
+}
+
+// Drop expired sessions and forget the oldest sessions over
+// maxGUISessions. Revoked sessions are kept until they expire so
+// their cookies remain rejected, and do not count towards the limit.
+func trimGUISessions(
+	sessions []*api_proto.GUISession, now uint64) []*api_proto.GUISession {
+	result := []*api_proto.GUISession{}
+	active := 0
+	for _, s := range sessions {
+		if s.Expires > 0 && s.Expires <= now {
+			continue
+		}
+
+		if !s.Revoked {
+			if active >= maxGUISessions {
+				continue
+			}
+			active++
+		}
+		result = append(result, s)
+	}
+	return result
+}
+
+func (self *UserManager) VerifyGUISession(
+	ctx context.Context,
+	cookie string, session *api_proto.GUISession) (
+	*api_proto.GUISession, error) {
+
+	if cookie == "" {
+		return nil, errors.New("No session cookie")
+	}
+
+	user_record, err := self.storage.GetUserWithHashes(ctx, session.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := hashGUISessionCookie(cookie)
+	now := uint64(utils.GetTime().Now().Unix())
+
+	// Most requests do not need to change the user record.
+	existing := findGUISession(user_record.GuiSessions, hash, now)
+	if existing != nil {
+		if existing.Revoked {
+			return nil, revokedGUISessionError(existing)
+		}
+
+		if existing.LastActive+guiSessionUsageResolution >= now &&
+			existing.LastActiveFrom == session.LastActiveFrom {
+			return cleanGUISession(existing), nil
+		}
+	}
+
+	// Record the activity or start a new session. The sessions may
+	// have changed since we read them so look again.
+	var result *api_proto.GUISession
+	created := false
+	err = self.storage.UpdateUserGUISessions(ctx, user_record.Name,
+		func(sessions []*api_proto.GUISession) (
+			[]*api_proto.GUISession, error) {
+			s := findGUISession(sessions, hash, now)
+			if s == nil {
+				s = &api_proto.GUISession{
+					SessionId:     "S." + utils.NextId(),
+					Username:      user_record.Name,
+					Authenticator: session.Authenticator,
+					LoginTime:     now,
+					LoginFrom:     session.LastActiveFrom,
+					UserAgent:     session.UserAgent,
+					Expires:       session.Expires,
+					Hash:          hash,
+				}
+				sessions = append([]*api_proto.GUISession{s}, sessions...)
+				created = true
+
+			} else if s.Revoked {
+				return nil, revokedGUISessionError(s)
+			}
+
+			s.LastActive = now
+			s.LastActiveFrom = session.LastActiveFrom
+			result = cleanGUISession(s)
+
+			return trimGUISessions(sessions, now), nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	if created {
+		self.audit(ctx, user_record.Name, "GUISessionStart",
+			ordereddict.NewDict().
+				Set("session_id", result.SessionId).
+				Set("authenticator", result.Authenticator).
+				Set("remote", result.LoginFrom).
+				Set("user_agent", result.UserAgent))
+	}
+
+	return result, nil
+}
+
+func (self *UserManager) ListGUISessions(
+	ctx context.Context,
+	principal, username string) ([]*api_proto.GUISession, error) {
+
+	var user_records []*api_proto.VelociraptorUser
+
+	if username == "" {
+		ok, err := self.isOrgAdmin(principal)
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			return nil, acls.PermissionDenied
+		}
+
+		user_records, err = self.storage.ListAllUsers(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+	} else {
+		// Sessions are managed by the same users as API tokens.
+		ok, err := self.canManageUser(ctx, principal, username)
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			return nil, acls.PermissionDenied
+		}
+
+		user_record, err := self.storage.GetUserWithHashes(ctx, username)
+		if err != nil {
+			return nil, err
+		}
+		user_records = append(user_records, user_record)
+	}
+
+	now := uint64(utils.GetTime().Now().Unix())
+	result := []*api_proto.GUISession{}
+	for _, user_record := range user_records {
+		for _, s := range user_record.GuiSessions {
+			if s.Expires == 0 || s.Expires > now {
+				result = append(result, cleanGUISession(s))
+			}
+		}
+	}
+
+	// Most recently active first.
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].LastActive > result[j].LastActive
+	})
+
+	return result, nil
+}
+
+func (self *UserManager) RevokeGUISession(
+	ctx context.Context,
+	principal, username, session_id, reason string) error {
+
+	ok, err := self.canManageUser(ctx, principal, username)
+	if err != nil {
+		return err
+	}
+
+	details := ordereddict.NewDict().
+		Set("user", username).
+		Set("session_id", session_id).
+		Set("reason", reason)
+
+	if !ok {
+		details.Set("error", acls.PermissionDenied.Error())
+		self.audit(ctx, principal, "RevokeGUISession", details)
+		return acls.PermissionDenied
+	}
+
+	user_record, err := self.storage.GetUserWithHashes(ctx, username)
+	if err != nil {
+		return err
+	}
+
+	var revoked []string
+	err = self.storage.UpdateUserGUISessions(ctx, user_record.Name,
+		func(sessions []*api_proto.GUISession) (
+			[]*api_proto.GUISession, error) {
+			now := uint64(utils.GetTime().Now().Unix())
+			found := false
+			for _, s := range sessions {
+				if session_id != "" && s.SessionId != session_id {
+					continue
+				}
+
+				found = true
+				if s.Revoked {
+					continue
+				}
+
+				s.Revoked = true
+				s.RevokedTime = now
+				s.RevokedBy = principal
+				s.RevokedReason = reason
+				revoked = append(revoked, s.SessionId)
+			}
+
+			if session_id != "" && !found {
+				return nil, fmt.Errorf("%w: GUI session %v",
+					utils.NotFoundError, session_id)
+			}
+
+			if len(revoked) == 0 {
+				return nil, noChangeError
+			}
+			return sessions, nil
+		})
+	if err != nil {
+		return err
+	}
+
+	if len(revoked) == 0 {
+		return nil
+	}
+
+	details.Set("revoked", revoked)
+	self.audit(ctx, principal, "RevokeGUISession", details)
+	return nil
+}
+
+func (self *UserManager) isOrgAdmin(principal string) (bool, error) {
+	org_manager, err := services.GetOrgManager()
+	if err != nil {
+		return false, err
+	}
+
+	root_config_obj, err := org_manager.GetOrgConfig(services.ROOT_ORG_ID)
+	if err != nil {
+		return false, err
+	}
+
+	ok, _ := services.CheckAccess(root_config_obj, principal, acls.ORG_ADMIN)
+	return ok, nil
+}
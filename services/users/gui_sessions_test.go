@@ -0,0 +1,229 @@
+package users_test
+
+import (
+	"fmt"
+	"time"
+
+	acl_proto "www.velocidex.com/golang/velociraptor/acls/proto"
+	api_proto "www.velocidex.com/golang/velociraptor/api/proto"
+	"www.velocidex.com/golang/velociraptor/datastore"
+	"www.velocidex.com/golang/velociraptor/paths"
+	"www.velocidex.com/golang/velociraptor/services"
+	"www.velocidex.com/golang/velociraptor/utils"
+	"www.velocidex.com/golang/velociraptor/vtesting/assert"
+)
+
+func (self *UserManagerTestSuite) TestGUISessions() {
+	self.makeUsers()
+
+	clock := utils.NewMockClock(time.Unix(1800000000, 0))
+	closer := utils.MockTime(clock)
+	defer closer()
+
+	users_manager := services.GetUserManager()
+	org_manager, err := services.GetOrgManager()
+	assert.NoError(self.T(), err)
+
+	o1_config_obj, err := org_manager.GetOrgConfig("O1")
+	assert.NoError(self.T(), err)
+
+	newSession := func(username, remote string) *api_proto.GUISession {
+		return &api_proto.GUISession{
+			Username:       username,
+			Authenticator:  "oidc",
+			LastActiveFrom: remote,
+			UserAgent:      "test",
+			Expires:        uint64(clock.Now().Add(time.Hour).Unix()),
+		}
+	}
+
+	// The first time a cookie is seen a new session is created.
+	session, err := users_manager.VerifyGUISession(
+		self.Ctx, "Cookie1", newSession("UserO1", "10.1.1.1"))
+	assert.NoError(self.T(), err)
+	assert.Nil(self.T(), session.Hash)
+	assert.Equal(self.T(), "10.1.1.1", session.LoginFrom)
+
+	// The same cookie continues the session.
+	clock.Set(clock.Now().Add(2 * time.Minute))
+	session2, err := users_manager.VerifyGUISession(
+		self.Ctx, "Cookie1", newSession("UserO1", "10.1.1.2"))
+	assert.NoError(self.T(), err)
+	assert.Equal(self.T(), session.SessionId, session2.SessionId)
+	assert.Equal(self.T(), "10.1.1.1", session2.LoginFrom)
+	assert.Equal(self.T(), "10.1.1.2", session2.LastActiveFrom)
+
+	_, err = users_manager.VerifyGUISession(
+		self.Ctx, "Cookie2", newSession("UserO1", "10.1.1.3"))
+	assert.NoError(self.T(), err)
+
+	// Updating the user record does not remove the sessions.
+	err = users_manager.SetUser(self.Ctx, &api_proto.VelociraptorUser{
+		Name: "UserO1",
+		Orgs: []*api_proto.OrgRecord{{Id: "O1"}},
+	})
+	assert.NoError(self.T(), err)
+
+	sessions, err := users_manager.ListGUISessions(self.Ctx, "UserO1", "UserO1")
+	assert.NoError(self.T(), err)
+	assert.Equal(self.T(), 2, len(sessions))
+
+	// The user record never exposes the sessions.
+	user_record, err := users_manager.GetUser(self.Ctx, "OrgAdmin", "UserO1")
+	assert.NoError(self.T(), err)
+	assert.Equal(self.T(), 0, len(user_record.GuiSessions))
+
+	// Only org admins can list everyone's sessions.
+	_, err = users_manager.ListGUISessions(self.Ctx, "AdminO1", "")
+	assert.ErrorContains(self.T(), err, "PermissionDenied")
+
+	sessions, err = users_manager.ListGUISessions(self.Ctx, "OrgAdmin", "")
+	assert.NoError(self.T(), err)
+	assert.Equal(self.T(), 2, len(sessions))
+
+	// Admins of other orgs can not revoke the session.
+	err = users_manager.RevokeGUISession(
+		self.Ctx, "AdminO2", "UserO1", session.SessionId, "Forced logout")
+	assert.ErrorContains(self.T(), err, "PermissionDenied")
+
+	err = users_manager.RevokeGUISession(
+		self.Ctx, "AdminO1", "UserO1", "S.Unknown", "Forced logout")
+	assert.ErrorContains(self.T(), err, "NotFoundError")
+
+	err = users_manager.RevokeGUISession(
+		self.Ctx, "AdminO1", "UserO1", session.SessionId, "Forced logout")
+	assert.NoError(self.T(), err)
+
+	// The revoked cookie is rejected but the other session continues.
+	_, err = users_manager.VerifyGUISession(
+		self.Ctx, "Cookie1", newSession("UserO1", "10.1.1.1"))
+	assert.ErrorContains(self.T(), err, "revoked by AdminO1: Forced logout")
+
+	_, err = users_manager.VerifyGUISession(
+		self.Ctx, "Cookie2", newSession("UserO1", "10.1.1.3"))
+	assert.NoError(self.T(), err)
+
+	// Changing the password ends all sessions.
+	err = users_manager.SetUserPassword(
+		self.Ctx, self.ConfigObj, "AdminO1", "UserO1", "MyPassword", "")
+	assert.NoError(self.T(), err)
+
+	_, err = users_manager.VerifyGUISession(
+		self.Ctx, "Cookie2", newSession("UserO1", "10.1.1.3"))
+	assert.ErrorContains(self.T(), err, "Password changed")
+
+	// Removing a role ends all sessions.
+	_, err = users_manager.VerifyGUISession(
+		self.Ctx, "Cookie3", newSession("UserO1", "10.1.1.3"))
+	assert.NoError(self.T(), err)
+
+	acl_manager, err := services.GetACLManager(o1_config_obj)
+	assert.NoError(self.T(), err)
+
+	err = acl_manager.SetPolicy(o1_config_obj, "UserO1",
+		&acl_proto.ApiClientACL{Roles: []string{"reader", "analyst"}})
+	assert.NoError(self.T(), err)
+
+	_, err = users_manager.VerifyGUISession(
+		self.Ctx, "Cookie3", newSession("UserO1", "10.1.1.3"))
+	assert.NoError(self.T(), err)
+
+	err = acl_manager.SetPolicy(o1_config_obj, "UserO1",
+		&acl_proto.ApiClientACL{Roles: []string{"analyst"}})
+	assert.NoError(self.T(), err)
+
+	_, err = users_manager.VerifyGUISession(
+		self.Ctx, "Cookie3", newSession("UserO1", "10.1.1.3"))
+	assert.ErrorContains(self.T(), err, "Roles removed in org O1: [reader]")
+
+	// Expired sessions are no longer listed and the cookie starts a
+	// new session.
+	clock.Set(clock.Now().Add(2 * time.Hour))
+	sessions, err = users_manager.ListGUISessions(self.Ctx, "UserO1", "UserO1")
+	assert.NoError(self.T(), err)
+	assert.Equal(self.T(), 0, len(sessions))
+
+	session3, err := users_manager.VerifyGUISession(
+		self.Ctx, "Cookie1", newSession("UserO1", "10.1.1.1"))
+	assert.NoError(self.T(), err)
+	assert.NotEqual(self.T(), session.SessionId, session3.SessionId)
+}
+
+func (self *UserManagerTestSuite) TestGUISessionsLimit() {
+	self.makeUsers()
+
+	clock := utils.NewMockClock(time.Unix(1800000000, 0))
+	closer := utils.MockTime(clock)
+	defer closer()
+
+	users_manager := services.GetUserManager()
+
+	newSession := func() *api_proto.GUISession {
+		return &api_proto.GUISession{
+			Username:       "UserO1",
+			Authenticator:  "oidc",
+			LastActiveFrom: "10.1.1.1",
+			Expires:        uint64(clock.Now().Add(time.Hour).Unix()),
+		}
+	}
+
+	session, err := users_manager.VerifyGUISession(
+		self.Ctx, "Revoked", newSession())
+	assert.NoError(self.T(), err)
+
+	err = users_manager.RevokeGUISession(self.Ctx, "AdminO1", "UserO1",
+		session.SessionId, "Forced logout")
+	assert.NoError(self.T(), err)
+
+	// Many newer sessions do not push out the revoked session.
+	for i := 0; i < 60; i++ {
+		_, err = users_manager.VerifyGUISession(
+			self.Ctx, fmt.Sprintf("Cookie%d", i), newSession())
+		assert.NoError(self.T(), err)
+	}
+
+	_, err = users_manager.VerifyGUISession(
+		self.Ctx, "Revoked", newSession())
+	assert.ErrorContains(self.T(), err, "revoked by AdminO1")
+
+	// Only the newest active sessions are kept.
+	sessions, err := users_manager.ListGUISessions(self.Ctx, "UserO1", "UserO1")
+	assert.NoError(self.T(), err)
+	assert.Equal(self.T(), 51, len(sessions))
+}
+
+func (self *UserManagerTestSuite) TestGUISessionsUncachedPolicy() {
+	self.makeUsers()
+
+	users_manager := services.GetUserManager()
+	org_manager, err := services.GetOrgManager()
+	assert.NoError(self.T(), err)
+
+	o1_config_obj, err := org_manager.GetOrgConfig("O1")
+	assert.NoError(self.T(), err)
+
+	// UserO2 has no policy in O1 yet. Store one behind the ACL
+	// manager's back as another frontend would.
+	db, err := datastore.GetDB(o1_config_obj)
+	assert.NoError(self.T(), err)
+
+	err = db.SetSubject(o1_config_obj,
+		paths.UserPathManager{Name: "UserO2"}.ACL(),
+		&acl_proto.ApiClientACL{Roles: []string{"reader", "analyst"}})
+	assert.NoError(self.T(), err)
+
+	_, err = users_manager.VerifyGUISession(self.Ctx, "Cookie1",
+		&api_proto.GUISession{Username: "UserO2"})
+	assert.NoError(self.T(), err)
+
+	acl_manager, err := services.GetACLManager(o1_config_obj)
+	assert.NoError(self.T(), err)
+
+	err = acl_manager.SetPolicy(o1_config_obj, "UserO2",
+		&acl_proto.ApiClientACL{Roles: []string{"analyst"}})
+	assert.NoError(self.T(), err)
+
+	_, err = users_manager.VerifyGUISession(self.Ctx, "Cookie1",
+		&api_proto.GUISession{Username: "UserO2"})
+	assert.ErrorContains(self.T(), err, "Roles removed in org O1: [reader]")
+}
@@ -0,0 +1,88 @@
+package users
+
+import (
+	"context"
+
+	"github.com/Velocidex/ordereddict"
+	"www.velocidex.com/golang/velociraptor/acls"
+	api_proto "www.velocidex.com/golang/velociraptor/api/proto"
+	"www.velocidex.com/golang/velociraptor/logging"
+	"www.velocidex.com/golang/velociraptor/services"
+)
+
+// Update the user's second factor enrollment.
+// A user may update their own second factor.
+// A ServerAdmin in any of the orgs the user belongs to can reset it.
+// An OrgAdmin can reset everyone's second factor.
+func (self *UserManager) SetUserMFA(
+	ctx context.Context,
+	principal, username string,
+	mfa *api_proto.UserMFA) error {
+
+	org_manager, err := services.GetOrgManager()
+	if err != nil {
+		return err
+	}
+
+	root_config_obj, err := org_manager.GetOrgConfig(services.ROOT_ORG_ID)
+	if err != nil {
+		return err
+	}
+
+	user_record, err := self.GetUser(ctx, principal, username)
+	if err != nil {
+		return err
+	}
+
+	operation := "Update Own Second Factor"
+	allowed := principal == username
+
+	// Admins may only reset the second factor - they can not enroll
+	// factors on behalf of the user.
+	if !allowed && mfa == nil {
+		operation = "Reset Second Factor By Admin"
+
+		ok, _ := services.CheckAccess(root_config_obj, principal, acls.ORG_ADMIN)
+		if ok {
+			allowed = true
+		}
+
+		for _, user_org := range user_record.Orgs {
+			if allowed {
+				break
+			}
+
+			org_config_obj, err := org_manager.GetOrgConfig(user_org.Id)
+			if err != nil {
+				continue
+			}
+
+			ok, _ := services.CheckAccess(
+				org_config_obj, principal, acls.SERVER_ADMIN)
+			if ok {
+				allowed = true
+			}
+		}
+	}
+
+	details := ordereddict.NewDict().
+		Set("operation", operation).
+		Set("user", user_record.Name)
+	if !allowed {
+		details.Set("error", acls.PermissionDenied.Error())
+	}
+
+	err = services.LogAudit(ctx,
+		root_config_obj, principal, "Update second factor", details)
+	if err != nil {
+		logger := logging.GetLogger(root_config_obj, &logging.FrontendComponent)
+		logger.Error("<red>UserManager Update Second Factor</> %v %v",
+			principal, user_record.Name)
+	}
+
+	if !allowed {
+		return acls.PermissionDenied
+	}
+
+	return self.storage.SetUserMFA(ctx, user_record.Name, mfa)
+}
@@ -0,0 +1,69 @@
+package users_test
+
+import (
+	api_proto "www.velocidex.com/golang/velociraptor/api/proto"
+	"www.velocidex.com/golang/velociraptor/services"
+	"www.velocidex.com/golang/velociraptor/vtesting/assert"
+)
+
+func (self *UserManagerTestSuite) TestSetUserMFA() {
+	self.makeUsers()
+
+	users_manager := services.GetUserManager()
+	mfa := &api_proto.UserMFA{
+		TotpSecret:  []byte("secret"),
+		TotpEnabled: true,
+	}
+
+	// Can a user enroll their own second factor?
+	err := users_manager.SetUserMFA(self.Ctx, "UserO1", "UserO1", mfa)
+	assert.NoError(self.T(), err)
+
+	user_record, err := users_manager.GetUserWithHashes(
+		self.Ctx, "UserO1", "UserO1")
+	assert.NoError(self.T(), err)
+	assert.Equal(self.T(), "secret", string(user_record.Mfa.TotpSecret))
+
+	// The second factor is not visible in the regular user record.
+	user_record, err = users_manager.GetUser(self.Ctx, "UserO1", "UserO1")
+	assert.NoError(self.T(), err)
+	assert.Nil(self.T(), user_record.Mfa)
+
+	// Updating the user record does not remove the second factor.
+	err = users_manager.SetUserPassword(
+		self.Ctx, self.ConfigObj, "UserO1", "UserO1", "MyPassword", "")
+	assert.NoError(self.T(), err)
+
+	user_record, err = users_manager.GetUserWithHashes(
+		self.Ctx, "UserO1", "UserO1")
+	assert.NoError(self.T(), err)
+	assert.True(self.T(), user_record.Mfa.TotpEnabled)
+
+	// Can a user reset another user's second factor?
+	err = users_manager.SetUserMFA(self.Ctx, "UserO2", "UserO1", nil)
+	assert.ErrorContains(self.T(), err, "PermissionDenied")
+
+	// Can an admin in another org reset it?
+	err = users_manager.SetUserMFA(self.Ctx, "AdminO2", "UserO1", nil)
+	assert.ErrorContains(self.T(), err, "PermissionDenied")
+
+	// Admins can not enroll factors on behalf of the user.
+	err = users_manager.SetUserMFA(self.Ctx, "AdminO1", "UserO1", mfa)
+	assert.ErrorContains(self.T(), err, "PermissionDenied")
+
+	// Can an admin in the user's org reset it?
+	err = users_manager.SetUserMFA(self.Ctx, "AdminO1", "UserO1", nil)
+	assert.NoError(self.T(), err)
+
+	user_record, err = users_manager.GetUserWithHashes(
+		self.Ctx, "UserO1", "UserO1")
+	assert.NoError(self.T(), err)
+	assert.Nil(self.T(), user_record.Mfa)
+
+	// Can an org admin reset anyone's second factor?
+	err = users_manager.SetUserMFA(self.Ctx, "UserO2", "UserO2", mfa)
+	assert.NoError(self.T(), err)
+
+	err = users_manager.SetUserMFA(self.Ctx, "OrgAdmin", "UserO2", nil)
+	assert.NoError(self.T(), err)
+}
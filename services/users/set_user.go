@@ -81,7 +81,7 @@ func (self *UserManager) SetUserPassword(
 			logger := logging.GetLogger(config_obj, &logging.FrontendComponent)
 			logger.Error("<red>UserManager Update Password</> %v", principal)
 		}
-		return user_manager.SetUser(ctx, user_record)
+		return self.updatePassword(ctx, principal, user_record, password)
 	}
 
 	// ORG_ADMINs can see everything
@@ -98,7 +98,7 @@ func (self *UserManager) SetUserPassword(
 				principal, user_record.Name)
 		}
 
-		return user_manager.SetUser(ctx, user_record)
+		return self.updatePassword(ctx, principal, user_record, password)
 	}
 
 	for _, user_org := range user_record.Orgs {
@@ -121,7 +121,7 @@ func (self *UserManager) SetUserPassword(
 					principal, user_record.Name)
 			}
 
-			return user_manager.SetUser(ctx, user_record)
+			return self.updatePassword(ctx, principal, user_record, password)
 		}
 	}
 
@@ -139,6 +139,19 @@ func (self *UserManager) SetUserPassword(
 	return acls.PermissionDenied
 }
 
+// Changing the password ends all the user's GUI sessions.
+func (self *UserManager) updatePassword(
+	ctx context.Context, principal string,
+	user_record *api_proto.VelociraptorUser, password string) error {
+	err := self.SetUser(ctx, user_record)
+	if err != nil || password == "" {
+		return err
+	}
+
+	return self.RevokeGUISession(ctx, principal, user_record.Name, "",
+		"Password changed")
+}
+
 func setPassword(user_record *api_proto.VelociraptorUser, password string) {
 	salt := make([]byte, 32)
 	_, err := rand.Read(salt)
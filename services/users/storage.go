@@ -24,6 +24,12 @@ import (
 	"www.velocidex.com/golang/velociraptor/utils"
 )
 
+var (
+	// Update callbacks return this to leave the user record
+	// unchanged.
+	noChangeError = errors.New("No change")
+)
+
 // Responsible for storing the User records
 type IUserStorageManager interface {
 	GetUserWithHashes(ctx context.Context, username string) (
@@ -31,6 +37,23 @@ type IUserStorageManager interface {
 
 	SetUser(ctx context.Context, user_record *api_proto.VelociraptorUser) error
 
+	// The second factor is only updated through this method.
+	SetUserMFA(ctx context.Context, username string, mfa *api_proto.UserMFA) error
+
+	// API tokens are only updated through these methods.
+	AddAPIToken(ctx context.Context,
+		username string, token *api_proto.ApiToken) error
+	RemoveAPIToken(ctx context.Context, username, token_id string) error
+	SetAPITokenLastUsed(ctx context.Context,
+		username, token_id string, last_used uint64, remote string) error
+
+	// GUI sessions are only updated through this method. The
+	// callback modifies the stored sessions while the user record is
+	// locked and returns the new sessions.
+	UpdateUserGUISessions(ctx context.Context, username string,
+		cb func(sessions []*api_proto.GUISession) (
+			[]*api_proto.GUISession, error)) error
+
 	ListAllUsers(ctx context.Context) ([]*api_proto.VelociraptorUser, error)
 
 	GetUserOptions(ctx context.Context, username string) (
@@ -73,6 +96,33 @@ func (self *NullStorageManager) SetUser(ctx context.Context,
 	return utils.NotImplementedError
 }
 
+func (self *NullStorageManager) SetUserMFA(ctx context.Context,
+	username string, mfa *api_proto.UserMFA) error {
+	return utils.NotImplementedError
+}
+
+func (self *NullStorageManager) AddAPIToken(ctx context.Context,
+	username string, token *api_proto.ApiToken) error {
+	return utils.NotImplementedError
+}
+
+func (self *NullStorageManager) RemoveAPIToken(ctx context.Context,
+	username, token_id string) error {
+	return utils.NotImplementedError
+}
+
+func (self *NullStorageManager) SetAPITokenLastUsed(ctx context.Context,
+	username, token_id string, last_used uint64, remote string) error {
+	return utils.NotImplementedError
+}
+
+func (self *NullStorageManager) UpdateUserGUISessions(
+	ctx context.Context, username string,
+	cb func(sessions []*api_proto.GUISession) (
+		[]*api_proto.GUISession, error)) error {
+	return utils.NotImplementedError
+}
+
 func (self *NullStorageManager) ListAllUsers(
 	ctx context.Context) ([]*api_proto.VelociraptorUser, error) {
 	return nil, utils.NotImplementedError
@@ -234,8 +284,25 @@ func (self *UserStorageManager) SetUser(
 		}
 	}
 
+	// Most callers update records obtained from GetUser() which
+	// strips the second factor, API tokens and GUI sessions so we
+	// always keep the existing ones. They can only be changed
+	// through SetUserMFA(), the API token methods and
+	// UpdateUserGUISessions()
+	var mfa *api_proto.UserMFA
+	var api_tokens []*api_proto.ApiToken
+	var gui_sessions []*api_proto.GUISession
+	if cache.user_record != nil {
+		mfa = cache.user_record.Mfa
+		api_tokens = cache.user_record.ApiTokens
+		gui_sessions = cache.user_record.GuiSessions
+	}
+
 	// Cache a copy of the new record in memory.
 	cache.user_record = proto.Clone(user_record).(*api_proto.VelociraptorUser)
+	cache.user_record.Mfa = mfa
+	cache.user_record.ApiTokens = api_tokens
+	cache.user_record.GuiSessions = gui_sessions
 
 	// Remove the org list because that will be built at runtime so it
 	// does not need to be stored.
@@ -264,6 +331,135 @@ func (self *UserStorageManager) SetUser(
 	})
 }
 
+func (self *UserStorageManager) SetUserMFA(
+	ctx context.Context, username string, mfa *api_proto.UserMFA) error {
+	return self.updateUserRecord(ctx, username,
+		func(user_record *api_proto.VelociraptorUser) error {
+			if mfa != nil {
+				user_record.Mfa = proto.Clone(mfa).(*api_proto.UserMFA)
+			} else {
+				user_record.Mfa = nil
+			}
+			return nil
+		})
+}
+
+// Adds the token and drops any expired ones.
+func (self *UserStorageManager) AddAPIToken(
+	ctx context.Context, username string, token *api_proto.ApiToken) error {
+	return self.updateUserRecord(ctx, username,
+		func(user_record *api_proto.VelociraptorUser) error {
+			now := uint64(utils.GetTime().Now().Unix())
+			tokens := []*api_proto.ApiToken{
+				proto.Clone(token).(*api_proto.ApiToken)}
+			for _, t := range user_record.ApiTokens {
+				if t.Expires > now {
+					tokens = append(tokens, t)
+				}
+			}
+			user_record.ApiTokens = tokens
+			return nil
+		})
+}
+
+func (self *UserStorageManager) RemoveAPIToken(
+	ctx context.Context, username, token_id string) error {
+	return self.updateUserRecord(ctx, username,
+		func(user_record *api_proto.VelociraptorUser) error {
+			for idx, t := range user_record.ApiTokens {
+				if t.TokenId == token_id {
+					user_record.ApiTokens = append(
+						user_record.ApiTokens[:idx],
+						user_record.ApiTokens[idx+1:]...)
+					return nil
+				}
+			}
+			return fmt.Errorf("%w: API token %v",
+				utils.NotFoundError, token_id)
+		})
+}
+
+func (self *UserStorageManager) SetAPITokenLastUsed(
+	ctx context.Context, username, token_id string,
+	last_used uint64, remote string) error {
+	return self.updateUserRecord(ctx, username,
+		func(user_record *api_proto.VelociraptorUser) error {
+			for _, t := range user_record.ApiTokens {
+				if t.TokenId == token_id {
+					t.LastUsed = last_used
+					t.LastUsedFrom = remote
+					return nil
+				}
+			}
+			return fmt.Errorf("%w: API token %v",
+				utils.NotFoundError, token_id)
+		})
+}
+
+func (self *UserStorageManager) UpdateUserGUISessions(
+	ctx context.Context, username string,
+	cb func(sessions []*api_proto.GUISession) (
+		[]*api_proto.GUISession, error)) error {
+	return self.updateUserRecord(ctx, username,
+		func(user_record *api_proto.VelociraptorUser) error {
+			sessions, err := cb(user_record.GuiSessions)
+			if err != nil {
+				return err
+			}
+			user_record.GuiSessions = sessions
+			return nil
+		})
+}
+
+// Modify a copy of the cached user record and store it. If the
+// callback fails, or returns noChangeError, the record is left
+// unchanged.
+func (self *UserStorageManager) updateUserRecord(
+	ctx context.Context, username string,
+	cb func(user_record *api_proto.VelociraptorUser) error) error {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	if username == "" {
+		return errors.New("Must set a username")
+	}
+
+	key := makeKey(username)
+	cache, pres := self.cache[key]
+	if !pres || cache.user_record == nil {
+		return fmt.Errorf("%w: %v", services.UserNotFoundError, username)
+	}
+
+	user_record := proto.Clone(cache.user_record).(*api_proto.VelociraptorUser)
+	err := cb(user_record)
+	if errors.Is(err, noChangeError) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	db, err := datastore.GetDB(self.config_obj)
+	if err != nil {
+		return err
+	}
+
+	err = db.SetSubject(self.config_obj,
+		paths.UserPathManager{Name: user_record.Name}.Path(),
+		user_record)
+	if err != nil {
+		return err
+	}
+
+	cache.user_record = user_record
+	cache.timestamp = utils.GetTime().Now()
+
+	return self.sendMutation(ctx, UserMutation{
+		Op:       "Update",
+		Username: user_record.Name,
+	})
+}
+
 // Advertise the changes. This will force all minions to flush their
 // caches.
 func (self *UserStorageManager) sendMutation(
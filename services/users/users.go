@@ -24,6 +24,7 @@ import (
 	"crypto/x509"
 	"fmt"
 	"regexp"
+	"strings"
 	"sync"
 
 	"github.com/Velocidex/ordereddict"
@@ -88,7 +89,7 @@ const (
 )
 
 var (
-	validUsernameRegEx = regexp.MustCompile(`^[a-zA-Z0-9@.\-_#+]+$`)
+	validUsernameRegEx = regexp.MustCompile(`^[a-zA-Z0-9@.\-_+]+$`)
 )
 
 type UserManager struct {
@@ -124,6 +125,13 @@ func ValidateUsername(config_obj *config_proto.Config, name string) error {
 		return fmt.Errorf("Username is reserved for the gateway: %v", name)
 	}
 
+	// The # character separates the user from the token id in API
+	// token principals (e.g. alice#T.xxx), so it may not appear in a
+	// username or the principal becomes ambiguous.
+	if strings.Contains(name, "#") {
+		return fmt.Errorf("Unacceptable username %v: # is not allowed", name)
+	}
+
 	return nil
 }
 
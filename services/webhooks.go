@@ -0,0 +1,62 @@
+package services
+
+import (
+	"context"
+
+	api_proto "www.velocidex.com/golang/velociraptor/api/proto"
+	config_proto "www.velocidex.com/golang/velociraptor/config/proto"
+)
+
+/*
+  The WebhookManager delivers server events to external endpoints
+  (e.g. SOAR platforms).
+
+  Administrators register webhooks with the events they are
+  interested in. The service watches the relevant journal queues and
+  POSTs each matching event to the webhook's url, signing the body
+  with a HMAC key from the secrets service. Failed deliveries are
+  retried with exponential backoff and every attempt is recorded in
+  the Server.Internal.WebhookDeliveries event artifact.
+
+  Each webhook has a bounded queue of pending deliveries kept on
+  disk, so they survive a restart. Events arriving while the queue is
+  full are dropped and recorded as such.
+*/
+
+// Event types a webhook can subscribe to.
+const (
+	WEBHOOK_FLOW_COMPLETED     = "FLOW_COMPLETED"
+	WEBHOOK_HUNT_STATE_CHANGED = "HUNT_STATE_CHANGED"
+	WEBHOOK_ALERT              = "ALERT"
+	WEBHOOK_CLIENT_ENROLLED    = "CLIENT_ENROLLED"
+)
+
+var (
+	WebhookEventTypes = []string{
+		WEBHOOK_FLOW_COMPLETED,
+		WEBHOOK_HUNT_STATE_CHANGED,
+		WEBHOOK_ALERT,
+		WEBHOOK_CLIENT_ENROLLED,
+	}
+)
+
+type WebhookManager interface {
+	// Add or replace a webhook. The principal is recorded as the
+	// owner of the webhook and must have access to the signing
+	// secret.
+	SetWebhook(ctx context.Context,
+		principal string, webhook *api_proto.Webhook) error
+
+	DeleteWebhook(ctx context.Context, principal, name string) error
+
+	ListWebhooks(ctx context.Context) ([]*api_proto.Webhook, error)
+}
+
+func GetWebhookManager(config_obj *config_proto.Config) (WebhookManager, error) {
+	org_manager, err := GetOrgManager()
+	if err != nil {
+		return nil, err
+	}
+
+	return org_manager.Services(config_obj.OrgId).WebhookManager()
+}
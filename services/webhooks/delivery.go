@@ -0,0 +1,354 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Velocidex/ordereddict"
+	api_proto "www.velocidex.com/golang/velociraptor/api/proto"
+	"www.velocidex.com/golang/velociraptor/constants"
+	"www.velocidex.com/golang/velociraptor/json"
+	"www.velocidex.com/golang/velociraptor/logging"
+	"www.velocidex.com/golang/velociraptor/paths/artifacts"
+	"www.velocidex.com/golang/velociraptor/services"
+	"www.velocidex.com/golang/velociraptor/utils"
+	"www.velocidex.com/golang/velociraptor/vql/networking"
+)
+
+var (
+	// The first retry happens after this delay, doubling for each
+	// subsequent retry up to maxRetryDelay.
+	retryDelay    = 2 * time.Second
+	maxRetryDelay = 5 * time.Minute
+
+	requestTimeout = 30 * time.Second
+
+	templateFuncs = map[string]interface{}{
+		"json": func(in interface{}) string {
+			return json.MustMarshalString(in)
+		},
+	}
+)
+
+// Only called from tests to avoid waiting for retries.
+func SetRetryDelayForTests(delay time.Duration) func() {
+	old_delay := retryDelay
+	retryDelay = delay
+	return func() {
+		retryDelay = old_delay
+	}
+}
+
+// The signing key and extra headers for a webhook.
+type deliveryOptions struct {
+	hmac_key      []byte
+	extra_headers *ordereddict.Dict
+	skip_verify   bool
+	root_ca       string
+}
+
+// Queue the event for delivery to the webhook in the background.
+func (self *WebhookManager) Deliver(webhook *api_proto.Webhook,
+	event string, data *ordereddict.Dict) {
+
+	payload := ordereddict.NewDict().
+		Set("Event", event).
+		Set("OrgId", utils.NormalizedOrgId(self.config_obj.OrgId)).
+		Set("Timestamp", utils.GetTime().Now().UTC()).
+		Set("Data", data)
+
+	serialized, err := json.Marshal(payload)
+	if err != nil {
+		self.logDropped(self.ctx, webhook, event, err)
+		return
+	}
+
+	self.mu.Lock()
+	queue, err := self._getQueue(webhook.Name)
+	self.mu.Unlock()
+	if err != nil {
+		self.logDropped(self.ctx, webhook, event, err)
+		return
+	}
+
+	err = queue.Enqueue(event, serialized)
+	if err != nil {
+		self.logDropped(self.ctx, webhook, event, err)
+	}
+}
+
+// Deliver the serialized payload, retrying as needed. Returns false
+// if the delivery was interrupted before it was final.
+func (self *WebhookManager) deliver(ctx context.Context,
+	webhook *api_proto.Webhook, event string, payload []byte) bool {
+
+	delivery_id := utils.NextId()
+
+	body, err := renderPayload(webhook, payload)
+	if err != nil {
+		self.logDelivery(ctx, webhook, event, delivery_id, 0, 0, 0, err, true)
+		return true
+	}
+
+	opts, err := self.getDeliveryOptions(ctx, webhook)
+	if err != nil {
+		self.logDelivery(ctx, webhook, event, delivery_id, 0, 0, 0, err, true)
+		return true
+	}
+
+	client, err := self.getHttpClient(opts)
+	if err != nil {
+		self.logDelivery(ctx, webhook, event, delivery_id, 0, 0, 0, err, true)
+		return true
+	}
+
+	max_retries := webhook.MaxRetries
+	if max_retries <= 0 {
+		max_retries = defaultMaxRetries
+	}
+
+	delay := retryDelay
+	for attempt := 1; ; attempt++ {
+		start := utils.GetTime().Now()
+		status, err := self.send(ctx, client, webhook, opts,
+			event, delivery_id, body)
+		duration := utils.GetTime().Now().Sub(start)
+
+		// Only transport errors, server errors and throttling are
+		// worth retrying. Other statuses will not change on retry.
+		retry := err != nil && (status == 0 ||
+			status == http.StatusTooManyRequests || status >= 500)
+		final := !retry || int64(attempt) > max_retries
+
+		self.logDelivery(ctx, webhook, event, delivery_id,
+			attempt, status, duration, err, final)
+		if final {
+			return true
+		}
+
+		if !utils.SleepWithCtx(ctx, utils.Jitter(delay)) {
+			return false
+		}
+
+		delay *= 2
+		if delay > maxRetryDelay {
+			delay = maxRetryDelay
+		}
+	}
+}
+
+func (self *WebhookManager) send(ctx context.Context,
+	client *http.Client, webhook *api_proto.Webhook,
+	opts *deliveryOptions, event, delivery_id string,
+	body []byte) (int, error) {
+
+	req, err := http.NewRequestWithContext(ctx, "POST",
+		webhook.Url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	content_type := webhook.ContentType
+	if content_type == "" {
+		content_type = "application/json"
+	}
+
+	timestamp := strconv.FormatInt(utils.GetTime().Now().Unix(), 10)
+
+	req.Header.Set("Content-Type", content_type)
+	req.Header.Set("User-Agent", constants.USER_AGENT)
+	req.Header.Set("X-Velociraptor-Event", event)
+	req.Header.Set("X-Velociraptor-Delivery", delivery_id)
+	req.Header.Set("X-Velociraptor-Timestamp", timestamp)
+
+	if len(opts.hmac_key) > 0 {
+		req.Header.Set("X-Velociraptor-Signature",
+			"sha256="+Sign(opts.hmac_key, timestamp, body))
+	}
+
+	if opts.extra_headers != nil {
+		for _, k := range opts.extra_headers.Keys() {
+			v, _ := opts.extra_headers.GetString(k)
+			req.Header.Set(k, v)
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1024*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("Webhook returned %v", resp.Status)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// Sign computes the signature sent in the X-Velociraptor-Signature
+// header. The timestamp is included so receivers can reject replayed
+// deliveries.
+func Sign(key []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write([]byte(timestamp + "."))
+	_, _ = mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func renderPayload(webhook *api_proto.Webhook,
+	serialized []byte) ([]byte, error) {
+	if webhook.Template == "" {
+		return serialized, nil
+	}
+
+	tmpl, err := parseTemplate(webhook.Template)
+	if err != nil {
+		return nil, err
+	}
+
+	// Templates operate on plain maps so fields can be accessed as
+	// {{ .Data.ClientId }}
+	var data interface{}
+	err = json.Unmarshal(serialized, &data)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := &bytes.Buffer{}
+	err = tmpl.Execute(buf, data)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (self *WebhookManager) getDeliveryOptions(ctx context.Context,
+	webhook *api_proto.Webhook) (*deliveryOptions, error) {
+	result := &deliveryOptions{}
+	if webhook.Secret == "" {
+		return result, nil
+	}
+
+	secrets, err := services.GetSecretsService(self.config_obj)
+	if err != nil {
+		return nil, err
+	}
+
+	// The secret is used on behalf of the user who registered the
+	// webhook.
+	secret, err := secrets.GetSecret(ctx, webhook.CreatedBy,
+		constants.WEBHOOK_SECRET, webhook.Secret)
+	if err != nil {
+		return nil, err
+	}
+
+	result.hmac_key = []byte(secret.GetString("hmac_key"))
+	result.skip_verify = secret.GetBool("skip_verify")
+	result.root_ca = secret.GetString("root_ca")
+	result.extra_headers, err = secret.GetDict("extra_headers")
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (self *WebhookManager) getHttpClient(
+	opts *deliveryOptions) (*http.Client, error) {
+	// Transports are cached so connections can be reused, but the
+	// cached transport must not be modified.
+	get_transport := networking.GetHttpTransport
+	if opts.skip_verify {
+		get_transport = networking.GetNewHttpTransport
+	}
+
+	transport, err := get_transport(self.config_obj.Client, opts.root_ca)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.skip_verify {
+		err = networking.EnableSkipVerify(
+			transport.TLSClientConfig, self.config_obj.Client)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &http.Client{
+		Timeout:   requestTimeout,
+		Transport: transport,
+	}, nil
+}
+
+func (self *WebhookManager) logDelivery(ctx context.Context,
+	webhook *api_proto.Webhook, event, delivery_id string,
+	attempt, status int, duration time.Duration, err error, final bool) {
+
+	row := deliveryRow(webhook, event, delivery_id).
+		Set("Attempt", attempt).
+		Set("Status", status).
+		Set("Duration", duration.Seconds()).
+		Set("Final", final)
+
+	if err != nil {
+		row.Update("Error", err.Error())
+
+		if final {
+			logger := logging.GetLogger(self.config_obj, &logging.FrontendComponent)
+			logger.Error("Webhook %v: delivery failed: %v", webhook.Name, err)
+		}
+	}
+
+	self.pushDeliveryRow(ctx, row)
+}
+
+// Record an event which was never queued for delivery.
+func (self *WebhookManager) logDropped(ctx context.Context,
+	webhook *api_proto.Webhook, event string, err error) {
+
+	logger := logging.GetLogger(self.config_obj, &logging.FrontendComponent)
+	logger.Error("Webhook %v: dropped %v event: %v", webhook.Name, event, err)
+
+	self.pushDeliveryRow(ctx, deliveryRow(webhook, event, utils.NextId()).
+		Set("Error", err.Error()).
+		Set("Final", true).
+		Set("Dropped", true))
+}
+
+func deliveryRow(webhook *api_proto.Webhook,
+	event, delivery_id string) *ordereddict.Dict {
+	return ordereddict.NewDict().
+		Set("DeliveryId", delivery_id).
+		Set("Webhook", webhook.Name).
+		Set("Event", event).
+		Set("Url", webhook.Url).
+		Set("Attempt", 0).
+		Set("Status", 0).
+		Set("Error", "").
+		Set("Duration", 0).
+		Set("Final", false).
+		Set("Dropped", false)
+}
+
+func (self *WebhookManager) pushDeliveryRow(
+	ctx context.Context, row *ordereddict.Dict) {
+	journal, err := services.GetJournal(self.config_obj)
+	if err != nil {
+		return
+	}
+
+	journal.PushRowsToArtifactAsync(ctx, self.config_obj, row,
+		artifacts.WEBHOOK_DELIVERIES)
+}
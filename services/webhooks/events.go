@@ -0,0 +1,240 @@
+package webhooks
+
+import (
+	"context"
+	"regexp"
+	"sync"
+
+	"github.com/Velocidex/ordereddict"
+	api_proto "www.velocidex.com/golang/velociraptor/api/proto"
+	config_proto "www.velocidex.com/golang/velociraptor/config/proto"
+	"www.velocidex.com/golang/velociraptor/paths/artifacts"
+	"www.velocidex.com/golang/velociraptor/services"
+	"www.velocidex.com/golang/velociraptor/services/journal"
+	"www.velocidex.com/golang/velociraptor/utils"
+)
+
+// Convert the journal events we are interested in into webhook
+// events.
+func (self *WebhookManager) watchEvents(
+	ctx context.Context, wg *sync.WaitGroup) error {
+
+	for _, watcher := range []struct {
+		queue     services.JournalOptions
+		processor func(ctx context.Context,
+			config_obj *config_proto.Config,
+			row *ordereddict.Dict) error
+	}{
+		{artifacts.FLOW_COMPLETION, self.processFlowCompletion},
+		{artifacts.HUNT_CREATION, self.processHuntCreation},
+		{artifacts.HUNT_MODIFICATIONS, self.processHuntModification},
+		{artifacts.ALERT_QUEUE, self.processAlert},
+		{artifacts.ENROLLMENT_QUEUE, self.processEnrollment},
+	} {
+		err := journal.WatchQueueWithCB(ctx, self.config_obj, wg,
+			watcher.queue, "WebhookService", watcher.processor)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (self *WebhookManager) processFlowCompletion(
+	ctx context.Context, config_obj *config_proto.Config,
+	row *ordereddict.Dict) error {
+
+	webhooks := self.getWebhooksForEvent(services.WEBHOOK_FLOW_COMPLETED)
+	if len(webhooks) == 0 {
+		return nil
+	}
+
+	flow, err := journal.GetFlowFromQueue(ctx, config_obj, row)
+	if err != nil {
+		return err
+	}
+
+	var artifact_names []string
+	if flow.Request != nil {
+		artifact_names = flow.Request.Artifacts
+	}
+
+	data := ordereddict.NewDict().
+		Set("ClientId", flow.ClientId).
+		Set("Hostname", self.getHostname(ctx, flow.ClientId)).
+		Set("FlowId", flow.SessionId).
+		Set("HuntId", "").
+		Set("Artifacts", artifact_names).
+		Set("ArtifactsWithResults", flow.ArtifactsWithResults).
+		Set("State", flow.State.String()).
+		Set("Status", flow.Status).
+		Set("TotalCollectedRows", flow.TotalCollectedRows).
+		Set("TotalUploadedBytes", flow.TotalUploadedBytes).
+		Set("CreateTime", flow.CreateTime).
+		Set("ActiveTime", flow.ActiveTime)
+
+	if flow.Request != nil {
+		data.Set("Creator", flow.Request.Creator)
+	}
+
+	hunt_id, ok := utils.ExtractHuntId(flow.SessionId)
+	if ok {
+		data.Update("HuntId", hunt_id)
+	}
+
+	for _, webhook := range webhooks {
+		if matchArtifacts(webhook, artifact_names...) {
+			self.Deliver(webhook, services.WEBHOOK_FLOW_COMPLETED, data)
+		}
+	}
+	return nil
+}
+
+func (self *WebhookManager) processHuntCreation(
+	ctx context.Context, config_obj *config_proto.Config,
+	row *ordereddict.Dict) error {
+
+	webhooks := self.getWebhooksForEvent(services.WEBHOOK_HUNT_STATE_CHANGED)
+	if len(webhooks) == 0 {
+		return nil
+	}
+
+	hunt_any, _ := row.Get("Hunt")
+	hunt := &api_proto.Hunt{}
+	err := utils.ParseIntoProtobuf(hunt_any, hunt)
+	if err != nil {
+		return err
+	}
+
+	data := huntData(hunt, hunt.State, hunt.Creator)
+	for _, webhook := range webhooks {
+		self.Deliver(webhook, services.WEBHOOK_HUNT_STATE_CHANGED, data)
+	}
+	return nil
+}
+
+func (self *WebhookManager) processHuntModification(
+	ctx context.Context, config_obj *config_proto.Config,
+	row *ordereddict.Dict) error {
+
+	webhooks := self.getWebhooksForEvent(services.WEBHOOK_HUNT_STATE_CHANGED)
+	if len(webhooks) == 0 {
+		return nil
+	}
+
+	mutation_any, _ := row.Get("mutation")
+	mutation := &api_proto.HuntMutation{}
+	err := utils.ParseIntoProtobuf(mutation_any, mutation)
+	if err != nil {
+		return err
+	}
+
+	// Most mutations just update the hunt stats.
+	if mutation.State == api_proto.Hunt_UNSET {
+		return nil
+	}
+
+	hunt := &api_proto.Hunt{HuntId: mutation.HuntId}
+	hunt_dispatcher, err := services.GetHuntDispatcher(config_obj)
+	if err == nil {
+		existing, pres := hunt_dispatcher.GetHunt(ctx,
+			services.GetHuntOptions{}, mutation.HuntId)
+		if pres {
+			hunt = existing
+		}
+	}
+
+	data := huntData(hunt, mutation.State, mutation.User)
+	for _, webhook := range webhooks {
+		self.Deliver(webhook, services.WEBHOOK_HUNT_STATE_CHANGED, data)
+	}
+	return nil
+}
+
+func huntData(hunt *api_proto.Hunt,
+	state api_proto.Hunt_State, user string) *ordereddict.Dict {
+	var artifact_names []string
+	if hunt.StartRequest != nil {
+		artifact_names = hunt.StartRequest.Artifacts
+	}
+
+	return ordereddict.NewDict().
+		Set("HuntId", hunt.HuntId).
+		Set("Description", hunt.HuntDescription).
+		Set("State", state.String()).
+		Set("User", user).
+		Set("Artifacts", artifact_names)
+}
+
+func (self *WebhookManager) processAlert(
+	ctx context.Context, config_obj *config_proto.Config,
+	row *ordereddict.Dict) error {
+
+	webhooks := self.getWebhooksForEvent(services.WEBHOOK_ALERT)
+	if len(webhooks) == 0 {
+		return nil
+	}
+
+	artifact, _ := row.GetString("artifact")
+	for _, webhook := range webhooks {
+		if matchArtifacts(webhook, artifact) {
+			self.Deliver(webhook, services.WEBHOOK_ALERT, row)
+		}
+	}
+	return nil
+}
+
+func (self *WebhookManager) processEnrollment(
+	ctx context.Context, config_obj *config_proto.Config,
+	row *ordereddict.Dict) error {
+
+	webhooks := self.getWebhooksForEvent(services.WEBHOOK_CLIENT_ENROLLED)
+	if len(webhooks) == 0 {
+		return nil
+	}
+
+	client_id, pres := row.GetString("ClientId")
+	if !pres {
+		return nil
+	}
+
+	data := ordereddict.NewDict().Set("ClientId", client_id)
+	for _, webhook := range webhooks {
+		self.Deliver(webhook, services.WEBHOOK_CLIENT_ENROLLED, data)
+	}
+	return nil
+}
+
+func (self *WebhookManager) getHostname(
+	ctx context.Context, client_id string) string {
+	client_info_manager, err := services.GetClientInfoManager(self.config_obj)
+	if err != nil {
+		return ""
+	}
+
+	info, err := client_info_manager.Get(ctx, client_id)
+	if err != nil {
+		return ""
+	}
+	return info.Hostname
+}
+
+// An empty regex matches everything.
+func matchArtifacts(webhook *api_proto.Webhook, names ...string) bool {
+	if webhook.ArtifactRegex == "" {
+		return true
+	}
+
+	re, err := regexp.Compile(webhook.ArtifactRegex)
+	if err != nil {
+		return false
+	}
+
+	for _, name := range names {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
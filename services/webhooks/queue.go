@@ -0,0 +1,213 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+
+	api_proto "www.velocidex.com/golang/velociraptor/api/proto"
+	"www.velocidex.com/golang/velociraptor/datastore"
+	"www.velocidex.com/golang/velociraptor/paths"
+	"www.velocidex.com/golang/velociraptor/services/journal"
+	utils_tempfile "www.velocidex.com/golang/velociraptor/utils/tempfile"
+)
+
+// Each webhook has its own queue of pending deliveries, drained in
+// order by a single worker. A slow or unreachable endpoint therefore
+// only delays its own events. The queue is kept in a buffer file in
+// the filestore so pending deliveries survive a restart.
+
+var (
+	// Once a webhook has this many bytes of pending deliveries, new
+	// events for it are dropped.
+	maxQueueSize int64 = 10 * 1024 * 1024
+
+	errQueueFull = errors.New("Delivery queue is full")
+)
+
+func SetMaxQueueSizeForTests(size int64) func() {
+	old_size := maxQueueSize
+	maxQueueSize = size
+	return func() {
+		maxQueueSize = old_size
+	}
+}
+
+type deliveryQueue struct {
+	name   string
+	buffer *journal.BufferFile
+
+	// Wakes the worker when new deliveries are queued.
+	notify chan bool
+
+	// Stops the worker.
+	cancel func()
+}
+
+func (self *deliveryQueue) Enqueue(event string, payload []byte) error {
+	header := self.buffer.GetHeader()
+	if header.WritePointer-header.ReadPointer > maxQueueSize {
+		return errQueueFull
+	}
+
+	err := self.buffer.Enqueue(&api_proto.PushEventRequest{
+		Artifact: event,
+		Jsonl:    payload,
+		Rows:     1,
+	})
+	if err != nil {
+		return err
+	}
+
+	select {
+	case self.notify <- true:
+	default:
+	}
+
+	return nil
+}
+
+// Get the queue for the webhook, starting its worker if needed. Must
+// be called under lock.
+func (self *WebhookManager) _getQueue(name string) (*deliveryQueue, error) {
+	queue, pres := self.queues[name]
+	if pres {
+		return queue, nil
+	}
+
+	buffer, err := self.openQueueBuffer(name)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(self.ctx)
+	queue = &deliveryQueue{
+		name:   name,
+		buffer: buffer,
+		notify: make(chan bool, 1),
+		cancel: cancel,
+	}
+	self.queues[name] = queue
+
+	self.wg.Add(1)
+	go func() {
+		defer self.wg.Done()
+		defer buffer.Close()
+
+		self.drainQueue(ctx, queue)
+	}()
+
+	return queue, nil
+}
+
+// Stop the worker and discard any pending deliveries. Must be called
+// under lock.
+func (self *WebhookManager) _removeQueue(name string) {
+	queue, pres := self.queues[name]
+	if pres {
+		queue.cancel()
+		delete(self.queues, name)
+	}
+
+	filename := self.queueFilename(name)
+	if filename != "" {
+		_ = os.Remove(filename)
+	}
+}
+
+// Deliver queued events one at a time until the service exits.
+func (self *WebhookManager) drainQueue(
+	ctx context.Context, queue *deliveryQueue) {
+	for {
+		item, err := queue.buffer.Lease()
+		if err != nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-queue.notify:
+			}
+			continue
+		}
+
+		// The webhook may have been disabled since the event was
+		// queued.
+		webhook := self.getWebhook(queue.name)
+		if webhook == nil || webhook.Disabled {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			_ = queue.buffer.Enqueue(item)
+			return
+		case self.sem <- true:
+		}
+
+		done := self.deliver(ctx, webhook, item.Artifact, item.Jsonl)
+		<-self.sem
+
+		// We are shutting down - put the delivery back so it is
+		// retried after a restart.
+		if !done {
+			_ = queue.buffer.Enqueue(item)
+			return
+		}
+	}
+}
+
+// The queue lives in the filestore. Without a filestore directory
+// (e.g. in tests) it is kept in a temp file and does not survive a
+// restart.
+func (self *WebhookManager) queueFilename(name string) string {
+	if self.config_obj.Datastore == nil ||
+		self.config_obj.Datastore.FilestoreDirectory == "" {
+		return ""
+	}
+
+	db, err := datastore.GetDB(self.config_obj)
+	if err != nil {
+		return ""
+	}
+
+	path_manager := paths.WebhooksPathManager{}
+	return datastore.AsFilestoreFilename(
+		db, self.config_obj, path_manager.Queue(name))
+}
+
+func (self *WebhookManager) openQueueBuffer(
+	name string) (*journal.BufferFile, error) {
+	filename := self.queueFilename(name)
+	if filename == "" {
+		tmpfile, err := utils_tempfile.TempFile("webhook")
+		if err != nil {
+			return nil, err
+		}
+		utils_tempfile.AddTmpFile(tmpfile.Name())
+
+		buffer, err := journal.NewBufferFile(self.config_obj, tmpfile)
+		if err != nil {
+			tmpfile.Close()
+			os.Remove(tmpfile.Name())
+			return nil, err
+		}
+		return buffer, nil
+	}
+
+	err := os.MkdirAll(filepath.Dir(filename), 0700)
+	if err != nil {
+		return nil, err
+	}
+
+	fd, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	buffer, err := journal.NewPersistentBufferFile(self.config_obj, fd)
+	if err != nil {
+		fd.Close()
+		return nil, err
+	}
+	return buffer, nil
+}
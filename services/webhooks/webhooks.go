@@ -0,0 +1,294 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/Velocidex/ordereddict"
+	"google.golang.org/protobuf/proto"
+	api_proto "www.velocidex.com/golang/velociraptor/api/proto"
+	config_proto "www.velocidex.com/golang/velociraptor/config/proto"
+	"www.velocidex.com/golang/velociraptor/constants"
+	"www.velocidex.com/golang/velociraptor/datastore"
+	"www.velocidex.com/golang/velociraptor/logging"
+	"www.velocidex.com/golang/velociraptor/paths"
+	"www.velocidex.com/golang/velociraptor/services"
+	"www.velocidex.com/golang/velociraptor/utils"
+)
+
+const (
+	defaultMaxRetries = 5
+
+	// How many deliveries may be in flight at once.
+	maxConcurrentDeliveries = 10
+)
+
+type WebhookManager struct {
+	mu sync.Mutex
+
+	config_obj *config_proto.Config
+	webhooks   map[string]*api_proto.Webhook
+
+	// Pending deliveries for each webhook.
+	queues map[string]*deliveryQueue
+
+	// Deliveries run in the background for the life of the service.
+	ctx context.Context
+	wg  *sync.WaitGroup
+	sem chan bool
+}
+
+func (self *WebhookManager) SetWebhook(ctx context.Context,
+	principal string, webhook *api_proto.Webhook) error {
+
+	webhook = proto.Clone(webhook).(*api_proto.Webhook)
+	err := self.validateWebhook(ctx, principal, webhook)
+	if err != nil {
+		return err
+	}
+
+	webhook.CreatedBy = principal
+	webhook.Created = uint64(utils.GetTime().Now().Unix())
+
+	db, err := datastore.GetDB(self.config_obj)
+	if err != nil {
+		return err
+	}
+
+	path_manager := paths.WebhooksPathManager{}
+	err = db.SetSubject(self.config_obj,
+		path_manager.Webhook(webhook.Name), webhook)
+	if err != nil {
+		return err
+	}
+
+	self.mu.Lock()
+	self.webhooks[webhook.Name] = webhook
+	self.mu.Unlock()
+
+	self.audit(ctx, principal, "SetWebhook", ordereddict.NewDict().
+		Set("name", webhook.Name).
+		Set("url", webhook.Url).
+		Set("events", webhook.Events))
+
+	return nil
+}
+
+func (self *WebhookManager) validateWebhook(ctx context.Context,
+	principal string, webhook *api_proto.Webhook) error {
+	if webhook.Name == "" {
+		return errors.New("Webhook name must be specified")
+	}
+
+	parsed, err := url.Parse(webhook.Url)
+	if err != nil {
+		return fmt.Errorf("Invalid webhook url: %w", err)
+	}
+
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return fmt.Errorf("Invalid webhook url %v: only http and https are supported",
+			webhook.Url)
+	}
+
+	if len(webhook.Events) == 0 {
+		return errors.New("At least one event must be specified")
+	}
+
+	for idx, event := range webhook.Events {
+		event = strings.ToUpper(event)
+		if !utils.InString(services.WebhookEventTypes, event) {
+			return fmt.Errorf("Unknown webhook event %v: should be one of %v",
+				event, services.WebhookEventTypes)
+		}
+		webhook.Events[idx] = event
+	}
+
+	if webhook.ArtifactRegex != "" {
+		_, err := regexp.Compile(webhook.ArtifactRegex)
+		if err != nil {
+			return fmt.Errorf("Invalid artifact_regex: %w", err)
+		}
+	}
+
+	if webhook.Template != "" {
+		_, err := parseTemplate(webhook.Template)
+		if err != nil {
+			return fmt.Errorf("Invalid webhook template: %w", err)
+		}
+	}
+
+	// Make sure the principal can actually use the secret, otherwise
+	// every delivery will fail.
+	if webhook.Secret != "" {
+		secrets, err := services.GetSecretsService(self.config_obj)
+		if err != nil {
+			return err
+		}
+
+		_, err = secrets.GetSecret(ctx, principal,
+			constants.WEBHOOK_SECRET, webhook.Secret)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (self *WebhookManager) DeleteWebhook(
+	ctx context.Context, principal, name string) error {
+	self.mu.Lock()
+	_, pres := self.webhooks[name]
+	delete(self.webhooks, name)
+	if pres {
+		self._removeQueue(name)
+	}
+	self.mu.Unlock()
+
+	if !pres {
+		return fmt.Errorf("%w: webhook %v", utils.NotFoundError, name)
+	}
+
+	db, err := datastore.GetDB(self.config_obj)
+	if err != nil {
+		return err
+	}
+
+	path_manager := paths.WebhooksPathManager{}
+	err = db.DeleteSubject(self.config_obj, path_manager.Webhook(name))
+	if err != nil {
+		return err
+	}
+
+	self.audit(ctx, principal, "DeleteWebhook",
+		ordereddict.NewDict().Set("name", name))
+
+	return nil
+}
+
+func (self *WebhookManager) ListWebhooks(
+	ctx context.Context) ([]*api_proto.Webhook, error) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	result := make([]*api_proto.Webhook, 0, len(self.webhooks))
+	for _, w := range self.webhooks {
+		result = append(result, proto.Clone(w).(*api_proto.Webhook))
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	return result, nil
+}
+
+func (self *WebhookManager) getWebhook(name string) *api_proto.Webhook {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	return self.webhooks[name]
+}
+
+// Get all the enabled webhooks interested in the event.
+func (self *WebhookManager) getWebhooksForEvent(
+	event string) []*api_proto.Webhook {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	var result []*api_proto.Webhook
+	for _, w := range self.webhooks {
+		if !w.Disabled && utils.InString(w.Events, event) {
+			result = append(result, w)
+		}
+	}
+	return result
+}
+
+func (self *WebhookManager) audit(ctx context.Context,
+	principal, operation string, details *ordereddict.Dict) {
+	err := services.LogAudit(ctx,
+		self.config_obj, principal, operation, details)
+	if err != nil {
+		logger := logging.GetLogger(self.config_obj, &logging.FrontendComponent)
+		logger.Error("<red>%v</> %v %v", operation, principal, details)
+	}
+}
+
+func (self *WebhookManager) loadWebhooks() error {
+	db, err := datastore.GetDB(self.config_obj)
+	if err != nil {
+		return err
+	}
+
+	path_manager := paths.WebhooksPathManager{}
+	children, err := db.ListChildren(
+		self.config_obj, path_manager.WebhooksDir())
+	if err != nil {
+		return err
+	}
+
+	for _, child := range children {
+		if child.IsDir() {
+			continue
+		}
+
+		webhook := &api_proto.Webhook{}
+		err := db.GetSubject(self.config_obj, child, webhook)
+		if err != nil || webhook.Name == "" {
+			continue
+		}
+
+		self.webhooks[webhook.Name] = webhook
+	}
+
+	return nil
+}
+
+func parseTemplate(text string) (*template.Template, error) {
+	return template.New("webhook").Funcs(templateFuncs).Parse(text)
+}
+
+func NewWebhookManager(
+	ctx context.Context,
+	wg *sync.WaitGroup,
+	config_obj *config_proto.Config) (services.WebhookManager, error) {
+
+	result := &WebhookManager{
+		config_obj: config_obj,
+		webhooks:   make(map[string]*api_proto.Webhook),
+		queues:     make(map[string]*deliveryQueue),
+		ctx:        ctx,
+		wg:         wg,
+		sem:        make(chan bool, maxConcurrentDeliveries),
+	}
+
+	err := result.loadWebhooks()
+	if err != nil {
+		return nil, err
+	}
+
+	// Resume any deliveries left over from before a restart.
+	result.mu.Lock()
+	for name := range result.webhooks {
+		_, err := result._getQueue(name)
+		if err != nil {
+			result.mu.Unlock()
+			return nil, err
+		}
+	}
+	result.mu.Unlock()
+
+	logger := logging.GetLogger(config_obj, &logging.FrontendComponent)
+	logger.Info("<green>Starting</> webhook service for %v with %v webhooks.",
+		services.GetOrgName(config_obj), len(result.webhooks))
+
+	return result, result.watchEvents(ctx, wg)
+}
@@ -0,0 +1,511 @@
+package webhooks_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Velocidex/ordereddict"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/protobuf/proto"
+	api_proto "www.velocidex.com/golang/velociraptor/api/proto"
+	config_proto "www.velocidex.com/golang/velociraptor/config/proto"
+	"www.velocidex.com/golang/velociraptor/constants"
+	"www.velocidex.com/golang/velociraptor/file_store/test_utils"
+	"www.velocidex.com/golang/velociraptor/json"
+	"www.velocidex.com/golang/velociraptor/paths/artifacts"
+	"www.velocidex.com/golang/velociraptor/services"
+	"www.velocidex.com/golang/velociraptor/services/journal"
+	"www.velocidex.com/golang/velociraptor/services/webhooks"
+	vql_subsystem "www.velocidex.com/golang/velociraptor/vql"
+	"www.velocidex.com/golang/velociraptor/vtesting"
+	"www.velocidex.com/golang/velociraptor/vtesting/assert"
+)
+
+var webhookDefinitions = []string{`
+name: Server.Internal.Alerts
+type: SERVER_EVENT
+`, `
+name: Server.Internal.WebhookDeliveries
+type: SERVER_EVENT
+`}
+
+type request struct {
+	headers http.Header
+	body    []byte
+}
+
+type WebhooksTestSuite struct {
+	test_utils.TestSuite
+
+	mu       sync.Mutex
+	requests []request
+
+	// The status codes to return for successive requests. Once
+	// exhausted we return 200.
+	responses []int
+
+	// If set, requests hang until this is closed.
+	block chan bool
+
+	server *httptest.Server
+	closer func()
+}
+
+func (self *WebhooksTestSuite) SetupTest() {
+	self.ConfigObj = self.TestSuite.LoadConfig()
+	self.ConfigObj.Services.WebhookService = true
+	self.LoadArtifactsIntoConfig(webhookDefinitions)
+
+	self.requests = nil
+	self.responses = nil
+	self.block = nil
+	self.server = httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+
+			self.mu.Lock()
+			self.requests = append(self.requests, request{
+				headers: r.Header.Clone(),
+				body:    body,
+			})
+
+			status := http.StatusOK
+			if len(self.responses) > 0 {
+				status = self.responses[0]
+				self.responses = self.responses[1:]
+			}
+			block := self.block
+			self.mu.Unlock()
+
+			if block != nil {
+				select {
+				case <-block:
+				case <-r.Context().Done():
+				}
+			}
+			w.WriteHeader(status)
+		}))
+
+	self.closer = webhooks.SetRetryDelayForTests(10 * time.Millisecond)
+
+	self.TestSuite.SetupTest()
+}
+
+func (self *WebhooksTestSuite) TearDownTest() {
+	self.server.Close()
+	self.closer()
+	self.TestSuite.TearDownTest()
+}
+
+func (self *WebhooksTestSuite) getRequests() []request {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	return append([]request{}, self.requests...)
+}
+
+func (self *WebhooksTestSuite) addSecret(name, hmac_key string) {
+	secrets, err := services.GetSecretsService(self.ConfigObj)
+	assert.NoError(self.T(), err)
+
+	err = secrets.AddSecret(self.Ctx, vql_subsystem.MakeScope(),
+		constants.WEBHOOK_SECRET, name, ordereddict.NewDict().
+			Set("hmac_key", hmac_key))
+	assert.NoError(self.T(), err)
+
+	err = secrets.ModifySecret(self.Ctx, &api_proto.ModifySecretRequest{
+		TypeName: constants.WEBHOOK_SECRET,
+		Name:     name,
+		AddUsers: []string{"admin"},
+	})
+	assert.NoError(self.T(), err)
+}
+
+// Collect the delivery log rows.
+func (self *WebhooksTestSuite) watchDeliveries() func() []*ordereddict.Dict {
+	var mu sync.Mutex
+	var rows []*ordereddict.Dict
+
+	err := journal.WatchQueueWithCB(self.Ctx, self.ConfigObj, self.Wg,
+		artifacts.WEBHOOK_DELIVERIES, "WebhooksTestSuite", func(
+			ctx context.Context, config_obj *config_proto.Config,
+			row *ordereddict.Dict) error {
+			mu.Lock()
+			defer mu.Unlock()
+			rows = append(rows, row)
+			return nil
+		})
+	assert.NoError(self.T(), err)
+
+	return func() []*ordereddict.Dict {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]*ordereddict.Dict{}, rows...)
+	}
+}
+
+func (self *WebhooksTestSuite) pushAlert(artifact string) {
+	journal, err := services.GetJournal(self.ConfigObj)
+	assert.NoError(self.T(), err)
+
+	err = journal.PushRowsToArtifact(self.Ctx, self.ConfigObj,
+		[]*ordereddict.Dict{ordereddict.NewDict().
+			Set("name", "TestAlert").
+			Set("artifact", artifact).
+			Set("client_id", "C.1234")},
+		artifacts.ALERT_QUEUE)
+	assert.NoError(self.T(), err)
+}
+
+func (self *WebhooksTestSuite) TestValidation() {
+	webhook_manager, err := services.GetWebhookManager(self.ConfigObj)
+	assert.NoError(self.T(), err)
+
+	for _, webhook := range []*api_proto.Webhook{
+		{Url: self.server.URL, Events: []string{"ALERT"}},
+		{Name: "Test", Url: "file:///etc/passwd", Events: []string{"ALERT"}},
+		{Name: "Test", Url: self.server.URL},
+		{Name: "Test", Url: self.server.URL, Events: []string{"FOO"}},
+		{Name: "Test", Url: self.server.URL, Events: []string{"ALERT"},
+			ArtifactRegex: "("},
+		{Name: "Test", Url: self.server.URL, Events: []string{"ALERT"},
+			Template: "{{ .Data "},
+
+		// The secret does not exist.
+		{Name: "Test", Url: self.server.URL, Events: []string{"ALERT"},
+			Secret: "NoSuchSecret"},
+	} {
+		err = webhook_manager.SetWebhook(self.Ctx, "admin", webhook)
+		assert.Error(self.T(), err)
+	}
+
+	// The secret exists but is not shared with this user.
+	self.addSecret("MySecret", "hunter2")
+	err = webhook_manager.SetWebhook(self.Ctx, "bob", &api_proto.Webhook{
+		Name: "Test", Url: self.server.URL, Events: []string{"ALERT"},
+		Secret: "MySecret"})
+	assert.Error(self.T(), err)
+	assert.Contains(self.T(), err.Error(), "Permission Denied")
+
+	items, err := webhook_manager.ListWebhooks(self.Ctx)
+	assert.NoError(self.T(), err)
+	assert.Equal(self.T(), 0, len(items))
+
+	err = webhook_manager.DeleteWebhook(self.Ctx, "admin", "Test")
+	assert.Error(self.T(), err)
+}
+
+func (self *WebhooksTestSuite) TestPersistence() {
+	webhook_manager, err := services.GetWebhookManager(self.ConfigObj)
+	assert.NoError(self.T(), err)
+
+	for _, name := range []string{"Second", "First"} {
+		err = webhook_manager.SetWebhook(self.Ctx, "admin", &api_proto.Webhook{
+			Name:   name,
+			Url:    self.server.URL,
+			Events: []string{"flow_completed", "ALERT"},
+		})
+		assert.NoError(self.T(), err)
+	}
+
+	// A new service loads the webhooks from the datastore.
+	new_manager, err := webhooks.NewWebhookManager(self.Ctx, self.Wg, self.ConfigObj)
+	assert.NoError(self.T(), err)
+
+	items, err := new_manager.ListWebhooks(self.Ctx)
+	assert.NoError(self.T(), err)
+	assert.Equal(self.T(), 2, len(items))
+	assert.Equal(self.T(), "First", items[0].Name)
+	assert.Equal(self.T(), "admin", items[0].CreatedBy)
+
+	// Events are normalized to upper case.
+	assert.Equal(self.T(), []string{"FLOW_COMPLETED", "ALERT"},
+		items[0].Events)
+
+	err = webhook_manager.DeleteWebhook(self.Ctx, "admin", "First")
+	assert.NoError(self.T(), err)
+
+	new_manager, err = webhooks.NewWebhookManager(self.Ctx, self.Wg, self.ConfigObj)
+	assert.NoError(self.T(), err)
+
+	items, err = new_manager.ListWebhooks(self.Ctx)
+	assert.NoError(self.T(), err)
+	assert.Equal(self.T(), 1, len(items))
+	assert.Equal(self.T(), "Second", items[0].Name)
+}
+
+func (self *WebhooksTestSuite) TestSignedDelivery() {
+	self.addSecret("MySecret", "hunter2")
+
+	webhook_manager, err := services.GetWebhookManager(self.ConfigObj)
+	assert.NoError(self.T(), err)
+
+	err = webhook_manager.SetWebhook(self.Ctx, "admin", &api_proto.Webhook{
+		Name:          "Test",
+		Url:           self.server.URL,
+		Events:        []string{"ALERT"},
+		ArtifactRegex: "^Windows",
+		Secret:        "MySecret",
+	})
+	assert.NoError(self.T(), err)
+
+	// This alert does not match the artifact regex.
+	self.pushAlert("Linux.Detection")
+	self.pushAlert("Windows.Detection")
+
+	vtesting.WaitUntil(5*time.Second, self.T(), func() bool {
+		return len(self.getRequests()) > 0
+	})
+
+	// Give the ignored alert a chance to be delivered.
+	time.Sleep(100 * time.Millisecond)
+	requests := self.getRequests()
+	assert.Equal(self.T(), 1, len(requests))
+
+	req := requests[0]
+	assert.Equal(self.T(), "ALERT", req.headers.Get("X-Velociraptor-Event"))
+	assert.Equal(self.T(), "application/json", req.headers.Get("Content-Type"))
+
+	timestamp := req.headers.Get("X-Velociraptor-Timestamp")
+	assert.Equal(self.T(),
+		"sha256="+webhooks.Sign([]byte("hunter2"), timestamp, req.body),
+		req.headers.Get("X-Velociraptor-Signature"))
+
+	payload := ordereddict.NewDict()
+	err = json.Unmarshal(req.body, payload)
+	assert.NoError(self.T(), err)
+
+	event, _ := payload.GetString("Event")
+	assert.Equal(self.T(), "ALERT", event)
+
+	artifact, _ := ordereddict.GetString(payload, "Data.artifact")
+	assert.Equal(self.T(), "Windows.Detection", artifact)
+}
+
+func (self *WebhooksTestSuite) TestTemplateAndRetries() {
+	get_deliveries := self.watchDeliveries()
+
+	// Fail twice then succeed.
+	self.mu.Lock()
+	self.responses = []int{500, 429}
+	self.mu.Unlock()
+
+	webhook_manager, err := services.GetWebhookManager(self.ConfigObj)
+	assert.NoError(self.T(), err)
+
+	err = webhook_manager.SetWebhook(self.Ctx, "admin", &api_proto.Webhook{
+		Name:        "Test",
+		Url:         self.server.URL,
+		Events:      []string{"ALERT"},
+		Template:    `{"text": "Alert from {{ .Data.artifact }}"}`,
+		ContentType: "application/x-slack",
+	})
+	assert.NoError(self.T(), err)
+
+	self.pushAlert("Windows.Detection")
+
+	vtesting.WaitUntil(5*time.Second, self.T(), func() bool {
+		return len(get_deliveries()) == 3
+	})
+
+	requests := self.getRequests()
+	assert.Equal(self.T(), 3, len(requests))
+	for _, req := range requests {
+		assert.Equal(self.T(), `{"text": "Alert from Windows.Detection"}`,
+			string(req.body))
+		assert.Equal(self.T(), "application/x-slack",
+			req.headers.Get("Content-Type"))
+
+		// No secret means no signature
+		assert.Equal(self.T(), "", req.headers.Get("X-Velociraptor-Signature"))
+	}
+
+	// All attempts share the same delivery id.
+	assert.Equal(self.T(), requests[0].headers.Get("X-Velociraptor-Delivery"),
+		requests[2].headers.Get("X-Velociraptor-Delivery"))
+
+	var statuses []int64
+	var finals []bool
+	for _, row := range get_deliveries() {
+		status, _ := row.GetInt64("Status")
+		statuses = append(statuses, status)
+
+		final, _ := row.GetBool("Final")
+		finals = append(finals, final)
+	}
+	assert.Equal(self.T(), []int64{500, 429, 200}, statuses)
+	assert.Equal(self.T(), []bool{false, false, true}, finals)
+}
+
+func (self *WebhooksTestSuite) TestClientErrorsAreNotRetried() {
+	get_deliveries := self.watchDeliveries()
+
+	// A client error will not go away by retrying.
+	self.mu.Lock()
+	self.responses = []int{404, 200}
+	self.mu.Unlock()
+
+	webhook_manager, err := services.GetWebhookManager(self.ConfigObj)
+	assert.NoError(self.T(), err)
+
+	err = webhook_manager.SetWebhook(self.Ctx, "admin", &api_proto.Webhook{
+		Name:   "Test",
+		Url:    self.server.URL,
+		Events: []string{"ALERT"},
+	})
+	assert.NoError(self.T(), err)
+
+	self.pushAlert("Windows.Detection")
+
+	vtesting.WaitUntil(5*time.Second, self.T(), func() bool {
+		return len(get_deliveries()) == 1
+	})
+
+	// Give a retry a chance to happen.
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(self.T(), 1, len(self.getRequests()))
+
+	deliveries := get_deliveries()
+	assert.Equal(self.T(), 1, len(deliveries))
+
+	status, _ := deliveries[0].GetInt64("Status")
+	assert.Equal(self.T(), int64(404), status)
+
+	final, _ := deliveries[0].GetBool("Final")
+	assert.True(self.T(), final)
+}
+
+func (self *WebhooksTestSuite) setBlock(block chan bool) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	self.block = block
+}
+
+// The artifact names of the alerts delivered so far.
+func (self *WebhooksTestSuite) getDeliveredArtifacts() []string {
+	var result []string
+	for _, req := range self.getRequests() {
+		payload := ordereddict.NewDict()
+		err := json.Unmarshal(req.body, payload)
+		assert.NoError(self.T(), err)
+
+		artifact, _ := ordereddict.GetString(payload, "Data.artifact")
+		result = append(result, artifact)
+	}
+	return result
+}
+
+func (self *WebhooksTestSuite) TestFullQueueDropsEvents() {
+	defer webhooks.SetMaxQueueSizeForTests(1)()
+
+	get_deliveries := self.watchDeliveries()
+
+	// Hold up the first delivery so the rest queue behind it.
+	block := make(chan bool)
+	self.setBlock(block)
+
+	webhook_manager, err := services.GetWebhookManager(self.ConfigObj)
+	assert.NoError(self.T(), err)
+
+	err = webhook_manager.SetWebhook(self.Ctx, "admin", &api_proto.Webhook{
+		Name:   "Test",
+		Url:    self.server.URL,
+		Events: []string{"ALERT"},
+	})
+	assert.NoError(self.T(), err)
+
+	self.pushAlert("A")
+	vtesting.WaitUntil(5*time.Second, self.T(), func() bool {
+		return len(self.getRequests()) == 1
+	})
+
+	// B fills the queue so C is dropped.
+	self.pushAlert("B")
+	self.pushAlert("C")
+
+	vtesting.WaitUntil(5*time.Second, self.T(), func() bool {
+		return len(get_deliveries()) == 1
+	})
+
+	dropped := get_deliveries()[0]
+	event, _ := dropped.GetString("Event")
+	assert.Equal(self.T(), "ALERT", event)
+
+	is_dropped, _ := dropped.GetBool("Dropped")
+	assert.True(self.T(), is_dropped)
+
+	error_message, _ := dropped.GetString("Error")
+	assert.Equal(self.T(), "Delivery queue is full", error_message)
+
+	self.setBlock(nil)
+	close(block)
+
+	vtesting.WaitUntil(5*time.Second, self.T(), func() bool {
+		return len(get_deliveries()) == 3
+	})
+
+	// Give C a chance to be delivered.
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(self.T(), []string{"A", "B"}, self.getDeliveredArtifacts())
+}
+
+func (self *WebhooksTestSuite) TestQueueSurvivesRestart() {
+	// Keep the queue on disk.
+	config_obj := proto.Clone(self.ConfigObj).(*config_proto.Config)
+	config_obj.Datastore.FilestoreDirectory = self.T().TempDir()
+
+	block := make(chan bool)
+	defer close(block)
+	self.setBlock(block)
+
+	ctx, cancel := context.WithCancel(self.Ctx)
+	wg := &sync.WaitGroup{}
+
+	webhook_manager, err := webhooks.NewWebhookManager(ctx, wg, config_obj)
+	assert.NoError(self.T(), err)
+
+	err = webhook_manager.SetWebhook(self.Ctx, "admin", &api_proto.Webhook{
+		Name:   "Test",
+		Url:    self.server.URL,
+		Events: []string{"ALERT"},
+	})
+	assert.NoError(self.T(), err)
+
+	self.pushAlert("A")
+	vtesting.WaitUntil(5*time.Second, self.T(), func() bool {
+		return len(self.getRequests()) == 1
+	})
+
+	self.pushAlert("B")
+	self.pushAlert("C")
+
+	// Give B and C a chance to be queued.
+	time.Sleep(200 * time.Millisecond)
+
+	// Shut the service down while A is still in flight.
+	cancel()
+	wg.Wait()
+
+	self.setBlock(nil)
+
+	// The new service delivers everything left in the queue,
+	// including the interrupted delivery.
+	_, err = webhooks.NewWebhookManager(self.Ctx, self.Wg, config_obj)
+	assert.NoError(self.T(), err)
+
+	vtesting.WaitUntil(5*time.Second, self.T(), func() bool {
+		return len(self.getRequests()) == 4
+	})
+
+	assert.Equal(self.T(), []string{"A", "B", "C", "A"},
+		self.getDeliveredArtifacts())
+}
+
+func TestWebhooks(t *testing.T) {
+	suite.Run(t, &WebhooksTestSuite{})
+}
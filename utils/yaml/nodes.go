@@ -9,7 +9,10 @@ import (
 type Node = yaml.Node
 
 var (
-	LiteralStyle = yaml.LiteralStyle
+	LiteralStyle      = yaml.LiteralStyle
+	FoldedStyle       = yaml.FoldedStyle
+	DoubleQuotedStyle = yaml.DoubleQuotedStyle
+	SingleQuotedStyle = yaml.SingleQuotedStyle
 )
 
 func Unmarshal(in []byte, item interface{}) error {
@@ -0,0 +1,149 @@
+package debugger
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const consoleHelp = `Commands:
+  c, continue          Run until the next breakpoint
+  n, next              Stop at the next row or statement
+  s, step              Stop at the next statement
+  b, break <spec>      Add a breakpoint: a line number, plugin or LET name,
+                       optionally followed by "if <condition>"
+  d, delete <id>       Delete a breakpoint
+  l, list              List breakpoints
+  v, vars              Show the current row and LET variables
+  p, print <expr>      Evaluate a VQL expression
+  stats                Show row counts for each query
+  q, quit              Cancel the query
+`
+
+// An interactive frontend which reads commands from a terminal.
+type ConsoleFrontend struct {
+	in  io.Reader
+	out io.Writer
+
+	once  sync.Once
+	lines chan string
+}
+
+func NewConsoleFrontend(in io.Reader, out io.Writer) *ConsoleFrontend {
+	return &ConsoleFrontend{in: in, out: out}
+}
+
+// Read lines in the background so we can give up when the context
+// is done.
+func (self *ConsoleFrontend) readLines() {
+	self.lines = make(chan string)
+	go func() {
+		defer close(self.lines)
+
+		scanner := bufio.NewScanner(self.in)
+		for scanner.Scan() {
+			self.lines <- scanner.Text()
+		}
+	}()
+}
+
+func (self *ConsoleFrontend) Stopped(
+	ctx context.Context, event *StopEvent) Action {
+	self.once.Do(self.readLines)
+
+	fmt.Fprintln(self.out, DescribeStop(event))
+
+	for {
+		fmt.Fprint(self.out, "(vql) ")
+
+		var line string
+		var ok bool
+		select {
+		case <-ctx.Done():
+			return ActionQuit
+		case line, ok = <-self.lines:
+			if !ok {
+				return ActionQuit
+			}
+		}
+
+		command, arg, _ := strings.Cut(strings.TrimSpace(line), " ")
+		arg = strings.TrimSpace(arg)
+
+		switch command {
+		case "":
+			continue
+
+		case "c", "continue":
+			return ActionContinue
+
+		case "n", "next":
+			return ActionNext
+
+		case "s", "step":
+			return ActionNextStatement
+
+		case "q", "quit":
+			return ActionQuit
+
+		case "b", "break":
+			bp, err := ParseBreakpoint(arg)
+			if err == nil {
+				err = event.Debugger.AddBreakpoint(bp)
+			}
+			if err != nil {
+				fmt.Fprintf(self.out, "Error: %v\n", err)
+				continue
+			}
+			fmt.Fprintf(self.out, "Breakpoint %v: %v\n", bp.Id, bp)
+
+		case "d", "delete":
+			id, err := strconv.Atoi(arg)
+			if err != nil || !event.Debugger.RemoveBreakpoint(id) {
+				fmt.Fprintf(self.out, "No breakpoint %v\n", arg)
+			}
+
+		case "l", "list":
+			for _, bp := range event.Debugger.Breakpoints() {
+				fmt.Fprintf(self.out, "%v: %v\n", bp.Id, bp)
+			}
+
+		case "v", "vars":
+			self.printVars(event)
+
+		case "p", "print":
+			value, err := event.Debugger.Evaluate(event, arg)
+			if err != nil {
+				fmt.Fprintf(self.out, "Error: %v\n", err)
+				continue
+			}
+			fmt.Fprintln(self.out, FormatValue(value))
+
+		case "stats":
+			for _, item := range event.Debugger.Stats().Get() {
+				fmt.Fprintln(self.out, item.String())
+			}
+
+		default:
+			fmt.Fprint(self.out, consoleHelp)
+		}
+	}
+}
+
+func (self *ConsoleFrontend) printVars(event *StopEvent) {
+	if event.Row != nil {
+		fmt.Fprintf(self.out, "Row %v from %v:\n", event.RowIndex, event.Location)
+		for _, i := range RowToDict(event).Items() {
+			fmt.Fprintf(self.out, "  %v = %v\n", i.Key, FormatValue(i.Value))
+		}
+	}
+
+	fmt.Fprintln(self.out, "Scope:")
+	for _, i := range event.Debugger.ScopeVariables(event).Items() {
+		fmt.Fprintf(self.out, "  %v = %v\n", i.Key, FormatValue(i.Value))
+	}
+}
@@ -0,0 +1,708 @@
+package debugger
+
+/*
+  A minimal Debug Adapter Protocol server.
+
+  https://microsoft.github.io/debug-adapter-protocol/specification
+
+  The server runs a single VQL file (the "program" argument of the
+  launch request) under the debugger. Rows are sent to the IDE as
+  output events, and the query is presented as a single thread with
+  a single stack frame for the current stop location.
+*/
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"www.velocidex.com/golang/velociraptor/constants"
+	"www.velocidex.com/golang/velociraptor/json"
+	"www.velocidex.com/golang/velociraptor/utils"
+	vql_subsystem "www.velocidex.com/golang/velociraptor/vql"
+	"www.velocidex.com/golang/vfilter"
+)
+
+const (
+	dapThreadId = 1
+
+	// Variable references for the scopes request.
+	dapRowReference   = 1
+	dapScopeReference = 2
+	dapStatsReference = 3
+)
+
+// Builds the scope the program runs in. Log messages should be sent
+// to the logger.
+type ScopeFactory func(ctx context.Context, logger *log.Logger) (
+	vfilter.Scope, error)
+
+type dapMessage struct {
+	Seq  int    `json:"seq"`
+	Type string `json:"type"`
+
+	Command   string          `json:"command,omitempty"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+}
+
+type dapResponse struct {
+	Seq        int         `json:"seq"`
+	Type       string      `json:"type"`
+	RequestSeq int         `json:"request_seq"`
+	Success    bool        `json:"success"`
+	Command    string      `json:"command"`
+	Message    string      `json:"message,omitempty"`
+	Body       interface{} `json:"body,omitempty"`
+}
+
+type dapEvent struct {
+	Seq   int         `json:"seq"`
+	Type  string      `json:"type"`
+	Event string      `json:"event"`
+	Body  interface{} `json:"body,omitempty"`
+}
+
+type dapSource struct {
+	Name string `json:"name,omitempty"`
+	Path string `json:"path,omitempty"`
+}
+
+type dapSourceBreakpoint struct {
+	Line      int    `json:"line"`
+	Condition string `json:"condition,omitempty"`
+}
+
+type dapFunctionBreakpoint struct {
+	Name      string `json:"name"`
+	Condition string `json:"condition,omitempty"`
+}
+
+type dapBreakpoint struct {
+	Id       int    `json:"id,omitempty"`
+	Verified bool   `json:"verified"`
+	Line     int    `json:"line,omitempty"`
+	Message  string `json:"message,omitempty"`
+}
+
+type dapVariable struct {
+	Name               string `json:"name"`
+	Value              string `json:"value"`
+	VariablesReference int    `json:"variablesReference"`
+}
+
+type DAPServer struct {
+	mu sync.Mutex
+
+	ctx        context.Context
+	newScope   ScopeFactory
+	debugger   *Debugger
+	writer     io.Writer
+	write_mu   sync.Mutex
+	seq        int
+	program    string
+	statements []*vfilter.VQL
+
+	stop_on_entry bool
+
+	// Breakpoint ids set by setBreakpoints and
+	// setFunctionBreakpoints. Each request replaces the previous set.
+	line_breakpoints     []int
+	function_breakpoints []int
+
+	// The current stop and a channel to resume it.
+	current *StopEvent
+	resume  chan Action
+
+	wg sync.WaitGroup
+}
+
+func NewDAPServer(ctx context.Context, factory ScopeFactory) *DAPServer {
+	self := &DAPServer{
+		newScope: factory,
+		resume:   make(chan Action, 1),
+	}
+	self.debugger = NewDebugger(ctx, self)
+	self.ctx = self.debugger.Context()
+	return self
+}
+
+// Serve a single debugging session on the connection. Returns when
+// the client disconnects.
+func (self *DAPServer) Serve(conn io.ReadWriter) error {
+	self.writer = conn
+	reader := bufio.NewReader(conn)
+
+	defer self.wg.Wait()
+	defer self.debugger.Cancel()
+
+	for {
+		message, err := readDAPMessage(reader)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		if message.Type != "request" {
+			continue
+		}
+
+		body, err := self.handleRequest(message)
+		self.sendResponse(message, body, err)
+
+		switch message.Command {
+		case "initialize":
+			self.sendEvent("initialized", nil)
+
+		case "disconnect":
+			return nil
+		}
+	}
+}
+
+func (self *DAPServer) handleRequest(message *dapMessage) (interface{}, error) {
+	switch message.Command {
+	case "initialize":
+		return map[string]interface{}{
+			"supportsConfigurationDoneRequest": true,
+			"supportsConditionalBreakpoints":   true,
+			"supportsFunctionBreakpoints":      true,
+			"supportsEvaluateForHovers":        true,
+			"supportsTerminateRequest":         true,
+			"supportTerminateDebuggee":         true,
+		}, nil
+
+	case "launch":
+		return nil, self.launch(message.Arguments)
+
+	case "setBreakpoints":
+		return self.setBreakpoints(message.Arguments)
+
+	case "setFunctionBreakpoints":
+		return self.setFunctionBreakpoints(message.Arguments)
+
+	case "setExceptionBreakpoints":
+		return map[string]interface{}{
+			"breakpoints": []dapBreakpoint{},
+		}, nil
+
+	case "configurationDone":
+		self.start()
+		return nil, nil
+
+	case "threads":
+		return map[string]interface{}{
+			"threads": []map[string]interface{}{{
+				"id": dapThreadId, "name": "VQL",
+			}},
+		}, nil
+
+	case "stackTrace":
+		return self.stackTrace(), nil
+
+	case "scopes":
+		return map[string]interface{}{
+			"scopes": []map[string]interface{}{
+				{"name": "Row", "variablesReference": dapRowReference},
+				{"name": "Scope", "variablesReference": dapScopeReference},
+				{"name": "Row Counts", "variablesReference": dapStatsReference},
+			},
+		}, nil
+
+	case "variables":
+		return self.variables(message.Arguments)
+
+	case "evaluate":
+		return self.evaluate(message.Arguments)
+
+	case "continue":
+		self.resumeWith(ActionContinue)
+		return map[string]interface{}{"allThreadsContinued": true}, nil
+
+	case "next", "stepIn":
+		self.resumeWith(ActionNext)
+		return nil, nil
+
+	case "stepOut":
+		self.resumeWith(ActionNextStatement)
+		return nil, nil
+
+	case "pause":
+		self.debugger.Pause()
+		return nil, nil
+
+	case "disconnect", "terminate":
+		self.debugger.Cancel()
+		return nil, nil
+	}
+
+	return nil, fmt.Errorf("Unsupported command %v", message.Command)
+}
+
+func (self *DAPServer) launch(arguments json.RawMessage) error {
+	args := &struct {
+		Program     string `json:"program"`
+		StopOnEntry bool   `json:"stopOnEntry"`
+	}{}
+	err := json.Unmarshal(arguments, args)
+	if err != nil {
+		return err
+	}
+
+	fd, err := os.Open(args.Program)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+
+	data, err := utils.ReadAllWithLimit(fd, constants.MAX_MEMORY)
+	if err != nil {
+		return err
+	}
+
+	statements, err := vfilter.MultiParse(string(data))
+	if err != nil {
+		return err
+	}
+
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	self.program = args.Program
+	self.statements = statements
+	self.stop_on_entry = args.StopOnEntry
+	return nil
+}
+
+func (self *DAPServer) setBreakpoints(arguments json.RawMessage) (
+	interface{}, error) {
+	args := &struct {
+		Breakpoints []dapSourceBreakpoint `json:"breakpoints"`
+	}{}
+	err := json.Unmarshal(arguments, args)
+	if err != nil {
+		return nil, err
+	}
+
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	for _, id := range self.line_breakpoints {
+		self.debugger.RemoveBreakpoint(id)
+	}
+	self.line_breakpoints = nil
+
+	valid_lines := breakableLines(self.statements)
+
+	result := []dapBreakpoint{}
+	for _, item := range args.Breakpoints {
+		bp := &Breakpoint{Line: item.Line, Condition: item.Condition}
+		err := self.debugger.AddBreakpoint(bp)
+		if err != nil {
+			result = append(result, dapBreakpoint{
+				Line: item.Line, Message: err.Error()})
+			continue
+		}
+		self.line_breakpoints = append(self.line_breakpoints, bp.Id)
+
+		// We can only check the lines once the program is launched.
+		result_bp := dapBreakpoint{
+			Id: bp.Id, Line: item.Line,
+			Verified: self.statements == nil || valid_lines[item.Line]}
+		if !result_bp.Verified {
+			result_bp.Message = "No statement or plugin call starts on this line"
+		}
+		result = append(result, result_bp)
+	}
+
+	return map[string]interface{}{"breakpoints": result}, nil
+}
+
+func (self *DAPServer) setFunctionBreakpoints(arguments json.RawMessage) (
+	interface{}, error) {
+	args := &struct {
+		Breakpoints []dapFunctionBreakpoint `json:"breakpoints"`
+	}{}
+	err := json.Unmarshal(arguments, args)
+	if err != nil {
+		return nil, err
+	}
+
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	for _, id := range self.function_breakpoints {
+		self.debugger.RemoveBreakpoint(id)
+	}
+	self.function_breakpoints = nil
+
+	result := []dapBreakpoint{}
+	for _, item := range args.Breakpoints {
+		bp := &Breakpoint{Name: item.Name, Condition: item.Condition}
+		err := self.debugger.AddBreakpoint(bp)
+		if err != nil {
+			result = append(result, dapBreakpoint{Message: err.Error()})
+			continue
+		}
+		self.function_breakpoints = append(self.function_breakpoints, bp.Id)
+		result = append(result, dapBreakpoint{Id: bp.Id, Verified: true})
+	}
+
+	return map[string]interface{}{"breakpoints": result}, nil
+}
+
+func (self *DAPServer) stackTrace() interface{} {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	frames := []map[string]interface{}{}
+	if self.current != nil {
+		frames = append(frames, map[string]interface{}{
+			"id":     1,
+			"name":   self.current.Location,
+			"line":   self.current.Line,
+			"column": self.current.Column,
+			"source": dapSource{
+				Name: filepath.Base(self.program),
+				Path: self.program,
+			},
+		})
+	}
+
+	return map[string]interface{}{
+		"stackFrames": frames,
+		"totalFrames": len(frames),
+	}
+}
+
+func (self *DAPServer) variables(arguments json.RawMessage) (
+	interface{}, error) {
+	args := &struct {
+		VariablesReference int `json:"variablesReference"`
+	}{}
+	err := json.Unmarshal(arguments, args)
+	if err != nil {
+		return nil, err
+	}
+
+	self.mu.Lock()
+	current := self.current
+	self.mu.Unlock()
+
+	result := []dapVariable{}
+	switch args.VariablesReference {
+	case dapRowReference:
+		if current != nil {
+			for _, i := range RowToDict(current).Items() {
+				result = append(result, dapVariable{
+					Name: i.Key, Value: FormatValue(i.Value)})
+			}
+		}
+
+	case dapScopeReference:
+		if current != nil {
+			for _, i := range self.debugger.ScopeVariables(current).Items() {
+				result = append(result, dapVariable{
+					Name: i.Key, Value: FormatValue(i.Value)})
+			}
+		}
+
+	case dapStatsReference:
+		for _, item := range self.debugger.Stats().Get() {
+			result = append(result, dapVariable{
+				Name: fmt.Sprintf("line %v: %v", item.Line, item.Plugin),
+				Value: strings.TrimPrefix(item.String(), fmt.Sprintf(
+					"line %v: %v: ", item.Line, item.Plugin)),
+			})
+		}
+	}
+
+	return map[string]interface{}{"variables": result}, nil
+}
+
+func (self *DAPServer) evaluate(arguments json.RawMessage) (
+	interface{}, error) {
+	args := &struct {
+		Expression string `json:"expression"`
+	}{}
+	err := json.Unmarshal(arguments, args)
+	if err != nil {
+		return nil, err
+	}
+
+	self.mu.Lock()
+	current := self.current
+	self.mu.Unlock()
+
+	if current == nil {
+		return nil, errors.New("The query is not stopped")
+	}
+
+	value, err := self.debugger.Evaluate(current, args.Expression)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"result":             FormatValue(value),
+		"variablesReference": 0,
+	}, nil
+}
+
+func (self *DAPServer) resumeWith(action Action) {
+	self.mu.Lock()
+	stopped := self.current != nil
+	self.mu.Unlock()
+
+	if !stopped {
+		return
+	}
+
+	select {
+	case self.resume <- action:
+	default:
+	}
+}
+
+// The debugger Frontend interface: forward the stop to the IDE and
+// wait for it to resume.
+func (self *DAPServer) Stopped(ctx context.Context, event *StopEvent) Action {
+	// Drop any stale resume requests.
+	select {
+	case <-self.resume:
+	default:
+	}
+
+	self.mu.Lock()
+	self.current = event
+	self.mu.Unlock()
+
+	defer func() {
+		self.mu.Lock()
+		self.current = nil
+		self.mu.Unlock()
+	}()
+
+	self.sendEvent("stopped", map[string]interface{}{
+		"reason":            event.Reason,
+		"description":       DescribeStop(event),
+		"threadId":          dapThreadId,
+		"allThreadsStopped": true,
+	})
+
+	select {
+	case <-ctx.Done():
+		return ActionQuit
+	case action := <-self.resume:
+		return action
+	}
+}
+
+// Run the program in the background.
+func (self *DAPServer) start() {
+	self.mu.Lock()
+	statements := self.statements
+	stop_on_entry := self.stop_on_entry
+	self.mu.Unlock()
+
+	if stop_on_entry {
+		self.debugger.StopOnEntry()
+	}
+
+	self.wg.Add(1)
+	go func() {
+		defer self.wg.Done()
+
+		err := self.run(statements)
+		if err != nil {
+			self.output("stderr", err.Error()+"\n")
+		}
+
+		self.sendEvent("terminated", nil)
+		self.sendEvent("exited", map[string]interface{}{"exitCode": 0})
+	}()
+}
+
+func (self *DAPServer) run(statements []*vfilter.VQL) error {
+	ctx := self.ctx
+	logger := log.New(&dapOutputWriter{server: self, category: "console"}, "", 0)
+
+	scope, err := self.newScope(ctx, logger)
+	if err != nil {
+		return err
+	}
+	defer scope.Close()
+
+	self.debugger.Install(scope)
+	opts := vql_subsystem.EncOptsFromScope(scope)
+
+	for _, vql := range statements {
+		self.debugger.BeforeStatement(scope, vql)
+
+		for row := range vql.Eval(ctx, scope) {
+			serialized, err := json.MarshalWithOptions(
+				vfilter.RowToDict(ctx, scope, row), opts)
+			if err != nil {
+				continue
+			}
+			self.output("stdout", string(serialized)+"\n")
+		}
+
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	for _, item := range self.debugger.Stats().Get() {
+		self.output("console", item.String()+"\n")
+	}
+	return nil
+}
+
+func (self *DAPServer) output(category, message string) {
+	self.sendEvent("output", map[string]interface{}{
+		"category": category,
+		"output":   message,
+	})
+}
+
+func (self *DAPServer) sendResponse(
+	request *dapMessage, body interface{}, err error) {
+	response := &dapResponse{
+		Type:       "response",
+		RequestSeq: request.Seq,
+		Success:    err == nil,
+		Command:    request.Command,
+		Body:       body,
+	}
+	if err != nil {
+		response.Message = err.Error()
+	}
+	self.send(func(seq int) interface{} {
+		response.Seq = seq
+		return response
+	})
+}
+
+func (self *DAPServer) sendEvent(event string, body interface{}) {
+	self.send(func(seq int) interface{} {
+		return &dapEvent{Seq: seq, Type: "event", Event: event, Body: body}
+	})
+}
+
+func (self *DAPServer) send(build func(seq int) interface{}) {
+	self.write_mu.Lock()
+	defer self.write_mu.Unlock()
+
+	self.seq++
+	serialized, err := json.Marshal(build(self.seq))
+	if err != nil {
+		return
+	}
+
+	fmt.Fprintf(self.writer, "Content-Length: %d\r\n\r\n", len(serialized))
+	_, _ = self.writer.Write(serialized)
+}
+
+type dapOutputWriter struct {
+	server   *DAPServer
+	category string
+}
+
+func (self *dapOutputWriter) Write(p []byte) (int, error) {
+	self.server.output(self.category, string(p))
+	return len(p), nil
+}
+
+func readDAPMessage(reader *bufio.Reader) (*dapMessage, error) {
+	length := -1
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+
+		name, value, ok := strings.Cut(line, ":")
+		if ok && strings.EqualFold(name, "Content-Length") {
+			length, err = strconv.Atoi(strings.TrimSpace(value))
+			if err != nil {
+				return nil, fmt.Errorf("Invalid Content-Length: %w", err)
+			}
+		}
+	}
+
+	if length < 0 || length > constants.MAX_MEMORY {
+		return nil, errors.New("Invalid Content-Length")
+	}
+
+	data := make([]byte, length)
+	_, err := io.ReadFull(reader, data)
+	if err != nil {
+		return nil, err
+	}
+
+	message := &dapMessage{}
+	err = json.Unmarshal(data, message)
+	return message, err
+}
+
+// Lines where a breakpoint may stop: statement starts and plugin
+// calls.
+func breakableLines(statements []*vfilter.VQL) map[int]bool {
+	result := make(map[int]bool)
+	for _, vql := range statements {
+		result[vql.Pos.Line] = true
+		collectPluginLines(reflect.ValueOf(vql), result)
+	}
+	return result
+}
+
+func collectPluginLines(v reflect.Value, result map[int]bool) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return
+		}
+		if v.Kind() == reflect.Ptr && v.CanInterface() {
+			plugin, ok := v.Interface().(*vfilter.Plugin)
+			if ok {
+				result[plugin.Pos.Line] = true
+			}
+		}
+		collectPluginLines(v.Elem(), result)
+
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			collectPluginLines(v.Index(i), result)
+		}
+
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			if !t.Field(i).IsExported() {
+				continue
+			}
+
+			field := v.Field(i)
+			if field.Kind() == reflect.Struct && field.CanAddr() {
+				field = field.Addr()
+			}
+			collectPluginLines(field, result)
+		}
+	}
+}
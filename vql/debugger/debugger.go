@@ -0,0 +1,598 @@
+/*
+  A VQL debugger.
+
+  The debugger is installed into a scope as a vfilter Explainer. The
+  query engine reports every row a plugin emits and every row a WHERE
+  clause rejects to the explainer from the goroutine which evaluates
+  the query. By blocking inside these callbacks the debugger can pause
+  the query at a breakpoint while a frontend inspects the current row
+  and the scope.
+
+  The runner must also call BeforeStatement() before evaluating each
+  top level statement so the debugger can stop between statements.
+
+  Frontends decide what happens when the debugger stops: the console
+  frontend prompts the user, the logging frontend just logs the
+  current state (i.e. breakpoints become log points) and the DAP
+  server forwards the stop to an IDE.
+*/
+
+package debugger
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Velocidex/ordereddict"
+	"www.velocidex.com/golang/velociraptor/json"
+	vql_subsystem "www.velocidex.com/golang/velociraptor/vql"
+	"www.velocidex.com/golang/vfilter"
+	"www.velocidex.com/golang/vfilter/types"
+)
+
+// How execution continues after a stop.
+type Action int
+
+const (
+	// Run until the next breakpoint.
+	ActionContinue Action = iota
+
+	// Stop at the next row from any plugin or the next statement.
+	ActionNext
+
+	// Stop at the next top level statement.
+	ActionNextStatement
+
+	// Cancel the query.
+	ActionQuit
+)
+
+const (
+	ReasonEntry      = "entry"
+	ReasonBreakpoint = "breakpoint"
+	ReasonStep       = "step"
+	ReasonPause      = "pause"
+)
+
+// Values longer than this are truncated when displayed.
+const maxValueLength = 500
+
+type Breakpoint struct {
+	Id int
+
+	// Stop before the statement starting on this line, or on rows
+	// from a plugin called on this line (1 based).
+	Line int
+
+	// Stop on rows from a plugin with this name, or before
+	// evaluating the LET with this name.
+	Name string
+
+	// An optional VQL expression. We only stop when it is true.
+	Condition string
+
+	lambda *vfilter.Lambda
+}
+
+func (self *Breakpoint) String() string {
+	res := self.Name
+	if self.Line > 0 {
+		res = fmt.Sprintf("line %v", self.Line)
+	}
+	if self.Condition != "" {
+		res += " if " + self.Condition
+	}
+	return res
+}
+
+// A StopEvent describes where the query is paused.
+type StopEvent struct {
+	Reason     string
+	Breakpoint *Breakpoint
+
+	// A description of the location (e.g. "glob()" or "LET X")
+	Location string
+	Line     int
+	Column   int
+
+	// The statement which is about to be evaluated. Only set for
+	// statement stops.
+	Statement *vfilter.VQL
+
+	// The row the plugin just emitted and its 1 based index. Only
+	// set for row stops.
+	Row      vfilter.Row
+	RowIndex int64
+
+	// The scope the statements run in. Note that variables from
+	// nested queries (e.g. the row of a foreach()) are not visible
+	// here.
+	Scope vfilter.Scope
+
+	// The debugger which stopped.
+	Debugger *Debugger
+}
+
+// A Frontend is notified when the debugger stops and decides how
+// execution continues. Stopped() may block for as long as it needs
+// to - the query is paused until it returns.
+type Frontend interface {
+	Stopped(ctx context.Context, event *StopEvent) Action
+}
+
+type Debugger struct {
+	mu sync.Mutex
+
+	ctx    context.Context
+	cancel func()
+
+	frontend    Frontend
+	breakpoints []*Breakpoint
+	next_id     int
+
+	// Set when the next row or statement should stop.
+	step_row       bool
+	step_statement bool
+	pause          bool
+	entry          bool
+
+	// Only one stop may be active at a time.
+	stop_mu sync.Mutex
+
+	scope vfilter.Scope
+
+	// The names of all the LET statements seen so far.
+	lets []string
+
+	// Map stored queries to the LET which defines them.
+	let_queries map[interface{}]string
+
+	stats *Stats
+}
+
+func NewDebugger(ctx context.Context, frontend Frontend) *Debugger {
+	sub_ctx, cancel := context.WithCancel(ctx)
+	return &Debugger{
+		ctx:         sub_ctx,
+		cancel:      cancel,
+		frontend:    frontend,
+		let_queries: make(map[interface{}]string),
+		stats:       NewStats(),
+	}
+}
+
+// The context which queries under the debugger should use. It is
+// cancelled when the frontend quits.
+func (self *Debugger) Context() context.Context {
+	return self.ctx
+}
+
+// Cancel the query.
+func (self *Debugger) Cancel() {
+	self.cancel()
+}
+
+func (self *Debugger) Stats() *Stats {
+	return self.stats
+}
+
+// Install the debugger into the scope. All queries evaluated in this
+// scope and its children will be debugged.
+func (self *Debugger) Install(scope vfilter.Scope) {
+	self.mu.Lock()
+	self.scope = scope
+	self.mu.Unlock()
+
+	scope.SetExplainer(self)
+	scope.EnableExplain()
+}
+
+func (self *Debugger) getScope() vfilter.Scope {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	return self.scope
+}
+
+// Stop before the next statement.
+func (self *Debugger) StopOnEntry() {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	self.entry = true
+}
+
+// Pause at the next row or statement.
+func (self *Debugger) Pause() {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	self.pause = true
+}
+
+func (self *Debugger) AddBreakpoint(bp *Breakpoint) error {
+	if bp.Condition != "" {
+		lambda, err := vfilter.ParseLambda("Row=>" + bp.Condition)
+		if err != nil {
+			return fmt.Errorf("Invalid condition %v: %w", bp.Condition, err)
+		}
+		bp.lambda = lambda
+	}
+	bp.Name = strings.TrimSuffix(bp.Name, "()")
+
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	self.next_id++
+	bp.Id = self.next_id
+	self.breakpoints = append(self.breakpoints, bp)
+	return nil
+}
+
+func (self *Debugger) RemoveBreakpoint(id int) bool {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	for idx, bp := range self.breakpoints {
+		if bp.Id == id {
+			self.breakpoints = append(
+				self.breakpoints[:idx], self.breakpoints[idx+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (self *Debugger) ClearBreakpoints() {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	self.breakpoints = nil
+}
+
+func (self *Debugger) Breakpoints() []*Breakpoint {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	return append([]*Breakpoint{}, self.breakpoints...)
+}
+
+// Must be called by the runner before each top level statement is
+// evaluated.
+func (self *Debugger) BeforeStatement(scope vfilter.Scope, vql *vfilter.VQL) {
+	location := "SELECT"
+	if vql.Let != "" {
+		location = "LET " + vql.Let
+	}
+
+	self.mu.Lock()
+	if vql.Let != "" {
+		self.lets = append(self.lets, vql.Let)
+		if vql.StoredQuery != nil {
+			self.let_queries[vql.StoredQuery] = vql.Let
+		}
+	}
+	reason := ""
+	if self.step_statement || self.step_row {
+		reason = ReasonStep
+	}
+	if self.pause {
+		reason = ReasonPause
+	}
+	if self.entry {
+		reason = ReasonEntry
+	}
+	self.mu.Unlock()
+
+	event := &StopEvent{
+		Reason:    reason,
+		Location:  location,
+		Line:      vql.Pos.Line,
+		Column:    vql.Pos.Column,
+		Statement: vql,
+		Scope:     scope,
+	}
+
+	if reason == "" {
+		event.Breakpoint = self.matchBreakpoint(func(bp *Breakpoint) bool {
+			return (bp.Line > 0 && bp.Line == vql.Pos.Line) ||
+				(bp.Name != "" && bp.Name == vql.Let)
+		}, nil)
+		if event.Breakpoint == nil {
+			return
+		}
+		event.Reason = ReasonBreakpoint
+	}
+
+	self.stop(event)
+}
+
+// Find the first breakpoint which matches and whose condition is
+// true.
+func (self *Debugger) matchBreakpoint(
+	match func(bp *Breakpoint) bool, row vfilter.Row) *Breakpoint {
+	for _, bp := range self.Breakpoints() {
+		if !match(bp) {
+			continue
+		}
+
+		if bp.lambda != nil && !self.evalCondition(bp, row) {
+			continue
+		}
+		return bp
+	}
+	return nil
+}
+
+func (self *Debugger) evalCondition(bp *Breakpoint, row vfilter.Row) bool {
+	scope := self.getScope()
+	if scope == nil {
+		return false
+	}
+
+	subscope := scope.Copy()
+	defer subscope.Close()
+
+	if row != nil {
+		subscope.AppendVars(row)
+	}
+
+	return scope.Bool(bp.lambda.Reduce(self.ctx, subscope, []vfilter.Any{row}))
+}
+
+func (self *Debugger) stop(event *StopEvent) {
+	self.stop_mu.Lock()
+	defer self.stop_mu.Unlock()
+
+	if self.ctx.Err() != nil {
+		return
+	}
+
+	event.Debugger = self
+	action := self.frontend.Stopped(self.ctx, event)
+
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	self.step_row = false
+	self.step_statement = false
+	self.pause = false
+	self.entry = false
+
+	switch action {
+	case ActionNext:
+		self.step_row = true
+		self.step_statement = true
+
+	case ActionNextStatement:
+		self.step_statement = true
+
+	case ActionQuit:
+		self.cancel()
+	}
+}
+
+// Evaluate a VQL expression in the context of the stop. The current
+// row is available as "Row" and its columns are visible as
+// variables.
+func (self *Debugger) Evaluate(event *StopEvent, expression string) (
+	vfilter.Any, error) {
+	lambda, err := vfilter.ParseLambda("Row=>" + expression)
+	if err != nil {
+		return nil, err
+	}
+
+	subscope := event.Scope.Copy()
+	defer subscope.Close()
+
+	if event.Row != nil {
+		subscope.AppendVars(event.Row)
+	}
+
+	value := lambda.Reduce(self.ctx, subscope, []vfilter.Any{event.Row})
+	return vql_subsystem.Materialize(self.ctx, subscope, value), nil
+}
+
+// Describe where the query stopped.
+func DescribeStop(event *StopEvent) string {
+	res := "Stopped"
+	switch event.Reason {
+	case ReasonBreakpoint:
+		if event.Breakpoint != nil {
+			res += fmt.Sprintf(" at breakpoint %v (%v)",
+				event.Breakpoint.Id, event.Breakpoint)
+		}
+	case ReasonEntry:
+		res += " on entry"
+	case ReasonStep:
+		res += " after step"
+	case ReasonPause:
+		res += " on pause"
+	}
+
+	res += fmt.Sprintf(" line %v: %v", event.Line, event.Location)
+	if event.Row != nil {
+		res += fmt.Sprintf(" row %v", event.RowIndex)
+	}
+	return res
+}
+
+// The columns of the current row.
+func RowToDict(event *StopEvent) *ordereddict.Dict {
+	result := ordereddict.NewDict()
+	if event.Row == nil {
+		return result
+	}
+
+	for _, key := range event.Scope.GetMembers(event.Row) {
+		value, pres := event.Scope.Associative(event.Row, key)
+		if pres {
+			result.Set(key, value)
+		}
+	}
+	return result
+}
+
+// The LET definitions seen so far and their current values. Stored
+// queries are shown as their VQL rather than evaluated.
+func (self *Debugger) ScopeVariables(event *StopEvent) *ordereddict.Dict {
+	self.mu.Lock()
+	lets := append([]string{}, self.lets...)
+	self.mu.Unlock()
+
+	sort.Strings(lets)
+
+	result := ordereddict.NewDict()
+	for _, name := range lets {
+		if _, pres := result.Get(name); pres {
+			continue
+		}
+
+		value, pres := event.Scope.Resolve(name)
+		if !pres {
+			continue
+		}
+
+		switch t := value.(type) {
+		case types.StoredQuery:
+			value = vfilter.FormatToString(event.Scope, t)
+		default:
+			value = vql_subsystem.Materialize(self.ctx, event.Scope, t)
+		}
+		result.Set(name, value)
+	}
+	return result
+}
+
+// Format a value for display.
+func FormatValue(value vfilter.Any) string {
+	var res string
+	switch t := value.(type) {
+	case string:
+		res = strconv.Quote(t)
+	case nil, types.Null, *types.Null:
+		res = "NULL"
+	default:
+		serialized, err := json.Marshal(value)
+		if err != nil {
+			res = fmt.Sprintf("%v", value)
+		} else {
+			res = string(serialized)
+		}
+	}
+
+	if len(res) > maxValueLength {
+		res = res[:maxValueLength] + " ..."
+	}
+	return res
+}
+
+var breakpointSpecRegex = regexp.MustCompile(`^\s*(\S+?)(?:\s+if\s+(.+))?\s*$`)
+
+// Parse a breakpoint specification: a line number, plugin name or
+// LET name, optionally followed by "if <condition>".
+func ParseBreakpoint(spec string) (*Breakpoint, error) {
+	match := breakpointSpecRegex.FindStringSubmatch(spec)
+	if match == nil {
+		return nil, fmt.Errorf("Invalid breakpoint %q", spec)
+	}
+
+	bp := &Breakpoint{Condition: match[2]}
+	line, err := strconv.Atoi(match[1])
+	if err == nil {
+		bp.Line = line
+	} else {
+		bp.Name = match[1]
+	}
+	return bp, nil
+}
+
+// The vfilter Explainer interface.
+func (self *Debugger) StartQuery(select_ast_node interface{}) {
+	self.stats.StartQuery(self.getScope(), select_ast_node)
+
+	self.mu.Lock()
+	name, pres := self.let_queries[select_ast_node]
+	self.mu.Unlock()
+
+	if !pres {
+		return
+	}
+
+	// Stop when a LET with a breakpoint is actually evaluated.
+	bp := self.matchBreakpoint(func(bp *Breakpoint) bool {
+		return bp.Name == name
+	}, nil)
+	if bp == nil {
+		return
+	}
+
+	line, column := astPosition(select_ast_node)
+	self.stop(&StopEvent{
+		Reason:     ReasonBreakpoint,
+		Breakpoint: bp,
+		Location:   "LET " + name,
+		Line:       line,
+		Column:     column,
+		Scope:      self.getScope(),
+	})
+}
+
+func (self *Debugger) PluginOutput(plugin_ast_node interface{}, row vfilter.Row) {
+	idx := self.stats.PluginOutput(plugin_ast_node)
+
+	plugin, ok := plugin_ast_node.(*vfilter.Plugin)
+	if !ok {
+		return
+	}
+
+	self.mu.Lock()
+	reason := ""
+	if self.step_row {
+		reason = ReasonStep
+	}
+	if self.pause {
+		reason = ReasonPause
+	}
+	self.mu.Unlock()
+
+	event := &StopEvent{
+		Reason:   reason,
+		Location: plugin.Name + "()",
+		Line:     plugin.Pos.Line,
+		Column:   plugin.Pos.Column,
+		Row:      row,
+		RowIndex: idx,
+		Scope:    self.getScope(),
+	}
+
+	if reason == "" {
+		event.Breakpoint = self.matchBreakpoint(func(bp *Breakpoint) bool {
+			return (bp.Line > 0 && bp.Line == plugin.Pos.Line) ||
+				bp.Name == plugin.Name
+		}, row)
+		if event.Breakpoint == nil {
+			return
+		}
+		event.Reason = ReasonBreakpoint
+	}
+
+	self.stop(event)
+}
+
+func (self *Debugger) SelectOutput(row vfilter.Row) {}
+
+func (self *Debugger) ParseArgs(
+	args *ordereddict.Dict, result interface{}, err error) {
+}
+
+func (self *Debugger) RejectRow(where_ast_node interface{}) {
+	self.stats.RejectRow(where_ast_node)
+}
+
+func (self *Debugger) Log(message string) {
+	scope := self.getScope()
+	if scope != nil {
+		scope.Log("DEBUG:%v", message)
+	}
+}
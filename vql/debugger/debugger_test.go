@@ -0,0 +1,291 @@
+package debugger
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Velocidex/ordereddict"
+	"github.com/stretchr/testify/assert"
+	"www.velocidex.com/golang/velociraptor/json"
+	"www.velocidex.com/golang/vfilter"
+)
+
+const testQuery = `LET X = SELECT _value AS V FROM range(start=0, end=10)
+
+SELECT V FROM X
+WHERE V > 6
+`
+
+// A frontend which records the stops and replays a list of actions.
+type scriptedFrontend struct {
+	events  []string
+	actions []Action
+	eval    func(event *StopEvent)
+}
+
+func (self *scriptedFrontend) Stopped(
+	ctx context.Context, event *StopEvent) Action {
+	self.events = append(self.events, DescribeStop(event))
+	if self.eval != nil {
+		self.eval(event)
+	}
+
+	if len(self.actions) == 0 {
+		return ActionContinue
+	}
+	action := self.actions[0]
+	self.actions = self.actions[1:]
+	return action
+}
+
+func runQuery(t *testing.T, debugger *Debugger, query string) []vfilter.Row {
+	statements, err := vfilter.MultiParse(query)
+	assert.NoError(t, err)
+
+	scope := vfilter.NewScope()
+	defer scope.Close()
+
+	debugger.Install(scope)
+
+	var rows []vfilter.Row
+	ctx := debugger.Context()
+	for _, vql := range statements {
+		debugger.BeforeStatement(scope, vql)
+		for row := range vql.Eval(ctx, scope) {
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+func TestStats(t *testing.T) {
+	debugger := NewDebugger(context.Background(), &scriptedFrontend{})
+	rows := runQuery(t, debugger, testQuery)
+	assert.Equal(t, 3, len(rows))
+
+	var stats []string
+	for _, item := range debugger.Stats().Get() {
+		stats = append(stats, item.String())
+	}
+	assert.Equal(t, []string{
+		"line 1: range(): 10 rows",
+		"line 3: X(): 10 rows, WHERE V > 6 dropped 7, 3 passed",
+	}, stats)
+}
+
+func TestBreakpoints(t *testing.T) {
+	var values []string
+
+	frontend := &scriptedFrontend{
+		// Step from the breakpoint to the next row.
+		actions: []Action{ActionNext},
+		eval: func(event *StopEvent) {
+			value, err := event.Debugger.Evaluate(event, "Row")
+			assert.NoError(t, err)
+			values = append(values, FormatValue(value))
+		},
+	}
+	debugger := NewDebugger(context.Background(), frontend)
+
+	bp, err := ParseBreakpoint("range() if _value = 3")
+	assert.NoError(t, err)
+	assert.NoError(t, debugger.AddBreakpoint(bp))
+
+	runQuery(t, debugger, "SELECT * FROM range(start=0, end=10)")
+
+	assert.Equal(t, []string{
+		"Stopped at breakpoint 1 (range if _value = 3) line 1: range() row 4",
+		"Stopped after step line 1: range() row 5",
+	}, frontend.events)
+	assert.Equal(t, []string{`{"_value":3}`, `{"_value":4}`}, values)
+}
+
+func TestStatementBreakpoints(t *testing.T) {
+	frontend := &scriptedFrontend{
+		actions: []Action{ActionNextStatement, ActionQuit},
+	}
+	debugger := NewDebugger(context.Background(), frontend)
+	debugger.StopOnEntry()
+
+	rows := runQuery(t, debugger, testQuery)
+
+	assert.Equal(t, []string{
+		"Stopped on entry line 1: LET X",
+		"Stopped after step line 3: SELECT",
+	}, frontend.events)
+
+	// The query was cancelled.
+	assert.Equal(t, 0, len(rows))
+}
+
+func TestConsole(t *testing.T) {
+	out := &strings.Builder{}
+	in := strings.NewReader(`
+b 1 if _value = 8
+c
+vars
+print V
+c
+`)
+
+	debugger := NewDebugger(context.Background(), NewConsoleFrontend(in, out))
+	debugger.StopOnEntry()
+	runQuery(t, debugger, testQuery)
+
+	assert.Equal(t, `Stopped on entry line 1: LET X
+(vql) (vql) Breakpoint 1: line 1 if _value = 8
+(vql) Stopped at breakpoint 1 (line 1 if _value = 8) line 1: range() row 9
+(vql) Row 9 from range():
+  _value = 8
+Scope:
+  X = "SELECT _value AS V FROM range(start=0, end=10)"
+(vql) NULL
+(vql) `, out.String())
+}
+
+type dapClient struct {
+	t      *testing.T
+	seq    int
+	writer io.Writer
+	reader *bufio.Reader
+}
+
+func (self *dapClient) send(command string, args interface{}) {
+	self.seq++
+	serialized, err := json.Marshal(ordereddict.NewDict().
+		Set("seq", self.seq).
+		Set("type", "request").
+		Set("command", command).
+		Set("arguments", args))
+	assert.NoError(self.t, err)
+
+	fmt.Fprintf(self.writer, "Content-Length: %d\r\n\r\n%s",
+		len(serialized), serialized)
+}
+
+// Read messages until we see one matching the event or command.
+func (self *dapClient) expect(name string) *ordereddict.Dict {
+	for {
+		length := 0
+		for {
+			line, err := self.reader.ReadString('\n')
+			assert.NoError(self.t, err)
+			line = strings.TrimSpace(line)
+			if line == "" {
+				break
+			}
+			_, err = fmt.Sscanf(line, "Content-Length: %d", &length)
+			assert.NoError(self.t, err)
+		}
+
+		data := make([]byte, length)
+		_, err := io.ReadFull(self.reader, data)
+		assert.NoError(self.t, err)
+
+		message := ordereddict.NewDict()
+		assert.NoError(self.t, json.Unmarshal(data, message))
+
+		event, _ := message.GetString("event")
+		command, _ := message.GetString("command")
+		if event == name || command == name {
+			return message
+		}
+	}
+}
+
+func TestDAP(t *testing.T) {
+	dir := t.TempDir()
+	program := filepath.Join(dir, "test.vql")
+	assert.NoError(t, os.WriteFile(program, []byte(testQuery), 0600))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	factory := func(ctx context.Context, logger *log.Logger) (
+		vfilter.Scope, error) {
+		scope := vfilter.NewScope()
+		scope.SetLogger(logger)
+		return scope, nil
+	}
+
+	client_reader, server_writer := io.Pipe()
+	server_reader, client_writer := io.Pipe()
+
+	server := NewDAPServer(ctx, factory)
+	done := make(chan error)
+	go func() {
+		done <- server.Serve(struct {
+			io.Reader
+			io.Writer
+		}{server_reader, server_writer})
+	}()
+
+	client := &dapClient{
+		t:      t,
+		writer: client_writer,
+		reader: bufio.NewReader(client_reader),
+	}
+
+	client.send("initialize", ordereddict.NewDict())
+	client.expect("initialized")
+
+	client.send("launch", ordereddict.NewDict().Set("program", program))
+	client.expect("launch")
+
+	client.send("setBreakpoints", ordereddict.NewDict().
+		Set("source", ordereddict.NewDict().Set("path", program)).
+		Set("breakpoints", []*ordereddict.Dict{
+			ordereddict.NewDict().Set("line", 1).Set("condition", "_value = 5"),
+			ordereddict.NewDict().Set("line", 2),
+		}))
+	response := client.expect("setBreakpoints")
+	assert.Equal(t, `[{"id":1,"verified":true,"line":1},{"id":2,"verified":false,"line":2,"message":"No statement or plugin call starts on this line"}]`,
+		json.MustMarshalString(getPath(response, "body", "breakpoints")))
+
+	client.send("configurationDone", ordereddict.NewDict())
+	stopped := client.expect("stopped")
+	assert.Equal(t, "breakpoint", getPath(stopped, "body", "reason"))
+
+	client.send("stackTrace", ordereddict.NewDict().Set("threadId", 1))
+	response = client.expect("stackTrace")
+	assert.Equal(t, `[{"column":33,"id":1,"line":1,"name":"range()","source":{"name":"test.vql","path":"`+program+`"}}]`,
+		json.MustMarshalString(getPath(response, "body", "stackFrames")))
+
+	client.send("variables", ordereddict.NewDict().
+		Set("variablesReference", dapRowReference))
+	response = client.expect("variables")
+	assert.Equal(t, `[{"name":"_value","value":"5","variablesReference":0}]`,
+		json.MustMarshalString(getPath(response, "body", "variables")))
+
+	client.send("evaluate", ordereddict.NewDict().Set("expression", "_value + 1"))
+	response = client.expect("evaluate")
+	assert.Equal(t, "6", getPath(response, "body", "result"))
+
+	client.send("continue", ordereddict.NewDict().Set("threadId", 1))
+	client.expect("terminated")
+
+	client.send("disconnect", ordereddict.NewDict())
+	client.expect("disconnect")
+
+	assert.NoError(t, <-done)
+}
+
+func getPath(item *ordereddict.Dict, path ...string) interface{} {
+	var result interface{} = item
+	for _, p := range path {
+		dict, ok := result.(*ordereddict.Dict)
+		if !ok {
+			return nil
+		}
+		result, _ = dict.Get(p)
+	}
+	return result
+}
@@ -0,0 +1,40 @@
+package debugger
+
+import (
+	"context"
+	"strings"
+
+	"www.velocidex.com/golang/vfilter"
+)
+
+// A frontend for non-interactive queries (e.g. notebook cells):
+// breakpoints become log points which log the current row and never
+// pause the query.
+type LoggingFrontend struct {
+	scope vfilter.Scope
+}
+
+func NewLoggingFrontend(scope vfilter.Scope) *LoggingFrontend {
+	return &LoggingFrontend{scope: scope}
+}
+
+func (self *LoggingFrontend) Stopped(
+	ctx context.Context, event *StopEvent) Action {
+	message := DescribeStop(event)
+	if event.Row != nil {
+		var columns []string
+		for _, i := range RowToDict(event).Items() {
+			columns = append(columns, i.Key+"="+FormatValue(i.Value))
+		}
+		message += ": " + strings.Join(columns, ", ")
+	}
+	self.scope.Log("DEBUG:%v", message)
+	return ActionContinue
+}
+
+// Log the row counts of all queries seen so far.
+func LogStats(scope vfilter.Scope, stats *Stats) {
+	for _, item := range stats.Get() {
+		scope.Log("DEBUG:%v", item.String())
+	}
+}
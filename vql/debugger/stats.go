@@ -0,0 +1,220 @@
+package debugger
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Velocidex/ordereddict"
+	"www.velocidex.com/golang/vfilter"
+)
+
+const maxQueryLength = 80
+
+// Row counts for a single SELECT clause. A SELECT inside a foreach()
+// is evaluated once for each row but its counts are accumulated
+// here.
+type QueryStats struct {
+	Query  string
+	Plugin string
+	Line   int
+	Column int
+	Where  string
+
+	// Rows of GROUP BY queries are not reported by the query engine.
+	Grouped bool
+
+	Rows     int64
+	Rejected int64
+}
+
+func (self *QueryStats) Passed() int64 {
+	return self.Rows - self.Rejected
+}
+
+func (self *QueryStats) String() string {
+	res := fmt.Sprintf("line %v: %v", self.Line, self.Plugin)
+	if self.Grouped {
+		return res + ": row counts are not available for GROUP BY queries"
+	}
+
+	res += fmt.Sprintf(": %v rows", self.Rows)
+	if self.Where != "" {
+		res += fmt.Sprintf(", WHERE %v dropped %v, %v passed",
+			self.Where, self.Rejected, self.Passed())
+	}
+	return res
+}
+
+type Stats struct {
+	mu sync.Mutex
+
+	by_plugin map[*vfilter.Plugin]*QueryStats
+	by_where  map[interface{}]*QueryStats
+}
+
+func NewStats() *Stats {
+	return &Stats{
+		by_plugin: make(map[*vfilter.Plugin]*QueryStats),
+		by_where:  make(map[interface{}]*QueryStats),
+	}
+}
+
+func (self *Stats) StartQuery(scope vfilter.Scope, node interface{}) {
+	plugin, where, grouped := selectClauses(node)
+	if plugin == nil {
+		return
+	}
+
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	_, pres := self.by_plugin[plugin]
+	if pres {
+		return
+	}
+
+	stats := &QueryStats{
+		Query:   abbreviate(vfilter.FormatToString(scope, node)),
+		Plugin:  plugin.Name + "()",
+		Line:    plugin.Pos.Line,
+		Column:  plugin.Pos.Column,
+		Grouped: grouped,
+	}
+	if where != nil {
+		stats.Where = abbreviate(vfilter.FormatToString(scope, where))
+		self.by_where[where] = stats
+	}
+	self.by_plugin[plugin] = stats
+}
+
+// Count a row from the plugin and return its 1 based index.
+func (self *Stats) PluginOutput(node interface{}) int64 {
+	plugin, ok := node.(*vfilter.Plugin)
+	if !ok {
+		return 0
+	}
+
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	stats, pres := self.by_plugin[plugin]
+	if !pres {
+		return 0
+	}
+	stats.Rows++
+	return stats.Rows
+}
+
+func (self *Stats) RejectRow(where interface{}) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	stats, pres := self.by_where[where]
+	if pres {
+		stats.Rejected++
+	}
+}
+
+// A snapshot of the stats in query order.
+func (self *Stats) Get() []*QueryStats {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	result := make([]*QueryStats, 0, len(self.by_plugin))
+	for _, stats := range self.by_plugin {
+		item := *stats
+		result = append(result, &item)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Line == result[j].Line {
+			return result[i].Column < result[j].Column
+		}
+		return result[i].Line < result[j].Line
+	})
+	return result
+}
+
+func (self *Stats) ToDicts() []*ordereddict.Dict {
+	result := []*ordereddict.Dict{}
+	for _, stats := range self.Get() {
+		result = append(result, ordereddict.NewDict().
+			Set("Line", stats.Line).
+			Set("Plugin", stats.Plugin).
+			Set("Query", stats.Query).
+			Set("Where", stats.Where).
+			Set("Grouped", stats.Grouped).
+			Set("Rows", stats.Rows).
+			Set("Rejected", stats.Rejected).
+			Set("Passed", stats.Passed()))
+	}
+	return result
+}
+
+// Extract the FROM plugin and WHERE clause from a SELECT. The AST
+// types are private to vfilter so we use reflection here.
+func selectClauses(node interface{}) (
+	plugin *vfilter.Plugin, where interface{}, grouped bool) {
+	v := reflect.ValueOf(node)
+	if v.Kind() != reflect.Ptr || v.IsNil() ||
+		v.Elem().Kind() != reflect.Struct {
+		return nil, nil, false
+	}
+	v = v.Elem()
+
+	from := v.FieldByName("From")
+	if !from.IsValid() || from.Kind() != reflect.Ptr || from.IsNil() {
+		return nil, nil, false
+	}
+
+	plugin_field := from.Elem().FieldByName("Plugin")
+	if !plugin_field.IsValid() || !plugin_field.CanAddr() {
+		return nil, nil, false
+	}
+
+	plugin, ok := plugin_field.Addr().Interface().(*vfilter.Plugin)
+	if !ok {
+		return nil, nil, false
+	}
+
+	where_field := v.FieldByName("Where")
+	if where_field.IsValid() && !where_field.IsNil() {
+		where = where_field.Interface()
+	}
+
+	group_by := v.FieldByName("GroupBy")
+	grouped = group_by.IsValid() && !group_by.IsNil()
+
+	return plugin, where, grouped
+}
+
+func astPosition(node interface{}) (line, column int) {
+	v := reflect.ValueOf(node)
+	if v.Kind() != reflect.Ptr || v.IsNil() ||
+		v.Elem().Kind() != reflect.Struct {
+		return 0, 0
+	}
+
+	pos := v.Elem().FieldByName("Pos")
+	if !pos.IsValid() {
+		return 0, 0
+	}
+
+	line_field := pos.FieldByName("Line")
+	column_field := pos.FieldByName("Column")
+	if !line_field.IsValid() || !column_field.IsValid() {
+		return 0, 0
+	}
+	return int(line_field.Int()), int(column_field.Int())
+}
+
+func abbreviate(query string) string {
+	query = strings.Join(strings.Fields(query), " ")
+	if len(query) > maxQueryLength {
+		query = query[:maxQueryLength] + " ..."
+	}
+	return query
+}
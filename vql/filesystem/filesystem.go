@@ -182,8 +182,10 @@ func (self GlobPlugin) Info(scope vfilter.Scope, type_map *vfilter.TypeMap) *vfi
 		Doc:     "Retrieve files based on a list of glob expressions",
 		ArgType: type_map.AddType(scope, &GlobPluginArgs{}),
 		Version: 3,
+		// Accessors may also expose their raw stat data in Sys.
 		Metadata: vql_subsystem.VQLMetadata().Permissions(
-			acls.FILESYSTEM_READ).Build(),
+			acls.FILESYSTEM_READ).RowType(&glob.GlobHit{}).
+			Columns("Sys:interface {}").Build(),
 	}
 }
 
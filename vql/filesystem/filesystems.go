@@ -74,7 +74,8 @@ func init() {
 
 				return result
 			},
-			ArgType: &PartitionsArgs{},
-			Doc:     "List all partititions",
+			ArgType:  &PartitionsArgs{},
+			Doc:      "List all partititions",
+			Metadata: vql_subsystem.VQLMetadata().RowType(&ExtendedFileSystemInfo{}).Build(),
 		})
 }
@@ -20,6 +20,7 @@ package vql
 import (
 	"context"
 	"os"
+	"reflect"
 	"runtime"
 	"time"
 
@@ -37,32 +38,68 @@ var (
 	start_time = time.Now()
 )
 
+// The row emitted by the info() plugin. Columns are in field order.
+type InfoRow struct {
+	Hostname             string
+	Uptime               uint64
+	BootTime             uint64
+	OS                   string
+	Platform             string
+	PlatformFamily       string
+	PlatformVersion      string
+	KernelVersion        string
+	VirtualizationSystem string
+	VirtualizationRole   string
+	CompilerVersion      string
+	HostID               string
+	Exe                  string
+	CWD                  string
+	IsAdmin              bool
+	ClientStart          time.Time
+	LocalTZ              string
+	LocalTZOffset        int
+	Fqdn                 string
+	Architecture         string
+}
+
+func (self *InfoRow) toDict() *ordereddict.Dict {
+	result := ordereddict.NewDict()
+	value := reflect.ValueOf(self).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		result.Set(value.Type().Field(i).Name, value.Field(i).Interface())
+	}
+	return result
+}
+
 func GetInfo(host *psutils.InfoStat) *ordereddict.Dict {
 	me, _ := os.Executable()
 	cwd, _ := os.Getwd()
 
 	zone, tz_offset := time.Now().Local().Zone()
 
-	return ordereddict.NewDict().
-		Set("Hostname", host.Hostname).
-		Set("Uptime", host.Uptime).
-		Set("BootTime", host.BootTime).
-		Set("OS", host.OS).
-		Set("Platform", host.Platform).
-		Set("PlatformFamily", host.PlatformFamily).
-		Set("PlatformVersion", host.PlatformVersion).
-		Set("KernelVersion", host.KernelVersion).
-		Set("VirtualizationSystem", host.VirtualizationSystem).
-		Set("VirtualizationRole", host.VirtualizationRole).
-		Set("CompilerVersion", runtime.Version()).
-		Set("HostID", psutils.HostID()).
-		Set("Exe", me).
-		Set("CWD", cwd).
-		Set("IsAdmin", IsAdmin()).
-		Set("ClientStart", start_time).
-		Set("LocalTZ", zone).
-		Set("LocalTZOffset", tz_offset)
-
+	row := &InfoRow{
+		Hostname:             host.Hostname,
+		Uptime:               host.Uptime,
+		BootTime:             host.BootTime,
+		OS:                   host.OS,
+		Platform:             host.Platform,
+		PlatformFamily:       host.PlatformFamily,
+		PlatformVersion:      host.PlatformVersion,
+		KernelVersion:        host.KernelVersion,
+		VirtualizationSystem: host.VirtualizationSystem,
+		VirtualizationRole:   host.VirtualizationRole,
+		CompilerVersion:      runtime.Version(),
+		HostID:               psutils.HostID(),
+		Exe:                  me,
+		CWD:                  cwd,
+		IsAdmin:              IsAdmin(),
+		ClientStart:          start_time,
+		LocalTZ:              zone,
+		LocalTZOffset:        tz_offset,
+		Fqdn:                 fqdn.Get(),
+		Architecture:         utils.GetArch(),
+	}
+	return row.toDict()
 }
 
 func info(
@@ -96,16 +133,15 @@ func info(
 		CacheSet(scope, "__info", info)
 	}
 
-	return GetInfo(info).
-		Set("Fqdn", fqdn.Get()).
-		Set("Architecture", utils.GetArch())
+	return GetInfo(info)
 }
 
 func init() {
 	RegisterPlugin(
 		vfilter.GenericListPlugin{
 			PluginName: "info",
-			Metadata:   VQLMetadata().Permissions(acls.MACHINE_STATE).Build(),
+			Metadata: VQLMetadata().Permissions(acls.MACHINE_STATE).
+				RowType(&InfoRow{}).Build(),
 			Function: func(
 				ctx context.Context,
 				scope vfilter.Scope,
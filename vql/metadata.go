@@ -1,9 +1,13 @@
 package vql
 
 import (
+	"fmt"
+	"reflect"
 	"strings"
 
 	"github.com/Velocidex/ordereddict"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
 	"www.velocidex.com/golang/velociraptor/acls"
 )
 
@@ -20,6 +24,31 @@ func (self *MetadataBuilder) Permissions(perms ...acls.ACL_PERMISSION) *Metadata
 	return self
 }
 
+// Declare the columns of the rows emitted by a plugin. Each column
+// is given as Name:Type. This is used by the static analysis to find
+// references to columns which do not exist.
+func (self *MetadataBuilder) Columns(columns ...string) *MetadataBuilder {
+	existing, pres := self.GetString("columns")
+	if pres {
+		columns = append([]string{existing}, columns...)
+	}
+	self.Set("columns", strings.Join(columns, ","))
+	return self
+}
+
+// Declare the columns of the rows emitted by a plugin from the Go
+// type of the row. VQL can access exported fields as well as
+// exported methods which take no args. Protobuf messages are
+// described by the fields json.ConvertProtoToOrderedDict() emits.
+func (self *MetadataBuilder) RowType(row interface{}) *MetadataBuilder {
+	message, ok := row.(proto.Message)
+	if ok {
+		return self.Columns(describeProtoType(
+			message.ProtoReflect().Descriptor())...)
+	}
+	return self.Columns(describeRowType(reflect.TypeOf(row))...)
+}
+
 func (self *MetadataBuilder) Build() *ordereddict.Dict {
 	return self.Dict
 }
@@ -27,3 +56,116 @@ func (self *MetadataBuilder) Build() *ordereddict.Dict {
 func VQLMetadata() *MetadataBuilder {
 	return &MetadataBuilder{ordereddict.NewDict()}
 }
+
+func describeRowType(row_type reflect.Type) []string {
+	var result []string
+	seen := make(map[string]bool)
+
+	add := func(name string, column_type reflect.Type) {
+		if seen[name] {
+			return
+		}
+		seen[name] = true
+		result = append(result, fmt.Sprintf("%v:%v", name, column_type))
+	}
+
+	// Allow the row type to be given as a pointer to an interface.
+	if row_type.Kind() == reflect.Ptr &&
+		row_type.Elem().Kind() == reflect.Interface {
+		row_type = row_type.Elem()
+	}
+
+	struct_type := row_type
+	if struct_type.Kind() == reflect.Ptr {
+		struct_type = struct_type.Elem()
+	}
+
+	if struct_type.Kind() == reflect.Struct {
+		for _, field := range reflect.VisibleFields(struct_type) {
+			if field.Anonymous || !field.IsExported() {
+				continue
+			}
+			add(field.Name, field.Type)
+		}
+	}
+
+	// The method set of the pointer includes the value methods.
+	method_type := row_type
+	if method_type.Kind() != reflect.Ptr &&
+		method_type.Kind() != reflect.Interface {
+		method_type = reflect.PointerTo(method_type)
+	}
+
+	for i := 0; i < method_type.NumMethod(); i++ {
+		method := method_type.Method(i)
+
+		// Interface methods have no receiver arg.
+		args := method.Type.NumIn()
+		if method_type.Kind() != reflect.Interface {
+			args--
+		}
+
+		if args != 0 || method.Type.NumOut() != 1 {
+			continue
+		}
+		add(method.Name, method.Type.Out(0))
+	}
+
+	return result
+}
+
+func describeProtoType(descriptor protoreflect.MessageDescriptor) []string {
+	var result []string
+
+	fields := descriptor.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		result = append(result, fmt.Sprintf("%v:%v",
+			field.Name(), describeProtoField(field)))
+	}
+	return result
+}
+
+// The type of the value json.ConvertProtoToOrderedDict() stores for
+// the field.
+func describeProtoField(field protoreflect.FieldDescriptor) string {
+	if field.IsMap() {
+		return "*ordereddict.Dict"
+	}
+
+	var field_type string
+	switch field.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		field_type = "*ordereddict.Dict"
+
+	// Enums are stored by name.
+	case protoreflect.EnumKind:
+		field_type = "string"
+
+	default:
+		field_type = protoScalarTypes[field.Kind()]
+	}
+
+	if field.Cardinality() == protoreflect.Repeated {
+		return "[]" + field_type
+	}
+	return field_type
+}
+
+var protoScalarTypes = map[protoreflect.Kind]string{
+	protoreflect.BoolKind:     "bool",
+	protoreflect.Int32Kind:    "int32",
+	protoreflect.Sint32Kind:   "int32",
+	protoreflect.Sfixed32Kind: "int32",
+	protoreflect.Uint32Kind:   "uint32",
+	protoreflect.Fixed32Kind:  "uint32",
+	protoreflect.Int64Kind:    "int64",
+	protoreflect.Sint64Kind:   "int64",
+	protoreflect.Sfixed64Kind: "int64",
+	protoreflect.Uint64Kind:   "uint64",
+	protoreflect.Fixed64Kind:  "uint64",
+	protoreflect.FloatKind:    "float32",
+	protoreflect.DoubleKind:   "float64",
+	protoreflect.StringKind:   "string",
+	protoreflect.BytesKind:    "[]uint8",
+}
@@ -0,0 +1,430 @@
+package profiler
+
+import (
+	"context"
+	"fmt"
+	"runtime/metrics"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Velocidex/ordereddict"
+	"github.com/dustin/go-humanize"
+	"www.velocidex.com/golang/velociraptor/constants"
+	"www.velocidex.com/golang/velociraptor/executor/throttler"
+	"www.velocidex.com/golang/velociraptor/utils"
+	vql_subsystem "www.velocidex.com/golang/velociraptor/vql"
+	"www.velocidex.com/golang/vfilter"
+	"www.velocidex.com/golang/vfilter/types"
+	vfilter_utils "www.velocidex.com/golang/vfilter/utils"
+)
+
+// The kinds of frames we profile.
+const (
+	FRAME_QUERY    = "query"
+	FRAME_LET      = "let"
+	FRAME_PLUGIN   = "plugin"
+	FRAME_FUNCTION = "function"
+)
+
+const allocMetric = "/gc/heap/allocs:bytes"
+
+type frameKey struct{}
+
+// A frame is a unique call stack - the same plugin called from
+// different places in the query gets a different frame.
+type frame struct {
+	stack string
+	name  string
+	kind  string
+
+	calls int64
+	rows  int64
+	wall  time.Duration
+	cpu   float64
+	alloc uint64
+
+	children []*frame
+}
+
+// Resource usage at a point in time. CPU and allocations are only
+// available for the whole process so they are approximate when
+// queries run in parallel.
+type sample struct {
+	time  time.Time
+	cpu   float64
+	alloc uint64
+}
+
+// A Profiler attributes time, rows and resources to each plugin,
+// function and LET evaluated by a query.
+type Profiler struct {
+	mu     sync.Mutex
+	root   *frame
+	frames map[string]*frame
+
+	cpu_reporter *throttler.CPUReporter
+}
+
+func NewProfiler(name string) *Profiler {
+	root := &frame{stack: name, name: name, kind: FRAME_QUERY}
+	return &Profiler{
+		root:         root,
+		frames:       map[string]*frame{name: root},
+		cpu_reporter: throttler.NewCPUReporter(),
+	}
+}
+
+// Returns a profiler if the query's environment sets VQL_PROFILE.
+func MaybeInstall(scope vfilter.Scope, name string) *Profiler {
+	if !vql_subsystem.GetBoolFromRow(scope, scope, constants.VQL_PROFILE) {
+		return nil
+	}
+
+	result := NewProfiler(name)
+	result.Install(scope)
+	return result
+}
+
+// Wrap all the plugins and functions in the scope so their calls
+// are recorded. The scope's dispatcher is private to the query so
+// this does not affect other queries.
+func (self *Profiler) Install(scope vfilter.Scope) {
+	info := scope.Describe(types.NewTypeMap())
+
+	for _, item := range info.Plugins {
+		plugin, pres := scope.GetPlugin(item.Name)
+		if !pres {
+			continue
+		}
+
+		_, ok := plugin.(*profiledPlugin)
+		if ok {
+			continue
+		}
+		scope.AppendPlugins(&profiledPlugin{
+			delegate: plugin,
+			name:     item.Name,
+			profiler: self,
+		})
+	}
+
+	for _, item := range info.Functions {
+		function, pres := scope.GetFunction(item.Name)
+		if !pres {
+			continue
+		}
+
+		_, ok := function.(*profiledFunction)
+		if ok {
+			continue
+		}
+		scope.AppendFunctions(&profiledFunction{
+			delegate: function,
+			name:     item.Name,
+			profiler: self,
+		})
+	}
+
+	// Artifacts are called through the Artifact variable rather
+	// than a registered plugin.
+	artifact_plugin, pres := scope.Resolve("Artifact")
+	if pres {
+		plugin, ok := artifact_plugin.(vfilter.PluginGeneratorInterface)
+		if ok {
+			scope.AppendVars(ordereddict.NewDict().Set("Artifact",
+				&profiledPlugin{
+					delegate: plugin,
+					name:     "Artifact",
+					profiler: self,
+				}))
+		}
+	}
+
+	scope.SetContext(constants.SCOPE_PROFILER, self)
+}
+
+func (self *Profiler) Close() {
+	self.cpu_reporter.Close()
+}
+
+// Evaluate a statement using the profiler installed in the scope if
+// there is one.
+func Eval(ctx context.Context,
+	scope vfilter.Scope, vql *vfilter.VQL) <-chan vfilter.Row {
+	profiler, ok := GetProfiler(scope)
+	if !ok {
+		return vql.Eval(ctx, scope)
+	}
+	return profiler.Eval(ctx, scope, vql)
+}
+
+func GetProfiler(scope vfilter.Scope) (*Profiler, bool) {
+	value, pres := scope.GetContext(constants.SCOPE_PROFILER)
+	if !pres {
+		return nil, false
+	}
+	profiler, ok := value.(*Profiler)
+	return profiler, ok
+}
+
+// Evaluate a statement under its own frame.
+func (self *Profiler) Eval(ctx context.Context,
+	scope vfilter.Scope, vql *vfilter.VQL) <-chan vfilter.Row {
+	if vql.Let == "" {
+		ctx, f := self.enter(ctx, "SELECT", FRAME_QUERY)
+		return self.track(ctx, f, true, func(ctx context.Context) <-chan vfilter.Row {
+			return vql.Eval(ctx, scope)
+		})
+	}
+
+	// Materialized LETs are evaluated here, while lazy LETs are
+	// evaluated when they are used.
+	name := vfilter_utils.Unquote_ident(vql.Let)
+	ctx, f := self.enter(ctx, "LET "+name, FRAME_LET)
+	rows := self.track(ctx, f, true, func(ctx context.Context) <-chan vfilter.Row {
+		return vql.Eval(ctx, scope)
+	})
+
+	output_chan := make(chan vfilter.Row)
+	go func() {
+		defer close(output_chan)
+
+		for range rows {
+		}
+
+		if vql.LetOperator == "=" && vql.StoredQuery != nil {
+			self.wrapStoredQuery(scope, name)
+		}
+	}()
+
+	return output_chan
+}
+
+func (self *Profiler) wrapStoredQuery(scope vfilter.Scope, name string) {
+	value, pres := scope.Resolve(name)
+	if !pres {
+		return
+	}
+
+	stored_query, ok := value.(vfilter.StoredQuery)
+	if !ok {
+		return
+	}
+
+	scope.AppendVars(ordereddict.NewDict().Set(name, &profiledQuery{
+		delegate: stored_query,
+		name:     name,
+		profiler: self,
+	}))
+}
+
+// Find or create the frame for name called from the frame in the
+// context.
+func (self *Profiler) enter(ctx context.Context,
+	name, kind string) (context.Context, *frame) {
+	parent, ok := ctx.Value(frameKey{}).(*frame)
+	if !ok {
+		parent = self.root
+	}
+
+	self.mu.Lock()
+	stack := parent.stack + ";" + name
+	f, pres := self.frames[stack]
+	if !pres {
+		f = &frame{stack: stack, name: name, kind: kind}
+		self.frames[stack] = f
+		parent.children = append(parent.children, f)
+	}
+	f.calls++
+	self.mu.Unlock()
+
+	return context.WithValue(ctx, frameKey{}, f), f
+}
+
+func (self *Profiler) sample() sample {
+	samples := []metrics.Sample{{Name: allocMetric}}
+	metrics.Read(samples)
+
+	result := sample{
+		time: time.Now(),
+		cpu:  self.cpu_reporter.GetCpuTime(context.Background()),
+	}
+	if samples[0].Value.Kind() == metrics.KindUint64 {
+		result.alloc = samples[0].Value.Uint64()
+	}
+	return result
+}
+
+// Charge the resources used since start to the frame.
+func (self *Profiler) record(f *frame, start sample, rows int64) {
+	end := self.sample()
+
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	f.rows += rows
+	f.wall += end.time.Sub(start.time)
+	if end.cpu > start.cpu {
+		f.cpu += end.cpu - start.cpu
+	}
+	if end.alloc > start.alloc {
+		f.alloc += end.alloc - start.alloc
+	}
+}
+
+// Relay the rows from the producer, charging the frame only for
+// the time spent waiting for each row and not the time the consumer
+// takes to process it. Statements are charged for their entire run
+// since the columns are evaluated lazily by the consumer.
+func (self *Profiler) track(ctx context.Context, f *frame, inclusive bool,
+	producer func(ctx context.Context) <-chan vfilter.Row) <-chan vfilter.Row {
+	output_chan := make(chan vfilter.Row)
+
+	go func() {
+		defer close(output_chan)
+
+		query_start := self.sample()
+		var rows int64
+		if inclusive {
+			defer func() {
+				self.record(f, query_start, rows)
+			}()
+		}
+
+		input_chan := producer(ctx)
+		if !inclusive {
+			self.record(f, query_start, 0)
+		}
+
+		for {
+			var start sample
+			if !inclusive {
+				start = self.sample()
+			}
+
+			row, ok := <-input_chan
+			if !ok {
+				if !inclusive {
+					self.record(f, start, 0)
+				}
+				return
+			}
+
+			if inclusive {
+				rows++
+			} else {
+				self.record(f, start, 1)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case output_chan <- row:
+			}
+		}
+	}()
+
+	return output_chan
+}
+
+// The profile as rows, one for each frame. Self time is the wall
+// time not accounted for by the frame's children.
+func (self *Profiler) Rows() []*ordereddict.Dict {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	var frames []*frame
+	for _, f := range self.frames {
+		if f != self.root {
+			frames = append(frames, f)
+		}
+	}
+	sort.Slice(frames, func(i, j int) bool {
+		return frames[i].stack < frames[j].stack
+	})
+
+	result := make([]*ordereddict.Dict, 0, len(frames))
+	for _, f := range frames {
+		self_time := f.wall
+		for _, child := range f.children {
+			self_time -= child.wall
+		}
+		if self_time < 0 {
+			self_time = 0
+		}
+
+		result = append(result, ordereddict.NewDict().
+			Set("Stack", f.stack).
+			Set("Name", f.name).
+			Set("Type", f.kind).
+			Set("Calls", f.calls).
+			Set("Rows", f.rows).
+			Set("WallTime", f.wall.Seconds()).
+			Set("SelfTime", self_time.Seconds()).
+			Set("CPUTime", f.cpu).
+			Set("Allocs", f.alloc))
+	}
+	return result
+}
+
+// Log a line for each frame - used when there is nowhere to store
+// the profile.
+func (self *Profiler) Log(scope vfilter.Scope) {
+	for _, row := range self.Rows() {
+		scope.Log("DEBUG:%v", FormatRow(row))
+	}
+}
+
+func FormatRow(row *ordereddict.Dict) string {
+	stack, _ := row.GetString("Stack")
+	calls, _ := row.GetInt64("Calls")
+	rows, _ := row.GetInt64("Rows")
+	wall, _ := row.Get("WallTime")
+	self_time, _ := row.Get("SelfTime")
+	cpu, _ := row.Get("CPUTime")
+	allocs, _ := row.GetInt64("Allocs")
+
+	return fmt.Sprintf(
+		"%v: %v calls, %v rows, wall %v, self %v, cpu %v, allocated %v",
+		stack, calls, rows, seconds(wall), seconds(self_time),
+		seconds(cpu), humanize.Bytes(uint64(allocs)))
+}
+
+// Times are stored as seconds but whole numbers may come back from
+// JSON as integers.
+func seconds(value interface{}) time.Duration {
+	switch t := value.(type) {
+	case float64:
+		return time.Duration(t * float64(time.Second)).Round(time.Microsecond)
+	}
+
+	integer, ok := utils.ToInt64(value)
+	if ok {
+		return time.Duration(integer) * time.Second
+	}
+	return 0
+}
+
+// Convert profile rows to the folded stack format used by flame
+// graph tools: the stack followed by its self time in microseconds.
+func FoldedStacks(rows []*ordereddict.Dict) []string {
+	totals := ordereddict.NewDict()
+	for _, row := range rows {
+		stack, _ := row.GetString("Stack")
+		value, _ := row.Get("SelfTime")
+		micros := int64(seconds(value) / time.Microsecond)
+
+		total, _ := totals.GetInt64(stack)
+		totals.Set(stack, total+micros)
+	}
+
+	var result []string
+	for _, item := range totals.Items() {
+		micros, _ := item.Value.(int64)
+		if micros > 0 {
+			result = append(result, fmt.Sprintf("%v %v", item.Key, micros))
+		}
+	}
+	return result
+}
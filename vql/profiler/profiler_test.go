@@ -0,0 +1,103 @@
+package profiler
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/Velocidex/ordereddict"
+	"github.com/stretchr/testify/assert"
+	"www.velocidex.com/golang/vfilter"
+)
+
+const testQuery = `LET X = SELECT _value AS V FROM range(start=0, end=10)
+LET Y <= SELECT * FROM X WHERE V > 6
+
+SELECT V, count() AS C FROM foreach(row=Y, query={SELECT * FROM X WHERE V = 0})
+`
+
+func runQuery(t *testing.T, scope vfilter.Scope, query string) []vfilter.Row {
+	statements, err := vfilter.MultiParse(query)
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+
+	var rows []vfilter.Row
+	for _, vql := range statements {
+		for row := range Eval(ctx, scope, vql) {
+			rows = append(rows, vfilter.RowToDict(ctx, scope, row))
+		}
+	}
+	return rows
+}
+
+func TestProfiler(t *testing.T) {
+	scope := vfilter.NewScope()
+	defer scope.Close()
+
+	profiler := NewProfiler("Test")
+	defer profiler.Close()
+
+	profiler.Install(scope)
+
+	rows := runQuery(t, scope, testQuery)
+	assert.Equal(t, 3, len(rows))
+
+	var profile []string
+	for _, row := range profiler.Rows() {
+		stack, _ := row.GetString("Stack")
+		kind, _ := row.GetString("Type")
+		calls, _ := row.GetInt64("Calls")
+		rows, _ := row.GetInt64("Rows")
+		profile = append(profile, fmt.Sprintf("%v (%v): %v calls, %v rows",
+			stack, kind, calls, rows))
+	}
+
+	assert.Equal(t, []string{
+		"Test;LET X (let): 1 calls, 0 rows",
+		"Test;LET Y (let): 1 calls, 0 rows",
+		"Test;LET Y;LET X (let): 1 calls, 10 rows",
+		"Test;LET Y;LET X;range() (plugin): 1 calls, 10 rows",
+		"Test;SELECT (query): 1 calls, 3 rows",
+		"Test;SELECT;count() (function): 3 calls, 0 rows",
+		"Test;SELECT;foreach() (plugin): 1 calls, 3 rows",
+		"Test;SELECT;foreach();LET X (let): 3 calls, 30 rows",
+		"Test;SELECT;foreach();LET X;range() (plugin): 3 calls, 30 rows",
+	}, profile)
+}
+
+func TestMaybeInstall(t *testing.T) {
+	scope := vfilter.NewScope()
+	defer scope.Close()
+
+	assert.Nil(t, MaybeInstall(scope, "Test"))
+
+	scope.AppendVars(ordereddict.NewDict().Set("VQL_PROFILE", "Y"))
+	profiler := MaybeInstall(scope, "Test")
+	assert.NotNil(t, profiler)
+	defer profiler.Close()
+
+	installed, ok := GetProfiler(scope)
+	assert.True(t, ok)
+	assert.Equal(t, profiler, installed)
+}
+
+func TestFoldedStacks(t *testing.T) {
+	rows := []*ordereddict.Dict{
+		ordereddict.NewDict().
+			Set("Stack", "A;SELECT;glob()").
+			Set("SelfTime", 1.5),
+		ordereddict.NewDict().
+			Set("Stack", "A;SELECT").
+			Set("SelfTime", int64(0)),
+
+		// Each query of a collection appends its own rows.
+		ordereddict.NewDict().
+			Set("Stack", "A;SELECT;glob()").
+			Set("SelfTime", int64(2)),
+	}
+
+	assert.Equal(t, []string{
+		"A;SELECT;glob() 3500000",
+	}, FoldedStacks(rows))
+}
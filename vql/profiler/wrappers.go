@@ -0,0 +1,185 @@
+package profiler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Velocidex/ordereddict"
+	vql_subsystem "www.velocidex.com/golang/velociraptor/vql"
+	"www.velocidex.com/golang/vfilter"
+	"www.velocidex.com/golang/vfilter/types"
+)
+
+// Records each call of a plugin and the rows it produces.
+type profiledPlugin struct {
+	delegate vfilter.PluginGeneratorInterface
+	name     string
+	profiler *Profiler
+}
+
+func (self *profiledPlugin) Call(ctx context.Context,
+	scope vfilter.Scope, args *ordereddict.Dict) <-chan vfilter.Row {
+	ctx, f := self.profiler.enter(ctx, self.name+"()", FRAME_PLUGIN)
+	return self.profiler.track(ctx, f, false,
+		func(ctx context.Context) <-chan vfilter.Row {
+			return self.delegate.Call(ctx, scope, args)
+		})
+}
+
+func (self *profiledPlugin) Info(
+	scope vfilter.Scope, type_map *vfilter.TypeMap) *vfilter.PluginInfo {
+	return self.delegate.Info(scope, type_map)
+}
+
+func (self *profiledPlugin) ApplyDefaults(
+	ctx context.Context, scope types.Scope, args *ordereddict.Dict) {
+	types.MaybeApplyDefaultArgs(self.delegate, ctx, scope, args)
+}
+
+// Records each call of a function. The result may be lazy so
+// some of the work may be charged to the caller.
+type profiledFunction struct {
+	delegate vfilter.FunctionInterface
+	name     string
+	profiler *Profiler
+}
+
+func (self *profiledFunction) Call(ctx context.Context,
+	scope vfilter.Scope, args *ordereddict.Dict) vfilter.Any {
+	ctx, f := self.profiler.enter(ctx, self.name+"()", FRAME_FUNCTION)
+
+	start := self.profiler.sample()
+	defer self.profiler.record(f, start, 0)
+
+	return self.delegate.Call(ctx, scope, args)
+}
+
+func (self *profiledFunction) Info(
+	scope vfilter.Scope, type_map *vfilter.TypeMap) *vfilter.FunctionInfo {
+	return self.delegate.Info(scope, type_map)
+}
+
+func (self *profiledFunction) ApplyDefaults(
+	ctx context.Context, scope types.Scope, args *ordereddict.Dict) {
+	types.MaybeApplyDefaultArgs(self.delegate, ctx, scope, args)
+}
+
+// Each reference to a function in the query gets its own copy so
+// it can keep state (e.g. aggregate functions).
+func (self *profiledFunction) Copy() types.FunctionInterface {
+	return &profiledFunction{
+		delegate: vfilter.CopyFunction(self.delegate),
+		name:     self.name,
+		profiler: self.profiler,
+	}
+}
+
+// Records each evaluation of a lazy LET query.
+type profiledQuery struct {
+	delegate vfilter.StoredQuery
+	name     string
+	profiler *Profiler
+}
+
+func (self *profiledQuery) Eval(
+	ctx context.Context, scope types.Scope) <-chan vfilter.Row {
+	ctx, f := self.profiler.enter(ctx, "LET "+self.name, FRAME_LET)
+	return self.profiler.track(ctx, f, false,
+		func(ctx context.Context) <-chan vfilter.Row {
+			return self.delegate.Eval(ctx, scope)
+		})
+}
+
+// Stored queries may be called like plugins with parameters.
+func (self *profiledQuery) Call(ctx context.Context,
+	scope vfilter.Scope, args *ordereddict.Dict) <-chan vfilter.Row {
+	plugin, ok := self.delegate.(vfilter.PluginGeneratorInterface)
+	if !ok {
+		scope.Log("ERROR:Symbol %v is not callable as a plugin", self.name)
+		output_chan := make(chan vfilter.Row)
+		close(output_chan)
+		return output_chan
+	}
+
+	ctx, f := self.profiler.enter(ctx, "LET "+self.name, FRAME_LET)
+	return self.profiler.track(ctx, f, false,
+		func(ctx context.Context) <-chan vfilter.Row {
+			return plugin.Call(ctx, scope, args)
+		})
+}
+
+func (self *profiledQuery) Info(
+	scope vfilter.Scope, type_map *vfilter.TypeMap) *vfilter.PluginInfo {
+	return &vfilter.PluginInfo{}
+}
+
+func (self *profiledQuery) ApplyDefaults(
+	ctx context.Context, scope types.Scope, args *ordereddict.Dict) {
+	types.MaybeApplyDefaultArgs(self.delegate, ctx, scope, args)
+}
+
+func (self *profiledQuery) Marshal(
+	scope types.Scope) (*types.MarshalItem, error) {
+	marshaller, ok := self.delegate.(types.Marshaler)
+	if !ok {
+		return nil, fmt.Errorf("LET %v can not be marshalled", self.name)
+	}
+	return marshaller.Marshal(scope)
+}
+
+// Resolve Artifact.Name.Source through the wrapped plugin so the
+// artifact calls are profiled under their full names.
+type _ProfiledPluginAssociative struct{}
+
+func (self _ProfiledPluginAssociative) Applicable(
+	a vfilter.Any, b vfilter.Any) bool {
+	_, ok := a.(*profiledPlugin)
+	if !ok {
+		return false
+	}
+
+	_, ok = b.(string)
+	return ok
+}
+
+func (self _ProfiledPluginAssociative) GetMembers(
+	scope vfilter.Scope, a vfilter.Any) []string {
+	plugin, ok := a.(*profiledPlugin)
+	if !ok {
+		return nil
+	}
+	return scope.GetMembers(plugin.delegate)
+}
+
+func (self _ProfiledPluginAssociative) Associative(
+	scope vfilter.Scope, a vfilter.Any, b vfilter.Any) (vfilter.Any, bool) {
+	plugin, ok := a.(*profiledPlugin)
+	if !ok {
+		return nil, false
+	}
+
+	key, ok := b.(string)
+	if !ok {
+		return nil, false
+	}
+
+	value, pres := scope.Associative(plugin.delegate, key)
+	if !pres {
+		return nil, false
+	}
+
+	sub_plugin, ok := value.(vfilter.PluginGeneratorInterface)
+	if !ok {
+		return value, true
+	}
+
+	return &profiledPlugin{
+		delegate: sub_plugin,
+		name:     plugin.name + "." + key,
+		profiler: plugin.profiler,
+	}, true
+}
+
+func init() {
+	vql_subsystem.RegisterProtocol(&_ProfiledPluginAssociative{})
+}
@@ -27,6 +27,7 @@ import (
 
 	"github.com/Velocidex/ordereddict"
 	"www.velocidex.com/golang/velociraptor/acls"
+	api_proto "www.velocidex.com/golang/velociraptor/api/proto"
 	"www.velocidex.com/golang/velociraptor/json"
 	"www.velocidex.com/golang/velociraptor/services"
 	"www.velocidex.com/golang/velociraptor/vql"
@@ -129,10 +130,11 @@ func (self ClientsPlugin) Call(
 
 func (self ClientsPlugin) Info(scope vfilter.Scope, type_map *vfilter.TypeMap) *vfilter.PluginInfo {
 	return &vfilter.PluginInfo{
-		Name:     "clients",
-		Doc:      "Retrieve the list of clients.",
-		ArgType:  type_map.AddType(scope, &ClientsPluginArgs{}),
-		Metadata: vql.VQLMetadata().Permissions(acls.READ_RESULTS).Build(),
+		Name:    "clients",
+		Doc:     "Retrieve the list of clients.",
+		ArgType: type_map.AddType(scope, &ClientsPluginArgs{}),
+		Metadata: vql.VQLMetadata().Permissions(acls.READ_RESULTS).
+			RowType(&api_proto.ApiClient{}).Build(),
 	}
 }
 
@@ -48,6 +48,8 @@ type ScheduleCollectionFunctionArg struct {
 	MaxBytes     uint64            `vfilter:"optional,field=max_bytes,doc=Max number of bytes to upload"`
 	Urgent       bool              `vfilter:"optional,field=urgent,doc=Set the collection as urgent - skips other queues collections on the client."`
 	OrgId        string            `vfilter:"optional,field=org_id,doc=If set the collection will be started in the specified org."`
+	DryRun       bool              `vfilter:"optional,field=dry_run,doc=If set the client only estimates the cost of the collection without uploading files or running side effecting plugins."`
+	Incremental  bool              `vfilter:"optional,field=incremental,doc=If set the client only sends rows of keyed sources which changed since the last incremental collection."`
 }
 
 type ScheduleCollectionFunction struct{}
@@ -150,6 +152,8 @@ func (self *ScheduleCollectionFunction) Call(ctx context.Context,
 		MaxRows:        arg.MaxRows,
 		MaxUploadBytes: arg.MaxBytes,
 		Urgent:         arg.Urgent,
+		DryRun:         arg.DryRun,
+		Incremental:    arg.Incremental,
 	}
 
 	if arg.Spec == nil {
@@ -10,14 +10,17 @@ import (
 	"github.com/stretchr/testify/suite"
 	"google.golang.org/protobuf/types/known/emptypb"
 	actions_proto "www.velocidex.com/golang/velociraptor/actions/proto"
+	crypto_proto "www.velocidex.com/golang/velociraptor/crypto/proto"
 	"www.velocidex.com/golang/velociraptor/datastore"
 	"www.velocidex.com/golang/velociraptor/file_store"
 	"www.velocidex.com/golang/velociraptor/file_store/api"
 	"www.velocidex.com/golang/velociraptor/file_store/path_specs"
 	"www.velocidex.com/golang/velociraptor/file_store/test_utils"
 	flows_proto "www.velocidex.com/golang/velociraptor/flows/proto"
+	"www.velocidex.com/golang/velociraptor/json"
 	"www.velocidex.com/golang/velociraptor/logging"
 	"www.velocidex.com/golang/velociraptor/paths"
+	artifact_paths "www.velocidex.com/golang/velociraptor/paths/artifacts"
 	"www.velocidex.com/golang/velociraptor/result_sets"
 	"www.velocidex.com/golang/velociraptor/services"
 	"www.velocidex.com/golang/velociraptor/utils"
@@ -195,6 +198,84 @@ func (self *FilestoreTestSuite) TestDeleteFlow() {
 
 }
 
+func (self *FilestoreTestSuite) writeIncrementalFlow(
+	flow_id, base string, sources []string, rows ...*ordereddict.Dict) {
+	db, err := datastore.GetDB(self.ConfigObj)
+	assert.NoError(self.T(), err)
+
+	flow_pm := paths.NewFlowPathManager(self.client_id, flow_id)
+	err = db.SetSubject(self.ConfigObj, flow_pm.Path(),
+		&flows_proto.ArtifactCollectorContext{
+			SessionId: flow_id,
+			ClientId:  self.client_id,
+			Request: &flows_proto.ArtifactCollectorArgs{
+				Artifacts:       []string{"Test.Incremental"},
+				Incremental:     true,
+				IncrementalBase: base,
+			},
+			QueryStats: []*crypto_proto.VeloStatus{{
+				IncrementalSources: sources,
+			}},
+		})
+	assert.NoError(self.T(), err)
+
+	path_manager, err := artifact_paths.NewArtifactPathManager(self.Ctx,
+		self.ConfigObj, self.client_id, flow_id, "Test.Incremental")
+	assert.NoError(self.T(), err)
+
+	rs_writer, err := result_sets.NewResultSetWriter(
+		file_store.GetFileStore(self.ConfigObj), path_manager.Path(),
+		nil, utils.SyncCompleter, result_sets.TruncateMode)
+	assert.NoError(self.T(), err)
+
+	for _, row := range rows {
+		rs_writer.Write(row)
+	}
+	rs_writer.Close()
+}
+
+func (self *FilestoreTestSuite) TestIncrementalResults() {
+	self.LoadArtifacts(`
+name: Test.Incremental
+sources:
+- key_column: Key
+  query: SELECT * FROM info()
+`)
+
+	// A full collection followed by two deltas.
+	self.writeIncrementalFlow("F.1", "", nil,
+		ordereddict.NewDict().Set("Key", 1).Set("Value", "A"),
+		ordereddict.NewDict().Set("Key", 2).Set("Value", "B"),
+		ordereddict.NewDict().Set("Key", 3).Set("Value", "C"))
+
+	self.writeIncrementalFlow("F.2", "F.1", []string{"Test.Incremental"},
+		ordereddict.NewDict().Set("Key", 2).Set("Value", "B2"),
+		ordereddict.NewDict().Set("Key", 3).Set("_Deleted", true))
+
+	self.writeIncrementalFlow("F.3", "F.2", []string{"Test.Incremental"},
+		ordereddict.NewDict().Set("Key", 4).Set("Value", "D"))
+
+	manager, _ := services.GetRepositoryManager(self.ConfigObj)
+	builder := services.ScopeBuilder{
+		Config:     self.ConfigObj,
+		ACLManager: acl_managers.NullACLManager{},
+		Logger: logging.NewPlainLogger(self.ConfigObj,
+			&logging.FrontendComponent),
+		Env: ordereddict.NewDict(),
+	}
+	scope := manager.BuildScope(builder)
+	defer scope.Close()
+
+	result := vtesting.RunPlugin(flows.IncrementalResultsPlugin{}.Call(
+		self.Ctx, scope, ordereddict.NewDict().
+			Set("flow_id", "F.3").
+			Set("client_id", self.client_id).
+			Set("artifact", "Test.Incremental")))
+
+	assert.Equal(self.T(), `[{"Key":1,"Value":"A"},{"Key":2,"Value":"B2"},{"Key":4,"Value":"D"}]`,
+		json.MustMarshalString(result))
+}
+
 func TestFilestorePlugin(t *testing.T) {
 	suite.Run(t, &FilestoreTestSuite{
 		client_id: "C.123",
@@ -6,6 +6,7 @@ import (
 	"github.com/Velocidex/ordereddict"
 	"www.velocidex.com/golang/velociraptor/acls"
 	"www.velocidex.com/golang/velociraptor/constants"
+	flows_proto "www.velocidex.com/golang/velociraptor/flows/proto"
 	"www.velocidex.com/golang/velociraptor/json"
 	"www.velocidex.com/golang/velociraptor/result_sets"
 	"www.velocidex.com/golang/velociraptor/services"
@@ -137,11 +138,13 @@ func (self FlowsPlugin) Call(
 
 func (self FlowsPlugin) Info(scope vfilter.Scope, type_map *vfilter.TypeMap) *vfilter.PluginInfo {
 	return &vfilter.PluginInfo{
-		Name:     "flows",
-		Doc:      "Retrieve the flows launched on each client.",
-		ArgType:  type_map.AddType(scope, &FlowsPluginArgs{}),
-		Metadata: vql_subsystem.VQLMetadata().Permissions(acls.READ_RESULTS).Build(),
-		Version:  3,
+		Name:    "flows",
+		Doc:     "Retrieve the flows launched on each client.",
+		ArgType: type_map.AddType(scope, &FlowsPluginArgs{}),
+		Metadata: vql_subsystem.VQLMetadata().Permissions(acls.READ_RESULTS).
+			RowType(&flows_proto.ArtifactCollectorContext{}).
+			Columns("AvailableDownloads:*api_proto.AvailableDownloads").Build(),
+		Version: 3,
 	}
 }
 
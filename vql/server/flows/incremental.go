@@ -0,0 +1,229 @@
+package flows
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Velocidex/ordereddict"
+	"www.velocidex.com/golang/velociraptor/acls"
+	config_proto "www.velocidex.com/golang/velociraptor/config/proto"
+	"www.velocidex.com/golang/velociraptor/file_store"
+	"www.velocidex.com/golang/velociraptor/json"
+	"www.velocidex.com/golang/velociraptor/paths"
+	artifact_paths "www.velocidex.com/golang/velociraptor/paths/artifacts"
+	"www.velocidex.com/golang/velociraptor/result_sets"
+	"www.velocidex.com/golang/velociraptor/services"
+	"www.velocidex.com/golang/velociraptor/utils"
+	vql_subsystem "www.velocidex.com/golang/velociraptor/vql"
+	"www.velocidex.com/golang/vfilter"
+	"www.velocidex.com/golang/vfilter/arg_parser"
+)
+
+const (
+	// Must match actions.INCREMENTAL_DELETED_COLUMN
+	incrementalDeletedColumn = "_Deleted"
+
+	// Stop following the chain of base flows after this many
+	// collections.
+	maxIncrementalDepth = 100
+)
+
+type IncrementalResultsPluginArgs struct {
+	Artifact  string `vfilter:"required,field=artifact,doc=The artifact to retrieve"`
+	Source    string `vfilter:"optional,field=source,doc=An optional source within the artifact."`
+	FlowId    string `vfilter:"required,field=flow_id,doc=The incremental flow to read."`
+	ClientId  string `vfilter:"required,field=client_id,doc=The client id to extract"`
+	KeyColumn string `vfilter:"optional,field=key_column,doc=The column identifying rows (default from the artifact source definition)"`
+}
+
+type IncrementalResultsPlugin struct{}
+
+func (self IncrementalResultsPlugin) Call(
+	ctx context.Context,
+	scope vfilter.Scope,
+	args *ordereddict.Dict) <-chan vfilter.Row {
+	output_chan := make(chan vfilter.Row)
+	go func() {
+		defer close(output_chan)
+		defer vql_subsystem.RegisterMonitor(ctx, "incremental_results", args)()
+
+		err := vql_subsystem.CheckAccess(scope, acls.READ_RESULTS)
+		if err != nil {
+			scope.Log("incremental_results: %s", err)
+			return
+		}
+
+		arg := &IncrementalResultsPluginArgs{}
+		err = arg_parser.ExtractArgsWithContext(ctx, scope, args, arg)
+		if err != nil {
+			scope.Log("incremental_results: %v", err)
+			return
+		}
+
+		err = services.RequireFrontend()
+		if err != nil {
+			scope.Log("incremental_results: %v", err)
+			return
+		}
+
+		config_obj, ok := vql_subsystem.GetServerConfig(scope)
+		if !ok {
+			scope.Log("incremental_results: Command can only run on the server")
+			return
+		}
+
+		if arg.Source != "" {
+			arg.Artifact = arg.Artifact + "/" + arg.Source
+			arg.Source = ""
+		}
+
+		if arg.KeyColumn == "" {
+			arg.KeyColumn, err = getKeyColumn(ctx, config_obj, arg.Artifact)
+			if err != nil {
+				scope.Log("incremental_results: %v", err)
+				return
+			}
+		}
+
+		view := ordereddict.NewDict()
+		err = stitchIncrementalResults(ctx, config_obj, view,
+			arg.ClientId, arg.FlowId, arg.Artifact, arg.KeyColumn, 0)
+		if err != nil {
+			scope.Log("incremental_results: %v", err)
+			return
+		}
+
+		for _, k := range view.Keys() {
+			row, _ := view.Get(k)
+			select {
+			case <-ctx.Done():
+				return
+			case output_chan <- row:
+			}
+		}
+	}()
+
+	return output_chan
+}
+
+func getKeyColumn(
+	ctx context.Context, config_obj *config_proto.Config,
+	full_name string) (string, error) {
+	manager, err := services.GetRepositoryManager(config_obj)
+	if err != nil {
+		return "", err
+	}
+
+	repository, err := manager.GetGlobalRepository(config_obj)
+	if err != nil {
+		return "", err
+	}
+
+	artifact_name, source_name := paths.SplitFullSourceName(full_name)
+	artifact, pres := repository.Get(ctx, config_obj, artifact_name)
+	if !pres {
+		return "", fmt.Errorf("Artifact %v not known", artifact_name)
+	}
+
+	for _, source := range artifact.Sources {
+		if source.Name == source_name {
+			return source.KeyColumn, nil
+		}
+	}
+
+	return "", nil
+}
+
+// Build the full view of an incremental flow into view. If the flow
+// only carries the changes for this source, we first build the view
+// of its base flow and then apply the changes on top.
+func stitchIncrementalResults(
+	ctx context.Context, config_obj *config_proto.Config,
+	view *ordereddict.Dict,
+	client_id, flow_id, artifact, key_column string, depth int) error {
+
+	if depth > maxIncrementalDepth {
+		return fmt.Errorf("Incremental chain too long at flow %v", flow_id)
+	}
+
+	launcher, err := services.GetLauncher(config_obj)
+	if err != nil {
+		return err
+	}
+
+	flow, err := launcher.Storage().LoadCollectionContext(ctx, config_obj,
+		client_id, flow_id, services.GetFlowOptions{})
+	if err != nil {
+		return err
+	}
+
+	is_delta := false
+	for _, status := range flow.QueryStats {
+		if utils.InString(status.IncrementalSources, artifact) {
+			is_delta = true
+			break
+		}
+	}
+
+	if is_delta && key_column != "" && flow.Request != nil &&
+		flow.Request.IncrementalBase != "" {
+		err = stitchIncrementalResults(ctx, config_obj, view, client_id,
+			flow.Request.IncrementalBase, artifact, key_column, depth+1)
+		if err != nil {
+			return err
+		}
+	}
+
+	path_manager, err := artifact_paths.NewArtifactPathManager(ctx,
+		config_obj, client_id, flow_id, artifact)
+	if err != nil {
+		return err
+	}
+
+	file_store_factory := file_store.GetFileStore(config_obj)
+	rs_reader, err := result_sets.NewResultSetReader(
+		file_store_factory, path_manager.Path())
+	if err != nil {
+		// No results in this flow
+		return nil
+	}
+	defer rs_reader.Close()
+
+	idx := 0
+	for row := range rs_reader.Rows(ctx) {
+		idx++
+
+		key_value, pres := row.Get(key_column)
+		if key_column == "" || !pres {
+			// Rows without a key can not be tracked so they are
+			// always kept.
+			view.Set(fmt.Sprintf("%v:%v", flow_id, idx), row)
+			continue
+		}
+
+		key := json.MustMarshalString(key_value)
+		deleted, _ := row.Get(incrementalDeletedColumn)
+		if deleted == true {
+			view.Delete(key)
+			continue
+		}
+
+		// Keep the position of rows that changed.
+		view.Update(key, row)
+	}
+
+	return nil
+}
+
+func (self IncrementalResultsPlugin) Info(scope vfilter.Scope, type_map *vfilter.TypeMap) *vfilter.PluginInfo {
+	return &vfilter.PluginInfo{
+		Name:     "incremental_results",
+		Doc:      "Retrieve the full view of an incremental collection by merging it with the collections it was based on.",
+		ArgType:  type_map.AddType(scope, &IncrementalResultsPluginArgs{}),
+		Metadata: vql_subsystem.VQLMetadata().Permissions(acls.READ_RESULTS).Build(),
+	}
+}
+
+func init() {
+	vql_subsystem.RegisterPlugin(&IncrementalResultsPlugin{})
+}
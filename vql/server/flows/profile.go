@@ -0,0 +1,109 @@
+package flows
+
+import (
+	"context"
+
+	"github.com/Velocidex/ordereddict"
+	"www.velocidex.com/golang/velociraptor/acls"
+	"www.velocidex.com/golang/velociraptor/file_store"
+	"www.velocidex.com/golang/velociraptor/paths"
+	"www.velocidex.com/golang/velociraptor/result_sets"
+	"www.velocidex.com/golang/velociraptor/services"
+	vql_subsystem "www.velocidex.com/golang/velociraptor/vql"
+	vql_profiler "www.velocidex.com/golang/velociraptor/vql/profiler"
+	"www.velocidex.com/golang/vfilter"
+	"www.velocidex.com/golang/vfilter/arg_parser"
+)
+
+type FlowProfilePluginArgs struct {
+	FlowId   string `vfilter:"required,field=flow_id,doc=The flow id to read."`
+	ClientId string `vfilter:"required,field=client_id,doc=The client id to extract"`
+	Folded   bool   `vfilter:"optional,field=folded,doc=Emit folded stacks (stack followed by self time in microseconds) for flame graph tools."`
+}
+
+type FlowProfilePlugin struct{}
+
+func (self FlowProfilePlugin) Call(
+	ctx context.Context,
+	scope vfilter.Scope,
+	args *ordereddict.Dict) <-chan vfilter.Row {
+	output_chan := make(chan vfilter.Row)
+	go func() {
+		defer close(output_chan)
+		defer vql_subsystem.RegisterMonitor(ctx, "flow_profile", args)()
+
+		err := vql_subsystem.CheckAccess(scope, acls.READ_RESULTS)
+		if err != nil {
+			scope.Log("flow_profile: %s", err)
+			return
+		}
+
+		arg := &FlowProfilePluginArgs{}
+		err = arg_parser.ExtractArgsWithContext(ctx, scope, args, arg)
+		if err != nil {
+			scope.Log("flow_profile: %v", err)
+			return
+		}
+
+		err = services.RequireFrontend()
+		if err != nil {
+			scope.Log("flow_profile: %v", err)
+			return
+		}
+
+		config_obj, ok := vql_subsystem.GetServerConfig(scope)
+		if !ok {
+			scope.Log("flow_profile: Command can only run on the server")
+			return
+		}
+
+		path_manager := paths.NewFlowPathManager(arg.ClientId, arg.FlowId)
+		file_store_factory := file_store.GetFileStore(config_obj)
+		rs_reader, err := result_sets.NewResultSetReader(
+			file_store_factory, path_manager.Profile())
+		if err != nil {
+			scope.Log("flow_profile: %v", err)
+			return
+		}
+		defer rs_reader.Close()
+
+		if !arg.Folded {
+			for row := range rs_reader.Rows(ctx) {
+				select {
+				case <-ctx.Done():
+					return
+				case output_chan <- row:
+				}
+			}
+			return
+		}
+
+		var rows []*ordereddict.Dict
+		for row := range rs_reader.Rows(ctx) {
+			rows = append(rows, row)
+		}
+
+		for _, line := range vql_profiler.FoldedStacks(rows) {
+			select {
+			case <-ctx.Done():
+				return
+			case output_chan <- ordereddict.NewDict().Set("Line", line):
+			}
+		}
+	}()
+
+	return output_chan
+}
+
+func (self FlowProfilePlugin) Info(scope vfilter.Scope, type_map *vfilter.TypeMap) *vfilter.PluginInfo {
+	return &vfilter.PluginInfo{
+		Name:     "flow_profile",
+		Doc:      "Retrieve the VQL profile of a flow collected with VQL_PROFILE set.",
+		ArgType:  type_map.AddType(scope, &FlowProfilePluginArgs{}),
+		Metadata: vql_subsystem.VQLMetadata().Permissions(acls.READ_RESULTS).Build(),
+	}
+}
+
+func init() {
+	vql_subsystem.RegisterPlugin(&FlowProfilePlugin{})
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/Velocidex/ordereddict"
 	"www.velocidex.com/golang/velociraptor/acls"
+	actions_proto "www.velocidex.com/golang/velociraptor/actions/proto"
 	"www.velocidex.com/golang/velociraptor/json"
 	"www.velocidex.com/golang/velociraptor/services"
 	vql_subsystem "www.velocidex.com/golang/velociraptor/vql"
@@ -82,10 +83,11 @@ func (self UploadTransactionsPlugin) Call(
 
 func (self UploadTransactionsPlugin) Info(scope vfilter.Scope, type_map *vfilter.TypeMap) *vfilter.PluginInfo {
 	return &vfilter.PluginInfo{
-		Name:     "upload_transactions",
-		Doc:      "View the outstanding transactions for uploads.",
-		ArgType:  type_map.AddType(scope, &UploadTransactionsPluginArgs{}),
-		Metadata: vql_subsystem.VQLMetadata().Permissions(acls.READ_RESULTS).Build(),
+		Name:    "upload_transactions",
+		Doc:     "View the outstanding transactions for uploads.",
+		ArgType: type_map.AddType(scope, &UploadTransactionsPluginArgs{}),
+		Metadata: vql_subsystem.VQLMetadata().Permissions(acls.READ_RESULTS).
+			RowType(&actions_proto.UploadTransaction{}).Build(),
 	}
 }
 
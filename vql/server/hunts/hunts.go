@@ -130,11 +130,12 @@ func (self HuntsPlugin) Call(
 
 func (self HuntsPlugin) Info(scope vfilter.Scope, type_map *vfilter.TypeMap) *vfilter.PluginInfo {
 	return &vfilter.PluginInfo{
-		Name:     "hunts",
-		Doc:      "Retrieve the list of hunts.",
-		ArgType:  type_map.AddType(scope, &HuntsPluginArgs{}),
-		Metadata: vql_subsystem.VQLMetadata().Permissions(acls.READ_RESULTS).Build(),
-		Version:  2,
+		Name:    "hunts",
+		Doc:     "Retrieve the list of hunts.",
+		ArgType: type_map.AddType(scope, &HuntsPluginArgs{}),
+		Metadata: vql_subsystem.VQLMetadata().Permissions(acls.READ_RESULTS).
+			RowType(&api_proto.Hunt{}).Build(),
+		Version: 2,
 	}
 }
 
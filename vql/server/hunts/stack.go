@@ -0,0 +1,119 @@
+package hunts
+
+import (
+	"context"
+	"sort"
+
+	"github.com/Velocidex/ordereddict"
+	"www.velocidex.com/golang/velociraptor/acls"
+	"www.velocidex.com/golang/velociraptor/services"
+	"www.velocidex.com/golang/velociraptor/services/hunt_manager"
+	vql_subsystem "www.velocidex.com/golang/velociraptor/vql"
+	"www.velocidex.com/golang/vfilter"
+	"www.velocidex.com/golang/vfilter/arg_parser"
+)
+
+type HuntStackPluginArgs struct {
+	HuntId   string `vfilter:"required,field=hunt_id,doc=The hunt id to read."`
+	Artifact string `vfilter:"required,field=artifact,doc=The artifact to stack"`
+	Source   string `vfilter:"optional,field=source,doc=An optional source within the artifact."`
+	Column   string `vfilter:"required,field=column,doc=The column to stack (must be declared in the source's stack_columns)"`
+	Limit    int64  `vfilter:"optional,field=limit,doc=Maximum number of client ids to return for each value (default 100)"`
+}
+
+type HuntStackPlugin struct{}
+
+func (self HuntStackPlugin) Call(
+	ctx context.Context,
+	scope vfilter.Scope,
+	args *ordereddict.Dict) <-chan vfilter.Row {
+	output_chan := make(chan vfilter.Row)
+
+	go func() {
+		defer close(output_chan)
+		defer vql_subsystem.RegisterMonitor(ctx, "hunt_stack", args)()
+
+		err := vql_subsystem.CheckAccess(scope, acls.READ_RESULTS)
+		if err != nil {
+			scope.Log("hunt_stack: %s", err)
+			return
+		}
+
+		arg := &HuntStackPluginArgs{}
+		err = arg_parser.ExtractArgsWithContext(ctx, scope, args, arg)
+		if err != nil {
+			scope.Log("hunt_stack: %v", err)
+			return
+		}
+
+		err = services.RequireFrontend()
+		if err != nil {
+			scope.Log("hunt_stack: %v", err)
+			return
+		}
+
+		config_obj, ok := vql_subsystem.GetServerConfig(scope)
+		if !ok {
+			scope.Log("hunt_stack: Command can only run on the server")
+			return
+		}
+
+		if arg.Source != "" {
+			arg.Artifact += "/" + arg.Source
+		}
+
+		if arg.Limit == 0 {
+			arg.Limit = 100
+		}
+
+		stack, err := hunt_manager.ReadHuntStack(ctx, config_obj,
+			arg.HuntId, arg.Artifact, arg.Column)
+		if err != nil {
+			scope.Log("hunt_stack: No stacking index for column %v of %v in %v",
+				arg.Column, arg.Artifact, arg.HuntId)
+			return
+		}
+
+		// Most common values first.
+		sort.SliceStable(stack.Values, func(i, j int) bool {
+			a, b := stack.Values[i], stack.Values[j]
+			if len(a.ClientIds) != len(b.ClientIds) {
+				return len(a.ClientIds) > len(b.ClientIds)
+			}
+			return a.Count > b.Count
+		})
+
+		for _, value := range stack.Values {
+			client_ids := value.ClientIds
+			if arg.Limit > 0 && int64(len(client_ids)) > arg.Limit {
+				client_ids = client_ids[:arg.Limit]
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case output_chan <- ordereddict.NewDict().
+				Set("Value", value.Value).
+				Set("Hosts", len(value.ClientIds)).
+				Set("Count", value.Count).
+				Set("ClientIds", client_ids):
+			}
+		}
+	}()
+
+	return output_chan
+}
+
+func (self HuntStackPlugin) Info(
+	scope vfilter.Scope, type_map *vfilter.TypeMap) *vfilter.PluginInfo {
+	return &vfilter.PluginInfo{
+		Name:     "hunt_stack",
+		Doc:      "Count the distinct values of a column across all clients in a hunt.",
+		ArgType:  type_map.AddType(scope, &HuntStackPluginArgs{}),
+		Metadata: vql_subsystem.VQLMetadata().Permissions(acls.READ_RESULTS).Build(),
+	}
+}
+
+func init() {
+	vql_subsystem.RegisterPlugin(&HuntStackPlugin{})
+}
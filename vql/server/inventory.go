@@ -258,9 +258,10 @@ func (self InventoryPlugin) Call(
 
 func (self InventoryPlugin) Info(scope vfilter.Scope, type_map *vfilter.TypeMap) *vfilter.PluginInfo {
 	return &vfilter.PluginInfo{
-		Name:    "inventory",
-		Doc:     "Retrieve the tools inventory.",
-		ArgType: type_map.AddType(scope, &InventoryPluginArgs{}),
+		Name:     "inventory",
+		Doc:      "Retrieve the tools inventory.",
+		ArgType:  type_map.AddType(scope, &InventoryPluginArgs{}),
+		Metadata: vql_subsystem.VQLMetadata().RowType(&artifacts_proto.Tool{}).Build(),
 	}
 }
 
@@ -6,6 +6,7 @@ import (
 
 	"github.com/Velocidex/ordereddict"
 	"www.velocidex.com/golang/velociraptor/acls"
+	api_proto "www.velocidex.com/golang/velociraptor/api/proto"
 	"www.velocidex.com/golang/velociraptor/json"
 	"www.velocidex.com/golang/velociraptor/services"
 	vql_subsystem "www.velocidex.com/golang/velociraptor/vql"
@@ -101,10 +102,11 @@ func (self *ListNotebookPlugin) Call(ctx context.Context,
 func (self ListNotebookPlugin) Info(
 	scope vfilter.Scope, type_map *vfilter.TypeMap) *vfilter.PluginInfo {
 	return &vfilter.PluginInfo{
-		Name:     "notebooks",
-		Doc:      "List all notebooks",
-		ArgType:  type_map.AddType(scope, &ListNotebookArgs{}),
-		Metadata: vql_subsystem.VQLMetadata().Permissions(acls.SERVER_ADMIN, acls.READ_RESULTS).Build(),
+		Name:    "notebooks",
+		Doc:     "List all notebooks",
+		ArgType: type_map.AddType(scope, &ListNotebookArgs{}),
+		Metadata: vql_subsystem.VQLMetadata().Permissions(acls.SERVER_ADMIN, acls.READ_RESULTS).
+			RowType(&api_proto.NotebookMetadata{}).Build(),
 	}
 }
 
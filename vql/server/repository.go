@@ -390,10 +390,11 @@ func (self ArtifactsPlugin) Call(
 
 func (self ArtifactsPlugin) Info(scope vfilter.Scope, type_map *vfilter.TypeMap) *vfilter.PluginInfo {
 	return &vfilter.PluginInfo{
-		Name:     "artifact_definitions",
-		Doc:      "Dump artifact definitions.",
-		ArgType:  type_map.AddType(scope, &ArtifactsPluginArgs{}),
-		Metadata: vql_subsystem.VQLMetadata().Permissions(acls.READ_RESULTS).Build(),
+		Name:    "artifact_definitions",
+		Doc:     "Dump artifact definitions.",
+		ArgType: type_map.AddType(scope, &ArtifactsPluginArgs{}),
+		Metadata: vql_subsystem.VQLMetadata().Permissions(acls.READ_RESULTS).
+			RowType(&artifacts_proto.Artifact{}).Build(),
 	}
 }
 
@@ -8,6 +8,7 @@ import (
 	"www.velocidex.com/golang/velociraptor/acls"
 	"www.velocidex.com/golang/velociraptor/json"
 	"www.velocidex.com/golang/velociraptor/services"
+	timelines_proto "www.velocidex.com/golang/velociraptor/timelines/proto"
 	"www.velocidex.com/golang/velociraptor/utils"
 	vql_subsystem "www.velocidex.com/golang/velociraptor/vql"
 	"www.velocidex.com/golang/velociraptor/vql/functions"
@@ -195,10 +196,11 @@ func (self TimelineListPlugin) Call(
 
 func (self TimelineListPlugin) Info(scope vfilter.Scope, type_map *vfilter.TypeMap) *vfilter.PluginInfo {
 	return &vfilter.PluginInfo{
-		Name:     "timelines",
-		Doc:      "List all timelines in a notebook",
-		ArgType:  type_map.AddType(scope, &TimelineListPluginArgs{}),
-		Metadata: vql_subsystem.VQLMetadata().Permissions(acls.READ_RESULTS).Build(),
+		Name:    "timelines",
+		Doc:     "List all timelines in a notebook",
+		ArgType: type_map.AddType(scope, &TimelineListPluginArgs{}),
+		Metadata: vql_subsystem.VQLMetadata().Permissions(acls.READ_RESULTS).
+			RowType(&timelines_proto.SuperTimeline{}).Build(),
 	}
 }
 
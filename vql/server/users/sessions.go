@@ -0,0 +1,152 @@
+package users
+
+import (
+	"context"
+	"time"
+
+	"github.com/Velocidex/ordereddict"
+	api_proto "www.velocidex.com/golang/velociraptor/api/proto"
+	"www.velocidex.com/golang/velociraptor/services"
+	vql_subsystem "www.velocidex.com/golang/velociraptor/vql"
+	"www.velocidex.com/golang/vfilter"
+	"www.velocidex.com/golang/vfilter/arg_parser"
+)
+
+type GUISessionsPluginArgs struct {
+	Username string `vfilter:"optional,field=user,doc=Only show sessions of this user (default all users)."`
+}
+
+type GUISessionsPlugin struct{}
+
+func (self GUISessionsPlugin) Call(
+	ctx context.Context,
+	scope vfilter.Scope,
+	args *ordereddict.Dict) <-chan vfilter.Row {
+	output_chan := make(chan vfilter.Row)
+
+	go func() {
+		defer close(output_chan)
+		defer vql_subsystem.RegisterMonitor(ctx, "gui_sessions", args)()
+
+		// Access checks are done by the users module.
+
+		arg := &GUISessionsPluginArgs{}
+		err := arg_parser.ExtractArgsWithContext(ctx, scope, args, arg)
+		if err != nil {
+			scope.Log("gui_sessions: %v", err)
+			return
+		}
+
+		err = services.RequireFrontend()
+		if err != nil {
+			scope.Log("gui_sessions: %v", err)
+			return
+		}
+
+		principal := vql_subsystem.GetPrincipal(scope)
+		users_manager := services.GetUserManager()
+		sessions, err := users_manager.ListGUISessions(ctx, principal, arg.Username)
+		if err != nil {
+			scope.Log("gui_sessions: %v", err)
+			return
+		}
+
+		for _, session := range sessions {
+			select {
+			case <-ctx.Done():
+				return
+			case output_chan <- sessionToDict(session):
+			}
+		}
+	}()
+
+	return output_chan
+}
+
+func (self GUISessionsPlugin) Info(scope vfilter.Scope, type_map *vfilter.TypeMap) *vfilter.PluginInfo {
+	return &vfilter.PluginInfo{
+		Name:    "gui_sessions",
+		Doc:     "Retrieve the GUI sessions of users on the server.",
+		ArgType: type_map.AddType(scope, &GUISessionsPluginArgs{}),
+	}
+}
+
+func sessionToDict(session *api_proto.GUISession) *ordereddict.Dict {
+	unix := func(t uint64) vfilter.Any {
+		if t == 0 {
+			return vfilter.Null{}
+		}
+		return time.Unix(int64(t), 0).UTC()
+	}
+
+	return ordereddict.NewDict().
+		Set("session_id", session.SessionId).
+		Set("user", session.Username).
+		Set("authenticator", session.Authenticator).
+		Set("login_time", unix(session.LoginTime)).
+		Set("login_from", session.LoginFrom).
+		Set("last_active", unix(session.LastActive)).
+		Set("last_active_from", session.LastActiveFrom).
+		Set("user_agent", session.UserAgent).
+		Set("expires", unix(session.Expires)).
+		Set("revoked", session.Revoked).
+		Set("revoked_time", unix(session.RevokedTime)).
+		Set("revoked_by", session.RevokedBy).
+		Set("revoked_reason", session.RevokedReason)
+}
+
+type GUISessionRevokeFunctionArgs struct {
+	Username  string `vfilter:"required,field=user,doc=The user whose session to revoke."`
+	SessionId string `vfilter:"optional,field=session_id,doc=The session to revoke (default all the user's sessions)."`
+	Reason    string `vfilter:"optional,field=reason,doc=Why the session is revoked."`
+}
+
+type GUISessionRevokeFunction struct{}
+
+func (self GUISessionRevokeFunction) Call(
+	ctx context.Context,
+	scope vfilter.Scope,
+	args *ordereddict.Dict) vfilter.Any {
+
+	err := services.RequireFrontend()
+	if err != nil {
+		scope.Log("gui_session_revoke: %v", err)
+		return vfilter.Null{}
+	}
+
+	// ACLs are checked by the users module
+	arg := &GUISessionRevokeFunctionArgs{}
+	err = arg_parser.ExtractArgsWithContext(ctx, scope, args, arg)
+	if err != nil {
+		scope.Log("gui_session_revoke: %v", err)
+		return vfilter.Null{}
+	}
+
+	if arg.Reason == "" {
+		arg.Reason = "Forced logout"
+	}
+
+	principal := vql_subsystem.GetPrincipal(scope)
+	users_manager := services.GetUserManager()
+	err = users_manager.RevokeGUISession(ctx, principal,
+		arg.Username, arg.SessionId, arg.Reason)
+	if err != nil {
+		scope.Log("gui_session_revoke: %v", err)
+		return vfilter.Null{}
+	}
+
+	return arg.Username
+}
+
+func (self GUISessionRevokeFunction) Info(scope vfilter.Scope, type_map *vfilter.TypeMap) *vfilter.FunctionInfo {
+	return &vfilter.FunctionInfo{
+		Name:    "gui_session_revoke",
+		Doc:     "Revoke a user's GUI sessions, forcing them to log in again.",
+		ArgType: type_map.AddType(scope, &GUISessionRevokeFunctionArgs{}),
+	}
+}
+
+func init() {
+	vql_subsystem.RegisterPlugin(&GUISessionsPlugin{})
+	vql_subsystem.RegisterFunction(&GUISessionRevokeFunction{})
+}
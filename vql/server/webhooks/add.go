@@ -0,0 +1,99 @@
+package webhooks
+
+import (
+	"context"
+
+	"github.com/Velocidex/ordereddict"
+	"www.velocidex.com/golang/velociraptor/acls"
+	api_proto "www.velocidex.com/golang/velociraptor/api/proto"
+	"www.velocidex.com/golang/velociraptor/json"
+	"www.velocidex.com/golang/velociraptor/services"
+	vql_subsystem "www.velocidex.com/golang/velociraptor/vql"
+	"www.velocidex.com/golang/vfilter"
+	"www.velocidex.com/golang/vfilter/arg_parser"
+)
+
+type AddWebhookFunctionArgs struct {
+	Name          string   `vfilter:"required,field=name,doc=Name of the webhook"`
+	Url           string   `vfilter:"required,field=url,doc=The url to POST events to"`
+	Events        []string `vfilter:"required,field=events,doc=The events to deliver (FLOW_COMPLETED, HUNT_STATE_CHANGED, ALERT, CLIENT_ENROLLED)"`
+	ArtifactRegex string   `vfilter:"optional,field=artifact_regex,doc=Only deliver flow completions and alerts from matching artifacts"`
+	Template      string   `vfilter:"optional,field=template,doc=A Go template to render the request body (default JSON)"`
+	ContentType   string   `vfilter:"optional,field=content_type,doc=The content type of the request body (default application/json)"`
+	Secret        string   `vfilter:"optional,field=secret,doc=The name of a Webhook Secrets secret with the HMAC key and extra headers"`
+	MaxRetries    int64    `vfilter:"optional,field=max_retries,doc=How many times to retry failed deliveries (default 5)"`
+	Disabled      bool     `vfilter:"optional,field=disabled,doc=Register the webhook but do not deliver events"`
+}
+
+type AddWebhookFunction struct{}
+
+func (self *AddWebhookFunction) Call(ctx context.Context,
+	scope vfilter.Scope,
+	args *ordereddict.Dict) vfilter.Any {
+
+	err := vql_subsystem.CheckAccess(scope, acls.SERVER_ADMIN)
+	if err != nil {
+		scope.Log("webhook_add: %v", err)
+		return vfilter.Null{}
+	}
+
+	arg := &AddWebhookFunctionArgs{}
+	err = arg_parser.ExtractArgsWithContext(ctx, scope, args, arg)
+	if err != nil {
+		scope.Log("webhook_add: %v", err)
+		return vfilter.Null{}
+	}
+
+	err = services.RequireFrontend()
+	if err != nil {
+		scope.Log("webhook_add: %v", err)
+		return vfilter.Null{}
+	}
+
+	org_config_obj, ok := vql_subsystem.GetServerConfig(scope)
+	if !ok {
+		scope.Log("webhook_add: Command can only run on the server")
+		return vfilter.Null{}
+	}
+
+	webhook_manager, err := services.GetWebhookManager(org_config_obj)
+	if err != nil {
+		scope.Log("webhook_add: %v", err)
+		return vfilter.Null{}
+	}
+
+	webhook := &api_proto.Webhook{
+		Name:          arg.Name,
+		Url:           arg.Url,
+		Events:        arg.Events,
+		ArtifactRegex: arg.ArtifactRegex,
+		Template:      arg.Template,
+		ContentType:   arg.ContentType,
+		Secret:        arg.Secret,
+		MaxRetries:    arg.MaxRetries,
+		Disabled:      arg.Disabled,
+	}
+
+	principal := vql_subsystem.GetPrincipal(scope)
+	err = webhook_manager.SetWebhook(ctx, principal, webhook)
+	if err != nil {
+		scope.Log("webhook_add: %v", err)
+		return vfilter.Null{}
+	}
+
+	return json.ConvertProtoToOrderedDict(webhook)
+}
+
+func (self AddWebhookFunction) Info(
+	scope vfilter.Scope, type_map *vfilter.TypeMap) *vfilter.FunctionInfo {
+	return &vfilter.FunctionInfo{
+		Name:     "webhook_add",
+		Doc:      "Add or replace an outbound webhook.",
+		ArgType:  type_map.AddType(scope, &AddWebhookFunctionArgs{}),
+		Metadata: vql_subsystem.VQLMetadata().Permissions(acls.SERVER_ADMIN).Build(),
+	}
+}
+
+func init() {
+	vql_subsystem.RegisterFunction(&AddWebhookFunction{})
+}
@@ -0,0 +1,77 @@
+package webhooks
+
+import (
+	"context"
+
+	"github.com/Velocidex/ordereddict"
+	"www.velocidex.com/golang/velociraptor/acls"
+	"www.velocidex.com/golang/velociraptor/services"
+	vql_subsystem "www.velocidex.com/golang/velociraptor/vql"
+	"www.velocidex.com/golang/vfilter"
+	"www.velocidex.com/golang/vfilter/arg_parser"
+)
+
+type DeleteWebhookFunctionArgs struct {
+	Name string `vfilter:"required,field=name,doc=Name of the webhook to delete"`
+}
+
+type DeleteWebhookFunction struct{}
+
+func (self *DeleteWebhookFunction) Call(ctx context.Context,
+	scope vfilter.Scope,
+	args *ordereddict.Dict) vfilter.Any {
+
+	err := vql_subsystem.CheckAccess(scope, acls.SERVER_ADMIN)
+	if err != nil {
+		scope.Log("webhook_delete: %v", err)
+		return vfilter.Null{}
+	}
+
+	arg := &DeleteWebhookFunctionArgs{}
+	err = arg_parser.ExtractArgsWithContext(ctx, scope, args, arg)
+	if err != nil {
+		scope.Log("webhook_delete: %v", err)
+		return vfilter.Null{}
+	}
+
+	err = services.RequireFrontend()
+	if err != nil {
+		scope.Log("webhook_delete: %v", err)
+		return vfilter.Null{}
+	}
+
+	org_config_obj, ok := vql_subsystem.GetServerConfig(scope)
+	if !ok {
+		scope.Log("webhook_delete: Command can only run on the server")
+		return vfilter.Null{}
+	}
+
+	webhook_manager, err := services.GetWebhookManager(org_config_obj)
+	if err != nil {
+		scope.Log("webhook_delete: %v", err)
+		return vfilter.Null{}
+	}
+
+	principal := vql_subsystem.GetPrincipal(scope)
+	err = webhook_manager.DeleteWebhook(ctx, principal, arg.Name)
+	if err != nil {
+		scope.Log("webhook_delete: %v", err)
+		return vfilter.Null{}
+	}
+
+	return arg.Name
+}
+
+func (self DeleteWebhookFunction) Info(
+	scope vfilter.Scope, type_map *vfilter.TypeMap) *vfilter.FunctionInfo {
+	return &vfilter.FunctionInfo{
+		Name:     "webhook_delete",
+		Doc:      "Delete an outbound webhook.",
+		ArgType:  type_map.AddType(scope, &DeleteWebhookFunctionArgs{}),
+		Metadata: vql_subsystem.VQLMetadata().Permissions(acls.SERVER_ADMIN).Build(),
+	}
+}
+
+func init() {
+	vql_subsystem.RegisterFunction(&DeleteWebhookFunction{})
+}
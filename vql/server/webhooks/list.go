@@ -0,0 +1,92 @@
+package webhooks
+
+import (
+	"context"
+
+	"github.com/Velocidex/ordereddict"
+	"www.velocidex.com/golang/velociraptor/acls"
+	api_proto "www.velocidex.com/golang/velociraptor/api/proto"
+	"www.velocidex.com/golang/velociraptor/json"
+	"www.velocidex.com/golang/velociraptor/services"
+	vql_subsystem "www.velocidex.com/golang/velociraptor/vql"
+	"www.velocidex.com/golang/vfilter"
+	"www.velocidex.com/golang/vfilter/arg_parser"
+)
+
+type WebhooksPluginArgs struct {
+}
+
+type WebhooksPlugin struct{}
+
+func (self WebhooksPlugin) Call(
+	ctx context.Context,
+	scope vfilter.Scope,
+	args *ordereddict.Dict) <-chan vfilter.Row {
+	output_chan := make(chan vfilter.Row)
+
+	go func() {
+		defer close(output_chan)
+		defer vql_subsystem.RegisterMonitor(ctx, "webhooks", args)()
+
+		err := vql_subsystem.CheckAccess(scope, acls.SERVER_ADMIN)
+		if err != nil {
+			scope.Log("webhooks: %v", err)
+			return
+		}
+
+		arg := &WebhooksPluginArgs{}
+		err = arg_parser.ExtractArgsWithContext(ctx, scope, args, arg)
+		if err != nil {
+			scope.Log("webhooks: %v", err)
+			return
+		}
+
+		err = services.RequireFrontend()
+		if err != nil {
+			scope.Log("webhooks: %v", err)
+			return
+		}
+
+		org_config_obj, ok := vql_subsystem.GetServerConfig(scope)
+		if !ok {
+			scope.Log("webhooks: Command can only run on the server")
+			return
+		}
+
+		webhook_manager, err := services.GetWebhookManager(org_config_obj)
+		if err != nil {
+			scope.Log("webhooks: %v", err)
+			return
+		}
+
+		webhooks, err := webhook_manager.ListWebhooks(ctx)
+		if err != nil {
+			scope.Log("webhooks: %v", err)
+			return
+		}
+
+		for _, webhook := range webhooks {
+			select {
+			case <-ctx.Done():
+				return
+			case output_chan <- json.ConvertProtoToOrderedDict(webhook):
+			}
+		}
+	}()
+
+	return output_chan
+}
+
+func (self WebhooksPlugin) Info(scope vfilter.Scope, type_map *vfilter.TypeMap) *vfilter.PluginInfo {
+	return &vfilter.PluginInfo{
+		Name:    "webhooks",
+		Doc:     "List the outbound webhooks registered on the server.",
+		ArgType: type_map.AddType(scope, &WebhooksPluginArgs{}),
+		Metadata: vql_subsystem.VQLMetadata().Permissions(acls.SERVER_ADMIN).
+			RowType(&api_proto.Webhook{}).Build(),
+	}
+}
+
+func init() {
+	vql_subsystem.RegisterPlugin(&WebhooksPlugin{})
+}
@@ -0,0 +1,1044 @@
+package sigma
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Velocidex/ordereddict"
+	"github.com/Velocidex/sigma-go"
+	"www.velocidex.com/golang/velociraptor/utils"
+	vql_subsystem "www.velocidex.com/golang/velociraptor/vql"
+	"www.velocidex.com/golang/velociraptor/vql/sigma/evaluator"
+	"www.velocidex.com/golang/velociraptor/vql/sigma/evaluator/modifiers"
+	"www.velocidex.com/golang/vfilter"
+	"www.velocidex.com/golang/vfilter/arg_parser"
+)
+
+/*
+  The Sigma compiler converts a set of Sigma rules into a native
+  CLIENT_EVENT artifact. Instead of evaluating every event against
+  every rule through the sigma() plugin, each rule is translated into
+  a VQL expression and all the rules watching the same log source are
+  combined into a single query:
+
+  ```vql
+  LET SigmaField_EventID(x) = x.System.EventID.Value
+
+  -- Failed logon
+  LET SigmaRule_0(Event) = str(str=SigmaField_EventID(x=Event)) = '4625'
+
+  -- Every rule requires one of these
+  LET SigmaPrefilter(Event) = str(str=SigmaField_EventID(x=Event)) = '4625'
+
+  LET SigmaEvents = SELECT * FROM watch_evtx(filename=...)
+
+  SELECT *, filter(list=[
+      if(condition=SigmaRule_0(Event=scope()), then=dict(Title='Failed logon', ...))
+    ], condition="x=>x") AS _Rules
+  FROM SigmaEvents
+  WHERE SigmaPrefilter(Event=scope()) AND len(list=_Rules) > 0
+  ```
+
+  Each rule is evaluated once per event: the WHERE clause refers to
+  the _Rules column which VQL only evaluates once. Most events can
+  not match any rule so the prefilter drops them early using only the
+  plain EventID, Channel and Provider_Name comparisons the rules
+  require. The prefilter is omitted if any rule does not require one.
+
+  Events are accessed through the scope() function so that fields
+  missing from the event do not generate symbol lookup errors.
+
+  Not every Sigma feature can be compiled. Rules using keywords,
+  correlations, aggregations, VQL lambdas or unsupported modifiers
+  are skipped and listed in the artifact description - such rules
+  should be run with the sigma() plugin instead.
+*/
+
+var (
+	nonIdentRegex = regexp.MustCompile("[^a-zA-Z0-9_]+")
+	simpleIdent   = regexp.MustCompile("^[a-zA-Z_][a-zA-Z0-9_]*$")
+
+	// Plain comparisons of these fields are checked for all the rules
+	// before any rule is evaluated.
+	prefilterFields = []string{"EventID", "Channel", "Provider_Name"}
+)
+
+type SigmaCompilerOptions struct {
+	Name        string
+	Description string
+	Rules       []sigma.Rule
+	Filters     []*evaluator.SigmaFilter
+
+	// Field name -> VQL lambda string
+	FieldMappings *ordereddict.Dict
+
+	// Log source name -> VQL query string
+	LogSources *ordereddict.Dict
+}
+
+type compiledField struct {
+	name      string
+	parameter string
+	body      string
+}
+
+type compiledRule struct {
+	idx    int
+	rule   sigma.Rule
+	expr   string
+	fields []string
+
+	// A cheap condition every event matching the rule meets, or ""
+	// if there is none.
+	prefilter string
+}
+
+type compiledLogSource struct {
+	names []string
+	query string
+	rules []*compiledRule
+}
+
+type sigmaCompiler struct {
+	ctx   context.Context
+	scope vfilter.Scope
+
+	// Field name -> the LET function that extracts it.
+	field_mappings map[string]*compiledField
+	field_names    map[string]bool
+
+	// The fields referenced by the rule currently being compiled.
+	current_fields []string
+}
+
+// Compile the rules into a CLIENT_EVENT artifact. Returns the
+// artifact YAML and a list of rules that could not be compiled.
+func CompileSigmaArtifact(
+	ctx context.Context, scope vfilter.Scope,
+	options SigmaCompilerOptions) (string, []string, error) {
+
+	if options.LogSources == nil || options.LogSources.Len() == 0 {
+		return "", nil, fmt.Errorf("No log sources provided")
+	}
+
+	self := &sigmaCompiler{
+		ctx:            ctx,
+		scope:          scope,
+		field_mappings: make(map[string]*compiledField),
+		field_names:    make(map[string]bool),
+	}
+
+	err := self.compileFieldMappings(options.FieldMappings)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// Log sources with the same query are combined into a single
+	// source so the events are only read once.
+	var sources []*compiledLogSource
+	by_query := make(map[string]*compiledLogSource)
+	for _, item := range options.LogSources.Items() {
+		query, ok := item.Value.(string)
+		if !ok {
+			return "", nil, fmt.Errorf(
+				"Log source %v should be a VQL query string, not %T",
+				item.Key, item.Value)
+		}
+		query = strings.TrimSpace(query)
+
+		// The log source is stored in a LET so must be a single
+		// SELECT statement.
+		vqls, err := vfilter.MultiParse(query)
+		if err != nil {
+			return "", nil, fmt.Errorf("Log source %v: %w", item.Key, err)
+		}
+
+		if len(vqls) != 1 || vqls[0].Let != "" {
+			return "", nil, fmt.Errorf(
+				"Log source %v: should be a single SELECT query", item.Key)
+		}
+
+		source, pres := by_query[query]
+		if !pres {
+			source = &compiledLogSource{query: query}
+			by_query[query] = source
+			sources = append(sources, source)
+		}
+		source.names = append(source.names, item.Key)
+	}
+
+	var skipped []string
+	total := 0
+	for idx, rule := range options.Rules {
+		compiled, err := self.compileRule(idx, rule, options.Filters)
+		if err != nil {
+			skipped = append(skipped, fmt.Sprintf("%v: %v", rule.Title, err))
+			continue
+		}
+
+		matched := false
+		for _, source := range sources {
+			for _, name := range source.names {
+				if matchLogSource(parseLogSourceTarget(name), rule) {
+					source.rules = append(source.rules, compiled)
+					matched = true
+					break
+				}
+			}
+		}
+
+		if !matched {
+			skipped = append(skipped, fmt.Sprintf(
+				"%v: No log source for %v", rule.Title,
+				logSourceName(rule.Logsource)))
+			continue
+		}
+		total++
+	}
+
+	return self.formatArtifact(options, sources, total, skipped), skipped, nil
+}
+
+func (self *sigmaCompiler) compileFieldMappings(mappings *ordereddict.Dict) error {
+	if mappings == nil {
+		return nil
+	}
+
+	for _, item := range mappings.Items() {
+		lambda_str, ok := item.Value.(string)
+		if !ok {
+			return fmt.Errorf("fieldmapping for %s should be string, got(%T)",
+				item.Key, item.Value)
+		}
+
+		lambda, err := vfilter.ParseLambda(lambda_str)
+		if err != nil {
+			return fmt.Errorf("fieldmapping for %s is not a valid VQL Lambda: %v",
+				item.Key, err)
+		}
+
+		parameters := lambda.GetParameters()
+		if len(parameters) != 1 {
+			return fmt.Errorf("fieldmapping for %s should take one parameter",
+				item.Key)
+		}
+
+		// The lambda was parsed already so it definitely has a =>
+		parts := strings.SplitN(lambda_str, "=>", 2)
+		self.field_mappings[item.Key] = &compiledField{
+			name:      self.uniqueFieldName(item.Key),
+			parameter: parameters[0],
+			body:      strings.TrimSpace(parts[1]),
+		}
+	}
+	return nil
+}
+
+func (self *sigmaCompiler) uniqueFieldName(field string) string {
+	base := "SigmaField_" + strings.Trim(
+		nonIdentRegex.ReplaceAllString(field, "_"), "_")
+	name := base
+	for i := 1; self.field_names[strings.ToLower(name)]; i++ {
+		name = fmt.Sprintf("%v_%v", base, i)
+	}
+	self.field_names[strings.ToLower(name)] = true
+	return name
+}
+
+func (self *sigmaCompiler) compileRule(
+	idx int, rule sigma.Rule,
+	filters []*evaluator.SigmaFilter) (*compiledRule, error) {
+	self.current_fields = nil
+
+	if rule.Correlation != nil {
+		return nil, fmt.Errorf("Correlation rules are not supported")
+	}
+
+	if rule.Logsource.Category == "" &&
+		rule.Logsource.Product == "" &&
+		rule.Logsource.Service == "" {
+		return nil, fmt.Errorf("No logsource specified")
+	}
+
+	for _, field := range []string{"vql", "vql_args", "enrichment"} {
+		_, pres := rule.AdditionalFields[field]
+		if pres {
+			return nil, fmt.Errorf("Rules with %v are not supported", field)
+		}
+	}
+
+	expr, prefilter, err := self.compileDetection(rule.Detection)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, filter := range filters {
+		if !filter.AppliesTo(rule) {
+			continue
+		}
+
+		filter_expr, _, err := self.compileDetection(filter.Detection)
+		if err != nil {
+			return nil, fmt.Errorf("Filter %v: %w", filter.Title, err)
+		}
+		expr = fmt.Sprintf("(%v) AND (%v)", expr, filter_expr)
+	}
+
+	return &compiledRule{
+		idx:       idx,
+		rule:      rule,
+		expr:      expr,
+		fields:    self.current_fields,
+		prefilter: prefilter,
+	}, nil
+}
+
+// Returns the expression for the detection and its prefilter.
+func (self *sigmaCompiler) compileDetection(
+	detection sigma.Detection) (string, string, error) {
+	if detection.Timeframe != "" {
+		return "", "", fmt.Errorf("Timeframe detections not supported")
+	}
+
+	names := make([]string, 0, len(detection.Searches))
+	for name := range detection.Searches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	searches := make(map[string]string)
+	prefilters := make(map[string]string)
+	for _, name := range names {
+		expr, prefilter, err := self.compileSearch(detection.Searches[name])
+		if err != nil {
+			return "", "", fmt.Errorf("Search %v: %w", name, err)
+		}
+		searches[name] = expr
+		prefilters[name] = prefilter
+	}
+
+	// A detection without a condition requires all the searches.
+	conditions := detection.Conditions
+	if len(conditions) == 0 {
+		conditions = sigma.Conditions{{Search: sigma.AllOfThem{}}}
+	}
+
+	var result, result_prefilters []string
+	for _, condition := range conditions {
+		if condition.Aggregation != nil {
+			return "", "", fmt.Errorf("Aggregations are not supported")
+		}
+
+		expr, err := self.compileCondition(condition.Search, names, searches)
+		if err != nil {
+			return "", "", err
+		}
+		result = append(result, expr)
+		result_prefilters = append(result_prefilters,
+			prefilterCondition(condition.Search, names, prefilters))
+	}
+
+	return joinExpr(result, "OR"), anyPrefilter(result_prefilters), nil
+}
+
+func (self *sigmaCompiler) compileCondition(
+	search sigma.SearchExpr,
+	names []string, searches map[string]string) (string, error) {
+
+	// Collect the compiled searches for all the names matching the
+	// pattern.
+	matching := func(pattern string) []string {
+		var result []string
+		for _, name := range names {
+			matches, _ := path.Match(pattern, name)
+			if matches {
+				result = append(result, searches[name])
+			}
+		}
+		return result
+	}
+
+	switch s := search.(type) {
+	case sigma.And, sigma.Or:
+		var nodes []sigma.SearchExpr
+		op := "AND"
+		if and, ok := s.(sigma.And); ok {
+			nodes = and
+		} else {
+			nodes = s.(sigma.Or)
+			op = "OR"
+		}
+
+		var result []string
+		for _, node := range nodes {
+			expr, err := self.compileCondition(node, names, searches)
+			if err != nil {
+				return "", err
+			}
+			result = append(result, expr)
+		}
+		return joinExpr(result, op), nil
+
+	case sigma.Not:
+		expr, err := self.compileCondition(s.Expr, names, searches)
+		if err != nil {
+			return "", err
+		}
+		return "NOT (" + expr + ")", nil
+
+	case sigma.SearchIdentifier:
+		expr, pres := searches[s.Name]
+		if !pres {
+			return "", fmt.Errorf("Condition references unknown search %v", s.Name)
+		}
+		return expr, nil
+
+	case sigma.OneOfIdentifier:
+		return self.compileCondition(s.Ident, names, searches)
+
+	case sigma.AllOfIdentifier:
+		return self.compileCondition(s.Ident, names, searches)
+
+	case sigma.OneOfThem:
+		return joinExprOr(matching("*"), "OR", "FALSE"), nil
+
+	case sigma.AllOfThem:
+		return joinExprOr(matching("*"), "AND", "TRUE"), nil
+
+	case sigma.OneOfPattern:
+		return joinExprOr(matching(s.Pattern), "OR", "FALSE"), nil
+
+	case sigma.AllOfPattern:
+		return joinExprOr(matching(s.Pattern), "AND", "TRUE"), nil
+	}
+
+	return "", fmt.Errorf("Unsupported condition %T", search)
+}
+
+// Derive a condition from the prefilters of the searches which every
+// event matching the condition meets. Negated searches can not
+// contribute to it.
+func prefilterCondition(
+	search sigma.SearchExpr,
+	names []string, prefilters map[string]string) string {
+
+	matching := func(pattern string) []string {
+		var result []string
+		for _, name := range names {
+			matches, _ := path.Match(pattern, name)
+			if matches {
+				result = append(result, prefilters[name])
+			}
+		}
+		return result
+	}
+
+	switch s := search.(type) {
+	case sigma.And:
+		var result []string
+		for _, node := range s {
+			result = append(result, prefilterCondition(node, names, prefilters))
+		}
+		return allPrefilters(result)
+
+	case sigma.Or:
+		var result []string
+		for _, node := range s {
+			result = append(result, prefilterCondition(node, names, prefilters))
+		}
+		return anyPrefilter(result)
+
+	case sigma.SearchIdentifier:
+		return prefilters[s.Name]
+
+	case sigma.OneOfIdentifier:
+		return prefilterCondition(s.Ident, names, prefilters)
+
+	case sigma.AllOfIdentifier:
+		return prefilterCondition(s.Ident, names, prefilters)
+
+	case sigma.OneOfThem:
+		return anyPrefilter(matching("*"))
+
+	case sigma.AllOfThem:
+		return allPrefilters(matching("*"))
+
+	case sigma.OneOfPattern:
+		return anyPrefilter(matching(s.Pattern))
+
+	case sigma.AllOfPattern:
+		return allPrefilters(matching(s.Pattern))
+	}
+
+	return ""
+}
+
+// Any of the prefilters must match. An empty prefilter matches
+// everything so makes the others redundant.
+func anyPrefilter(prefilters []string) string {
+	var result []string
+	for _, prefilter := range prefilters {
+		if prefilter == "" {
+			return ""
+		}
+		if !utils.InString(result, prefilter) {
+			result = append(result, prefilter)
+		}
+	}
+	return joinExprOr(result, "OR", "")
+}
+
+// All the prefilters must match. Empty prefilters are ignored.
+func allPrefilters(prefilters []string) string {
+	var result []string
+	for _, prefilter := range prefilters {
+		if prefilter != "" && !utils.InString(result, prefilter) {
+			result = append(result, prefilter)
+		}
+	}
+	return joinExprOr(result, "AND", "")
+}
+
+// Returns the expression for the search and its prefilter.
+func (self *sigmaCompiler) compileSearch(search sigma.Search) (string, string, error) {
+	if len(search.Keywords) > 0 {
+		return "", "", fmt.Errorf("Keyword searches are not supported")
+	}
+
+	// degenerate case (but common for logsource conditions)
+	if len(search.EventMatchers) == 0 {
+		return "TRUE", "", nil
+	}
+
+	// All fields must match for an EventMatcher to match, but only
+	// one EventMatcher needs to match for the search to match.
+	var matchers, prefilters []string
+	for _, event_matcher := range search.EventMatchers {
+		var fields, field_prefilters []string
+		for _, field_matcher := range event_matcher {
+			expr, err := self.compileFieldMatcher(field_matcher)
+			if err != nil {
+				return "", "", err
+			}
+			fields = append(fields, expr)
+
+			if len(field_matcher.Modifiers) == 0 &&
+				utils.InString(prefilterFields, field_matcher.Field) {
+				field_prefilters = append(field_prefilters, expr)
+			}
+		}
+		matchers = append(matchers, joinExprOr(fields, "AND", "TRUE"))
+		prefilters = append(prefilters, allPrefilters(field_prefilters))
+	}
+
+	return joinExpr(matchers, "OR"), anyPrefilter(prefilters), nil
+}
+
+func (self *sigmaCompiler) compileFieldMatcher(
+	field_matcher sigma.FieldMatcher) (string, error) {
+	if field_matcher.Field == "" {
+		return "", fmt.Errorf("Field matchers must specify a field")
+	}
+
+	var transforms []modifiers.ValueModifier
+	comparator := ""
+	all := false
+
+	// The sigma() plugin compares the alternatives produced by
+	// base64offset case sensitively.
+	case_sensitive := false
+
+	for _, name := range field_matcher.Modifiers {
+		switch name {
+		case "base64", "base64offset", "windash":
+			if comparator != "" {
+				return "", fmt.Errorf("Modifier %v must precede %v", name, comparator)
+			}
+			transforms = append(transforms, modifiers.ValueModifiers[name])
+			if name == "base64offset" {
+				case_sensitive = true
+			}
+
+		case "contains", "startswith", "endswith", "re", "cidr",
+			"gt", "gte", "lt", "lte":
+			if comparator != "" {
+				return "", fmt.Errorf("Modifier %v can not follow %v", name, comparator)
+			}
+			comparator = name
+
+		case "all":
+			all = true
+
+		default:
+			return "", fmt.Errorf("Modifier %v is not supported", name)
+		}
+	}
+
+	accessor := self.fieldAccessor(field_matcher.Field)
+
+	// Each expected value may expand to a number of alternatives
+	// through the transforming modifiers.
+	var groups [][]interface{}
+	for _, value := range field_matcher.Values {
+		alternatives := []interface{}{value}
+		for _, transform := range transforms {
+			_, expanded, err := transform.Modify(
+				self.ctx, self.scope, nil, alternatives)
+			if err != nil {
+				return "", err
+			}
+			alternatives = flattenAlternatives(expanded)
+		}
+		groups = append(groups, alternatives)
+	}
+
+	// Without the all modifier any value may match, so all the
+	// alternatives can be combined into a single comparison.
+	if !all {
+		var merged []interface{}
+		for _, group := range groups {
+			merged = append(merged, group...)
+		}
+		groups = [][]interface{}{merged}
+	}
+
+	var result []string
+	for _, group := range groups {
+		expr, err := compileComparison(comparator, accessor, group, case_sensitive)
+		if err != nil {
+			return "", err
+		}
+		result = append(result, expr)
+	}
+
+	return joinExprOr(result, "AND", "TRUE"), nil
+}
+
+// Build a VQL expression to extract the field from the event.
+func (self *sigmaCompiler) fieldAccessor(field string) string {
+	if !utils.InString(self.current_fields, field) {
+		self.current_fields = append(self.current_fields, field)
+	}
+
+	mapping, pres := self.field_mappings[field]
+	if pres {
+		return fmt.Sprintf("%v(%v=Event)", mapping.name, mapping.parameter)
+	}
+
+	// Unmapped fields are looked up directly in the event. Dotted
+	// fields refer to nested members.
+	parts := []string{"Event"}
+	for _, part := range strings.Split(field, ".") {
+		parts = append(parts, vqlIdentifier(part))
+	}
+	return strings.Join(parts, ".")
+}
+
+func compileComparison(
+	comparator, accessor string, values []interface{},
+	case_sensitive bool) (string, error) {
+	var result []string
+
+	// Null values match missing fields.
+	var non_null []interface{}
+	for _, value := range values {
+		if utils.IsNil(value) {
+			if comparator != "" {
+				return "", fmt.Errorf("Modifier %v can not match null", comparator)
+			}
+			result = append(result, accessor+" = NULL")
+			continue
+		}
+		non_null = append(non_null, value)
+	}
+
+	if len(non_null) > 0 {
+		expr, err := compileValues(comparator, accessor, non_null, case_sensitive)
+		if err != nil {
+			return "", err
+		}
+		result = append(result, expr)
+	}
+
+	return joinExprOr(result, "OR", "FALSE"), nil
+}
+
+// Plain values are compared case sensitively while the other string
+// comparisons are case insensitive, just like in the sigma() plugin.
+func compileValues(
+	comparator, accessor string, values []interface{},
+	case_sensitive bool) (string, error) {
+
+	// Build a regex alternation of all the values. VQL regular
+	// expressions are case insensitive unless the flag is cleared.
+	alternation := func(quote bool) string {
+		var parts []string
+		for _, v := range values {
+			part := toString(v)
+			if quote {
+				part = regexp.QuoteMeta(part)
+			}
+			parts = append(parts, part)
+		}
+		result := "(?:" + strings.Join(parts, "|") + ")"
+		if case_sensitive {
+			result = "(?-i)" + result
+		}
+		return result
+	}
+
+	switch comparator {
+	case "":
+		// Event logs sometimes encode integers as strings so we
+		// compare the string forms.
+		if len(values) == 1 {
+			return fmt.Sprintf("str(str=%v) = %v",
+				accessor, vqlString(toString(values[0]))), nil
+		}
+
+		var literals []string
+		for _, v := range values {
+			literals = append(literals, vqlString(toString(v)))
+		}
+		return fmt.Sprintf("str(str=%v) IN (%v)",
+			accessor, strings.Join(literals, ", ")), nil
+
+	case "contains":
+		return fmt.Sprintf("%v =~ %v", accessor, vqlString(alternation(true))), nil
+
+	case "startswith":
+		return fmt.Sprintf("%v =~ %v", accessor,
+			vqlString("^"+alternation(true))), nil
+
+	case "endswith":
+		return fmt.Sprintf("%v =~ %v", accessor,
+			vqlString(alternation(true)+"$")), nil
+
+	case "re":
+		for _, v := range values {
+			_, err := regexp.Compile(toString(v))
+			if err != nil {
+				return "", err
+			}
+		}
+		return fmt.Sprintf("%v =~ %v", accessor, vqlString(alternation(false))), nil
+
+	case "cidr":
+		var ranges []string
+		for _, v := range values {
+			ranges = append(ranges, vqlString(toString(v)))
+		}
+		return fmt.Sprintf("cidr_contains(ip=%v, ranges=[%v])",
+			accessor, strings.Join(ranges, ", ")), nil
+
+	case "gt", "gte", "lt", "lte":
+		op := map[string]string{
+			"gt": ">", "gte": ">=", "lt": "<", "lte": "<="}[comparator]
+
+		var result []string
+		for _, v := range values {
+			result = append(result, fmt.Sprintf("%v %v %v",
+				accessor, op, vqlLiteral(v)))
+		}
+		return joinExpr(result, "OR"), nil
+	}
+
+	return "", fmt.Errorf("Modifier %v is not supported", comparator)
+}
+
+func (self *sigmaCompiler) formatArtifact(
+	options SigmaCompilerOptions,
+	sources []*compiledLogSource, total int, skipped []string) string {
+
+	name := options.Name
+	if name == "" {
+		name = "Custom.Sigma.Compiled"
+	}
+
+	description := options.Description
+	if description == "" {
+		description = "Sigma rules compiled into native VQL."
+	}
+
+	description += fmt.Sprintf(
+		"\n\nCompiled %v rules into %v log sources. Each matching event is "+
+			"emitted once with the _Rules column listing the matching rules.\n",
+		total, len(sources))
+
+	if len(skipped) > 0 {
+		description += "\nThe following rules could not be compiled:\n\n"
+		for _, s := range skipped {
+			description += "* " + s + "\n"
+		}
+	}
+
+	out := &strings.Builder{}
+	fmt.Fprintf(out, "name: %v\n", yamlString(name))
+	out.WriteString("description: |\n")
+	out.WriteString(indent(description, "  "))
+	out.WriteString("\ntype: CLIENT_EVENT\n\nsources:\n")
+
+	source_names := make(map[string]bool)
+	for _, source := range sources {
+		if len(source.rules) == 0 {
+			continue
+		}
+
+		source_name := strings.Trim(nonIdentRegex.ReplaceAllString(
+			strings.Join(source.names, "/"), "_"), "_")
+		for i := 1; source_names[source_name]; i++ {
+			source_name = fmt.Sprintf("%v_%v", source_name, i)
+		}
+		source_names[source_name] = true
+
+		fmt.Fprintf(out, "- name: %v\n", yamlString(source_name))
+		fmt.Fprintf(out, "  description: %v\n", yamlString(
+			"Log sources: "+strings.Join(source.names, ", ")))
+		out.WriteString("  query: |\n")
+		out.WriteString(indent(self.formatSourceQuery(source), "    "))
+		out.WriteString("\n")
+	}
+
+	return strings.TrimRight(out.String(), "\n") + "\n"
+}
+
+func (self *sigmaCompiler) formatSourceQuery(source *compiledLogSource) string {
+	out := &strings.Builder{}
+
+	// Only define the fields needed by the rules in this source.
+	var fields []string
+	for _, rule := range source.rules {
+		for _, field := range rule.fields {
+			if !utils.InString(fields, field) {
+				fields = append(fields, field)
+			}
+		}
+	}
+	sort.Strings(fields)
+
+	for _, field := range fields {
+		mapping, pres := self.field_mappings[field]
+		if pres {
+			fmt.Fprintf(out, "LET %v(%v) = %v\n",
+				mapping.name, mapping.parameter, mapping.body)
+		}
+	}
+
+	var matches []string
+	for _, rule := range source.rules {
+		rule_name := fmt.Sprintf("SigmaRule_%v", rule.idx)
+		call := rule_name + "(Event=scope())"
+
+		fmt.Fprintf(out, "\n-- %v\nLET %v(Event) = %v\n",
+			strings.Join(strings.Fields(rule.rule.Title), " "),
+			rule_name, rule.expr)
+
+		details := []string{"Title=" + vqlString(rule.rule.Title)}
+		if rule.rule.ID != "" {
+			details = append(details, "Id="+vqlString(rule.rule.ID))
+		}
+		if rule.rule.Level != "" {
+			details = append(details, "Level="+vqlString(rule.rule.Level))
+		}
+
+		matches = append(matches, fmt.Sprintf(
+			"  if(condition=%v, then=dict(%v))",
+			call, strings.Join(details, ", ")))
+	}
+
+	// Events which can not match any rule are dropped by the
+	// prefilter before the rules are evaluated. This is only
+	// possible if every rule has a prefilter.
+	var prefilters []string
+	for _, rule := range source.rules {
+		prefilters = append(prefilters, rule.prefilter)
+	}
+	prefilter := anyPrefilter(prefilters)
+	if prefilter != "" {
+		fmt.Fprintf(out, "\n-- Every rule requires one of these\nLET SigmaPrefilter(Event) = %v\n",
+			prefilter)
+	}
+
+	// Columns are evaluated once per row so the WHERE clause reuses
+	// the _Rules column rather than evaluating the rules again.
+	fmt.Fprintf(out, "\nLET SigmaEvents = %v\n\n", source.query)
+	fmt.Fprintf(out, "SELECT *, filter(list=[\n%v\n], condition=\"x=>x\") AS _Rules\n",
+		strings.Join(matches, ",\n"))
+	out.WriteString("FROM SigmaEvents\n")
+	if prefilter != "" {
+		out.WriteString("WHERE SigmaPrefilter(Event=scope()) AND len(list=_Rules) > 0\n")
+	} else {
+		out.WriteString("WHERE len(list=_Rules) > 0\n")
+	}
+
+	return out.String()
+}
+
+// Expected values may be expanded into lists of alternatives by the
+// transforming modifiers.
+func flattenAlternatives(in []interface{}) []interface{} {
+	var result []interface{}
+	for _, item := range in {
+		switch t := item.(type) {
+		case []string:
+			for _, i := range t {
+				result = append(result, i)
+			}
+		default:
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+func joinExpr(expr []string, op string) string {
+	if len(expr) == 1 {
+		return expr[0]
+	}
+	var result []string
+	for _, e := range expr {
+		result = append(result, "("+e+")")
+	}
+	return strings.Join(result, " "+op+" ")
+}
+
+// Join the expressions returning the empty value if there are none.
+func joinExprOr(expr []string, op, empty string) string {
+	if len(expr) == 0 {
+		return empty
+	}
+	return joinExpr(expr, op)
+}
+
+func toString(value interface{}) string {
+	switch t := value.(type) {
+	case string:
+		return t
+	default:
+		return fmt.Sprintf("%v", value)
+	}
+}
+
+func vqlLiteral(value interface{}) string {
+	switch t := value.(type) {
+	case int, int64, uint64, int32, uint32:
+		return fmt.Sprintf("%v", t)
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	case bool:
+		if t {
+			return "TRUE"
+		}
+		return "FALSE"
+	}
+	return vqlString(toString(value))
+}
+
+// Quote a string as a VQL string literal.
+func vqlString(in string) string {
+	replacer := strings.NewReplacer(
+		`\`, `\\`, `'`, `\'`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+	return "'" + replacer.Replace(in) + "'"
+}
+
+func vqlIdentifier(in string) string {
+	if simpleIdent.MatchString(in) {
+		return in
+	}
+	return "`" + strings.ReplaceAll(in, "`", "") + "`"
+}
+
+// YAML accepts JSON strings.
+func yamlString(in string) string {
+	return strconv.Quote(in)
+}
+
+func indent(in, prefix string) string {
+	lines := strings.Split(strings.TrimRight(in, "\n"), "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+type SigmaCompileFunctionArgs struct {
+	Rules         []string          `vfilter:"required,field=rules,doc=A list of sigma rules to compile."`
+	Filters       []string          `vfilter:"optional,field=filters,doc=A list of sigma filter documents to apply to the rules (filters may also be given in rules)."`
+	LogSources    *ordereddict.Dict `vfilter:"required,field=log_sources,doc=A dict mapping log source names (category/product/service) to the VQL query producing their events."`
+	FieldMappings *ordereddict.Dict `vfilter:"optional,field=field_mapping,doc=A dict containing a mapping between a rule field name and a VQL Lambda to get the value of the field from the event."`
+	RuleFilter    *vfilter.Lambda   `vfilter:"optional,field=rule_filter,doc=If specified we use this callback to filter the rules for inclusion."`
+	Name          string            `vfilter:"optional,field=name,doc=The name of the generated artifact (default Custom.Sigma.Compiled)."`
+	Description   string            `vfilter:"optional,field=description,doc=The description of the generated artifact."`
+}
+
+type SigmaCompileFunction struct{}
+
+func (self SigmaCompileFunction) Call(
+	ctx context.Context,
+	scope vfilter.Scope,
+	args *ordereddict.Dict) vfilter.Any {
+	defer vql_subsystem.RegisterMonitor(ctx, "sigma_compile", args)()
+
+	arg := &SigmaCompileFunctionArgs{}
+	err := arg_parser.ExtractArgsWithContext(ctx, scope, args, arg)
+	if err != nil {
+		scope.Log("sigma_compile: %v", err)
+		return vfilter.Null{}
+	}
+
+	rules, filters := parseRulesAndFilters(ctx, scope,
+		append(arg.Rules, arg.Filters...), arg.RuleFilter)
+
+	// Log sources may be given as query strings or stored queries.
+	log_sources := ordereddict.NewDict()
+	for _, item := range arg.LogSources.Items() {
+		switch t := item.Value.(type) {
+		case string:
+			log_sources.Set(item.Key, t)
+		case vfilter.StoredQuery:
+			log_sources.Set(item.Key, vfilter.FormatToString(scope, t))
+		default:
+			scope.Log("sigma_compile: log source %v must be a query", item.Key)
+			return vfilter.Null{}
+		}
+	}
+
+	artifact, skipped, err := CompileSigmaArtifact(ctx, scope,
+		SigmaCompilerOptions{
+			Name:          arg.Name,
+			Description:   arg.Description,
+			Rules:         rules,
+			Filters:       filters,
+			FieldMappings: arg.FieldMappings,
+			LogSources:    log_sources,
+		})
+	if err != nil {
+		scope.Log("sigma_compile: %v", err)
+		return vfilter.Null{}
+	}
+
+	for _, s := range skipped {
+		scope.Log("sigma_compile: Skipped rule %v", s)
+	}
+
+	return artifact
+}
+
+func (self SigmaCompileFunction) Info(scope vfilter.Scope, type_map *vfilter.TypeMap) *vfilter.FunctionInfo {
+	return &vfilter.FunctionInfo{
+		Name:    "sigma_compile",
+		Doc:     "Compile sigma rules into a client event artifact.",
+		ArgType: type_map.AddType(scope, &SigmaCompileFunctionArgs{}),
+	}
+}
+
+func init() {
+	vql_subsystem.RegisterFunction(&SigmaCompileFunction{})
+}
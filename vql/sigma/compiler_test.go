@@ -0,0 +1,92 @@
+package sigma
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Velocidex/ordereddict"
+	"github.com/stretchr/testify/suite"
+	"www.velocidex.com/golang/velociraptor/file_store/test_utils"
+	"www.velocidex.com/golang/velociraptor/services"
+	"www.velocidex.com/golang/velociraptor/services/launcher"
+	vql_subsystem "www.velocidex.com/golang/velociraptor/vql"
+	"www.velocidex.com/golang/velociraptor/vtesting/assert"
+
+	_ "www.velocidex.com/golang/velociraptor/vql/functions"
+	_ "www.velocidex.com/golang/velociraptor/vql/parsers"
+)
+
+const compilerTestRules = `
+title: Failed logon
+id: failed_logon
+level: medium
+logsource:
+   product: windows
+   service: security
+detection:
+   selection:
+     EventID: 4625
+     TargetUserName|startswith:
+       - adm
+       - root
+   condition: selection
+---
+title: Process creation
+logsource:
+   product: windows
+   category: process_creation
+detection:
+   selection:
+     Image|endswith: \whoami.exe
+   condition: selection
+`
+
+type SigmaCompilerTestSuite struct {
+	test_utils.TestSuite
+}
+
+// The compiled artifact must load into the repository and pass the
+// verifier like any other artifact.
+func (self *SigmaCompilerTestSuite) TestCompiledArtifactVerifies() {
+	scope := vql_subsystem.MakeScope()
+	defer scope.Close()
+
+	artifact_yaml := SigmaCompileFunction{}.Call(
+		context.Background(), scope, ordereddict.NewDict().
+			Set("name", "Custom.Sigma.Compiled").
+			Set("rules", []string{compilerTestRules}).
+			Set("field_mapping", ordereddict.NewDict().
+				Set("EventID", "x=>x.System.EventID.Value").
+				Set("TargetUserName", "x=>x.EventData.TargetUserName").
+				Set("Image", "x=>x.EventData.Image")).
+			Set("log_sources", ordereddict.NewDict().
+				Set("*/windows/security", `
+SELECT * FROM watch_evtx(filename="C:/Windows/System32/winevt/Logs/Security.evtx")`).
+				Set("process_creation/windows/*", `
+SELECT * FROM watch_evtx(filename="C:/Windows/System32/winevt/Logs/Microsoft-Windows-Sysmon%4Operational.evtx")`)))
+
+	artifact_str, ok := artifact_yaml.(string)
+	assert.True(self.T(), ok)
+
+	manager, err := services.GetRepositoryManager(self.ConfigObj)
+	assert.NoError(self.T(), err)
+
+	repository, err := manager.GetGlobalRepository(self.ConfigObj)
+	assert.NoError(self.T(), err)
+
+	artifact, err := repository.LoadYaml(artifact_str, services.ArtifactOptions{
+		ValidateArtifact: true,
+	})
+	assert.NoError(self.T(), err)
+	assert.Equal(self.T(), "Custom.Sigma.Compiled", artifact.Name)
+	assert.Equal(self.T(), "client_event", artifact.Type)
+	assert.Equal(self.T(), 2, len(artifact.Sources))
+
+	state := launcher.NewAnalysisState(artifact.Name)
+	launcher.VerifyArtifact(self.Ctx, self.ConfigObj, repository, artifact, state)
+	assert.Equal(self.T(), 0, len(state.Errors), "%v", state.Errors)
+}
+
+func TestSigmaCompiler(t *testing.T) {
+	suite.Run(t, &SigmaCompilerTestSuite{})
+}
@@ -42,6 +42,20 @@
 
   3. temporal: This object maintains the total rules that match.
 
+  4. valueSum: This object maintains the sum of a numeric field
+     within the timespan. The condition clause must contain a `field`
+     value.
+
+  5. valueAvg: Like valueSum but compares the average of the field.
+
+  6. valuePercentile: This object keeps all the field values within
+     the timespan and compares the requested percentile of them. The
+     condition clause must contain a `field` value and may specify
+     the `percentile` (default 50, i.e. the median).
+
+  7. temporalOrdered: Like temporal but the rules must also match in
+     the order they are listed in the correlation.
+
 */
 
 package evaluator
@@ -49,7 +63,9 @@ package evaluator
 import (
 	"context"
 	"fmt"
+	"math"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -78,7 +94,7 @@ type correlationComparator interface {
 type eventCount struct {
 	total_events int
 
-	cmp func(count int) bool
+	cmp func(value interface{}) bool
 }
 
 func (self *eventCount) addEvent(
@@ -110,25 +126,21 @@ type valueCount struct {
 	value_field string
 	value_map   map[string]int
 
-	cmp func(count int) bool
+	cmp func(value interface{}) bool
 }
 
 func NewValueCount(
 	ctx context.Context, scope types.Scope,
 	rule sigma.Rule) (*valueCount, error) {
 	condition := rule.Correlation.Condition
-
-	if condition == nil {
-		return nil, fmt.Errorf("While parsing rule %v: value_count rule requires a condition", rule.Title)
-	}
-	value_field_any, pres := condition["field"]
-	if !pres {
-		return nil, fmt.Errorf("While parsing rule %v: value_count rule requires a field in condition clause", rule.Title)
+	value_field, err := getConditionField(rule)
+	if err != nil {
+		return nil, err
 	}
 
 	return &valueCount{
 		value_map:   make(map[string]int),
-		value_field: utils.ToString(value_field_any),
+		value_field: value_field,
 		cmp:         getCmp(scope, condition),
 	}, nil
 }
@@ -213,6 +225,189 @@ func (self *temporal) evictEvent(
 	}
 }
 
+// Correlations which aggregate a numeric field over the timespan.
+type valueAggregate struct {
+	value_field string
+
+	// Keep the field value of each event so we can remove it again
+	// when the event is evicted. Events without a numeric field
+	// value do not contribute.
+	values map[*TimedEvent]float64
+
+	// Reduce the values to a single number for comparison.
+	reduce func(values map[*TimedEvent]float64) float64
+
+	cmp func(value interface{}) bool
+}
+
+func (self *valueAggregate) check() bool {
+	if len(self.values) == 0 {
+		return false
+	}
+	return self.cmp(self.reduce(self.values))
+}
+
+func (self *valueAggregate) addEvent(
+	ctx context.Context, scope types.Scope,
+	event *TimedEvent, rule *VQLRuleEvaluator) {
+	field_any, err := rule.GetFieldValuesFromEvent(
+		ctx, scope, self.value_field, event.Event)
+	if err != nil || len(field_any) == 0 {
+		return
+	}
+
+	value, ok := toFloat(field_any[0])
+	if ok {
+		self.values[event] = value
+	}
+}
+
+func (self *valueAggregate) evictEvent(
+	ctx context.Context, scope types.Scope,
+	event *TimedEvent, rule *VQLRuleEvaluator) {
+	delete(self.values, event)
+}
+
+func newValueAggregate(
+	scope types.Scope, rule sigma.Rule,
+	reduce func(values map[*TimedEvent]float64) float64) (
+	*valueAggregate, error) {
+	value_field, err := getConditionField(rule)
+	if err != nil {
+		return nil, err
+	}
+
+	return &valueAggregate{
+		value_field: value_field,
+		values:      make(map[*TimedEvent]float64),
+		reduce:      reduce,
+		cmp:         getCmp(scope, rule.Correlation.Condition),
+	}, nil
+}
+
+func NewValueSum(
+	ctx context.Context, scope types.Scope,
+	rule sigma.Rule) (*valueAggregate, error) {
+	return newValueAggregate(scope, rule, sumValues)
+}
+
+func NewValueAvg(
+	ctx context.Context, scope types.Scope,
+	rule sigma.Rule) (*valueAggregate, error) {
+	return newValueAggregate(scope, rule,
+		func(values map[*TimedEvent]float64) float64 {
+			return sumValues(values) / float64(len(values))
+		})
+}
+
+func NewValuePercentile(
+	ctx context.Context, scope types.Scope,
+	rule sigma.Rule) (*valueAggregate, error) {
+	percentile := float64(50)
+	percentile_any, pres := rule.Correlation.Condition["percentile"]
+	if pres {
+		value, ok := toFloat(percentile_any)
+		if !ok || value < 0 || value > 100 {
+			return nil, fmt.Errorf("While parsing rule %v: value_percentile percentile should be a number between 0 and 100", rule.Title)
+		}
+		percentile = value
+	}
+
+	return newValueAggregate(scope, rule,
+		func(values map[*TimedEvent]float64) float64 {
+			return percentileValues(values, percentile)
+		})
+}
+
+func sumValues(values map[*TimedEvent]float64) float64 {
+	var total float64
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
+
+// Nearest rank percentile of the values.
+func percentileValues(
+	values map[*TimedEvent]float64, percentile float64) float64 {
+	sorted := make([]float64, 0, len(values))
+	for _, v := range values {
+		sorted = append(sorted, v)
+	}
+	sort.Float64s(sorted)
+
+	rank := int(math.Ceil(percentile / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+type timedRule struct {
+	event *TimedEvent
+	name  string
+}
+
+// Like temporal but the rules must match in the order given in the
+// correlation rule.
+type temporalOrdered struct {
+	rules  []string
+	events []timedRule
+}
+
+func NewTemporalOrdered(
+	ctx context.Context, scope types.Scope,
+	rule sigma.Rule) (*temporalOrdered, error) {
+	return &temporalOrdered{
+		rules: rule.Correlation.Rules,
+	}, nil
+}
+
+func (self *temporalOrdered) check() bool {
+	if len(self.rules) == 0 {
+		return false
+	}
+
+	events := append([]timedRule{}, self.events...)
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].event.ts.Before(events[j].event.ts)
+	})
+
+	// Look for the rules as a subsequence of the events.
+	idx := 0
+	for _, e := range events {
+		if e.name == self.rules[idx] {
+			idx++
+			if idx >= len(self.rules) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (self *temporalOrdered) addEvent(
+	ctx context.Context, scope types.Scope,
+	event *TimedEvent, rule *VQLRuleEvaluator) {
+	name := rule.Name
+	if name == "" {
+		name = rule.ID
+	}
+
+	self.events = append(self.events, timedRule{event: event, name: name})
+}
+
+func (self *temporalOrdered) evictEvent(
+	ctx context.Context, scope types.Scope,
+	event *TimedEvent, rule *VQLRuleEvaluator) {
+	for idx, e := range self.events {
+		if e.event == event {
+			self.events = append(self.events[:idx], self.events[idx+1:]...)
+			return
+		}
+	}
+}
+
 type timespanManager struct {
 	timespan time.Duration
 	times    []*TimedEvent
@@ -342,66 +537,54 @@ func NewSigmaCorrelatorGroup(
 	ctx context.Context, scope types.Scope,
 	rule sigma.Rule) (*SigmaCorrelatorGroup, error) {
 
+	var correlator correlationComparator
+	var err error
+
 	// Figure out the type of the correlation
 	switch rule.Correlation.Type {
 	case "event_count":
-		correlator, err := NewEventCount(ctx, scope, rule)
-		if err != nil {
-			return nil, err
-		}
-
-		ts, err := NewTimespanManager(correlator, rule)
-		if err != nil {
-			return nil, err
-		}
-
-		return &SigmaCorrelatorGroup{
-			timespanManager: ts,
-			correlator:      correlator,
-		}, nil
+		correlator, err = NewEventCount(ctx, scope, rule)
 
 	case "value_count":
-		correlator, err := NewValueCount(ctx, scope, rule)
-		if err != nil {
-			return nil, err
-		}
+		correlator, err = NewValueCount(ctx, scope, rule)
 
-		ts, err := NewTimespanManager(correlator, rule)
-		if err != nil {
-			return nil, err
-		}
+	case "value_sum":
+		correlator, err = NewValueSum(ctx, scope, rule)
 
-		return &SigmaCorrelatorGroup{
-			timespanManager: ts,
-			correlator:      correlator,
-		}, nil
+	case "value_avg":
+		correlator, err = NewValueAvg(ctx, scope, rule)
 
-	case "temporal":
-		correlator, err := NewTemporal(ctx, scope, rule)
-		if err != nil {
-			return nil, err
-		}
+	case "value_percentile":
+		correlator, err = NewValuePercentile(ctx, scope, rule)
 
-		ts, err := NewTimespanManager(correlator, rule)
-		if err != nil {
-			return nil, err
-		}
+	case "temporal":
+		correlator, err = NewTemporal(ctx, scope, rule)
 
-		return &SigmaCorrelatorGroup{
-			timespanManager: ts,
-			correlator:      correlator,
-		}, nil
+	case "temporal_ordered":
+		correlator, err = NewTemporalOrdered(ctx, scope, rule)
 
-	//case "ordered_temporal":
 	default:
 		return nil, fmt.Errorf("Unsupported correlation type for %v: %v",
 			rule.Title, rule.Correlation.Type)
 	}
+	if err != nil {
+		return nil, err
+	}
+
+	ts, err := NewTimespanManager(correlator, rule)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SigmaCorrelatorGroup{
+		timespanManager: ts,
+		correlator:      correlator,
+	}, nil
 }
 
 func getCmp(scope vfilter.Scope,
-	condition map[string]interface{}) func(count int) bool {
-	cmp := func(count int) bool {
+	condition map[string]interface{}) func(value interface{}) bool {
+	cmp := func(value interface{}) bool {
 		return true
 	}
 
@@ -409,33 +592,92 @@ func getCmp(scope vfilter.Scope,
 		return cmp
 	}
 
+	gt_value, pres := condition["gt"]
+	if pres {
+		base := cmp
+		cmp = func(value interface{}) bool {
+			return base(value) && scope.Gt(value, gt_value)
+		}
+	}
+
 	gte_value, pres := condition["gte"]
 	if pres {
-		cmp = func(count int) bool {
-			return (scope.Gt(count, gte_value) || scope.Eq(count, gte_value))
+		base := cmp
+		cmp = func(value interface{}) bool {
+			return base(value) && (scope.Gt(value, gte_value) ||
+				scope.Eq(value, gte_value))
+		}
+	}
+
+	lt_value, pres := condition["lt"]
+	if pres {
+		base := cmp
+		cmp = func(value interface{}) bool {
+			return base(value) && scope.Lt(value, lt_value)
 		}
 	}
 
 	lte_value, pres := condition["lte"]
 	if pres {
 		base := cmp
-		cmp = func(count int) bool {
-			return base(count) && (scope.Lt(count, lte_value) ||
-				scope.Eq(count, lte_value))
+		cmp = func(value interface{}) bool {
+			return base(value) && (scope.Lt(value, lte_value) ||
+				scope.Eq(value, lte_value))
 		}
 	}
 
 	eq_value, pres := condition["eq"]
 	if pres {
 		base := cmp
-		cmp = func(count int) bool {
-			return base(count) && scope.Eq(count, eq_value)
+		cmp = func(value interface{}) bool {
+			return base(value) && scope.Eq(value, eq_value)
+		}
+	}
+
+	neq_value, pres := condition["neq"]
+	if pres {
+		base := cmp
+		cmp = func(value interface{}) bool {
+			return base(value) && !scope.Eq(value, neq_value)
 		}
 	}
 
 	return cmp
 }
 
+// The value based correlations operate on the field given in the
+// condition clause.
+func getConditionField(rule sigma.Rule) (string, error) {
+	condition := rule.Correlation.Condition
+	if condition == nil {
+		return "", fmt.Errorf("While parsing rule %v: %v rule requires a condition",
+			rule.Title, rule.Correlation.Type)
+	}
+
+	value_field_any, pres := condition["field"]
+	if !pres {
+		return "", fmt.Errorf("While parsing rule %v: %v rule requires a field in condition clause",
+			rule.Title, rule.Correlation.Type)
+	}
+
+	return utils.ToString(value_field_any), nil
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch t := value.(type) {
+	case float64:
+		return t, true
+	case float32:
+		return float64(t), true
+	case string:
+		result, err := strconv.ParseFloat(t, 64)
+		return result, err == nil
+	}
+
+	result, ok := utils.ToInt64(value)
+	return float64(result), ok
+}
+
 // One correlator per correlation rule
 type SigmaCorrelator struct {
 	*VQLRuleEvaluator
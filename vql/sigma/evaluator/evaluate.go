@@ -41,6 +41,10 @@ type VQLRuleEvaluator struct {
 
 	fieldmappings *FieldMappingResolver
 
+	// Sigma filters which apply to this rule. Each filter's condition
+	// must also be true for the rule to match.
+	filters []*VQLRuleEvaluator
+
 	// If this rule has correlators, then forward the match to each of
 	// them. A source rule may be referenced by more than one correlation.
 	Correlators []*SigmaCorrelator `json:"correlators,omitempty" yaml:"correlators,omitempty"`
@@ -183,6 +187,18 @@ func (self *VQLRuleEvaluator) Match(ctx context.Context,
 		}
 	}
 
+	// Known benign events are suppressed by the rule's filters.
+	if result.Match && len(self.filters) > 0 {
+		filtered, err := self.isFiltered(ctx, scope, event)
+		if err != nil {
+			return nil, err
+		}
+		if filtered {
+			result.Match = false
+			return &result, nil
+		}
+	}
+
 	// If we get here the base rule would have matched. When there are
 	// correlators the pool dispatches the event to each of them and
 	// emits one row per fired correlation.
@@ -6,6 +6,7 @@ import (
 	"reflect"
 	"strings"
 
+	"www.velocidex.com/golang/velociraptor/utils"
 	"www.velocidex.com/golang/velociraptor/vql/sigma/evaluator/modifiers"
 	"www.velocidex.com/golang/vfilter/types"
 
@@ -123,8 +124,21 @@ eventMatcher:
 				return false, err
 			}
 
+			// The fieldref modifier compares against the values of
+			// other fields in the same event.
+			expected := fieldMatcher.Values
+			modifier_names := fieldMatcher.Modifiers
+			if utils.InString(modifier_names, "fieldref") {
+				expected, err = self.resolveFieldRefs(
+					ctx, scope, expected, event)
+				if err != nil {
+					return false, err
+				}
+				modifier_names = utils.FilterSlice(modifier_names, "fieldref")
+			}
+
 			// Get all relevant modifiers
-			modifiers, err := modifiers.GetModifiers(fieldMatcher.Modifiers)
+			modifiers, err := modifiers.GetModifiers(modifier_names)
 			if err != nil {
 				return false, err
 			}
@@ -132,7 +146,7 @@ eventMatcher:
 			// Match using these modifiers
 			if !self.applyModifiers(
 				ctx, scope,
-				fieldMatcher.Values, modifiers, values) {
+				expected, modifiers, values) {
 
 				// this field didn't match so the overall matcher
 				// doesn't match, try the next EventMatcher
@@ -148,6 +162,22 @@ eventMatcher:
 	return false, nil
 }
 
+// Replace the field names with their values from the event.
+func (self *VQLRuleEvaluator) resolveFieldRefs(
+	ctx context.Context, scope types.Scope,
+	fields []interface{}, event *Event) ([]interface{}, error) {
+	var result []interface{}
+	for _, field := range fields {
+		values, err := self.GetFieldValuesFromEvent(
+			ctx, scope, utils.ToString(field), event)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, values...)
+	}
+	return result, nil
+}
+
 func (self *VQLRuleEvaluator) applyModifiers(
 	ctx context.Context, scope types.Scope,
 	expected []interface{},
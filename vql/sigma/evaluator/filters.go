@@ -0,0 +1,179 @@
+/*
+  Sigma filters are documents which suppress known benign activity
+  across a number of rules without needing to edit those rules.
+
+  Full details here https://sigmahq.io/docs/meta/filters.html
+
+  A filter document looks like a rule but has a `filter` section
+  instead of a `detection` section. The filter section lists the
+  rules it applies to (by id or name) and contains the usual
+  searches and condition:
+
+  ```yaml
+  title: Filter out admin logons
+  logsource:
+    product: windows
+  filter:
+    rules:
+      - failed_logon
+    selection:
+      TargetUserName: admin
+    condition: not selection
+  ```
+
+  The filter condition is combined with the rule's condition using
+  `and`, so an event matching a filtered rule is only reported if the
+  filter condition is also true.
+*/
+
+package evaluator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Velocidex/sigma-go"
+	"gopkg.in/yaml.v3"
+	"www.velocidex.com/golang/velociraptor/utils"
+	"www.velocidex.com/golang/vfilter/types"
+)
+
+type SigmaFilter struct {
+	Title     string
+	Rules     []string
+	Logsource sigma.Logsource
+	Detection sigma.Detection
+}
+
+// Filter documents are parsed as rules with an additional filter
+// field.
+func IsFilter(rule sigma.Rule) bool {
+	if rule.AdditionalFields == nil {
+		return false
+	}
+
+	_, pres := rule.AdditionalFields["filter"]
+	return pres
+}
+
+func NewSigmaFilter(rule sigma.Rule) (*SigmaFilter, error) {
+	filter_any, pres := rule.AdditionalFields["filter"]
+	if !pres {
+		return nil, fmt.Errorf("Filter %v: no filter section", rule.Title)
+	}
+
+	// Round trip the filter section through yaml so we can reuse the
+	// sigma parser for the detection part.
+	serialized, err := yaml.Marshal(filter_any)
+	if err != nil {
+		return nil, fmt.Errorf("Filter %v: %w", rule.Title, err)
+	}
+
+	node := &yaml.Node{}
+	err = yaml.Unmarshal(serialized, node)
+	if err != nil {
+		return nil, fmt.Errorf("Filter %v: %w", rule.Title, err)
+	}
+
+	if node.Kind != yaml.DocumentNode || len(node.Content) != 1 ||
+		node.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("Filter %v: filter section should be a mapping",
+			rule.Title)
+	}
+
+	result := &SigmaFilter{
+		Title:     rule.Title,
+		Logsource: rule.Logsource,
+	}
+
+	// Separate the rules list from the detection.
+	mapping := node.Content[0]
+	detection := &yaml.Node{Kind: yaml.MappingNode}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		key, value := mapping.Content[i], mapping.Content[i+1]
+		if key.Value == "rules" {
+			err = value.Decode(&result.Rules)
+			if err != nil {
+				return nil, fmt.Errorf("Filter %v: rules: %w", rule.Title, err)
+			}
+			continue
+		}
+		detection.Content = append(detection.Content, key, value)
+	}
+
+	if len(result.Rules) == 0 {
+		return nil, fmt.Errorf("Filter %v: does not reference any rules",
+			rule.Title)
+	}
+
+	err = result.Detection.UnmarshalYAML(detection)
+	if err != nil {
+		return nil, fmt.Errorf("Filter %v: %w", rule.Title, err)
+	}
+
+	if len(result.Detection.Conditions) == 0 {
+		return nil, fmt.Errorf("Filter %v: no condition specified", rule.Title)
+	}
+
+	return result, nil
+}
+
+// Does the filter apply to this rule? The filter must reference the
+// rule and its log source must not contradict the rule's log source.
+func (self *SigmaFilter) AppliesTo(rule sigma.Rule) bool {
+	if !utils.InString(self.Rules, rule.ID) &&
+		!utils.InString(self.Rules, rule.Name) {
+		return false
+	}
+
+	for _, pair := range [][2]string{
+		{self.Logsource.Category, rule.Logsource.Category},
+		{self.Logsource.Product, rule.Logsource.Product},
+		{self.Logsource.Service, rule.Logsource.Service},
+	} {
+		if pair[0] != "" && pair[0] != pair[1] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Attach the filter to the rule. The filter is evaluated using the
+// same field mappings as the rule itself.
+func (self *VQLRuleEvaluator) AddFilter(filter *SigmaFilter) error {
+	if filter == nil {
+		return errors.New("AddFilter: nil filter")
+	}
+
+	evaluator := NewVQLRuleEvaluator(self.scope, sigma.Rule{
+		Title:     filter.Title,
+		Logsource: filter.Logsource,
+		Detection: filter.Detection,
+	}, self.fieldmappings)
+
+	err := evaluator.CheckRule()
+	if err != nil {
+		return err
+	}
+
+	self.filters = append(self.filters, evaluator)
+	return nil
+}
+
+// Returns true if any of the rule's filters rejects the event.
+func (self *VQLRuleEvaluator) isFiltered(
+	ctx context.Context, scope types.Scope, event *Event) (bool, error) {
+	for _, filter := range self.filters {
+		res, err := filter.Match(ctx, scope, event)
+		if err != nil {
+			return false, err
+		}
+
+		if !res.Match {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,31 @@
+package modifiers
+
+import (
+	"context"
+
+	"www.velocidex.com/golang/velociraptor/utils"
+	"www.velocidex.com/golang/vfilter/types"
+)
+
+// The exists modifier checks the presence of the field in the event
+// (`Field|exists: true`) or its absence (`Field|exists: false`).
+type exists struct{}
+
+func (exists) Modify(ctx context.Context, scope types.Scope,
+	value []any, expected []any) (new_value []any, new_expected []any, err error) {
+
+	present := false
+	for _, v := range value {
+		if !utils.IsNil(v) {
+			present = true
+			break
+		}
+	}
+
+	want := true
+	if len(expected) > 0 {
+		want = scope.Bool(expected[0])
+	}
+
+	return []any{present == want}, expected, nil
+}
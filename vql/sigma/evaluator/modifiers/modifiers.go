@@ -45,7 +45,11 @@ var ValueModifiers = map[string]ValueModifier{
 	"base64offset":   b64offset{},
 	"expand":         expandModifier{},
 	"cidr":           AnyComparator{cidr{}},
-	"wide":           wide{},
+	"wide":           wide,
+	"utf16le":        utf16le,
+	"utf16be":        utf16be,
+	"utf16":          utf16,
+	"exists":         exists{},
 	"gt":             AnyComparator{gt{}},
 	"gte":            AnyComparator{gte{}},
 	"lt":             AnyComparator{lt{}},
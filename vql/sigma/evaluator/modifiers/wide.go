@@ -7,21 +7,32 @@ import (
 	"www.velocidex.com/golang/vfilter/types"
 )
 
-var (
-	utf16Encoder = unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM).NewEncoder()
-)
-
-type wide struct{}
+// Encode the expected values as UTF16. Encoders are not safe for
+// concurrent use so we make a new one for each call.
+type utf16Modifier struct {
+	endianness unicode.Endianness
+	bom        unicode.BOMPolicy
+}
 
-func (wide) Modify(ctx context.Context, scope types.Scope,
+func (self utf16Modifier) Modify(ctx context.Context, scope types.Scope,
 	value []any, expected []any) (new_value []any, new_expected []any, err error) {
 
+	encoder := unicode.UTF16(self.endianness, self.bom).NewEncoder()
 	for _, e := range expected {
 		expected_str := coerceString(e)
-		utf16, err := utf16Encoder.String(expected_str)
+		utf16, err := encoder.String(expected_str)
 		if err == nil {
 			new_expected = append(new_expected, utf16)
 		}
 	}
 	return value, new_expected, nil
 }
+
+var (
+	wide    = utf16Modifier{endianness: unicode.LittleEndian, bom: unicode.IgnoreBOM}
+	utf16le = wide
+	utf16be = utf16Modifier{endianness: unicode.BigEndian, bom: unicode.IgnoreBOM}
+
+	// The utf16 modifier prepends a little endian byte order mark.
+	utf16 = utf16Modifier{endianness: unicode.LittleEndian, bom: unicode.UseBOM}
+)
@@ -0,0 +1,449 @@
+package sigma
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/Velocidex/ordereddict"
+	"github.com/Velocidex/sigma-go"
+	"www.velocidex.com/golang/velociraptor/acls"
+	"www.velocidex.com/golang/velociraptor/utils"
+	vql_subsystem "www.velocidex.com/golang/velociraptor/vql"
+	"www.velocidex.com/golang/velociraptor/vql/sigma/evaluator"
+	"www.velocidex.com/golang/vfilter"
+	"www.velocidex.com/golang/vfilter/arg_parser"
+	"www.velocidex.com/golang/vfilter/types"
+)
+
+/* A test harness for sigma rules.
+
+Each test case names a rule and provides positive events (which the
+rule must detect) and negative events (which it must not). The events
+are fed through the same evaluator used by the sigma() plugin so the
+test exercises the field mappings, filters and correlations exactly
+as they would run in production.
+*/
+
+type SigmaTestPluginArgs struct {
+	Rules         []string            `vfilter:"required,field=rules,doc=A list of sigma rules to test."`
+	Filters       []string            `vfilter:"optional,field=filters,doc=A list of sigma filter documents to apply to the rules."`
+	FieldMappings *ordereddict.Dict   `vfilter:"optional,field=field_mapping,doc=A dict containing a mapping between a rule field name and a VQL Lambda to get the value of the field from the event."`
+	LogSources    vfilter.Any         `vfilter:"optional,field=log_sources,doc=The configured log sources (from sigma_log_sources() or a list of names). Rules without a configured log source fail."`
+	Tests         []*ordereddict.Dict `vfilter:"required,field=tests,doc=A list of test cases, each a dict with name, rule, log_source, count, positive and negative."`
+}
+
+// A single test case. Events may be given as a JSONL or EVTX file
+// name, a list of file names, a query or a list of rows.
+type SigmaTestCase struct {
+	Name      string      `vfilter:"optional,field=name,doc=A name for the test case"`
+	Rule      string      `vfilter:"required,field=rule,doc=The title, id or name of the rule under test"`
+	LogSource string      `vfilter:"optional,field=log_source,doc=The log source to feed the events into (default derived from the rule)"`
+	Count     int64       `vfilter:"optional,field=count,doc=If set, the exact number of hits expected from the positive events"`
+	Positive  vfilter.Any `vfilter:"optional,field=positive,doc=Events the rule must match"`
+	Negative  vfilter.Any `vfilter:"optional,field=negative,doc=Events the rule must not match"`
+}
+
+type SigmaTestPlugin struct{}
+
+func (self SigmaTestPlugin) Call(
+	ctx context.Context,
+	scope vfilter.Scope,
+	args *ordereddict.Dict) <-chan vfilter.Row {
+	output_chan := make(chan vfilter.Row)
+
+	go func() {
+		defer close(output_chan)
+		defer vql_subsystem.RegisterMonitor(ctx, "sigma_test", args)()
+		defer utils.RecoverVQL(scope)
+
+		arg := &SigmaTestPluginArgs{}
+		err := arg_parser.ExtractArgsWithContext(ctx, scope, args, arg)
+		if err != nil {
+			scope.Log("sigma_test: %v", err)
+			return
+		}
+
+		rules, filters := parseRulesAndFilters(ctx, scope,
+			append(arg.Rules, arg.Filters...), nil)
+
+		log_sources := getLogSourceNames(arg.LogSources)
+
+		for idx, test_dict := range arg.Tests {
+			test_case := &SigmaTestCase{}
+			err := arg_parser.ExtractArgsWithContext(ctx, scope, test_dict, test_case)
+			if err != nil {
+				scope.Log("sigma_test: test %v: %v", idx, err)
+				continue
+			}
+
+			if test_case.Name == "" {
+				test_case.Name = fmt.Sprintf("%v #%v", test_case.Rule, idx)
+			}
+
+			for _, kind := range []string{"positive", "negative"} {
+				events := test_case.Positive
+				if kind == "negative" {
+					events = test_case.Negative
+				}
+
+				if utils.IsNil(events) {
+					continue
+				}
+
+				result := runSigmaTestCase(ctx, scope, arg, rules, filters,
+					log_sources, test_case, kind, events)
+
+				select {
+				case <-ctx.Done():
+					return
+				case output_chan <- result:
+				}
+			}
+		}
+	}()
+
+	return output_chan
+}
+
+func runSigmaTestCase(
+	ctx context.Context, scope vfilter.Scope,
+	arg *SigmaTestPluginArgs,
+	rules []sigma.Rule, filters []*evaluator.SigmaFilter,
+	log_sources []string,
+	test_case *SigmaTestCase, kind string,
+	events_any vfilter.Any) *ordereddict.Dict {
+
+	result := ordereddict.NewDict().
+		Set("Name", test_case.Name).
+		Set("Rule", test_case.Rule).
+		Set("Kind", kind).
+		Set("LogSource", test_case.LogSource).
+		Set("Events", 0).
+		Set("Hits", 0).
+		Set("Passed", false).
+		Set("Searches", []string{}).
+		Set("OtherRules", []string{}).
+		Set("Error", "")
+
+	log_source, err := getTestLogSource(rules, log_sources, test_case)
+	if err != nil {
+		return result.Set("Error", err.Error())
+	}
+	result.Set("LogSource", log_source)
+
+	events, err := loadTestEvents(ctx, scope, events_any)
+	if err != nil {
+		return result.Set("Error", err.Error())
+	}
+	result.Set("Events", len(events))
+
+	provider := &LogSourceProvider{
+		queries: map[string]types.StoredQuery{
+			log_source: &eventsQuery{rows: events},
+		},
+	}
+
+	// Rules feeding a correlation only report the correlation, so
+	// when testing a detection rule on its own we leave the
+	// correlations out.
+	if !isCorrelation(rules, test_case.Rule) {
+		var detections []sigma.Rule
+		for _, r := range rules {
+			if r.Correlation == nil {
+				detections = append(detections, r)
+			}
+		}
+		rules = detections
+	}
+
+	sub_ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	sigma_context, err := NewSigmaContext(sub_ctx, scope, rules, filters,
+		arg.FieldMappings, provider, nil, false)
+	if err != nil {
+		return result.Set("Error", err.Error())
+	}
+	defer sigma_context.Close()
+
+	hits := 0
+	searches := make(map[string]bool)
+	other_rules := make(map[string]bool)
+
+	for row := range sigma_context.Rows(sub_ctx, scope) {
+		rule_any, _ := scope.Associative(row, "_Rule")
+		rule, ok := rule_any.(*evaluator.VQLRuleEvaluator)
+		if !ok {
+			continue
+		}
+
+		if !isRule(rule.Rule, test_case.Rule) {
+			other_rules[rule.Title] = true
+			continue
+		}
+		hits++
+
+		// Record which detection branches matched.
+		match_any, _ := scope.Associative(row, "_Match")
+		match, ok := match_any.(*evaluator.Result)
+		if ok {
+			for k, v := range match.SearchResults {
+				if v {
+					searches[k] = true
+				}
+			}
+		}
+	}
+
+	passed := hits == 0
+	if kind == "positive" {
+		passed = hits > 0
+		if test_case.Count > 0 {
+			passed = int64(hits) == test_case.Count
+		}
+	}
+
+	return result.Set("Hits", hits).
+		Set("Passed", passed).
+		Set("Searches", sortedKeys(searches)).
+		Set("OtherRules", sortedKeys(other_rules))
+}
+
+func isRule(rule sigma.Rule, name string) bool {
+	return name != "" &&
+		(rule.Title == name || rule.ID == name || rule.Name == name)
+}
+
+func isCorrelation(rules []sigma.Rule, name string) bool {
+	for _, r := range rules {
+		if isRule(r, name) {
+			return r.Correlation != nil
+		}
+	}
+	return false
+}
+
+// Figure out which log source the events should be fed into. If the
+// log sources are configured, the rule must match one of them.
+func getTestLogSource(
+	rules []sigma.Rule, log_sources []string,
+	test_case *SigmaTestCase) (string, error) {
+	if test_case.LogSource != "" {
+		return test_case.LogSource, nil
+	}
+
+	var rule *sigma.Rule
+	for idx := range rules {
+		if isRule(rules[idx], test_case.Rule) {
+			rule = &rules[idx]
+			break
+		}
+	}
+
+	if rule == nil {
+		return "", fmt.Errorf("Rule %v not found", test_case.Rule)
+	}
+
+	// Correlations do not have log sources so use the log source of
+	// the first rule they reference.
+	if rule.Correlation != nil {
+		var base *sigma.Rule
+		for _, name := range rule.Correlation.Rules {
+			for idx := range rules {
+				if rules[idx].Correlation == nil && isRule(rules[idx], name) {
+					base = &rules[idx]
+					break
+				}
+			}
+			if base != nil {
+				break
+			}
+		}
+
+		if base == nil {
+			return "", fmt.Errorf(
+				"Correlation %v does not reference any known rules", rule.Title)
+		}
+		rule = base
+	}
+
+	if len(log_sources) == 0 {
+		return logSourceName(rule.Logsource), nil
+	}
+
+	for _, name := range log_sources {
+		if matchLogSource(parseLogSourceTarget(name), *rule) {
+			return name, nil
+		}
+	}
+
+	return "", fmt.Errorf("No configured log source for rule %v (%v)",
+		rule.Title, logSourceName(rule.Logsource))
+}
+
+func logSourceName(logsource sigma.Logsource) string {
+	parts := []string{logsource.Category, logsource.Product, logsource.Service}
+	for idx, p := range parts {
+		if p == "" {
+			parts[idx] = "*"
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+func getLogSourceNames(log_sources vfilter.Any) []string {
+	var result []string
+	switch t := log_sources.(type) {
+	case *LogSourceProvider:
+		for k := range t.Queries() {
+			result = append(result, k)
+		}
+
+	case string:
+		result = append(result, t)
+
+	case []string:
+		result = append(result, t...)
+
+	case []vfilter.Any:
+		for _, item := range t {
+			result = append(result, utils.ToString(item))
+		}
+	}
+
+	sort.Strings(result)
+	return result
+}
+
+// Load the test events from files, queries or literal rows.
+func loadTestEvents(
+	ctx context.Context, scope vfilter.Scope,
+	events vfilter.Any) ([]*ordereddict.Dict, error) {
+	events = vql_subsystem.Materialize(ctx, scope, events)
+
+	switch t := events.(type) {
+	case nil, types.Null, *types.Null:
+		return nil, nil
+
+	case string:
+		return loadTestEventsFile(ctx, scope, t)
+
+	case *ordereddict.Dict:
+		return []*ordereddict.Dict{t}, nil
+	}
+
+	rv := reflect.ValueOf(events)
+	if rv.Kind() != reflect.Slice {
+		return []*ordereddict.Dict{toDict(scope, events)}, nil
+	}
+
+	var result []*ordereddict.Dict
+	for i := 0; i < rv.Len(); i++ {
+		item := rv.Index(i).Interface()
+		filename, ok := item.(string)
+		if ok {
+			rows, err := loadTestEventsFile(ctx, scope, filename)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, rows...)
+			continue
+		}
+
+		result = append(result, toDict(scope, item))
+	}
+
+	return result, nil
+}
+
+// EVTX files are parsed with parse_evtx() and everything else is
+// treated as JSONL.
+func loadTestEventsFile(
+	ctx context.Context, scope vfilter.Scope,
+	filename string) ([]*ordereddict.Dict, error) {
+	query := "SELECT * FROM parse_jsonl(filename=EventFile)"
+	if strings.EqualFold(filepath.Ext(filename), ".evtx") {
+		query = "SELECT * FROM parse_evtx(filename=EventFile)"
+	}
+
+	vql, err := vfilter.Parse(query)
+	if err != nil {
+		return nil, err
+	}
+
+	subscope := scope.Copy().AppendVars(
+		ordereddict.NewDict().Set("EventFile", filename))
+	defer subscope.Close()
+
+	var result []*ordereddict.Dict
+	for row := range vql.Eval(ctx, subscope) {
+		result = append(result, toDict(subscope, row))
+	}
+
+	if len(result) == 0 {
+		return nil, fmt.Errorf("No events loaded from %v", filename)
+	}
+
+	return result, nil
+}
+
+func sortedKeys(in map[string]bool) []string {
+	result := make([]string, 0, len(in))
+	for k := range in {
+		result = append(result, k)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// Feeds the test events into the log source.
+type eventsQuery struct {
+	rows []*ordereddict.Dict
+}
+
+func (self *eventsQuery) ToString(scope types.Scope) string {
+	return "Sigma Test Events"
+}
+
+func (self *eventsQuery) Eval(
+	ctx context.Context, scope types.Scope) <-chan types.Row {
+	output_chan := make(chan vfilter.Row)
+
+	go func() {
+		defer close(output_chan)
+
+		for _, r := range self.rows {
+			select {
+			case <-ctx.Done():
+				return
+			case output_chan <- r:
+			}
+		}
+	}()
+
+	return output_chan
+}
+
+func (self SigmaTestPlugin) Info(scope vfilter.Scope, type_map *vfilter.TypeMap) *vfilter.PluginInfo {
+	return &vfilter.PluginInfo{
+		Name:    "sigma_test",
+		Doc:     "Test sigma rules against positive and negative sample events.",
+		ArgType: type_map.AddType(scope, &SigmaTestPluginArgs{}),
+		// Same as the sigma() plugin, as well as reading the event
+		// files.
+		Metadata: vql_subsystem.VQLMetadata().Permissions(
+			acls.MACHINE_STATE,
+			acls.EXECVE,
+			acls.FILESYSTEM_READ,
+			acls.FILESYSTEM_WRITE,
+		).Build(),
+	}
+}
+
+func init() {
+	vql_subsystem.RegisterPlugin(&SigmaTestPlugin{})
+}
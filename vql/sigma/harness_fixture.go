@@ -0,0 +1,279 @@
+package sigma
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/Velocidex/ordereddict"
+	"github.com/Velocidex/yaml/v2"
+)
+
+// A test fixture describes a sigma rule pack test suite in a YAML
+// file. Paths are relative to the fixture file:
+//
+// ```yaml
+// rules:
+//   - rules/*.yml
+// filters:
+//   - filters/*.yml
+// field_mapping:
+//   EventID: x=>x.System.EventID.Value
+// log_sources:
+//   - "*/windows/security"
+// tests:
+//   - name: Detect failed logons
+//     rule: failed_logon
+//     positive: events/failed_logon.evtx
+//     negative:
+//       - events/benign.jsonl
+// ```
+
+type SigmaTestFixtureCase struct {
+	Name      string      `json:"name,omitempty"`
+	Rule      string      `json:"rule,omitempty"`
+	LogSource string      `json:"log_source,omitempty"`
+	Count     int64       `json:"count,omitempty"`
+	Positive  interface{} `json:"positive,omitempty"`
+	Negative  interface{} `json:"negative,omitempty"`
+}
+
+type SigmaTestFixture struct {
+	Rules        []string                `json:"rules,omitempty"`
+	Filters      []string                `json:"filters,omitempty"`
+	FieldMapping map[string]string       `json:"field_mapping,omitempty"`
+	LogSources   []string                `json:"log_sources,omitempty"`
+	Tests        []*SigmaTestFixtureCase `json:"tests,omitempty"`
+}
+
+// Load a test fixture and build the arguments to the sigma_test()
+// plugin.
+func LoadSigmaTestFixture(path string) (*ordereddict.Dict, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	fixture := &SigmaTestFixture{}
+	err = yaml.UnmarshalStrict(data, fixture)
+	if err != nil {
+		return nil, fmt.Errorf("While parsing %v: %w", path, err)
+	}
+
+	// Event files are opened through VQL accessors which need
+	// absolute paths.
+	base_dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := readGlobs(base_dir, fixture.Rules)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("%v: No rules found", path)
+	}
+
+	filters, err := readGlobs(base_dir, fixture.Filters)
+	if err != nil {
+		return nil, err
+	}
+
+	tests := make([]*ordereddict.Dict, 0, len(fixture.Tests))
+	for _, test_case := range fixture.Tests {
+		test := ordereddict.NewDict().
+			Set("name", test_case.Name).
+			Set("rule", test_case.Rule).
+			Set("log_source", test_case.LogSource).
+			Set("count", test_case.Count)
+
+		if test_case.Positive != nil {
+			test.Set("positive", resolveEvents(base_dir, test_case.Positive))
+		}
+
+		if test_case.Negative != nil {
+			test.Set("negative", resolveEvents(base_dir, test_case.Negative))
+		}
+
+		tests = append(tests, test)
+	}
+
+	return ordereddict.NewDict().
+		Set("rules", rules).
+		Set("filters", filters).
+		Set("field_mapping", sortedDict(fixture.FieldMapping)).
+		Set("log_sources", fixture.LogSources).
+		Set("tests", tests), nil
+}
+
+// Read all the files matching the globs.
+func readGlobs(base_dir string, globs []string) ([]string, error) {
+	// An empty list rather than nil which VQL would see as Null.
+	result := []string{}
+	for _, glob := range globs {
+		if !filepath.IsAbs(glob) {
+			glob = filepath.Join(base_dir, glob)
+		}
+
+		matches, err := filepath.Glob(glob)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("No files match %v", glob)
+		}
+
+		for _, match := range matches {
+			data, err := os.ReadFile(match)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, string(data))
+		}
+	}
+	return result, nil
+}
+
+// Events may be given as a file name, a list of file names or a list
+// of literal events. File names are relative to the fixture.
+func resolveEvents(base_dir string, events interface{}) interface{} {
+	switch t := events.(type) {
+	case string:
+		return resolvePath(base_dir, t)
+
+	case []interface{}:
+		result := make([]interface{}, 0, len(t))
+		for _, item := range t {
+			filename, ok := item.(string)
+			if ok {
+				result = append(result, resolvePath(base_dir, filename))
+				continue
+			}
+			result = append(result, normalizeYaml(item))
+		}
+		return result
+	}
+
+	return normalizeYaml(events)
+}
+
+func resolvePath(base_dir, filename string) string {
+	if filepath.IsAbs(filename) {
+		return filename
+	}
+	return filepath.Join(base_dir, filename)
+}
+
+// The yaml library decodes mappings as map[interface{}]interface{}
+// which VQL can not use.
+func normalizeYaml(in interface{}) interface{} {
+	switch t := in.(type) {
+	case map[interface{}]interface{}:
+		result := ordereddict.NewDict()
+		keys := make([]string, 0, len(t))
+		lookup := make(map[string]interface{})
+		for k, v := range t {
+			key := fmt.Sprintf("%v", k)
+			keys = append(keys, key)
+			lookup[key] = v
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			result.Set(k, normalizeYaml(lookup[k]))
+		}
+		return result
+
+	case []interface{}:
+		result := make([]interface{}, 0, len(t))
+		for _, item := range t {
+			result = append(result, normalizeYaml(item))
+		}
+		return result
+	}
+	return in
+}
+
+// A compile spec describes how to build a client event artifact
+// from a sigma rule pack for `velociraptor sigma compile`. Paths are
+// relative to the spec file:
+//
+// ```yaml
+// name: Custom.Sigma.Windows
+// rules:
+//   - rules/*.yml
+// field_mapping:
+//   EventID: x=>x.System.EventID.Value
+// log_sources:
+//   "*/windows/security": |
+//      SELECT * FROM watch_evtx(filename="C:/Windows/System32/WinEvt/Logs/Security.evtx")
+// ```
+
+type SigmaCompileSpec struct {
+	Name         string            `json:"name,omitempty"`
+	Description  string            `json:"description,omitempty"`
+	Rules        []string          `json:"rules,omitempty"`
+	Filters      []string          `json:"filters,omitempty"`
+	FieldMapping map[string]string `json:"field_mapping,omitempty"`
+	LogSources   map[string]string `json:"log_sources,omitempty"`
+}
+
+// Load a compile spec and build the arguments to the sigma_compile()
+// function.
+func LoadSigmaCompileSpec(path string) (*ordereddict.Dict, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	spec := &SigmaCompileSpec{}
+	err = yaml.UnmarshalStrict(data, spec)
+	if err != nil {
+		return nil, fmt.Errorf("While parsing %v: %w", path, err)
+	}
+
+	// Event files are opened through VQL accessors which need
+	// absolute paths.
+	base_dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := readGlobs(base_dir, spec.Rules)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("%v: No rules found", path)
+	}
+
+	filters, err := readGlobs(base_dir, spec.Filters)
+	if err != nil {
+		return nil, err
+	}
+
+	return ordereddict.NewDict().
+		Set("name", spec.Name).
+		Set("description", spec.Description).
+		Set("rules", rules).
+		Set("filters", filters).
+		Set("field_mapping", sortedDict(spec.FieldMapping)).
+		Set("log_sources", sortedDict(spec.LogSources)), nil
+}
+
+func sortedDict(in map[string]string) *ordereddict.Dict {
+	result := ordereddict.NewDict()
+	keys := make([]string, 0, len(in))
+	for k := range in {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		result.Set(k, in[k])
+	}
+	return result
+}
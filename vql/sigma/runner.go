@@ -190,6 +190,7 @@ func NewSigmaContext(
 	ctx context.Context,
 	scope types.Scope,
 	rules []sigma.Rule,
+	filters []*evaluator.SigmaFilter,
 	fieldmappings *ordereddict.Dict,
 	log_sources *LogSourceProvider,
 	default_details *vfilter.Lambda,
@@ -266,6 +267,18 @@ func NewSigmaContext(
 					continue
 				}
 
+				for _, filter := range filters {
+					if !filter.AppliesTo(r) {
+						continue
+					}
+
+					err := evaluator_rule.AddFilter(filter)
+					if err != nil {
+						scope.Log("sigma: Error in filter '%v': %v",
+							filter.Title, err)
+					}
+				}
+
 				runner.rules = append(runner.rules, evaluator_rule)
 				self.total_rules++
 
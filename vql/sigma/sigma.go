@@ -9,6 +9,7 @@ import (
 	"www.velocidex.com/golang/velociraptor/acls"
 	"www.velocidex.com/golang/velociraptor/utils"
 	vql_subsystem "www.velocidex.com/golang/velociraptor/vql"
+	"www.velocidex.com/golang/velociraptor/vql/sigma/evaluator"
 	"www.velocidex.com/golang/vfilter"
 	"www.velocidex.com/golang/vfilter/arg_parser"
 
@@ -20,6 +21,7 @@ import (
 
 type SigmaPluginArgs struct {
 	Rules          []string          `vfilter:"required,field=rules,doc=A list of sigma rules to compile."`
+	Filters        []string          `vfilter:"optional,field=filters,doc=A list of sigma filter documents to apply to the rules (filters may also be given in rules)."`
 	LogSources     vfilter.Any       `vfilter:"required,field=log_sources,doc=A log source object as obtained from the sigma_log_sources() VQL function."`
 	FieldMappings  *ordereddict.Dict `vfilter:"optional,field=field_mapping,doc=A dict containing a mapping between a rule field name and a VQL Lambda to get the value of the field from the event."`
 	Debug          bool              `vfilter:"optional,field=debug,doc=If enabled we emit all match objects with description of what would match."`
@@ -54,46 +56,15 @@ func (self SigmaPlugin) Call(
 		}
 
 		// Compile all the rules
-		var rules []sigma.Rule
-		for _, rules_text := range arg.Rules {
-			for _, r := range strings.Split(rules_text, "\n---\n") {
-
-				// Just ignore empty rules.
-				r := strings.TrimSpace(r)
-				if len(r) == 0 {
-					continue
-				}
-
-				rule, err := sigma.ParseRule([]byte(r))
-				if err != nil {
-					// Skip the rules we can not parse
-					scope.Log("sigma: Error parsing: %v in rule '%v'",
-						err, utils.Elide(r, 20))
-					continue
-				}
-
-				// A rule must have a title
-				if rule.Title == "" {
-					scope.Log("sigma: Error parsing rule '%v': no title set",
-						utils.Elide(r, 20))
-					continue
-				}
-
-				if arg.RuleFilter != nil &&
-					!scope.Bool(arg.RuleFilter.Reduce(ctx, scope, []vfilter.Any{rule})) {
-					continue
-				}
-
-				rules = append(rules, rule)
-			}
-		}
+		rules, filters := parseRulesAndFilters(ctx, scope,
+			append(arg.Rules, arg.Filters...), arg.RuleFilter)
 
 		// Build a new evaluation context around the rules. This binds
 		// the rules to the log sources. Only the relevant log sources
 		// will be evaluated - i.e. only those that have some rules
 		// watching them.
 		sigma_context, err := NewSigmaContext(
-			ctx, scope, rules,
+			ctx, scope, rules, filters,
 			arg.FieldMappings, log_sources,
 			arg.DefaultDetails, arg.Debug)
 		if err != nil {
@@ -115,6 +86,57 @@ func (self SigmaPlugin) Call(
 	return output_chan
 }
 
+// Parse the rule documents, separating out the filters. This is
+// shared with the sigma_test() plugin.
+func parseRulesAndFilters(
+	ctx context.Context, scope vfilter.Scope,
+	rules_texts []string, rule_filter *vfilter.Lambda) (
+	rules []sigma.Rule, filters []*evaluator.SigmaFilter) {
+	for _, rules_text := range rules_texts {
+		for _, r := range strings.Split(rules_text, "\n---\n") {
+
+			// Just ignore empty rules.
+			r := strings.TrimSpace(r)
+			if len(r) == 0 {
+				continue
+			}
+
+			rule, err := sigma.ParseRule([]byte(r))
+			if err != nil {
+				// Skip the rules we can not parse
+				scope.Log("sigma: Error parsing: %v in rule '%v'",
+					err, utils.Elide(r, 20))
+				continue
+			}
+
+			// A rule must have a title
+			if rule.Title == "" {
+				scope.Log("sigma: Error parsing rule '%v': no title set",
+					utils.Elide(r, 20))
+				continue
+			}
+
+			if evaluator.IsFilter(rule) {
+				filter, err := evaluator.NewSigmaFilter(rule)
+				if err != nil {
+					scope.Log("sigma: %v", err)
+					continue
+				}
+				filters = append(filters, filter)
+				continue
+			}
+
+			if rule_filter != nil &&
+				!scope.Bool(rule_filter.Reduce(ctx, scope, []vfilter.Any{rule})) {
+				continue
+			}
+
+			rules = append(rules, rule)
+		}
+	}
+	return rules, filters
+}
+
 func (self SigmaPlugin) Info(scope vfilter.Scope, type_map *vfilter.TypeMap) *vfilter.PluginInfo {
 	return &vfilter.PluginInfo{
 		Name:    "sigma",
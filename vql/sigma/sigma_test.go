@@ -7,14 +7,18 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 	"sort"
 	"strings"
 	"testing"
 
 	"github.com/Velocidex/ordereddict"
+	"github.com/Velocidex/yaml/v2"
 	"github.com/stretchr/testify/suite"
 	"www.velocidex.com/golang/velociraptor/json"
+	"www.velocidex.com/golang/velociraptor/utils"
 	vql_subsystem "www.velocidex.com/golang/velociraptor/vql"
+	"www.velocidex.com/golang/velociraptor/vql/acl_managers"
 	"www.velocidex.com/golang/velociraptor/vtesting/assert"
 	"www.velocidex.com/golang/velociraptor/vtesting/goldie"
 	"www.velocidex.com/golang/vfilter"
@@ -673,6 +677,130 @@ detection:
 							Set("Baz", "Hello world"))),
 			},
 		},
+		{
+			description: "Match fieldref, exists and utf16 modifiers",
+			rule: `
+title: New Modifiers
+logsource:
+  product: windows
+  service: application
+
+detection:
+  fieldref:
+     Target|fieldref: Source
+
+  fieldref_endswith:
+     Target|fieldref|endswith: Suffix
+
+  exists:
+     Optional|exists: true
+
+  not_exists:
+     Missing|exists: false
+
+  utf16le:
+     Data|utf16le|base64offset|contains: ping
+
+  utf16be:
+     Data|utf16be|base64offset|contains: ping
+
+  utf16:
+     Data|utf16|base64|contains: ping
+
+  condition: 1 of them
+`,
+			fieldmappings: ordereddict.NewDict().
+				Set("Source", "x=>x.Source").
+				Set("Target", "x=>x.Target").
+				Set("Suffix", "x=>x.Suffix").
+				Set("Optional", "x=>x.Optional").
+				Set("Missing", "x=>x.Missing").
+				Set("Data", "x=>x.Data"),
+			debug: true,
+			rows: []*ordereddict.Dict{
+				ordereddict.NewDict().
+					Set("Match", "Should match fieldref, exists, not_exists and utf16le (and utf16be since it is shifted by one byte)").
+					Set("Source", "C:\\Windows\\cmd.exe").
+					Set("Target", "C:\\Windows\\cmd.exe").
+					Set("Suffix", "cmd.exe").
+					Set("Optional", 0).
+					Set("Data", base64.StdEncoding.EncodeToString(
+						[]byte("x\x00p\x00i\x00n\x00g\x00"))),
+				ordereddict.NewDict().
+					Set("Match", "Should match fieldref_endswith and utf16be").
+					Set("Source", "C:\\Windows\\cmd.exe").
+					Set("Target", "C:\\Temp\\cmd.exe").
+					Set("Suffix", "cmd.exe").
+					Set("Missing", "present").
+					Set("Data", base64.StdEncoding.EncodeToString(
+						[]byte("\x00p\x00i\x00n\x00g"))),
+				ordereddict.NewDict().
+					Set("Match", "Should match utf16 with BOM (and utf16le)").
+					Set("Source", "a").
+					Set("Target", "b").
+					Set("Suffix", "c").
+					Set("Missing", "present").
+					Set("Data", base64.StdEncoding.EncodeToString(
+						[]byte("\xff\xfep\x00i\x00n\x00g\x00"))),
+			},
+		},
+		{
+			description: "Sigma filters suppress known benign events",
+			rule: `
+title: Process Launched
+id: 5b8f2f7c-7c43-4c1b-9c52-cd5e8a4a7b3d
+logsource:
+  product: windows
+  service: application
+detection:
+  selection:
+     Image|endswith: powershell.exe
+  condition: selection
+---
+title: Other Rule
+name: other_rule
+logsource:
+  product: windows
+  service: application
+detection:
+  selection:
+     Image|endswith: .exe
+  condition: selection
+---
+title: Admin Scripts
+logsource:
+  product: windows
+filter:
+  rules:
+    - 5b8f2f7c-7c43-4c1b-9c52-cd5e8a4a7b3d
+  selection:
+     User: admin
+  condition: not selection
+---
+title: Wrong Logsource
+logsource:
+  product: linux
+filter:
+  rules:
+    - other_rule
+  selection:
+     User: admin
+  condition: not selection
+`,
+			fieldmappings: ordereddict.NewDict().
+				Set("Image", "x=>x.Image").
+				Set("User", "x=>x.User"),
+			rows: []*ordereddict.Dict{
+				ordereddict.NewDict().
+					Set("Match", "Should be filtered from Process Launched but match Other Rule").
+					Set("Image", "C:\\Windows\\powershell.exe").
+					Set("User", "admin"),
+				ordereddict.NewDict().
+					Set("Match", "Should match both rules").
+					Set("Image", "C:\\Windows\\powershell.exe").
+					Set("User", "bob"),
+			},
+		},
 	}
 )
 
@@ -815,6 +943,29 @@ falsepositives:
 ---
 `
 
+	base_rule_network_transfer = `
+title: Network transfer
+name: network_transfer
+logsource:
+   product: windows
+   service: security
+detection:
+   selection:
+     EventID: 3
+   condition: selection
+---
+`
+
+	networkTransfer_field_mappings = ordereddict.NewDict().
+					Set("Timestamp", "x=>x.Timestamp").
+					Set("EventID", "x=>x.EventID").
+					Set("User", "x=>x.User").
+					Set("Bytes", "x=>x.Bytes")
+
+	simpleTemporalOrderedCorrelationRule = strings.Replace(
+		simpleTemporalCorrelationRule,
+		"type: temporal", "type: temporal_ordered", 1)
+
 	sigmaCorrelationTestCases = []testCase{
 		{
 			description: "Correlation Test Too few hits",
@@ -1118,6 +1269,165 @@ level: high
 			},
 			// One row per correlation rule.
 			expected_count: 2,
+		}, {
+			description: "Correlation Test VALUE_SUM",
+			rule: base_rule_network_transfer + `
+title: More than 1GB transferred by one user
+correlation:
+  type: value_sum
+  rules:
+    - network_transfer
+  group-by:
+    - User
+  timespan: 10m
+  condition:
+    gt: 1000000000
+    field: Bytes
+level: high
+`,
+			fieldmappings: networkTransfer_field_mappings,
+			rows: []*ordereddict.Dict{
+				ordereddict.NewDict().
+					Set("Timestamp", "2024-10-10T12:20:00+10").
+					Set("EventID", 3).
+					Set("User", "A").
+					Set("Bytes", 400000000),
+				ordereddict.NewDict().
+					Set("Timestamp", "2024-10-10T12:21:00+10").
+					Set("EventID", 3).
+					Set("User", "B").
+					Set("Bytes", 400000000),
+				ordereddict.NewDict().
+					Set("Timestamp", "2024-10-10T12:22:00+10").
+					Set("EventID", 3).
+					Set("User", "A").
+					Set("Bytes", 400000000),
+
+				// Sum for user A exceeds 1GB here.
+				ordereddict.NewDict().
+					Set("Timestamp", "2024-10-10T12:23:00+10").
+					Set("EventID", 3).
+					Set("User", "A").
+					Set("Bytes", 400000000),
+
+				// First event expired from the timespan.
+				ordereddict.NewDict().
+					Set("Timestamp", "2024-10-10T12:31:00+10").
+					Set("EventID", 3).
+					Set("User", "A").
+					Set("Bytes", 100000000),
+			},
+			expected_count: 1,
+		}, {
+			description: "Correlation Test VALUE_AVG",
+			rule: base_rule_network_transfer + `
+title: Large average transfer size
+correlation:
+  type: value_avg
+  rules:
+    - network_transfer
+  group-by:
+    - User
+  timespan: 10m
+  condition:
+    gte: 500
+    field: Bytes
+level: high
+`,
+			fieldmappings: networkTransfer_field_mappings,
+			rows: []*ordereddict.Dict{
+				ordereddict.NewDict().
+					Set("Timestamp", "2024-10-10T12:20:00+10").
+					Set("EventID", 3).
+					Set("User", "A").
+					Set("Bytes", 100),
+
+				// Average is 500
+				ordereddict.NewDict().
+					Set("Timestamp", "2024-10-10T12:21:00+10").
+					Set("EventID", 3).
+					Set("User", "A").
+					Set("Bytes", 900),
+
+				// Average is 300
+				ordereddict.NewDict().
+					Set("Timestamp", "2024-10-10T12:22:00+10").
+					Set("EventID", 3).
+					Set("User", "A").
+					Set("Bytes", "-100"),
+			},
+			expected_count: 1,
+		}, {
+			description: "Correlation Test VALUE_PERCENTILE",
+			rule: base_rule_network_transfer + `
+title: Outlier transfer size
+correlation:
+  type: value_percentile
+  rules:
+    - network_transfer
+  timespan: 10m
+  condition:
+    field: Bytes
+    percentile: 90
+    gte: 1000
+level: high
+`,
+			fieldmappings: networkTransfer_field_mappings,
+			rows: []*ordereddict.Dict{
+				ordereddict.NewDict().
+					Set("Timestamp", "2024-10-10T12:20:00+10").
+					Set("EventID", 3).
+					Set("User", "A").
+					Set("Bytes", 10),
+				ordereddict.NewDict().
+					Set("Timestamp", "2024-10-10T12:21:00+10").
+					Set("EventID", 3).
+					Set("User", "B").
+					Set("Bytes", 20),
+
+				// 90th percentile is now 5000
+				ordereddict.NewDict().
+					Set("Timestamp", "2024-10-10T12:22:00+10").
+					Set("EventID", 3).
+					Set("User", "C").
+					Set("Bytes", 5000),
+			},
+			expected_count: 1,
+		}, {
+			description: "Correlation Test TEMPORAL_ORDERED",
+			rule:        simpleTemporalOrderedCorrelationRule,
+			fieldmappings: ordereddict.NewDict().
+				Set("ParentImage", "x=>x.ParentImage").
+				Set("cs-method", "x=>x.`cs-method`"),
+			rows: []*ordereddict.Dict{
+				// Should trigger r1
+				ordereddict.NewDict().
+					Set("Timestamp", "2024-10-10T12:22:00+10").
+					Set("ParentImage", "C:\\Windows\\tomcat8.exe"),
+
+				// Should trigger r2
+				ordereddict.NewDict().
+					Set("Timestamp", "2024-10-10T12:25:00+10").
+					Set("cs-method", "POST"),
+			},
+			expected_count: 1,
+		}, {
+			description: "Correlation Test TEMPORAL_ORDERED Wrong order should not fire",
+			rule:        simpleTemporalOrderedCorrelationRule,
+			fieldmappings: ordereddict.NewDict().
+				Set("ParentImage", "x=>x.ParentImage").
+				Set("cs-method", "x=>x.`cs-method`"),
+			rows: []*ordereddict.Dict{
+				// Should trigger r2
+				ordereddict.NewDict().
+					Set("Timestamp", "2024-10-10T12:22:00+10").
+					Set("cs-method", "POST"),
+
+				// Should trigger r1
+				ordereddict.NewDict().
+					Set("Timestamp", "2024-10-10T12:25:00+10").
+					Set("ParentImage", "C:\\Windows\\tomcat8.exe"),
+			},
 		},
 	}
 )
@@ -1198,3 +1508,415 @@ func (self *SigmaTestSuite) TestSigmaCorrelations() {
 func TestSigmaPlugin(t *testing.T) {
 	suite.Run(t, &SigmaTestSuite{})
 }
+
+func (self *SigmaTestSuite) TestSigmaTestHarness() {
+	ctx := context.Background()
+	scope := vql_subsystem.MakeScope().AppendVars(ordereddict.NewDict().
+		Set(vql_subsystem.ACL_MANAGER_VAR, acl_managers.NullACLManager{}))
+	defer scope.Close()
+
+	log_collector := &bytes.Buffer{}
+	scope.SetLogger(log.New(log_collector, "", 0))
+
+	tmpdir := self.T().TempDir()
+	write := func(name, data string) {
+		err := os.WriteFile(filepath.Join(tmpdir, name), []byte(data), 0600)
+		assert.NoError(self.T(), err)
+	}
+
+	write("rules.yml", base_rule_Failed_logon+`
+title: Multiple failed logons
+correlation:
+    type: event_count
+    rules:
+        - failed_logon
+    group-by:
+        - TargetUserName
+    timespan: 5m
+    condition:
+        gte: 2
+`)
+	write("filter.yml", `
+title: Ignore service account
+logsource:
+   product: windows
+filter:
+  rules:
+    - failed_logon
+  selection:
+    TargetUserName: svc
+  condition: not selection
+`)
+	write("positive.jsonl", `{"Timestamp":"2024-10-10T12:22:00+10","EventID":4625,"TargetUserName":"A"}
+{"Timestamp":"2024-10-10T12:23:00+10","EventID":4625,"TargetUserName":"A"}
+`)
+	write("negative.jsonl", `{"Timestamp":"2024-10-10T12:22:00+10","EventID":4624,"TargetUserName":"A"}
+{"Timestamp":"2024-10-10T12:23:00+10","EventID":4625,"TargetUserName":"svc"}
+`)
+	write("fixture.yaml", `
+rules:
+  - rules.yml
+filters:
+  - filter.yml
+field_mapping:
+  EventID: x=>x.EventID
+  Timestamp: x=>x.Timestamp
+  TargetUserName: x=>x.TargetUserName
+log_sources:
+  - "*/windows/security"
+tests:
+  - name: Failed logon
+    rule: failed_logon
+    count: 2
+    positive: positive.jsonl
+    negative: negative.jsonl
+
+  - name: Brute force
+    rule: Multiple failed logons
+    positive: positive.jsonl
+    negative:
+      - EventID: 4625
+        Timestamp: "2024-10-10T12:22:00+10"
+        TargetUserName: B
+
+  # This should fail because the rule does not match.
+  - name: Expected failure
+    rule: failed_logon
+    positive: negative.jsonl
+
+  - name: Missing log source
+    rule: failed_logon
+    log_source: "*/linux/auth"
+    positive: positive.jsonl
+`)
+
+	args, err := LoadSigmaTestFixture(filepath.Join(tmpdir, "fixture.yaml"))
+	assert.NoError(self.T(), err)
+
+	results := ordereddict.NewDict()
+	for row := range (SigmaTestPlugin{}).Call(ctx, scope, args) {
+		name, _ := scope.Associative(row, "Name")
+		kind, _ := scope.Associative(row, "Kind")
+		passed, _ := scope.Associative(row, "Passed")
+		hits, _ := scope.Associative(row, "Hits")
+		searches, _ := scope.Associative(row, "Searches")
+		results.Set(fmt.Sprintf("%v %v", name, kind),
+			fmt.Sprintf("%v %v %v", passed, hits, searches))
+	}
+
+	assert.Equal(self.T(), ordereddict.NewDict().
+		Set("Failed logon positive", "true 2 [selection]").
+		Set("Failed logon negative", "true 0 []").
+		Set("Brute force positive", "true 1 []").
+		Set("Brute force negative", "true 0 []").
+		Set("Expected failure positive", "false 0 []").
+		Set("Missing log source positive", "false 0 []"),
+		results, log_collector.String())
+}
+
+func (self *SigmaTestSuite) TestSigmaCompiler() {
+	ctx := context.Background()
+	scope := vql_subsystem.MakeScope()
+	defer scope.Close()
+
+	log_collector := &bytes.Buffer{}
+	scope.SetLogger(log.New(log_collector, "", 0))
+
+	rules := base_rule_Failed_logon + `
+title: Suspicious logon
+id: 1234
+level: high
+logsource:
+   product: windows
+   service: security
+detection:
+   selection:
+     EventID: 4624
+     TargetUserName|startswith:
+       - adm
+       - root
+   network:
+     IpAddress|cidr: 10.0.0.0/8
+   cmdline:
+     CommandLine|base64offset|contains: whoami
+   filter:
+     Nested.Value|re: ^ignore
+   condition: selection and (network or cmdline) and not filter
+---
+title: Keyword rule
+logsource:
+   product: windows
+detection:
+   keywords:
+     - foo
+   condition: keywords
+---
+title: Linux rule
+logsource:
+   product: linux
+detection:
+   selection:
+     EventID: 1
+`
+	filter := `
+title: Ignore service account
+logsource:
+   product: windows
+filter:
+  rules:
+    - failed_logon
+  selection:
+    TargetUserName: svc
+  condition: not selection
+`
+
+	events := []*ordereddict.Dict{
+		ordereddict.NewDict().Set("EventID", 4625).Set("TargetUserName", "A"),
+		ordereddict.NewDict().Set("EventID", "4625").Set("TargetUserName", "svc"),
+		ordereddict.NewDict().Set("EventID", 4624).Set("TargetUserName", "Administrator").
+			Set("IpAddress", "10.1.1.1"),
+		ordereddict.NewDict().Set("EventID", 4624).Set("TargetUserName", "rooted").
+			Set("IpAddress", "192.168.1.1").
+			Set("CommandLine", base64.StdEncoding.EncodeToString([]byte("cmd /c whoami"))),
+		ordereddict.NewDict().Set("EventID", 4624).Set("TargetUserName", "admin").
+			Set("IpAddress", "10.1.1.1").
+			Set("Nested", ordereddict.NewDict().Set("Value", "Ignore me")),
+		ordereddict.NewDict().Set("EventID", 4624).Set("TargetUserName", "bob").
+			Set("IpAddress", "10.1.1.1"),
+	}
+
+	artifact := SigmaCompileFunction{}.Call(ctx, scope, ordereddict.NewDict().
+		Set("rules", []string{rules}).
+		Set("filters", []string{filter}).
+		Set("field_mapping", ordereddict.NewDict().
+			Set("EventID", "x=>x.EventID").
+			Set("TargetUserName", "x=>x.TargetUserName")).
+		Set("log_sources", ordereddict.NewDict().
+			Set("*/windows/security", "SELECT * FROM foreach(row=TestEvents)").
+			Set("*/windows/system", "SELECT * FROM foreach(row=TestEvents)")))
+
+	artifact_str, ok := artifact.(string)
+	assert.True(self.T(), ok, log_collector.String())
+
+	compiled := &struct {
+		Type    string `json:"type"`
+		Sources []struct {
+			Name  string `json:"name"`
+			Query string `json:"query"`
+		} `json:"sources"`
+	}{}
+	err := yaml.Unmarshal([]byte(artifact_str), compiled)
+	assert.NoError(self.T(), err)
+	assert.Equal(self.T(), "CLIENT_EVENT", compiled.Type)
+
+	// Both log sources share the same query so should be combined.
+	assert.Equal(self.T(), 1, len(compiled.Sources))
+
+	vqls, err := vfilter.MultiParse(compiled.Sources[0].Query)
+	assert.NoError(self.T(), err)
+
+	subscope := scope.Copy().AppendVars(
+		ordereddict.NewDict().Set("TestEvents", events))
+	defer subscope.Close()
+
+	var hits []string
+	for _, vql := range vqls {
+		for row := range vql.Eval(ctx, subscope) {
+			user, _ := subscope.Associative(row, "TargetUserName")
+			matches, _ := subscope.Associative(row, "_Rules")
+			hits = append(hits, fmt.Sprintf("%v: %v", user,
+				json.MustMarshalString(matches)))
+		}
+	}
+
+	// Missing fields should not cause errors.
+	assert.NotContains(self.T(), log_collector.String(), "ERROR")
+
+	goldie.Assert(self.T(), "TestSigmaCompiler", []byte(
+		artifact_str+"\n# Hits\n"+strings.Join(hits, "\n")+"\n"))
+}
+
+// Rows which differ only by case, to check the compiled artifact
+// compares them the same way as the sigma() plugin.
+var sigmaParityTestCases = []testCase{
+	{
+		description: "Case handling",
+		rule: `
+title: Equal
+logsource:
+   product: windows
+   service: application
+detection:
+  selection:
+     Foo: Bar
+---
+title: Contains
+logsource:
+   product: windows
+   service: application
+detection:
+  selection:
+     Foo|contains: AR
+---
+title: StartsWith
+logsource:
+   product: windows
+   service: application
+detection:
+  selection:
+     Foo|startswith: b
+---
+title: EndsWith
+logsource:
+   product: windows
+   service: application
+detection:
+  selection:
+     Foo|endswith: R
+---
+title: Regex
+logsource:
+   product: windows
+   service: application
+detection:
+  selection:
+     Foo|re: ^B.r$
+---
+title: Base64
+logsource:
+   product: windows
+   service: application
+detection:
+  selection:
+     Encoded|base64offset|contains: whoami
+---
+title: EventID
+logsource:
+   product: windows
+   service: application
+detection:
+  selection:
+     EventID: 4625
+`,
+		fieldmappings: ordereddict.NewDict(),
+		rows: []*ordereddict.Dict{
+			ordereddict.NewDict().Set("Foo", "Bar"),
+			ordereddict.NewDict().Set("Foo", "bar"),
+			ordereddict.NewDict().Set("Foo", "BAR"),
+			ordereddict.NewDict().Set("Foo", "xbarx"),
+			ordereddict.NewDict().Set("Encoded",
+				base64.StdEncoding.EncodeToString([]byte("cmd /c whoami"))),
+			ordereddict.NewDict().Set("Encoded", strings.ToLower(
+				base64.StdEncoding.EncodeToString([]byte("cmd /c whoami")))),
+			ordereddict.NewDict().Set("EventID", 4625),
+			ordereddict.NewDict().Set("EventID", "4625"),
+		},
+	},
+}
+
+// Every rule the compiler accepts must match the same events in the
+// compiled artifact as in the sigma() plugin.
+func (self *SigmaTestSuite) TestSigmaCompilerParity() {
+	ctx := context.Background()
+	scope := vql_subsystem.MakeScope().
+		AppendVars(ordereddict.NewDict().Set("ScopeVar", "I'm a scope var:"))
+	defer scope.Close()
+
+	log_collector := &bytes.Buffer{}
+	scope.SetLogger(log.New(log_collector, "", 0))
+
+	// Run the interpreted rules on the row and return the titles of
+	// the matching rules.
+	interpreted := func(test_case testCase, row *ordereddict.Dict) []string {
+		args := ordereddict.NewDict().
+			Set("rules", test_case.rule).
+			Set("log_sources", &LogSourceProvider{
+				queries: map[string]types.StoredQuery{
+					"*/windows/application": &MockQuery{
+						rows: []*ordereddict.Dict{row},
+					},
+				},
+			}).
+			Set("field_mapping", test_case.fieldmappings)
+
+		var result []string
+		for hit := range (SigmaPlugin{}).Call(ctx, scope, args) {
+			rule, _ := scope.Associative(hit, "_Rule")
+			title, _ := scope.Associative(rule, "Title")
+			result = append(result, utils.ToString(title))
+		}
+		sort.Strings(result)
+		return result
+	}
+
+	cases := append([]testCase{}, sigmaTestCases...)
+	cases = append(cases, sigmaParityTestCases...)
+
+	compiled_count := 0
+	for _, test_case := range cases {
+		rules, filters := parseRulesAndFilters(ctx, scope,
+			[]string{test_case.rule}, nil)
+		artifact, skipped, err := CompileSigmaArtifact(ctx, scope,
+			SigmaCompilerOptions{
+				Rules:         rules,
+				Filters:       filters,
+				FieldMappings: test_case.fieldmappings,
+				LogSources: ordereddict.NewDict().
+					Set("*/windows/application",
+						"SELECT * FROM foreach(row=TestEvents)"),
+			})
+		assert.NoError(self.T(), err, test_case.description)
+
+		// Only compare the rules which were compiled.
+		skipped_titles := make(map[string]bool)
+		for _, s := range skipped {
+			skipped_titles[strings.SplitN(s, ": ", 2)[0]] = true
+		}
+
+		compiled := &struct {
+			Sources []struct {
+				Query string `json:"query"`
+			} `json:"sources"`
+		}{}
+		err = yaml.Unmarshal([]byte(artifact), compiled)
+		assert.NoError(self.T(), err)
+
+		for _, source := range compiled.Sources {
+			compiled_count++
+
+			vqls, err := vfilter.MultiParse(source.Query)
+			assert.NoError(self.T(), err, test_case.description)
+
+			for idx, row := range test_case.rows {
+				var expected []string
+				for _, title := range interpreted(test_case, row) {
+					if !skipped_titles[title] {
+						expected = append(expected, title)
+					}
+				}
+
+				subscope := scope.Copy().AppendVars(ordereddict.NewDict().
+					Set("TestEvents", []*ordereddict.Dict{row}))
+
+				var actual []string
+				for _, vql := range vqls {
+					for hit := range vql.Eval(ctx, subscope) {
+						rules, _ := subscope.Associative(hit, "_Rules")
+						for _, rule := range rules.([]vfilter.Any) {
+							title, _ := subscope.Associative(rule, "Title")
+							actual = append(actual, utils.ToString(title))
+						}
+					}
+				}
+				subscope.Close()
+				sort.Strings(actual)
+
+				assert.Equal(self.T(), expected, actual, "%v: row %v",
+					test_case.description, idx)
+			}
+		}
+	}
+
+	// Make sure we actually compared something.
+	assert.True(self.T(), compiled_count > 10)
+}
@@ -0,0 +1,541 @@
+package artifact_tests
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/Velocidex/ordereddict"
+	"www.velocidex.com/golang/velociraptor/acls"
+	artifacts_proto "www.velocidex.com/golang/velociraptor/artifacts/proto"
+	"www.velocidex.com/golang/velociraptor/constants"
+	"www.velocidex.com/golang/velociraptor/json"
+	"www.velocidex.com/golang/velociraptor/logging"
+	"www.velocidex.com/golang/velociraptor/services"
+	"www.velocidex.com/golang/velociraptor/utils"
+	vql_subsystem "www.velocidex.com/golang/velociraptor/vql"
+	"www.velocidex.com/golang/velociraptor/vql/remapping"
+	vql_server "www.velocidex.com/golang/velociraptor/vql/server"
+	"www.velocidex.com/golang/vfilter"
+	"www.velocidex.com/golang/vfilter/arg_parser"
+)
+
+/* A test harness for artifacts.
+
+Artifact authors may add a tests section to the artifact
+definition. Each test names the parameters to pass to the artifact,
+mocks for any plugins, functions or artifacts it depends on, and the
+rows it is expected to produce:
+
+```yaml
+tests:
+  - name: Finds a text file
+    parameters:
+      - key: Glob
+        value: /tmp/*.txt
+    mocks:
+      - plugin: glob
+        results: |
+          [{"OSPath": "/tmp/a.txt", "Size": 10}]
+    expected: |
+      [
+       {
+        "OSPath": "/tmp/a.txt"
+       }
+      ]
+```
+
+Each test runs in its own scope using a copy of the repository so
+mocks do not leak between tests.
+*/
+
+const (
+	NO_EXPECTED_ROWS = "No expected rows recorded"
+)
+
+type ArtifactTestPluginArgs struct {
+	Artifact   string   `vfilter:"required,field=artifact,doc=The artifact to test. This can be an artifact definition in yaml or the name of an artifact"`
+	Repository string   `vfilter:"optional,field=repository,doc=The repository to load the artifact into (e.g. one populated by artifact_set()). If not set, we use a copy of the global repository."`
+	Tests      []string `vfilter:"optional,field=tests,doc=Only run tests with these names."`
+}
+
+type ArtifactTestPlugin struct{}
+
+func (self ArtifactTestPlugin) Call(
+	ctx context.Context,
+	scope vfilter.Scope,
+	args *ordereddict.Dict) <-chan vfilter.Row {
+	output_chan := make(chan vfilter.Row)
+
+	go func() {
+		defer close(output_chan)
+		defer vql_subsystem.RegisterMonitor(ctx, "artifact_test", args)()
+		defer utils.RecoverVQL(scope)
+
+		err := vql_subsystem.CheckAccess(scope, acls.ARTIFACT_WRITER)
+		if err != nil {
+			scope.Log("artifact_test: %v", err)
+			return
+		}
+
+		arg := &ArtifactTestPluginArgs{}
+		err = arg_parser.ExtractArgsWithContext(ctx, scope, args, arg)
+		if err != nil {
+			scope.Log("artifact_test: %v", err)
+			return
+		}
+
+		repository, err := getRepository(ctx, scope, arg.Repository)
+		if err != nil {
+			scope.Log("artifact_test: %v", err)
+			return
+		}
+
+		config_obj, _ := vql_subsystem.GetServerConfig(scope)
+		artifact, pres := repository.Get(ctx, config_obj, arg.Artifact)
+		if !pres {
+			artifact, err = repository.LoadYaml(arg.Artifact,
+				services.ArtifactOptions{
+					ValidateArtifact:     true,
+					ArtifactIsBuiltIn:    true,
+					AllowOverridingAlias: true,
+				})
+			if err != nil {
+				scope.Log("artifact_test: %v", err)
+				return
+			}
+		}
+
+		for idx, test := range artifact.Tests {
+			name := TestName(test, idx)
+			if len(arg.Tests) > 0 && !utils.InString(arg.Tests, name) {
+				continue
+			}
+
+			result := runArtifactTest(
+				ctx, scope, repository, artifact, name, test)
+
+			select {
+			case <-ctx.Done():
+				return
+			case output_chan <- result:
+			}
+		}
+	}()
+
+	return output_chan
+}
+
+// Unnamed tests are referred to by their index.
+func TestName(test *artifacts_proto.ArtifactTest, idx int) string {
+	if test.Name != "" {
+		return test.Name
+	}
+	return fmt.Sprintf("#%v", idx)
+}
+
+// Tests operate on a copy of the repository so the artifact under
+// test does not replace the real one.
+func getRepository(ctx context.Context, scope vfilter.Scope,
+	name string) (services.Repository, error) {
+	if name != "" {
+		cached_any := vql_subsystem.CacheGet(
+			scope, vql_server.REPOSITORY_CACHE_TAG+name)
+		cached_repository, ok := cached_any.(services.Repository)
+		if !ok {
+			return nil, fmt.Errorf("Repository %v not found", name)
+		}
+		return cached_repository.Copy(), nil
+	}
+
+	config_obj, ok := vql_subsystem.GetServerConfig(scope)
+	if !ok {
+		return nil, fmt.Errorf("Command can only run on the server")
+	}
+
+	manager, err := services.GetRepositoryManager(config_obj)
+	if err != nil {
+		return nil, err
+	}
+
+	repository, err := manager.GetGlobalRepository(config_obj)
+	if err != nil {
+		return nil, err
+	}
+
+	return repository.Copy(), nil
+}
+
+func runArtifactTest(
+	ctx context.Context, scope vfilter.Scope,
+	repository services.Repository,
+	artifact *artifacts_proto.Artifact, name string,
+	test *artifacts_proto.ArtifactTest) *ordereddict.Dict {
+
+	result := ordereddict.NewDict().
+		Set("Artifact", artifact.Name).
+		Set("Name", name).
+		Set("Passed", false).
+		Set("Rows", 0).
+		Set("Diff", "").
+		Set("Actual", "").
+		Set("Logs", []string{}).
+		Set("Error", "")
+
+	config_obj, _ := vql_subsystem.GetServerConfig(scope)
+	manager, err := services.GetRepositoryManager(config_obj)
+	if err != nil {
+		result.Update("Error", err.Error())
+		return result
+	}
+
+	// Capture the logs of the test while still relaying them to the
+	// caller.
+	logs := &logCollector{logger: scope.GetLogger()}
+
+	builder := services.ScopeBuilderFromScope(scope)
+	builder.Repository = repository
+	builder.Logger = log.New(logs, "", 0)
+	builder.Env = ordereddict.NewDict().
+		Set(constants.SCOPE_MOCK, &remapping.MockingScopeContext{})
+
+	sub_scope := manager.BuildScope(builder)
+	defer sub_scope.Close()
+
+	artifact_plugin, err := getArtifactPlugin(sub_scope, artifact.Name)
+	if err != nil {
+		result.Update("Error", err.Error())
+		return result
+	}
+
+	for _, mock := range test.Mocks {
+		err := installMock(ctx, sub_scope, mock)
+		if err != nil {
+			result.Update("Error", err.Error())
+			return result
+		}
+	}
+
+	call_args := ordereddict.NewDict()
+	for _, param := range test.Parameters {
+		call_args.Set(param.Key, param.Value)
+	}
+	if test.Source != "" {
+		call_args.Set("source", test.Source)
+	}
+
+	var rows []*ordereddict.Dict
+	for row := range artifact_plugin.Call(ctx, sub_scope, call_args) {
+		// The _Source column is added by the artifact plugin and
+		// is not part of the collected rows.
+		dict := vfilter.RowToDict(ctx, sub_scope, row)
+		dict.Delete("_Source")
+		rows = append(rows, dict)
+	}
+
+	actual, err := normalizeRows(rows)
+	if err != nil {
+		result.Update("Error", err.Error())
+		return result
+	}
+
+	result.Update("Rows", len(rows)).
+		Update("Actual", actual).
+		Update("Logs", logs.Lines())
+
+	errors := logs.Errors()
+	if len(errors) > 0 {
+		result.Update("Error", strings.Join(errors, "\n"))
+		return result
+	}
+
+	if test.Expected == "" {
+		result.Update("Error", NO_EXPECTED_ROWS)
+		return result
+	}
+
+	expected, err := normalizeJson([]byte(test.Expected))
+	if err != nil {
+		result.Update("Error", fmt.Sprintf(
+			"While parsing expected rows: %v", err))
+		return result
+	}
+
+	if expected != actual {
+		result.Update("Diff", Diff(expected, actual))
+		return result
+	}
+
+	result.Update("Passed", true)
+	return result
+}
+
+// Resolve the artifact plugin in the same way Artifact.Name is
+// resolved in VQL.
+func getArtifactPlugin(
+	scope vfilter.Scope, name string) (vfilter.PluginGeneratorInterface, error) {
+	value, pres := scope.Resolve("Artifact")
+	if !pres {
+		return nil, fmt.Errorf("Artifact repository not available")
+	}
+
+	for _, component := range strings.Split(name, ".") {
+		value, pres = scope.Associative(value, component)
+		if !pres {
+			return nil, fmt.Errorf("Artifact %v not found", name)
+		}
+	}
+
+	plugin, ok := value.(vfilter.PluginGeneratorInterface)
+	if !ok {
+		return nil, fmt.Errorf("Artifact %v not found", name)
+	}
+	return plugin, nil
+}
+
+func installMock(ctx context.Context,
+	scope vfilter.Scope, mock *artifacts_proto.ArtifactTestMock) error {
+	calls, err := getMockResults(ctx, scope, mock)
+	if err != nil {
+		return err
+	}
+
+	mock_context, ok := remapping.GetMockContext(scope)
+	if !ok {
+		return fmt.Errorf("Mocking is not available")
+	}
+
+	switch {
+	case mock.Plugin != "":
+		mock_plugin := remapping.NewMockerPlugin(mock.Plugin, calls)
+		mock_context.AddPlugin(mock_plugin)
+		scope.AppendPlugins(mock_plugin)
+
+	case mock.Function != "":
+		// Each call to a function returns the next value.
+		var values []vfilter.Any
+		for _, call := range calls {
+			rows, ok := call.([]vfilter.Row)
+			if !ok {
+				values = append(values, call)
+				continue
+			}
+			for _, row := range rows {
+				values = append(values, row)
+			}
+		}
+
+		if len(values) == 0 {
+			return fmt.Errorf("Mock for %v has no results", mock.Function)
+		}
+
+		mock_function := remapping.NewMockerFunction(mock.Function, values)
+		mock_context.AddFunction(mock_function)
+		scope.AppendFunctions(mock_function)
+
+	case mock.Artifact != "":
+		plugin, err := getArtifactPlugin(scope, mock.Artifact)
+		if err != nil {
+			return err
+		}
+
+		mockable, ok := plugin.(services.MockablePlugin)
+		if !ok {
+			return fmt.Errorf("Artifact %v can not be mocked", mock.Artifact)
+		}
+		var rows []vfilter.Row
+		for _, call := range calls {
+			rows = append(rows, call)
+		}
+		mockable.SetMock(mockable.Name(), rows)
+
+	default:
+		return fmt.Errorf("Mock should specify a plugin, function or artifact")
+	}
+
+	return nil
+}
+
+// Returns a list of calls, each a list of rows.
+func getMockResults(ctx context.Context, scope vfilter.Scope,
+	mock *artifacts_proto.ArtifactTestMock) ([]vfilter.Any, error) {
+	if mock.Query != "" {
+		vql, err := vfilter.Parse(mock.Query)
+		if err != nil {
+			return nil, fmt.Errorf("While parsing mock query: %w", err)
+		}
+
+		rows := []vfilter.Row{}
+		for row := range vql.Eval(ctx, scope) {
+			rows = append(rows, row)
+		}
+		return []vfilter.Any{rows}, nil
+	}
+
+	return parseMockResults([]byte(mock.Results))
+}
+
+func parseMockResults(data []byte) ([]vfilter.Any, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, fmt.Errorf("Mock has no results")
+	}
+
+	// A single row
+	if data[0] == '{' {
+		row, err := utils.ParseJsonToObject(data)
+		if err != nil {
+			return nil, err
+		}
+		return []vfilter.Any{[]vfilter.Row{row}}, nil
+	}
+
+	var raw_calls []json.RawMessage
+	err := json.Unmarshal(data, &raw_calls)
+	if err != nil {
+		return nil, fmt.Errorf("Mock results should be a JSON list: %w", err)
+	}
+
+	// A list of lists is a multi call mock.
+	if len(raw_calls) > 0 &&
+		bytes.HasPrefix(bytes.TrimSpace(raw_calls[0]), []byte("[")) {
+		var result []vfilter.Any
+		for _, raw_call := range raw_calls {
+			rows, err := parseRows(raw_call)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, rows)
+		}
+		return result, nil
+	}
+
+	rows, err := parseRows(data)
+	if err != nil {
+		return nil, err
+	}
+	return []vfilter.Any{rows}, nil
+}
+
+// Rows may be dicts or plain values (e.g. for function mocks).
+func parseRows(data []byte) ([]vfilter.Row, error) {
+	var raw_rows []json.RawMessage
+	err := json.Unmarshal(data, &raw_rows)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]vfilter.Row, 0, len(raw_rows))
+	for _, raw_row := range raw_rows {
+		raw_row = bytes.TrimSpace(raw_row)
+		if len(raw_row) > 0 && raw_row[0] == '{' {
+			row, err := utils.ParseJsonToObject(raw_row)
+			if err != nil {
+				return nil, err
+			}
+			rows = append(rows, row)
+			continue
+		}
+
+		var value interface{}
+		err := json.Unmarshal(raw_row, &value)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, value)
+	}
+	return rows, nil
+}
+
+// Rows are compared in their serialized form so both sides need to be
+// encoded in the same way.
+func normalizeRows(rows []*ordereddict.Dict) (string, error) {
+	if rows == nil {
+		rows = []*ordereddict.Dict{}
+	}
+
+	serialized, err := json.Marshal(rows)
+	if err != nil {
+		return "", err
+	}
+	return normalizeJson(serialized)
+}
+
+func normalizeJson(data []byte) (string, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || data[0] != '[' {
+		return "", fmt.Errorf("Expected a JSON list of rows")
+	}
+
+	rows, err := utils.ParseJsonToDicts(data)
+	if err != nil {
+		return "", err
+	}
+
+	if rows == nil {
+		rows = []*ordereddict.Dict{}
+	}
+
+	serialized, err := json.MarshalIndent(rows)
+	if err != nil {
+		return "", err
+	}
+	return string(serialized), nil
+}
+
+type logCollector struct {
+	mu     sync.Mutex
+	lines  []string
+	logger *log.Logger
+}
+
+func (self *logCollector) Write(b []byte) (int, error) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	line := strings.TrimRight(string(b), "\n")
+	level, _ := logging.SplitIntoLevelAndLog([]byte(line))
+	if level != logging.DEBUG {
+		self.lines = append(self.lines, line)
+	}
+
+	if self.logger != nil {
+		self.logger.Print(line)
+	}
+	return len(b), nil
+}
+
+func (self *logCollector) Lines() []string {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	return append([]string{}, self.lines...)
+}
+
+// Errors logged by the artifact fail the test.
+func (self *logCollector) Errors() (result []string) {
+	for _, line := range self.Lines() {
+		level, msg := logging.SplitIntoLevelAndLog([]byte(line))
+		if level == logging.ERROR {
+			result = append(result, msg)
+		}
+	}
+	return result
+}
+
+func (self ArtifactTestPlugin) Info(
+	scope vfilter.Scope, type_map *vfilter.TypeMap) *vfilter.PluginInfo {
+	return &vfilter.PluginInfo{
+		Name:     "artifact_test",
+		Doc:      "Run the tests defined in an artifact's tests section.",
+		ArgType:  type_map.AddType(scope, &ArtifactTestPluginArgs{}),
+		Metadata: vql_subsystem.VQLMetadata().Permissions(acls.ARTIFACT_WRITER).Build(),
+	}
+}
+
+func init() {
+	vql_subsystem.RegisterPlugin(&ArtifactTestPlugin{})
+}
@@ -0,0 +1,165 @@
+package artifact_tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Velocidex/ordereddict"
+	"github.com/stretchr/testify/suite"
+	"www.velocidex.com/golang/velociraptor/file_store/test_utils"
+	"www.velocidex.com/golang/velociraptor/services"
+	"www.velocidex.com/golang/velociraptor/vql/acl_managers"
+	"www.velocidex.com/golang/velociraptor/vtesting/assert"
+	"www.velocidex.com/golang/vfilter"
+)
+
+const testArtifact = `
+name: Custom.Test.Files
+parameters:
+ - name: MinSize
+   type: int
+   default: 5
+sources:
+ - query: |
+     SELECT OSPath, Size, hash(path=OSPath).MD5 AS MD5
+     FROM glob(globs="/tmp/*")
+     WHERE Size > MinSize
+tests:
+ - name: Filters small files
+   mocks:
+    - plugin: glob
+      results: |
+        [{"OSPath": "/tmp/a", "Size": 10}, {"OSPath": "/tmp/b", "Size": 1}]
+    - function: hash
+      results: |
+        [{"MD5": "abcd"}]
+   expected: |
+     [{"OSPath": "/tmp/a", "Size": 10, "MD5": "abcd"}]
+
+ - name: Parameters
+   parameters:
+    - key: MinSize
+      value: "0"
+   mocks:
+    - plugin: glob
+      results: |
+        [{"OSPath": "/tmp/a", "Size": 10}, {"OSPath": "/tmp/b", "Size": 1}]
+   expected: |
+     [{"OSPath": "/tmp/a", "Size": 10, "MD5": null}]
+
+ - name: No expectations
+   mocks:
+    - plugin: glob
+      results: |
+        []
+`
+
+const testCallerArtifact = `
+name: Custom.Test.Caller
+sources:
+ - query: |
+     SELECT count() AS Count FROM Artifact.Custom.Test.Files()
+     GROUP BY 1
+tests:
+ - mocks:
+    - artifact: Custom.Test.Files
+      query: SELECT * FROM range(end=3)
+   expected: |
+     [{"Count": 3}]
+`
+
+type ArtifactTestsTestSuite struct {
+	test_utils.TestSuite
+}
+
+func (self *ArtifactTestsTestSuite) SetupTest() {
+	self.ConfigObj = self.LoadConfig()
+	self.LoadArtifactsIntoConfig([]string{testArtifact})
+	self.TestSuite.SetupTest()
+}
+
+func (self *ArtifactTestsTestSuite) runTests(artifact string) []*ordereddict.Dict {
+	manager, err := services.GetRepositoryManager(self.ConfigObj)
+	assert.NoError(self.T(), err)
+
+	builder := services.ScopeBuilder{
+		Config:     self.ConfigObj,
+		ACLManager: acl_managers.NullACLManager{},
+		Env:        ordereddict.NewDict(),
+	}
+	scope := manager.BuildScope(builder)
+	defer scope.Close()
+
+	ctx := context.Background()
+
+	var rows []*ordereddict.Dict
+	for row := range (ArtifactTestPlugin{}).Call(ctx, scope,
+		ordereddict.NewDict().Set("artifact", artifact)) {
+		rows = append(rows, vfilter.RowToDict(ctx, scope, row))
+	}
+	return rows
+}
+
+func (self *ArtifactTestsTestSuite) TestArtifactTests() {
+	rows := self.runTests(testArtifact)
+	assert.Equal(self.T(), 3, len(rows))
+
+	passed, _ := rows[0].Get("Passed")
+	assert.Equal(self.T(), true, passed)
+
+	// The expected rows do not match the actual rows.
+	passed, _ = rows[1].Get("Passed")
+	assert.Equal(self.T(), false, passed)
+
+	diff, _ := rows[1].GetString("Diff")
+	assert.Contains(self.T(), diff, `+  "OSPath": "/tmp/b"`)
+
+	// A test without expectations records the actual rows.
+	error_message, _ := rows[2].GetString("Error")
+	assert.Equal(self.T(), "No expected rows recorded", error_message)
+
+	actual, _ := rows[2].GetString("Actual")
+	assert.Equal(self.T(), "[]", actual)
+}
+
+func (self *ArtifactTestsTestSuite) TestArtifactMocks() {
+	rows := self.runTests(testCallerArtifact)
+	assert.Equal(self.T(), 1, len(rows))
+
+	name, _ := rows[0].GetString("Name")
+	assert.Equal(self.T(), "#0", name)
+
+	passed, _ := rows[0].Get("Passed")
+	assert.Equal(self.T(), true, passed)
+}
+
+func (self *ArtifactTestsTestSuite) TestUpdateExpectations() {
+	rows := self.runTests(testArtifact)
+
+	expected := make(map[string]string)
+	for _, row := range rows {
+		name, _ := row.GetString("Name")
+		actual, _ := row.GetString("Actual")
+		expected[name] = actual
+	}
+
+	updated, err := UpdateExpectations(testArtifact, expected)
+	assert.NoError(self.T(), err)
+
+	// All the tests should now pass.
+	rows = self.runTests(updated)
+	assert.Equal(self.T(), 3, len(rows))
+	for _, row := range rows {
+		passed, _ := row.Get("Passed")
+		assert.Equal(self.T(), true, passed)
+	}
+
+	// Updating again is stable.
+	updated_again, err := UpdateExpectations(updated, expected)
+	assert.NoError(self.T(), err)
+	assert.Equal(self.T(), updated, updated_again)
+}
+
+func TestArtifactTests(t *testing.T) {
+	suite.Run(t, &ArtifactTestsTestSuite{})
+}
@@ -0,0 +1,142 @@
+package artifact_tests
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+	"www.velocidex.com/golang/velociraptor/utils/yaml"
+)
+
+// Produce a line based diff between the expected and actual rows.
+func Diff(expected, actual string) string {
+	dmp := diffmatchpatch.New()
+	a, b, lines := dmp.DiffLinesToChars(expected, actual)
+	diffs := dmp.DiffCharsToLines(dmp.DiffMain(a, b, false), lines)
+
+	result := []string{}
+	for _, d := range diffs {
+		prefix := " "
+		switch d.Type {
+		case diffmatchpatch.DiffInsert:
+			prefix = "+"
+		case diffmatchpatch.DiffDelete:
+			prefix = "-"
+		}
+
+		for _, line := range strings.Split(
+			strings.TrimSuffix(d.Text, "\n"), "\n") {
+			result = append(result, prefix+line)
+		}
+	}
+
+	return strings.Join(result, "\n")
+}
+
+type expectation struct {
+	// Zero based line numbers in the artifact yaml.
+	start, end int
+	indent     int
+	lines      []string
+}
+
+// Rewrite the expected section of the named tests in the artifact
+// yaml, keeping the rest of the document intact. The expected map is
+// keyed by test name (as returned from TestName()).
+func UpdateExpectations(
+	artifact_yaml string, expected map[string]string) (string, error) {
+	var root yaml.Node
+	err := yaml.Unmarshal([]byte(artifact_yaml), &root)
+	if err != nil {
+		return "", err
+	}
+
+	if len(root.Content) == 0 {
+		return artifact_yaml, nil
+	}
+
+	var nodes []yaml.NodeContext
+	yaml.GetYamlNodes(root.Content[0], root.Content[0],
+		[]string{"tests", "[]"}, &nodes)
+
+	var updates []expectation
+	for idx, n := range nodes {
+		if n.Tag != "!!map" {
+			continue
+		}
+
+		name := ""
+		var name_node, expected_key, expected_value *yaml.Node
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			switch n.Content[i].Value {
+			case "name":
+				name_node = n.Content[i]
+				name = n.Content[i+1].Value
+			case "expected":
+				expected_key = n.Content[i]
+				expected_value = n.Content[i+1]
+			}
+		}
+
+		if name == "" {
+			name = fmt.Sprintf("#%v", idx)
+		}
+
+		rows, pres := expected[name]
+		if !pres {
+			continue
+		}
+
+		// Replace the existing expected section.
+		if expected_key != nil {
+			end := expected_key.Line
+			if expected_value.Style == yaml.LiteralStyle {
+				end += len(strings.Split(
+					strings.TrimSuffix(expected_value.Value, "\n"), "\n"))
+			}
+
+			updates = append(updates, expectation{
+				start:  expected_key.Line - 1,
+				end:    end,
+				indent: expected_key.Column - 1,
+				lines:  strings.Split(rows, "\n"),
+			})
+			continue
+		}
+
+		// Otherwise add it after the name.
+		if name_node == nil {
+			return "", fmt.Errorf(
+				"Test %v needs a name to record expected rows", name)
+		}
+
+		updates = append(updates, expectation{
+			start:  name_node.Line,
+			end:    name_node.Line,
+			indent: name_node.Column - 1,
+			lines:  strings.Split(rows, "\n"),
+		})
+	}
+
+	sort.Slice(updates, func(i, j int) bool {
+		return updates[i].start < updates[j].start
+	})
+
+	lines := strings.Split(artifact_yaml, "\n")
+	result := []string{}
+	current := 0
+	for _, u := range updates {
+		result = append(result, lines[current:u.start]...)
+
+		indent := strings.Repeat(" ", u.indent)
+		result = append(result, indent+"expected: |")
+		for _, l := range u.lines {
+			result = append(result, indent+"  "+l)
+		}
+		current = u.end
+	}
+	result = append(result, lines[current:]...)
+
+	return strings.Join(result, "\n"), nil
+}
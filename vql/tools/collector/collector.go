@@ -32,10 +32,12 @@ var (
 
 type CollectPluginArgs struct {
 	Artifacts           []string            `vfilter:"required,field=artifacts,doc=A list of artifacts to collect."`
-	Output              string              `vfilter:"optional,field=output,doc=A path to write the output file on."`
+	Output              string              `vfilter:"optional,field=output,doc=A path to write the output file on. May also be a remote URL (e.g. s3://bucket/key.zip or sftp://user@host:22/path/key.zip) to stream the container without a local copy."`
+	OutputOptions       *ordereddict.Dict   `vfilter:"optional,field=output_options,doc=Options for remote outputs (e.g. credentials, endpoint, part_size)."`
 	Report              string              `vfilter:"optional,field=report,doc=A path to write the report on (deprecated and ignored)."`
 	Args                vfilter.Any         `vfilter:"optional,field=args,doc=Optional parameters."`
 	Password            string              `vfilter:"optional,field=password,doc=An optional password to encrypt the collection zip."`
+	PublicKeys          []string            `vfilter:"optional,field=public_keys,doc=Encrypt the collection zip for these recipients (PEM encoded X509 certificates, RSA or X25519 public keys). Any one of the matching private keys can decrypt it."`
 	Format              string              `vfilter:"optional,field=format,doc=Output format (csv, jsonl, csv_only)."`
 	ArtifactDefinitions vfilter.Any         `vfilter:"optional,field=artifact_definitions,doc=Optional additional custom artifacts."`
 	Template            string              `vfilter:"optional,field=template,doc=(Deprecated Ignored)."`
@@ -157,23 +159,74 @@ func (self CollectPlugin) configureCollection(
 
 	// If required create an output container
 	if arg.Output != "" {
-
-		// Make sure we are allowed to write there.
-		err = file.CheckPath(arg.Output)
-		if err != nil {
-			return nil, err
-		}
-
 		// Set any metadata for the container.
 		if !utils.IsNil(arg.Metadata) {
 			manager.SetMetadata(arg.Metadata)
 		}
 
-		// Build the container to receive the output from the
-		// queries. The container may be password protected.
-		err = manager.MakeContainer(arg.Output, arg.Password, arg.Level)
-		if err != nil {
-			return nil, err
+		// Encrypt the container with a random session password
+		// wrapped for each of the recipients.
+		if len(arg.PublicKeys) > 0 {
+			if arg.Password != "" {
+				return nil, errors.New(
+					"Only one of password or public_keys may be specified")
+			}
+
+			arg.Password, err = manager.SetRecipients(arg.PublicKeys)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		sink, destination, pres := getOutputSink(arg.Output)
+		if pres {
+			// Streaming to a remote destination requires network
+			// access.
+			err = vql_subsystem.CheckAccess(scope, acls.NETWORK)
+			if err != nil {
+				return nil, err
+			}
+
+			options := arg.OutputOptions
+			if options == nil {
+				options = ordereddict.NewDict()
+			}
+
+			fd, err := sink(ctx, scope, destination, options)
+			if err != nil {
+				return nil, err
+			}
+
+			err = manager.MakeContainerFromWriter(
+				redactOutputURL(destination), fd, arg.Password, arg.Level)
+			if err != nil {
+				fd.Close()
+				return nil, err
+			}
+
+		} else {
+			if strings.Contains(arg.Output, "://") {
+				output := "output"
+				if destination != nil {
+					output = redactOutputURL(destination)
+				}
+				return nil, fmt.Errorf(
+					"Unsupported output URL %v: supported schemes are %v",
+					output, RegisteredOutputSinks())
+			}
+
+			// Make sure we are allowed to write there.
+			err = file.CheckPath(arg.Output)
+			if err != nil {
+				return nil, err
+			}
+
+			// Build the container to receive the output from the
+			// queries. The container may be password protected.
+			err = manager.MakeContainer(arg.Output, arg.Password, arg.Level)
+			if err != nil {
+				return nil, err
+			}
 		}
 	}
 
@@ -2,10 +2,14 @@ package collector
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"log"
 	"reflect"
 	"runtime"
+	"strings"
 	"sync"
 	"time"
 
@@ -19,6 +23,7 @@ import (
 	"www.velocidex.com/golang/velociraptor/config"
 	config_proto "www.velocidex.com/golang/velociraptor/config/proto"
 	crypto_proto "www.velocidex.com/golang/velociraptor/crypto/proto"
+	"www.velocidex.com/golang/velociraptor/crypto/storage"
 	"www.velocidex.com/golang/velociraptor/executor/throttler"
 	"www.velocidex.com/golang/velociraptor/file_store/path_specs"
 	"www.velocidex.com/golang/velociraptor/flows"
@@ -207,6 +212,37 @@ func (self *collectionManager) SetMetadata(metadata vfilter.StoredQuery) {
 	self.metadata = types.Materialize(self.ctx, self.scope, metadata)
 }
 
+// Generate a random session password and record it in the metadata,
+// wrapped for each of the recipient public keys.
+func (self *collectionManager) SetRecipients(public_keys []string) (string, error) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	session_key := make([]byte, 32)
+	_, err := rand.Read(session_key)
+	if err != nil {
+		return "", err
+	}
+	password := hex.EncodeToString(session_key)
+
+	recipients, err := storage.WrapSessionKey(
+		[]byte(password), []byte(strings.Join(public_keys, "\n")))
+	if err != nil {
+		return "", err
+	}
+
+	for _, r := range recipients {
+		self.scope.Log("collect: Encrypting container for recipient %v %v",
+			r.KeyId, r.Name)
+	}
+
+	self.metadata = append(self.metadata, ordereddict.NewDict().
+		Set("Scheme", storage.RECIPIENTS_SCHEME).
+		Set("Recipients", recipients))
+
+	return password, nil
+}
+
 func (self *collectionManager) SetFormat(
 	format reporting.ContainerFormat) error {
 	self.mu.Lock()
@@ -524,6 +560,36 @@ func (self *collectionManager) MakeContainer(filename, password string, level in
 
 	self.scope.Log("Will create container at %s", filename)
 
+	return self.initContainer()
+}
+
+// Build a container that streams into the writer as the collection
+// progresses. This is used for remote outputs where there is no
+// local file.
+func (self *collectionManager) MakeContainerFromWriter(
+	name string, fd io.WriteCloser, password string, level int64) (err error) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	if password != "" {
+		self.scope.Log("Will password protect container")
+	}
+
+	self.scope.Log("Setting compression level to %v", level)
+
+	self.Output = name
+	self.container, err = reporting.NewContainerFromWriter(
+		name, self.config_obj, fd, password, level, self.metadata)
+	if err != nil {
+		return err
+	}
+
+	self.scope.Log("Will stream container to %s", name)
+
+	return self.initContainer()
+}
+
+func (self *collectionManager) initContainer() (err error) {
 	self.collection_context.SessionId = utils.NewFlowId("")
 	self.log_file, err = reporting.NewResultSetWriter(
 		self.container, "log.json")
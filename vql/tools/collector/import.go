@@ -48,11 +48,12 @@ const (
 )
 
 type ImportCollectionFunctionArgs struct {
-	ClientId   string `vfilter:"optional,field=client_id,doc=The client id to import to. Use 'auto' to generate a new client id or use the host info from the collection."`
-	Hostname   string `vfilter:"optional,field=hostname,doc=When creating a new client, set this as the hostname."`
-	Filename   string `vfilter:"required,field=filename,doc=Path on server to the collector zip."`
-	Accessor   string `vfilter:"optional,field=accessor,doc=The accessor to use."`
-	ImportType string `vfilter:"optional,field=import_type,doc=Whether the import is an offline_collector or hunt."`
+	ClientId    string   `vfilter:"optional,field=client_id,doc=The client id to import to. Use 'auto' to generate a new client id or use the host info from the collection."`
+	Hostname    string   `vfilter:"optional,field=hostname,doc=When creating a new client, set this as the hostname."`
+	Filename    string   `vfilter:"required,field=filename,doc=Path on server to the collector zip."`
+	Accessor    string   `vfilter:"optional,field=accessor,doc=The accessor to use."`
+	ImportType  string   `vfilter:"optional,field=import_type,doc=Whether the import is an offline_collector or hunt."`
+	PrivateKeys []string `vfilter:"optional,field=private_keys,doc=PEM encoded private keys used to open collections encrypted for multiple recipients."`
 }
 
 type ImportCollectionFunction struct{}
@@ -85,6 +86,13 @@ func (self ImportCollectionFunction) Call(ctx context.Context,
 	// Do not expand sparse files when we import them - they can be
 	// deflated by the user later.
 
+	// Make the private keys available to the collector accessor.
+	if len(arg.PrivateKeys) > 0 {
+		scope = scope.Copy()
+		scope.AppendVars(ordereddict.NewDict().
+			Set(constants.COLLECTION_PRIVATE_KEYS, arg.PrivateKeys))
+	}
+
 	// Open the collection using the accessor
 	accessor, err := accessors.GetAccessor("collector_sparse", scope)
 	if err != nil {
@@ -0,0 +1,213 @@
+//go:build sumo
+// +build sumo
+
+package tools
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/Velocidex/ordereddict"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"www.velocidex.com/golang/velociraptor/artifacts"
+	"www.velocidex.com/golang/velociraptor/vql/networking"
+	"www.velocidex.com/golang/velociraptor/vql/tools/collector"
+	"www.velocidex.com/golang/vfilter"
+	"www.velocidex.com/golang/vfilter/arg_parser"
+)
+
+const (
+	// S3 requires all parts except the last to be at least 5mb.
+	S3_MIN_PART_SIZE     = 5 * 1024 * 1024
+	S3_DEFAULT_PART_SIZE = 16 * 1024 * 1024
+	S3_PART_RETRIES      = 5
+)
+
+type S3OutputArgs struct {
+	Region               string `vfilter:"optional,field=region,doc=The region the bucket is in"`
+	CredentialsKey       string `vfilter:"optional,field=credentials_key,doc=The AWS key credentials to use"`
+	CredentialsSecret    string `vfilter:"optional,field=credentials_secret,doc=The AWS secret credentials to use"`
+	CredentialsToken     string `vfilter:"optional,field=credentials_token,doc=The AWS session token to use (only needed for temporary credentials)"`
+	Endpoint             string `vfilter:"optional,field=endpoint,doc=The Endpoint to use"`
+	ServerSideEncryption string `vfilter:"optional,field=serverside_encryption,doc=The server side encryption method to use"`
+	KmsEncryptionKey     string `vfilter:"optional,field=kms_encryption_key,doc=The server side KMS key to use"`
+	SkipVerify           bool   `vfilter:"optional,field=skip_verify,doc=Skip TLS Verification"`
+	UsePathStyle         bool   `vfilter:"optional,field=path_style,doc=Use path style URLs if set"`
+	Secret               string `vfilter:"optional,field=secret,doc=Alternatively use a secret from the secrets service. Secret must be of type 'AWS S3 Creds'"`
+	PartSize             int64  `vfilter:"optional,field=part_size,doc=Size of each multipart upload part (default 16mb, minimum 5mb)"`
+}
+
+// Streams the container into the AWS multipart uploader through a
+// pipe. The uploader buffers one part at a time and retries failed
+// parts without restarting the upload.
+type s3PipeWriter struct {
+	*io.PipeWriter
+
+	once sync.Once
+	done chan error
+	err  error
+}
+
+func (self *s3PipeWriter) Close() error {
+	self.once.Do(func() {
+		self.PipeWriter.Close()
+		self.err = <-self.done
+	})
+	return self.err
+}
+
+func openS3Output(
+	ctx context.Context, scope vfilter.Scope,
+	destination *url.URL, options *ordereddict.Dict) (io.WriteCloser, error) {
+
+	mergeScope(ctx, scope, options)
+
+	arg := &S3OutputArgs{}
+	err := arg_parser.ExtractArgsWithContext(ctx, scope, options, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	bucket := destination.Host
+	key := strings.TrimPrefix(destination.Path, "/")
+	if bucket == "" || key == "" {
+		return nil, errors.New("S3 output must be of the form s3://bucket/key")
+	}
+
+	upload_arg := &S3UploadArgs{
+		Name:                 key,
+		Bucket:               bucket,
+		Region:               arg.Region,
+		CredentialsKey:       arg.CredentialsKey,
+		CredentialsSecret:    arg.CredentialsSecret,
+		CredentialsToken:     arg.CredentialsToken,
+		Endpoint:             arg.Endpoint,
+		ServerSideEncryption: arg.ServerSideEncryption,
+		KmsEncryptionKey:     arg.KmsEncryptionKey,
+		SkipVerify:           arg.SkipVerify,
+		UsePathStyle:         arg.UsePathStyle,
+		Secret:               arg.Secret,
+	}
+
+	err = S3UploadFunction{}.maybeForceSecrets(ctx, scope, upload_arg)
+	if err != nil {
+		return nil, err
+	}
+
+	if upload_arg.Secret != "" {
+		err := mergeSecret(ctx, scope, upload_arg)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	conf := []func(*config.LoadOptions) error{
+		config.WithRegion(upload_arg.Region),
+		config.WithRetryMaxAttempts(S3_PART_RETRIES),
+		config.WithRetryMode(aws.RetryModeStandard),
+	}
+
+	if upload_arg.CredentialsKey != "" && upload_arg.CredentialsSecret != "" {
+		conf = append(conf, config.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(
+				upload_arg.CredentialsKey, upload_arg.CredentialsSecret,
+				upload_arg.CredentialsToken),
+		))
+	}
+
+	s3_opts := []func(*s3.Options){}
+	if upload_arg.Endpoint != "" {
+		s3_opts = append(s3_opts, func(o *s3.Options) {
+			o.BaseEndpoint = aws.String(upload_arg.Endpoint)
+		})
+	}
+
+	if upload_arg.UsePathStyle {
+		s3_opts = append(s3_opts, func(o *s3.Options) {
+			o.UsePathStyle = true
+		})
+	}
+
+	clientConfig, ok := artifacts.GetConfig(scope)
+	if ok {
+		if upload_arg.SkipVerify {
+			http_client, err := networking.GetSkipVerifyHTTPClient(
+				ctx, clientConfig, scope, "", nil)
+			if err != nil {
+				return nil, err
+			}
+			conf = append(conf, config.WithHTTPClient(http_client))
+
+		} else {
+			http_client, err := networking.GetDefaultHTTPClient(
+				ctx, clientConfig, scope, "", nil)
+			if err != nil {
+				return nil, err
+			}
+			conf = append(conf, config.WithHTTPClient(http_client))
+		}
+	}
+
+	sess, err := config.LoadDefaultConfig(ctx, conf...)
+	if err != nil {
+		return nil, err
+	}
+
+	part_size := arg.PartSize
+	if part_size == 0 {
+		part_size = S3_DEFAULT_PART_SIZE
+	} else if part_size < S3_MIN_PART_SIZE {
+		part_size = S3_MIN_PART_SIZE
+	}
+
+	client := s3.NewFromConfig(sess, s3_opts...)
+	uploader := manager.NewUploader(client, func(u *manager.Uploader) {
+		u.PartSize = part_size
+		u.Concurrency = 1
+	})
+
+	s3_params := &s3.PutObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}
+	if upload_arg.ServerSideEncryption != "" {
+		s3_params.ServerSideEncryption = types.ServerSideEncryption(
+			upload_arg.ServerSideEncryption)
+	}
+
+	if upload_arg.KmsEncryptionKey != "" {
+		s3_params.SSEKMSKeyId = aws.String(upload_arg.KmsEncryptionKey)
+	}
+
+	reader, writer := io.Pipe()
+	s3_params.Body = reader
+
+	result := &s3PipeWriter{
+		PipeWriter: writer,
+		done:       make(chan error, 1),
+	}
+
+	go func() {
+		_, err := uploader.Upload(ctx, s3_params)
+		// Unblock the container if the upload failed.
+		reader.CloseWithError(err)
+		result.done <- err
+	}()
+
+	scope.Log("collect: Streaming container to s3://%v/%v", bucket, key)
+
+	return result, nil
+}
+
+func init() {
+	collector.RegisterOutputSink("s3", openS3Output)
+}
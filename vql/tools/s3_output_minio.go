@@ -0,0 +1,252 @@
+//go:build !sumo
+// +build !sumo
+
+package tools
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Velocidex/ordereddict"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/encrypt"
+	"www.velocidex.com/golang/velociraptor/vql/tools/collector"
+	"www.velocidex.com/golang/vfilter"
+	"www.velocidex.com/golang/vfilter/arg_parser"
+)
+
+const (
+	// S3 requires all parts except the last to be at least 5mb.
+	S3_MIN_PART_SIZE     = 5 * 1024 * 1024
+	S3_DEFAULT_PART_SIZE = 16 * 1024 * 1024
+	S3_PART_RETRIES      = 5
+)
+
+type S3OutputArgs struct {
+	Region               string `vfilter:"optional,field=region,doc=The region the bucket is in"`
+	CredentialsKey       string `vfilter:"optional,field=credentials_key,doc=The AWS key credentials to use"`
+	CredentialsSecret    string `vfilter:"optional,field=credentials_secret,doc=The AWS secret credentials to use"`
+	CredentialsToken     string `vfilter:"optional,field=credentials_token,doc=The AWS session token to use (only needed for temporary credentials)"`
+	Endpoint             string `vfilter:"optional,field=endpoint,doc=The Endpoint to use"`
+	ServerSideEncryption string `vfilter:"optional,field=serverside_encryption,doc=The server side encryption method to use"`
+	KmsEncryptionKey     string `vfilter:"optional,field=kms_encryption_key,doc=The server side KMS key to use"`
+	SkipVerify           bool   `vfilter:"optional,field=skip_verify,doc=Skip TLS Verification"`
+	UsePathStyle         bool   `vfilter:"optional,field=path_style,doc=Use path style URLs if set"`
+	Secret               string `vfilter:"optional,field=secret,doc=Alternatively use a secret from the secrets service. Secret must be of type 'AWS S3 Creds'"`
+	PartSize             int64  `vfilter:"optional,field=part_size,doc=Size of each multipart upload part (default 16mb, minimum 5mb)"`
+}
+
+// Streams the collection container to S3 using a multipart
+// upload. Each part is buffered in memory until it is acknowledged
+// so a failed part can be retried without restarting the upload.
+type s3MultipartWriter struct {
+	mu sync.Mutex
+
+	ctx   context.Context
+	scope vfilter.Scope
+
+	client    minio.Core
+	bucket    string
+	key       string
+	upload_id string
+	sse       encrypt.ServerSide
+
+	part_size int
+	buffer    bytes.Buffer
+	parts     []minio.CompletePart
+	total     int64
+	err       error
+	closed    bool
+}
+
+func (self *s3MultipartWriter) Write(buff []byte) (int, error) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	if self.err != nil {
+		return 0, self.err
+	}
+
+	n, _ := self.buffer.Write(buff)
+	for self.buffer.Len() >= self.part_size {
+		err := self.uploadPart(self.buffer.Next(self.part_size))
+		if err != nil {
+			self.err = err
+			return 0, err
+		}
+	}
+
+	return n, nil
+}
+
+func (self *s3MultipartWriter) uploadPart(data []byte) (err error) {
+	part_number := len(self.parts) + 1
+	delay := time.Second
+
+	for attempt := 0; attempt < S3_PART_RETRIES; attempt++ {
+		var part minio.ObjectPart
+		part, err = self.client.PutObjectPart(self.ctx, self.bucket,
+			self.key, self.upload_id, part_number,
+			bytes.NewReader(data), int64(len(data)),
+			minio.PutObjectPartOptions{SSE: self.sse})
+		if err == nil {
+			self.parts = append(self.parts, minio.CompletePart{
+				PartNumber: part_number,
+				ETag:       part.ETag,
+			})
+			self.total += int64(len(data))
+			return nil
+		}
+
+		self.scope.Log("collect: Failed uploading part %v of %v (attempt %v): %v",
+			part_number, self.key, attempt+1, err)
+
+		select {
+		case <-self.ctx.Done():
+			return self.ctx.Err()
+		case <-time.After(delay):
+			delay *= 2
+		}
+	}
+
+	return fmt.Errorf("Unable to upload part %v of %v: %w",
+		part_number, self.key, err)
+}
+
+func (self *s3MultipartWriter) Close() error {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	if self.closed {
+		return self.err
+	}
+	self.closed = true
+
+	// Flush the last part - it may be smaller than the part size.
+	if self.err == nil && (self.buffer.Len() > 0 || len(self.parts) == 0) {
+		self.err = self.uploadPart(self.buffer.Bytes())
+		self.buffer.Reset()
+	}
+
+	if self.err != nil {
+		// Do not leave partial uploads behind - they are billed
+		// but invisible in the bucket.
+		abort_err := self.client.AbortMultipartUpload(
+			context.Background(), self.bucket, self.key, self.upload_id)
+		if abort_err != nil {
+			self.scope.Log("collect: Unable to abort upload %v of %v: %v",
+				self.upload_id, self.key, abort_err)
+		}
+		return self.err
+	}
+
+	_, err := self.client.CompleteMultipartUpload(self.ctx, self.bucket,
+		self.key, self.upload_id, self.parts,
+		minio.PutObjectOptions{ServerSideEncryption: self.sse})
+	if err != nil {
+		self.err = err
+		return err
+	}
+
+	self.scope.Log("collect: Uploaded %v bytes to s3://%v/%v in %v parts",
+		self.total, self.bucket, self.key, len(self.parts))
+	return nil
+}
+
+func openS3Output(
+	ctx context.Context, scope vfilter.Scope,
+	destination *url.URL, options *ordereddict.Dict) (io.WriteCloser, error) {
+
+	mergeScope(ctx, scope, options)
+
+	arg := &S3OutputArgs{}
+	err := arg_parser.ExtractArgsWithContext(ctx, scope, options, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	bucket := destination.Host
+	key := strings.TrimPrefix(destination.Path, "/")
+	if bucket == "" || key == "" {
+		return nil, errors.New("S3 output must be of the form s3://bucket/key")
+	}
+
+	upload_arg := &S3UploadArgs{
+		Name:                 key,
+		Bucket:               bucket,
+		Region:               arg.Region,
+		CredentialsKey:       arg.CredentialsKey,
+		CredentialsSecret:    arg.CredentialsSecret,
+		CredentialsToken:     arg.CredentialsToken,
+		Endpoint:             arg.Endpoint,
+		ServerSideEncryption: arg.ServerSideEncryption,
+		SkipVerify:           arg.SkipVerify,
+		UsePathStyle:         arg.UsePathStyle,
+		Secret:               arg.Secret,
+	}
+
+	err = S3UploadFunction{}.maybeForceSecrets(ctx, scope, upload_arg)
+	if err != nil {
+		return nil, err
+	}
+
+	if upload_arg.Secret != "" {
+		err := mergeSecret(ctx, scope, upload_arg)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if arg.KmsEncryptionKey != "" {
+		return nil, errors.New(
+			"KmsEncryptionKey not supported, rebuild with sumo option")
+	}
+
+	client, err := GetS3Client(ctx, scope, upload_arg)
+	if err != nil {
+		return nil, err
+	}
+
+	part_size := arg.PartSize
+	if part_size == 0 {
+		part_size = S3_DEFAULT_PART_SIZE
+	} else if part_size < S3_MIN_PART_SIZE {
+		part_size = S3_MIN_PART_SIZE
+	}
+
+	result := &s3MultipartWriter{
+		ctx:       ctx,
+		scope:     scope,
+		client:    minio.Core{Client: client},
+		bucket:    bucket,
+		key:       key,
+		part_size: int(part_size),
+	}
+
+	if upload_arg.ServerSideEncryption != "" {
+		result.sse = encrypt.DefaultPBKDF(
+			[]byte(upload_arg.ServerSideEncryption), []byte(bucket+key))
+	}
+
+	result.upload_id, err = result.client.NewMultipartUpload(ctx,
+		bucket, key, minio.PutObjectOptions{ServerSideEncryption: result.sse})
+	if err != nil {
+		return nil, err
+	}
+
+	scope.Log("collect: Streaming container to s3://%v/%v (upload id %v)",
+		bucket, key, result.upload_id)
+
+	return result, nil
+}
+
+func init() {
+	collector.RegisterOutputSink("s3", openS3Output)
+}
@@ -0,0 +1,235 @@
+//go:build extras
+// +build extras
+
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Velocidex/ordereddict"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"www.velocidex.com/golang/velociraptor/vql/tools/collector"
+	"www.velocidex.com/golang/vfilter"
+	"www.velocidex.com/golang/vfilter/arg_parser"
+)
+
+const (
+	SFTP_DEFAULT_CHUNK_SIZE = 4 * 1024 * 1024
+	SFTP_CHUNK_RETRIES      = 5
+)
+
+type SFTPOutputArgs struct {
+	User       string `vfilter:"optional,field=user,doc=The username to connect to the endpoint with (default is the user in the URL)"`
+	PrivateKey string `vfilter:"required,field=privatekey,doc=The private key to use"`
+	HostKey    string `vfilter:"optional,field=hostkey,doc=Host key to verify. Blank to disable"`
+	ChunkSize  int64  `vfilter:"optional,field=chunk_size,doc=Size of each chunk written to the server (default 4mb)"`
+}
+
+// Streams the container to an SFTP server in chunks. Each chunk is
+// kept until the server accepted it so when the connection drops we
+// reconnect and resume writing at the last acknowledged offset.
+type sftpChunkedWriter struct {
+	mu sync.Mutex
+
+	ctx   context.Context
+	scope vfilter.Scope
+
+	user, private_key, endpoint, host_key string
+	path                                  string
+
+	conn   *ssh.Client
+	client *sftp.Client
+	fd     *sftp.File
+
+	chunk_size int
+	chunk      []byte
+	offset     int64
+	err        error
+	closed     bool
+}
+
+func (self *sftpChunkedWriter) connect(flags int) error {
+	self.disconnect()
+
+	conn, client, err := dialSFTP(
+		self.user, self.private_key, self.endpoint, self.host_key)
+	if err != nil {
+		return err
+	}
+
+	fd, err := client.OpenFile(self.path, flags)
+	if err != nil {
+		client.Close()
+		conn.Close()
+		return err
+	}
+
+	self.conn = conn
+	self.client = client
+	self.fd = fd
+	return nil
+}
+
+func (self *sftpChunkedWriter) disconnect() {
+	if self.fd != nil {
+		self.fd.Close()
+		self.fd = nil
+	}
+	if self.client != nil {
+		self.client.Close()
+		self.client = nil
+	}
+	if self.conn != nil {
+		self.conn.Close()
+		self.conn = nil
+	}
+}
+
+func (self *sftpChunkedWriter) Write(buff []byte) (int, error) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	if self.err != nil {
+		return 0, self.err
+	}
+
+	self.chunk = append(self.chunk, buff...)
+	if len(self.chunk) >= self.chunk_size {
+		err := self.flushChunk()
+		if err != nil {
+			self.err = err
+			return 0, err
+		}
+	}
+
+	return len(buff), nil
+}
+
+func (self *sftpChunkedWriter) flushChunk() (err error) {
+	if len(self.chunk) == 0 {
+		return nil
+	}
+
+	delay := time.Second
+	for attempt := 0; attempt < SFTP_CHUNK_RETRIES; attempt++ {
+		if self.fd == nil {
+			// Reopen without truncating so we keep what was
+			// already acknowledged.
+			err = self.connect(os.O_WRONLY | os.O_CREATE)
+		}
+
+		if err == nil {
+			_, err = self.fd.WriteAt(self.chunk, self.offset)
+			if err == nil {
+				self.offset += int64(len(self.chunk))
+				self.chunk = self.chunk[:0]
+				return nil
+			}
+			self.disconnect()
+		}
+
+		self.scope.Log("collect: Failed writing %v at offset %v (attempt %v): %v",
+			self.path, self.offset, attempt+1, err)
+
+		select {
+		case <-self.ctx.Done():
+			return self.ctx.Err()
+		case <-time.After(delay):
+			delay *= 2
+		}
+	}
+
+	return fmt.Errorf("Unable to write %v at offset %v: %w",
+		self.path, self.offset, err)
+}
+
+func (self *sftpChunkedWriter) Close() error {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	if self.closed {
+		return self.err
+	}
+	self.closed = true
+
+	if self.err == nil {
+		self.err = self.flushChunk()
+	}
+
+	if self.err == nil {
+		// Drop any stale data beyond what we wrote in case we
+		// resumed over an older file.
+		self.err = self.fd.Truncate(self.offset)
+	}
+	self.disconnect()
+
+	if self.err == nil {
+		self.scope.Log("collect: Uploaded %v bytes to sftp://%v%v",
+			self.offset, self.endpoint, self.path)
+	}
+
+	return self.err
+}
+
+func openSFTPOutput(
+	ctx context.Context, scope vfilter.Scope,
+	destination *url.URL, options *ordereddict.Dict) (io.WriteCloser, error) {
+
+	arg := &SFTPOutputArgs{}
+	err := arg_parser.ExtractArgsWithContext(ctx, scope, options, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	if arg.User == "" && destination.User != nil {
+		arg.User = destination.User.Username()
+	}
+
+	if arg.User == "" || destination.Host == "" || destination.Path == "" {
+		return nil, errors.New(
+			"SFTP output must be of the form sftp://user@host:port/path")
+	}
+
+	endpoint := destination.Host
+	if destination.Port() == "" {
+		endpoint += ":22"
+	}
+
+	chunk_size := arg.ChunkSize
+	if chunk_size <= 0 {
+		chunk_size = SFTP_DEFAULT_CHUNK_SIZE
+	}
+
+	result := &sftpChunkedWriter{
+		ctx:         ctx,
+		scope:       scope,
+		user:        arg.User,
+		private_key: arg.PrivateKey,
+		endpoint:    endpoint,
+		host_key:    arg.HostKey,
+		path:        destination.Path,
+		chunk_size:  int(chunk_size),
+	}
+
+	err = result.connect(os.O_WRONLY | os.O_CREATE | os.O_TRUNC)
+	if err != nil {
+		return nil, err
+	}
+
+	scope.Log("collect: Streaming container to sftp://%v%v",
+		endpoint, destination.Path)
+
+	return result, nil
+}
+
+func init() {
+	collector.RegisterOutputSink("sftp", openSFTPOutput)
+}
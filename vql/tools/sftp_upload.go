@@ -117,41 +117,51 @@ func hostkeycallback(trustedkey string) ssh.HostKeyCallback {
 	}
 }
 
+// Open a new, uncached connection to the SFTP server.
+func dialSFTP(user string, privateKey string,
+	endpoint string, hostKey string) (*ssh.Client, *sftp.Client, error) {
+	signer, err := ssh.ParsePrivateKey([]byte(privateKey))
+	if err != nil {
+		return nil, nil, err
+	}
+	var clientConfig *ssh.ClientConfig
+	if hostKey == "" {
+		clientConfig = &ssh.ClientConfig{
+			User: user,
+			Auth: []ssh.AuthMethod{
+				ssh.PublicKeys(signer),
+			},
+			HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		}
+	} else {
+		clientConfig = &ssh.ClientConfig{
+			User: user,
+			Auth: []ssh.AuthMethod{
+				ssh.PublicKeys(signer),
+			},
+			HostKeyCallback: hostkeycallback(hostKey),
+		}
+	}
+
+	conn, err := ssh.Dial("tcp", endpoint, clientConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	client, err := sftp.NewClient(conn)
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+
+	return conn, client, nil
+}
+
 func getSFTPClient(scope vfilter.Scope, user string, privateKey string,
 	endpoint string, hostKey string) (*sftp.Client, error) {
 	cacheKey := fmt.Sprintf("%s %s", user, endpoint)
 	client := vql_subsystem.CacheGet(scope, cacheKey)
 	if client == nil {
-		signer, err := ssh.ParsePrivateKey([]byte(privateKey))
-		if err != nil {
-			vql_subsystem.CacheSet(scope, cacheKey, err)
-			return nil, err
-		}
-		var clientConfig *ssh.ClientConfig
-		if hostKey == "" {
-			clientConfig = &ssh.ClientConfig{
-				User: user,
-				Auth: []ssh.AuthMethod{
-					ssh.PublicKeys(signer),
-				},
-				HostKeyCallback: ssh.InsecureIgnoreHostKey(),
-			}
-		} else {
-			clientConfig = &ssh.ClientConfig{
-				User: user,
-				Auth: []ssh.AuthMethod{
-					ssh.PublicKeys(signer),
-				},
-				HostKeyCallback: hostkeycallback(hostKey),
-			}
-		}
-
-		conn, err := ssh.Dial("tcp", endpoint, clientConfig)
-		if err != nil {
-			vql_subsystem.CacheSet(scope, cacheKey, err)
-			return nil, err
-		}
-		client, err := sftp.NewClient(conn)
+		conn, client, err := dialSFTP(user, privateKey, endpoint, hostKey)
 		if err != nil {
 			vql_subsystem.CacheSet(scope, cacheKey, err)
 			return nil, err
@@ -43,6 +43,7 @@ import (
 	_ "www.velocidex.com/golang/velociraptor/vql/protocols"
 	_ "www.velocidex.com/golang/velociraptor/vql/sigma"
 	_ "www.velocidex.com/golang/velociraptor/vql/tools"
+	_ "www.velocidex.com/golang/velociraptor/vql/tools/artifact_tests"
 	_ "www.velocidex.com/golang/velociraptor/vql/tools/collector"
 	_ "www.velocidex.com/golang/velociraptor/vql/tools/dns"
 	_ "www.velocidex.com/golang/velociraptor/vql/tools/index"
@@ -39,4 +39,5 @@ import (
 	_ "www.velocidex.com/golang/velociraptor/vql/server/secrets"
 	_ "www.velocidex.com/golang/velociraptor/vql/server/timelines"
 	_ "www.velocidex.com/golang/velociraptor/vql/server/users"
+	_ "www.velocidex.com/golang/velociraptor/vql/server/webhooks"
 )